@@ -21,6 +21,8 @@ import (
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/attach"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/embedded"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/spf13/cobra"
 )
 
@@ -34,6 +36,8 @@ func Attach() *cobra.Command {
 		attachSignature(),
 		attachSBOM(),
 		attachAttestation(),
+		attachArtifact(),
+		attachBinarySignature(),
 	)
 
 	return cmd
@@ -62,11 +66,20 @@ func attachSignature() *cobra.Command {
 		cosign attach signature --payload <payload.json> --signature <base64 signature file>  --rekor-response <proper rekor-response format file> $IMAGE
 
 		# Attach signature attaches payload, signature and rekor-bundle directly to a supplied image
-		cosign attach signature --payload <payload.json> --signature <base64 signature file>  --rekor-response <rekor-bundle file> $IMAGE`,
+		cosign attach signature --payload <payload.json> --signature <base64 signature file>  --rekor-response <rekor-bundle file> $IMAGE
+
+		# Attach signature attaches payload, signature, certificate and the Rekor log entry of a signature made elsewhere
+		cosign attach signature --payload <payload.json> --signature <base64 signature file> --certificate <cert.pem> --tlog-entry <log entry file> $IMAGE
+
+		# Attach signature attaches a DSSE payload from 'cosign generate --format dsse' and its signature as an attestation
+		cosign attach signature --payload <payload.pae> --signature <base64 signature file> --tlog-entry <log entry file> $IMAGE`,
 		PersistentPreRun: options.BindViper,
 		Args:             cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return attach.SignatureCmd(cmd.Context(), o.Registry, o.Signature, o.Payload, o.Cert, o.CertChain, o.TimeStampedSig, o.RekorBundle, args[0])
+			if err := o.Upload.ApplyTo(&o.Registry); err != nil {
+				return err
+			}
+			return attach.SignatureCmd(cmd.Context(), o.Registry, o.Signature, o.Payload, o.Cert, o.CertChain, o.TimeStampedSig, o.RekorBundle, o.TLogEntry, args[0])
 		},
 	}
 
@@ -92,6 +105,9 @@ func attachSBOM() *cobra.Command {
 				return err
 			}
 			fmt.Fprintf(os.Stderr, "WARNING: Attaching SBOMs this way does not sign them. To sign them, use 'cosign attest --predicate %s --key <key path>'.\n", o.SBOM)
+			if err := o.Upload.ApplyTo(&o.Registry); err != nil {
+				return err
+			}
 			return attach.SBOMCmd(cmd.Context(), o.Registry, o.RegistryExperimental, o.SBOM, mediaType, args[0])
 		},
 	}
@@ -115,12 +131,95 @@ func attachAttestation() *cobra.Command {
   # attach attestation from bundle files in form of JSONLines to a container image
   # https://github.com/in-toto/attestation/blob/main/spec/v1.0-draft/bundle.md
   cosign attach attestation --attestation <attestation bundle file path> <image uri>
+
+  # attach a DSSE envelope signed by in-toto or witness, checking that its statement names the image
+  cosign attach attestation --dsse <envelope file path> <image uri>
 `,
 
 		Args:             cobra.MinimumNArgs(1),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return attach.AttestationCmd(cmd.Context(), o.Registry, o.Attestations, args[0])
+			if err := o.Upload.ApplyTo(&o.Registry); err != nil {
+				return err
+			}
+			if err := attach.AttestationCmd(cmd.Context(), o.Registry, o.Attestations, args[0]); err != nil {
+				return err
+			}
+			if len(o.DSSEEnvelopes) > 0 {
+				return attach.DSSECmd(cmd.Context(), o.Registry, o.DSSEEnvelopes, args[0])
+			}
+			return nil
+		},
+	}
+
+	o.AddFlags(cmd)
+
+	return cmd
+}
+
+func attachArtifact() *cobra.Command {
+	o := &options.AttachArtifactOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "artifact",
+		Short: "Attach an arbitrary file to the supplied container image",
+		Example: `  cosign attach artifact --artifact <file path> --name <attachment name> [--media-type <media type>] <image uri>
+
+  # attach a license next to a container image
+  cosign attach artifact --artifact LICENSE --name license --media-type text/plain <image uri>
+
+  # attach a policy bundle as an OCI 1.1 referrer of a container image, with annotations
+  cosign attach artifact --artifact policy.tar.gz --name policy --media-type application/vnd.example.policy.v1.tar+gzip \
+    -a org.opencontainers.image.source=https://example.com/policy --registry-referrers-mode oci-1-1 <image uri>`,
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ann, err := o.AnnotationsMap()
+			if err != nil {
+				return err
+			}
+			annotations := make(map[string]string, len(ann.Annotations))
+			for k, v := range ann.Annotations {
+				annotations[k] = fmt.Sprint(v)
+			}
+			if err := o.Upload.ApplyTo(&o.Registry); err != nil {
+				return err
+			}
+			return attach.ArtifactCmd(cmd.Context(), o.Registry, o.RegistryExperimental, o.Artifact, o.Name,
+				types.MediaType(o.MediaType), o.ArtifactType, annotations, args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+
+	return cmd
+}
+
+func attachBinarySignature() *cobra.Command {
+	o := &options.AttachBinarySignatureOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "binary-signature",
+		Short: "Embed a sigstore bundle in the supplied executable",
+		Long: `Embed a sigstore bundle in an ELF, Mach-O or PE executable, for it to be verified with cosign verify-binary.
+
+The bundle is written into a zero filled section that must be reserved when the executable is built:
+` + embedded.ELFSection + ` in ELF, ` + embedded.MachOSection + ` (in any segment) in Mach-O and ` + embedded.PESection + ` in PE files.
+Sign the executable as built, before embedding the bundle; verification zeroes the section again.`,
+		Example: `  cosign attach binary-signature --bundle <bundle path> <executable>
+
+  # reserve 16KiB for the bundle in an ELF executable, sign it and embed the bundle
+  head -c 16384 /dev/zero > sigstore.section
+  objcopy --add-section .sigstore=sigstore.section tool
+  cosign sign-blob --bundle tool.bundle ./tool
+  cosign attach binary-signature --bundle tool.bundle ./tool
+
+  # reserve the section when linking a Mach-O executable
+  cc -Wl,-sectcreate,__DATA,__sigstore,sigstore.section -o tool main.c`,
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return attach.BinarySignatureCmd(o.Bundle, args[0])
 		},
 	}
 
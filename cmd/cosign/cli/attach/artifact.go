@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attach
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ocitypes "github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	ociexperimental "github.com/franchb/cosign/v2/internal/pkg/oci/remote"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+// ArtifactCmd attaches the file at artifactRef to imageRef as the attachment
+// attName. In oci-1-1 referrers mode it is stored as a referrer whose subject
+// is the image, otherwise under the image's attachment tag for attName.
+func ArtifactCmd(ctx context.Context, regOpts options.RegistryOptions, regExpOpts options.RegistryExperimentalOptions,
+	artifactRef, attName string, mediaType ocitypes.MediaType, artifactType string, annotations map[string]string, imageRef string) error {
+	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+	b, err := sbomBytes(artifactRef)
+	if err != nil {
+		return err
+	}
+	remoteOpts := regOpts.GetRegistryClientOpts(ctx)
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := []static.Option{static.WithLayerMediaType(mediaType)}
+	if len(annotations) > 0 {
+		opts = append(opts, static.WithAnnotations(annotations))
+	}
+
+	if regExpOpts.RegistryReferrersMode == options.RegistryReferrersModeOCI11 {
+		desc, err := remote.Head(ref, remoteOpts...)
+		if err != nil {
+			return err
+		}
+		if artifactType == "" {
+			artifactType = ociexperimental.ArtifactType(attName)
+		}
+		f, err := static.NewFile(b, append(opts,
+			static.WithConfigMediaType(ocitypes.MediaType(artifactType)),
+			static.WithSubject(*desc))...)
+		if err != nil {
+			return err
+		}
+		h, err := f.Digest()
+		if err != nil {
+			return err
+		}
+		dstRef := ref.Context().Digest(h.String())
+		fmt.Fprintf(os.Stderr, "Uploading %s for [%s] to [%s] with config.mediaType [%s] layers[0].mediaType [%s].\n",
+			attName, ref.Name(), dstRef.String(), artifactType, mediaType)
+		if err := ociremote.UploadLayers(dstRef.Context(), f, ociremoteOpts...); err != nil {
+			return err
+		}
+		return remote.Write(dstRef, f, remoteOpts...)
+	}
+
+	if artifactType != "" {
+		opts = append(opts, static.WithConfigMediaType(ocitypes.MediaType(artifactType)))
+	}
+	f, err := static.NewFile(b, opts...)
+	if err != nil {
+		return err
+	}
+	dstRef, err := ociremote.AttachmentTag(ref, attName, ociremoteOpts...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Uploading %s for [%s] to [%s] with mediaType [%s].\n", attName, ref.Name(), dstRef.Name(), mediaType)
+	if err := ociremote.UploadLayers(dstRef.Context(), f, ociremoteOpts...); err != nil {
+		return err
+	}
+	return remote.Write(dstRef, f, remoteOpts...)
+}
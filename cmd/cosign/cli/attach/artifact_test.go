@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attach
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ocitypes "github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+)
+
+func TestArtifactCmdReferrers(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0)), registry.WithReferrersSupport(true)))
+	defer s.Close()
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/app:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	artifactPath := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(artifactPath, []byte(`{"passed":true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	regExpOpts := options.RegistryExperimentalOptions{RegistryReferrersMode: options.RegistryReferrersModeOCI11}
+	if err := ArtifactCmd(ctx, options.RegistryOptions{}, regExpOpts, artifactPath, "report",
+		"application/json", "application/vnd.example.report+json", map[string]string{"example.com/kind": "report"}, ref.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The artifact is stored as a referrer whose subject is the image.
+	idx, err := remote.Referrers(ref.Context().Digest(h.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Manifests) != 1 {
+		t.Fatalf("got %d referrers, want 1", len(m.Manifests))
+	}
+	desc := m.Manifests[0]
+	if desc.ArtifactType != "application/vnd.example.report+json" {
+		t.Errorf("artifactType = %q, want application/vnd.example.report+json", desc.ArtifactType)
+	}
+	artifact, err := remote.Image(ref.Context().Digest(desc.Digest.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := artifact.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Subject == nil || manifest.Subject.Digest != h {
+		t.Errorf("subject = %v, want the image %s", manifest.Subject, h)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != ocitypes.MediaType("application/json") {
+		t.Errorf("layers = %v, want a single application/json layer", manifest.Layers)
+	}
+	if manifest.Annotations["example.com/kind"] != "report" {
+		t.Errorf("annotations = %v, want example.com/kind=report", manifest.Annotations)
+	}
+}
@@ -16,9 +16,13 @@ package attach
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/internal/ui"
@@ -27,6 +31,8 @@ import (
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/franchb/cosign/v2/pkg/types"
 	"github.com/google/go-containerregistry/pkg/name"
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	"github.com/in-toto/in-toto-golang/in_toto"
 	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
 )
 
@@ -53,15 +59,17 @@ func attachAttestation(ctx context.Context, remoteOpts []ociremote.Option, signe
 	}
 	defer attestationFile.Close()
 
-	env := ssldsse.Envelope{}
 	decoder := json.NewDecoder(attestationFile)
 	for decoder.More() {
-		if err := decoder.Decode(&env); err != nil {
+		// Keep the envelope as it was written, so that envelopes from other
+		// tools keep fields and signatures cosign does not know about.
+		var envelope json.RawMessage
+		if err := decoder.Decode(&envelope); err != nil {
 			return err
 		}
 
-		payload, err := json.Marshal(env)
-		if err != nil {
+		env := ssldsse.Envelope{}
+		if err := json.Unmarshal(envelope, &env); err != nil {
 			return err
 		}
 
@@ -90,8 +98,7 @@ func attachAttestation(ctx context.Context, remoteOpts []ociremote.Option, signe
 		// each access.
 		ref = digest // nolint
 
-		opts := []static.Option{static.WithLayerMediaType(types.DssePayloadType)}
-		att, err := static.NewAttestation(payload, opts...)
+		att, err := static.NewAttestation(envelope, static.WithDSSEEnvelope())
 		if err != nil {
 			return err
 		}
@@ -114,3 +121,107 @@ func attachAttestation(ctx context.Context, remoteOpts []ociremote.Option, signe
 	}
 	return nil
 }
+
+// DSSECmd attaches the DSSE envelopes at envelopePaths, signed by other
+// tools such as in-toto or witness, as attestations of the image, without
+// re-signing them. Each envelope must be a signed in-toto statement with the
+// image as a subject.
+func DSSECmd(ctx context.Context, regOpts options.RegistryOptions, envelopePaths []string, imageRef string) error {
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("constructing client options: %w", err)
+	}
+	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+	if _, ok := ref.(name.Digest); !ok {
+		msg := fmt.Sprintf(ui.TagReferenceMessage, imageRef)
+		ui.Warnf(ctx, msg)
+	}
+	digest, err := ociremote.ResolveDigest(ref, ociremoteOpts...)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range envelopePaths {
+		if err := attachDSSEEnvelope(digest, path, ociremoteOpts); err != nil {
+			return fmt.Errorf("attaching DSSE envelope from %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func attachDSSEEnvelope(digest name.Digest, path string, ociremoteOpts []ociremote.Option) error {
+	fmt.Fprintln(os.Stderr, "Using DSSE envelope from:", path)
+	envelope, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	predicateType, err := validateDSSEEnvelope(envelope, digest)
+	if err != nil {
+		return err
+	}
+
+	// The envelope is attached as it was written, so that the fields and
+	// signatures of other tools are kept.
+	att, err := static.NewAttestation(envelope,
+		static.WithDSSEEnvelope(),
+		static.WithAnnotations(map[string]string{static.PredicateTypeAnnotationKey: predicateType}))
+	if err != nil {
+		return err
+	}
+	se, err := ociremote.SignedEntity(digest, ociremoteOpts...)
+	if err != nil {
+		return err
+	}
+	newSE, err := mutate.AttachAttestationToEntity(se, att)
+	if err != nil {
+		return err
+	}
+	return ociremote.WriteAttestations(digest.Repository, newSE, ociremoteOpts...)
+}
+
+// validateDSSEEnvelope checks that envelope is a signed DSSE envelope of an
+// in-toto statement with the image at digest as a subject, and returns the
+// predicate type of the statement. The signatures are not verified, as the
+// keys of other tools are not known here.
+func validateDSSEEnvelope(envelope []byte, digest name.Digest) (string, error) {
+	env := ssldsse.Envelope{}
+	if err := json.Unmarshal(envelope, &env); err != nil {
+		return "", fmt.Errorf("parsing DSSE envelope: %w", err)
+	}
+	if env.PayloadType != types.IntotoPayloadType {
+		return "", fmt.Errorf("invalid payloadType %s on envelope. Expected %s", env.PayloadType, types.IntotoPayloadType)
+	}
+	if len(env.Signatures) == 0 {
+		return "", errors.New("DSSE envelope has no signatures")
+	}
+	for i, sig := range env.Signatures {
+		if b, err := base64.StdEncoding.DecodeString(sig.Sig); err != nil || len(b) == 0 {
+			return "", fmt.Errorf("signature %d of the DSSE envelope is not a base64-encoded signature", i)
+		}
+	}
+	body, err := env.DecodeB64Payload()
+	if err != nil {
+		return "", fmt.Errorf("decoding DSSE payload: %w", err)
+	}
+
+	var statement in_toto.StatementHeader
+	if err := json.Unmarshal(body, &statement); err != nil {
+		return "", fmt.Errorf("parsing in-toto statement: %w", err)
+	}
+	if statement.Type != in_toto.StatementInTotoV01 && statement.Type != intotov1.StatementTypeUri {
+		return "", fmt.Errorf("invalid statement type %q, expected %s or %s", statement.Type, in_toto.StatementInTotoV01, intotov1.StatementTypeUri)
+	}
+	if statement.PredicateType == "" {
+		return "", errors.New("in-toto statement has no predicate type")
+	}
+	algorithm, hex, _ := strings.Cut(digest.DigestStr(), ":")
+	for _, subject := range statement.Subject {
+		if subject.Digest[algorithm] == hex {
+			return statement.PredicateType, nil
+		}
+	}
+	return "", fmt.Errorf("no subject of the in-toto statement has the digest of %s", digest)
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attach
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/types"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigstoredsse "github.com/franchb/sigstore/pkg/signature/dsse"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDSSECmd(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/app:latest")
+	require.NoError(t, err)
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	h, err := img.Digest()
+	require.NoError(t, err)
+
+	// Sign the statement as another tool would.
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	require.NoError(t, err)
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app","digest":{"sha256":%q}}],"predicateType":"https://witness.dev/attestation-collection/v0.1","predicate":{"name":"build"}}`, h.Hex)
+	envelope, err := sigstoredsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(strings.NewReader(statement))
+	require.NoError(t, err)
+	envelopePath := filepath.Join(td, "envelope.json")
+	require.NoError(t, os.WriteFile(envelopePath, envelope, 0o600))
+
+	require.NoError(t, DSSECmd(ctx, options.RegistryOptions{}, []string{envelopePath}, ref.String()))
+
+	atts, _, err := cosign.VerifyImageAttestations(ctx, ref, &cosign.CheckOpts{
+		SigVerifier: sv,
+		IgnoreTlog:  true,
+	})
+	require.NoError(t, err)
+	require.Len(t, atts, 1)
+	payload, err := atts[0].Payload()
+	require.NoError(t, err)
+	require.Equal(t, envelope, payload, "the envelope was re-encoded")
+	annotations, err := atts[0].Annotations()
+	require.NoError(t, err)
+	require.Equal(t, "https://witness.dev/attestation-collection/v0.1", annotations["predicateType"])
+
+	// An envelope of another image is rejected.
+	other, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	otherRef, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/other:latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(otherRef, other))
+	require.ErrorContains(t, DSSECmd(ctx, options.RegistryOptions{}, []string{envelopePath}, otherRef.String()), "no subject")
+}
+
+func TestValidateDSSEEnvelope(t *testing.T) {
+	digest, err := name.NewDigest("registry.example.com/app@sha256:" + strings.Repeat("a", 64))
+	require.NoError(t, err)
+	statement := func(typ, predicateType, hex string) string {
+		return fmt.Sprintf(`{"_type":%q,"subject":[{"name":"app","digest":{"sha256":%q}}],"predicateType":%q,"predicate":{}}`, typ, hex, predicateType)
+	}
+	envelope := func(payloadType, body string, sigs ...string) []byte {
+		env := ssldsse.Envelope{
+			PayloadType: payloadType,
+			Payload:     base64.StdEncoding.EncodeToString([]byte(body)),
+		}
+		for _, sig := range sigs {
+			env.Signatures = append(env.Signatures, ssldsse.Signature{Sig: sig})
+		}
+		b, err := json.Marshal(env)
+		require.NoError(t, err)
+		return b
+	}
+	sig := base64.StdEncoding.EncodeToString([]byte("signature"))
+	valid := statement("https://in-toto.io/Statement/v0.1", "https://slsa.dev/provenance/v1", strings.Repeat("a", 64))
+
+	for _, tc := range []struct {
+		name     string
+		envelope []byte
+		wantErr  string
+	}{{
+		name:     "valid",
+		envelope: envelope(types.IntotoPayloadType, valid, sig),
+	}, {
+		name:     "not JSON",
+		envelope: []byte("DSSEv1 28 application/vnd.in-toto+json 2 {}"),
+		wantErr:  "parsing DSSE envelope",
+	}, {
+		name:     "payload type",
+		envelope: envelope("application/json", valid, sig),
+		wantErr:  "invalid payloadType",
+	}, {
+		name:     "unsigned",
+		envelope: envelope(types.IntotoPayloadType, valid),
+		wantErr:  "no signatures",
+	}, {
+		name:     "empty signature",
+		envelope: envelope(types.IntotoPayloadType, valid, sig, ""),
+		wantErr:  "signature 1",
+	}, {
+		name:     "statement type",
+		envelope: envelope(types.IntotoPayloadType, statement("https://example.com/Statement", "https://slsa.dev/provenance/v1", strings.Repeat("a", 64)), sig),
+		wantErr:  "invalid statement type",
+	}, {
+		name:     "no predicate type",
+		envelope: envelope(types.IntotoPayloadType, statement("https://in-toto.io/Statement/v1", "", strings.Repeat("a", 64)), sig),
+		wantErr:  "no predicate type",
+	}, {
+		name:     "other subject",
+		envelope: envelope(types.IntotoPayloadType, statement("https://in-toto.io/Statement/v1", "https://slsa.dev/provenance/v1", strings.Repeat("b", 64)), sig),
+		wantErr:  "no subject",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			predicateType, err := validateDSSEEnvelope(tc.envelope, digest)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "https://slsa.dev/provenance/v1", predicateType)
+		})
+	}
+}
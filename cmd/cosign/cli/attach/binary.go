@@ -0,0 +1,36 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attach
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/embedded"
+)
+
+// BinarySignatureCmd embeds the bundle at bundlePath in the section reserved
+// for it in the executable at binaryPath.
+func BinarySignatureCmd(bundlePath, binaryPath string) error {
+	b, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return fmt.Errorf("reading bundle: %w", err)
+	}
+	if err := embedded.WriteBundle(binaryPath, b); err != nil {
+		return fmt.Errorf("embedding bundle in %s: %w", binaryPath, err)
+	}
+	fmt.Fprintf(os.Stderr, "Embedded bundle in %s\n", binaryPath)
+	return nil
+}
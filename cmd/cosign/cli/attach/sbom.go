@@ -70,6 +70,9 @@ func SBOMCmd(ctx context.Context, regOpts options.RegistryOptions, regExpOpts op
 	if err != nil {
 		return err
 	}
+	if err := ociremote.UploadLayers(dstRef.Context(), img, remoteOpts...); err != nil {
+		return err
+	}
 	return remote.Write(dstRef, img, regOpts.GetRegistryClientOpts(ctx)...)
 }
 
@@ -131,6 +134,13 @@ func sbomCmdOCIExperimental(ctx context.Context, regOpts options.RegistryOptions
 
 	fmt.Fprintf(os.Stderr, "Uploading SBOM file for [%s] to [%s] with config.mediaType [%s] layers[0].mediaType [%s].\n",
 		ref.Name(), dstRef.String(), artifactType, sbomType)
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ociremote.UploadLayers(dstRef.Context(), att, ociremoteOpts...); err != nil {
+		return err
+	}
 	return remote.Write(dstRef, att, regOpts.GetRegistryClientOpts(ctx)...)
 }
 
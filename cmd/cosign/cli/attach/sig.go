@@ -16,23 +16,40 @@
 package attach
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/mutate"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/pkg/types"
+	"github.com/franchb/rekor/pkg/generated/models"
 	"github.com/google/go-containerregistry/pkg/name"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
 )
 
-func SignatureCmd(ctx context.Context, regOpts options.RegistryOptions, sigRef, payloadRef, certRef, certChainRef, timeStampedSigRef, rekorBundleRef, imageRef string) error {
+// SignatureCmd attaches the signature at sigRef of the payload at payloadRef
+// to the image at imageRef. A payload generated with 'cosign generate
+// --format dsse' is attached as a DSSE attestation instead, with the
+// signature as that of its envelope.
+func SignatureCmd(ctx context.Context, regOpts options.RegistryOptions, sigRef, payloadRef, certRef, certChainRef, timeStampedSigRef, rekorBundleRef, tlogEntryRef, imageRef string) error {
+	if rekorBundleRef != "" && tlogEntryRef != "" {
+		return errors.New("only one of --rekor-response and --tlog-entry may be provided")
+	}
+
 	b64SigBytes, err := signatureBytes(sigRef)
 	if err != nil {
 		return err
@@ -67,11 +84,6 @@ func SignatureCmd(ctx context.Context, regOpts options.RegistryOptions, sigRef,
 		return err
 	}
 
-	sig, err := static.NewSignature(payload, string(b64SigBytes))
-	if err != nil {
-		return err
-	}
-
 	var cert []byte
 	var certChain []byte
 	var timeStampedSig []byte
@@ -114,12 +126,28 @@ func SignatureCmd(ctx context.Context, regOpts options.RegistryOptions, sigRef,
 		rekorBundle = localCosignPayload.Bundle
 	}
 
-	newSig, err := mutate.Signature(sig, mutate.WithCertChain(cert, certChain), mutate.WithRFC3161Timestamp(tsBundle), mutate.WithBundle(rekorBundle))
+	if tlogEntryRef != "" {
+		rekorBundle, err = tlogEntryBundle(tlogEntryRef)
+		if err != nil {
+			return err
+		}
+	}
+
+	se, err := ociremote.SignedEntity(digest, ociremoteOpts...)
 	if err != nil {
 		return err
 	}
 
-	se, err := ociremote.SignedEntity(digest, ociremoteOpts...)
+	if payloadType, body, ok := parsePAE(payload); ok {
+		return attachDSSESignature(digest, se, payloadType, body, string(b64SigBytes), cert, certChain, tsBundle, rekorBundle, ociremoteOpts)
+	}
+
+	sig, err := static.NewSignature(payload, string(b64SigBytes))
+	if err != nil {
+		return err
+	}
+
+	newSig, err := mutate.Signature(sig, mutate.WithCertChain(cert, certChain), mutate.WithRFC3161Timestamp(tsBundle), mutate.WithBundle(rekorBundle))
 	if err != nil {
 		return err
 	}
@@ -134,6 +162,108 @@ func SignatureCmd(ctx context.Context, regOpts options.RegistryOptions, sigRef,
 	return ociremote.WriteSignatures(digest.Repository, newSE, ociremoteOpts...)
 }
 
+// attachDSSESignature attaches the DSSE envelope of the in-toto statement
+// body signed with b64Sig as an attestation of se.
+func attachDSSESignature(digest name.Digest, se oci.SignedEntity, payloadType string, body []byte, b64Sig string, cert, certChain []byte, tsBundle *bundle.RFC3161Timestamp, rekorBundle *bundle.RekorBundle, ociremoteOpts []ociremote.Option) error {
+	if payloadType != types.IntotoPayloadType {
+		return fmt.Errorf("invalid payloadType %s in DSSE payload. Expected %s", payloadType, types.IntotoPayloadType)
+	}
+	var statement struct {
+		PredicateType string `json:"predicateType"`
+	}
+	if err := json.Unmarshal(body, &statement); err != nil {
+		return fmt.Errorf("parsing in-toto statement: %w", err)
+	}
+	envelope, err := json.Marshal(ssldsse.Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(body),
+		Signatures:  []ssldsse.Signature{{Sig: strings.TrimSpace(b64Sig)}},
+	})
+	if err != nil {
+		return err
+	}
+
+	opts := []static.Option{
+		static.WithDSSEEnvelope(),
+		static.WithAnnotations(map[string]string{static.PredicateTypeAnnotationKey: statement.PredicateType}),
+	}
+	if cert != nil {
+		opts = append(opts, static.WithCertChain(cert, certChain))
+	}
+	if tsBundle != nil {
+		opts = append(opts, static.WithRFC3161Timestamp(tsBundle))
+	}
+	if rekorBundle != nil {
+		opts = append(opts, static.WithBundle(rekorBundle))
+	}
+	att, err := static.NewAttestation(envelope, opts...)
+	if err != nil {
+		return err
+	}
+
+	newSE, err := mutate.AttachAttestationToEntity(se, att)
+	if err != nil {
+		return err
+	}
+	return ociremote.WriteAttestations(digest.Repository, newSE, ociremoteOpts...)
+}
+
+// parsePAE returns the payload type and body of b if it is a DSSE
+// pre-authentication encoding, as written by 'cosign generate --format dsse'.
+func parsePAE(b []byte) (string, []byte, bool) {
+	rest, ok := bytes.CutPrefix(b, []byte("DSSEv1 "))
+	if !ok {
+		return "", nil, false
+	}
+	field := func() ([]byte, bool) {
+		n, after, ok := bytes.Cut(rest, []byte(" "))
+		if !ok {
+			return nil, false
+		}
+		l, err := strconv.Atoi(string(n))
+		if err != nil || l < 0 || l > len(after) {
+			return nil, false
+		}
+		rest = after[l:]
+		return after[:l], true
+	}
+	payloadType, ok := field()
+	if !ok {
+		return "", nil, false
+	}
+	if rest, ok = bytes.CutPrefix(rest, []byte(" ")); !ok {
+		return "", nil, false
+	}
+	body, ok := field()
+	if !ok || len(rest) != 0 {
+		return "", nil, false
+	}
+	return string(payloadType), body, true
+}
+
+// tlogEntryBundle returns the bundle of the Rekor log entry at path, either
+// as returned by the Rekor API, keyed by its UUID, or on its own.
+func tlogEntryBundle(path string) (*bundle.RekorBundle, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var entry models.LogEntryAnon
+	var entries models.LogEntry
+	if err := json.Unmarshal(b, &entries); err == nil && len(entries) == 1 {
+		for _, e := range entries {
+			entry = e
+		}
+	} else if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, fmt.Errorf("parsing tlog entry: %w", err)
+	}
+	if entry.Verification == nil || entry.Verification.SignedEntryTimestamp == nil ||
+		entry.Body == nil || entry.IntegratedTime == nil || entry.LogIndex == nil || entry.LogID == nil {
+		return nil, errors.New("tlog entry is missing its body, integrated time, log index, log ID or signed entry timestamp")
+	}
+	return bundle.EntryToBundle(&entry), nil
+}
+
 type SignatureArgType uint8
 
 const (
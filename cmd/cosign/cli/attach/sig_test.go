@@ -0,0 +1,132 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attach
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/types"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePAE(t *testing.T) {
+	body := []byte(`{"predicateType": "https://example.com/v1"} with spaces`)
+	payloadType, got, ok := parsePAE(dsse.PAE(types.IntotoPayloadType, body))
+	require.True(t, ok)
+	require.Equal(t, types.IntotoPayloadType, payloadType)
+	require.Equal(t, body, got)
+
+	for _, b := range []string{
+		`{"critical": {}}`,
+		"DSSEv1 ",
+		"DSSEv1 3 abc",
+		"DSSEv1 3 abc 5 body",
+		"DSSEv1 3 abc 2 body",
+		"DSSEv1 x abc 4 body",
+		"DSSEv1 3 abc4 body",
+	} {
+		_, _, ok := parsePAE([]byte(b))
+		require.False(t, ok, b)
+	}
+}
+
+func TestTlogEntryBundle(t *testing.T) {
+	td := t.TempDir()
+	entry := `{"body": "Ym9keQ==", "integratedTime": 1700000000, "logID": "c0ffee", "logIndex": 42, "verification": {"signedEntryTimestamp": "c2V0"}}`
+	for name, content := range map[string]string{
+		"api":   `{"24296fb24b8ad77a": ` + entry + `}`,
+		"entry": entry,
+	} {
+		path := filepath.Join(td, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		b, err := tlogEntryBundle(path)
+		require.NoError(t, err, name)
+		require.Equal(t, int64(42), b.Payload.LogIndex)
+		require.Equal(t, int64(1700000000), b.Payload.IntegratedTime)
+		require.Equal(t, "c0ffee", b.Payload.LogID)
+		require.Equal(t, []byte("set"), []byte(b.SignedEntryTimestamp))
+	}
+
+	path := filepath.Join(td, "incomplete")
+	require.NoError(t, os.WriteFile(path, []byte(`{"body": "Ym9keQ==", "logIndex": 42}`), 0o600))
+	_, err := tlogEntryBundle(path)
+	require.Error(t, err)
+}
+
+func TestSignatureCmdDSSE(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/app:latest")
+	require.NoError(t, err)
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	predicatePath := filepath.Join(td, "predicate.json")
+	require.NoError(t, os.WriteFile(predicatePath, []byte(`{"builder": "offline"}`), 0o600))
+	payloadPath := filepath.Join(td, "payload.pae")
+	f, err := os.Create(payloadPath)
+	require.NoError(t, err)
+	require.NoError(t, generate.GenerateDSSECmd(ctx, options.RegistryOptions{}, ref.String(), predicatePath, "custom", f))
+	require.NoError(t, f.Close())
+
+	// Sign the generated bytes as an external signer would.
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	require.NoError(t, err)
+	pae, err := os.ReadFile(payloadPath)
+	require.NoError(t, err)
+	sig, err := sv.SignMessage(strings.NewReader(string(pae)))
+	require.NoError(t, err)
+	sigPath := filepath.Join(td, "payload.sig")
+	require.NoError(t, os.WriteFile(sigPath, []byte(base64.StdEncoding.EncodeToString(sig)), 0o600))
+
+	require.NoError(t, SignatureCmd(ctx, options.RegistryOptions{}, sigPath, payloadPath, "", "", "", "", "", ref.String()))
+
+	atts, _, err := cosign.VerifyImageAttestations(ctx, ref, &cosign.CheckOpts{
+		SigVerifier: sv,
+		IgnoreTlog:  true,
+	})
+	require.NoError(t, err)
+	require.Len(t, atts, 1)
+	annotations, err := atts[0].Annotations()
+	require.NoError(t, err)
+	require.Equal(t, "https://cosign.sigstore.dev/attestation/v1", annotations["predicateType"])
+}
@@ -48,20 +48,47 @@ func Attest() *cobra.Command {
   # attach an attestation to a container image with a key pair stored in Google Cloud KMS
   cosign attest --predicate <FILE> --type <TYPE> --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY]/versions/[VERSION] <IMAGE>
 
+  # attach an attestation to a container image with a key pair stored in Oracle Cloud Infrastructure Vault
+  cosign attest --predicate <FILE> --type <TYPE> --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <IMAGE>
+
+  # attach an attestation to a container image with a key pair stored in Alibaba Cloud KMS
+  cosign attest --predicate <FILE> --type <TYPE> --key alibabakms://[REGION]/[KEY_ID] <IMAGE>
+
+  # attach an attestation to a container image with a key pair stored in Tencent Cloud KMS
+  cosign attest --predicate <FILE> --type <TYPE> --key tencentkms://[REGION]/[KEY_ID] <IMAGE>
+
   # attach an attestation to a container image with a key pair stored in Hashicorp Vault
   cosign attest --predicate <FILE> --type <TYPE> --key hashivault://[KEY] <IMAGE>
 
   # attach an attestation to a container image with a local key pair file, including a certificate and certificate chain
   cosign attest --predicate <FILE> --type <TYPE> --key cosign.key --cert cosign.crt --cert-chain chain.crt <IMAGE>
 
+  # attach an attestation to a container image, writing a Sigstore bundle that other Sigstore clients can verify
+  cosign attest --predicate <FILE> --type <TYPE> --key cosign.key --bundle attestation.sigstore.json --bundle-format sigstore <IMAGE>
+
   # attach an attestation to a container image which does not fully support OCI media types
   COSIGN_DOCKER_MEDIA_TYPES=1 cosign attest --predicate <FILE> --type <TYPE> --key cosign.key legacy-registry.example.com/my/image
 
   # supply attestation via stdin
   echo <PAYLOAD> | cosign attest --predicate - <IMAGE>
 
+  # attach an attestation to a multi-arch image and each of its discrete images, naming the platform of each in the predicate
+  cosign attest --predicate <FILE> --type <TYPE> --key cosign.key --recursive --predicate-template <MULTI-ARCH IMAGE DIGEST>
+
+  # attach a provenance attestation to a Helm chart in an OCI registry, naming its chart archive as a subject too
+  cosign attest --predicate provenance.json --type slsaprovenance --key cosign.key helm://<REGISTRY>/<REPO>/<CHART>:<VERSION>
+
   # attach an attestation to a container image and honor the creation timestamp of the signature
-  cosign attest --predicate <FILE> --type <TYPE> --key cosign.key --record-creation-timestamp <IMAGE>`,
+  cosign attest --predicate <FILE> --type <TYPE> --key cosign.key --record-creation-timestamp <IMAGE>
+
+  # attach an attestation to a container image reproducibly, so that attesting it again with the same key gives the same attestation
+  cosign attest --predicate <FILE> --type <TYPE> --key cosign.key --deterministic --tlog-upload=false <IMAGE DIGEST>
+
+  # generate the SPDX SBOM of a container image and attest it in one step, with cosign built with -tags syft
+  cosign attest --type spdxjson --predicate-gen syft --key cosign.key <IMAGE>
+
+  # attach a large SBOM attestation in 64MiB chunks, retrying failed chunks
+  cosign attest --predicate sbom.spdx.json --type spdxjson --key cosign.key --upload-chunk-size 64MiB --registry-retries 5 <IMAGE>`,
 
 		Args:             cobra.MinimumNArgs(1),
 		PersistentPreRun: options.BindViper,
@@ -70,6 +97,10 @@ func Attest() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err := o.Upload.ApplyTo(&o.Registry); err != nil {
+				return err
+			}
+			tsaServerURL, additionalTSAServerURLs := options.SplitTSAServerURLs(o.TSAServerURLs)
 			ko := options.KeyOpts{
 				KeyRef:                   o.Key,
 				PassFunc:                 generate.GetPass,
@@ -79,28 +110,40 @@ func Attest() *cobra.Command {
 				IDToken:                  o.Fulcio.IdentityToken,
 				FulcioAuthFlow:           o.Fulcio.AuthFlow,
 				InsecureSkipFulcioVerify: o.Fulcio.InsecureSkipFulcioVerify,
+				CertificateProvider:      o.Fulcio.CertificateProvider,
 				RekorURL:                 o.Rekor.URL,
+				AdditionalRekorURLs:      o.Rekor.AdditionalURLs,
 				OIDCIssuer:               o.OIDC.Issuer,
 				OIDCClientID:             o.OIDC.ClientID,
 				OIDCClientSecret:         oidcClientSecret,
 				OIDCRedirectURL:          o.OIDC.RedirectURL,
 				OIDCProvider:             o.OIDC.Provider,
 				SkipConfirmation:         o.SkipConfirmation,
-				TSAServerURL:             o.TSAServerURL,
+				TSAServerURL:             tsaServerURL,
+				AdditionalTSAServerURLs:  additionalTSAServerURLs,
+				BundlePath:               o.BundlePath,
+				NewBundleFormat:          o.BundleFormat == options.BundleFormatSigstore,
+				Deterministic:            o.Deterministic,
 			}
 			attestCommand := attest.AttestCommand{
-				KeyOpts:                 ko,
-				RegistryOptions:         o.Registry,
-				CertPath:                o.Cert,
-				CertChainPath:           o.CertChain,
-				NoUpload:                o.NoUpload,
-				PredicatePath:           o.Predicate.Path,
-				PredicateType:           o.Predicate.Type,
-				Replace:                 o.Replace,
-				Timeout:                 ro.Timeout,
-				TlogUpload:              o.TlogUpload,
-				RekorEntryType:          o.RekorEntryType,
-				RecordCreationTimestamp: o.RecordCreationTimestamp,
+				KeyOpts:                     ko,
+				RegistryOptions:             o.Registry,
+				RegistryExperimentalOptions: o.RegistryExperimental,
+				CertPath:                    o.Cert,
+				CertChainPath:               o.CertChain,
+				NoUpload:                    o.NoUpload,
+				PredicatePath:               o.Predicate.Path,
+				PredicateType:               o.Predicate.Type,
+				Recursive:                   o.Recursive,
+				MultiSubject:                o.MultiSubject,
+				PredicateTemplate:           o.PredicateTemplate,
+				PredicateGenerator:          o.PredicateGenerator,
+				Replace:                     o.Replace,
+				Timeout:                     ro.Timeout,
+				TlogUpload:                  o.TlogUpload,
+				RekorEntryType:              o.RekorEntryType,
+				RecordCreationTimestamp:     o.RecordCreationTimestamp,
+				Zstd:                        o.Zstd,
 			}
 
 			for _, img := range args {
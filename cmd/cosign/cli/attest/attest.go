@@ -19,13 +19,19 @@ import (
 	"bytes"
 	"context"
 	_ "crypto/sha256" // for `crypto.SHA256`
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
@@ -37,9 +43,13 @@ import (
 	"github.com/franchb/cosign/v2/pkg/cosign/attestation"
 	cbundle "github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	cremote "github.com/franchb/cosign/v2/pkg/cosign/remote"
+	"github.com/franchb/cosign/v2/pkg/helm"
+	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/mutate"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/pkg/oci/walk"
+	"github.com/franchb/cosign/v2/pkg/sbom"
 	"github.com/franchb/cosign/v2/pkg/types"
 	"github.com/franchb/rekor/pkg/generated/client"
 	"github.com/franchb/rekor/pkg/generated/models"
@@ -49,7 +59,7 @@ import (
 
 type tlogUploadFn func(*client.Rekor, []byte) (*models.LogEntryAnon, error)
 
-func uploadToTlog(ctx context.Context, sv *sign.SignerVerifier, rekorURL string, upload tlogUploadFn) (*cbundle.RekorBundle, error) {
+func uploadToTlog(ctx context.Context, sv *sign.SignerVerifier, rekorURL string, additionalRekorURLs []string, upload tlogUploadFn) (*models.LogEntryAnon, error) {
 	rekorBytes, err := sv.Bytes(ctx)
 	if err != nil {
 		return nil, err
@@ -64,24 +74,37 @@ func uploadToTlog(ctx context.Context, sv *sign.SignerVerifier, rekorURL string,
 		return nil, err
 	}
 	fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
-	return cbundle.EntryToBundle(entry), nil
+	if err := rekor.UploadToAdditionalLogs(ctx, rekorURL, additionalRekorURLs, func(r *client.Rekor) (*models.LogEntryAnon, error) {
+		return upload(r, rekorBytes)
+	}); err != nil {
+		return nil, err
+	}
+	return entry, nil
 }
 
 // nolint
 type AttestCommand struct {
 	options.KeyOpts
 	options.RegistryOptions
+	options.RegistryExperimentalOptions
 	CertPath                string
 	CertChainPath           string
 	NoUpload                bool
 	PredicatePath           string
 	PredicateType           string
+	Recursive               bool
+	MultiSubject            bool
 	Replace                 bool
 	Timeout                 time.Duration
 	TlogUpload              bool
 	TSAServerURL            string
 	RekorEntryType          string
 	RecordCreationTimestamp bool
+	Zstd                    bool
+	PredicateTemplate       bool
+	// PredicateGenerator, when set, is the SBOM generator the predicate of
+	// each attested image is generated with, instead of PredicatePath.
+	PredicateGenerator string
 }
 
 // nolint
@@ -91,19 +114,62 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 		return &options.KeyParseError{}
 	}
 
-	if c.PredicatePath == "" {
+	if c.PredicatePath == "" && c.PredicateGenerator == "" {
 		return fmt.Errorf("predicate cannot be empty")
 	}
+	if c.PredicatePath != "" && c.PredicateGenerator != "" {
+		return fmt.Errorf("--predicate cannot be used with --predicate-gen")
+	}
+	var generator sbom.Generator
+	var sbomFormat sbom.Format
+	if c.PredicateGenerator != "" {
+		var err error
+		if generator, err = sbom.GeneratorFor(c.PredicateGenerator); err != nil {
+			return err
+		}
+		switch c.PredicateType {
+		case options.PredicateSPDXJSON:
+			sbomFormat = sbom.FormatSPDXJSON
+		case options.PredicateCycloneDX:
+			sbomFormat = sbom.FormatCycloneDXJSON
+		default:
+			return fmt.Errorf("--predicate-gen generates %s or %s predicates, not %s", options.PredicateSPDXJSON, options.PredicateCycloneDX, c.PredicateType)
+		}
+		if c.PredicateTemplate {
+			return fmt.Errorf("--predicate-template cannot be used with --predicate-gen")
+		}
+	}
 
 	if c.RekorEntryType != "dsse" && c.RekorEntryType != "intoto" {
 		return fmt.Errorf("unknown value for rekor-entry-type")
 	}
+	if c.Deterministic {
+		// Timestamps differ every time the digest is attested.
+		if c.KeyOpts.TSAServerURL != "" {
+			return fmt.Errorf("--deterministic cannot be combined with --timestamp-server-url")
+		}
+		if c.RecordCreationTimestamp {
+			return fmt.Errorf("--deterministic cannot be combined with --record-creation-timestamp")
+		}
+	}
 
-	predicateURI, err := options.ParsePredicateType(c.PredicateType)
-	if err != nil {
+	if _, err := options.ParsePredicateType(c.PredicateType); err != nil {
 		return err
 	}
-	ref, err := name.ParseReference(imageRef, c.NameOptions()...)
+	chartRef := helm.IsReference(imageRef)
+	if chartRef && c.Recursive {
+		return fmt.Errorf("--recursive cannot be used with a Helm chart")
+	}
+	if chartRef && generator != nil {
+		return fmt.Errorf("--predicate-gen cannot be used with a Helm chart")
+	}
+	var ref name.Reference
+	var err error
+	if chartRef {
+		ref, err = helm.ParseReference(imageRef, c.NameOptions()...)
+	} else {
+		ref, err = name.ParseReference(imageRef, c.NameOptions()...)
+	}
 	if err != nil {
 		return fmt.Errorf("parsing reference: %w", err)
 	}
@@ -126,7 +192,6 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 	if err != nil {
 		return err
 	}
-	h, _ := v1.NewHash(digest.Identifier())
 	// Overwrite "ref" with a digest to avoid a race where we use a tag
 	// multiple times, and it potentially points to different things at
 	// each access.
@@ -137,21 +202,96 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 		return fmt.Errorf("getting signer: %w", err)
 	}
 	defer sv.Close()
-	wrapped := dsse.WrapSigner(sv, types.IntotoPayloadType)
 	dd := cremote.NewDupeDetector(sv)
 
-	predicate, err := predicateReader(c.PredicatePath)
+	// The predicate is read once, as it is attested for each image when
+	// attesting recursively, while a generated one is generated for each.
+	var predicateBytes []byte
+	if generator == nil {
+		predicate, err := predicateReader(c.PredicatePath)
+		if err != nil {
+			return fmt.Errorf("getting predicate reader: %w", err)
+		}
+		defer predicate.Close()
+		predicateBytes, err = io.ReadAll(predicate)
+		if err != nil {
+			return fmt.Errorf("reading predicate: %w", err)
+		}
+	}
+	predicateFor := func(digest name.Digest, platform v1.Platform) ([]byte, error) {
+		if generator == nil {
+			return c.renderPredicate(predicateBytes, digest, platform)
+		}
+		fmt.Fprintf(os.Stderr, "Generating %s SBOM of %s with %s\n", c.PredicateType, digest, c.PredicateGenerator)
+		b, err := generator(ctx, digest, sbomFormat, sbom.Options{
+			Keychain:      c.RegistryOptions.AuthKeychain(),
+			AllowInsecure: c.RegistryOptions.AllowInsecure,
+			AllowHTTP:     c.RegistryOptions.AllowHTTPRegistry,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("generating SBOM of %s: %w", digest, err)
+		}
+		return b, nil
+	}
+
+	if !c.Recursive || c.MultiSubject {
+		var imageDigests []string
+		if chartRef {
+			// The chart archive is a subject too, so that the attestation
+			// holds for the .tgz file `helm pull` writes.
+			chart, err := helm.Resolve(digest, ociremoteOpts...)
+			if err != nil {
+				return err
+			}
+			imageDigests = []string{chart.ArchiveDigest.Hex}
+		}
+		if c.Recursive {
+			imageDigests, err = indexImageDigests(ctx, digest, ociremoteOpts...)
+			if err != nil {
+				return err
+			}
+		}
+		p, err := predicateFor(digest, v1.Platform{})
+		if err != nil {
+			return err
+		}
+		return c.attestDigest(ctx, digest, p, imageDigests, sv, dd, ociremoteOpts)
+	}
+
+	targets, err := indexPlatformDigests(digest, ociremoteOpts...)
 	if err != nil {
-		return fmt.Errorf("getting predicate reader: %w", err)
+		return err
 	}
-	defer predicate.Close()
+	for _, target := range targets {
+		p, err := predicateFor(target.digest, target.platform)
+		if err != nil {
+			return err
+		}
+		if err := c.attestDigest(ctx, target.digest, p, nil, sv, dd, ociremoteOpts); err != nil {
+			return fmt.Errorf("attesting %s: %w", target.digest, err)
+		}
+	}
+	return nil
+}
 
-	sh, err := attestation.GenerateStatement(attestation.GenerateOpts{
-		Predicate: predicate,
-		Type:      c.PredicateType,
-		Digest:    h.Hex,
-		Repo:      digest.Repository.String(),
-	})
+// attestDigest attaches an attestation of predicate to the image at digest,
+// whose statement additionally has the subjects of additionalDigests.
+func (c *AttestCommand) attestDigest(ctx context.Context, digest name.Digest, predicate []byte, additionalDigests []string, sv *sign.SignerVerifier, dd mutate.DupeDetector, ociremoteOpts []ociremote.Option) error {
+	h, _ := v1.NewHash(digest.Identifier())
+	wrapped := dsse.WrapSigner(sv, types.IntotoPayloadType)
+
+	generateOpts := attestation.GenerateOpts{
+		Predicate:         bytes.NewReader(predicate),
+		Type:              c.PredicateType,
+		Digest:            h.Hex,
+		Repo:              digest.Repository.String(),
+		AdditionalDigests: additionalDigests,
+	}
+	if c.Deterministic {
+		// Custom predicates are stamped with the zero time instead of now.
+		generateOpts.Time = func() time.Time { return time.Time{} }
+	}
+	sh, err := attestation.GenerateStatement(generateOpts)
 	if err != nil {
 		return err
 	}
@@ -160,6 +300,11 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 	if err != nil {
 		return err
 	}
+	if c.Deterministic {
+		if payload, err = jsoncanonicalizer.Transform(payload); err != nil {
+			return fmt.Errorf("canonicalizing statement: %w", err)
+		}
+	}
 	signedPayload, err := wrapped.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("signing: %w", err)
@@ -174,6 +319,10 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 	if sv.Cert != nil {
 		opts = append(opts, static.WithCertChain(sv.Cert, sv.Chain))
 	}
+	if c.Zstd {
+		opts = append(opts, static.WithZstdCompression())
+	}
+	var timestampBytes []byte
 	if c.KeyOpts.TSAServerURL != "" {
 		// TODO - change this when we implement protobuf / new bundle support
 		//
@@ -183,13 +332,26 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 		// to send to the timestamp authority based on our output format.
 		//
 		// See cmd/cosign/cli/attest/attest_blob.go
-		responseBytes, err := tsa.GetTimestampedSignature(signedPayload, tsaclient.NewTSAClient(c.KeyOpts.TSAServerURL))
+		tsaClients := []tsaclient.TimestampAuthorityClient{tsaclient.NewTSAClient(c.KeyOpts.TSAServerURL)}
+		for _, url := range c.KeyOpts.AdditionalTSAServerURLs {
+			tsaClients = append(tsaClients, tsaclient.NewTSAClient(url))
+		}
+		timestamps, err := tsa.GetTimestampedSignatures(ctx, signedPayload, tsaClients)
 		if err != nil {
 			return err
 		}
-		bundle := cbundle.TimestampToRFC3161Timestamp(responseBytes)
+		bundle := cbundle.TimestampsToRFC3161Timestamp(timestamps)
 
 		opts = append(opts, static.WithRFC3161Timestamp(bundle))
+
+		// The Sigstore bundle needs its own timestamp, of the DSSE
+		// signature rather than of the envelope.
+		if c.BundlePath != "" && c.NewBundleFormat {
+			timestampBytes, err = timestampEnvelopeSignature(signedPayload, c.KeyOpts.TSAServerURL)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	predicateType, err := options.ParsePredicateType(c.PredicateType)
@@ -198,7 +360,7 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 	}
 
 	predicateTypeAnnotation := map[string]string{
-		"predicateType": predicateType,
+		static.PredicateTypeAnnotationKey: predicateType,
 	}
 	// Add predicateType as manifest annotation
 	opts = append(opts, static.WithAnnotations(predicateTypeAnnotation))
@@ -208,8 +370,9 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 	if err != nil {
 		return fmt.Errorf("should upload to tlog: %w", err)
 	}
+	var rekorEntry *models.LogEntryAnon
 	if shouldUpload {
-		bundle, err := uploadToTlog(ctx, sv, c.RekorURL, func(r *client.Rekor, b []byte) (*models.LogEntryAnon, error) {
+		rekorEntry, err = uploadToTlog(ctx, sv, c.RekorURL, c.AdditionalRekorURLs, func(r *client.Rekor, b []byte) (*models.LogEntryAnon, error) {
 			if c.RekorEntryType == "intoto" {
 				return cosign.TLogUploadInTotoAttestation(ctx, r, signedPayload, b)
 			} else {
@@ -220,7 +383,13 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 		if err != nil {
 			return err
 		}
-		opts = append(opts, static.WithBundle(bundle))
+		opts = append(opts, static.WithBundle(cbundle.EntryToBundle(rekorEntry)))
+	}
+
+	if c.BundlePath != "" {
+		if err := c.writeBundle(ctx, digest, sv, payload, signedPayload, rekorEntry, timestampBytes); err != nil {
+			return err
+		}
 	}
 
 	sig, err := static.NewAttestation(signedPayload, opts...)
@@ -238,7 +407,7 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 	}
 
 	if c.Replace {
-		ro := cremote.NewReplaceOp(predicateURI)
+		ro := cremote.NewReplaceOp(predicateType)
 		signOpts = append(signOpts, mutate.WithReplaceOp(ro))
 	}
 
@@ -248,6 +417,157 @@ func (c *AttestCommand) Exec(ctx context.Context, imageRef string) error {
 		return err
 	}
 
+	// Publish the attestations associated with this entity (using OCI 1.1+ behavior)
+	if c.RegistryReferrersMode == options.RegistryReferrersModeOCI11 {
+		return ociremote.WriteAttestationsReferrers(digest, newSE, ociremoteOpts...)
+	}
+
 	// Publish the attestations associated with this entity
 	return ociremote.WriteAttestations(digest.Repository, newSE, ociremoteOpts...)
 }
+
+// writeBundle writes the bundle of the attestation to c.BundlePath, suffixed
+// with the digest of the image when attesting each image of an index.
+func (c *AttestCommand) writeBundle(ctx context.Context, digest name.Digest, sv *sign.SignerVerifier, payload, signedPayload []byte, rekorEntry *models.LogEntryAnon, timestampBytes []byte) error {
+	signer, err := sv.Bytes(ctx)
+	if err != nil {
+		return err
+	}
+	var contents []byte
+	if c.NewBundleFormat {
+		contents, err = makeNewBundle(sv, rekorEntry, payload, signedPayload, signer, timestampBytes)
+		if err != nil {
+			return err
+		}
+	} else {
+		signed := cosign.LocalSignedPayload{
+			Base64Signature: base64.StdEncoding.EncodeToString(signedPayload),
+			Cert:            base64.StdEncoding.EncodeToString(signer),
+		}
+		if rekorEntry != nil {
+			signed.Bundle = cbundle.EntryToBundle(rekorEntry)
+		}
+		contents, err = json.Marshal(signed)
+		if err != nil {
+			return err
+		}
+	}
+	bundlePath := c.BundlePath
+	if c.Recursive && !c.MultiSubject {
+		bundlePath = fmt.Sprintf("%s-%s", bundlePath, strings.Replace(digest.DigestStr(), ":", "-", 1))
+	}
+	if err := os.WriteFile(bundlePath, contents, 0600); err != nil {
+		return fmt.Errorf("create bundle file: %w", err)
+	}
+	ui.Infof(ctx, "Wrote bundle to file %s", bundlePath)
+	return nil
+}
+
+// timestampEnvelopeSignature returns an RFC3161 timestamp of the first
+// signature of the DSSE envelope, which Sigstore bundles carry.
+func timestampEnvelopeSignature(envelope []byte, tsaServerURL string) ([]byte, error) {
+	var env ssldsse.Envelope
+	if err := json.Unmarshal(envelope, &env); err != nil {
+		return nil, err
+	}
+	if len(env.Signatures) == 0 {
+		return nil, fmt.Errorf("envelope has no signatures")
+	}
+	sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+	if err != nil {
+		return nil, err
+	}
+	return tsa.GetTimestampedSignature(sig, tsaclient.NewTSAClient(tsaServerURL))
+}
+
+// indexImageDigests returns the hex digests of every image and image index
+// beneath the image index at digest, or nothing if digest is an image.
+func indexImageDigests(ctx context.Context, digest name.Digest, opts ...ociremote.Option) ([]string, error) {
+	se, err := ociremote.SignedEntity(digest, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("accessing entity: %w", err)
+	}
+	var digests []string
+	if err := walk.SignedEntity(ctx, se, func(_ context.Context, se oci.SignedEntity) error {
+		d, err := se.(interface{ Digest() (v1.Hash, error) }).Digest()
+		if err != nil {
+			return fmt.Errorf("computing digest: %w", err)
+		}
+		if d.String() != digest.DigestStr() {
+			digests = append(digests, d.Hex)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("walking image index: %w", err)
+	}
+	return digests, nil
+}
+
+// platformDigest is an image or image index to attest, with the platform
+// its parent image index lists it for.
+type platformDigest struct {
+	digest   name.Digest
+	platform v1.Platform
+}
+
+// indexPlatformDigests returns digest followed by every image and image index
+// beneath it, if it is an image index.
+func indexPlatformDigests(digest name.Digest, opts ...ociremote.Option) ([]platformDigest, error) {
+	se, err := ociremote.SignedEntity(digest, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("accessing entity: %w", err)
+	}
+	targets := []platformDigest{{digest: digest}}
+	idx, ok := se.(oci.SignedImageIndex)
+	if !ok {
+		return targets, nil
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading index manifest: %w", err)
+	}
+	for _, desc := range im.Manifests {
+		child := digest.Context().Digest(desc.Digest.String())
+		if desc.MediaType.IsIndex() {
+			children, err := indexPlatformDigests(child, opts...)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, children...)
+			continue
+		}
+		target := platformDigest{digest: child}
+		if desc.Platform != nil {
+			target.platform = *desc.Platform
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// predicateTemplateData is what the predicate is rendered with when
+// c.PredicateTemplate is set.
+type predicateTemplateData struct {
+	// Digest is the digest of the attested image, e.g. sha256:abc...
+	Digest string
+	// Platform is the platform the image index lists the image for, which is
+	// empty for image indexes and images outside of one.
+	Platform v1.Platform
+}
+
+// renderPredicate renders the predicate as a Go template for the image at
+// digest, if c.PredicateTemplate is set.
+func (c *AttestCommand) renderPredicate(predicate []byte, digest name.Digest, platform v1.Platform) ([]byte, error) {
+	if !c.PredicateTemplate {
+		return predicate, nil
+	}
+	tmpl, err := template.New("predicate").Option("missingkey=error").Parse(string(predicate))
+	if err != nil {
+		return nil, fmt.Errorf("parsing predicate template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, predicateTemplateData{Digest: digest.DigestStr(), Platform: platform}); err != nil {
+		return nil, fmt.Errorf("rendering predicate template for %s: %w", digest, err)
+	}
+	return b.Bytes(), nil
+}
@@ -31,6 +31,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/pkg/errors"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -40,10 +41,14 @@ import (
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/client"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/blob"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/attestation"
 	cbundle "github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/helm"
 	"github.com/franchb/cosign/v2/pkg/types"
+	rekorclient "github.com/franchb/rekor/pkg/generated/client"
 	"github.com/franchb/rekor/pkg/generated/models"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/franchb/sigstore/pkg/signature"
@@ -60,6 +65,9 @@ type AttestBlobCommand struct {
 	CertChainPath string
 
 	ArtifactHash string
+	// Directory is the directory whose files are attested, each as a
+	// subject named by its path in the directory, instead of a blob.
+	Directory string
 
 	PredicatePath string
 	PredicateType string
@@ -95,15 +103,23 @@ func (c *AttestBlobCommand) Exec(ctx context.Context, artifactPath string) error
 		defer cancelFn()
 	}
 
-	if c.TSAServerURL != "" && c.RFC3161TimestampPath == "" && !c.NewBundleFormat {
-		return errors.New("expected either new bundle or an rfc3161-timestamp path when using a TSA server")
+	if c.TSAServerURL != "" && c.RFC3161TimestampPath == "" && c.BundlePath == "" && !c.NewBundleFormat {
+		return errors.New("expected either a bundle or an rfc3161-timestamp path when using a TSA server")
 	}
 
 	var artifact []byte
 	var hexDigest string
+	var subjects []in_toto.Subject
 	var err error
 
-	if c.ArtifactHash == "" {
+	if c.Directory != "" {
+		if c.ArtifactHash != "" {
+			return errors.New("--hash cannot be used with --directory")
+		}
+		if subjects, err = directorySubjects(c.Directory); err != nil {
+			return err
+		}
+	} else if c.ArtifactHash == "" {
 		if artifactPath == "-" {
 			artifact, err = io.ReadAll(os.Stdin)
 		} else {
@@ -115,13 +131,16 @@ func (c *AttestBlobCommand) Exec(ctx context.Context, artifactPath string) error
 		}
 	}
 
-	if c.ArtifactHash == "" {
+	switch {
+	case c.Directory != "":
+		// Each file of the directory is a subject.
+	case c.ArtifactHash == "":
 		digest, _, err := signature.ComputeDigestForSigning(bytes.NewReader(artifact), crypto.SHA256, []crypto.Hash{crypto.SHA256, crypto.SHA384})
 		if err != nil {
 			return err
 		}
 		hexDigest = strings.ToLower(hex.EncodeToString(digest))
-	} else {
+	default:
 		hexDigest = c.ArtifactHash
 	}
 
@@ -139,12 +158,18 @@ func (c *AttestBlobCommand) Exec(ctx context.Context, artifactPath string) error
 	wrapped := sigstoredsse.WrapSigner(sv, types.IntotoPayloadType)
 
 	base := path.Base(artifactPath)
+	if c.Directory != "" {
+		base = ""
+	} else if chart, err := helm.ReadChart(bytes.NewReader(artifact)); err == nil {
+		ui.Infof(ctx, "Attesting version %s of Helm chart %s", chart.Version, chart.Name)
+	}
 
 	sh, err := attestation.GenerateStatement(attestation.GenerateOpts{
 		Predicate: predicate,
 		Type:      c.PredicateType,
 		Digest:    hexDigest,
 		Repo:      base,
+		Subjects:  subjects,
 	})
 	if err != nil {
 		return err
@@ -238,7 +263,18 @@ func (c *AttestBlobCommand) Exec(ctx context.Context, artifactPath string) error
 			return err
 		}
 		fmt.Fprintln(os.Stderr, "tlog entry created with index:", *rekorEntry.LogIndex)
+		if err := rekor.UploadToAdditionalLogs(ctx, c.RekorURL, c.AdditionalRekorURLs, func(r *rekorclient.Rekor) (*models.LogEntryAnon, error) {
+			if c.RekorEntryType == "intoto" {
+				return cosign.TLogUploadInTotoAttestation(ctx, r, sig, signer)
+			}
+			return cosign.TLogUploadDSSEEnvelope(ctx, r, sig, signer)
+		}); err != nil {
+			return err
+		}
 		signedPayload.Bundle = cbundle.EntryToBundle(rekorEntry)
+		if rekorEntry.Verification != nil {
+			signedPayload.InclusionProof = rekorEntry.Verification.InclusionProof
+		}
 	}
 
 	if c.BundlePath != "" {
@@ -251,6 +287,10 @@ func (c *AttestBlobCommand) Exec(ctx context.Context, artifactPath string) error
 		} else {
 			signedPayload.Base64Signature = base64.StdEncoding.EncodeToString(sig)
 			signedPayload.Cert = base64.StdEncoding.EncodeToString(signer)
+			if len(sv.Chain) > 0 {
+				signedPayload.CertChain = base64.StdEncoding.EncodeToString(sv.Chain)
+			}
+			signedPayload.RFC3161Timestamp = rfc3161Timestamp
 
 			contents, err = json.Marshal(signedPayload)
 			if err != nil {
@@ -366,3 +406,21 @@ func makeNewBundle(sv *sign.SignerVerifier, rekorEntry *models.LogEntryAnon, pay
 
 	return contents, nil
 }
+
+// directorySubjects returns the files of dir as in-toto subjects, named by
+// their paths in dir.
+func directorySubjects(dir string) ([]in_toto.Subject, error) {
+	fmt.Fprintln(os.Stderr, "Using files from directory:", dir)
+	files, err := blob.HashDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	subjects := make([]in_toto.Subject, 0, len(files))
+	for _, f := range files {
+		subjects = append(subjects, in_toto.Subject{
+			Name:   f.Path,
+			Digest: map[string]string{"sha256": f.SHA256},
+		})
+	}
+	return subjects, nil
+}
@@ -25,6 +25,7 @@ import (
 	"encoding/pem"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -275,6 +276,66 @@ func TestAttestBlob(t *testing.T) {
 	}
 }
 
+// TestAttestBlobDirectory tests that the files of a directory are the
+// subjects of the statement, named by their paths in the directory.
+func TestAttestBlobDirectory(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	keys, _ := cosign.GenerateKeyPair(nil)
+	keyRef := writeFile(t, td, string(keys.PrivateBytes), "key.pem")
+	predicatePath := makeSLSA02PredicateFile(t, td)
+
+	dir := filepath.Join(td, "dist")
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "readme", "README.md")
+	writeFile(t, filepath.Join(dir, "bin"), "tool", "tool")
+
+	dssePath := filepath.Join(td, "dsse.intoto.jsonl")
+	at := AttestBlobCommand{
+		KeyOpts:         options.KeyOpts{KeyRef: keyRef},
+		PredicatePath:   predicatePath,
+		PredicateType:   "slsaprovenance",
+		OutputSignature: dssePath,
+		RekorEntryType:  "dsse",
+		Directory:       dir,
+	}
+	if err := at.Exec(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	dsseBytes, _ := os.ReadFile(dssePath)
+	env := &ssldsse.Envelope{}
+	if err := json.Unmarshal(dsseBytes, env); err != nil {
+		t.Fatal(err)
+	}
+	decodedPredicate, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatalf("decoding dsse payload: %v", err)
+	}
+	var statement in_toto.Statement
+	if err := json.Unmarshal(decodedPredicate, &statement); err != nil {
+		t.Fatalf("decoding predicate: %v", err)
+	}
+	want := []in_toto.Subject{{
+		Name:   "README.md",
+		Digest: map[string]string{"sha256": "711a6108ba2ce6ca93dd47d6817f2361db10d8ab6eec89460b2dfc2c325efabe"},
+	}, {
+		Name:   "bin/tool",
+		Digest: map[string]string{"sha256": "7c9bbe5ec9b3fb774e8fa0f54247e93c34ddf8e5d16fe3073420de0ae81a262d"},
+	}}
+	if !reflect.DeepEqual(statement.Subject, want) {
+		t.Fatalf("subjects = %v, want %v", statement.Subject, want)
+	}
+
+	at.ArtifactHash = "deadbeef"
+	if err := at.Exec(ctx, ""); err == nil {
+		t.Fatal("attesting a directory with a hash succeeded, want error")
+	}
+}
+
 func TestBadRekorEntryType(t *testing.T) {
 	ctx := context.Background()
 	td := t.TempDir()
@@ -0,0 +1,295 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/types"
+)
+
+func TestAttestBundle(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/app:latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	h, err := img.Digest()
+	require.NoError(t, err)
+
+	keys, err := cosign.GenerateKeyPair(nil)
+	require.NoError(t, err)
+	keyRef := writeFile(t, td, string(keys.PrivateBytes), "key.pem")
+	predicatePath := makeSLSA02PredicateFile(t, td)
+
+	attest := func(t *testing.T, newBundleFormat bool) []byte {
+		t.Helper()
+		bundlePath := filepath.Join(t.TempDir(), "bundle.json")
+		at := AttestCommand{
+			KeyOpts:        options.KeyOpts{KeyRef: keyRef, BundlePath: bundlePath, NewBundleFormat: newBundleFormat},
+			PredicatePath:  predicatePath,
+			PredicateType:  "slsaprovenance",
+			RekorEntryType: "dsse",
+		}
+		require.NoError(t, at.Exec(ctx, ref.String()))
+		b, err := os.ReadFile(bundlePath)
+		require.NoError(t, err)
+		return b
+	}
+
+	t.Run("legacy", func(t *testing.T) {
+		var signed cosign.LocalSignedPayload
+		require.NoError(t, json.Unmarshal(attest(t, false), &signed))
+		envelope, err := base64.StdEncoding.DecodeString(signed.Base64Signature)
+		require.NoError(t, err)
+		require.Contains(t, string(envelope), types.IntotoPayloadType)
+		require.Nil(t, signed.Bundle)
+	})
+
+	t.Run("sigstore", func(t *testing.T) {
+		var bundle protobundle.Bundle
+		require.NoError(t, protojson.Unmarshal(attest(t, true), &bundle))
+		require.Equal(t, "application/vnd.dev.sigstore.bundle.v0.3+json", bundle.MediaType)
+		require.NotEmpty(t, bundle.GetVerificationMaterial().GetPublicKey().GetHint())
+
+		envelope := bundle.GetDsseEnvelope()
+		require.NotNil(t, envelope)
+		require.Equal(t, types.IntotoPayloadType, envelope.PayloadType)
+		require.Len(t, envelope.Signatures, 1)
+		var statement in_toto.Statement
+		require.NoError(t, json.Unmarshal(envelope.Payload, &statement))
+		require.Len(t, statement.Subject, 1)
+		require.Equal(t, h.Hex, statement.Subject[0].Digest["sha256"])
+	})
+}
+
+func TestAttestRecursive(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	var adds []ggcrmutate.IndexAddendum
+	for _, arch := range []string{"amd64", "arm64"} {
+		img, err := random.Image(1024, 1)
+		require.NoError(t, err)
+		adds = append(adds, ggcrmutate.IndexAddendum{
+			Add: img,
+			Descriptor: v1.Descriptor{
+				Platform: &v1.Platform{OS: "linux", Architecture: arch},
+			},
+		})
+	}
+	idx := ggcrmutate.AppendManifests(empty.Index, adds...)
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/app:latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, idx))
+	im, err := idx.IndexManifest()
+	require.NoError(t, err)
+
+	keys, err := cosign.GenerateKeyPair(nil)
+	require.NoError(t, err)
+	keyRef := writeFile(t, td, string(keys.PrivateBytes), "key.pem")
+	predicatePath := writeFile(t, td, `{"digest": "{{.Digest}}", "arch": "{{.Platform.Architecture}}"}`, "predicate.json")
+
+	at := AttestCommand{
+		KeyOpts:           options.KeyOpts{KeyRef: keyRef},
+		PredicatePath:     predicatePath,
+		PredicateType:     "custom",
+		RekorEntryType:    "dsse",
+		Recursive:         true,
+		PredicateTemplate: true,
+	}
+	require.NoError(t, at.Exec(ctx, ref.String()))
+
+	h, err := idx.Digest()
+	require.NoError(t, err)
+	want := map[string]string{h.String(): ""}
+	for _, desc := range im.Manifests {
+		want[desc.Digest.String()] = desc.Platform.Architecture
+	}
+	for digest, arch := range want {
+		se, err := ociremote.SignedEntity(ref.Context().Digest(digest))
+		require.NoError(t, err)
+		atts, err := se.Attestations()
+		require.NoError(t, err)
+		sigs, err := atts.Get()
+		require.NoError(t, err)
+		require.Len(t, sigs, 1, digest)
+
+		envelope, err := sigs[0].Payload()
+		require.NoError(t, err)
+		var env struct {
+			Payload string `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(envelope, &env))
+		payload, err := base64.StdEncoding.DecodeString(env.Payload)
+		require.NoError(t, err)
+		var statement struct {
+			in_toto.StatementHeader
+			Predicate struct {
+				Data string `json:"Data"`
+			} `json:"predicate"`
+		}
+		require.NoError(t, json.Unmarshal(payload, &statement))
+		require.Len(t, statement.Subject, 1)
+		require.Equal(t, strings.TrimPrefix(digest, "sha256:"), statement.Subject[0].Digest["sha256"])
+		require.JSONEq(t, `{"digest": "`+digest+`", "arch": "`+arch+`"}`, statement.Predicate.Data)
+	}
+}
+
+func TestAttestPredicateGenerator(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	keys, err := cosign.GenerateKeyPair(nil)
+	require.NoError(t, err)
+	keyRef := writeFile(t, td, string(keys.PrivateBytes), "key.pem")
+	predicatePath := makeSLSA02PredicateFile(t, td)
+
+	for _, tc := range []struct {
+		name    string
+		cmd     AttestCommand
+		ref     string
+		wantErr string
+	}{{
+		name:    "no predicate",
+		cmd:     AttestCommand{PredicateType: "spdxjson"},
+		wantErr: "predicate cannot be empty",
+	}, {
+		name:    "predicate and generator",
+		cmd:     AttestCommand{PredicatePath: predicatePath, PredicateGenerator: "syft", PredicateType: "spdxjson"},
+		wantErr: "--predicate cannot be used with --predicate-gen",
+	}, {
+		name:    "unknown generator",
+		cmd:     AttestCommand{PredicateGenerator: "unknown", PredicateType: "spdxjson"},
+		wantErr: `unknown SBOM generator "unknown"`,
+	}, {
+		name:    "not an SBOM",
+		cmd:     AttestCommand{PredicateGenerator: "syft", PredicateType: "slsaprovenance"},
+		wantErr: "--predicate-gen generates spdxjson or cyclonedx predicates, not slsaprovenance",
+	}, {
+		name:    "template",
+		cmd:     AttestCommand{PredicateGenerator: "syft", PredicateType: "cyclonedx", PredicateTemplate: true},
+		wantErr: "--predicate-template cannot be used with --predicate-gen",
+	}, {
+		name:    "Helm chart",
+		cmd:     AttestCommand{PredicateGenerator: "syft", PredicateType: "spdxjson"},
+		ref:     "helm://registry.example.com/charts/app:1.0.0",
+		wantErr: "--predicate-gen cannot be used with a Helm chart",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cmd.KeyOpts = options.KeyOpts{KeyRef: keyRef}
+			tc.cmd.RekorEntryType = "dsse"
+			ref := tc.ref
+			if ref == "" {
+				ref = "registry.example.com/app:latest"
+			}
+			require.ErrorContains(t, tc.cmd.Exec(ctx, ref), tc.wantErr)
+		})
+	}
+}
+
+func TestAttestDeterministic(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/app:latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	// ed25519 signs deterministically whatever Go cosign is built with.
+	keys, err := cosign.GenerateKeyPairWithOptions(cosign.KeyPairOptions{KeyType: cosign.KeyTypeED25519}, nil)
+	require.NoError(t, err)
+	keyRef := writeFile(t, td, string(keys.PrivateBytes), "key.pem")
+	predicatePath := writeFile(t, td, `{"b": 2, "a": 1}`, "predicate.json")
+
+	attest := func(t *testing.T) cosign.LocalSignedPayload {
+		t.Helper()
+		bundlePath := filepath.Join(t.TempDir(), "bundle.json")
+		at := AttestCommand{
+			KeyOpts:        options.KeyOpts{KeyRef: keyRef, BundlePath: bundlePath, Deterministic: true},
+			PredicatePath:  predicatePath,
+			PredicateType:  "custom",
+			RekorEntryType: "dsse",
+		}
+		require.NoError(t, at.Exec(ctx, ref.String()))
+		b, err := os.ReadFile(bundlePath)
+		require.NoError(t, err)
+		var signed cosign.LocalSignedPayload
+		require.NoError(t, json.Unmarshal(b, &signed))
+		return signed
+	}
+
+	signed := attest(t)
+	require.Equal(t, signed.Base64Signature, attest(t).Base64Signature)
+
+	// The statement is canonical, with the custom predicate stamped with the zero time.
+	b, err := base64.StdEncoding.DecodeString(signed.Base64Signature)
+	require.NoError(t, err)
+	var envelope ssldsse.Envelope
+	require.NoError(t, json.Unmarshal(b, &envelope))
+	payload, err := envelope.DecodeB64Payload()
+	require.NoError(t, err)
+	canonical, err := jsoncanonicalizer.Transform(payload)
+	require.NoError(t, err)
+	require.Equal(t, string(canonical), string(payload))
+	require.Contains(t, string(payload), `"Timestamp":"0001-01-01T00:00:00Z"`)
+
+	for _, at := range []AttestCommand{
+		{KeyOpts: options.KeyOpts{KeyRef: keyRef, Deterministic: true, TSAServerURL: "https://tsa.example.com/tsr"}},
+		{KeyOpts: options.KeyOpts{KeyRef: keyRef, Deterministic: true}, RecordCreationTimestamp: true},
+	} {
+		at.PredicatePath = predicatePath
+		at.PredicateType = "custom"
+		at.RekorEntryType = "dsse"
+		require.ErrorContains(t, at.Exec(ctx, ref.String()), "--deterministic cannot be combined")
+	}
+}
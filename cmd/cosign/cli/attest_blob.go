@@ -41,13 +41,34 @@ func AttestBlob() *cobra.Command {
   # attach an attestation to a blob with a key pair stored in Google Cloud KMS
   cosign attest-blob --predicate <FILE> --type <TYPE> --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY]/versions/[VERSION] <BLOB>
 
+  # attach an attestation to a blob with a key pair stored in Oracle Cloud Infrastructure Vault
+  cosign attest-blob --predicate <FILE> --type <TYPE> --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <BLOB>
+
+  # attach an attestation to a blob with a key pair stored in Alibaba Cloud KMS
+  cosign attest-blob --predicate <FILE> --type <TYPE> --key alibabakms://[REGION]/[KEY_ID] <BLOB>
+
+  # attach an attestation to a blob with a key pair stored in Tencent Cloud KMS
+  cosign attest-blob --predicate <FILE> --type <TYPE> --key tencentkms://[REGION]/[KEY_ID] <BLOB>
+
   # attach an attestation to a blob with a key pair stored in Hashicorp Vault
   cosign attest-blob --predicate <FILE> --type <TYPE> --key hashivault://[KEY] <BLOB>
 
+  # attach an attestation to a blob, writing a bundle that can be verified offline with its tlog inclusion proof and RFC3161 timestamp
+  cosign attest-blob --predicate <FILE> --type <TYPE> --key cosign.key --bundle <BLOB>.bundle --timestamp-server-url https://freetsa.org/tsr <BLOB>
+
+  # attest every file of a release directory at once, each as a subject named by its path in it
+  cosign attest-blob --predicate <FILE> --type <TYPE> --key cosign.key --bundle dist.bundle --directory ./dist
+
   # supply attestation via stdin
   echo <PAYLOAD> | cosign attest-blob --predicate - --yes`,
 
-		Args:             cobra.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			// A directory replaces the blob.
+			if o.Directory != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			oidcClientSecret, err := o.OIDC.ClientSecret()
@@ -63,7 +84,9 @@ func AttestBlob() *cobra.Command {
 				IDToken:                  o.Fulcio.IdentityToken,
 				FulcioAuthFlow:           o.Fulcio.AuthFlow,
 				InsecureSkipFulcioVerify: o.Fulcio.InsecureSkipFulcioVerify,
+				CertificateProvider:      o.Fulcio.CertificateProvider,
 				RekorURL:                 o.Rekor.URL,
+				AdditionalRekorURLs:      o.Rekor.AdditionalURLs,
 				OIDCIssuer:               o.OIDC.Issuer,
 				OIDCClientID:             o.OIDC.ClientID,
 				OIDCClientSecret:         oidcClientSecret,
@@ -80,6 +103,7 @@ func AttestBlob() *cobra.Command {
 				CertPath:          o.Cert,
 				CertChainPath:     o.CertChain,
 				ArtifactHash:      o.Hash,
+				Directory:         o.Directory,
 				TlogUpload:        o.TlogUpload,
 				PredicateType:     o.Predicate.Type,
 				PredicatePath:     o.Predicate.Path,
@@ -89,7 +113,11 @@ func AttestBlob() *cobra.Command {
 				Timeout:           ro.Timeout,
 				RekorEntryType:    o.RekorEntryType,
 			}
-			return v.Exec(cmd.Context(), args[0])
+			var artifactPath string
+			if len(args) > 0 {
+				artifactPath = args[0]
+			}
+			return v.Exec(cmd.Context(), artifactPath)
 		},
 	}
 	o.AddFlags(cmd)
@@ -0,0 +1,83 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/verify"
+	"github.com/franchb/cosign/v2/internal/ui"
+)
+
+func Audit() *cobra.Command {
+	o := &options.AuditOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Print a chronological trust timeline of every signature and attestation on the supplied container image",
+		Long: `Verify every signature and attestation on an image, rather than stopping
+at the first that verifies, and print them as a timeline ordered by their
+transparency log integrated time or signed timestamp.
+
+Each entry shows whether it verified, and signatures whose certificate was
+valid when they were made but would no longer be accepted now, e.g. because
+the certificate or its issuer has since expired, are flagged. Entries without
+a verified timestamp are listed last.`,
+		Example: `  cosign audit --key <key path>|<key url>|<kms uri> <IMAGE>
+
+  # audit keyless signatures made by an identity
+  cosign audit --certificate-identity=name@example.com --certificate-oidc-issuer=https://accounts.example.com <IMAGE>
+
+  # print the timeline as JSON
+  cosign audit --key cosign.pub --output json <IMAGE>`,
+		Args:             cobra.MinimumNArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a := &verify.AuditCommand{
+				RegistryOptions:     o.Registry,
+				CertVerifyOptions:   o.CertVerify,
+				KeyRef:              o.Key,
+				Sk:                  o.SecurityKey.Use,
+				Slot:                o.SecurityKey.Slot,
+				Output:              o.Output,
+				RekorURL:            o.Rekor.URL,
+				Offline:             o.CommonVerifyOptions.Offline,
+				TSACertChainPath:    o.CommonVerifyOptions.TSACertChainPath,
+				UseSignedTimestamps: o.CommonVerifyOptions.UseSignedTimestamps,
+				IgnoreTlog:          o.CommonVerifyOptions.IgnoreTlog,
+			}
+			if o.Registry.AllowInsecure {
+				a.NameOptions = append(a.NameOptions, name.Insecure)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), ro.Timeout)
+			defer cancel()
+
+			if o.CommonVerifyOptions.IgnoreTlog && !o.CommonVerifyOptions.PrivateInfrastructure {
+				ui.Warnf(ctx, fmt.Sprintf(ignoreTLogMessage, "signature"))
+			}
+
+			return a.Exec(ctx, args)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
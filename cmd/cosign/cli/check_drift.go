@@ -0,0 +1,214 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+)
+
+func CheckDrift() *cobra.Command {
+	o := &options.CheckDriftOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "check-drift",
+		Short: "Find the signatures of deleted images, and the images without signatures, of a repository",
+		Long: `Compare the images of a repository with the signatures, attestations and SBOMs
+stored for them, which are kept in the repository named by COSIGN_REPOSITORY
+when it is set, and report how they have drifted apart:
+
+  - orphaned: signatures, attestations and SBOMs of images that no longer
+    exist, which deleting an image leaves behind in a separate repository
+  - unsigned: tagged images without a signature
+  - misplaced: signatures, attestations and SBOMs stored alongside the
+    images although COSIGN_REPOSITORY points elsewhere, which cosign does
+    not find
+
+The command fails if any drift is found. With --prune, the orphaned tags are
+removed and no longer count as drift.`,
+		Example: `  COSIGN_REPOSITORY=example.com/signatures cosign check-drift <REPOSITORY>
+
+  # remove the signatures, attestations and SBOMs of deleted images
+  COSIGN_REPOSITORY=example.com/signatures cosign check-drift --prune <REPOSITORY>
+
+  # report the drift as JSON
+  COSIGN_REPOSITORY=example.com/signatures cosign check-drift --output json <REPOSITORY>`,
+		Args:             argsOrOutputSchema(&o.OutputSchema, cobra.ExactArgs(1)),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.OutputSchema.OutputSchema {
+				return printOutputSchema(schema.CheckDrift)
+			}
+			return CheckDriftCmd(cmd.Context(), o.Registry, args[0], o.Output, o.Prune, o.Force)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+// CheckDriftOutput is the JSON output of cosign check-drift, see
+// schema.CheckDrift.
+type CheckDriftOutput struct {
+	Repository          string             `json:"repository"`
+	SignatureRepository string             `json:"signatureRepository"`
+	Orphaned            []CheckDriftOrphan `json:"orphaned"`
+	Unsigned            []CheckDriftImage  `json:"unsigned"`
+	Misplaced           []string           `json:"misplaced"`
+}
+
+// CheckDriftOrphan is a tag holding the signatures, attestations or SBOM of
+// an image that no longer exists.
+type CheckDriftOrphan struct {
+	Tag string `json:"tag"`
+	// Type is signature, attestation or sbom.
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+}
+
+// CheckDriftImage is an image or image index without a signature.
+type CheckDriftImage struct {
+	Digest string   `json:"digest"`
+	Tags   []string `json:"tags"`
+}
+
+// CheckDriftCmd reports how the signatures, attestations and SBOMs stored
+// for the images of repository have drifted apart from them, see
+// ociremote.CheckDrift, and fails if they have. With prune, those of images
+// that no longer exist are removed, and no longer count as drift.
+func CheckDriftCmd(ctx context.Context, regOpts options.RegistryOptions, repository, output string, prune, force bool) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
+	repo, err := name.NewRepository(repository, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("constructing client options: %w", err)
+	}
+	drift, err := ociremote.CheckDrift(repo, ociremoteOpts...)
+	if err != nil {
+		return fmt.Errorf("checking drift of %s: %w", repo, err)
+	}
+	if err := printDrift(os.Stdout, output, checkDriftOutput(repo, drift)); err != nil {
+		return err
+	}
+
+	if prune && len(drift.Orphaned) > 0 {
+		if !force {
+			ui.Warnf(ctx, "this will remove the %d orphaned tags listed above from %s", len(drift.Orphaned), drift.SignatureRepository)
+			if err := ui.ConfirmContinue(ctx); err != nil {
+				return err
+			}
+		}
+		remoteOpts := regOpts.GetRegistryClientOpts(ctx)
+		for _, o := range drift.Orphaned {
+			deleteTag(o.Tag, o.Subject.String(), remoteOpts...)
+		}
+		drift.Orphaned = nil
+	}
+
+	var found []string
+	if n := len(drift.Orphaned); n > 0 {
+		found = append(found, fmt.Sprintf("%d orphaned", n))
+	}
+	if n := len(drift.Unsigned); n > 0 {
+		found = append(found, fmt.Sprintf("%d unsigned", n))
+	}
+	if n := len(drift.Misplaced); n > 0 {
+		found = append(found, fmt.Sprintf("%d misplaced", n))
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("%s has drifted from its signatures in %s: %s", repo, drift.SignatureRepository, strings.Join(found, ", "))
+	}
+	return nil
+}
+
+func checkDriftOutput(repo name.Repository, drift *ociremote.Drift) CheckDriftOutput {
+	out := CheckDriftOutput{
+		Repository:          repo.String(),
+		SignatureRepository: drift.SignatureRepository.String(),
+		Orphaned:            []CheckDriftOrphan{},
+		Unsigned:            []CheckDriftImage{},
+		Misplaced:           []string{},
+	}
+	for _, o := range drift.Orphaned {
+		out.Orphaned = append(out.Orphaned, CheckDriftOrphan{
+			Tag:     o.Tag.String(),
+			Type:    artifactTypeOfSuffix(o.Suffix),
+			Subject: o.Subject.DigestStr(),
+		})
+	}
+	for _, td := range drift.Unsigned {
+		out.Unsigned = append(out.Unsigned, CheckDriftImage{Digest: td.Digest.DigestStr(), Tags: td.Tags})
+	}
+	for _, t := range drift.Misplaced {
+		out.Misplaced = append(out.Misplaced, t.String())
+	}
+	return out
+}
+
+// artifactTypeOfSuffix returns what the tags with suffix hold.
+func artifactTypeOfSuffix(suffix string) string {
+	switch suffix {
+	case ociremote.AttestationTagSuffix:
+		return cosign.Attestation
+	case ociremote.SBOMTagSuffix:
+		return cosign.SBOM
+	default:
+		return cosign.Signature
+	}
+}
+
+func printDrift(w io.Writer, output string, out CheckDriftOutput) error {
+	if output == "json" {
+		b, err := json.Marshal(out)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	if len(out.Orphaned)+len(out.Unsigned)+len(out.Misplaced) == 0 {
+		fmt.Fprintf(w, "No drift found between %s and its signatures in %s\n", out.Repository, out.SignatureRepository)
+		return nil
+	}
+	for _, o := range out.Orphaned {
+		fmt.Fprintf(w, "orphaned: %s (%s no longer exists)\n", o.Tag, o.Subject)
+	}
+	for _, i := range out.Unsigned {
+		fmt.Fprintf(w, "unsigned: %s@%s (%s)\n", out.Repository, i.Digest, strings.Join(i.Tags, ", "))
+	}
+	for _, t := range out.Misplaced {
+		fmt.Fprintf(w, "misplaced: %s (signatures are stored in %s)\n", t, out.SignatureRepository)
+	}
+	return nil
+}
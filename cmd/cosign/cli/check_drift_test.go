@@ -0,0 +1,70 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+)
+
+func TestPrintDrift(t *testing.T) {
+	repo := name.MustParseReference("example.com/app").Context()
+	sigRepo := name.MustParseReference("example.com/sigs").Context()
+	gone := repo.Digest("sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	unsigned := repo.Digest("sha256:a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447")
+	drift := &ociremote.Drift{
+		SignatureRepository: sigRepo,
+		Orphaned: []ociremote.OrphanedTag{{
+			Tag:     sigRepo.Tag("sha256-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.att"),
+			Suffix:  ociremote.AttestationTagSuffix,
+			Subject: gone,
+		}},
+		Unsigned:  []ociremote.TaggedDigest{{Digest: unsigned, Tags: []string{"latest", "v1"}}},
+		Misplaced: []name.Tag{repo.Tag("sha256-a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447.sig")},
+	}
+
+	var b bytes.Buffer
+	if err := printDrift(&b, "json", checkDriftOutput(repo, drift)); err != nil {
+		t.Fatalf("printDrift() = %v", err)
+	}
+	if err := schema.Validate(schema.CheckDrift, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	// No drift is empty arrays rather than null.
+	b.Reset()
+	if err := printDrift(&b, "json", checkDriftOutput(repo, &ociremote.Drift{SignatureRepository: sigRepo})); err != nil {
+		t.Fatalf("printDrift() = %v", err)
+	}
+	if err := schema.Validate(schema.CheckDrift, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	b.Reset()
+	if err := printDrift(&b, "text", checkDriftOutput(repo, drift)); err != nil {
+		t.Fatalf("printDrift() = %v", err)
+	}
+	want := "orphaned: " + drift.Orphaned[0].Tag.String() + " (" + gone.DigestStr() + " no longer exists)\n" +
+		"unsigned: " + unsigned.String() + " (latest, v1)\n" +
+		"misplaced: " + drift.Misplaced[0].String() + " (signatures are stored in example.com/sigs)\n"
+	if got := b.String(); got != want {
+		t.Errorf("printDrift() = %q, wanted %q", got, want)
+	}
+}
@@ -19,16 +19,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 )
 
@@ -36,13 +44,47 @@ func Clean() *cobra.Command {
 	c := &options.CleanOptions{}
 
 	cmd := &cobra.Command{
-		Use:              "clean",
-		Short:            "Remove all signatures from an image.",
-		Example:          "  cosign clean <IMAGE>",
+		Use:   "clean",
+		Short: "Remove all signatures from an image.",
+		Example: `  cosign clean <IMAGE>
+
+  # remove all but the 3 most recently attached signatures
+  cosign clean --type signature --keep-latest 3 <IMAGE>
+
+  # remove signatures and attestations logged more than 30 days ago
+  cosign clean --older-than 720h <IMAGE>
+
+  # remove a single compromised signature, by the digest of its layer as listed by cosign tree
+  cosign clean --type signature --signature-digest sha256:<DIGEST> <IMAGE>
+
+  # list, then remove, the signatures, attestations and SBOMs of deleted images of a repository
+  cosign clean --orphans --dry-run <REPOSITORY>
+  cosign clean --orphans <REPOSITORY>
+
+  # remove attestations, except those a retention policy keeps or other attestations name as their subject
+  cosign clean --type attestation --retention-policy retention.json <IMAGE>`,
 		Args:             cobra.ExactArgs(1),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return CleanCmd(cmd.Context(), c.Registry, c.CleanType, args[0], c.Force)
+			if c.Orphans {
+				if c.SignatureDigest != "" || c.KeepLatest > 0 || c.OlderThan > 0 {
+					return errors.New("--orphans cannot be combined with --signature-digest, --keep-latest or --older-than")
+				}
+				return CleanOrphansCmd(cmd.Context(), c.Registry, c.Retention, c.CleanType, args[0], c.DryRun, c.Force)
+			}
+			if c.DryRun {
+				return errors.New("--dry-run can only be used with --orphans")
+			}
+			if c.SignatureDigest != "" {
+				if c.KeepLatest > 0 || c.OlderThan > 0 {
+					return errors.New("--signature-digest cannot be combined with --keep-latest or --older-than")
+				}
+				return RemoveSignatureCmd(cmd.Context(), c.Registry, c.Retention, c.CleanType, args[0], c.SignatureDigest, c.Force)
+			}
+			if c.KeepLatest > 0 || c.OlderThan > 0 {
+				return PruneCmd(cmd.Context(), c.Registry, c.Retention, c.CleanType, args[0], c.KeepLatest, c.OlderThan, c.Force)
+			}
+			return CleanCmd(cmd.Context(), c.Registry, c.Retention, c.CleanType, args[0], c.Force)
 		},
 	}
 
@@ -50,7 +92,7 @@ func Clean() *cobra.Command {
 	return cmd
 }
 
-func CleanCmd(ctx context.Context, regOpts options.RegistryOptions, cleanType options.CleanType, imageRef string, force bool) error {
+func CleanCmd(ctx context.Context, regOpts options.RegistryOptions, retention options.RetentionOptions, cleanType options.CleanType, imageRef string, force bool) error {
 	if !force {
 		ui.Warnf(ctx, prompt(cleanType))
 		if err := ui.ConfirmContinue(ctx); err != nil {
@@ -93,36 +135,419 @@ func CleanCmd(ctx context.Context, regOpts options.RegistryOptions, cleanType op
 		panic("invalid CleanType value")
 	}
 
+	if cleansAttestations(cleanType) {
+		guard, err := newEvidenceGuard(retention, ref.Context(), ociremote.WithRemoteOptions(remoteOpts...))
+		if err != nil {
+			return err
+		}
+		if err := guard.checkTag(attRef); err != nil {
+			return err
+		}
+	}
+
 	for _, t := range cleanTags {
-		if err := remote.Delete(t, remoteOpts...); err != nil {
-			var te *transport.Error
-			switch {
-			case errors.As(err, &te) && te.StatusCode == http.StatusNotFound:
-				// If the tag doesn't exist, some registries may
-				// respond with a 404, which shouldn't be considered an
-				// error.
-			case errors.As(err, &te) && te.StatusCode == http.StatusBadRequest:
-				// Docker registry >=v2.3 requires does not allow deleting the OCI object name directly, must use the digest instead.
-				// See https://github.com/distribution/distribution/blob/main/docs/content/spec/api.md#deleting-an-image
-				if err := deleteByDigest(t, remoteOpts...); err != nil {
-					if errors.As(err, &te) && te.StatusCode == http.StatusNotFound { //nolint: revive
-					} else {
-						fmt.Fprintf(os.Stderr, "could not delete %s by digest from %s:\n%v\n", t, imageRef, err)
-					}
-				} else {
-					fmt.Fprintf(os.Stderr, "Removed %s from %s\n", t, imageRef)
-				}
-			default:
-				fmt.Fprintf(os.Stderr, "could not delete %s from %s:\n%v\n", t, imageRef, err)
+		deleteTag(t, imageRef, remoteOpts...)
+	}
+
+	return nil
+}
+
+// PruneCmd removes the signatures and attestations of imageRef recorded in
+// the transparency log longer than olderThan ago, and then all but the
+// keepLatest most recently attached ones, rewriting the images holding them.
+// A zero keepLatest or olderThan disables that limit.
+func PruneCmd(ctx context.Context, regOpts options.RegistryOptions, retention options.RetentionOptions, cleanType options.CleanType, imageRef string, keepLatest int, olderThan time.Duration, force bool) error {
+	if cleanType == options.CleanTypeSbom {
+		return errors.New("--keep-latest and --older-than do not apply to SBOMs")
+	}
+	if !force {
+		ui.Warnf(ctx, prunePrompt(cleanType, keepLatest, olderThan))
+		if err := ui.ConfirmContinue(ctx); err != nil {
+			return err
+		}
+	}
+	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+
+	remoteOpts := regOpts.GetRegistryClientOpts(ctx)
+	ociremoteOpts := []ociremote.Option{ociremote.WithRemoteOptions(remoteOpts...)}
+
+	var pruneTags []name.Tag
+	if cleanType == options.CleanTypeSignature || cleanType == options.CleanTypeAll {
+		sigRef, err := ociremote.SignatureTag(ref, ociremoteOpts...)
+		if err != nil {
+			return err
+		}
+		pruneTags = append(pruneTags, sigRef)
+	}
+	var attRef name.Tag
+	var guard *evidenceGuard
+	if cleansAttestations(cleanType) {
+		if attRef, err = ociremote.AttestationTag(ref, ociremoteOpts...); err != nil {
+			return err
+		}
+		pruneTags = append(pruneTags, attRef)
+		if guard, err = newEvidenceGuard(retention, ref.Context(), ociremoteOpts...); err != nil {
+			return err
+		}
+	}
+
+	// Nothing is removed until every tag has been checked by the guard.
+	type pruning struct {
+		tag           name.Tag
+		pruned        oci.Signatures
+		before, after int
+	}
+	var prunings []pruning
+	for _, t := range pruneTags {
+		sigs, err := ociremote.Signatures(t, ociremoteOpts...)
+		if err != nil {
+			return err
+		}
+		before, err := sigs.Get()
+		if err != nil {
+			return err
+		}
+		pruned := sigs
+		if olderThan > 0 {
+			if pruned, err = mutate.PruneSignaturesBefore(pruned, time.Now().Add(-olderThan)); err != nil {
+				return err
+			}
+		}
+		if keepLatest > 0 {
+			if pruned, err = mutate.KeepLatestSignatures(pruned, keepLatest); err != nil {
+				return err
+			}
+		}
+		after, err := pruned.Get()
+		if err != nil {
+			return err
+		}
+		if len(after) == len(before) {
+			continue
+		}
+		if t == attRef {
+			if err := guard.check(t, sigs, pruned); err != nil {
+				return err
+			}
+		}
+		prunings = append(prunings, pruning{tag: t, pruned: pruned, before: len(before), after: len(after)})
+	}
+
+	for _, p := range prunings {
+		if p.after == 0 {
+			deleteTag(p.tag, imageRef, remoteOpts...)
+			continue
+		}
+		if err := remote.Write(p.tag, p.pruned, remoteOpts...); err != nil {
+			return fmt.Errorf("writing %s: %w", p.tag, err)
+		}
+		fmt.Fprintf(os.Stderr, "Removed %d of %d entries of %s from %s\n", p.before-p.after, p.before, p.tag, imageRef)
+	}
+
+	return nil
+}
+
+// RemoveSignatureCmd removes the one signature or attestation of imageRef
+// with the given digest, see mutate.RemoveSignature, rewriting the image
+// holding it. With CleanTypeAll, it is looked for among both.
+func RemoveSignatureCmd(ctx context.Context, regOpts options.RegistryOptions, retention options.RetentionOptions, cleanType options.CleanType, imageRef, digest string, force bool) error {
+	if cleanType == options.CleanTypeSbom {
+		return errors.New("--signature-digest does not apply to SBOMs")
+	}
+	h, err := v1.NewHash(digest)
+	if err != nil {
+		return fmt.Errorf("parsing --signature-digest: %w", err)
+	}
+	if !force {
+		ui.Warnf(ctx, "this will remove the signature or attestation %s from the image", h)
+		if err := ui.ConfirmContinue(ctx); err != nil {
+			return err
+		}
+	}
+	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+
+	remoteOpts := regOpts.GetRegistryClientOpts(ctx)
+	ociremoteOpts := []ociremote.Option{ociremote.WithRemoteOptions(remoteOpts...)}
+
+	var tags []name.Tag
+	if cleanType == options.CleanTypeSignature || cleanType == options.CleanTypeAll {
+		sigRef, err := ociremote.SignatureTag(ref, ociremoteOpts...)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, sigRef)
+	}
+	var attRef name.Tag
+	var guard *evidenceGuard
+	if cleansAttestations(cleanType) {
+		if attRef, err = ociremote.AttestationTag(ref, ociremoteOpts...); err != nil {
+			return err
+		}
+		tags = append(tags, attRef)
+		if guard, err = newEvidenceGuard(retention, ref.Context(), ociremoteOpts...); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range tags {
+		sigs, err := ociremote.Signatures(t, ociremoteOpts...)
+		if err != nil {
+			return err
+		}
+		removed, err := mutate.RemoveSignature(sigs, h)
+		if errors.Is(err, mutate.ErrSignatureNotFound) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		if t == attRef {
+			if err := guard.check(t, sigs, removed); err != nil {
+				return err
+			}
+		}
+		left, err := removed.Get()
+		if err != nil {
+			return err
+		}
+		if len(left) == 0 {
+			deleteTag(t, imageRef, remoteOpts...)
+			return nil
+		}
+		if err := remote.Write(t, removed, remoteOpts...); err != nil {
+			return fmt.Errorf("writing %s: %w", t, err)
+		}
+		fmt.Fprintf(os.Stderr, "Removed %s from %s of %s\n", h, t, imageRef)
+		return nil
+	}
+	return fmt.Errorf("%w: %s in %s", mutate.ErrSignatureNotFound, h, imageRef)
+}
+
+// CleanOrphansCmd removes the tags of repository holding the signatures,
+// attestations and SBOMs of image manifests that no longer exist, limited to
+// those of cleanType. With dryRun, they are only listed.
+func CleanOrphansCmd(ctx context.Context, regOpts options.RegistryOptions, retention options.RetentionOptions, cleanType options.CleanType, repository string, dryRun, force bool) error {
+	repo, err := name.NewRepository(repository, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("constructing client options: %w", err)
+	}
+	orphaned, err := ociremote.ListOrphanedTags(repo, ociremoteOpts...)
+	if err != nil {
+		return fmt.Errorf("listing orphaned tags of %s: %w", repo, err)
+	}
+	orphaned = slices.DeleteFunc(orphaned, func(o ociremote.OrphanedTag) bool {
+		return !cleansSuffix(cleanType, o.Suffix)
+	})
+	if len(orphaned) == 0 {
+		ui.Infof(ctx, "No orphaned tags found in %s", repo)
+		return nil
+	}
+
+	for _, o := range orphaned {
+		fmt.Fprintf(os.Stdout, "%s (%s no longer exists)\n", o.Tag, o.Subject.DigestStr())
+	}
+	if cleansAttestations(cleanType) {
+		guard, err := newEvidenceGuard(retention, repo, ociremoteOpts...)
+		if err != nil {
+			return err
+		}
+		for _, o := range orphaned {
+			if o.Suffix != ociremote.AttestationTagSuffix {
+				continue
+			}
+			if err := guard.checkTag(o.Tag); err != nil {
+				return err
 			}
-		} else {
-			fmt.Fprintf(os.Stderr, "Removed %s from %s\n", t, imageRef)
 		}
 	}
+	if dryRun {
+		return nil
+	}
+	if !force {
+		ui.Warnf(ctx, "this will remove the %d tags listed above from %s", len(orphaned), repo)
+		if err := ui.ConfirmContinue(ctx); err != nil {
+			return err
+		}
+	}
+	remoteOpts := regOpts.GetRegistryClientOpts(ctx)
+	for _, o := range orphaned {
+		deleteTag(o.Tag, o.Subject.String(), remoteOpts...)
+	}
+	return nil
+}
+
+// cleansAttestations reports whether cleanType removes attestations.
+func cleansAttestations(cleanType options.CleanType) bool {
+	return cleanType == options.CleanTypeAttestation || cleanType == options.CleanTypeAll
+}
+
+// evidenceGuard refuses to remove attestations that are kept as audit
+// evidence: those that other attestations name as their subject, and those
+// that the retention policy retains.
+type evidenceGuard struct {
+	repo   name.Repository
+	policy *cosign.RetentionPolicy
+	refs   map[v1.Hash][]cosign.AttestationReference
+	opts   []ociremote.Option
+}
+
+// newEvidenceGuard returns the evidenceGuard for the attestations of the
+// images of repo, or nil if retention forces their removal.
+func newEvidenceGuard(retention options.RetentionOptions, repo name.Repository, opts ...ociremote.Option) (*evidenceGuard, error) {
+	if retention.Force {
+		return nil, nil
+	}
+	g := &evidenceGuard{repo: repo, opts: opts}
+	if retention.Policy != "" {
+		p, err := cosign.LoadRetentionPolicy(retention.Policy)
+		if err != nil {
+			return nil, err
+		}
+		g.policy = p
+	}
+	refs, err := cosign.ListAttestationReferences(repo, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("listing the attestations of %s: %w", repo, err)
+	}
+	g.refs = refs
+	return g, nil
+}
+
+// checkTag fails if removing tag destroys evidence.
+func (g *evidenceGuard) checkTag(tag name.Tag) error {
+	if g == nil {
+		return nil
+	}
+	sigs, err := ociremote.Signatures(tag, g.opts...)
+	if err != nil {
+		return err
+	}
+	return g.check(tag, sigs, nil)
+}
 
+// check fails if rewriting tag from the attestations of before to those of
+// after, which is nil if tag is removed, destroys evidence. Besides the
+// attestations left out, the image holding before is gone afterwards.
+func (g *evidenceGuard) check(tag name.Tag, before, after oci.Signatures) error {
+	if g == nil {
+		return nil
+	}
+	atts, err := before.Get()
+	if err != nil {
+		return err
+	}
+	kept := map[v1.Hash]bool{}
+	if after != nil {
+		left, err := after.Get()
+		if err != nil {
+			return err
+		}
+		for _, att := range left {
+			d, err := att.Digest()
+			if err != nil {
+				return err
+			}
+			kept[d] = true
+		}
+	}
+	held, err := before.Digest()
+	if err != nil {
+		return err
+	}
+	gone := map[v1.Hash]bool{held: true}
+	var removed []oci.Signature
+	for _, att := range atts {
+		d, err := att.Digest()
+		if err != nil {
+			return err
+		}
+		if !kept[d] {
+			gone[d] = true
+			removed = append(removed, att)
+		}
+	}
+
+	var errs []error
+	for _, att := range removed {
+		d, err := att.Digest()
+		if err != nil {
+			return err
+		}
+		retained, err := g.policy.Retains(g.repo, att)
+		if err != nil {
+			return fmt.Errorf("checking %s of %s against the retention policy: %w", d, tag, err)
+		}
+		if retained {
+			errs = append(errs, fmt.Errorf("attestation %s of %s is retained by the retention policy", d, tag))
+		}
+	}
+	for _, d := range slices.SortedFunc(maps.Keys(gone), func(a, b v1.Hash) int { return strings.Compare(a.String(), b.String()) }) {
+		for _, ref := range g.refs[d] {
+			// Attestations removed along with what they name are no loss.
+			if ref.Tag == tag && gone[ref.Digest] {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s of %s is the subject of attestation %s of %s", d, tag, ref.Digest, ref.Tag))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("refusing to remove audit evidence, use --force-retained to remove it anyway: %w", errors.Join(errs...))
+	}
 	return nil
 }
 
+// cleansSuffix reports whether the tags with suffix hold what cleanType
+// removes.
+func cleansSuffix(cleanType options.CleanType, suffix string) bool {
+	switch cleanType {
+	case options.CleanTypeSignature:
+		return suffix == ociremote.SignatureTagSuffix
+	case options.CleanTypeAttestation:
+		return suffix == ociremote.AttestationTagSuffix
+	case options.CleanTypeSbom:
+		return suffix == ociremote.SBOMTagSuffix
+	default:
+		return true
+	}
+}
+
+// deleteTag deletes t, reporting the outcome on stderr.
+func deleteTag(t name.Tag, imageRef string, opts ...remote.Option) {
+	if err := remote.Delete(t, opts...); err != nil {
+		var te *transport.Error
+		switch {
+		case errors.As(err, &te) && te.StatusCode == http.StatusNotFound:
+			// If the tag doesn't exist, some registries may
+			// respond with a 404, which shouldn't be considered an
+			// error.
+		case errors.As(err, &te) && te.StatusCode == http.StatusBadRequest:
+			// Docker registry >=v2.3 requires does not allow deleting the OCI object name directly, must use the digest instead.
+			// See https://github.com/distribution/distribution/blob/main/docs/content/spec/api.md#deleting-an-image
+			if err := deleteByDigest(t, opts...); err != nil {
+				if errors.As(err, &te) && te.StatusCode == http.StatusNotFound { //nolint: revive
+				} else {
+					fmt.Fprintf(os.Stderr, "could not delete %s by digest from %s:\n%v\n", t, imageRef, err)
+				}
+			} else {
+				fmt.Fprintf(os.Stderr, "Removed %s from %s\n", t, imageRef)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "could not delete %s from %s:\n%v\n", t, imageRef, err)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Removed %s from %s\n", t, imageRef)
+	}
+}
+
 func deleteByDigest(tag name.Tag, opts ...remote.Option) error {
 	digestTag, err := ociremote.DockerContentDigest(tag, ociremote.WithRemoteOptions(opts...))
 	if err != nil {
@@ -144,3 +569,21 @@ func prompt(cleanType options.CleanType) string {
 	}
 	panic("invalid CleanType value")
 }
+
+func prunePrompt(cleanType options.CleanType, keepLatest int, olderThan time.Duration) string {
+	what := "signatures and attestations"
+	switch cleanType {
+	case options.CleanTypeSignature:
+		what = "signatures"
+	case options.CleanTypeAttestation:
+		what = "attestations"
+	}
+	var limits []string
+	if olderThan > 0 {
+		limits = append(limits, fmt.Sprintf("logged more than %s ago", olderThan))
+	}
+	if keepLatest > 0 {
+		limits = append(limits, fmt.Sprintf("not among the %d most recent", keepLatest))
+	}
+	return fmt.Sprintf("this will remove %s from the image that are %s", what, strings.Join(limits, " or "))
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/oci/empty"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	"github.com/franchb/cosign/v2/pkg/oci/ocitest"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+// writeTestAttestation stores an attestation with predicateType over subject
+// for the image d, returning the digests of its layer and of the image
+// holding it.
+func writeTestAttestation(t *testing.T, d name.Digest, predicateType string, subject v1.Hash) (v1.Hash, v1.Hash) {
+	t.Helper()
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":%q,"subject":[{"name":"x","digest":{%q:%q}}],"predicate":{}}`,
+		predicateType, subject.Algorithm, subject.Hex)
+	envelope := `{"payloadType":"application/vnd.in-toto+json","payload":"` + base64.StdEncoding.EncodeToString([]byte(statement)) + `","signatures":[]}`
+	att, err := static.NewAttestation([]byte(envelope))
+	if err != nil {
+		t.Fatalf("NewAttestation() = %v", err)
+	}
+	atts, err := mutate.AppendSignatures(empty.Signatures(), false, att)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	tag, err := ociremote.AttestationTag(d)
+	if err != nil {
+		t.Fatalf("AttestationTag() = %v", err)
+	}
+	if err := remote.Write(tag, atts); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	layer, err := att.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	held, err := atts.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	return layer, held
+}
+
+func TestCleanRefusesEvidence(t *testing.T) {
+	ctx := context.Background()
+	repo := ocitest.New(t).Repository("app")
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	if err := remote.Write(repo.Tag("latest"), img); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	image := repo.Digest(h.String())
+	attTag, err := ociremote.AttestationTag(image)
+	if err != nil {
+		t.Fatalf("AttestationTag() = %v", err)
+	}
+
+	// A review of the scan attestation is stored for the image holding it.
+	scan, scans := writeTestAttestation(t, image, "https://cosign.sigstore.dev/attestation/vuln/v1", h)
+	writeTestAttestation(t, repo.Digest(scans.String()), "https://example.com/review/v1", scan)
+
+	err = CleanCmd(ctx, options.RegistryOptions{}, options.RetentionOptions{}, options.CleanTypeAttestation, image.String(), true)
+	if err == nil || !strings.Contains(err.Error(), "is the subject of attestation") {
+		t.Errorf("CleanCmd() = %v, wanted the scan to be kept for its review", err)
+	}
+	// Signatures are not protected.
+	if err := CleanCmd(ctx, options.RegistryOptions{}, options.RetentionOptions{}, options.CleanTypeSignature, image.String(), true); err != nil {
+		t.Errorf("CleanCmd() = %v", err)
+	}
+
+	// Without the review, the retention policy still keeps the scan.
+	reviewTag, err := ociremote.AttestationTag(repo.Digest(scans.String()))
+	if err != nil {
+		t.Fatalf("AttestationTag() = %v", err)
+	}
+	if err := remote.Delete(reviewTag); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	policy := filepath.Join(t.TempDir(), "retention.json")
+	if err := os.WriteFile(policy, []byte(`{"predicateTypes":["https://cosign.sigstore.dev/attestation/vuln/v1"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err = CleanCmd(ctx, options.RegistryOptions{}, options.RetentionOptions{Policy: policy}, options.CleanTypeAll, image.String(), true)
+	if err == nil || !strings.Contains(err.Error(), "retained by the retention policy") {
+		t.Errorf("CleanCmd() = %v, wanted the scan to be retained", err)
+	}
+	if _, err := remote.Head(attTag); err != nil {
+		t.Errorf("Head(%s) = %v, wanted the scan to be kept", attTag, err)
+	}
+
+	if err := CleanCmd(ctx, options.RegistryOptions{}, options.RetentionOptions{Policy: policy, Force: true}, options.CleanTypeAll, image.String(), true); err != nil {
+		t.Fatalf("CleanCmd() = %v", err)
+	}
+	if _, err := remote.Head(attTag); err == nil {
+		t.Errorf("Head(%s) succeeded after forcing the scan to be removed", attTag)
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/templates"
+	"github.com/franchb/cosign/v2/internal/pkg/ratelimit"
 	cranecmd "github.com/google/go-containerregistry/cmd/crane/cmd"
 	cobracompletefig "github.com/withfig/autocomplete-tools/integrations/cobra"
 )
@@ -78,9 +79,13 @@ func New() *cobra.Command {
 				logs.Debug.SetOutput(os.Stderr)
 			}
 
-			return nil
+			return ro.SetRateLimits()
 		},
 		PersistentPostRun: func(_ *cobra.Command, _ []string) {
+			for _, s := range ratelimit.AllStats() {
+				logs.Debug.Printf("rate limit of %s: %d requests, %d delayed by %s in total, at most %d queued",
+					s.Endpoint, s.Requests, s.Delayed, s.WaitTime, s.MaxWaiting)
+			}
 			if out != nil {
 				_ = out.Close()
 			}
@@ -95,7 +100,10 @@ func New() *cobra.Command {
 	cmd.AddCommand(Attach())
 	cmd.AddCommand(Attest())
 	cmd.AddCommand(AttestBlob())
+	cmd.AddCommand(Audit())
+	cmd.AddCommand(CheckDrift())
 	cmd.AddCommand(Clean())
+	cmd.AddCommand(Conformance())
 	cmd.AddCommand(Debug())
 	cmd.AddCommand(Tree())
 	cmd.AddCommand(Completion())
@@ -111,15 +119,23 @@ func New() *cobra.Command {
 	cmd.AddCommand(PIVTool())
 	cmd.AddCommand(PKCS11Tool())
 	cmd.AddCommand(PublicKey())
+	cmd.AddCommand(Resign())
 	cmd.AddCommand(Save())
+	cmd.AddCommand(Search())
+	cmd.AddCommand(Self())
 	cmd.AddCommand(Sign())
 	cmd.AddCommand(SignBlob())
+	cmd.AddCommand(SignGit())
+	cmd.AddCommand(Sync())
 	cmd.AddCommand(Upload())
 	cmd.AddCommand(Verify())
 	cmd.AddCommand(VerifyAttestation())
+	cmd.AddCommand(VerifyBinary())
 	cmd.AddCommand(VerifyBlob())
 	cmd.AddCommand(VerifyBlobAttestation())
+	cmd.AddCommand(VerifyGit())
 	cmd.AddCommand(Triangulate())
+	cmd.AddCommand(TrustedRoot())
 	cmd.AddCommand(Env())
 	cmd.AddCommand(version.WithFont("starwars"))
 
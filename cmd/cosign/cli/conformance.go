@@ -0,0 +1,56 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"flag"
+
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/conformance"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+)
+
+func Conformance() *cobra.Command {
+	o := &options.ConformanceOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "conformance",
+		Short: "Verify sigstore-conformance test vectors and report deviations from the expected outcomes.",
+		Long: `Verify sigstore-conformance test vectors and report deviations from the expected outcomes.
+
+The directory holds one subdirectory per test vector, laid out like the
+bundle-verify assets of https://github.com/sigstore/sigstore-conformance:
+bundle.sigstore.json, an optional trusted_root.json and an optional artifact
+file, falling back to the a.txt artifact shared by the suite. Vectors whose
+directory name ends in "_fail" must fail verification. Running the suite checks
+that bundles produced by other Sigstore clients verify with this binary.`,
+		Example:          "  cosign conformance <VECTORS DIR>",
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return flag.ErrHelp
+			}
+			c := &conformance.ConformanceCmd{
+				ConformanceOptions: *o,
+				Out:                cmd.OutOrStdout(),
+			}
+			return c.Exec(cmd.Context(), args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/verify"
+	"github.com/franchb/sigstore-go/pkg/bundle"
+)
+
+const (
+	// BundleFile is the name of the bundle in a test vector directory.
+	BundleFile = "bundle.sigstore.json"
+	// TrustedRootFile is the name of the optional trusted root in a test
+	// vector directory.
+	TrustedRootFile = "trusted_root.json"
+	// ArtifactFile is the name of the signed artifact in a test vector
+	// directory. Vectors without one use the artifact shared by the suite.
+	ArtifactFile = "artifact"
+	// SharedArtifactFile is the name of the artifact shared by the suite, in
+	// the directory holding the test vectors.
+	SharedArtifactFile = "a.txt"
+	// failSuffix marks test vectors that must fail verification.
+	failSuffix = "_fail"
+)
+
+// Case is a single test vector in the layout of the sigstore-conformance
+// bundle-verify assets: a directory holding BundleFile, and optionally
+// TrustedRootFile and ArtifactFile. Directories named with a "_fail" suffix
+// must fail verification.
+type Case struct {
+	Name            string
+	BundlePath      string
+	TrustedRootPath string
+	ArtifactPath    string
+	ExpectFailure   bool
+}
+
+// Result is the outcome of verifying a Case.
+type Result struct {
+	Case
+	// Err is the verification error, if verification failed.
+	Err error
+}
+
+// Deviates reports whether the outcome differs from the expected one.
+func (r Result) Deviates() bool {
+	return (r.Err != nil) != r.ExpectFailure
+}
+
+// LoadCases returns the test vectors in the subdirectories of dir, sorted by
+// name.
+func LoadCases(dir string) ([]Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var cases []Case
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		caseDir := filepath.Join(dir, e.Name())
+		c := Case{
+			Name:          e.Name(),
+			BundlePath:    filepath.Join(caseDir, BundleFile),
+			ArtifactPath:  filepath.Join(caseDir, ArtifactFile),
+			ExpectFailure: strings.HasSuffix(e.Name(), failSuffix),
+		}
+		if _, err := os.Stat(c.BundlePath); err != nil {
+			// Not a test vector.
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(caseDir, TrustedRootFile)); err == nil {
+			c.TrustedRootPath = filepath.Join(caseDir, TrustedRootFile)
+		}
+		if _, err := os.Stat(c.ArtifactPath); err != nil {
+			c.ArtifactPath = filepath.Join(dir, SharedArtifactFile)
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no test vectors containing %s found in %s", BundleFile, dir)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// ConformanceCmd verifies sigstore-conformance test vectors and reports the
+// ones whose outcome deviates from the expected one.
+type ConformanceCmd struct {
+	options.ConformanceOptions
+	// Out receives the report.
+	Out io.Writer
+	// verify overrides verifyCase in tests.
+	verify func(ctx context.Context, c Case) error
+}
+
+// Exec runs every test vector in dir.
+func (c *ConformanceCmd) Exec(ctx context.Context, dir string) error {
+	cases, err := LoadCases(dir)
+	if err != nil {
+		return err
+	}
+	verifyFn := c.verify
+	if verifyFn == nil {
+		verifyFn = c.verifyCase
+	}
+
+	deviations := 0
+	for _, tc := range cases {
+		r := Result{Case: tc, Err: verifyFn(ctx, tc)}
+		status := "PASS"
+		if r.Deviates() {
+			status = "DEVIATION"
+			deviations++
+		}
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(c.Out, "%s\t%s\tverification failed: %v\n", status, tc.Name, r.Err)
+		case tc.ExpectFailure:
+			fmt.Fprintf(c.Out, "%s\t%s\tverification succeeded but was expected to fail\n", status, tc.Name)
+		default:
+			fmt.Fprintf(c.Out, "%s\t%s\n", status, tc.Name)
+		}
+	}
+	if deviations > 0 {
+		return fmt.Errorf("%d of %d conformance test vectors deviated", deviations, len(cases))
+	}
+	fmt.Fprintf(c.Out, "all %d conformance test vectors passed\n", len(cases))
+	return nil
+}
+
+// verifyCase verifies tc the way verify-blob --new-bundle-format does.
+func (c *ConformanceCmd) verifyCase(ctx context.Context, tc Case) error {
+	b, err := bundle.LoadJSONFromPath(tc.BundlePath)
+	if err != nil {
+		return err
+	}
+	ts, err := b.Timestamps()
+	if err != nil {
+		return err
+	}
+	trustedRoot := tc.TrustedRootPath
+	if trustedRoot == "" {
+		trustedRoot = c.TrustedRootPath
+	}
+	if _, err := os.Stat(tc.ArtifactPath); err != nil {
+		return errors.New("test vector has no artifact")
+	}
+	cmd := &verify.VerifyBlobCmd{
+		KeyOpts: options.KeyOpts{
+			BundlePath:      tc.BundlePath,
+			NewBundleFormat: true,
+		},
+		CertVerifyOptions: options.CertVerifyOptions{
+			CertIdentity:   c.CertIdentity,
+			CertOidcIssuer: c.CertOidcIssuer,
+		},
+		TrustedRootPath:     trustedRoot,
+		UseSignedTimestamps: len(ts) > 0,
+	}
+	return cmd.Exec(ctx, tc.ArtifactPath)
+}
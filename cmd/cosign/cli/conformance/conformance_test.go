@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeVector(t *testing.T, dir, name string, files ...string) {
+	t.Helper()
+	caseDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(caseDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range append([]string{BundleFile}, files...) {
+		if err := os.WriteFile(filepath.Join(caseDir, f), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadCases(t *testing.T) {
+	dir := t.TempDir()
+	writeVector(t, dir, "b-happy-path")
+	writeVector(t, dir, "a-bad-signature_fail", TrustedRootFile, ArtifactFile)
+	if err := os.MkdirAll(filepath.Join(dir, "not-a-vector"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := LoadCases(dir)
+	if err != nil {
+		t.Fatalf("LoadCases() = %v", err)
+	}
+	if len(cases) != 2 {
+		t.Fatalf("LoadCases() returned %d cases, wanted 2", len(cases))
+	}
+	fail, happy := cases[0], cases[1]
+	if !fail.ExpectFailure || happy.ExpectFailure {
+		t.Errorf("ExpectFailure = %v, %v, wanted true, false", fail.ExpectFailure, happy.ExpectFailure)
+	}
+	if fail.TrustedRootPath != filepath.Join(dir, fail.Name, TrustedRootFile) || happy.TrustedRootPath != "" {
+		t.Errorf("TrustedRootPath = %q, %q", fail.TrustedRootPath, happy.TrustedRootPath)
+	}
+	if fail.ArtifactPath != filepath.Join(dir, fail.Name, ArtifactFile) {
+		t.Errorf("ArtifactPath = %q, wanted the vector's own artifact", fail.ArtifactPath)
+	}
+	if happy.ArtifactPath != filepath.Join(dir, SharedArtifactFile) {
+		t.Errorf("ArtifactPath = %q, wanted the shared artifact", happy.ArtifactPath)
+	}
+
+	if _, err := LoadCases(t.TempDir()); err == nil {
+		t.Error("LoadCases() succeeded without any test vectors")
+	}
+}
+
+func TestExecReportsDeviations(t *testing.T) {
+	dir := t.TempDir()
+	writeVector(t, dir, "verifies")
+	writeVector(t, dir, "rejected")
+	writeVector(t, dir, "verifies_fail")
+	writeVector(t, dir, "rejected_fail")
+
+	var out bytes.Buffer
+	c := &ConformanceCmd{
+		Out: &out,
+		verify: func(_ context.Context, tc Case) error {
+			if strings.HasPrefix(tc.Name, "rejected") {
+				return errors.New("bad signature")
+			}
+			return nil
+		},
+	}
+	err := c.Exec(context.Background(), dir)
+	if err == nil || !strings.Contains(err.Error(), "2 of 4") {
+		t.Fatalf("Exec() = %v, wanted 2 of 4 deviations", err)
+	}
+	for _, want := range []string{
+		"DEVIATION\trejected\tverification failed: bad signature",
+		"DEVIATION\tverifies_fail\tverification succeeded but was expected to fail",
+		"PASS\trejected_fail\tverification failed: bad signature",
+		"PASS\tverifies\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+}
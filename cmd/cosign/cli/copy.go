@@ -16,10 +16,19 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/copy"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 )
 
 func Copy() *cobra.Command {
@@ -39,19 +48,121 @@ func Copy() *cobra.Command {
   # copy the signatures, attestations, sbom only
   cosign copy --only=sig,att,sbom example.com/src example.com/dest
 
+  # copy the image and its attestations, but not its signatures or sbom
+  cosign copy --only=image,att example.com/src example.com/dest
+
   # overwrite destination image and signatures
   cosign copy -f example.com/src example.com/dest
 
   # copy a container image and its signatures for a specific platform
-  cosign copy --platform=linux/amd64 example.com/src:latest example.com/dest:latest`,
+  cosign copy --platform=linux/amd64 example.com/src:latest example.com/dest:latest
+
+  # copy only the arm64 and arm/v7 images of a multi-platform image, and their signatures
+  cosign copy --platform=linux/arm64,linux/arm/v7 example.com/src:latest example.com/dest:latest
+
+  # copy a multi-platform image and its signatures, 16 artifacts at a time
+  cosign copy --jobs 16 example.com/src:latest example.com/dest:latest
+
+  # promote an image, signing it and each of its platform images in the destination
+  cosign copy --sign --key cosign.key example.com/staging/app:v1 example.com/prod/app:v1`,
 
 		Args:             cobra.ExactArgs(2),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return copy.CopyCmd(cmd.Context(), o.Registry, args[0], args[1], o.SignatureOnly, o.Force, o.CopyOnly, o.Platform)
+			if err := checkCopySign(o); err != nil {
+				return err
+			}
+			if err := copy.CopyCmd(cmd.Context(), o.Registry, args[0], args[1], o.SignatureOnly, o.Force, o.CopyOnly, o.Platform, o.Jobs); err != nil {
+				return err
+			}
+			if !o.Sign {
+				return nil
+			}
+			return signCopy(cmd.Context(), o, args[1])
 		},
 	}
 
 	o.AddFlags(cmd)
 	return cmd
 }
+
+// checkCopySign fails if the signing flags of o are set without --sign, or
+// if --sign is set but the images are not copied.
+func checkCopySign(o *options.CopyOptions) error {
+	if !o.Sign {
+		if o.Key != "" || o.SecurityKey.Use {
+			return errors.New("--key and --sk require --sign")
+		}
+		return nil
+	}
+	if o.SignatureOnly {
+		return errors.New("--sign cannot be combined with --sig-only")
+	}
+	if o.CopyOnly != "" {
+		artifacts, err := ociremote.ParseArtifacts(o.CopyOnly)
+		if err != nil {
+			return fmt.Errorf("parsing --only: %w", err)
+		}
+		if !slices.Contains(artifacts, ociremote.ArtifactImage) {
+			return errors.New("--sign requires --only to include image")
+		}
+	}
+	return nil
+}
+
+// signCopy signs the image copied to dstImg and each image within it, as
+// cosign sign --recursive does.
+func signCopy(ctx context.Context, o *options.CopyOptions, dstImg string) error {
+	ref, err := name.ParseReference(dstImg, o.Registry.NameOptions()...)
+	if err != nil {
+		return err
+	}
+	opts, err := o.Registry.ClientOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("constructing client options: %w", err)
+	}
+	digest, err := ociremote.ResolveDigest(ref, opts...)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	oidcClientSecret, err := o.OIDC.ClientSecret()
+	if err != nil {
+		return err
+	}
+	ko := options.KeyOpts{
+		KeyRef:                   o.Key,
+		PassFunc:                 generate.GetPass,
+		Sk:                       o.SecurityKey.Use,
+		Slot:                     o.SecurityKey.Slot,
+		FulcioURL:                o.Fulcio.URL,
+		IDToken:                  o.Fulcio.IdentityToken,
+		FulcioAuthFlow:           o.Fulcio.AuthFlow,
+		InsecureSkipFulcioVerify: o.Fulcio.InsecureSkipFulcioVerify,
+		CertificateProvider:      o.Fulcio.CertificateProvider,
+		RekorURL:                 o.Rekor.URL,
+		AdditionalRekorURLs:      o.Rekor.AdditionalURLs,
+		OIDCIssuer:               o.OIDC.Issuer,
+		OIDCClientID:             o.OIDC.ClientID,
+		OIDCClientSecret:         oidcClientSecret,
+		OIDCRedirectURL:          o.OIDC.RedirectURL,
+		OIDCDisableProviders:     o.OIDC.DisableAmbientProviders,
+		OIDCProvider:             o.OIDC.Provider,
+		SkipConfirmation:         o.SkipConfirmation,
+	}
+	so := options.SignOptions{
+		Key:              o.Key,
+		Upload:           true,
+		Recursive:        true,
+		SkipConfirmation: o.SkipConfirmation,
+		TlogUpload:       o.TlogUpload,
+		Rekor:            o.Rekor,
+		Fulcio:           o.Fulcio,
+		OIDC:             o.OIDC,
+		SecurityKey:      o.SecurityKey,
+		Registry:         o.Registry,
+	}
+	if err := sign.SignCmd(ro, ko, so, []string{digest.String()}); err != nil {
+		return fmt.Errorf("signing %s: %w", digest, err)
+	}
+	return nil
+}
@@ -21,24 +21,23 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/pkg/oci"
 	ociplatform "github.com/franchb/cosign/v2/pkg/oci/platform"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
-	"github.com/franchb/cosign/v2/pkg/oci/walk"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"golang.org/x/sync/errgroup"
-	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // CopyCmd implements the logic to copy the supplied container image and signatures.
 // nolint
-func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstImg string, sigOnly, force bool, copyOnly, platform string) error {
+func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstImg string, sigOnly, force bool, copyOnly, platform string, jobs int) error {
 	no := regOpts.NameOptions()
 	srcRef, err := name.ParseReference(srcImg, no...)
 	if err != nil {
@@ -66,73 +65,61 @@ func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstIm
 
 	ociRemoteOpts = append(ociRemoteOpts, ociremote.WithRemoteOptions(remoteOpts...))
 
-	g, gctx := errgroup.WithContext(ctx)
-	g.SetLimit(runtime.GOMAXPROCS(0))
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	q := newCopyQueue(jobs)
 
 	root, err := ociremote.SignedEntity(srcRef, ociRemoteOpts...)
 	if err != nil {
 		return err
 	}
 
-	root, err = ociplatform.SignedEntityForPlatform(root, platform)
-	if err != nil {
-		return err
+	// With a platform, the index is rewritten to list only the images of that
+	// platform, and so has a digest of its own without signatures.
+	var filtered oci.SignedImageIndex
+	if platform != "" {
+		if _, ok := dstRef.(name.Digest); ok {
+			return errors.New("--platform rewrites the image index, so it can only be copied to a tag")
+		}
+		if filtered, err = ociplatform.FilterSignedImageIndex(root, platform); err != nil {
+			return err
+		}
+		root = filtered
 	}
 
-	onlyFlagSet := false
-	tags := parseOnlyOpt(copyOnly, sigOnly)
-	if len(tags) > 0 {
-		onlyFlagSet = true
-	} else {
-		tags = []tagMap{ociremote.SignatureTag, ociremote.AttestationTag, ociremote.SBOMTag}
+	artifacts, err := parseOnlyOpt(copyOnly, sigOnly)
+	if err != nil {
+		return err
 	}
-	if err := walk.SignedEntity(gctx, root, func(ctx context.Context, se oci.SignedEntity) error {
-		// Both of the SignedEntity types implement Digest()
-		h, err := se.Digest()
+	var skip string
+	if filtered != nil {
+		// The rewritten index is pushed to dstRef once its images are copied.
+		h, err := filtered.Digest()
 		if err != nil {
 			return err
 		}
-		srcDigest := srcRepoRef.Digest(h.String())
-
-		copyTag := func(tm tagMap) error {
-			src, err := tm(srcDigest, ociRemoteOpts...)
-			if err != nil {
-				return err
-			}
-
-			dst := dstRepoRef.Tag(src.Identifier())
-			g.Go(func() error {
-				return remoteCopy(ctx, pusher, src, dst, force, remoteOpts...)
-			})
-
+		skip = h.String()
+	}
+	if err := ociremote.CopyTasks(ctx, root, srcRepoRef, dstRepoRef, artifacts, func(t ociremote.CopyTask) error {
+		if t.Subject.DigestStr() == skip {
 			return nil
 		}
-
-		for _, tm := range tags {
-			if err := copyTag(tm); err != nil {
-				return err
-			}
-		}
-
-		// Copy the entity itself.
-		g.Go(func() error {
-			dst := dstRepoRef.Tag(srcDigest.Identifier())
-			dst = dst.Tag(fmt.Sprint(regOpts.RefOpts.TagPrefix, h.Algorithm, "-", h.Hex))
-			return remoteCopy(ctx, pusher, srcDigest, dst, force, remoteOpts...)
+		q.Go(func() error {
+			return remoteCopy(ctx, pusher, t.Src, t.Dst, force, remoteOpts...)
 		})
-
 		return nil
-	}); err != nil {
+	}, ociRemoteOpts...); err != nil {
 		return err
 	}
 
 	// Wait for everything to be copied over.
-	if err := g.Wait(); err != nil {
+	if err := q.Wait(); err != nil {
 		return err
 	}
 
 	// If we're only copying sig/att/sbom, we have nothing left to do.
-	if onlyFlagSet {
+	if !slices.Contains(artifacts, ociremote.ArtifactImage) {
 		return nil
 	}
 
@@ -141,9 +128,46 @@ func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstIm
 	if err != nil {
 		return err
 	}
+	if filtered != nil {
+		return push(ctx, pusher, srcRef, filtered, h, dstRef, force, remoteOpts...)
+	}
 	return remoteCopy(ctx, pusher, srcRepoRef.Digest(h.String()), dstRef, force, remoteOpts...)
 }
 
+// copyQueue runs copies on up to a fixed number of goroutines at once, and
+// reports the errors of all of them in the order they were queued, so that
+// the outcome does not depend on which copy happened to fail first.
+type copyQueue struct {
+	g    errgroup.Group
+	errs []*error
+}
+
+func newCopyQueue(jobs int) *copyQueue {
+	q := &copyQueue{}
+	q.g.SetLimit(jobs)
+	return q
+}
+
+// Go queues f, blocking until there is a goroutine free to run it.
+func (q *copyQueue) Go(f func() error) {
+	err := new(error)
+	q.errs = append(q.errs, err)
+	q.g.Go(func() error {
+		*err = f()
+		return nil
+	})
+}
+
+// Wait waits for every queued copy and joins their errors in queue order.
+func (q *copyQueue) Wait() error {
+	_ = q.g.Wait()
+	errs := make([]error, 0, len(q.errs))
+	for _, err := range q.errs {
+		errs = append(errs, *err)
+	}
+	return errors.Join(errs...)
+}
+
 func descriptorsEqual(a, b *v1.Descriptor) bool {
 	if a == nil || b == nil {
 		return a == nil && b == nil
@@ -151,8 +175,6 @@ func descriptorsEqual(a, b *v1.Descriptor) bool {
 	return a.Digest == b.Digest
 }
 
-type tagMap func(name.Reference, ...ociremote.Option) (name.Tag, error)
-
 func remoteCopy(ctx context.Context, pusher *remote.Pusher, src, dest name.Reference, overwrite bool, opts ...remote.Option) error {
 	got, err := remote.Get(src, opts...)
 	if err != nil {
@@ -166,10 +188,16 @@ func remoteCopy(ctx context.Context, pusher *remote.Pusher, src, dest name.Refer
 		}
 		return err
 	}
+	return push(ctx, pusher, src, got, got.Digest, dest, overwrite, opts...)
+}
 
+// push pushes t, copied from src, to dest, unless dest already points to
+// digest, the digest of t. Without overwrite, it fails if dest points to
+// something else.
+func push(ctx context.Context, pusher *remote.Pusher, src name.Reference, t remote.Taggable, digest v1.Hash, dest name.Reference, overwrite bool, opts ...remote.Option) error {
 	if !overwrite {
 		if dstDesc, err := remote.Head(dest, opts...); err == nil {
-			if descriptorsEqual(&got.Descriptor, dstDesc) {
+			if descriptorsEqual(&v1.Descriptor{Digest: digest}, dstDesc) {
 				return nil
 			}
 			return fmt.Errorf("image %q already exists. Use `-f` to overwrite", dest.Name())
@@ -177,26 +205,22 @@ func remoteCopy(ctx context.Context, pusher *remote.Pusher, src, dest name.Refer
 	}
 
 	fmt.Fprintf(os.Stderr, "Copying %s to %s...\n", src, dest)
-	return pusher.Push(ctx, dest, got)
+	return pusher.Push(ctx, dest, t)
 }
 
-func parseOnlyOpt(onlyFlag string, sigOnly bool) []tagMap {
-	var tags []tagMap
-	tagSet := sets.New(strings.Split(onlyFlag, ",")...)
-
+// parseOnlyOpt returns the artifacts to copy, all of them unless onlyFlag or
+// the deprecated sigOnly select some.
+func parseOnlyOpt(onlyFlag string, sigOnly bool) ([]ociremote.Artifact, error) {
 	if sigOnly {
 		fmt.Fprintf(os.Stderr, "--sig-only is deprecated, use --only=sig instead")
-		tagSet.Insert("sign")
-	}
-
-	if tagSet.Has("sig") {
-		tags = append(tags, ociremote.SignatureTag)
+		onlyFlag = strings.Trim(onlyFlag+","+string(ociremote.ArtifactSignature), ",")
 	}
-	if tagSet.Has("sbom") {
-		tags = append(tags, ociremote.SBOMTag)
+	if onlyFlag == "" {
+		return ociremote.Artifacts, nil
 	}
-	if tagSet.Has("att") {
-		tags = append(tags, ociremote.AttestationTag)
+	artifacts, err := ociremote.ParseArtifacts(onlyFlag)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --only: %w", err)
 	}
-	return tags
+	return artifacts, nil
 }
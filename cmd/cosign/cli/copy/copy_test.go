@@ -16,9 +16,20 @@ package copy
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/oci/ocitest"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 )
 
 func TestCopyAttachmentTagPrefix(t *testing.T) {
@@ -33,7 +44,7 @@ func TestCopyAttachmentTagPrefix(t *testing.T) {
 
 	err := CopyCmd(ctx, options.RegistryOptions{
 		RefOpts: refOpts,
-	}, srcImg, destImg, false, true, "", "")
+	}, srcImg, destImg, false, true, "", "", 1)
 	if err == nil {
 		t.Fatal("failed to copy with attachment-tag-prefix")
 	}
@@ -45,8 +56,149 @@ func TestCopyPlatformOpt(t *testing.T) {
 	srcImg := "alpine"
 	destImg := "test-alpine"
 
-	err := CopyCmd(ctx, options.RegistryOptions{}, srcImg, destImg, false, true, "", "linux/amd64")
+	err := CopyCmd(ctx, options.RegistryOptions{}, srcImg, destImg, false, true, "", "linux/amd64", 1)
 	if err == nil {
 		t.Fatal("failed to copy with platform")
 	}
 }
+
+func TestCopyPlatformFilter(t *testing.T) {
+	r := ocitest.New(t)
+	src, dst := r.Repository("src").Tag("latest"), r.Repository("dst").Tag("latest")
+
+	var ii v1.ImageIndex = empty.Index
+	digests := map[string]v1.Hash{}
+	for _, p := range []string{"linux/amd64", "linux/arm64", "linux/s390x"} {
+		img, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		plat, err := v1.ParsePlatform(p)
+		if err != nil {
+			t.Fatalf("ParsePlatform() = %v", err)
+		}
+		ii = mutate.AppendManifests(ii, mutate.IndexAddendum{Add: img, Descriptor: v1.Descriptor{Platform: plat}})
+		if digests[p], err = img.Digest(); err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+	}
+	if err := remote.WriteIndex(src, ii); err != nil {
+		t.Fatalf("WriteIndex() = %v", err)
+	}
+	sig, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	sigTag, err := ociremote.SignatureTag(src.Context().Digest(digests["linux/arm64"].String()))
+	if err != nil {
+		t.Fatalf("SignatureTag() = %v", err)
+	}
+	if err := remote.Write(sigTag, sig); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+
+	if err := CopyCmd(context.Background(), options.RegistryOptions{}, src.String(), dst.String(), false, false, "", "linux/arm64,linux/amd64", 1); err != nil {
+		t.Fatalf("CopyCmd() = %v", err)
+	}
+
+	idx, err := remote.Index(dst)
+	if err != nil {
+		t.Fatalf("Index() = %v", err)
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatalf("IndexManifest() = %v", err)
+	}
+	if len(im.Manifests) != 2 || im.Manifests[0].Digest != digests["linux/amd64"] || im.Manifests[1].Digest != digests["linux/arm64"] {
+		t.Errorf("copied index = %v, wanted the linux/amd64 and linux/arm64 images", im.Manifests)
+	}
+	if _, err := remote.Head(dst.Context().Tag(sigTag.TagStr())); err != nil {
+		t.Errorf("signature of the linux/arm64 image was not copied: %v", err)
+	}
+	if _, err := remote.Head(dst.Context().Digest(digests["linux/s390x"].String())); err == nil {
+		t.Error("the linux/s390x image was copied")
+	}
+
+	// The rewritten index has a digest of its own.
+	if err := CopyCmd(context.Background(), options.RegistryOptions{}, src.String(), dst.Context().Digest(digests["linux/amd64"].String()).String(), false, false, "", "linux/amd64", 1); err == nil {
+		t.Error("CopyCmd() to a digest succeeded with --platform")
+	}
+}
+
+func TestCopyOnly(t *testing.T) {
+	r := ocitest.New(t)
+	src, dst := r.Repository("src").Tag("latest"), r.Repository("dst").Tag("latest")
+
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	if err := remote.Write(src, img); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	subject := src.Context().Digest(h.String())
+	tags := map[string]name.Tag{}
+	for kind, tm := range map[string]func(name.Reference, ...ociremote.Option) (name.Tag, error){
+		"sig": ociremote.SignatureTag, "att": ociremote.AttestationTag,
+	} {
+		tag, err := tm(subject)
+		if err != nil {
+			t.Fatalf("%s tag = %v", kind, err)
+		}
+		artifact, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		if err := remote.Write(tag, artifact); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+		tags[kind] = dst.Context().Tag(tag.TagStr())
+	}
+
+	if err := CopyCmd(context.Background(), options.RegistryOptions{}, src.String(), dst.String(), false, false, "att", "", 1); err != nil {
+		t.Fatalf("CopyCmd() = %v", err)
+	}
+	if _, err := remote.Head(tags["att"]); err != nil {
+		t.Errorf("attestations were not copied: %v", err)
+	}
+	if _, err := remote.Head(tags["sig"]); err == nil {
+		t.Error("signatures were copied with --only=att")
+	}
+	if _, err := remote.Head(dst); err == nil {
+		t.Error("the image was copied with --only=att")
+	}
+
+	if err := CopyCmd(context.Background(), options.RegistryOptions{}, src.String(), dst.String(), false, false, "image,sig", "", 1); err != nil {
+		t.Fatalf("CopyCmd() = %v", err)
+	}
+	if _, err := remote.Head(tags["sig"]); err != nil {
+		t.Errorf("signatures were not copied: %v", err)
+	}
+	if d, err := remote.Head(dst); err != nil || d.Digest != h {
+		t.Errorf("Head(%s) = %v, %v, wanted the image %s", dst, d, err, h)
+	}
+
+	if err := CopyCmd(context.Background(), options.RegistryOptions{}, src.String(), dst.String(), false, false, "sig,layers", "", 1); err == nil {
+		t.Error("CopyCmd() succeeded with an unknown artifact")
+	}
+}
+
+func TestCopyQueueErrorOrder(t *testing.T) {
+	for _, jobs := range []int{1, 2, 8} {
+		q := newCopyQueue(jobs)
+		q.Go(func() error {
+			time.Sleep(50 * time.Millisecond)
+			return errors.New("first")
+		})
+		q.Go(func() error { return nil })
+		q.Go(func() error { return errors.New("second") })
+		err := q.Wait()
+		if err == nil || err.Error() != "first\nsecond" {
+			t.Errorf("Wait() with %d jobs = %v, wanted first and second in order", jobs, err)
+		}
+	}
+}
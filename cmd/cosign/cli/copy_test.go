@@ -0,0 +1,55 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+)
+
+func TestCheckCopySign(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		o       options.CopyOptions
+		wantErr bool
+	}{{
+		name: "no signing",
+	}, {
+		name: "sign",
+		o:    options.CopyOptions{Sign: true, Key: "cosign.key"},
+	}, {
+		name: "sign keyless with images only",
+		o:    options.CopyOptions{Sign: true, CopyOnly: "image"},
+	}, {
+		name:    "key without sign",
+		o:       options.CopyOptions{Key: "cosign.key"},
+		wantErr: true,
+	}, {
+		name:    "sign without images",
+		o:       options.CopyOptions{Sign: true, CopyOnly: "sig,att"},
+		wantErr: true,
+	}, {
+		name:    "sign with sig-only",
+		o:       options.CopyOptions{Sign: true, SignatureOnly: true},
+		wantErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkCopySign(&tc.o); (err != nil) != tc.wantErr {
+				t.Errorf("checkCopySign() = %v, wanted error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 )
 
 func Dockerfile() *cobra.Command {
@@ -75,6 +76,15 @@ Shell-like variables in the Dockerfile's FROM lines will be substituted with val
   # verify images with public key stored in Google Cloud KMS
   cosign dockerfile verify --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] <path/to/Dockerfile>
 
+  # verify images with public key stored in Oracle Cloud Infrastructure Vault
+  cosign dockerfile verify --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <path/to/Dockerfile>
+
+  # verify images with public key stored in Alibaba Cloud KMS
+  cosign dockerfile verify --key alibabakms://[REGION]/[KEY_ID] <path/to/Dockerfile>
+
+  # verify images with public key stored in Tencent Cloud KMS
+  cosign dockerfile verify --key tencentkms://[REGION]/[KEY_ID] <path/to/Dockerfile>
+
   # verify images with public key stored in Hashicorp Vault
   cosign dockerfile verify --key hashivault://[KEY] <path/to/Dockerfile>`,
 		Args: cobra.ExactArgs(1),
@@ -83,6 +93,10 @@ Shell-like variables in the Dockerfile's FROM lines will be substituted with val
 			if err != nil {
 				return err
 			}
+			annotationConditions, err := cosign.ParseAnnotationConditions(o.AnnotationConditions)
+			if err != nil {
+				return err
+			}
 			v := &dockerfile.VerifyDockerfileCommand{
 				VerifyCommand: verify.VerifyCommand{
 					RegistryOptions:              o.Registry,
@@ -102,13 +116,20 @@ Shell-like variables in the Dockerfile's FROM lines will be substituted with val
 					Slot:                         o.SecurityKey.Slot,
 					Output:                       o.Output,
 					RekorURL:                     o.Rekor.URL,
+					AdditionalRekorURLs:          o.Rekor.AdditionalURLs,
 					Attachment:                   o.Attachment,
 					Annotations:                  annotations,
+					AnnotationConditions:         annotationConditions,
 					LocalImage:                   o.LocalImage,
+					Platform:                     o.Platform,
 					Offline:                      o.CommonVerifyOptions.Offline,
 					TSACertChainPath:             o.CommonVerifyOptions.TSACertChainPath,
 					IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
 					MaxWorkers:                   o.CommonVerifyOptions.MaxWorkers,
+					SignatureMirrors:             o.SignatureMirrors.Mirrors,
+					RegistryMirrorConfig:         o.RegistryMirrors.Config,
+					SignatureCacheDir:            o.SignatureCache.Dir,
+					SignatureCacheTTL:            o.SignatureCache.TTL,
 				},
 				BaseOnly: o.BaseImageOnly,
 			}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/download"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
 )
 
 func Download() *cobra.Command {
@@ -42,19 +43,24 @@ func Download() *cobra.Command {
 
 func downloadSignature() *cobra.Command {
 	o := &options.RegistryOptions{}
+	so := &options.OutputSchemaOptions{}
 
 	cmd := &cobra.Command{
 		Use:              "signature",
 		Short:            "Download signatures from the supplied container image",
 		Example:          "  cosign download signature <image uri>",
-		Args:             cobra.ExactArgs(1),
+		Args:             argsOrOutputSchema(so, cobra.ExactArgs(1)),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if so.OutputSchema {
+				return printOutputSchema(schema.DownloadSignature)
+			}
 			return download.SignatureCmd(cmd.Context(), *o, args[0])
 		},
 	}
 
 	o.AddFlags(cmd)
+	so.AddFlags(cmd)
 
 	return cmd
 }
@@ -87,20 +93,25 @@ func downloadSBOM() *cobra.Command {
 func downloadAttestation() *cobra.Command {
 	o := &options.RegistryOptions{}
 	ao := &options.AttestationDownloadOptions{}
+	so := &options.OutputSchemaOptions{}
 
 	cmd := &cobra.Command{
 		Use:              "attestation",
 		Short:            "Download in-toto attestations from the supplied container image",
 		Example:          "  cosign download attestation <image uri> [--predicate-type]",
-		Args:             cobra.ExactArgs(1),
+		Args:             argsOrOutputSchema(so, cobra.ExactArgs(1)),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if so.OutputSchema {
+				return printOutputSchema(schema.DownloadAttestation)
+			}
 			return download.AttestationCmd(cmd.Context(), *o, *ao, args[0])
 		},
 	}
 
 	o.AddFlags(cmd)
 	ao.AddFlags(cmd)
+	so.AddFlags(cmd)
 
 	return cmd
 }
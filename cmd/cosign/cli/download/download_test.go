@@ -0,0 +1,78 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+)
+
+func TestOutputMatchesSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		out    interface{}
+	}{{
+		name:   "signature",
+		schema: schema.DownloadSignature,
+		out: cosign.SignedPayload{
+			Base64Signature: "MEUCIQ==",
+			Payload:         []byte(`{"critical":{}}`),
+		},
+	}, {
+		name:   "signature with certificate and bundle",
+		schema: schema.DownloadSignature,
+		out: cosign.SignedPayload{
+			Base64Signature: "MEUCIQ==",
+			Payload:         []byte(`{"critical":{}}`),
+			Cert:            &x509.Certificate{},
+			Chain:           []*x509.Certificate{{}},
+			Bundle: &bundle.RekorBundle{
+				SignedEntryTimestamp: []byte("set"),
+				Payload: bundle.RekorPayload{
+					Body:           "e30=",
+					IntegratedTime: 1700000000,
+					LogIndex:       1,
+					LogID:          "c0d23d6ad406973f",
+				},
+			},
+			RFC3161Timestamp: &bundle.RFC3161Timestamp{SignedRFC3161Timestamp: []byte("ts")},
+		},
+	}, {
+		name:   "attestation",
+		schema: schema.DownloadAttestation,
+		out: cosign.AttestationPayload{
+			PayloadType: "application/vnd.in-toto+json",
+			PayLoad:     "e30=",
+			Signatures:  []cosign.Signatures{{Sig: "MEUCIQ=="}},
+		},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.out)
+			if err != nil {
+				t.Fatalf("json.Marshal() = %v", err)
+			}
+			if err := schema.Validate(tc.schema, b); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign/privacy"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/fulcio/fulcioroots"
+	"github.com/franchb/cosign/v2/internal/pkg/ratelimit"
 	"github.com/franchb/cosign/v2/internal/ui"
 	"github.com/franchb/cosign/v2/pkg/providers"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
@@ -118,25 +119,9 @@ func NewSigner(ctx context.Context, ko options.KeyOpts, signer signature.SignerV
 		return nil, fmt.Errorf("creating Fulcio client: %w", err)
 	}
 
-	idToken, err := idToken(ko.IDToken)
+	idToken, err := GetIDToken(ctx, ko)
 	if err != nil {
-		return nil, fmt.Errorf("getting id token: %w", err)
-	}
-	var provider providers.Interface
-	// If token is not set in the options, get one from the provders
-	if idToken == "" && providers.Enabled(ctx) && !ko.OIDCDisableProviders {
-		if ko.OIDCProvider != "" {
-			provider, err = providers.ProvideFrom(ctx, ko.OIDCProvider)
-			if err != nil {
-				return nil, fmt.Errorf("getting provider: %w", err)
-			}
-			idToken, err = provider.Provide(ctx, "sigstore")
-		} else {
-			idToken, err = providers.Provide(ctx, "sigstore")
-		}
-		if err != nil {
-			return nil, fmt.Errorf("fetching ambient OIDC credentials: %w", err)
-		}
+		return nil, err
 	}
 
 	fmt.Fprintln(os.Stderr, "Retrieving signed certificate...")
@@ -182,6 +167,42 @@ func NewSigner(ctx context.Context, ko options.KeyOpts, signer signature.SignerV
 	return f, nil
 }
 
+// GetIDToken returns the identity token of ko.IDToken, of the OIDC
+// provider ko.OIDCProvider or of an ambient OIDC provider, in that order,
+// or empty if none applies and an interactive flow must get one.
+func GetIDToken(ctx context.Context, ko options.KeyOpts) (string, error) {
+	idToken, err := idToken(ko.IDToken)
+	if err != nil {
+		return "", fmt.Errorf("getting id token: %w", err)
+	}
+	var provider providers.Interface
+	// If token is not set in the options, get one from the provders
+	switch {
+	case idToken != "" || ko.OIDCDisableProviders:
+	case ko.OIDCProvider != "":
+		// A provider asked for by name must supply the token, such as the
+		// SPIFFE Workload API of a mesh workload, rather than fall back to
+		// an interactive flow.
+		provider, err = providers.ProvideFrom(ctx, ko.OIDCProvider)
+		if err != nil {
+			return "", fmt.Errorf("getting provider: %w", err)
+		}
+		if !provider.Enabled(ctx) {
+			return "", fmt.Errorf("OIDC provider %s is not available in this environment", ko.OIDCProvider)
+		}
+		idToken, err = provider.Provide(ctx, "sigstore")
+		if err != nil {
+			return "", fmt.Errorf("fetching ambient OIDC credentials: %w", err)
+		}
+	case providers.Enabled(ctx):
+		idToken, err = providers.Provide(ctx, "sigstore")
+		if err != nil {
+			return "", fmt.Errorf("fetching ambient OIDC credentials: %w", err)
+		}
+	}
+	return idToken, nil
+}
+
 func (f *Signer) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) { //nolint: revive
 	return f.SignerVerifier.PublicKey()
 }
@@ -202,7 +223,7 @@ func NewClient(fulcioURL string) (api.LegacyClient, error) {
 		return nil, err
 	}
 	fClient := api.NewClient(fulcioServer, api.WithUserAgent(options.UserAgent()))
-	return fClient, nil
+	return ratelimit.FulcioClient(fClient, ratelimit.For(ratelimit.Fulcio, fulcioURL)), nil
 }
 
 // idToken allows users to either pass in an identity token directly
@@ -16,6 +16,9 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
@@ -40,16 +43,35 @@ to sign payloads with your own tooling or algorithms.`,
   cosign generate -a foo=bar <IMAGE>
 
   # Use this payload in another tool
-  gpg --output image.sig --detach-sig <(cosign generate <IMAGE>)`,
+  gpg --output image.sig --detach-sig <(cosign generate <IMAGE>)
+
+  # Generate the bytes to sign for an attestation, to sign them on another machine
+  cosign generate --format dsse --predicate <FILE> --type slsaprovenance <IMAGE> > payload.pae`,
 
 		Args:             cobra.ExactArgs(1),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			annotationMap, err := o.AnnotationsMap()
-			if err != nil {
-				return err
+			switch o.Format {
+			case "simplesigning":
+				if o.PredicatePath != "" {
+					return errors.New("--predicate is only supported with --format dsse")
+				}
+				annotationMap, err := o.AnnotationsMap()
+				if err != nil {
+					return err
+				}
+				return generate.GenerateCmd(cmd.Context(), o.Registry, args[0], annotationMap.Annotations, cmd.OutOrStdout())
+			case "dsse":
+				if o.PredicatePath == "" {
+					return errors.New("--predicate is required with --format dsse")
+				}
+				if len(o.Annotations) > 0 {
+					return errors.New("annotations are not supported with --format dsse")
+				}
+				return generate.GenerateDSSECmd(cmd.Context(), o.Registry, args[0], o.PredicatePath, o.PredicateOptions.Type, cmd.OutOrStdout())
+			default:
+				return fmt.Errorf("unsupported format %q, must be simplesigning or dsse", o.Format)
 			}
-			return generate.GenerateCmd(cmd.Context(), o.Registry, args[0], annotationMap.Annotations, cmd.OutOrStdout())
 		},
 	}
 
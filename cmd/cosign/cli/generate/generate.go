@@ -17,12 +17,19 @@ package generate
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/attestation"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/types"
 	"github.com/franchb/sigstore/pkg/signature/payload"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 )
 
 // nolint
@@ -51,3 +58,44 @@ func GenerateCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef
 	w.Write(json)
 	return nil
 }
+
+// GenerateDSSECmd writes the DSSE pre-authentication encoding of the in-toto
+// statement attesting predicatePath for imageRef, as 'cosign attest' signs
+// it. Signing these bytes with any tool yields the signature of the DSSE
+// envelope, which 'cosign attach signature' attaches as an attestation.
+func GenerateDSSECmd(ctx context.Context, regOpts options.RegistryOptions, imageRef, predicatePath, predicateType string, w io.Writer) error {
+	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return err
+	}
+	digest, err := ociremote.ResolveDigest(ref, ociremoteOpts...)
+	if err != nil {
+		return err
+	}
+
+	predicate, err := os.Open(filepath.Clean(predicatePath))
+	if err != nil {
+		return err
+	}
+	defer predicate.Close()
+	h, _ := v1.NewHash(digest.Identifier())
+	sh, err := attestation.GenerateStatement(attestation.GenerateOpts{
+		Predicate: predicate,
+		Type:      predicateType,
+		Digest:    h.Hex,
+		Repo:      digest.Repository.String(),
+	})
+	if err != nil {
+		return err
+	}
+	statement, err := json.Marshal(sh)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(dsse.PAE(types.IntotoPayloadType, statement))
+	return err
+}
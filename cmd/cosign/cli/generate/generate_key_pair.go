@@ -43,10 +43,17 @@ var (
 )
 
 // nolint
-func GenerateKeyPairCmd(ctx context.Context, kmsVal string, outputKeyPrefixVal string, args []string) error {
+func GenerateKeyPairCmd(ctx context.Context, kmsVal string, outputKeyPrefixVal string, keyOpts cosign.KeyPairOptions, ageRecipients []string, args []string) error {
 	privateKeyFileName := outputKeyPrefixVal + ".key"
 	publicKeyFileName := outputKeyPrefixVal + ".pub"
 
+	if len(ageRecipients) > 0 && (kmsVal != "" || len(args) > 0) {
+		return errors.New("--age-recipient is only supported for key pairs written to files")
+	}
+	if keyOpts != (cosign.KeyPairOptions{}) && (kmsVal != "" || len(args) > 0) {
+		return errors.New("--key-type and --rsa-scheme are only supported for key pairs written to files")
+	}
+
 	if kmsVal != "" {
 		k, err := kms.Get(ctx, kmsVal, crypto.SHA256)
 		if err != nil {
@@ -86,7 +93,7 @@ func GenerateKeyPairCmd(ctx context.Context, kmsVal string, outputKeyPrefixVal s
 		return fmt.Errorf("undefined provider: %s", provider)
 	}
 
-	keys, err := cosign.GenerateKeyPair(GetPass)
+	keys, err := generateKeys(keyOpts, ageRecipients)
 	if err != nil {
 		return err
 	}
@@ -107,6 +114,20 @@ func GenerateKeyPairCmd(ctx context.Context, kmsVal string, outputKeyPrefixVal s
 	return writeKeyFiles(privateKeyFileName, publicKeyFileName, keys)
 }
 
+// generateKeys generates a key pair of the type selected by keyOpts,
+// encrypting the private key to ageRecipients if any are given, or with a
+// password otherwise.
+func generateKeys(keyOpts cosign.KeyPairOptions, ageRecipients []string) (*cosign.KeysBytes, error) {
+	if len(ageRecipients) == 0 {
+		return cosign.GenerateKeyPairWithOptions(keyOpts, GetPass)
+	}
+	recipients, err := cosign.ParseAgeRecipients(ageRecipients)
+	if err != nil {
+		return nil, err
+	}
+	return cosign.GenerateKeyPairAge(keyOpts, recipients)
+}
+
 func writeKeyFiles(privateKeyFileName string, publicKeyFileName string, keys *cosign.KeysBytes) error {
 	// TODO: make sure the perms are locked down first.
 	if err := os.WriteFile(privateKeyFileName, keys.PrivateBytes, 0600); err != nil {
@@ -17,10 +17,15 @@ package generate
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"os"
 	"testing"
 
+	"filippo.io/age"
 	icos "github.com/franchb/cosign/v2/internal/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -57,12 +62,76 @@ func TestGenerationOfKeys(t *testing.T) {
 	// be default it's set to `cosign`, but this is done by the CLI flag
 	// framework if there is no value set by the user when running the
 	// command.
-	GenerateKeyPairCmd(context.Background(), "", "my-test", nil)
+	GenerateKeyPairCmd(context.Background(), "", "my-test", cosign.KeyPairOptions{}, nil, nil)
 
 	checkIfFileExistsThenDelete(privateKeyName, t)
 	checkIfFileExistsThenDelete(publicKeyName, t)
 }
 
+func TestGenerationOfKeysAge(t *testing.T) {
+	var privateKeyName = "my-age-test.key"
+	var publicKeyName = "my-age-test.pub"
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No password is needed when encrypting to age recipients.
+	Read = func(bool) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			return nil, errors.New("unexpected password prompt")
+		}
+	}
+	t.Cleanup(func() { Read = readPasswordFn })
+
+	if err := GenerateKeyPairCmd(context.Background(), "", "my-age-test", cosign.KeyPairOptions{}, []string{id.Recipient().String()}, nil); err != nil {
+		t.Fatalf("GenerateKeyPairCmd() = %v", err)
+	}
+	defer checkIfFileExistsThenDelete(privateKeyName, t)
+	defer checkIfFileExistsThenDelete(publicKeyName, t)
+
+	kb, err := os.ReadFile(privateKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cosign.LoadAgePrivateKey(kb, []age.Identity{id}); err != nil {
+		t.Fatalf("LoadAgePrivateKey() = %v", err)
+	}
+
+	if err := GenerateKeyPairCmd(context.Background(), "", "my-age-test", cosign.KeyPairOptions{}, []string{id.Recipient().String()}, []string{"k8s://default/cosign"}); err == nil {
+		t.Error("GenerateKeyPairCmd() succeeded with age recipients for a Kubernetes secret")
+	}
+}
+
+func TestGenerationOfKeysKeyType(t *testing.T) {
+	var privateKeyName = "my-ed25519-test.key"
+	var publicKeyName = "my-ed25519-test.pub"
+	t.Setenv("COSIGN_PASSWORD", "test")
+
+	keyOpts := cosign.KeyPairOptions{KeyType: cosign.KeyTypeED25519}
+	if err := GenerateKeyPairCmd(context.Background(), "", "my-ed25519-test", keyOpts, nil, nil); err != nil {
+		t.Fatalf("GenerateKeyPairCmd() = %v", err)
+	}
+	defer checkIfFileExistsThenDelete(privateKeyName, t)
+	defer checkIfFileExistsThenDelete(publicKeyName, t)
+
+	pb, err := os.ReadFile(publicKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pub.(ed25519.PublicKey); !ok {
+		t.Errorf("generated public key is %T, wanted ed25519.PublicKey", pub)
+	}
+
+	if err := GenerateKeyPairCmd(context.Background(), "", "my-ed25519-test", keyOpts, nil, []string{"k8s://default/cosign"}); err == nil {
+		t.Error("GenerateKeyPairCmd() succeeded with a key type for a Kubernetes secret")
+	}
+}
+
 func checkIfFileExistsThenDelete(fileName string, t *testing.T) {
 	fileExists, err := icos.FileExists(fileName)
 	if err != nil {
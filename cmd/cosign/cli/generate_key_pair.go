@@ -20,6 +20,7 @@ import (
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 )
 
 func GenerateKeyPair() *cobra.Command {
@@ -37,6 +38,15 @@ func GenerateKeyPair() *cobra.Command {
   # generate key-pair and write to custom named my-name.key and my-name.pub files
   cosign generate-key-pair --output-key-prefix my-name
 
+  # generate an ed25519 key-pair
+  cosign generate-key-pair --key-type ed25519
+
+  # generate a 4096 bit RSA key-pair signing with RSA-PSS
+  cosign generate-key-pair --key-type rsa-4096 --rsa-scheme pss
+
+  # generate key-pair with the private key encrypted to an age recipient instead of a password
+  cosign generate-key-pair --age-recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+
   # generate a key-pair in Azure Key Vault
   cosign generate-key-pair --kms azurekms://[VAULT_NAME][VAULT_URI]/[KEY]
 
@@ -46,9 +56,21 @@ func GenerateKeyPair() *cobra.Command {
   # generate a key-pair in Google Cloud KMS
   cosign generate-key-pair --kms gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY]
 
+  # generate a key-pair in Oracle Cloud Infrastructure Vault
+  cosign generate-key-pair --kms ocikms://[VAULT_CRYPTO_ENDPOINT]/compartment/[COMPARTMENT_OCID]/keyname/[KEY_NAME]
+
+  # generate a key-pair in Alibaba Cloud KMS
+  cosign generate-key-pair --kms alibabakms://[REGION]/alias/[ALIAS]
+
+  # generate a key-pair in Tencent Cloud KMS
+  cosign generate-key-pair --kms tencentkms://[REGION]/alias/[ALIAS]
+
   # generate a key-pair in Hashicorp Vault
   cosign generate-key-pair --kms hashivault://[KEY]
 
+  # generate a key-pair with the signer plugin cosign-signer-[NAME] in PATH
+  cosign generate-key-pair --kms plugin://[NAME]/[KEY]
+
   # generate a key-pair in Kubernetes Secret
   cosign generate-key-pair k8s://[NAMESPACE]/[NAME]
 
@@ -63,11 +85,14 @@ func GenerateKeyPair() *cobra.Command {
 
 CAVEATS:
   This command interactively prompts for a password. You can use
-  the COSIGN_PASSWORD environment variable to provide one.`,
+  the COSIGN_PASSWORD environment variable to provide one. Private keys
+  encrypted with --age-recipient are decrypted with the age identity file
+  named by the COSIGN_AGE_IDENTITY_FILE environment variable.`,
 
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return generate.GenerateKeyPairCmd(cmd.Context(), o.KMS, o.OutputKeyPrefix, args)
+			keyOpts := cosign.KeyPairOptions{KeyType: o.KeyType, RSAScheme: o.RSAScheme}
+			return generate.GenerateKeyPairCmd(cmd.Context(), o.KMS, o.OutputKeyPrefix, keyOpts, o.AgeRecipients, args)
 		},
 	}
 
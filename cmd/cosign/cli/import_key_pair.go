@@ -27,8 +27,8 @@ func ImportKeyPair() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:   "import-key-pair",
-		Short: "Imports a PEM-encoded RSA or EC private key.",
-		Long:  "Imports a PEM-encoded RSA or EC private key for signing.",
+		Short: "Imports a private key from a PEM file, PKCS #12 bundle or JWK.",
+		Long:  "Imports a PEM-encoded RSA, EC or ED25519 private key, a PKCS #12 bundle or a JWK for signing.",
 		Example: `  cosign import-key-pair  --key openssl.key --output-key-prefix my-key
 
   # import PEM-encoded RSA or EC private key and write to import-cosign.key and import-cosign.pub files
@@ -37,9 +37,25 @@ func ImportKeyPair() *cobra.Command {
   # import PEM-encoded RSA or EC private key and write to my-key.key and my-key.pub files
   cosign import-key-pair --key <key path> --output-key-prefix my-key
 
+  # import PEM-encoded RSA or EC private key, encrypting it to an age recipient instead of a password
+  cosign import-key-pair --key <key path> --age-recipient <age1... public key or recipients file>
+
+  # import the key of a PKCS #12 bundle, writing its certificate and chain to my-key.crt and my-key-chain.crt
+  COSIGN_PKCS12_PASSWORD=<bundle password> cosign import-key-pair --key signer.p12 --output-key-prefix my-key
+
+  # import the private key with key id "signing" from a JWK set
+  cosign import-key-pair --key keys.json --jwk-key-id signing
+
+  # re-encrypt a cosign private key in the Argon2id format, writing my-key.key and my-key.pub files
+  cosign import-key-pair --key cosign.key --upgrade --output-key-prefix my-key
+
 CAVEATS:
   This command interactively prompts for a password. You can use
-  the COSIGN_PASSWORD environment variable to provide one.`,
+  the COSIGN_PASSWORD environment variable to provide one. Private keys
+  encrypted with --age-recipient are decrypted with the age identity file
+  named by the COSIGN_AGE_IDENTITY_FILE environment variable. The password
+  of PKCS #12 bundles is prompted for on a terminal, or read from the
+  COSIGN_PKCS12_PASSWORD environment variable.`,
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return importkeypair.ImportKeyPairCmd(cmd.Context(), *o, args)
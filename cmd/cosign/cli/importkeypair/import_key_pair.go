@@ -17,15 +17,19 @@ package importkeypair
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"syscall"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	icos "github.com/franchb/cosign/v2/internal/pkg/cosign"
 	"github.com/franchb/cosign/v2/internal/ui"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
+	"golang.org/x/term"
 )
 
 var (
@@ -35,9 +39,42 @@ var (
 
 // nolint
 func ImportKeyPairCmd(ctx context.Context, o options.ImportKeyPairOptions, args []string) error {
-	keys, err := cosign.ImportKeyPair(o.Key, GetPass)
-	if err != nil {
-		return err
+	importOpts := cosign.ImportOptions{
+		BundlePassFunc: GetBundlePass,
+		KeyID:          o.JWKKeyID,
+	}
+	var keys *cosign.KeysBytes
+	if o.Upgrade {
+		if len(o.AgeRecipients) > 0 {
+			return errors.New("--upgrade cannot be used with --age-recipient")
+		}
+		kb, err := os.ReadFile(filepath.Clean(o.Key))
+		if err != nil {
+			return err
+		}
+		pw, err := GetPass(false)
+		if err != nil {
+			return err
+		}
+		keys, err = cosign.UpgradePrivateKey(kb, pw)
+		if err != nil {
+			return err
+		}
+	} else if len(o.AgeRecipients) > 0 {
+		recipients, err := cosign.ParseAgeRecipients(o.AgeRecipients)
+		if err != nil {
+			return err
+		}
+		keys, err = cosign.ImportKeyPairAge(o.Key, importOpts, recipients)
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		keys, err = cosign.ImportKeyPairWithOptions(o.Key, importOpts, GetPass)
+		if err != nil {
+			return err
+		}
 	}
 
 	privateKeyFileName := o.OutputKeyPrefix + ".key"
@@ -66,6 +103,23 @@ func ImportKeyPairCmd(ctx context.Context, o options.ImportKeyPairOptions, args
 		return err
 	} // #nosec G306
 	fmt.Fprintln(os.Stderr, "Public key written to", publicKeyFileName)
+
+	// PKCS #12 bundles and JWKs can carry the certificate chain of the key,
+	// kept for use with sign --certificate and --certificate-chain.
+	if len(keys.CertificateBytes) > 0 {
+		certificateFileName := o.OutputKeyPrefix + ".crt"
+		if err := os.WriteFile(certificateFileName, keys.CertificateBytes, 0644); err != nil {
+			return err
+		} // #nosec G306
+		fmt.Fprintln(os.Stderr, "Certificate written to", certificateFileName)
+	}
+	if len(keys.CertificateChainBytes) > 0 {
+		chainFileName := o.OutputKeyPrefix + "-chain.crt"
+		if err := os.WriteFile(chainFileName, keys.CertificateChainBytes, 0644); err != nil {
+			return err
+		} // #nosec G306
+		fmt.Fprintln(os.Stderr, "Certificate chain written to", chainFileName)
+	}
 	return nil
 }
 
@@ -74,6 +128,24 @@ func GetPass(confirm bool) ([]byte, error) {
 	return read()
 }
 
+// GetBundlePass returns the password of an imported PKCS #12 bundle, from
+// COSIGN_PKCS12_PASSWORD or prompted for on the terminal. Without either,
+// the bundle is opened with an empty password.
+func GetBundlePass(_ bool) ([]byte, error) {
+	if pw, ok := env.LookupEnv(env.VariablePKCS12Password); ok {
+		return []byte(pw), nil
+	}
+	if !cosign.IsTerminal() {
+		return nil, nil
+	}
+	fmt.Fprint(os.Stderr, "Enter password for PKCS #12 bundle: ")
+	// Unnecessary convert of syscall.Stdin on *nix, but Windows is a uintptr
+	// nolint:unconvert
+	pw, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	return pw, err
+}
+
 func readPasswordFn(confirm bool) func() ([]byte, error) {
 	pw, ok := env.LookupEnv(env.VariablePassword)
 	switch {
@@ -22,11 +22,14 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	icos "github.com/franchb/cosign/v2/internal/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/google/go-cmp/cmp"
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
 )
 
 func TestReadPasswordFn_env(t *testing.T) {
@@ -79,6 +82,24 @@ func TestImportOfKeys(t *testing.T) {
 	checkIfFileExistsThenDelete(outputtedKeyPairFileName+".pub", t)
 }
 
+func TestImportOfPKCS12Bundle(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "test")
+	t.Setenv("COSIGN_PKCS12_PASSWORD", "hello")
+
+	outputtedKeyPairFileName := "my-pkcs12-test"
+	if err := ImportKeyPairCmd(context.Background(), options.ImportKeyPairOptions{
+		Key:             "../../../../pkg/cosign/testdata/pkcs12/ecdsa.p12",
+		OutputKeyPrefix: outputtedKeyPairFileName,
+	}, nil); err != nil {
+		t.Fatalf("ImportKeyPairCmd() = %v", err)
+	}
+
+	checkIfFileExistsThenDelete(outputtedKeyPairFileName+".key", t)
+	checkIfFileExistsThenDelete(outputtedKeyPairFileName+".pub", t)
+	checkIfFileExistsThenDelete(outputtedKeyPairFileName+".crt", t)
+	checkIfFileExistsThenDelete(outputtedKeyPairFileName+"-chain.crt", t)
+}
+
 func createTemporaryPrivateKeyForImporting(privateKeyName string) {
 	bitSize := 4096
 
@@ -124,3 +145,62 @@ func deleteKeyFile(fileName string, t *testing.T) {
 		t.Logf("Removed keyfile %s", fileName)
 	})
 }
+
+func TestUpgradeOfKeys(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "test")
+	td := t.TempDir()
+
+	// A private key in the original scrypt format.
+	priv, err := cosign.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := encrypted.Encrypt(der, []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: cosign.SigstorePrivateKeyPemType, Bytes: enc}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := filepath.Join(td, "upgraded")
+	if err := ImportKeyPairCmd(context.Background(), options.ImportKeyPairOptions{
+		Key:             keyFile,
+		OutputKeyPrefix: prefix,
+		Upgrade:         true,
+	}, nil); err != nil {
+		t.Fatalf("ImportKeyPairCmd() = %v", err)
+	}
+	upgraded, err := os.ReadFile(prefix + ".key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, err := cosign.PrivateKeyMetadata(upgraded); err != nil || md == nil {
+		t.Fatalf("PrivateKeyMetadata() = %v, %v, want the metadata of an upgraded key", md, err)
+	}
+	sv, err := cosign.LoadPrivateKey(upgraded, []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.PublicKey.Equal(pub) {
+		t.Error("the upgraded key is not the original key")
+	}
+
+	if err := ImportKeyPairCmd(context.Background(), options.ImportKeyPairOptions{
+		Key:             keyFile,
+		OutputKeyPrefix: prefix,
+		Upgrade:         true,
+		AgeRecipients:   []string{"age1invalid"},
+	}, nil); err == nil {
+		t.Error("ImportKeyPairCmd() upgraded a key to age recipients")
+	}
+}
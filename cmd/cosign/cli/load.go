@@ -53,8 +53,22 @@ func LoadCmd(ctx context.Context, opts options.LoadOptions, imageRef string) err
 		return fmt.Errorf("parsing image name %s: %w", imageRef, err)
 	}
 
+	dir := opts.Directory
+	isTar, err := layout.IsTar(dir)
+	if err != nil {
+		return err
+	}
+	if isTar {
+		var cleanup func()
+		dir, cleanup, err = layout.OpenTar(opts.Directory)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+
 	// get the signed image from disk
-	sii, err := layout.SignedImageIndex(opts.Directory)
+	sii, err := layout.SignedImageIndex(dir)
 	if err != nil {
 		return fmt.Errorf("signed image index: %w", err)
 	}
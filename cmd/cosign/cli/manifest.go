@@ -23,6 +23,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 )
 
 func Manifest() *cobra.Command {
@@ -69,6 +70,15 @@ against the transparency log.`,
   # verify images with public key stored in Google Cloud KMS
   cosign manifest verify --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] <path/to/my-deployment.yaml>
 
+  # verify images with public key stored in Oracle Cloud Infrastructure Vault
+  cosign manifest verify --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <path/to/my-deployment.yaml>
+
+  # verify images with public key stored in Alibaba Cloud KMS
+  cosign manifest verify --key alibabakms://[REGION]/[KEY_ID] <path/to/my-deployment.yaml>
+
+  # verify images with public key stored in Tencent Cloud KMS
+  cosign manifest verify --key tencentkms://[REGION]/[KEY_ID] <path/to/my-deployment.yaml>
+
   # verify images with public key stored in Hashicorp Vault
   cosign manifest verify --key hashivault://[KEY] <path/to/my-deployment.yaml>`,
 		Args:             cobra.ExactArgs(1),
@@ -78,6 +88,10 @@ against the transparency log.`,
 			if err != nil {
 				return err
 			}
+			annotationConditions, err := cosign.ParseAnnotationConditions(o.AnnotationConditions)
+			if err != nil {
+				return err
+			}
 			v := &manifest.VerifyManifestCommand{
 				VerifyCommand: verify.VerifyCommand{
 					RegistryOptions:              o.Registry,
@@ -97,13 +111,20 @@ against the transparency log.`,
 					Slot:                         o.SecurityKey.Slot,
 					Output:                       o.Output,
 					RekorURL:                     o.Rekor.URL,
+					AdditionalRekorURLs:          o.Rekor.AdditionalURLs,
 					Attachment:                   o.Attachment,
 					Annotations:                  annotations,
+					AnnotationConditions:         annotationConditions,
 					LocalImage:                   o.LocalImage,
+					Platform:                     o.Platform,
 					Offline:                      o.CommonVerifyOptions.Offline,
 					TSACertChainPath:             o.CommonVerifyOptions.TSACertChainPath,
 					IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
 					MaxWorkers:                   o.CommonVerifyOptions.MaxWorkers,
+					SignatureMirrors:             o.SignatureMirrors.Mirrors,
+					RegistryMirrorConfig:         o.RegistryMirrors.Config,
+					SignatureCacheDir:            o.SignatureCache.Dir,
+					SignatureCacheTTL:            o.SignatureCache.TTL,
 				},
 			}
 
@@ -33,7 +33,9 @@ type AttachSignatureOptions struct {
 	CertChain      string
 	TimeStampedSig string
 	RekorBundle    string
+	TLogEntry      string
 	Registry       RegistryOptions
+	Upload         UploadOptions
 }
 
 var _ Interface = (*AttachSignatureOptions)(nil)
@@ -41,6 +43,7 @@ var _ Interface = (*AttachSignatureOptions)(nil)
 // AddFlags implements Interface
 func (o *AttachSignatureOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
+	o.Upload.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Signature, "signature", "",
 		"path to the signature, or {-} for stdin")
@@ -60,6 +63,8 @@ func (o *AttachSignatureOptions) AddFlags(cmd *cobra.Command) {
 		"path to the Time Stamped Signature Response from RFC3161 compliant TSA")
 	cmd.Flags().StringVar(&o.RekorBundle, "rekor-response", "",
 		"path to the rekor bundle")
+	cmd.Flags().StringVar(&o.TLogEntry, "tlog-entry", "",
+		"path to the Rekor log entry of the signature, as returned by the Rekor API, to include as its bundle")
 }
 
 // AttachSBOMOptions is the top level wrapper for the attach sbom command.
@@ -69,6 +74,7 @@ type AttachSBOMOptions struct {
 	SBOMInputFormat      string
 	Registry             RegistryOptions
 	RegistryExperimental RegistryExperimentalOptions
+	Upload               UploadOptions
 }
 
 var _ Interface = (*AttachSBOMOptions)(nil)
@@ -77,6 +83,7 @@ var _ Interface = (*AttachSBOMOptions)(nil)
 func (o *AttachSBOMOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
 	o.RegistryExperimental.AddFlags(cmd)
+	o.Upload.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.SBOM, "sbom", "",
 		"path to the sbom, or {-} for stdin")
@@ -124,14 +131,77 @@ func (o *AttachSBOMOptions) MediaType() (types.MediaType, error) {
 
 // AttachAttestationOptions is the top level wrapper for the attach attestation command.
 type AttachAttestationOptions struct {
-	Attestations []string
-	Registry     RegistryOptions
+	Attestations  []string
+	DSSEEnvelopes []string
+	Registry      RegistryOptions
+	Upload        UploadOptions
 }
 
 // AddFlags implements Interface
 func (o *AttachAttestationOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
+	o.Upload.AddFlags(cmd)
 
 	cmd.Flags().StringArrayVarP(&o.Attestations, "attestation", "", nil,
 		"path to the attestation envelope")
+
+	cmd.Flags().StringArrayVar(&o.DSSEEnvelopes, "dsse", nil,
+		"path to a DSSE envelope of an in-toto statement signed by another tool, such as in-toto or witness, attached without re-signing once checked to be signed and to name the image as a subject")
+	_ = cmd.Flags().SetAnnotation("dsse", cobra.BashCompFilenameExt, []string{"json"})
+	cmd.MarkFlagsOneRequired("attestation", "dsse")
+}
+
+// AttachArtifactOptions is the top level wrapper for the attach artifact command.
+type AttachArtifactOptions struct {
+	Artifact             string
+	Name                 string
+	MediaType            string
+	ArtifactType         string
+	Registry             RegistryOptions
+	RegistryExperimental RegistryExperimentalOptions
+	Upload               UploadOptions
+	AnnotationOptions
+}
+
+var _ Interface = (*AttachArtifactOptions)(nil)
+
+// AddFlags implements Interface
+func (o *AttachArtifactOptions) AddFlags(cmd *cobra.Command) {
+	o.Registry.AddFlags(cmd)
+	o.RegistryExperimental.AddFlags(cmd)
+	o.Upload.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Artifact, "artifact", "",
+		"path to the file to attach, or {-} for stdin")
+	_ = cmd.Flags().SetAnnotation("artifact", cobra.BashCompFilenameExt, []string{})
+	_ = cmd.MarkFlagRequired("artifact")
+
+	cmd.Flags().StringVar(&o.Name, "name", "",
+		"name of the attachment, under which it can be fetched again")
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.Flags().StringVar(&o.MediaType, "media-type", "application/octet-stream",
+		"media type of the attached file")
+
+	cmd.Flags().StringVar(&o.ArtifactType, "artifact-type", "",
+		"artifact type of the attachment, stored as its config media type; defaults to one derived from --name in oci-1-1 referrers mode")
+
+	cmd.Flags().StringSliceVarP(&o.Annotations, "annotations", "a", nil,
+		"extra key=value annotations of the attachment manifest")
+}
+
+// AttachBinarySignatureOptions is the top level wrapper for the attach
+// binary-signature command.
+type AttachBinarySignatureOptions struct {
+	Bundle string
+}
+
+var _ Interface = (*AttachBinarySignatureOptions)(nil)
+
+// AddFlags implements Interface
+func (o *AttachBinarySignatureOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Bundle, "bundle", "",
+		"path to the bundle to embed, as written by cosign sign-blob --bundle")
+	_ = cmd.Flags().SetAnnotation("bundle", cobra.BashCompFilenameExt, []string{})
+	_ = cmd.MarkFlagRequired("bundle")
 }
@@ -26,12 +26,19 @@ type AttestOptions struct {
 	CertChain               string
 	NoUpload                bool
 	Recursive               bool
+	MultiSubject            bool
 	Replace                 bool
 	SkipConfirmation        bool
 	TlogUpload              bool
-	TSAServerURL            string
+	TSAServerURLs           []string
 	RekorEntryType          string
 	RecordCreationTimestamp bool
+	Deterministic           bool
+	Zstd                    bool
+	PredicateTemplate       bool
+	PredicateGenerator      string
+	BundlePath              string
+	BundleFormat            BundleFormat
 
 	Rekor       RekorOptions
 	Fulcio      FulcioOptions
@@ -39,6 +46,9 @@ type AttestOptions struct {
 	SecurityKey SecurityKeyOptions
 	Predicate   PredicateLocalOptions
 	Registry    RegistryOptions
+	Upload      UploadOptions
+
+	RegistryExperimental RegistryExperimentalOptions
 }
 
 var _ Interface = (*AttestOptions)(nil)
@@ -51,6 +61,8 @@ func (o *AttestOptions) AddFlags(cmd *cobra.Command) {
 	o.OIDC.AddFlags(cmd)
 	o.Rekor.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
+	o.RegistryExperimental.AddFlags(cmd)
+	o.Upload.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Key, "key", "",
 		"path to the private key file, KMS URI or Kubernetes Secret")
@@ -70,8 +82,27 @@ func (o *AttestOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.NoUpload, "no-upload", false,
 		"do not upload the generated attestation")
 
+	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
+		"write everything required to verify the attestation to FILE, suffixed with the digest of each image when attesting recursively")
+	_ = cmd.Flags().SetAnnotation("bundle", cobra.BashCompFilenameExt, []string{})
+
+	addBundleFormatFlag(cmd, &o.BundleFormat)
+
 	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "r", false,
-		"if a multi-arch image is specified, additionally sign each discrete image")
+		"if a multi-arch image is specified, additionally attest each discrete image")
+
+	cmd.Flags().BoolVar(&o.MultiSubject, "multi-subject", false,
+		"with --recursive, attach a single attestation to the multi-arch image whose subjects are the image index and each discrete image")
+
+	cmd.Flags().BoolVar(&o.PredicateTemplate, "predicate-template", false,
+		"render the predicate as a Go template for each attested image, with its {{.Digest}} and the {{.Platform.OS}}, {{.Platform.Architecture}} and {{.Platform.Variant}} the multi-arch image lists it for")
+
+	cmd.Flags().StringVar(&o.PredicateGenerator, "predicate-gen", "",
+		"instead of reading --predicate, generate the SBOM of each attested image as its predicate, in the format of --type (spdxjson or cyclonedx), with the generator: syft. Requires cosign to be built with -tags syft")
+	// The predicate may be generated instead of read.
+	_ = cmd.Flags().SetAnnotation("predicate", cobra.BashCompOneRequiredFlag, []string{"false"})
+	cmd.MarkFlagsOneRequired("predicate", "predicate-gen")
+	cmd.MarkFlagsMutuallyExclusive("predicate", "predicate-gen")
 
 	cmd.Flags().BoolVarP(&o.Replace, "replace", "", false,
 		"")
@@ -85,9 +116,19 @@ func (o *AttestOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.RekorEntryType, "rekor-entry-type", "dsse",
 		"specifies the type to be used for a rekor entry upload. Options are intoto or dsse (default). ")
 
-	cmd.Flags().StringVar(&o.TSAServerURL, "timestamp-server-url", "",
-		"url to the Timestamp RFC3161 server, default none. Must be the path to the API to request timestamp responses, e.g. https://freetsa.org/tsr")
+	cmd.Flags().StringSliceVar(&o.TSAServerURLs, "timestamp-server-url", nil,
+		"url to the Timestamp RFC3161 server, default none. Must be the path to the API to request timestamp responses, e.g. https://freetsa.org/tsr. "+
+			"May be repeated to store the timestamps of several servers, any of which is accepted when verifying; servers that fail are skipped as long as one responds")
 
 	cmd.Flags().BoolVar(&o.RecordCreationTimestamp, "record-creation-timestamp", false,
 		"set the createdAt timestamp in the attestation artifact to the time it was created; by default, cosign sets this to the zero value")
+
+	cmd.Flags().BoolVar(&o.Deterministic, "deterministic", false,
+		"attest such that attesting the same digest with the same predicate and --key gives the same attestation, for reproducibility audits: "+
+			"ECDSA keys sign with RFC 6979 nonces (cosign built with Go 1.24 or later), the statement is canonicalized and custom predicates are stamped with the zero time. "+
+			"It is rejected with --record-creation-timestamp and --timestamp-server-url, leaving createdAt at the zero value. "+
+			"Combine with --tlog-upload=false for the attestation layer to be reproducible too, as the tlog entry records when it was made")
+
+	cmd.Flags().BoolVar(&o.Zstd, "zstd", false,
+		"store the attestation zstd compressed, suffixing its media type with +zstd, to reduce the size of large predicates such as SBOMs. Older cosign versions cannot read such attestations")
 }
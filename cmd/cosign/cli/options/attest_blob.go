@@ -30,6 +30,7 @@ type AttestBlobOptions struct {
 	RFC3161TimestampPath string
 
 	Hash      string
+	Directory string
 	Predicate PredicateLocalOptions
 
 	OutputSignature   string
@@ -93,6 +94,11 @@ func (o *AttestBlobOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Hash, "hash", "",
 		"hash of blob in hexadecimal (base16). Used if you want to sign an artifact stored elsewhere and have the hash")
 
+	cmd.Flags().StringVar(&o.Directory, "directory", "",
+		"attest the files in the tree of the directory instead of a blob, each as a subject named by its path in the directory")
+	_ = cmd.Flags().SetAnnotation("directory", cobra.BashCompSubdirsInDir, []string{})
+	cmd.MarkFlagsMutuallyExclusive("directory", "hash")
+
 	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
 		"skip confirmation prompts for non-destructive operations")
 
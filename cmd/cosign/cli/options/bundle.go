@@ -0,0 +1,60 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// BundleFormat is the format bundles are written in with --bundle.
+type BundleFormat string
+
+const (
+	// BundleFormatLegacy is the JSON format cosign has written bundles in,
+	// which only cosign reads.
+	BundleFormatLegacy BundleFormat = "legacy"
+	// BundleFormatSigstore is the protobuf Sigstore bundle, version 0.3,
+	// that other Sigstore clients also verify.
+	BundleFormatSigstore BundleFormat = "sigstore"
+)
+
+func (f *BundleFormat) String() string {
+	return string(*f)
+}
+
+func (f *BundleFormat) Set(v string) error {
+	switch BundleFormat(v) {
+	case BundleFormatLegacy, BundleFormatSigstore:
+		*f = BundleFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "legacy", "sigstore"`)
+	}
+}
+
+func (f *BundleFormat) Type() string {
+	return "bundleFormat"
+}
+
+// addBundleFormatFlag adds the --bundle-format flag, defaulting to the
+// legacy format.
+func addBundleFormatFlag(cmd *cobra.Command, f *BundleFormat) {
+	*f = BundleFormatLegacy
+	cmd.Flags().Var(f, "bundle-format",
+		"format of the bundle written with --bundle. allowed: legacy, sigstore. "+
+			"sigstore writes the protobuf Sigstore bundle (v0.3) that other Sigstore clients can verify")
+}
@@ -28,6 +28,8 @@ type CertVerifyOptions struct {
 	CertIdentityRegexp           string
 	CertOidcIssuer               string
 	CertOidcIssuerRegexp         string
+	CertIdentityURINormalize     []string
+	CertIdentityTrace            bool
 	CertGithubWorkflowTrigger    string
 	CertGithubWorkflowSha        string
 	CertGithubWorkflowName       string
@@ -60,6 +62,13 @@ func (o *CertVerifyOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.CertOidcIssuerRegexp, "certificate-oidc-issuer-regexp", "",
 		"A regular expression alternative to --certificate-oidc-issuer. Accepts the Go regular expression syntax described at https://golang.org/s/re2syntax. Either --certificate-oidc-issuer or --certificate-oidc-issuer-regexp must be set for keyless flows.")
 
+	cmd.Flags().StringSliceVar(&o.CertIdentityURINormalize, "certificate-identity-uri-normalize", nil,
+		"normalizations applied to URI subject alternative names before they are matched with --certificate-identity or --certificate-identity-regexp: "+
+			"case (compare case-insensitively), trailing-slash (ignore a trailing slash of the path), port (ignore the default port of http and https). May be repeated")
+
+	cmd.Flags().BoolVar(&o.CertIdentityTrace, "certificate-identity-trace", false,
+		"print which subject alternative name of the certificate matched which expected identity")
+
 	// -- Cert extensions begin --
 	// Source: https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md
 	cmd.Flags().StringVar(&o.CertGithubWorkflowTrigger, "certificate-github-workflow-trigger", "",
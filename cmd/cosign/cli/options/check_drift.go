@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// CheckDriftOptions is the top level wrapper for the check-drift command.
+type CheckDriftOptions struct {
+	Prune        bool
+	Force        bool
+	Output       string
+	OutputSchema OutputSchemaOptions
+	Registry     RegistryOptions
+}
+
+var _ Interface = (*CheckDriftOptions)(nil)
+
+// AddFlags implements Interface
+func (o *CheckDriftOptions) AddFlags(cmd *cobra.Command) {
+	o.Registry.AddFlags(cmd)
+	o.OutputSchema.AddFlags(cmd)
+
+	cmd.Flags().BoolVar(&o.Prune, "prune", false,
+		"remove the signatures, attestations and SBOMs of images that no longer exist")
+	cmd.Flags().BoolVarP(&o.Force, "force", "f", false,
+		"do not prompt for confirmation before pruning")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "text",
+		"output format for the drift found (text|json)")
+}
@@ -16,6 +16,7 @@ package options
 
 import (
 	"errors"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -55,17 +56,56 @@ func (c *CleanType) Type() string {
 }
 
 type CleanOptions struct {
-	Registry  RegistryOptions
-	CleanType CleanType
-	Force     bool
+	Registry   RegistryOptions
+	CleanType  CleanType
+	Force      bool
+	KeepLatest int
+	OlderThan  time.Duration
+
+	SignatureDigest string
+
+	Orphans bool
+	DryRun  bool
+
+	Retention RetentionOptions
 }
 
 var _ Interface = (*CleanOptions)(nil)
 
 func (c *CleanOptions) AddFlags(cmd *cobra.Command) {
 	c.Registry.AddFlags(cmd)
+	c.Retention.AddFlags(cmd)
 	c.CleanType = defaultCleanType()
 	cmd.Flags().Var(&c.CleanType, "type", "a type of clean: <signature|attestation|sbom|all> (sbom is deprecated)")
 	// TODO(#2044): Rename to --skip-confirmation for consistency?
 	cmd.Flags().BoolVarP(&c.Force, "force", "f", false, "do not prompt for confirmation")
+	cmd.Flags().IntVar(&c.KeepLatest, "keep-latest", 0,
+		"keep the N most recently attached signatures or attestations and remove the rest")
+	cmd.Flags().DurationVar(&c.OlderThan, "older-than", 0,
+		"remove signatures or attestations recorded in the transparency log longer ago than this; those without a tlog entry are kept")
+	cmd.Flags().StringVar(&c.SignatureDigest, "signature-digest", "",
+		"remove only the signature or attestation with this digest, either the digest of its layer or the sha256 digest of its raw signature")
+	cmd.Flags().BoolVar(&c.Orphans, "orphans", false,
+		"treat the argument as a repository and remove the signatures, attestations and SBOMs of its images that no longer exist")
+	cmd.Flags().BoolVar(&c.DryRun, "dry-run", false,
+		"with --orphans, only list what would be removed")
+}
+
+// RetentionOptions is the wrapper for the options protecting attestations
+// kept as audit evidence from being cleaned.
+type RetentionOptions struct {
+	Policy string
+	Force  bool
+}
+
+var _ Interface = (*RetentionOptions)(nil)
+
+// AddFlags implements Interface
+func (o *RetentionOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Policy, "retention-policy", "",
+		"path to a JSON file of the repositories and predicate types of attestations to keep, "+
+			"such as {\"repositories\": [\"ghcr.io/org/*\"], \"predicateTypes\": [\"https://slsa.dev/provenance/v1\"]}")
+	_ = cmd.Flags().SetAnnotation("retention-policy", cobra.BashCompFilenameExt, []string{"json"})
+	cmd.Flags().BoolVar(&o.Force, "force-retained", false,
+		"also remove attestations that the retention policy keeps or that other attestations name as their subject")
 }
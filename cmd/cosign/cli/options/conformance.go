@@ -0,0 +1,42 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// ConformanceOptions is the top level wrapper for the conformance command.
+type ConformanceOptions struct {
+	CertIdentity    string
+	CertOidcIssuer  string
+	TrustedRootPath string
+}
+
+var _ Interface = (*ConformanceOptions)(nil)
+
+// AddFlags implements Interface
+func (o *ConformanceOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.CertIdentity, "certificate-identity",
+		"https://github.com/sigstore-conformance/extremely-dangerous-public-oidc-beacon/.github/workflows/extremely-dangerous-oidc-beacon.yml@refs/heads/main",
+		"the identity expected in the signing certificate of every test vector")
+
+	cmd.Flags().StringVar(&o.CertOidcIssuer, "certificate-oidc-issuer", "https://token.actions.githubusercontent.com",
+		"the OIDC issuer expected in the signing certificate of every test vector")
+
+	cmd.Flags().StringVar(&o.TrustedRootPath, "trusted-root", "",
+		"path to a Sigstore TrustedRoot JSON file used for test vectors without their own trusted_root.json. Defaults to the TUF trusted root")
+	_ = cmd.Flags().SetAnnotation("trusted-root", cobra.BashCompFilenameExt, []string{"json"})
+}
@@ -25,7 +25,17 @@ type CopyOptions struct {
 	SignatureOnly bool
 	Force         bool
 	Platform      string
+	Jobs          int
 	Registry      RegistryOptions
+
+	Sign             bool
+	Key              string
+	TlogUpload       bool
+	SkipConfirmation bool
+	Rekor            RekorOptions
+	Fulcio           FulcioOptions
+	OIDC             OIDCOptions
+	SecurityKey      SecurityKeyOptions
 }
 
 var _ Interface = (*CopyOptions)(nil)
@@ -33,9 +43,13 @@ var _ Interface = (*CopyOptions)(nil)
 // AddFlags implements Interface
 func (o *CopyOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.Fulcio.AddFlags(cmd)
+	o.OIDC.AddFlags(cmd)
+	o.SecurityKey.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.CopyOnly, "only", "",
-		"custom string array to only copy specific items, this flag is comma delimited. ex: --only=sbom,sign,att")
+		"comma separated artifacts to copy out of sig, att, sbom and image, the image itself. Copies all of them if unset")
 
 	cmd.Flags().BoolVar(&o.SignatureOnly, "sig-only", false,
 		"[DEPRECATED] only copy the image signature")
@@ -44,5 +58,22 @@ func (o *CopyOptions) AddFlags(cmd *cobra.Command) {
 		"overwrite destination image(s), if necessary")
 
 	cmd.Flags().StringVar(&o.Platform, "platform", "",
-		"only copy container image and its signatures for a specific platform image")
+		"only copy the images of these comma separated platforms, such as linux/arm64, and their signatures, "+
+			"rewriting the image index to list only them")
+
+	cmd.Flags().IntVar(&o.Jobs, "jobs", 0,
+		"number of images, signatures, attestations and SBOMs to copy at once, defaults to the number of CPUs")
+
+	cmd.Flags().BoolVar(&o.Sign, "sign", false,
+		"also sign the copied image, and each image within it, in the destination repository, with --key or else keyless")
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the private key file, KMS URI or Kubernetes Secret to sign with, with --sign")
+	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().BoolVar(&o.TlogUpload, "tlog-upload", true,
+		"whether or not to upload to the tlog, with --sign")
+
+	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
+		"skip confirmation prompts for non-destructive operations")
 }
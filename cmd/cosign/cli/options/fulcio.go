@@ -27,6 +27,7 @@ type FulcioOptions struct {
 	AuthFlow                 string
 	IdentityToken            string
 	InsecureSkipFulcioVerify bool
+	CertificateProvider      string
 }
 
 var _ Interface = (*FulcioOptions)(nil)
@@ -45,4 +46,7 @@ func (o *FulcioOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&o.InsecureSkipFulcioVerify, "insecure-skip-verify", false,
 		"skip verifying fulcio published to the SCT (this should only be used for testing).")
+
+	cmd.Flags().StringVar(&o.CertificateProvider, "certificate-provider", "",
+		"private CA to request the certificate of keyless signing from instead of Fulcio, as step-ca://<host>[:<port>] or vault-pki://[<mount>/]<role>")
 }
@@ -22,7 +22,10 @@ import (
 // GenerateOptions is the top level wrapper for the generate command.
 type GenerateOptions struct {
 	AnnotationOptions
-	Registry RegistryOptions
+	PredicateOptions
+	Registry      RegistryOptions
+	Format        string
+	PredicatePath string
 }
 
 var _ Interface = (*GenerateOptions)(nil)
@@ -30,5 +33,14 @@ var _ Interface = (*GenerateOptions)(nil)
 // AddFlags implements Interface
 func (o *GenerateOptions) AddFlags(cmd *cobra.Command) {
 	o.AnnotationOptions.AddFlags(cmd)
+	o.PredicateOptions.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Format, "format", "simplesigning",
+		"payload to generate: simplesigning for the payload of a signature, or dsse for the DSSE pre-authentication encoding "+
+			"of an in-toto attestation of --predicate, the bytes to sign for a DSSE envelope")
+
+	cmd.Flags().StringVar(&o.PredicatePath, "predicate", "",
+		"path to the predicate file to attest with --format dsse")
+	_ = cmd.Flags().SetAnnotation("predicate", cobra.BashCompFilenameExt, []string{})
 }
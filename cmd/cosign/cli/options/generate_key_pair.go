@@ -24,6 +24,11 @@ type GenerateKeyPairOptions struct {
 	// KMS Key Management Service
 	KMS             string
 	OutputKeyPrefix string
+	// AgeRecipients encrypt the private key with age instead of a password
+	AgeRecipients []string
+	// KeyType and RSAScheme select the key pair to generate
+	KeyType   string
+	RSAScheme string
 }
 
 var _ Interface = (*GenerateKeyPairOptions)(nil)
@@ -34,4 +39,10 @@ func (o *GenerateKeyPairOptions) AddFlags(cmd *cobra.Command) {
 		"create key pair in KMS service to use for signing")
 	cmd.Flags().StringVar(&o.OutputKeyPrefix, "output-key-prefix", "cosign",
 		"name used for generated .pub and .key files (defaults to `cosign`)")
+	cmd.Flags().StringSliceVar(&o.AgeRecipients, "age-recipient", nil,
+		"encrypt the private key to this age recipient instead of a password, either an age1... public key or a file of them (can be repeated)")
+	cmd.Flags().StringVar(&o.KeyType, "key-type", "",
+		"type of the key pair to generate: ecdsa-p256 (the default), ed25519, rsa-3072 or rsa-4096")
+	cmd.Flags().StringVar(&o.RSAScheme, "rsa-scheme", "",
+		"signature scheme of RSA keys: pkcs1v15 (the default) or pss. PSS public keys are marked as such, so verification uses PSS automatically")
 }
@@ -0,0 +1,117 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// SignGitOptions is the top level wrapper for the sign-git command.
+type SignGitOptions struct {
+	Key              string
+	Cert             string
+	CertChain        string
+	Repository       string
+	SecurityKey      SecurityKeyOptions
+	Fulcio           FulcioOptions
+	OIDC             OIDCOptions
+	SkipConfirmation bool
+	TSAClientCACert  string
+	TSAClientCert    string
+	TSAClientKey     string
+	TSAServerName    string
+	TSAServerURL     string
+	IssueCertificate bool
+}
+
+var _ Interface = (*SignGitOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SignGitOptions) AddFlags(cmd *cobra.Command) {
+	o.SecurityKey.AddFlags(cmd)
+	o.Fulcio.AddFlags(cmd)
+	o.OIDC.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the private key file, KMS URI or Kubernetes Secret, whose certificate must be passed with --certificate unless --issue-certificate is set")
+	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().StringVar(&o.Cert, "certificate", "",
+		"path to the X.509 certificate in PEM format to include in the signature")
+	_ = cmd.Flags().SetAnnotation("certificate", cobra.BashCompFilenameExt, []string{"cert"})
+
+	cmd.Flags().StringVar(&o.CertChain, "certificate-chain", "",
+		"path to a list of CA X.509 certificates in PEM format which will be needed "+
+			"when building the certificate chain for the signing certificate. "+
+			"Must start with the parent intermediate CA certificate of the "+
+			"signing certificate and end with the root certificate. Included in the signature.")
+	_ = cmd.Flags().SetAnnotation("certificate-chain", cobra.BashCompFilenameExt, []string{"cert"})
+
+	cmd.Flags().StringVarP(&o.Repository, "repository", "C", "",
+		"path to the git repository, the current directory by default")
+	_ = cmd.Flags().SetAnnotation("repository", cobra.BashCompSubdirsInDir, []string{})
+
+	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
+		"skip confirmation prompts for non-destructive operations")
+
+	cmd.Flags().StringVar(&o.TSAClientCACert, "timestamp-client-cacert", "",
+		"path to the X.509 CA certificate file in PEM format to be used for the connection to the TSA Server")
+
+	cmd.Flags().StringVar(&o.TSAClientCert, "timestamp-client-cert", "",
+		"path to the X.509 certificate file in PEM format to be used for the connection to the TSA Server")
+
+	cmd.Flags().StringVar(&o.TSAClientKey, "timestamp-client-key", "",
+		"path to the X.509 private key file in PEM format to be used, together with the 'timestamp-client-cert' value, for the connection to the TSA Server")
+
+	cmd.Flags().StringVar(&o.TSAServerName, "timestamp-server-name", "",
+		"SAN name to use as the 'ServerName' tls.Config field to verify the mTLS connection to the TSA Server")
+
+	cmd.Flags().StringVar(&o.TSAServerURL, "timestamp-server-url", "",
+		"url to the Timestamp RFC3161 server, default none. Must be the path to the API to request timestamp responses, e.g. https://freetsa.org/tsr")
+
+	cmd.Flags().BoolVar(&o.IssueCertificate, "issue-certificate", false,
+		"issue a code signing certificate from Fulcio, even if a key is provided")
+}
+
+// VerifyGitOptions is the top level wrapper for the verify-git command.
+type VerifyGitOptions struct {
+	Key                 string
+	Repository          string
+	CertVerify          CertVerifyOptions
+	TSACertChainPath    string
+	UseSignedTimestamps bool
+}
+
+var _ Interface = (*VerifyGitOptions)(nil)
+
+// AddFlags implements Interface
+func (o *VerifyGitOptions) AddFlags(cmd *cobra.Command) {
+	o.CertVerify.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret the certificate of the signature must hold the key of")
+	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().StringVarP(&o.Repository, "repository", "C", "",
+		"path to the git repository, the current directory by default")
+	_ = cmd.Flags().SetAnnotation("repository", cobra.BashCompSubdirsInDir, []string{})
+
+	cmd.Flags().StringVar(&o.TSACertChainPath, "timestamp-certificate-chain", "",
+		"path to PEM-encoded certificate chain file for the RFC3161 timestamp authority. Must contain the root CA certificate. "+
+			"Optionally may contain intermediate CA certificates, and may contain the leaf TSA certificate if not present in the timestamp. May contain the chains of several timestamp authorities, any of which is trusted")
+
+	cmd.Flags().BoolVar(&o.UseSignedTimestamps, "use-signed-timestamps", false,
+		"verify the RFC3161 timestamp of the signature with the timestamp authorities of the trusted root")
+}
@@ -28,6 +28,16 @@ type ImportKeyPairOptions struct {
 	OutputKeyPrefix string
 
 	SkipConfirmation bool
+
+	// AgeRecipients encrypt the private key with age instead of a password
+	AgeRecipients []string
+
+	// JWKKeyID selects the private key of a JWK set by its key id
+	JWKKeyID string
+
+	// Upgrade re-encrypts an encrypted cosign private key in the current
+	// format, with the same password
+	Upgrade bool
 }
 
 var _ Interface = (*ImportKeyPairOptions)(nil)
@@ -35,7 +45,7 @@ var _ Interface = (*ImportKeyPairOptions)(nil)
 // AddFlags implements Interface
 func (o *ImportKeyPairOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Key, "key", "k", "",
-		"import key pair to use for signing")
+		"import key pair to use for signing: a PEM-encoded private key, a PKCS #12 bundle (.p12, .pfx) or a JWK or JWK set")
 	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
 
 	cmd.Flags().StringVarP(&o.OutputKeyPrefix, "output-key-prefix", "o", "import-cosign",
@@ -44,4 +54,13 @@ func (o *ImportKeyPairOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
 		"skip confirmation prompts for overwriting existing key")
+
+	cmd.Flags().StringSliceVar(&o.AgeRecipients, "age-recipient", nil,
+		"encrypt the private key to this age recipient instead of a password, either an age1... public key or a file of them (can be repeated)")
+
+	cmd.Flags().StringVar(&o.JWKKeyID, "jwk-key-id", "",
+		"key id (\"kid\") of the private key to import from a JWK set holding several")
+
+	cmd.Flags().BoolVar(&o.Upgrade, "upgrade", false,
+		"re-encrypt a password-encrypted cosign private key in the current Argon2id format, keeping its password")
 }
@@ -23,6 +23,7 @@ type KeyOpts struct {
 	KeyRef               string
 	FulcioURL            string
 	RekorURL             string
+	AdditionalRekorURLs  []string // Additional Rekor instances that receive a copy of every tlog entry
 	IDToken              string
 	PassFunc             cosign.PassFunc
 	OIDCIssuer           string
@@ -41,10 +42,19 @@ type KeyOpts struct {
 	TSAServerURL         string
 	RFC3161TimestampPath string
 	TSACertChainPath     string
+
+	// AdditionalTSAServerURLs are further TSAs whose timestamps are stored
+	// alongside that of TSAServerURL.
+	AdditionalTSAServerURLs []string
+
 	// IssueCertificate controls whether to issue a certificate when a key is
 	// provided.
 	IssueCertificateForExistingKey bool
 
+	// GenerateHardwareKey generates the key of keyless signing in the
+	// security key or PKCS11 token, rather than in memory.
+	GenerateHardwareKey bool
+
 	// FulcioAuthFlow is the auth flow to use when authenticating against
 	// Fulcio. See https://pkg.go.dev/github.com/franchb/cosign/v2/cmd/cosign/cli/fulcio#pkg-constants
 	// for valid values.
@@ -53,4 +63,27 @@ type KeyOpts struct {
 	// Modeled after InsecureSkipVerify in tls.Config, this disables
 	// verifying the SCT.
 	InsecureSkipFulcioVerify bool
+
+	// CertificateProvider is the reference of the private CA to request
+	// the certificate of keyless signing from instead of Fulcio, see
+	// package certprovider.
+	CertificateProvider string
+
+	// SSHNamespace is the namespace of signatures made or verified with an
+	// SSH key, see sshsig.DefaultNamespace.
+	SSHNamespace string
+
+	// Deterministic signs such that signing the same payload with the same
+	// key gives the same signature, see
+	// signature.DeterministicSignerVerifierFromKeyRef.
+	Deterministic bool
+}
+
+// SplitTSAServerURLs splits the URLs of a repeated --timestamp-server-url
+// into the URL of the first TSA and those of the additional ones.
+func SplitTSAServerURLs(urls []string) (string, []string) {
+	if len(urls) == 0 {
+		return "", nil
+	}
+	return urls[0], urls[1:]
 }
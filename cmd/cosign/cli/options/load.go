@@ -31,7 +31,7 @@ var _ Interface = (*LoadOptions)(nil)
 func (o *LoadOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
 	cmd.Flags().StringVar(&o.Directory, "dir", "",
-		"path to directory where the signed image is stored on disk")
+		"path to directory where the signed image is stored on disk, or to the file written by 'cosign save --format bundle-tar'")
 	_ = cmd.Flags().SetAnnotation("dir", cobra.BashCompSubdirsInDir, []string{})
 	_ = cmd.MarkFlagRequired("dir")
 }
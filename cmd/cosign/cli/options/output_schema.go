@@ -0,0 +1,33 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// OutputSchemaOptions is the wrapper for commands that can print the JSON
+// Schema of their machine-readable output.
+type OutputSchemaOptions struct {
+	OutputSchema bool
+}
+
+var _ Interface = (*OutputSchemaOptions)(nil)
+
+// AddFlags implements Interface
+func (o *OutputSchemaOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.OutputSchema, "output-schema", false,
+		"print the JSON Schema of the command's JSON output and exit")
+}
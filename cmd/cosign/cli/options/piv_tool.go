@@ -105,16 +105,17 @@ func (o *PIVToolAttestationOptions) AddFlags(cmd *cobra.Command) {
 		"format to output attestation information in. (text|json)")
 
 	cmd.Flags().StringVar(&o.Slot, "slot", "",
-		"Slot to use for generated key (authentication|signature|card-authentication|key-management)")
+		"Slot to use for generated key (authentication|signature|card-authentication|key-management|retired-82..retired-95)")
 }
 
 // PIVToolGenerateKeyOptions is the wrapper for `piv-tool generate-key` related options.
 type PIVToolGenerateKeyOptions struct {
-	ManagementKey string
-	RandomKey     bool
-	Slot          string
-	PINPolicy     string
-	TouchPolicy   string
+	ManagementKey   string
+	RandomKey       bool
+	Slot            string
+	PINPolicy       string
+	TouchPolicy     string
+	SkipAttestation bool
 }
 
 var _ Interface = (*PIVToolGenerateKeyOptions)(nil)
@@ -128,11 +129,14 @@ func (o *PIVToolGenerateKeyOptions) AddFlags(cmd *cobra.Command) {
 		"if set to true, generates a new random management key and deletes it after")
 
 	cmd.Flags().StringVar(&o.Slot, "slot", "",
-		"Slot to use for generated key (authentication|signature|card-authentication|key-management)")
+		"Slot to use for generated key (authentication|signature|card-authentication|key-management|retired-82..retired-95)")
 
 	cmd.Flags().StringVar(&o.PINPolicy, "pin-policy", "",
 		"PIN policy for slot (never|once|always)")
 
 	cmd.Flags().StringVar(&o.TouchPolicy, "touch-policy", "",
 		"Touch policy for slot (never|always|cached)")
+
+	cmd.Flags().BoolVar(&o.SkipAttestation, "skip-attestation", false,
+		"skip the YubiKey attestation of the generated key and store a self-signed certificate in the slot instead, for non-Yubico PIV devices")
 }
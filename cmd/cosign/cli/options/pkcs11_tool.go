@@ -36,9 +36,11 @@ func (o *PKCS11ToolListTokensOptions) AddFlags(cmd *cobra.Command) {
 
 // PKCS11ToolListKeysUrisOptions is the wrapper for `pkcs11-tool list-keys-uris` related options.
 type PKCS11ToolListKeysUrisOptions struct {
-	ModulePath string
-	SlotID     uint
-	Pin        string
+	ModulePath  string
+	SlotID      uint
+	TokenLabel  string
+	TokenSerial string
+	Pin         string
 }
 
 var _ Interface = (*PKCS11ToolListKeysUrisOptions)(nil)
@@ -52,6 +54,38 @@ func (o *PKCS11ToolListKeysUrisOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().UintVar(&o.SlotID, "slot-id", 0,
 		"id of the PKCS11 slot, uses 0 if empty")
 
+	cmd.Flags().StringVar(&o.TokenLabel, "token-label", "",
+		"label of the PKCS11 token, selecting its slot instead of --slot-id")
+
+	cmd.Flags().StringVar(&o.TokenSerial, "serial", "",
+		"serial number of the PKCS11 token, selecting its slot instead of --slot-id")
+
 	cmd.Flags().StringVar(&o.Pin, "pin", "",
 		"pin of the PKCS11 slot, uses environment variable COSIGN_PKCS11_PIN if empty")
 }
+
+// PKCS11ToolListMechanismsOptions is the wrapper for `pkcs11-tool list-mechanisms` related options.
+type PKCS11ToolListMechanismsOptions struct {
+	ModulePath  string
+	SlotID      uint
+	TokenLabel  string
+	TokenSerial string
+}
+
+var _ Interface = (*PKCS11ToolListMechanismsOptions)(nil)
+
+// AddFlags implements Interface
+func (o *PKCS11ToolListMechanismsOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.ModulePath, "module-path", env.Getenv(env.VariablePKCS11ModulePath),
+		"absolute path to the PKCS11 module")
+	_ = cmd.Flags().SetAnnotation("module-path", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().UintVar(&o.SlotID, "slot-id", 0,
+		"id of the PKCS11 slot, uses 0 if empty")
+
+	cmd.Flags().StringVar(&o.TokenLabel, "token-label", "",
+		"label of the PKCS11 token, selecting its slot instead of --slot-id")
+
+	cmd.Flags().StringVar(&o.TokenSerial, "serial", "",
+		"serial number of the PKCS11 token, selecting its slot instead of --slot-id")
+}
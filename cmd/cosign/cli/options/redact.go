@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/pkg/cosign"
+)
+
+// RedactOptions is the wrapper for redacting certificate fields from
+// verification reports.
+type RedactOptions struct {
+	Redact []string
+}
+
+var _ Interface = (*RedactOptions)(nil)
+
+// AddFlags implements Interface
+func (o *RedactOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&o.Redact, "redact", nil,
+		"redact a certificate field from the verification report, as FIELD=MODE where MODE is omit or hash. "+
+			"FIELD is one of subject, issuer, github-workflow-trigger, github-workflow-sha, github-workflow-name, "+
+			"github-workflow-repository and github-workflow-ref. Redacting any field also leaves out the transparency log bundle, which embeds the certificate. "+
+			"Can be given multiple times")
+}
+
+// Redactions parses the --redact flags.
+func (o *RedactOptions) Redactions() (cosign.Redactions, error) {
+	return cosign.ParseRedactions(o.Redact)
+}
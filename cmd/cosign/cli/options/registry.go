@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -39,6 +40,13 @@ type RegistryOptions struct {
 	RefOpts            ReferenceOptions
 	Keychain           Keychain
 	AuthConfig         authn.AuthConfig
+	Retries            int
+	RetryBackoff       time.Duration
+	RetryStatusCodes   []int
+
+	// UploadChunkSize, if positive, is the size of the chunks the layers of
+	// signatures, attestations and SBOMs are uploaded in, see UploadOptions.
+	UploadChunkSize int64
 
 	// RegistryClientOpts allows overriding the result of GetRegistryClientOpts.
 	RegistryClientOpts []remote.Option
@@ -66,11 +74,30 @@ func (o *RegistryOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.AuthConfig.RegistryToken, "registry-token", "",
 		"registry bearer auth token")
 
+	cmd.Flags().IntVar(&o.Retries, "registry-retries", 0,
+		"number of times to retry registry operations that fail with a retryable status code or a network error")
+
+	cmd.Flags().DurationVar(&o.RetryBackoff, "registry-retry-backoff", time.Second,
+		"wait before the first retry of a registry operation, doubling after each further retry")
+
+	cmd.Flags().IntSliceVar(&o.RetryStatusCodes, "registry-retry-status-codes", nil,
+		"HTTP status codes of registry responses to retry, defaults to 408,429,500,502,503,504")
+
 	o.RefOpts.AddFlags(cmd)
 }
 
 func (o *RegistryOptions) ClientOpts(ctx context.Context) ([]ociremote.Option, error) {
 	opts := []ociremote.Option{ociremote.WithRemoteOptions(o.GetRegistryClientOpts(ctx)...)}
+	if p := o.retryPolicy(); p != nil {
+		opts = append(opts, ociremote.WithRetryPolicy(*p))
+	}
+	if o.UploadChunkSize > 0 {
+		opts = append(opts, ociremote.WithChunkedUploads(ctx, ociremote.ChunkedUploads{
+			ChunkSize: o.UploadChunkSize,
+			Keychain:  o.AuthKeychain(),
+			Transport: o.transport(),
+		}))
+	}
 	if o.RefOpts.TagPrefix != "" {
 		opts = append(opts, ociremote.WithPrefix(o.RefOpts.TagPrefix))
 	}
@@ -81,9 +108,78 @@ func (o *RegistryOptions) ClientOpts(ctx context.Context) ([]ociremote.Option, e
 	if (targetRepoOverride != name.Repository{}) {
 		opts = append(opts, ociremote.WithTargetRepository(targetRepoOverride))
 	}
+	repoMapping, err := ociremote.GetEnvRepositoryMapping()
+	if err != nil {
+		return nil, err
+	}
+	if repoMapping != nil {
+		opts = append(opts, ociremote.WithRepositoryMapping(repoMapping))
+	}
 	return opts, nil
 }
 
+// SignatureMirrorOptions is the wrapper for the registries signatures are
+// read from when their registry is failing.
+type SignatureMirrorOptions struct {
+	Mirrors []string
+}
+
+var _ Interface = (*SignatureMirrorOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SignatureMirrorOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.Mirrors, "signature-mirror", nil,
+		"registry to read signatures and attestations from, under the same repository path, when their registry fails with a server error. May be repeated, mirrors are tried in order")
+}
+
+// RegistryMirrorOptions is the wrapper for the mirror configuration images
+// and signatures are read with.
+type RegistryMirrorOptions struct {
+	Config string
+}
+
+var _ Interface = (*RegistryMirrorOptions)(nil)
+
+// AddFlags implements Interface
+func (o *RegistryMirrorOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Config, "registry-mirror-config", "",
+		"path to a JSON file configuring the mirrors, such as pull-through caches, that images, signatures and attestations are read from before their registry, "+
+			"with the TLS settings and credentials of each mirror")
+	_ = cmd.Flags().SetAnnotation("registry-mirror-config", cobra.BashCompFilenameExt, []string{"json"})
+}
+
+// SignatureCacheOptions is the wrapper for caching the signatures and
+// attestations read for verification on disk.
+type SignatureCacheOptions struct {
+	Dir string
+	TTL time.Duration
+}
+
+var _ Interface = (*SignatureCacheOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SignatureCacheOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Dir, "signature-cache-dir", "",
+		"directory to cache the signatures and attestations fetched for an image digest in, so verifying it again does not download them. Disabled when empty")
+	_ = cmd.MarkFlagDirname("signature-cache-dir")
+
+	cmd.Flags().DurationVar(&o.TTL, "signature-cache-ttl", 10*time.Minute,
+		"how long cached signatures and attestations are used before they are fetched again")
+}
+
+// retryPolicy returns the policy for retrying registry operations, or nil if
+// they are not retried.
+func (o *RegistryOptions) retryPolicy() *ociremote.RetryPolicy {
+	if o.Retries <= 0 {
+		return nil
+	}
+	return &ociremote.RetryPolicy{
+		MaxAttempts: o.Retries + 1,
+		Backoff:     o.RetryBackoff,
+		StatusCodes: o.RetryStatusCodes,
+	}
+}
+
 func (o *RegistryOptions) NameOptions() []name.Option {
 	var nameOpts []name.Option
 	if o.AllowHTTPRegistry {
@@ -104,24 +200,14 @@ func (o *RegistryOptions) GetRegistryClientOpts(ctx context.Context) []remote.Op
 		remote.WithUserAgent(UserAgent()),
 	}
 
-	switch {
-	case o.Keychain != nil:
-		opts = append(opts, remote.WithAuthFromKeychain(o.Keychain))
-	case o.KubernetesKeychain:
-		kc := authn.NewMultiKeychain(
-			authn.DefaultKeychain,
-		)
-		opts = append(opts, remote.WithAuthFromKeychain(kc))
-	case o.AuthConfig.Username != "" && o.AuthConfig.Password != "":
-		opts = append(opts, remote.WithAuth(&authn.Basic{Username: o.AuthConfig.Username, Password: o.AuthConfig.Password}))
-	case o.AuthConfig.RegistryToken != "":
-		opts = append(opts, remote.WithAuth(&authn.Bearer{Token: o.AuthConfig.RegistryToken}))
-	default:
-		opts = append(opts, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	opts = append(opts, remote.WithAuthFromKeychain(o.AuthKeychain()))
+
+	if p := o.retryPolicy(); p != nil {
+		opts = append(opts, p.RemoteOptions()...)
 	}
 
-	if o.AllowInsecure {
-		opts = append(opts, remote.WithTransport(&http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}})) // #nosec G402
+	if t := o.transport(); t != nil {
+		opts = append(opts, remote.WithTransport(t))
 	}
 
 	// Reuse a remote.Pusher and a remote.Puller for all operations that use these opts.
@@ -139,6 +225,43 @@ func (o *RegistryOptions) GetRegistryClientOpts(ctx context.Context) []remote.Op
 	return opts
 }
 
+// AuthKeychain returns the keychain registry credentials are resolved with.
+func (o *RegistryOptions) AuthKeychain() authn.Keychain {
+	switch {
+	case o.Keychain != nil:
+		return o.Keychain
+	case o.KubernetesKeychain:
+		return authn.NewMultiKeychain(
+			authn.DefaultKeychain,
+		)
+	case o.AuthConfig.Username != "" && o.AuthConfig.Password != "":
+		return staticKeychain{&authn.Basic{Username: o.AuthConfig.Username, Password: o.AuthConfig.Password}}
+	case o.AuthConfig.RegistryToken != "":
+		return staticKeychain{&authn.Bearer{Token: o.AuthConfig.RegistryToken}}
+	default:
+		return authn.DefaultKeychain
+	}
+}
+
+// staticKeychain resolves every registry to the same credentials.
+type staticKeychain struct {
+	auth authn.Authenticator
+}
+
+// Resolve implements authn.Keychain
+func (k staticKeychain) Resolve(authn.Resource) (authn.Authenticator, error) {
+	return k.auth, nil
+}
+
+// transport returns the transport for registry requests, or nil for the
+// default one.
+func (o *RegistryOptions) transport() http.RoundTripper {
+	if o.AllowInsecure {
+		return &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} // #nosec G402
+	}
+	return nil
+}
+
 type RegistryReferrersMode string
 
 const (
@@ -180,5 +303,6 @@ var _ Interface = (*RegistryExperimentalOptions)(nil)
 // AddFlags implements Interface
 func (o *RegistryExperimentalOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().Var(&o.RegistryReferrersMode, "registry-referrers-mode",
-		"mode for fetching references from the registry. allowed: legacy, oci-1-1")
+		"mode for storing and fetching references in the registry. allowed: legacy, oci-1-1. "+
+			"oci-1-1 stores signatures and attestations as OCI 1.1 referrers, falling back to tags on registries that reject them")
 }
@@ -23,7 +23,8 @@ const DefaultRekorURL = "https://rekor.sigstore.dev"
 
 // RekorOptions is the wrapper for Rekor related options.
 type RekorOptions struct {
-	URL string
+	URL            string
+	AdditionalURLs []string
 }
 
 var _ Interface = (*RekorOptions)(nil)
@@ -32,4 +33,8 @@ var _ Interface = (*RekorOptions)(nil)
 func (o *RekorOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.URL, "rekor-url", DefaultRekorURL,
 		"address of rekor STL server")
+	cmd.Flags().StringSliceVar(&o.AdditionalURLs, "additional-rekor-url", nil,
+		"address of an additional rekor STL server. When signing, entries are uploaded to every log; "+
+			"when verifying, an entry found in any of the logs is accepted if it is signed by a trusted Rekor key. "+
+			"Keys of logs missing from the TUF root must be listed in SIGSTORE_REKOR_PUBLIC_KEY, which replaces the TUF root as the source of Rekor keys. May be repeated")
 }
@@ -0,0 +1,55 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// ResignOptions is the top level wrapper for the resign command.
+type ResignOptions struct {
+	SignOptions
+
+	// VerifyKey and CertVerify are what the existing signatures are
+	// verified with, the other options are those of the new signatures.
+	VerifyKey  string
+	CertVerify CertVerifyOptions
+}
+
+var _ Interface = (*ResignOptions)(nil)
+
+// AddFlags implements Interface
+func (o *ResignOptions) AddFlags(cmd *cobra.Command) {
+	o.SignOptions.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.VerifyKey, "verify-key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret the existing signatures must verify with. "+
+			"Without it, they must verify with a Fulcio certificate for the expected identity")
+	_ = cmd.Flags().SetAnnotation("verify-key", cobra.BashCompFilenameExt, []string{})
+
+	// The identity flags of CertVerifyOptions, whose certificate flags
+	// would clash with those of the new signatures.
+	cmd.Flags().StringVar(&o.CertVerify.CertIdentity, "certificate-identity", "",
+		"The identity expected in the Fulcio certificates of the existing signatures. Either --certificate-identity or --certificate-identity-regexp must be set without --verify-key.")
+
+	cmd.Flags().StringVar(&o.CertVerify.CertIdentityRegexp, "certificate-identity-regexp", "",
+		"A regular expression alternative to --certificate-identity. Accepts the Go regular expression syntax described at https://golang.org/s/re2syntax.")
+
+	cmd.Flags().StringVar(&o.CertVerify.CertOidcIssuer, "certificate-oidc-issuer", "",
+		"The OIDC issuer expected in the Fulcio certificates of the existing signatures. Either --certificate-oidc-issuer or --certificate-oidc-issuer-regexp must be set without --verify-key.")
+
+	cmd.Flags().StringVar(&o.CertVerify.CertOidcIssuerRegexp, "certificate-oidc-issuer-regexp", "",
+		"A regular expression alternative to --certificate-oidc-issuer. Accepts the Go regular expression syntax described at https://golang.org/s/re2syntax.")
+}
@@ -24,15 +24,19 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"github.com/franchb/cosign/v2/internal/pkg/ratelimit"
 )
 
 const EnvPrefix = "COSIGN"
 
 // RootOptions define flags and options for the root cosign cli.
 type RootOptions struct {
-	OutputFile string
-	Verbose    bool
-	Timeout    time.Duration
+	OutputFile      string
+	Verbose         bool
+	Timeout         time.Duration
+	RekorRateLimit  string
+	FulcioRateLimit string
 }
 
 // DefaultTimeout specifies the default timeout for commands.
@@ -51,6 +55,30 @@ func (o *RootOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.PersistentFlags().DurationVarP(&o.Timeout, "timeout", "t", DefaultTimeout,
 		"timeout for commands")
+
+	cmd.PersistentFlags().StringVar(&o.RekorRateLimit, "rekor-rate-limit", "",
+		"limit the requests to each Rekor instance to RATE[/UNIT][:BURST], such as 5 or 300/m:10, where UNIT is s, m or h. Unlimited by default")
+
+	cmd.PersistentFlags().StringVar(&o.FulcioRateLimit, "fulcio-rate-limit", "",
+		"limit the requests to each Fulcio instance to RATE[/UNIT][:BURST], such as 5 or 300/m:10, where UNIT is s, m or h. Unlimited by default")
+}
+
+// SetRateLimits configures the limits of the requests to Rekor and Fulcio.
+func (o *RootOptions) SetRateLimits() error {
+	for service, spec := range map[string]string{
+		ratelimit.Rekor:  o.RekorRateLimit,
+		ratelimit.Fulcio: o.FulcioRateLimit,
+	} {
+		if spec == "" {
+			continue
+		}
+		l, err := ratelimit.ParseLimit(spec)
+		if err != nil {
+			return fmt.Errorf("--%s-rate-limit: %w", service, err)
+		}
+		ratelimit.SetLimit(service, l)
+	}
+	return nil
 }
 
 func BindViper(cmd *cobra.Command, args []string) {
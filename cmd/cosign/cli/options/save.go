@@ -16,13 +16,51 @@
 package options
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 )
 
+// SaveFormat is how 'cosign save' stores the image on disk.
+type SaveFormat string
+
+const (
+	// SaveFormatLayout stores the image as an OCI image layout directory.
+	SaveFormatLayout SaveFormat = "layout"
+	// SaveFormatBundleTar stores the OCI image layout, with the trust
+	// material needed to verify it, as a single tar file.
+	SaveFormatBundleTar SaveFormat = "bundle-tar"
+)
+
+// String implements github.com/spf13/pflag.Value.
+func (f *SaveFormat) String() string {
+	return string(*f)
+}
+
+// Set implements github.com/spf13/pflag.Value.
+func (f *SaveFormat) Set(v string) error {
+	switch v {
+	case "layout", "bundle-tar":
+		*f = SaveFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "layout" or "bundle-tar"`)
+	}
+}
+
+// Type implements github.com/spf13/pflag.Value.
+func (f *SaveFormat) Type() string {
+	return "FORMAT"
+}
+
 // SaveOptions is the top level wrapper for the load command.
 type SaveOptions struct {
-	Directory string
-	Registry  RegistryOptions
+	Directory       string
+	TrustedRootPath string
+	Format          SaveFormat
+	Key             string
+	Jobs            int
+	Registry        RegistryOptions
 }
 
 var _ Interface = (*SaveOptions)(nil)
@@ -31,7 +69,24 @@ var _ Interface = (*SaveOptions)(nil)
 func (o *SaveOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
 	cmd.Flags().StringVar(&o.Directory, "dir", "",
-		"path to dir where the signed image should be stored on disk")
+		"path to dir where the signed image should be stored on disk, or to the file to write with --format bundle-tar")
 	_ = cmd.Flags().SetAnnotation("dir", cobra.BashCompSubdirsInDir, []string{})
 	_ = cmd.MarkFlagRequired("dir")
+
+	cmd.Flags().StringVar(&o.TrustedRootPath, "trusted-root", "",
+		"path to a Sigstore trusted root JSON file to store alongside the image, used by 'cosign verify --local-image' "+
+			"to verify signatures offline. Fetched from the Sigstore TUF repository if unset")
+	_ = cmd.Flags().SetAnnotation("trusted-root", cobra.BashCompFilenameExt, []string{})
+
+	o.Format = SaveFormatLayout
+	cmd.Flags().Var(&o.Format, "format",
+		"how to store the signed image: <layout|bundle-tar>. bundle-tar writes a single tar file holding the image layout, "+
+			"its signatures, attestations and trust material, for transfer to air-gapped environments")
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the public key, or a KMS URI, to store alongside the image with --format bundle-tar")
+	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().IntVar(&o.Jobs, "jobs", 0,
+		"number of images, signatures and attestations to save at once, defaults to the number of CPUs")
 }
@@ -0,0 +1,58 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// SearchAttestationsOptions is the top level wrapper for the search
+// attestations command.
+type SearchAttestationsOptions struct {
+	Key    string
+	Query  string
+	Output string
+
+	CommonVerifyOptions CommonVerifyOptions
+	SecurityKey         SecurityKeyOptions
+	CertVerify          CertVerifyOptions
+	Rekor               RekorOptions
+	Registry            RegistryOptions
+	Predicate           PredicateRemoteOptions
+}
+
+var _ Interface = (*SearchAttestationsOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SearchAttestationsOptions) AddFlags(cmd *cobra.Command) {
+	o.SecurityKey.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.CertVerify.AddFlags(cmd)
+	o.Registry.AddFlags(cmd)
+	o.Predicate.AddFlags(cmd)
+	o.CommonVerifyOptions.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret")
+	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().StringVar(&o.Query, "query", "",
+		"jq-like query the predicate of a verified attestation must match, such as '.packages[].name == \"openssl\"' or "+
+			"'.packages[].versionInfo | startswith(\"3.0.\")'")
+	_ = cmd.MarkFlagRequired("query")
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "text",
+		"output format for the matching images (json|text)")
+}
@@ -33,5 +33,5 @@ func (o *SecurityKeyOptions) AddFlags(cmd *cobra.Command) {
 		"whether to use a hardware security key")
 
 	cmd.Flags().StringVar(&o.Slot, "slot", "",
-		"security key slot to use for generated key (default: signature) (authentication|signature|card-authentication|key-management)")
+		"security key slot to use for generated key (default: signature) (authentication|signature|card-authentication|key-management|retired-82..retired-95)")
 }
@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultSelfReleaseURL is the GitHub releases page of cosign, which
+	// release binaries and their signatures are downloaded from.
+	DefaultSelfReleaseURL = "https://github.com/sigstore/cosign/releases"
+	// DefaultSelfCertIdentity is the identity cosign release binaries are
+	// signed by.
+	DefaultSelfCertIdentity = "keyless@projectsigstore.iam.gserviceaccount.com"
+	// DefaultSelfCertOidcIssuer is the issuer of DefaultSelfCertIdentity.
+	DefaultSelfCertOidcIssuer = "https://accounts.google.com"
+)
+
+// SelfOptions is the top level wrapper for the self verify command.
+type SelfOptions struct {
+	ReleaseURL     string
+	CertIdentity   string
+	CertOidcIssuer string
+	Rekor          RekorOptions
+}
+
+var _ Interface = (*SelfOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SelfOptions) AddFlags(cmd *cobra.Command) {
+	o.Rekor.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.ReleaseURL, "release-url", DefaultSelfReleaseURL,
+		"releases page to download cosign binaries and their signatures from, laid out like GitHub's, with binaries under download/<version>/")
+
+	cmd.Flags().StringVar(&o.CertIdentity, "certificate-identity", DefaultSelfCertIdentity,
+		"identity release binaries must be signed by")
+
+	cmd.Flags().StringVar(&o.CertOidcIssuer, "certificate-oidc-issuer", DefaultSelfCertOidcIssuer,
+		"OIDC issuer of the identity release binaries must be signed by")
+}
+
+// SelfUpdateOptions is the top level wrapper for the self update command.
+type SelfUpdateOptions struct {
+	SelfOptions
+	Version string
+	Verify  bool
+}
+
+var _ Interface = (*SelfUpdateOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SelfUpdateOptions) AddFlags(cmd *cobra.Command) {
+	o.SelfOptions.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Version, "version", "",
+		"release to update to, such as v2.4.1. Defaults to the latest release")
+
+	cmd.Flags().BoolVar(&o.Verify, "verify", true,
+		"verify the downloaded binary against its release signature before replacing the running one")
+}
@@ -16,9 +16,41 @@
 package options
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 )
 
+// SignType is the type of the artifacts signed, selected with --type.
+type SignType string
+
+const (
+	// SignTypeImage signs container images and other artifacts already in
+	// a registry.
+	SignTypeImage SignType = "image"
+	// SignTypeWasm pushes a WebAssembly module to the image reference as an
+	// OCI artifact, then signs it.
+	SignTypeWasm SignType = "wasm"
+)
+
+func (t *SignType) String() string {
+	return string(*t)
+}
+
+func (t *SignType) Set(v string) error {
+	switch SignType(v) {
+	case SignTypeImage, SignTypeWasm:
+		*t = SignType(v)
+		return nil
+	default:
+		return errors.New(`must be one of "image", "wasm"`)
+	}
+}
+
+func (t *SignType) Type() string {
+	return "signType"
+}
+
 // SignOptions is the top level wrapper for the sign command.
 type SignOptions struct {
 	Key                     string
@@ -38,10 +70,21 @@ type SignOptions struct {
 	TSAClientCert           string
 	TSAClientKey            string
 	TSAServerName           string
-	TSAServerURL            string
+	TSAServerURLs           []string
 	IssueCertificate        bool
+	Keyless                 bool
 	SignContainerIdentity   string
 	RecordCreationTimestamp bool
+	Deterministic           bool
+	NoDuplicate             bool
+	Policies                []string
+	PolicyAttestationKey    string
+	ImagesFile              string
+	OutputResults           string
+	BundlePath              string
+	BundleFormat            BundleFormat
+	Type                    SignType
+	WasmModule              string
 
 	Rekor       RekorOptions
 	Fulcio      FulcioOptions
@@ -93,6 +136,12 @@ func (o *SignOptions) AddFlags(cmd *cobra.Command) {
 		"write the certificate to FILE")
 	_ = cmd.Flags().SetAnnotation("output-certificate", cobra.BashCompFilenameExt, []string{})
 
+	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
+		"write everything required to verify the signature to FILE, suffixed with the digest of each image when signing recursively")
+	_ = cmd.Flags().SetAnnotation("bundle", cobra.BashCompFilenameExt, []string{})
+
+	addBundleFormatFlag(cmd, &o.BundleFormat)
+
 	cmd.Flags().StringVar(&o.PayloadPath, "payload", "",
 		"path to a payload file to use rather than generating one")
 	_ = cmd.Flags().SetAnnotation("payload", cobra.BashCompFilenameExt, []string{})
@@ -121,16 +170,54 @@ func (o *SignOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.TSAServerName, "timestamp-server-name", "",
 		"SAN name to use as the 'ServerName' tls.Config field to verify the mTLS connection to the TSA Server")
 
-	cmd.Flags().StringVar(&o.TSAServerURL, "timestamp-server-url", "",
-		"url to the Timestamp RFC3161 server, default none. Must be the path to the API to request timestamp responses, e.g. https://freetsa.org/tsr")
+	cmd.Flags().StringSliceVar(&o.TSAServerURLs, "timestamp-server-url", nil,
+		"url to the Timestamp RFC3161 server, default none. Must be the path to the API to request timestamp responses, e.g. https://freetsa.org/tsr. "+
+			"May be repeated to store the timestamps of several servers, any of which is accepted when verifying; servers that fail are skipped as long as one responds")
 
 	_ = cmd.Flags().SetAnnotation("certificate", cobra.BashCompFilenameExt, []string{"cert"})
 
 	cmd.Flags().BoolVar(&o.IssueCertificate, "issue-certificate", false,
 		"issue a code signing certificate from Fulcio, even if a key is provided")
 
+	cmd.Flags().BoolVar(&o.Keyless, "keyless", false,
+		"generate the key of keyless signing in the security key (--sk) or PKCS11 token (--key pkcs11:...), replacing its key, and issue a code signing certificate for it from Fulcio")
+
 	cmd.Flags().StringVar(&o.SignContainerIdentity, "sign-container-identity", "",
 		"manually set the .critical.docker-reference field for the signed identity, which is useful when image proxies are being used where the pull reference should match the signature")
 
 	cmd.Flags().BoolVar(&o.RecordCreationTimestamp, "record-creation-timestamp", false, "set the createdAt timestamp in the signature artifact to the time it was created; by default, cosign sets this to the zero value")
+
+	cmd.Flags().BoolVar(&o.Deterministic, "deterministic", false,
+		"sign such that signing the same digest with the same --key gives the same signature and payload, for reproducibility audits: "+
+			"ECDSA keys sign with RFC 6979 nonces (cosign built with Go 1.24 or later) and the payload is canonicalized. "+
+			"It is rejected with --record-creation-timestamp and --timestamp-server-url, leaving createdAt at the zero value. Combine with --tlog-upload=false for the signature layer to be reproducible too, as the tlog entry records when it was made")
+
+	cmd.Flags().BoolVar(&o.NoDuplicate, "no-duplicate", false,
+		"do not attach the signature if the image already has one over the same payload from the same key, even if its certificate, tlog bundle or timestamp differ")
+
+	cmd.Flags().StringSliceVar(&o.Policies, "policy", nil,
+		"CUE or Rego files with policies each image must pass before it is signed, evaluated against a JSON document "+
+			"with its image, repository, digest and attestations")
+
+	cmd.Flags().StringVar(&o.PolicyAttestationKey, "policy-attestation-key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret that the attestations given to --policy are verified with. "+
+			"Without it, the policies are given no attestations")
+	_ = cmd.Flags().SetAnnotation("policy-attestation-key", cobra.BashCompFilenameExt, []string{})
+
+	cmd.Flags().StringVar(&o.ImagesFile, "images-file", "",
+		"path to a file listing images to sign in addition to the arguments, one per line, or - for stdin")
+	_ = cmd.Flags().SetAnnotation("images-file", cobra.BashCompFilenameExt, []string{})
+
+	o.Type = SignTypeImage
+	cmd.Flags().Var(&o.Type, "type", "type of the artifact to sign. allowed: image, wasm. "+
+		"wasm pushes the module of --wasm-module to the image reference as a WebAssembly OCI artifact, which wasm runtimes pull, then signs it")
+
+	cmd.Flags().StringVar(&o.WasmModule, "wasm-module", "",
+		"path to the WebAssembly module, core module or component, to push and sign with --type wasm")
+	_ = cmd.Flags().SetAnnotation("wasm-module", cobra.BashCompFilenameExt, []string{"wasm"})
+
+	cmd.Flags().StringVar(&o.OutputResults, "output-results", "",
+		"write the digests signed for each image, and the error of each image that could not be signed, as JSON to FILE, or - for stdout. "+
+			"Signing then continues past images that fail")
+	_ = cmd.Flags().SetAnnotation("output-results", cobra.BashCompFilenameExt, []string{})
 }
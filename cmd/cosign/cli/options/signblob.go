@@ -17,6 +17,8 @@ package options
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
 )
 
 // SignBlobOptions is the top level wrapper for the sign-blob command.
@@ -34,6 +36,7 @@ type SignBlobOptions struct {
 	Registry             RegistryOptions
 	BundlePath           string
 	NewBundleFormat      bool
+	BundleFormat         BundleFormat
 	SkipConfirmation     bool
 	TlogUpload           bool
 	TSAClientCACert      string
@@ -43,6 +46,8 @@ type SignBlobOptions struct {
 	TSAServerURL         string
 	RFC3161TimestampPath string
 	IssueCertificate     bool
+	Keyless              bool
+	SSHNamespace         string
 }
 
 var _ Interface = (*SignBlobOptions)(nil)
@@ -55,7 +60,7 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 	o.OIDC.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Key, "key", "",
-		"path to the private key file, KMS URI or Kubernetes Secret")
+		"path to the private key file, KMS URI, Kubernetes Secret, ssh://<path> of an OpenSSH private key, minisign://<path> of a minisign secret key or gpg://<path> of an OpenPGP secret key")
 	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
 
 	cmd.Flags().BoolVar(&o.Base64Output, "b64", true,
@@ -80,6 +85,8 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.NewBundleFormat, "new-bundle-format", false,
 		"output bundle in new format that contains all verification material")
 
+	addBundleFormatFlag(cmd, &o.BundleFormat)
+
 	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
 		"skip confirmation prompts for non-destructive operations")
 
@@ -107,4 +114,10 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&o.IssueCertificate, "issue-certificate", false,
 		"issue a code signing certificate from Fulcio, even if a key is provided")
+
+	cmd.Flags().BoolVar(&o.Keyless, "keyless", false,
+		"generate the key of keyless signing in the security key (--sk) or PKCS11 token (--key pkcs11:...), replacing its key, and issue a code signing certificate for it from Fulcio")
+
+	cmd.Flags().StringVar(&o.SSHNamespace, "ssh-namespace", sshsig.DefaultNamespace,
+		"namespace of the signature made with an ssh:// key, as with ssh-keygen -Y sign -n")
 }
@@ -17,12 +17,21 @@ package options
 import "github.com/spf13/cobra"
 
 type TreeOptions struct {
-	Registry  RegistryOptions
-	CleanType string
+	Registry     RegistryOptions
+	CleanType    string
+	Output       string
+	Type         string
+	OutputSchema OutputSchemaOptions
 }
 
 var _ Interface = (*TreeOptions)(nil)
 
 func (c *TreeOptions) AddFlags(cmd *cobra.Command) {
 	c.Registry.AddFlags(cmd)
+	c.OutputSchema.AddFlags(cmd)
+	cmd.Flags().StringVarP(&c.Output, "output", "o", "text",
+		"output format for the artifacts found (text|json)")
+	cmd.Flags().StringVar(&c.Type, "type", "",
+		"only display artifacts of this type: attestation, signature, sbom, referrer, or the artifact type of referrers such as application/spdx+json. "+
+			"Displays all artifacts, including referrers not written by cosign, if unset")
 }
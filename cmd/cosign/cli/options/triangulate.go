@@ -21,8 +21,10 @@ import (
 
 // TriangulateOptions is the top level wrapper for the triangulate command.
 type TriangulateOptions struct {
-	Type     string
-	Registry RegistryOptions
+	Type         string
+	Output       string
+	Registry     RegistryOptions
+	OutputSchema OutputSchemaOptions
 }
 
 var _ Interface = (*TriangulateOptions)(nil)
@@ -30,7 +32,12 @@ var _ Interface = (*TriangulateOptions)(nil)
 // AddFlags implements Interface
 func (o *TriangulateOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
+	o.OutputSchema.AddFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "text",
+		"output format for the located reference (text|json)")
 
 	cmd.Flags().StringVar(&o.Type, "type", "signature",
-		"related attachment to triangulate (attestation|sbom|signature|digest), default signature (sbom is deprecated)")
+		"related attachment to triangulate (attestation|sbom|signature|digest|referrer), default signature (sbom is deprecated). "+
+			"referrer, or the artifact type of referrers such as application/spdx+json, lists the referrers of the image, including those not written by cosign")
 }
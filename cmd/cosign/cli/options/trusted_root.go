@@ -0,0 +1,39 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// TrustedRootExportOptions is the top level wrapper for the trusted-root
+// export command.
+type TrustedRootExportOptions struct {
+	TrustedRootPath string
+	Out             string
+}
+
+var _ Interface = (*TrustedRootExportOptions)(nil)
+
+// AddFlags implements Interface
+func (o *TrustedRootExportOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.TrustedRootPath, "trusted-root", "",
+		"path to a Sigstore trusted root JSON file to export. Fetched from the Sigstore TUF repository if unset")
+	_ = cmd.Flags().SetAnnotation("trusted-root", cobra.BashCompFilenameExt, []string{"json"})
+
+	cmd.Flags().StringVar(&o.Out, "out", "",
+		"path to write the exported trust material to. Written to stdout if unset")
+	_ = cmd.Flags().SetAnnotation("out", cobra.BashCompFilenameExt, []string{})
+}
@@ -16,6 +16,9 @@
 package options
 
 import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 )
 
@@ -57,3 +60,31 @@ func (o *UploadWASMOptions) AddFlags(cmd *cobra.Command) {
 	_ = cmd.Flags().SetAnnotation("file", cobra.BashCompFilenameExt, []string{})
 	_ = cmd.MarkFlagRequired("file")
 }
+
+// UploadOptions is the wrapper for how attachments are uploaded to
+// registries.
+type UploadOptions struct {
+	ChunkSize string
+}
+
+var _ Interface = (*UploadOptions)(nil)
+
+// AddFlags implements Interface
+func (o *UploadOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.ChunkSize, "upload-chunk-size", "",
+		"upload layers in chunks of this size, such as 64MiB, resuming an upload interrupted by a retryable error from the last chunk the registry received. "+
+			"Layers are uploaded in a single request when empty")
+}
+
+// ApplyTo sets the chunk size of the uploads made with r.
+func (o *UploadOptions) ApplyTo(r *RegistryOptions) error {
+	if o.ChunkSize == "" {
+		return nil
+	}
+	n, err := humanize.ParseBytes(o.ChunkSize)
+	if err != nil || n == 0 || n > 1<<62 {
+		return fmt.Errorf("invalid --upload-chunk-size %q", o.ChunkSize)
+	}
+	r.UploadChunkSize = int64(n)
+	return nil
+}
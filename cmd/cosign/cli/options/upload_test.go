@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import "testing"
+
+func TestUploadOptionsApplyTo(t *testing.T) {
+	tests := []struct {
+		chunkSize string
+		want      int64
+		wantErr   bool
+	}{
+		{chunkSize: "", want: 0},
+		{chunkSize: "64MiB", want: 64 << 20},
+		{chunkSize: "1000", want: 1000},
+		{chunkSize: "0", wantErr: true},
+		{chunkSize: "lots", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.chunkSize, func(t *testing.T) {
+			var r RegistryOptions
+			err := (&UploadOptions{ChunkSize: tc.chunkSize}).ApplyTo(&r)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ApplyTo() = %v, wanted error %v", err, tc.wantErr)
+			}
+			if r.UploadChunkSize != tc.want {
+				t.Errorf("UploadChunkSize = %d, wanted %d", r.UploadChunkSize, tc.want)
+			}
+		})
+	}
+}
@@ -16,9 +16,12 @@
 package options
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/internal/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
 )
 
 type CommonVerifyOptions struct {
@@ -39,7 +42,7 @@ func (o *CommonVerifyOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.TSACertChainPath, "timestamp-certificate-chain", "",
 		"path to PEM-encoded certificate chain file for the RFC3161 timestamp authority. Must contain the root CA certificate. "+
-			"Optionally may contain intermediate CA certificates, and may contain the leaf TSA certificate if not present in the timestamp")
+			"Optionally may contain intermediate CA certificates, and may contain the leaf TSA certificate if not present in the timestamp. May contain the chains of several timestamp authorities, any of which is trusted")
 
 	cmd.Flags().BoolVar(&o.UseSignedTimestamps, "use-signed-timestamps", false,
 		"use signed timestamps if available")
@@ -67,13 +70,38 @@ type VerifyOptions struct {
 	SignatureRef string
 	PayloadRef   string
 	LocalImage   bool
+	Recursive    bool
+	// Platform selects the image within a multiarch index to verify.
+	Platform string
+	// CheckTagDigest fails verification of repo:tag@digest references whose
+	// tag was moved to another digest.
+	CheckTagDigest bool
+	// ChangedSince is the path of the state file recording the images
+	// verified under the current policy, which are not verified again.
+	ChangedSince string
+	// RequireSignatures is the number of distinct signers whose signatures
+	// must verify.
+	RequireSignatures int
+	// HelmProvenanceType is the predicate type of the provenance attestation
+	// of Helm charts given as helm:// references.
+	HelmProvenanceType string
+	// WasmModule is the path of a WebAssembly module the verified images
+	// must be the OCI artifacts of.
+	WasmModule string
+
+	AnnotationConditions []string
 
 	CommonVerifyOptions CommonVerifyOptions
 	SecurityKey         SecurityKeyOptions
 	CertVerify          CertVerifyOptions
 	Rekor               RekorOptions
 	Registry            RegistryOptions
+	SignatureMirrors    SignatureMirrorOptions
+	RegistryMirrors     RegistryMirrorOptions
+	SignatureCache      SignatureCacheOptions
 	SignatureDigest     SignatureDigestOptions
+	OutputSchema        OutputSchemaOptions
+	Redact              RedactOptions
 
 	AnnotationOptions
 }
@@ -82,16 +110,21 @@ var _ Interface = (*VerifyOptions)(nil)
 
 // AddFlags implements Interface
 func (o *VerifyOptions) AddFlags(cmd *cobra.Command) {
+	o.OutputSchema.AddFlags(cmd)
 	o.SecurityKey.AddFlags(cmd)
 	o.Rekor.AddFlags(cmd)
 	o.CertVerify.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
+	o.SignatureMirrors.AddFlags(cmd)
+	o.RegistryMirrors.AddFlags(cmd)
+	o.SignatureCache.AddFlags(cmd)
 	o.SignatureDigest.AddFlags(cmd)
 	o.AnnotationOptions.AddFlags(cmd)
+	o.Redact.AddFlags(cmd)
 	o.CommonVerifyOptions.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Key, "key", "",
-		"path to the public key file, KMS URI or Kubernetes Secret")
+		"path to the public key file or key ring, KMS URI or Kubernetes Secret")
 	_ = cmd.Flags().SetAnnotation("key", cobra.BashCompFilenameExt, []string{})
 
 	cmd.Flags().BoolVar(&o.CheckClaims, "check-claims", true,
@@ -111,6 +144,36 @@ func (o *VerifyOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&o.LocalImage, "local-image", false,
 		"whether the specified image is a path to an image saved locally via 'cosign save'")
+
+	cmd.Flags().BoolVar(&o.Recursive, "recursive", false,
+		"with --local-image, if a multi-arch image was saved, additionally verify the signature of each discrete image")
+
+	cmd.Flags().StringVar(&o.Platform, "platform", "",
+		"verify the image for a specific platform within a multi-arch index, such as linux/arm64")
+
+	cmd.Flags().BoolVar(&o.CheckTagDigest, "check-tag-digest", false,
+		"when an image is given as repo:tag@digest, fail if the tag no longer points at the digest. The digest is verified either way")
+
+	cmd.Flags().StringVar(&o.ChangedSince, "changed-since", "",
+		"path to a state file recording the image digests verified under the same policy and trust root. Images it records are skipped, "+
+			"and images that verify are added to it, so that only new or changed images are verified. Created if missing")
+	_ = cmd.Flags().SetAnnotation("changed-since", cobra.BashCompFilenameExt, []string{"json"})
+
+	cmd.Flags().IntVar(&o.RequireSignatures, "require-signatures", 1,
+		"require signatures that verify by at least this many distinct signers: distinct keys of a key ring given with --key, "+
+			"or distinct certificate identities matching --certificate-identity-regexp")
+
+	cmd.Flags().StringVar(&o.HelmProvenanceType, "helm-provenance-type", "slsaprovenance",
+		"predicate type of the provenance attestation, naming the chart archive as a subject, that Helm charts given as helm://registry/repo/chart:version must have. "+
+			"Accepts the values of 'cosign attest --type'")
+
+	cmd.Flags().StringVar(&o.WasmModule, "wasm-module", "",
+		"path to a WebAssembly module, such as one pulled by a wasm runtime, that each image must be the WebAssembly OCI artifact of. "+
+			"The signatures of the artifact holding the module are verified")
+	_ = cmd.Flags().SetAnnotation("wasm-module", cobra.BashCompFilenameExt, []string{"wasm"})
+
+	cmd.Flags().StringArrayVar(&o.AnnotationConditions, "annotation-condition", nil,
+		"condition the signed annotations must satisfy, such as 'build>=42', 'created<2024-06-01T00:00:00Z' or 'version>=1.2.0 && version<2.0.0 || env=dev'. Ordering operators compare numbers, RFC 3339 timestamps or semantic versions. May be repeated, and every condition must hold")
 }
 
 // VerifyAttestationOptions is the top level wrapper for the `verify attestation` command.
@@ -124,9 +187,20 @@ type VerifyAttestationOptions struct {
 	Rekor               RekorOptions
 	CertVerify          CertVerifyOptions
 	Registry            RegistryOptions
+	SignatureMirrors    SignatureMirrorOptions
+	RegistryMirrors     RegistryMirrorOptions
+	SignatureCache      SignatureCacheOptions
 	Predicate           PredicateRemoteOptions
+	Redact              RedactOptions
 	Policies            []string
+	PolicyPlugins       []string
 	LocalImage          bool
+	Platform            string
+	MaxAttestationAge   time.Duration
+	StatementTime       bool
+	CheckTagDigest      bool
+	AttestationIndex    string
+	RequireSignatures   int
 }
 
 var _ Interface = (*VerifyAttestationOptions)(nil)
@@ -137,7 +211,11 @@ func (o *VerifyAttestationOptions) AddFlags(cmd *cobra.Command) {
 	o.Rekor.AddFlags(cmd)
 	o.CertVerify.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
+	o.SignatureMirrors.AddFlags(cmd)
+	o.RegistryMirrors.AddFlags(cmd)
+	o.SignatureCache.AddFlags(cmd)
 	o.Predicate.AddFlags(cmd)
+	o.Redact.AddFlags(cmd)
 	o.CommonVerifyOptions.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Key, "key", "",
@@ -149,11 +227,36 @@ func (o *VerifyAttestationOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&o.Policies, "policy", nil,
 		"specify CUE or Rego files with policies to be used for validation")
 
+	cmd.Flags().StringSliceVar(&o.PolicyPlugins, "policy-plugin", nil,
+		"executable consulted for each attestation, receiving the attestation and verification context as JSON on stdin "+
+			"and answering with a JSON verdict {\"allow\": bool, \"violations\": [...], \"warnings\": [...]} on stdout")
+
 	cmd.Flags().StringVarP(&o.Output, "output", "o", "json",
 		"output format for the signing image information (json|text)")
 
 	cmd.Flags().BoolVar(&o.LocalImage, "local-image", false,
 		"whether the specified image is a path to an image saved locally via 'cosign save'")
+
+	cmd.Flags().StringVar(&o.Platform, "platform", "",
+		"verify the attestations of the image for a specific platform within a multi-arch index, such as linux/arm64")
+
+	cmd.Flags().DurationVar(&o.MaxAttestationAge, "max-attestation-age", 0,
+		"fail verification if the newest matching attestation is older than this duration (e.g. 72h), judged by its verified transparency log or RFC3161 timestamp. 0 disables the check")
+
+	cmd.Flags().BoolVar(&o.StatementTime, "attestation-time-from-statement", false,
+		"when checking --max-attestation-age, fall back to the timestamp recorded in the attestation's predicate if it has no verified transparency log or RFC3161 timestamp. "+
+			"This time is chosen by the signer")
+
+	cmd.Flags().BoolVar(&o.CheckTagDigest, "check-tag-digest", false,
+		"when an image is given as repo:tag@digest, fail if the tag no longer points at the digest. The digest is verified either way")
+
+	cmd.Flags().StringVar(&o.AttestationIndex, "attestation-index", "",
+		"multi-arch image whose attestations, created with 'cosign attest --recursive --multi-subject', name the verified images as subjects. "+
+			"Attestations are read from it instead of from each image")
+
+	cmd.Flags().IntVar(&o.RequireSignatures, "require-signatures", 1,
+		"require attestations that verify by at least this many distinct signers: distinct keys of a key ring given with --key, "+
+			"or distinct certificate identities matching --certificate-identity-regexp")
 }
 
 // VerifyBlobOptions is the top level wrapper for the `verify blob` command.
@@ -170,6 +273,8 @@ type VerifyBlobOptions struct {
 	CommonVerifyOptions CommonVerifyOptions
 
 	RFC3161TimestampPath string
+	SSHIdentity          string
+	SSHNamespace         string
 }
 
 var _ Interface = (*VerifyBlobOptions)(nil)
@@ -182,7 +287,7 @@ func (o *VerifyBlobOptions) AddFlags(cmd *cobra.Command) {
 	o.CommonVerifyOptions.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Key, "key", "",
-		"path to the public key file, KMS URI or Kubernetes Secret")
+		"path to the public key file, KMS URI, Kubernetes Secret, ssh://<path> of an OpenSSH allowed signers file, minisign://<path> of a minisign or signify public key or gpg://<path> of an OpenPGP keyring")
 
 	cmd.Flags().StringVar(&o.Signature, "signature", "",
 		"signature content or path or remote URL")
@@ -195,10 +300,47 @@ func (o *VerifyBlobOptions) AddFlags(cmd *cobra.Command) {
 		"output bundle in new format that contains all verification material")
 
 	cmd.Flags().StringVar(&o.TrustedRootPath, "trusted-root", "",
-		"path to trusted root FILE")
+		"path to trusted root FILE. Its checkpointWitnesses field may list the note verifier keys of witnesses, and a threshold of them, that must cosign the checkpoints of Rekor inclusion proofs")
 
 	cmd.Flags().StringVar(&o.RFC3161TimestampPath, "rfc3161-timestamp", "",
 		"path to RFC3161 timestamp FILE")
+
+	cmd.Flags().StringVar(&o.SSHIdentity, "ssh-identity", "",
+		"principal the signature must be made for when --key is an ssh://<path> allowed signers file, as with ssh-keygen -Y verify -I")
+
+	cmd.Flags().StringVar(&o.SSHNamespace, "ssh-namespace", sshsig.DefaultNamespace,
+		"namespace the signature must be made in when --key is an ssh://<path> allowed signers file, as with ssh-keygen -Y verify -n")
+}
+
+// VerifyBinaryOptions is the top level wrapper for the `verify-binary` command.
+type VerifyBinaryOptions struct {
+	Key             string
+	NewBundleFormat bool
+	TrustedRootPath string
+
+	SecurityKey         SecurityKeyOptions
+	CertVerify          CertVerifyOptions
+	Rekor               RekorOptions
+	CommonVerifyOptions CommonVerifyOptions
+}
+
+var _ Interface = (*VerifyBinaryOptions)(nil)
+
+// AddFlags implements Interface
+func (o *VerifyBinaryOptions) AddFlags(cmd *cobra.Command) {
+	o.SecurityKey.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.CertVerify.AddFlags(cmd)
+	o.CommonVerifyOptions.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret")
+
+	cmd.Flags().BoolVar(&o.NewBundleFormat, "new-bundle-format", false,
+		"the embedded bundle is in the new format that contains all verification material")
+
+	cmd.Flags().StringVar(&o.TrustedRootPath, "trusted-root", "",
+		"path to trusted root FILE. Its checkpointWitnesses field may list the note verifier keys of witnesses, and a threshold of them, that must cosign the checkpoints of Rekor inclusion proofs")
 }
 
 // VerifyDockerfileOptions is the top level wrapper for the `dockerfile verify` command.
@@ -227,6 +369,7 @@ type VerifyBlobAttestationOptions struct {
 
 	PredicateOptions
 	CheckClaims bool
+	Directory   string
 
 	SecurityKey         SecurityKeyOptions
 	CertVerify          CertVerifyOptions
@@ -260,11 +403,15 @@ func (o *VerifyBlobAttestationOptions) AddFlags(cmd *cobra.Command) {
 		"output bundle in new format that contains all verification material")
 
 	cmd.Flags().StringVar(&o.TrustedRootPath, "trusted-root", "",
-		"path to trusted root FILE")
+		"path to trusted root FILE. Its checkpointWitnesses field may list the note verifier keys of witnesses, and a threshold of them, that must cosign the checkpoints of Rekor inclusion proofs")
 
 	cmd.Flags().BoolVar(&o.CheckClaims, "check-claims", true,
 		"if true, verifies the provided blob's sha256 digest exists as an in-toto subject within the attestation. If false, only the DSSE envelope is verified.")
 
+	cmd.Flags().StringVar(&o.Directory, "directory", "",
+		"verify the files in the tree of the directory instead of a blob: the subjects of the attestation must be exactly its files, named by their paths in the directory")
+	_ = cmd.Flags().SetAnnotation("directory", cobra.BashCompSubdirsInDir, []string{})
+
 	cmd.Flags().StringVar(&o.RFC3161TimestampPath, "rfc3161-timestamp", "",
 		"path to RFC3161 timestamp FILE")
 }
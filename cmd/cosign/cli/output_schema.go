@@ -0,0 +1,45 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+)
+
+// argsOrOutputSchema validates positional arguments with args, unless
+// --output-schema is set, which needs none.
+func argsOrOutputSchema(o *options.OutputSchemaOptions, args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, a []string) error {
+		if o.OutputSchema {
+			return nil
+		}
+		return args(cmd, a)
+	}
+}
+
+// printOutputSchema prints the JSON Schema called name to stdout.
+func printOutputSchema(name string) error {
+	b, err := schema.Get(name)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(b))
+	return nil
+}
@@ -159,7 +159,7 @@ func pivToolGenerateKey() *cobra.Command {
 		Args:  cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return pivcli.GenerateKeyCmd(cmd.Context(), o.ManagementKey, o.RandomKey,
-				o.Slot, o.PINPolicy, o.TouchPolicy)
+				o.Slot, o.PINPolicy, o.TouchPolicy, o.SkipAttestation)
 		},
 	}
 
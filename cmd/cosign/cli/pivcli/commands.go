@@ -200,7 +200,7 @@ func toPem(c *x509.Certificate) string {
 	return string(b)
 }
 
-func GenerateKeyCmd(ctx context.Context, managementKey string, randomKey bool, slotArg string, pinPolicyArg string, touchPolicyArg string) error {
+func GenerateKeyCmd(ctx context.Context, managementKey string, randomKey bool, slotArg string, pinPolicyArg string, touchPolicyArg string, skipAttestation bool) error {
 	slot := pivkey.SlotForName(slotArg)
 	if slot == nil {
 		return flag.ErrHelp
@@ -263,6 +263,12 @@ func GenerateKeyCmd(ctx context.Context, managementKey string, randomKey bool, s
 	})
 
 	fmt.Println(string(pemBytes))
+
+	if skipAttestation {
+		// Devices without YubiKey attestation read the public key back from
+		// the certificate of the slot.
+		return yk.SetSelfSignedCertificate(*keyBytes, *slot, pubKey, pinPolicy)
+	}
 	yk.Close()
 
 	att, err := AttestationCmd(ctx, slotArg)
@@ -35,6 +35,7 @@ func PKCS11Tool() *cobra.Command {
 	cmd.AddCommand(
 		pkcs11ToolListTokens(),
 		PKCS11ToolListKeysUrisOptions(),
+		pkcs11ToolListMechanisms(),
 	)
 
 	// TODO: drop -f in favor of --no-input only
@@ -70,7 +71,30 @@ func PKCS11ToolListKeysUrisOptions() *cobra.Command {
 		Short: "list-keys-uris lists URIs of all keys in a PKCS11 token",
 		Args:  cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return pkcs11cli.ListKeysUrisCmd(cmd.Context(), o.ModulePath, o.SlotID, o.Pin)
+			return pkcs11cli.ListKeysUrisCmd(cmd.Context(), o.ModulePath, o.SlotID, o.TokenLabel, o.TokenSerial, o.Pin)
+		},
+	}
+
+	o.AddFlags(cmd)
+
+	return cmd
+}
+
+func pkcs11ToolListMechanisms() *cobra.Command {
+	o := &options.PKCS11ToolListMechanismsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list-mechanisms",
+		Short: "list-mechanisms lists the mechanisms supported by a PKCS11 token",
+		Long: `list-mechanisms lists the mechanisms supported by a PKCS11 token.
+
+Cosign signs with ECDSA keys on the P-256, P-384 and P-521 curves through
+CKM_ECDSA, and with RSA keys through CKM_RSA_PKCS or, for keys whose URI has
+the x-rsa-scheme=pss query attribute, CKM_RSA_PKCS_PSS.`,
+		Example: `  cosign pkcs11-tool list-mechanisms --module-path /usr/lib/softhsm/libsofthsm2.so --token-label "My Token"`,
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pkcs11cli.ListMechanismsCmd(cmd.Context(), o.ModulePath, o.SlotID, o.TokenLabel, o.TokenSerial)
 		},
 	}
 
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
@@ -44,6 +45,40 @@ type KeyInfo struct {
 	KeyURI   string
 }
 
+type Mechanism struct {
+	Type uint
+	Info pkcs11.MechanismInfo
+}
+
+// signingMechanisms names the mechanisms relevant to signing with cosign.
+// Keys are used through CKM_ECDSA, CKM_RSA_PKCS and CKM_RSA_PKCS_PSS, which
+// sign digests computed by cosign.
+var signingMechanisms = map[uint]string{
+	pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN: "CKM_RSA_PKCS_KEY_PAIR_GEN",
+	pkcs11.CKM_RSA_PKCS:              "CKM_RSA_PKCS",
+	pkcs11.CKM_RSA_PKCS_PSS:          "CKM_RSA_PKCS_PSS",
+	pkcs11.CKM_SHA256_RSA_PKCS:       "CKM_SHA256_RSA_PKCS",
+	pkcs11.CKM_SHA384_RSA_PKCS:       "CKM_SHA384_RSA_PKCS",
+	pkcs11.CKM_SHA512_RSA_PKCS:       "CKM_SHA512_RSA_PKCS",
+	pkcs11.CKM_SHA256_RSA_PKCS_PSS:   "CKM_SHA256_RSA_PKCS_PSS",
+	pkcs11.CKM_SHA384_RSA_PKCS_PSS:   "CKM_SHA384_RSA_PKCS_PSS",
+	pkcs11.CKM_SHA512_RSA_PKCS_PSS:   "CKM_SHA512_RSA_PKCS_PSS",
+	pkcs11.CKM_EC_KEY_PAIR_GEN:       "CKM_EC_KEY_PAIR_GEN",
+	pkcs11.CKM_ECDSA:                 "CKM_ECDSA",
+	pkcs11.CKM_ECDSA_SHA256:          "CKM_ECDSA_SHA256",
+	pkcs11.CKM_ECDSA_SHA384:          "CKM_ECDSA_SHA384",
+	pkcs11.CKM_ECDSA_SHA512:          "CKM_ECDSA_SHA512",
+}
+
+// MechanismName returns the name of mechanism type, or its hexadecimal value
+// if it is not relevant to signing.
+func MechanismName(mechanism uint) string {
+	if name, ok := signingMechanisms[mechanism]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%08x", mechanism)
+}
+
 func GetTokens(_ context.Context, modulePath string) ([]Token, error) {
 	if modulePath == "" || !filepath.IsAbs(modulePath) {
 		return nil, flag.ErrHelp
@@ -79,6 +114,58 @@ func GetTokens(_ context.Context, modulePath string) ([]Token, error) {
 	return tokens, nil
 }
 
+// FindSlot returns the slot holding the token with tokenLabel or, if empty,
+// with the serial number tokenSerial.
+func FindSlot(ctx context.Context, modulePath string, tokenLabel string, tokenSerial string) (uint, error) {
+	tokens, err := GetTokens(ctx, modulePath)
+	if err != nil {
+		return 0, err
+	}
+	for _, token := range tokens {
+		if (tokenLabel != "" && token.TokenInfo.Label == tokenLabel) ||
+			(tokenLabel == "" && token.TokenInfo.SerialNumber == tokenSerial) {
+			return token.Slot, nil
+		}
+	}
+	if tokenLabel != "" {
+		return 0, fmt.Errorf("could not find a slot for the token '%s'", tokenLabel)
+	}
+	return 0, fmt.Errorf("could not find a slot for the token with serial number '%s'", tokenSerial)
+}
+
+func GetMechanisms(_ context.Context, modulePath string, slotID uint) ([]Mechanism, error) {
+	if modulePath == "" || !filepath.IsAbs(modulePath) {
+		return nil, flag.ErrHelp
+	}
+
+	// Initialize PKCS11 module.
+	p := pkcs11.New(modulePath)
+	if p == nil {
+		return nil, errors.New("failed to load PKCS11 module")
+	}
+	err := p.Initialize()
+	if err != nil {
+		return nil, fmt.Errorf("initialize PKCS11 module: %w", err)
+	}
+	defer p.Destroy()
+	defer p.Finalize()
+
+	list, err := p.GetMechanismList(slotID)
+	if err != nil {
+		return nil, fmt.Errorf("get mechanism list: %w", err)
+	}
+	mechanisms := make([]Mechanism, 0, len(list))
+	for _, m := range list {
+		info, err := p.GetMechanismInfo(slotID, []*pkcs11.Mechanism{m})
+		if err != nil {
+			return nil, fmt.Errorf("get mechanism info: %w", err)
+		}
+		mechanisms = append(mechanisms, Mechanism{Type: m.Mechanism, Info: info})
+	}
+
+	return mechanisms, nil
+}
+
 func GetKeysInfo(_ context.Context, modulePath string, slotID uint, pin string) ([]KeyInfo, error) {
 	if modulePath == "" || !filepath.IsAbs(modulePath) {
 		return nil, flag.ErrHelp
@@ -225,10 +312,16 @@ func ListTokensCmd(ctx context.Context, modulePath string) error {
 	return nil
 }
 
-func ListKeysUrisCmd(ctx context.Context, modulePath string, slotID uint, pin string) error {
+func ListKeysUrisCmd(ctx context.Context, modulePath string, slotID uint, tokenLabel string, tokenSerial string, pin string) error {
 	if modulePath == "" {
 		return fmt.Errorf("please specify --module-path or set COSIGN_PKCS11_MODULE_PATH")
 	}
+	if tokenLabel != "" || tokenSerial != "" {
+		var err error
+		if slotID, err = FindSlot(ctx, modulePath, tokenLabel, tokenSerial); err != nil {
+			return err
+		}
+	}
 	keysInfo, err := GetKeysInfo(ctx, modulePath, slotID, pin)
 	if err != nil {
 		return err
@@ -248,3 +341,40 @@ func ListKeysUrisCmd(ctx context.Context, modulePath string, slotID uint, pin st
 
 	return nil
 }
+
+func ListMechanismsCmd(ctx context.Context, modulePath string, slotID uint, tokenLabel string, tokenSerial string) error {
+	if modulePath == "" {
+		return fmt.Errorf("please specify --module-path or set COSIGN_PKCS11_MODULE_PATH")
+	}
+	if tokenLabel != "" || tokenSerial != "" {
+		var err error
+		if slotID, err = FindSlot(ctx, modulePath, tokenLabel, tokenSerial); err != nil {
+			return err
+		}
+	}
+	mechanisms, err := GetMechanisms(ctx, modulePath, slotID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "\nListing mechanisms of slot '%d' of PKCS11 module '%s'\n", slotID, modulePath)
+	for _, m := range mechanisms {
+		var usages []string
+		if m.Info.Flags&pkcs11.CKF_SIGN != 0 {
+			usages = append(usages, "sign")
+		}
+		if m.Info.Flags&pkcs11.CKF_VERIFY != 0 {
+			usages = append(usages, "verify")
+		}
+		if m.Info.Flags&pkcs11.CKF_GENERATE_KEY_PAIR != 0 {
+			usages = append(usages, "generate-key-pair")
+		}
+		fmt.Fprintf(os.Stdout, "%s\n", MechanismName(m.Type))
+		fmt.Fprintf(os.Stdout, "\tKey sizes: %d-%d\n", m.Info.MinKeySize, m.Info.MaxKeySize)
+		if len(usages) > 0 {
+			fmt.Fprintf(os.Stdout, "\tUsage: %s\n", strings.Join(usages, ", "))
+		}
+	}
+
+	return nil
+}
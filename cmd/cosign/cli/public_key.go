@@ -48,9 +48,21 @@ func PublicKey() *cobra.Command {
   # extract public key from Google Cloud KMS
   cosign public-key --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY]
 
+  # extract public key from Oracle Cloud Infrastructure Vault
+  cosign public-key --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID]
+
+  # extract public key from Alibaba Cloud KMS
+  cosign public-key --key alibabakms://[REGION]/[KEY_ID]
+
+  # extract public key from Tencent Cloud KMS
+  cosign public-key --key tencentkms://[REGION]/[KEY_ID]
+
   # extract public key from Hashicorp Vault KMS
   cosign public-key --key hashivault://[KEY]
 
+  # extract public key from the signer plugin cosign-signer-[NAME] in PATH
+  cosign public-key --key plugin://[NAME]/[KEY]
+
   # extract public key from GitLab with project name
   cosign public-key --key gitlab://[OWNER]/[PROJECT_NAME] <IMAGE>
 
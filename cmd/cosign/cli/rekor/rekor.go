@@ -15,10 +15,16 @@
 package rekor
 
 import (
+	"context"
+	"fmt"
+
 	rekor "github.com/franchb/rekor/pkg/client"
 	"github.com/franchb/rekor/pkg/generated/client"
+	"github.com/franchb/rekor/pkg/generated/models"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	irekor "github.com/franchb/cosign/v2/internal/pkg/cosign/rekor"
+	"github.com/franchb/cosign/v2/internal/pkg/ratelimit"
 )
 
 func NewClient(rekorURL string) (*client.Rekor, error) {
@@ -26,5 +32,60 @@ func NewClient(rekorURL string) (*client.Rekor, error) {
 	if err != nil {
 		return nil, err
 	}
+	rekorClient.SetTransport(ratelimit.Transport(rekorClient.Transport, ratelimit.For(ratelimit.Rekor, rekorURL)))
 	return rekorClient, nil
 }
+
+// AdditionalURLs returns rekorURLs in the order given, without duplicates and
+// without primaryURL, which is always handled separately.
+func AdditionalURLs(primaryURL string, rekorURLs []string) []string {
+	seen := map[string]bool{primaryURL: true}
+	urls := make([]string, 0, len(rekorURLs))
+	for _, u := range rekorURLs {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// NewAdditionalLogs returns a Rekor client for each of the additional URLs,
+// see AdditionalURLs.
+func NewAdditionalLogs(primaryURL string, rekorURLs []string) ([]irekor.AdditionalLog, error) {
+	urls := AdditionalURLs(primaryURL, rekorURLs)
+	logs := make([]irekor.AdditionalLog, 0, len(urls))
+	for _, u := range urls {
+		c, err := NewClient(u)
+		if err != nil {
+			return nil, fmt.Errorf("creating Rekor client for %s: %w", u, err)
+		}
+		logs = append(logs, irekor.AdditionalLog{URL: u, Client: c})
+	}
+	return logs, nil
+}
+
+// NewClients returns a Rekor client for each of the additional URLs, see
+// AdditionalURLs, keyed by URL.
+func NewClients(primaryURL string, rekorURLs []string) (map[string]*client.Rekor, error) {
+	logs, err := NewAdditionalLogs(primaryURL, rekorURLs)
+	if err != nil {
+		return nil, err
+	}
+	clients := make(map[string]*client.Rekor, len(logs))
+	for _, l := range logs {
+		clients[l.URL] = l.Client
+	}
+	return clients, nil
+}
+
+// UploadToAdditionalLogs uploads an entry to each of the additional Rekor
+// instances, see AdditionalURLs.
+func UploadToAdditionalLogs(ctx context.Context, primaryURL string, rekorURLs []string, upload func(*client.Rekor) (*models.LogEntryAnon, error)) error {
+	logs, err := NewAdditionalLogs(primaryURL, rekorURLs)
+	if err != nil {
+		return err
+	}
+	return irekor.UploadToAdditionalLogs(ctx, logs, upload)
+}
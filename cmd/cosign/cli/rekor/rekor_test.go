@@ -17,6 +17,7 @@ package rekor
 import (
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
@@ -50,3 +51,16 @@ func TestNewClient(t *testing.T) {
 		t.Fatal("no requests were received")
 	}
 }
+
+func TestAdditionalURLs(t *testing.T) {
+	got := AdditionalURLs("https://a.example.com", []string{
+		"https://b.example.com",
+		"https://a.example.com",
+		"https://c.example.com",
+		"https://b.example.com",
+	})
+	want := []string{"https://b.example.com", "https://c.example.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("AdditionalURLs() = %v, wanted %v", got, want)
+	}
+}
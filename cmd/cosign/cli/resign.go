@@ -0,0 +1,78 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign"
+)
+
+func Resign() *cobra.Command {
+	o := &options.ResignOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "resign",
+		Short: "Sign container images again, renewing their existing signatures.",
+		Long: `Sign container images again, renewing their existing signatures.
+
+The existing signatures of each image are verified, and the image is signed
+again with a new certificate, transparency log entry and timestamp for each
+of them, keeping the claimed identity and annotations of their payloads. This
+keeps long-lived images verifiable under policies their older signatures,
+certificates or timestamps have aged out of.
+`,
+		Example: `  cosign resign [--verify-key <key path>|<kms uri>] --key <key path>|<kms uri> <image digest uri>
+
+  # renew the signatures made with a key, signing with the same key
+  cosign resign --verify-key cosign.pub --key cosign.key <IMAGE DIGEST>
+
+  # renew keyless signatures with the Sigstore OIDC flow
+  cosign resign --certificate-identity ci@example.com --certificate-oidc-issuer https://accounts.example.com <IMAGE DIGEST>
+
+  # renew the signatures of a key being retired with a new key and a fresh timestamp
+  cosign resign --verify-key old.pub --key new.key --timestamp-server-url https://freetsa.org/tsr <IMAGE DIGEST>
+
+  # renew the signatures of every image listed in a file, adding an annotation
+  cosign resign --verify-key cosign.pub --key cosign.key -a renewed=2026 --images-file images.txt`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			// The images can also come from --images-file.
+			if o.ImagesFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		PersistentPreRun: options.BindViper,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if o.Attachment != "" {
+				return fmt.Errorf("--attachment is not supported by resign")
+			}
+			ko, err := signKeyOpts(&o.SignOptions)
+			if err != nil {
+				return err
+			}
+			if err := sign.ResignCmd(ro, ko, *o, args); err != nil {
+				return fmt.Errorf("re-signing %v: %w", args, err)
+			}
+			return nil
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
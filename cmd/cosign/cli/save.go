@@ -19,11 +19,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"runtime"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/layout"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	"github.com/franchb/sigstore-go/pkg/root"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/spf13/cobra"
 )
@@ -32,10 +39,17 @@ func Save() *cobra.Command {
 	o := &options.SaveOptions{}
 
 	cmd := &cobra.Command{
-		Use:              "save",
-		Short:            "Save the container image and associated signatures to disk at the specified directory.",
-		Long:             "Save the container image and associated signatures to disk at the specified directory.",
-		Example:          `  cosign save --dir <path to directory> <IMAGE>`,
+		Use:   "save",
+		Short: "Save the container image and associated signatures to disk at the specified directory.",
+		Long:  "Save the container image and associated signatures to disk at the specified directory.",
+		Example: `  cosign save --dir <path to directory> <IMAGE>
+
+  # save the image, its signatures, attestations and trust material as a single
+  # file for transfer to an air-gapped environment
+  cosign save --format bundle-tar --key cosign.pub --dir <path to file> <IMAGE>
+
+  # save a multi-platform image and its signatures, 16 artifacts at a time
+  cosign save --jobs 16 --dir <path to directory> <IMAGE>`,
 		Args:             cobra.ExactArgs(1),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -48,6 +62,62 @@ func Save() *cobra.Command {
 }
 
 func SaveCmd(ctx context.Context, opts options.SaveOptions, imageRef string) error {
+	if opts.Format == options.SaveFormatBundleTar {
+		return saveBundleTar(ctx, opts, imageRef)
+	}
+	if opts.Key != "" {
+		return errors.New("--key can only be used with --format bundle-tar")
+	}
+	return saveLayout(ctx, opts, imageRef)
+}
+
+// saveBundleTar writes the image layout saved by saveLayout, along with the
+// public key at opts.Key, to the tar file at opts.Directory.
+func saveBundleTar(ctx context.Context, opts options.SaveOptions, imageRef string) error {
+	dir, err := os.MkdirTemp("", "cosign-save-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	layoutOpts := opts
+	layoutOpts.Directory = dir
+	if err := saveLayout(ctx, layoutOpts, imageRef); err != nil {
+		return err
+	}
+
+	if opts.Key != "" {
+		verifier, err := sigs.PublicKeyFromKeyRef(ctx, opts.Key)
+		if err != nil {
+			return fmt.Errorf("loading public key: %w", err)
+		}
+		pub, err := verifier.PublicKey()
+		if err != nil {
+			return fmt.Errorf("getting public key: %w", err)
+		}
+		pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+		if err != nil {
+			return fmt.Errorf("marshaling public key: %w", err)
+		}
+		if err := layout.WritePublicKey(dir, pemBytes); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(opts.Directory)
+	if err != nil {
+		return err
+	}
+	if err := layout.WriteTar(dir, f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", opts.Directory, err)
+	}
+	return f.Close()
+}
+
+// saveLayout saves the image and its signatures and attestations as an OCI
+// image layout in the directory opts.Directory.
+func saveLayout(ctx context.Context, opts options.SaveOptions, imageRef string) error {
 	regOpts := opts.Registry
 	regClientOpts, err := regOpts.ClientOpts(ctx)
 	if err != nil {
@@ -64,12 +134,20 @@ func SaveCmd(ctx context.Context, opts options.SaveOptions, imageRef string) err
 		return fmt.Errorf("signed entity: %w", err)
 	}
 
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
 	if _, ok := se.(oci.SignedImage); ok {
 		si, err := ociremote.SignedImage(ref, regClientOpts...)
 		if err != nil {
 			return fmt.Errorf("getting signed image: %w", err)
 		}
-		return layout.WriteSignedImage(opts.Directory, si)
+		if err := layout.WriteSignedImage(opts.Directory, si, layout.WithJobs(jobs)); err != nil {
+			return err
+		}
+		return saveTrustedRoot(ctx, opts)
 	}
 
 	if _, ok := se.(oci.SignedImageIndex); ok {
@@ -77,7 +155,41 @@ func SaveCmd(ctx context.Context, opts options.SaveOptions, imageRef string) err
 		if err != nil {
 			return fmt.Errorf("getting signed image index: %w", err)
 		}
-		return layout.WriteSignedImageIndex(opts.Directory, sii)
+		if err := layout.WriteSignedImageIndex(opts.Directory, sii, layout.WithJobs(jobs)); err != nil {
+			return err
+		}
+		return saveTrustedRoot(ctx, opts)
 	}
 	return errors.New("unknown signed entity")
 }
+
+// saveTrustedRoot stores the trusted root in the saved layout, so keyless
+// signatures can be verified from it without network access.
+func saveTrustedRoot(ctx context.Context, opts options.SaveOptions) error {
+	if opts.TrustedRootPath != "" {
+		// The file is stored as is, to keep the checkpoint witnesses it may
+		// configure next to the trusted root schema.
+		b, err := os.ReadFile(opts.TrustedRootPath)
+		if err != nil {
+			return fmt.Errorf("loading trusted root: %w", err)
+		}
+		rootJSON, _, err := cosign.SplitTrustedRootWitnesses(b)
+		if err != nil {
+			return fmt.Errorf("loading trusted root: %w", err)
+		}
+		if _, err := root.NewTrustedRootFromJSON(rootJSON); err != nil {
+			return fmt.Errorf("loading trusted root: %w", err)
+		}
+		return layout.WriteTrustedRoot(opts.Directory, b)
+	}
+	trustedRoot, err := root.FetchTrustedRoot()
+	if err != nil {
+		ui.Warnf(ctx, "unable to fetch the trusted root, verifying the saved image will need network access: %v", err)
+		return nil
+	}
+	b, err := trustedRoot.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshaling trusted root: %w", err)
+	}
+	return layout.WriteTrustedRoot(opts.Directory, b)
+}
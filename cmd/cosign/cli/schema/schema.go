@@ -0,0 +1,88 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema holds the JSON Schemas of the machine-readable outputs of
+// cosign commands. Changing an output's shape must go with a change to its
+// schema, which the tests of each command enforce.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+)
+
+// Names of the schemas, one per output.
+const (
+	Verify              = "verify"
+	Tree                = "tree"
+	Triangulate         = "triangulate"
+	DownloadSignature   = "download-signature"
+	DownloadAttestation = "download-attestation"
+	CheckDrift          = "check-drift"
+)
+
+const suffix = ".schema.json"
+
+//go:embed *.schema.json
+var schemas embed.FS
+
+// Names returns the names of all schemas, sorted.
+func Names() []string {
+	entries, _ := schemas.ReadDir(".")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), suffix))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the schema called name.
+func Get(name string) ([]byte, error) {
+	b, err := schemas.ReadFile(name + suffix)
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %q output", name)
+	}
+	return b, nil
+}
+
+// Validate checks the JSON document doc against the schema called name.
+func Validate(name string, doc []byte) error {
+	b, err := Get(name)
+	if err != nil {
+		return err
+	}
+	s := &spec.Schema{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return fmt.Errorf("parsing %s schema: %w", name, err)
+	}
+	if err := spec.ExpandSchema(s, s, nil); err != nil {
+		return fmt.Errorf("expanding %s schema: %w", name, err)
+	}
+	var data interface{}
+	if err := json.Unmarshal(doc, &data); err != nil {
+		return fmt.Errorf("parsing %s output: %w", name, err)
+	}
+	if err := validate.AgainstSchema(s, data, strfmt.Default); err != nil {
+		return fmt.Errorf("%s output does not match its schema: %w", name, err)
+	}
+	return nil
+}
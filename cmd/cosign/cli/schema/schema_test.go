@@ -0,0 +1,112 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	got := Names()
+	for _, want := range []string{Verify, Tree, Triangulate, DownloadSignature, DownloadAttestation, CheckDrift} {
+		found := false
+		for _, n := range got {
+			found = found || n == want
+		}
+		if !found {
+			t.Errorf("Names() = %v, missing %q", got, want)
+		}
+	}
+	if _, err := Get("nope"); err == nil {
+		t.Error("Get() succeeded for an unknown schema")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		doc     string
+		wantErr bool
+	}{{
+		name:   "triangulate",
+		schema: Triangulate,
+		doc:    `{"type": "signature", "reference": "example.com/app:sha256-abc.sig"}`,
+	}, {
+		name:   "triangulate referrers",
+		schema: Triangulate,
+		doc:    `{"type": "application/spdx+json", "reference": "example.com/app@sha256:abc", "referrers": [{"reference": "example.com/app@sha256:def", "artifactType": "application/spdx+json"}]}`,
+	}, {
+		name:    "triangulate with unknown type",
+		schema:  Triangulate,
+		doc:     `{"type": "nope", "reference": "example.com/app:sha256-abc.sig"}`,
+		wantErr: true,
+	}, {
+		name:   "tree",
+		schema: Tree,
+		doc:    `{"image": "example.com/app:latest", "artifacts": [{"type": "signature", "tag": "example.com/app:sha256-abc.sig", "layers": ["sha256:abc"]}]}`,
+	}, {
+		name:   "tree referrer",
+		schema: Tree,
+		doc:    `{"image": "example.com/app:latest", "artifacts": [{"type": "referrer", "artifactType": "application/spdx+json", "tag": "example.com/app@sha256:def", "layers": ["sha256:abc"]}]}`,
+	}, {
+		name:    "tree missing artifacts",
+		schema:  Tree,
+		doc:     `{"image": "example.com/app:latest"}`,
+		wantErr: true,
+	}, {
+		name:   "verify with bundle",
+		schema: Verify,
+		doc: `[{"critical": {"identity": {"docker-reference": "example.com/app"}, "image": {"docker-manifest-digest": "sha256:abc"}, "type": "cosign container image signature"},
+			"optional": {"Bundle": {"SignedEntryTimestamp": "MEUC", "Payload": {"body": "e30=", "integratedTime": 1700000000, "logIndex": 1, "logID": "c0d2"}}}}]`,
+	}, {
+		name:    "verify not an array",
+		schema:  Verify,
+		doc:     `{}`,
+		wantErr: true,
+	}, {
+		name:   "download signature",
+		schema: DownloadSignature,
+		doc:    `{"Base64Signature": "MEUC", "Payload": "e30=", "Cert": null, "Chain": null, "Bundle": null, "RFC3161Timestamp": null}`,
+	}, {
+		name:   "download attestation",
+		schema: DownloadAttestation,
+		doc:    `{"payloadType": "application/vnd.in-toto+json", "payload": "e30=", "signatures": [{"keyid": "", "sig": "MEUC"}]}`,
+	}, {
+		name:   "check-drift",
+		schema: CheckDrift,
+		doc: `{"repository": "example.com/app", "signatureRepository": "example.com/sigs",
+			"orphaned": [{"tag": "example.com/sigs:sha256-abc.sig", "type": "signature", "subject": "sha256:abc"}],
+			"unsigned": [{"digest": "sha256:def", "tags": ["latest"]}], "misplaced": []}`,
+	}, {
+		name:    "check-drift missing unsigned",
+		schema:  CheckDrift,
+		doc:     `{"repository": "example.com/app", "signatureRepository": "example.com/sigs", "orphaned": [], "misplaced": []}`,
+		wantErr: true,
+	}, {
+		name:    "not json",
+		schema:  Verify,
+		doc:     `nope`,
+		wantErr: true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.schema, []byte(tc.doc))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() = %v, wanted error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/verify"
+	"github.com/franchb/cosign/v2/internal/ui"
+)
+
+func Search() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Provides utilities for searching the signed artifacts of a repository",
+	}
+
+	cmd.AddCommand(
+		searchAttestations(),
+	)
+
+	return cmd
+}
+
+func searchAttestations() *cobra.Command {
+	o := &options.SearchAttestationsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "attestations",
+		Short: "List the images of a repository with a verified attestation whose predicate matches a query",
+		Long: `List the images of a repository with a verified attestation whose predicate matches a query.
+
+Every image and image index a tag of the repository points to is visited, its
+attestations of the --type predicate type are verified, and the image is listed
+if the predicate of any of them matches the --query.
+
+Queries are written in a subset of jq: a path such as .packages[].name,
+optionally compared with a JSON value using ==, !=, <, <=, > or >=, or tested
+with startswith, endswith, contains or test (a regular expression). Queries
+can be combined with and, or and parentheses, and a path followed by | and a
+query applies the query to each value the path selects. A comparison matches
+if any value the path selects satisfies it.`,
+		Example: `  cosign search attestations --key cosign.pub --type spdxjson --query '.packages[].name == "openssl"' <REPOSITORY>
+
+  # which images ship openssl 3.0.x
+  cosign search attestations --key cosign.pub --type spdxjson --query '.packages[] | .name == "openssl" and (.versionInfo | startswith("3.0."))' <REPOSITORY>
+
+  # search attestations signed by an identity
+  cosign search attestations --certificate-identity=name@example.com --certificate-oidc-issuer=https://accounts.example.com --type spdxjson --query '.packages[].name == "openssl"' <REPOSITORY>
+
+  # list the matching images as JSON
+  cosign search attestations --key cosign.pub --type spdxjson --query '.packages[].name == "openssl"' --output json <REPOSITORY>`,
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := &verify.SearchAttestationsCommand{
+				AuditCommand: verify.AuditCommand{
+					RegistryOptions:     o.Registry,
+					CertVerifyOptions:   o.CertVerify,
+					KeyRef:              o.Key,
+					Sk:                  o.SecurityKey.Use,
+					Slot:                o.SecurityKey.Slot,
+					Output:              o.Output,
+					RekorURL:            o.Rekor.URL,
+					Offline:             o.CommonVerifyOptions.Offline,
+					TSACertChainPath:    o.CommonVerifyOptions.TSACertChainPath,
+					UseSignedTimestamps: o.CommonVerifyOptions.UseSignedTimestamps,
+					IgnoreTlog:          o.CommonVerifyOptions.IgnoreTlog,
+				},
+				PredicateType: o.Predicate.Type,
+				Query:         o.Query,
+			}
+			if o.Registry.AllowInsecure {
+				s.NameOptions = append(s.NameOptions, name.Insecure)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), ro.Timeout)
+			defer cancel()
+
+			if o.CommonVerifyOptions.IgnoreTlog && !o.CommonVerifyOptions.PrivateInfrastructure {
+				ui.Warnf(ctx, fmt.Sprintf(ignoreTLogMessage, "attestation"))
+			}
+
+			return s.Exec(ctx, args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/self"
+	"github.com/spf13/cobra"
+)
+
+func Self() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "self",
+		Short: "Provides utilities for verifying and updating cosign against its release signatures",
+	}
+
+	cmd.AddCommand(
+		selfVerify(),
+		selfUpdate(),
+	)
+
+	return cmd
+}
+
+func selfVerify() *cobra.Command {
+	o := &options.SelfOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the running cosign binary against the signature published with its release",
+		Long: `Verify the running cosign binary against the keyless signature published with its release.
+
+The signature and certificate are downloaded from the release, and the certificate must have been
+issued to the identity cosign releases are signed by. Use --release-url to read them from a mirror
+of the GitHub releases page.`,
+		Example: `  cosign self verify
+
+  # verify against a mirror of the releases page
+  cosign self verify --release-url https://mirror.example.com/sigstore/cosign/releases`,
+		Args:             cobra.NoArgs,
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := self.Executable()
+			if err != nil {
+				return err
+			}
+			v := &self.VerifyCmd{
+				KeyOpts: options.KeyOpts{
+					RekorURL:            o.Rekor.URL,
+					AdditionalRekorURLs: o.Rekor.AdditionalURLs,
+				},
+				ReleaseURL:     o.ReleaseURL,
+				CertIdentity:   o.CertIdentity,
+				CertOidcIssuer: o.CertOidcIssuer,
+			}
+			return v.Exec(cmd.Context(), path)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func selfUpdate() *cobra.Command {
+	o := &options.SelfUpdateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Replace the running cosign binary with a verified release",
+		Long: `Replace the running cosign binary with another release.
+
+The release binary for this platform is downloaded next to the running one, verified against the
+keyless signature published with it, and renamed over the running binary, so that it is either
+fully replaced or left untouched.`,
+		Example: `  cosign self update --verify
+
+  # install a specific release
+  cosign self update --verify --version v2.4.1`,
+		Args:             cobra.NoArgs,
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := self.Executable()
+			if err != nil {
+				return err
+			}
+			u := &self.UpdateCmd{
+				VerifyCmd: self.VerifyCmd{
+					KeyOpts: options.KeyOpts{
+						RekorURL:            o.Rekor.URL,
+						AdditionalRekorURLs: o.Rekor.AdditionalURLs,
+					},
+					ReleaseURL:     o.ReleaseURL,
+					CertIdentity:   o.CertIdentity,
+					CertOidcIssuer: o.CertOidcIssuer,
+				},
+				Version: o.Version,
+				Verify:  o.Verify,
+			}
+			return u.Exec(cmd.Context(), path)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package self verifies the running cosign binary against the signature
+// published with its release, and replaces it with another release.
+package self
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/verify"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"golang.org/x/mod/semver"
+	"sigs.k8s.io/release-utils/version"
+)
+
+// AssetName returns the name cosign release binaries for goos and goarch
+// are published under.
+func AssetName(goos, goarch string) string {
+	name := "cosign-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Executable returns the path of the running binary, with symlinks resolved.
+func Executable() (string, error) {
+	p, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(p)
+}
+
+// VerifyCmd verifies a cosign binary against the keyless signature published
+// with its release.
+type VerifyCmd struct {
+	options.KeyOpts
+	ReleaseURL     string
+	CertIdentity   string
+	CertOidcIssuer string
+}
+
+// Exec verifies the binary at path against the release of the running
+// cosign, which must be a release build.
+func (c *VerifyCmd) Exec(ctx context.Context, path string) error {
+	v := version.GetVersionInfo().GitVersion
+	if !semver.IsValid(v) {
+		return fmt.Errorf("cosign %s is not a release build", v)
+	}
+	if err := c.verifyRelease(ctx, v, path); err != nil {
+		return err
+	}
+	ui.Infof(ctx, "%s is cosign %s as released", path, v)
+	return nil
+}
+
+// verifyRelease verifies the binary at path against the signature published
+// for this platform's binary in release v.
+func (c *VerifyCmd) verifyRelease(ctx context.Context, v, path string) error {
+	asset := c.assetURL(v)
+	cmd := &verify.VerifyBlobCmd{
+		KeyOpts: c.KeyOpts,
+		CertVerifyOptions: options.CertVerifyOptions{
+			CertIdentity:   c.CertIdentity,
+			CertOidcIssuer: c.CertOidcIssuer,
+		},
+		CertRef: asset + "-keyless.pem",
+		SigRef:  asset + "-keyless.sig",
+	}
+	if err := cmd.Exec(ctx, path); err != nil {
+		return fmt.Errorf("verifying %s against cosign %s: %w", path, v, err)
+	}
+	return nil
+}
+
+// assetURL returns the URL of this platform's binary in release v.
+func (c *VerifyCmd) assetURL(v string) string {
+	return strings.TrimSuffix(c.ReleaseURL, "/") + "/download/" + v + "/" + AssetName(runtime.GOOS, runtime.GOARCH)
+}
+
+// UpdateCmd replaces a cosign binary with another release.
+type UpdateCmd struct {
+	VerifyCmd
+	// Version is the release to install, or the latest release if empty.
+	Version string
+	// Verify is whether the downloaded binary is verified before it is
+	// installed.
+	Verify bool
+}
+
+// Exec replaces the binary at path with the configured release. The new
+// binary is downloaded next to path, verified, and renamed over it, so path
+// is never left partially written.
+func (c *UpdateCmd) Exec(ctx context.Context, path string) error {
+	v := c.Version
+	if v == "" {
+		var err error
+		if v, err = c.latestVersion(ctx); err != nil {
+			return err
+		}
+	}
+	if v == version.GetVersionInfo().GitVersion {
+		ui.Infof(ctx, "cosign is already at %s", v)
+		return nil
+	}
+
+	tmp, err := c.download(ctx, v, filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	if c.Verify {
+		if err := c.verifyRelease(ctx, v, tmp); err != nil {
+			return err
+		}
+	} else {
+		ui.Warnf(ctx, "installing cosign %s without verifying its signature", v)
+	}
+	if err := replace(tmp, path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	ui.Infof(ctx, "updated %s to cosign %s", path, v)
+	return nil
+}
+
+// latestVersion returns the tag the latest release redirects to.
+func (c *UpdateCmd) latestVersion(ctx context.Context) (string, error) {
+	resp, err := get(ctx, strings.TrimSuffix(c.ReleaseURL, "/")+"/latest")
+	if err != nil {
+		return "", fmt.Errorf("resolving the latest release: %w", err)
+	}
+	resp.Body.Close()
+	v := path.Base(resp.Request.URL.Path)
+	if !semver.IsValid(v) {
+		return "", fmt.Errorf("resolving the latest release: %s is not a release tag", resp.Request.URL)
+	}
+	return v, nil
+}
+
+// download writes this platform's binary in release v to a new file in dir,
+// returning its name.
+func (c *UpdateCmd) download(ctx context.Context, v, dir string) (string, error) {
+	url := c.assetURL(v)
+	resp, err := get(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("downloading cosign %s: %w", v, err)
+	}
+	defer resp.Body.Close()
+
+	f, err := os.CreateTemp(dir, ".cosign-"+v+"-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// get fetches url, failing unless it is served successfully.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// replace renames src over dst, giving it the permissions of dst. A running
+// executable cannot be overwritten on Windows, so dst is moved aside first.
+func replace(src, dst string) error {
+	fi, err := os.Stat(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(src, fi.Mode().Perm()); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := dst + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(dst, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(src, dst)
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package self
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestAssetName(t *testing.T) {
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", "cosign-linux-amd64"},
+		{"darwin", "arm64", "cosign-darwin-arm64"},
+		{"windows", "amd64", "cosign-windows-amd64.exe"},
+	}
+	for _, tc := range tests {
+		if got := AssetName(tc.goos, tc.goarch); got != tc.want {
+			t.Errorf("AssetName(%q, %q) = %q, wanted %q", tc.goos, tc.goarch, got, tc.want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/releases/tag/v9.9.9", http.StatusFound)
+	})
+	mux.HandleFunc("/releases/tag/v9.9.9", func(_ http.ResponseWriter, _ *http.Request) {})
+	mux.HandleFunc("/releases/download/v9.9.9/"+AssetName(runtime.GOOS, runtime.GOARCH), func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("new cosign"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "cosign")
+	if err := os.WriteFile(bin, []byte("old cosign"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A release that does not exist leaves the binary alone.
+	c := &UpdateCmd{
+		VerifyCmd: VerifyCmd{ReleaseURL: s.URL + "/releases/"},
+		Version:   "v0.0.1",
+	}
+	if err := c.Exec(context.Background(), bin); err == nil {
+		t.Error("Exec() succeeded for a missing release")
+	}
+	if b, _ := os.ReadFile(bin); string(b) != "old cosign" {
+		t.Errorf("binary = %q after a failed update", b)
+	}
+
+	c.Version = ""
+	if err := c.Exec(context.Background(), bin); err != nil {
+		t.Fatalf("Exec() = %v", err)
+	}
+	if b, _ := os.ReadFile(bin); string(b) != "new cosign" {
+		t.Errorf("binary = %q, wanted the latest release", b)
+	}
+	if runtime.GOOS == "windows" {
+		// The replaced binary is kept aside on Windows.
+		return
+	}
+	if fi, err := os.Stat(bin); err != nil || fi.Mode().Perm() != 0o755 {
+		t.Errorf("Stat() = %v, %v, wanted mode 0755", fi, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("update left %d files behind, wanted only the binary", len(entries)-1)
+	}
+}
@@ -43,12 +43,22 @@ race conditions or (worse) malicious tampering.
   # sign a container image with the Sigstore OIDC flow
   cosign sign <IMAGE DIGEST>
 
+  # sign a container image in a SPIRE-managed workload with a Fulcio certificate for its
+  # SPIFFE ID, exchanging a JWT-SVID from the Workload API for the certificate
+  SPIFFE_ENDPOINT_SOCKET=unix:///run/spire/sockets/agent.sock cosign sign --oidc-provider spiffe <IMAGE DIGEST>
+
   # sign a container image with a local key pair file
   cosign sign --key cosign.key <IMAGE DIGEST>
 
   # sign a multi-arch container image AND all referenced, discrete images
   cosign sign --key cosign.key --recursive <MULTI-ARCH IMAGE DIGEST>
 
+  # sign every image listed in a file, reporting the digests signed for each as JSON
+  cosign sign --key cosign.key --recursive --images-file images.txt --output-results results.json
+
+  # sign the images listed on stdin
+  crane ls [REPOSITORY] --full-ref | cosign sign --key cosign.key --images-file -
+
   # sign a container image and add annotations
   cosign sign --key cosign.key -a key1=value1 -a key2=value2 <IMAGE DIGEST>
 
@@ -58,21 +68,61 @@ race conditions or (worse) malicious tampering.
   # sign a container image with a key pair stored in Azure Key Vault
   cosign sign --key azurekms://[VAULT_NAME][VAULT_URI]/[KEY] <IMAGE DIGEST>
 
+  # sign a container image with a key pair stored in an Azure Key Vault Managed HSM
+  cosign sign --key azurekms://[HSM_NAME].managedhsm.azure.net/[KEY] <IMAGE DIGEST>
+
   # sign a container image with a key pair stored in AWS KMS
   cosign sign --key awskms://[ENDPOINT]/[ID/ALIAS/ARN] <IMAGE DIGEST>
 
   # sign a container image with a key pair stored in Google Cloud KMS
   cosign sign --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY]/versions/[VERSION] <IMAGE DIGEST>
 
+  # sign a container image with a key pair stored in KMS and a Fulcio certificate binding the key to your identity
+  cosign sign --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY]/versions/[VERSION] --issue-certificate <IMAGE DIGEST>
+
+  # sign a container image keyless, with a key generated in a hardware security key and a Fulcio certificate for it
+  cosign sign --keyless --sk <IMAGE DIGEST>
+
+  # sign a container image with an ephemeral key and a certificate issued by a step-ca server instead of Fulcio
+  COSIGN_STEP_CA_ROOT=root_ca.crt cosign sign --certificate-provider step-ca://ca.example.com:9000 --identity-token <TOKEN> <IMAGE DIGEST>
+
+  # sign a container image with an ephemeral key and a certificate issued by a role of a Vault PKI secrets engine
+  cosign sign --certificate-provider vault-pki://pki_int/cosign <IMAGE DIGEST>
+
+  # sign a container image with a key pair stored in Oracle Cloud Infrastructure Vault
+  cosign sign --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <IMAGE DIGEST>
+
+  # sign a container image with a key pair stored in Alibaba Cloud KMS
+  cosign sign --key alibabakms://[REGION]/[KEY_ID] <IMAGE DIGEST>
+
+  # sign a container image with a key pair stored in Tencent Cloud KMS
+  cosign sign --key tencentkms://[REGION]/[KEY_ID] <IMAGE DIGEST>
+
   # sign a container image with a key pair stored in Hashicorp Vault
   cosign sign --key hashivault://[KEY] <IMAGE DIGEST>
 
+  # sign a container image with version [VERSION] of a key pair stored in a Hashicorp Vault Enterprise namespace,
+  # logging in to Vault with the Kubernetes service account of the pod
+  VAULT_AUTH_METHOD=kubernetes VAULT_ROLE=[ROLE] cosign sign --key hashivault://[NAMESPACE]/[KEY]/versions/[VERSION] <IMAGE DIGEST>
+
+  # sign a container image with a key held by the signer plugin cosign-signer-[NAME] in PATH
+  cosign sign --key plugin://[NAME]/[KEY] <IMAGE DIGEST>
+
   # sign a container image with a key pair stored in a Kubernetes secret
   cosign sign --key k8s://[NAMESPACE]/[KEY] <IMAGE DIGEST>
 
   # sign a container image with a key, attaching a certificate and certificate chain
   cosign sign --key cosign.key --cert cosign.crt --cert-chain chain.crt <IMAGE DIGEST>
 
+  # sign a container image, writing a Sigstore bundle that other Sigstore clients can verify
+  cosign sign --key cosign.key --bundle cosign.sigstore.json --bundle-format sigstore <IMAGE DIGEST>
+
+  # push a WebAssembly module as an OCI artifact that wasm runtimes pull, and sign it
+  cosign sign --key cosign.key --type wasm --wasm-module hello.wasm <IMAGE URI>
+
+  # sign a container image, storing timestamps from two timestamp authorities
+  cosign sign --key cosign.key --timestamp-server-url https://tsa1.example.com/tsr --timestamp-server-url https://tsa2.example.com/tsr <IMAGE DIGEST>
+
   # sign a container in a registry which does not fully support OCI media types
   COSIGN_DOCKER_MEDIA_TYPES=1 cosign sign --key cosign.key legacy-registry.example.com/my/image@<DIGEST>
 
@@ -86,9 +136,24 @@ race conditions or (worse) malicious tampering.
   cosign sign --sign-container-identity <NEW IMAGE DIGEST> <IMAGE DIGEST>
 
   # sign a container image and honor the creation timestamp of the signature
-  cosign sign --key cosign.key --record-creation-timestamp <IMAGE DIGEST>`,
+  cosign sign --key cosign.key --record-creation-timestamp <IMAGE DIGEST>
+
+  # sign a container image reproducibly, so that signing it again with the same key gives the same signature
+  cosign sign --key cosign.key --deterministic --tlog-upload=false <IMAGE DIGEST>
 
-		Args:             cobra.MinimumNArgs(1),
+  # sign a container image unless it already carries a signature of it by this key
+  cosign sign --key cosign.key --no-duplicate <IMAGE DIGEST>
+
+  # sign a container image only if it passes a policy over its attestations verified with a scanner's key
+  cosign sign --key cosign.key --policy require-scan.rego --policy-attestation-key scanner.pub <IMAGE DIGEST>`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			// The images can also come from --images-file.
+			if o.ImagesFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		PersistentPreRun: options.BindViper,
 		RunE: func(_ *cobra.Command, args []string) error {
 			switch o.Attachment {
@@ -99,34 +164,10 @@ race conditions or (worse) malicious tampering.
 			default:
 				return fmt.Errorf("specified image attachment %s not specified. Can be 'sbom'", o.Attachment)
 			}
-			oidcClientSecret, err := o.OIDC.ClientSecret()
+			ko, err := signKeyOpts(o)
 			if err != nil {
 				return err
 			}
-			ko := options.KeyOpts{
-				KeyRef:                         o.Key,
-				PassFunc:                       generate.GetPass,
-				Sk:                             o.SecurityKey.Use,
-				Slot:                           o.SecurityKey.Slot,
-				FulcioURL:                      o.Fulcio.URL,
-				IDToken:                        o.Fulcio.IdentityToken,
-				FulcioAuthFlow:                 o.Fulcio.AuthFlow,
-				InsecureSkipFulcioVerify:       o.Fulcio.InsecureSkipFulcioVerify,
-				RekorURL:                       o.Rekor.URL,
-				OIDCIssuer:                     o.OIDC.Issuer,
-				OIDCClientID:                   o.OIDC.ClientID,
-				OIDCClientSecret:               oidcClientSecret,
-				OIDCRedirectURL:                o.OIDC.RedirectURL,
-				OIDCDisableProviders:           o.OIDC.DisableAmbientProviders,
-				OIDCProvider:                   o.OIDC.Provider,
-				SkipConfirmation:               o.SkipConfirmation,
-				TSAClientCACert:                o.TSAClientCACert,
-				TSAClientCert:                  o.TSAClientCert,
-				TSAClientKey:                   o.TSAClientKey,
-				TSAServerName:                  o.TSAServerName,
-				TSAServerURL:                   o.TSAServerURL,
-				IssueCertificateForExistingKey: o.IssueCertificate,
-			}
 			if err := sign.SignCmd(ro, ko, *o, args); err != nil {
 				if o.Attachment == "" {
 					return fmt.Errorf("signing %v: %w", args, err)
@@ -139,3 +180,44 @@ race conditions or (worse) malicious tampering.
 	o.AddFlags(cmd)
 	return cmd
 }
+
+// signKeyOpts returns the KeyOpts of the signing options o.
+func signKeyOpts(o *options.SignOptions) (options.KeyOpts, error) {
+	oidcClientSecret, err := o.OIDC.ClientSecret()
+	if err != nil {
+		return options.KeyOpts{}, err
+	}
+	tsaServerURL, additionalTSAServerURLs := options.SplitTSAServerURLs(o.TSAServerURLs)
+	ko := options.KeyOpts{
+		KeyRef:                         o.Key,
+		PassFunc:                       generate.GetPass,
+		Sk:                             o.SecurityKey.Use,
+		Slot:                           o.SecurityKey.Slot,
+		FulcioURL:                      o.Fulcio.URL,
+		IDToken:                        o.Fulcio.IdentityToken,
+		FulcioAuthFlow:                 o.Fulcio.AuthFlow,
+		InsecureSkipFulcioVerify:       o.Fulcio.InsecureSkipFulcioVerify,
+		CertificateProvider:            o.Fulcio.CertificateProvider,
+		RekorURL:                       o.Rekor.URL,
+		AdditionalRekorURLs:            o.Rekor.AdditionalURLs,
+		OIDCIssuer:                     o.OIDC.Issuer,
+		OIDCClientID:                   o.OIDC.ClientID,
+		OIDCClientSecret:               oidcClientSecret,
+		OIDCRedirectURL:                o.OIDC.RedirectURL,
+		OIDCDisableProviders:           o.OIDC.DisableAmbientProviders,
+		OIDCProvider:                   o.OIDC.Provider,
+		SkipConfirmation:               o.SkipConfirmation,
+		TSAClientCACert:                o.TSAClientCACert,
+		TSAClientCert:                  o.TSAClientCert,
+		TSAClientKey:                   o.TSAClientKey,
+		TSAServerName:                  o.TSAServerName,
+		TSAServerURL:                   tsaServerURL,
+		AdditionalTSAServerURLs:        additionalTSAServerURLs,
+		IssueCertificateForExistingKey: o.IssueCertificate,
+		GenerateHardwareKey:            o.Keyless,
+		BundlePath:                     o.BundlePath,
+		NewBundleFormat:                o.BundleFormat == options.BundleFormatSigstore,
+		Deterministic:                  o.Deterministic,
+	}
+	return ko, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/franchb/rekor/pkg/generated/client"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
+	icos "github.com/franchb/cosign/v2/internal/pkg/cosign"
+	irekor "github.com/franchb/cosign/v2/internal/pkg/cosign/rekor"
+)
+
+// ReadImagesFile reads the image references listed in path, or stdin if
+// path is "-", one per line. Blank lines and lines starting with # are
+// skipped.
+func ReadImagesFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var imgs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		imgs = append(imgs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading images from %s: %w", path, err)
+	}
+	return imgs, nil
+}
+
+// SignResult is the outcome of signing one of the images given to SignCmd,
+// written to --output-results.
+type SignResult struct {
+	Image string `json:"image"`
+	// Digests are the digests signed for the image, which are several
+	// when signing recursively.
+	Digests []string `json:"digests,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// writeSignResults writes results as JSON to path, or stdout if path is "-".
+func writeSignResults(path string, results []SignResult) error {
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// tlogConnection connects to Rekor once, on first use, for all the images
+// signed by an invocation.
+type tlogConnection struct {
+	rekorURL       string
+	additionalURLs []string
+
+	client     *client.Rekor
+	additional []irekor.AdditionalLog
+}
+
+// signer wraps s to upload its signatures to the transparency logs.
+func (t *tlogConnection) signer(s icos.Signer) (icos.Signer, error) {
+	if t.client == nil {
+		rClient, err := rekor.NewClient(t.rekorURL)
+		if err != nil {
+			return nil, err
+		}
+		if len(t.additionalURLs) > 0 {
+			t.additional, err = rekor.NewAdditionalLogs(t.rekorURL, t.additionalURLs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		t.client = rClient
+	}
+	if len(t.additional) > 0 {
+		return irekor.NewSignerWithAdditionalLogs(s, t.client, t.additional), nil
+	}
+	return irekor.NewSigner(s, t.client), nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+)
+
+func TestReadImagesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "images.txt")
+	content := `# release images
+example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000001
+
+  example.com/sidecar:v1  
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	imgs, err := ReadImagesFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000001",
+		"example.com/sidecar:v1",
+	}, imgs)
+
+	_, err = ReadImagesFile(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
+
+// newTestRegistry starts an in-memory registry, returning its host.
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// pushRandomImage pushes a random image to repo at host.
+func pushRandomImage(t *testing.T, host, repo string) name.Digest {
+	t.Helper()
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(host + "/" + repo + ":latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	d, err := img.Digest()
+	require.NoError(t, err)
+	return ref.Context().Digest(d.String())
+}
+
+func TestSignCmdImagesFile(t *testing.T) {
+	host := newTestRegistry(t)
+	digests := []name.Digest{pushRandomImage(t, host, "app"), pushRandomImage(t, host, "sidecar")}
+
+	td := t.TempDir()
+	keys, err := cosign.GenerateKeyPair(pass("hunter2"))
+	require.NoError(t, err)
+	keyPath := filepath.Join(td, "cosign.key")
+	require.NoError(t, os.WriteFile(keyPath, keys.PrivateBytes, 0600))
+
+	missing := host + "/missing@sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	imagesPath := filepath.Join(td, "images.txt")
+	require.NoError(t, os.WriteFile(imagesPath, []byte(digests[1].String()+"\n"+missing+"\n"), 0600))
+	resultsPath := filepath.Join(td, "results.json")
+
+	ro := &options.RootOptions{Timeout: options.DefaultTimeout}
+	ko := options.KeyOpts{KeyRef: keyPath, PassFunc: pass("hunter2")}
+	so := options.SignOptions{
+		Upload:        true,
+		ImagesFile:    imagesPath,
+		OutputResults: resultsPath,
+	}
+	err = SignCmd(ro, ko, so, []string{digests[0].String(), "not a reference"})
+	require.ErrorContains(t, err, "failed to sign 1 of 4 images")
+
+	b, err := os.ReadFile(resultsPath)
+	require.NoError(t, err)
+	var results []SignResult
+	require.NoError(t, json.Unmarshal(b, &results))
+	require.Len(t, results, 4)
+	require.Equal(t, SignResult{Image: digests[0].String(), Digests: []string{digests[0].String()}}, results[0])
+	require.Equal(t, "not a reference", results[1].Image)
+	require.NotEmpty(t, results[1].Error)
+	require.Equal(t, SignResult{Image: digests[1].String(), Digests: []string{digests[1].String()}}, results[2])
+	// Signatures can be attached to digests the registry does not know.
+	require.Equal(t, SignResult{Image: missing, Digests: []string{missing}}, results[3])
+
+	for _, d := range digests {
+		se, err := ociremote.SignedImage(d)
+		require.NoError(t, err)
+		sigs, err := se.Signatures()
+		require.NoError(t, err)
+		got, err := sigs.Get()
+		require.NoError(t, err)
+		require.Len(t, got, 1, "signatures of %s", d)
+	}
+
+	// Without results, the first failure stops signing.
+	so.OutputResults = ""
+	err = SignCmd(ro, ko, so, []string{"not a reference"})
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "failed to sign")
+}
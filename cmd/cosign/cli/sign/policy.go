@@ -0,0 +1,115 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/policy"
+	sigs "github.com/franchb/cosign/v2/pkg/signature"
+)
+
+// signingPolicy refuses to sign images that do not pass the --policy files.
+type signingPolicy struct {
+	evaluators []policy.PolicyEvaluator
+	// co verifies the attestations given to the policies, and is nil if
+	// they are given none.
+	co *cosign.CheckOpts
+}
+
+// newSigningPolicy returns the signingPolicy of signOpts, or nil if it has
+// no policies.
+func newSigningPolicy(ctx context.Context, signOpts options.SignOptions) (*signingPolicy, error) {
+	if len(signOpts.Policies) == 0 {
+		if signOpts.PolicyAttestationKey != "" {
+			return nil, errors.New("--policy-attestation-key requires --policy")
+		}
+		return nil, nil
+	}
+
+	var cuePolicies, regoPolicies []string
+	for _, p := range signOpts.Policies {
+		switch filepath.Ext(p) {
+		case ".rego":
+			regoPolicies = append(regoPolicies, p)
+		case ".cue":
+			cuePolicies = append(cuePolicies, p)
+		default:
+			return nil, errors.New("invalid policy format, expected .cue or .rego")
+		}
+	}
+	sp := &signingPolicy{}
+	if len(cuePolicies) > 0 {
+		sp.evaluators = append(sp.evaluators, policy.NewCUEFileEvaluator(cuePolicies))
+	}
+	if len(regoPolicies) > 0 {
+		sp.evaluators = append(sp.evaluators, policy.NewRegoFileEvaluator(regoPolicies))
+	}
+
+	if signOpts.PolicyAttestationKey != "" {
+		verifier, err := sigs.PublicKeyFromKeyRef(ctx, signOpts.PolicyAttestationKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading policy attestation key: %w", err)
+		}
+		opts, err := signOpts.Registry.ClientOpts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("constructing client options: %w", err)
+		}
+		sp.co = &cosign.CheckOpts{
+			SigVerifier:        verifier,
+			ClaimVerifier:      cosign.IntotoSubjectClaimVerifier,
+			RegistryClientOpts: opts,
+			// The key is what attestations are trusted for, as the signer
+			// is not expected to look them up in a transparency log.
+			IgnoreTlog: true,
+		}
+	}
+	return sp, nil
+}
+
+// check fails if digest does not pass the policies.
+func (sp *signingPolicy) check(ctx context.Context, digest name.Digest) error {
+	if sp == nil {
+		return nil
+	}
+	var atts []oci.Signature
+	if sp.co != nil {
+		verified, _, err := cosign.VerifyImageAttestations(ctx, digest, sp.co)
+		var noMatch *cosign.ErrNoMatchingAttestations
+		// Whether the image must have attestations is up to the policies.
+		if err != nil && !errors.As(err, &noMatch) {
+			return fmt.Errorf("verifying attestations for the signing policy: %w", err)
+		}
+		atts = verified
+	}
+	in, err := policy.NewSigningInput(digest, atts)
+	if err != nil {
+		return err
+	}
+	warnings, err := policy.CheckSigningPolicy(ctx, in, sp.evaluators)
+	if warnings != nil {
+		ui.Warnf(ctx, "%v", warnings)
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+)
+
+func TestNewSigningPolicy(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name     string
+		signOpts options.SignOptions
+		wantErr  bool
+		wantNil  bool
+	}{{
+		name:    "no policy",
+		wantNil: true,
+	}, {
+		name:     "cue and rego",
+		signOpts: options.SignOptions{Policies: []string{"a.cue", "b.rego"}},
+	}, {
+		name:     "unknown format",
+		signOpts: options.SignOptions{Policies: []string{"a.json"}},
+		wantErr:  true,
+	}, {
+		name:     "attestation key without policy",
+		signOpts: options.SignOptions{PolicyAttestationKey: "cosign.pub"},
+		wantErr:  true,
+	}, {
+		name:     "missing attestation key",
+		signOpts: options.SignOptions{Policies: []string{"a.cue"}, PolicyAttestationKey: "does-not-exist.pub"},
+		wantErr:  true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sp, err := newSigningPolicy(ctx, tc.signOpts)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("newSigningPolicy() = %v, wanted error: %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && (sp == nil) != tc.wantNil {
+				t.Errorf("newSigningPolicy() = %v, wanted nil: %v", sp, tc.wantNil)
+			}
+		})
+	}
+}
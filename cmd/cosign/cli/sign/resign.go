@@ -0,0 +1,218 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/fulcio"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	sigPayload "github.com/franchb/sigstore/pkg/signature/payload"
+)
+
+// ResignCmd verifies the existing signatures of each of imgs, with the key or
+// certificate identity of resignOpts, and signs the image again once for
+// each distinct payload among them. The new payloads keep the claimed
+// identity and annotations of the verified ones, with the annotations of
+// resignOpts added, while their certificate, tlog entry and timestamp are
+// new, so images outliving those of their signatures stay verifiable.
+func ResignCmd(ro *options.RootOptions, ko options.KeyOpts, resignOpts options.ResignOptions, imgs []string) error {
+	signOpts := resignOpts.SignOptions
+	if options.NOf(ko.KeyRef, ko.Sk) > 1 {
+		return &options.KeyParseError{}
+	}
+	if signOpts.PayloadPath != "" {
+		return errors.New("--payload is not supported, the payloads of the existing signatures are signed again")
+	}
+	if signOpts.Recursive {
+		return errors.New("--recursive is not supported, each image to re-sign must be listed")
+	}
+	if signOpts.Type == options.SignTypeWasm || signOpts.WasmModule != "" {
+		return errors.New("--type wasm is not supported, the images to re-sign must already be in the registry")
+	}
+	if ko.Deterministic {
+		return errors.New("--deterministic is not supported, re-signing renews the signatures")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ro.Timeout)
+	defer cancel()
+
+	sp, err := newSigningPolicy(ctx, signOpts)
+	if err != nil {
+		return err
+	}
+	regOpts := signOpts.Registry
+	opts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("constructing client options: %w", err)
+	}
+	co, err := resignCheckOpts(ctx, ko, resignOpts, opts)
+	if err != nil {
+		return err
+	}
+	am, err := signOpts.AnnotationsMap()
+	if err != nil {
+		return fmt.Errorf("getting annotations: %w", err)
+	}
+
+	if signOpts.ImagesFile != "" {
+		listed, err := ReadImagesFile(signOpts.ImagesFile)
+		if err != nil {
+			return fmt.Errorf("reading images file: %w", err)
+		}
+		imgs = append(imgs, listed...)
+	}
+	if len(imgs) == 0 {
+		return errors.New("no images to re-sign")
+	}
+
+	sv, err := SignerFromKeyOpts(ctx, signOpts.Cert, signOpts.CertChain, ko)
+	if err != nil {
+		return fmt.Errorf("getting signer: %w", err)
+	}
+	defer sv.Close()
+	tlog := &tlogConnection{rekorURL: ko.RekorURL, additionalURLs: ko.AdditionalRekorURLs}
+
+	for _, inputImg := range imgs {
+		ref, err := ParseOCIReference(ctx, inputImg, regOpts.NameOptions()...)
+		if err != nil {
+			return err
+		}
+		digest, err := ociremote.ResolveDigest(ref, opts...)
+		if err != nil {
+			return err
+		}
+		if err := sp.check(ctx, digest); err != nil {
+			return err
+		}
+		verified, _, err := cosign.VerifyImageSignatures(ctx, digest, co)
+		if err != nil {
+			return fmt.Errorf("verifying existing signatures of %s: %w", digest, err)
+		}
+		payloads, err := resignPayloads(verified, am.Annotations)
+		if err != nil {
+			return fmt.Errorf("reading existing signatures of %s: %w", digest, err)
+		}
+		for _, p := range payloads {
+			// Each signature is attached to the entity as left by the
+			// previous one.
+			se, err := ociremote.SignedEntity(digest, opts...)
+			if err != nil {
+				return fmt.Errorf("accessing image: %w", err)
+			}
+			payloadOpts := signOpts
+			payloadOpts.SignContainerIdentity = p.identity
+			if err := signDigest(ctx, digest, nil, ko, payloadOpts, p.annotations, nil, sv, se, tlog); err != nil {
+				return fmt.Errorf("signing digest: %w", err)
+			}
+		}
+		ui.Infof(ctx, "Re-signed %d of %d verified signatures of %s", len(payloads), len(verified), digest)
+	}
+	return nil
+}
+
+// resignPayload is what a payload signed again keeps of a verified one.
+type resignPayload struct {
+	identity    string
+	annotations map[string]interface{}
+}
+
+// resignPayloads returns the distinct payloads to sign again for the verified
+// signatures sigs, adding extra to their annotations.
+func resignPayloads(sigs []oci.Signature, extra map[string]interface{}) ([]resignPayload, error) {
+	var payloads []resignPayload
+	seen := map[string]bool{}
+	for _, sig := range sigs {
+		b, err := sig.Payload()
+		if err != nil {
+			return nil, err
+		}
+		var sci sigPayload.SimpleContainerImage
+		if err := json.Unmarshal(b, &sci); err != nil {
+			return nil, fmt.Errorf("parsing payload: %w", err)
+		}
+		p := resignPayload{
+			identity:    sci.Critical.Identity.DockerReference,
+			annotations: maps.Clone(sci.Optional),
+		}
+		if len(extra) > 0 {
+			if p.annotations == nil {
+				p.annotations = map[string]interface{}{}
+			}
+			maps.Copy(p.annotations, extra)
+		}
+		key, err := json.Marshal([]any{p.identity, p.annotations})
+		if err != nil {
+			return nil, err
+		}
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		payloads = append(payloads, p)
+	}
+	return payloads, nil
+}
+
+// resignCheckOpts returns the options the existing signatures are verified
+// with: resignOpts.VerifyKey or, without it, a Fulcio certificate for the
+// identity of resignOpts.CertVerify. Their tlog entries are verified unless
+// the new signatures are not uploaded to the tlog either.
+func resignCheckOpts(ctx context.Context, ko options.KeyOpts, resignOpts options.ResignOptions, registryOpts []ociremote.Option) (*cosign.CheckOpts, error) {
+	co := &cosign.CheckOpts{
+		ClaimVerifier:      cosign.SimpleClaimVerifier,
+		RegistryClientOpts: registryOpts,
+		IgnoreTlog:         !resignOpts.TlogUpload,
+	}
+	var err error
+	if !co.IgnoreTlog {
+		if co.RekorClient, err = rekor.NewClient(ko.RekorURL); err != nil {
+			return nil, fmt.Errorf("creating Rekor client: %w", err)
+		}
+		if co.RekorPubKeys, err = cosign.GetRekorPubs(ctx); err != nil {
+			return nil, fmt.Errorf("getting Rekor public keys: %w", err)
+		}
+	}
+
+	if resignOpts.VerifyKey != "" {
+		if co.SigVerifier, err = sigs.PublicKeyFromKeyRef(ctx, resignOpts.VerifyKey); err != nil {
+			return nil, fmt.Errorf("loading public key: %w", err)
+		}
+		return co, nil
+	}
+	if co.Identities, err = resignOpts.CertVerify.Identities(); err != nil {
+		return nil, err
+	}
+	if co.RootCerts, err = fulcio.GetRoots(); err != nil {
+		return nil, fmt.Errorf("getting Fulcio roots: %w", err)
+	}
+	if co.IntermediateCerts, err = fulcio.GetIntermediates(); err != nil {
+		return nil, fmt.Errorf("getting Fulcio intermediates: %w", err)
+	}
+	if co.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx); err != nil {
+		return nil, fmt.Errorf("getting ctlog public keys: %w", err)
+	}
+	return co, nil
+}
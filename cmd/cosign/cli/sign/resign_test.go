@@ -0,0 +1,145 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	sigPayload "github.com/franchb/sigstore/pkg/signature/payload"
+)
+
+func TestResignPayloads(t *testing.T) {
+	newSig := func(identity string, annotations map[string]interface{}) oci.Signature {
+		t.Helper()
+		var sci sigPayload.SimpleContainerImage
+		sci.Critical.Identity.DockerReference = identity
+		sci.Optional = annotations
+		b, err := json.Marshal(sci)
+		require.NoError(t, err)
+		sig, err := static.NewSignature(b, "")
+		require.NoError(t, err)
+		return sig
+	}
+	sigs := []oci.Signature{
+		newSig("example.com/app", map[string]interface{}{"env": "prod"}),
+		newSig("example.com/app", map[string]interface{}{"env": "prod"}),
+		newSig("example.com/mirror/app", nil),
+	}
+
+	payloads, err := resignPayloads(sigs, nil)
+	require.NoError(t, err)
+	require.Equal(t, []resignPayload{
+		{identity: "example.com/app", annotations: map[string]interface{}{"env": "prod"}},
+		{identity: "example.com/mirror/app"},
+	}, payloads)
+
+	payloads, err = resignPayloads(sigs, map[string]interface{}{"renewed": "true", "env": "staging"})
+	require.NoError(t, err)
+	require.Equal(t, []resignPayload{
+		{identity: "example.com/app", annotations: map[string]interface{}{"env": "staging", "renewed": "true"}},
+		{identity: "example.com/mirror/app", annotations: map[string]interface{}{"env": "staging", "renewed": "true"}},
+	}, payloads)
+
+	_, err = resignPayloads([]oci.Signature{mustSignature(t, []byte("not json"))}, nil)
+	require.ErrorContains(t, err, "parsing payload")
+}
+
+func mustSignature(t *testing.T, payload []byte) oci.Signature {
+	t.Helper()
+	sig, err := static.NewSignature(payload, "")
+	require.NoError(t, err)
+	return sig
+}
+
+func TestResignCmd(t *testing.T) {
+	host := newTestRegistry(t)
+	digest := pushRandomImage(t, host, "app")
+
+	td := t.TempDir()
+	oldKeys, err := cosign.GenerateKeyPair(pass("hunter2"))
+	require.NoError(t, err)
+	oldKeyPath := filepath.Join(td, "old.key")
+	require.NoError(t, os.WriteFile(oldKeyPath, oldKeys.PrivateBytes, 0600))
+	oldPubPath := filepath.Join(td, "old.pub")
+	require.NoError(t, os.WriteFile(oldPubPath, oldKeys.PublicBytes, 0600))
+	newKeys, err := cosign.GenerateKeyPair(pass("hunter2"))
+	require.NoError(t, err)
+	newKeyPath := filepath.Join(td, "new.key")
+	require.NoError(t, os.WriteFile(newKeyPath, newKeys.PrivateBytes, 0600))
+	newPubPath := filepath.Join(td, "new.pub")
+	require.NoError(t, os.WriteFile(newPubPath, newKeys.PublicBytes, 0600))
+
+	ro := &options.RootOptions{Timeout: options.DefaultTimeout}
+	so := options.SignOptions{
+		Upload:                true,
+		SignContainerIdentity: "example.com/app",
+		AnnotationOptions:     options.AnnotationOptions{Annotations: []string{"env=prod"}},
+	}
+	ko := options.KeyOpts{KeyRef: oldKeyPath, PassFunc: pass("hunter2")}
+	require.NoError(t, SignCmd(ro, ko, so, []string{digest.String()}))
+
+	// Signatures the verify key does not verify are not re-signed.
+	resignOpts := options.ResignOptions{
+		SignOptions: options.SignOptions{
+			Upload:            true,
+			AnnotationOptions: options.AnnotationOptions{Annotations: []string{"renewed=true"}},
+		},
+		VerifyKey: newPubPath,
+	}
+	ko = options.KeyOpts{KeyRef: newKeyPath, PassFunc: pass("hunter2")}
+	require.ErrorContains(t, ResignCmd(ro, ko, resignOpts, []string{digest.String()}), "verifying existing signatures")
+
+	resignOpts.VerifyKey = oldPubPath
+	require.NoError(t, ResignCmd(ro, ko, resignOpts, []string{digest.String()}))
+
+	verifier, err := sigs.LoadPublicKey(context.Background(), newPubPath)
+	require.NoError(t, err)
+	verified, _, err := cosign.VerifyImageSignatures(context.Background(), digest, &cosign.CheckOpts{
+		SigVerifier:   verifier,
+		ClaimVerifier: cosign.SimpleClaimVerifier,
+		IgnoreTlog:    true,
+	})
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+	b, err := verified[0].Payload()
+	require.NoError(t, err)
+	var sci sigPayload.SimpleContainerImage
+	require.NoError(t, json.Unmarshal(b, &sci))
+	require.Equal(t, "example.com/app", sci.Critical.Identity.DockerReference)
+	require.Equal(t, map[string]interface{}{"env": "prod", "renewed": "true"}, sci.Optional)
+
+	se, err := ociremote.SignedImage(digest)
+	require.NoError(t, err)
+	all, err := se.Signatures()
+	require.NoError(t, err)
+	got, err := all.Get()
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	resignOpts.Recursive = true
+	require.ErrorContains(t, ResignCmd(ro, ko, resignOpts, []string{digest.String()}), "--recursive is not supported")
+}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -29,6 +30,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -36,7 +38,6 @@ import (
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/fulcio"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/fulcio/fulcioverifier"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
-	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign/privacy"
 	icos "github.com/franchb/cosign/v2/internal/pkg/cosign"
 	ifulcio "github.com/franchb/cosign/v2/internal/pkg/cosign/fulcio"
@@ -45,6 +46,7 @@ import (
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/client"
 	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/certprovider"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/pivkey"
 	"github.com/franchb/cosign/v2/pkg/cosign/pkcs11key"
@@ -54,6 +56,7 @@ import (
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 	"github.com/franchb/cosign/v2/pkg/oci/walk"
 	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	"github.com/franchb/rekor/pkg/generated/models"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/franchb/sigstore/pkg/signature"
 	signatureoptions "github.com/franchb/sigstore/pkg/signature/options"
@@ -134,16 +137,33 @@ func SignCmd(ro *options.RootOptions, ko options.KeyOpts, signOpts options.SignO
 	if options.NOf(ko.KeyRef, ko.Sk) > 1 {
 		return &options.KeyParseError{}
 	}
+	if ko.Deterministic {
+		// Timestamps differ every time the digest is signed.
+		if ko.TSAServerURL != "" {
+			return errors.New("--deterministic cannot be combined with --timestamp-server-url")
+		}
+		if signOpts.RecordCreationTimestamp {
+			return errors.New("--deterministic cannot be combined with --record-creation-timestamp")
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), ro.Timeout)
 	defer cancel()
 
+	sp, err := newSigningPolicy(ctx, signOpts)
+	if err != nil {
+		return err
+	}
+
 	sv, err := SignerFromKeyOpts(ctx, signOpts.Cert, signOpts.CertChain, ko)
 	if err != nil {
 		return fmt.Errorf("getting signer: %w", err)
 	}
 	defer sv.Close()
 	dd := cremote.NewDupeDetector(sv)
+	if signOpts.NoDuplicate {
+		dd = cremote.NewPayloadDupeDetector(sv)
+	}
 
 	var staticPayload []byte
 	if signOpts.PayloadPath != "" {
@@ -169,7 +189,30 @@ func SignCmd(ro *options.RootOptions, ko options.KeyOpts, signOpts options.SignO
 		return fmt.Errorf("getting annotations: %w", err)
 	}
 	annotations := am.Annotations
-	for _, inputImg := range imgs {
+	tlog := &tlogConnection{rekorURL: ko.RekorURL, additionalURLs: ko.AdditionalRekorURLs}
+
+	if signOpts.ImagesFile != "" {
+		listed, err := ReadImagesFile(signOpts.ImagesFile)
+		if err != nil {
+			return fmt.Errorf("reading images file: %w", err)
+		}
+		imgs = append(imgs, listed...)
+	}
+	if len(imgs) == 0 {
+		return errors.New("no images to sign")
+	}
+	switch signOpts.Type {
+	case options.SignTypeWasm:
+		if imgs, err = pushWasmModule(ctx, signOpts, imgs); err != nil {
+			return err
+		}
+	default:
+		if signOpts.WasmModule != "" {
+			return errors.New("--wasm-module requires --type wasm")
+		}
+	}
+
+	signImage := func(inputImg string, signed func(name.Digest)) error {
 		ref, err := ParseOCIReference(ctx, inputImg, regOpts.NameOptions()...)
 		if err != nil {
 			return err
@@ -186,11 +229,15 @@ func SignCmd(ro *options.RootOptions, ko options.KeyOpts, signOpts options.SignO
 			} else if err != nil {
 				return fmt.Errorf("accessing image: %w", err)
 			}
-			err = signDigest(ctx, digest, staticPayload, ko, signOpts, annotations, dd, sv, se)
+			if err := sp.check(ctx, digest); err != nil {
+				return err
+			}
+			err = signDigest(ctx, digest, staticPayload, ko, signOpts, annotations, dd, sv, se, tlog)
 			if err != nil {
 				return fmt.Errorf("signing digest: %w", err)
 			}
-			continue
+			signed(digest)
+			return nil
 		}
 
 		se, err := ociremote.SignedEntity(ref, opts...)
@@ -205,22 +252,55 @@ func SignCmd(ro *options.RootOptions, ko options.KeyOpts, signOpts options.SignO
 				return fmt.Errorf("computing digest: %w", err)
 			}
 			digest := ref.Context().Digest(d.String())
-			err = signDigest(ctx, digest, staticPayload, ko, signOpts, annotations, dd, sv, se)
+			if err := sp.check(ctx, digest); err != nil {
+				return err
+			}
+			err = signDigest(ctx, digest, staticPayload, ko, signOpts, annotations, dd, sv, se, tlog)
 			if err != nil {
 				return fmt.Errorf("signing digest: %w", err)
 			}
+			signed(digest)
 			return ErrDone
 		}); err != nil {
 			return fmt.Errorf("recursively signing: %w", err)
 		}
+		return nil
 	}
 
+	// Without --output-results, signing stops at the first image that
+	// fails. With it, the other images are still signed and the failures
+	// are reported in the results.
+	if signOpts.OutputResults == "" {
+		for _, inputImg := range imgs {
+			if err := signImage(inputImg, func(name.Digest) {}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	results := make([]SignResult, 0, len(imgs))
+	failed := 0
+	for _, inputImg := range imgs {
+		result := SignResult{Image: inputImg}
+		if err := signImage(inputImg, func(d name.Digest) { result.Digests = append(result.Digests, d.String()) }); err != nil {
+			ui.Warnf(ctx, "signing %s: %v", inputImg, err)
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+	}
+	if err := writeSignResults(signOpts.OutputResults, results); err != nil {
+		return fmt.Errorf("writing results: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to sign %d of %d images", failed, len(imgs))
+	}
 	return nil
 }
 
 func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko options.KeyOpts, signOpts options.SignOptions,
 	annotations map[string]interface{},
-	dd mutate.DupeDetector, sv *SignerVerifier, se oci.SignedEntity) error {
+	dd mutate.DupeDetector, sv *SignerVerifier, se oci.SignedEntity, tlog *tlogConnection) error {
 	var err error
 	// The payload can be passed to skip generation.
 	if len(payload) == 0 {
@@ -232,6 +312,11 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko opti
 		if err != nil {
 			return fmt.Errorf("payload: %w", err)
 		}
+		if ko.Deterministic {
+			if payload, err = jsoncanonicalizer.Transform(payload); err != nil {
+				return fmt.Errorf("canonicalizing payload: %w", err)
+			}
+		}
 	}
 
 	var s icos.Signer
@@ -241,27 +326,32 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko opti
 	}
 
 	if ko.TSAServerURL != "" {
-		if ko.TSAClientCACert == "" && ko.TSAClientCert == "" { // no mTLS params or custom CA
-			s = tsa.NewSigner(s, client.NewTSAClient(ko.TSAServerURL))
-		} else {
-			s = tsa.NewSigner(s, client.NewTSAClientMTLS(ko.TSAServerURL,
+		newTSAClient := func(url string) client.TimestampAuthorityClient {
+			if ko.TSAClientCACert == "" && ko.TSAClientCert == "" { // no mTLS params or custom CA
+				return client.NewTSAClient(url)
+			}
+			return client.NewTSAClientMTLS(url,
 				ko.TSAClientCACert,
 				ko.TSAClientCert,
 				ko.TSAClientKey,
 				ko.TSAServerName,
-			))
+			)
+		}
+		var additionalTSAClients []client.TimestampAuthorityClient
+		for _, url := range ko.AdditionalTSAServerURLs {
+			additionalTSAClients = append(additionalTSAClients, newTSAClient(url))
 		}
+		s = tsa.NewSigner(s, newTSAClient(ko.TSAServerURL), additionalTSAClients...)
 	}
 	shouldUpload, err := ShouldUploadToTlog(ctx, ko, digest, signOpts.TlogUpload)
 	if err != nil {
 		return fmt.Errorf("should upload to tlog: %w", err)
 	}
 	if shouldUpload {
-		rClient, err := rekor.NewClient(ko.RekorURL)
+		s, err = tlog.signer(s)
 		if err != nil {
 			return err
 		}
-		s = irekor.NewSigner(s, rClient)
 	}
 
 	ociSig, _, err := s.Sign(ctx, bytes.NewReader(payload))
@@ -309,19 +399,32 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko opti
 	}
 
 	if ko.BundlePath != "" {
-		signedPayload, err := fetchLocalSignedPayload(ociSig)
-		if err != nil {
-			return fmt.Errorf("failed to fetch signed payload: %w", err)
-		}
+		var contents []byte
+		if ko.NewBundleFormat {
+			contents, err = sigstoreBundle(ctx, sv, ociSig, irekor.LastEntry(s))
+			if err != nil {
+				return fmt.Errorf("failed to create bundle: %w", err)
+			}
+		} else {
+			signedPayload, err := fetchLocalSignedPayload(ociSig)
+			if err != nil {
+				return fmt.Errorf("failed to fetch signed payload: %w", err)
+			}
 
-		contents, err := json.Marshal(signedPayload)
-		if err != nil {
-			return fmt.Errorf("failed to marshal signed payload: %w", err)
+			contents, err = json.Marshal(signedPayload)
+			if err != nil {
+				return fmt.Errorf("failed to marshal signed payload: %w", err)
+			}
 		}
-		if err := os.WriteFile(ko.BundlePath, contents, 0600); err != nil {
+		bundlePath := ko.BundlePath
+		// Add digest to suffix to differentiate each image during recursive signing
+		if signOpts.Recursive {
+			bundlePath = fmt.Sprintf("%s-%s", bundlePath, strings.Replace(digest.DigestStr(), ":", "-", 1))
+		}
+		if err := os.WriteFile(bundlePath, contents, 0600); err != nil {
 			return fmt.Errorf("create bundle file: %w", err)
 		}
-		ui.Infof(ctx, "Wrote bundle to file %s", ko.BundlePath)
+		ui.Infof(ctx, "Wrote bundle to file %s", bundlePath)
 	}
 
 	if !signOpts.Upload {
@@ -329,7 +432,10 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko opti
 	}
 
 	// Attach the signature to the entity.
-	newSE, err := mutate.AttachSignatureToEntity(se, ociSig, mutate.WithDupeDetector(dd), mutate.WithRecordCreationTimestamp(signOpts.RecordCreationTimestamp))
+	newSE, err := mutate.AttachSignatureToEntity(se, ociSig,
+		mutate.WithDupeDetector(dd),
+		mutate.WithNoDuplicates(signOpts.NoDuplicate),
+		mutate.WithRecordCreationTimestamp(signOpts.RecordCreationTimestamp))
 	if err != nil {
 		return err
 	}
@@ -342,6 +448,11 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko opti
 
 	// Check if we are overriding the signatures repository location
 	repo, _ := ociremote.GetEnvTargetRepository()
+	if repo.RepositoryStr() == "" {
+		if m, _ := ociremote.GetEnvRepositoryMapping(); m != nil {
+			repo, _ = m.Lookup(digest.Repository)
+		}
+	}
 	if repo.RepositoryStr() == "" {
 		ui.Infof(ctx, "Pushing signature to: %s", digest.Repository)
 	} else {
@@ -350,7 +461,7 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko opti
 
 	// Publish the signatures associated with this entity (using OCI 1.1+ behavior)
 	if signOpts.RegistryExperimental.RegistryReferrersMode == options.RegistryReferrersModeOCI11 {
-		return ociremote.WriteSignaturesExperimentalOCI(digest, newSE, walkOpts...)
+		return ociremote.WriteSignaturesReferrers(digest, newSE, walkOpts...)
 	}
 
 	// Publish the signatures associated with this entity
@@ -396,6 +507,22 @@ func signerFromKeyRef(ctx context.Context, certPath, certChainPath, keyRef strin
 	if err != nil {
 		return nil, fmt.Errorf("reading key: %w", err)
 	}
+	return signerFromKey(ctx, certPath, certChainPath, k)
+}
+
+// deterministicSignerFromKeyRef is like signerFromKeyRef, but the key signs
+// deterministically.
+func deterministicSignerFromKeyRef(ctx context.Context, certPath, certChainPath, keyRef string, passFunc cosign.PassFunc) (*SignerVerifier, error) {
+	k, err := sigs.DeterministicSignerVerifierFromKeyRef(ctx, keyRef, passFunc)
+	if err != nil {
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+	return signerFromKey(ctx, certPath, certChainPath, k)
+}
+
+// signerFromKey returns the signer of k, with the certificate at certPath,
+// or in the PKCS11 token of k, and its chain at certChainPath.
+func signerFromKey(ctx context.Context, certPath, certChainPath string, k signature.SignerVerifier) (*SignerVerifier, error) {
 	certSigner := &SignerVerifier{
 		SignerVerifier: k,
 	}
@@ -536,7 +663,69 @@ func signerFromNewKey() (*SignerVerifier, error) {
 	}, nil
 }
 
+// signerFromNewHardwareKey generates the key of keyless signing in the
+// security key or PKCS11 token of ko, replacing the key there, so that the
+// certificate Fulcio issues binds a key that never leaves the device.
+func signerFromNewHardwareKey(ctx context.Context, ko options.KeyOpts) (*SignerVerifier, error) {
+	var device string
+	switch {
+	case ko.Sk:
+		slot := ko.Slot
+		if slot == "" {
+			slot = "signature"
+		}
+		device = fmt.Sprintf("the %s slot of the security key", slot)
+	case strings.HasPrefix(ko.KeyRef, pkcs11key.ReferenceScheme):
+		device = "the PKCS11 token"
+	default:
+		return nil, errors.New("--keyless requires --sk or a --key of a PKCS11 token")
+	}
+	if !ko.SkipConfirmation {
+		ui.Warnf(ctx, "Generating the signing key in %s, which destroys the key there.", device)
+		if err := ui.ConfirmContinue(ctx); err != nil {
+			return nil, err
+		}
+	}
+	ui.Infof(ctx, "Generating signing key in %s...", device)
+
+	if ko.Sk {
+		sk, err := pivkey.GetKeyWithSlot(ko.Slot)
+		if err != nil {
+			return nil, err
+		}
+		if err := sk.GenerateEphemeralKey(); err != nil {
+			sk.Close()
+			return nil, err
+		}
+		sv, err := sk.SignerVerifier()
+		if err != nil {
+			sk.Close()
+			return nil, err
+		}
+		return &SignerVerifier{SignerVerifier: sv, close: sk.Close}, nil
+	}
+
+	pkcs11UriConfig := pkcs11key.NewPkcs11UriConfig()
+	if err := pkcs11UriConfig.Parse(ko.KeyRef); err != nil {
+		return nil, fmt.Errorf("parsing pkcs11 uri: %w", err)
+	}
+	k, err := pkcs11key.GenerateKeyWithURIConfig(pkcs11UriConfig)
+	if err != nil {
+		return nil, fmt.Errorf("generating pkcs11 token key: %w", err)
+	}
+	sv, err := k.SignerVerifier()
+	if err != nil {
+		k.Close()
+		return nil, err
+	}
+	return &SignerVerifier{SignerVerifier: sv, close: k.Close}, nil
+}
+
 func keylessSigner(ctx context.Context, ko options.KeyOpts, sv *SignerVerifier) (*SignerVerifier, error) {
+	if ko.CertificateProvider != "" {
+		return certificateProviderSigner(ctx, ko, sv)
+	}
+
 	var (
 		k   *fulcio.Signer
 		err error
@@ -556,16 +745,63 @@ func keylessSigner(ctx context.Context, ko options.KeyOpts, sv *SignerVerifier)
 		Cert:           k.Cert,
 		Chain:          k.Chain,
 		SignerVerifier: k,
+		close:          sv.close,
+	}, nil
+}
+
+// certificateProviderSigner certifies the key of sv with the private CA
+// ko.CertificateProvider refers to, instead of Fulcio.
+func certificateProviderSigner(ctx context.Context, ko options.KeyOpts, sv *SignerVerifier) (*SignerVerifier, error) {
+	provider, err := certprovider.Get(ctx, ko.CertificateProvider)
+	if err != nil {
+		return nil, fmt.Errorf("getting certificate provider: %w", err)
+	}
+	idToken, err := fulcio.GetIDToken(ctx, ko)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := certprovider.CryptoSigner(sv.SignerVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	ui.Infof(ctx, "Retrieving signed certificate from %s...", ko.CertificateProvider)
+	cert, err := provider.Certificate(ctx, certprovider.Request{Signer: signer, IDToken: idToken})
+	if err != nil {
+		return nil, fmt.Errorf("retrieving cert: %w", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(cert.CertPEM)
+	if err != nil || len(certs) == 0 {
+		return nil, fmt.Errorf("parsing certificate from %s: %w", ko.CertificateProvider, err)
+	}
+	if err := cryptoutils.EqualKeys(certs[0].PublicKey, signer.Public()); err != nil {
+		return nil, fmt.Errorf("certificate from %s is not for the signing key: %w", ko.CertificateProvider, err)
+	}
+
+	return &SignerVerifier{
+		Cert:           cert.CertPEM,
+		Chain:          cert.ChainPEM,
+		SignerVerifier: sv.SignerVerifier,
+		close:          sv.close,
 	}, nil
 }
 
 func SignerFromKeyOpts(ctx context.Context, certPath string, certChainPath string, ko options.KeyOpts) (*SignerVerifier, error) {
+	if ko.Deterministic && (ko.KeyRef == "" || ko.Sk || ko.GenerateHardwareKey || ko.IssueCertificateForExistingKey) {
+		return nil, errors.New("deterministic signing requires a private key given with --key, without a certificate issued by Fulcio")
+	}
+
 	var sv *SignerVerifier
 	var err error
 	genKey := false
 	switch {
+	case ko.GenerateHardwareKey:
+		genKey = true
+		sv, err = signerFromNewHardwareKey(ctx, ko)
 	case ko.Sk:
 		sv, err = signerFromSecurityKey(ctx, ko.Slot)
+	case ko.KeyRef != "" && ko.Deterministic:
+		sv, err = deterministicSignerFromKeyRef(ctx, certPath, certChainPath, ko.KeyRef, ko.PassFunc)
 	case ko.KeyRef != "":
 		sv, err = signerFromKeyRef(ctx, certPath, certChainPath, ko.KeyRef, ko.PassFunc)
 	default:
@@ -610,6 +846,30 @@ func (c *SignerVerifier) Bytes(ctx context.Context) ([]byte, error) {
 	return pemBytes, nil
 }
 
+// sigstoreBundle returns the protobuf Sigstore bundle of ociSig, the
+// signature by sv of its payload, which was uploaded to the tlog as entry
+// unless entry is nil.
+func sigstoreBundle(ctx context.Context, sv *SignerVerifier, ociSig oci.Signature, entry *models.LogEntryAnon) ([]byte, error) {
+	payload, err := ociSig.Payload()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := ociSig.Signature()
+	if err != nil {
+		return nil, err
+	}
+	var timestampBytes []byte
+	ts, err := ociSig.RFC3161Timestamp()
+	if err != nil {
+		return nil, err
+	}
+	if ts != nil {
+		timestampBytes = ts.SignedRFC3161Timestamp
+	}
+	digest := sha256.Sum256(payload)
+	return makeMessageSignatureBundle(ctx, sv, digest[:], sig, entry, timestampBytes)
+}
+
 func fetchLocalSignedPayload(sig oci.Signature) (*cosign.LocalSignedPayload, error) {
 	signedPayload := &cosign.LocalSignedPayload{}
 	var err error
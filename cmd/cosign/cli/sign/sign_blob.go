@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"google.golang.org/protobuf/encoding/protojson"
 
@@ -35,6 +36,11 @@ import (
 	"github.com/franchb/cosign/v2/internal/ui"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	cbundle "github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/cosign/minisign"
+	"github.com/franchb/cosign/v2/pkg/cosign/pgp"
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
+	"github.com/franchb/cosign/v2/pkg/helm"
+	rekorclient "github.com/franchb/rekor/pkg/generated/client"
 	"github.com/franchb/rekor/pkg/generated/models"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	signatureoptions "github.com/franchb/sigstore/pkg/signature/options"
@@ -50,6 +56,16 @@ func SignBlobCmd(ro *options.RootOptions, ko options.KeyOpts, payloadPath string
 	ctx, cancel := context.WithTimeout(context.Background(), ro.Timeout)
 	defer cancel()
 
+	if strings.HasPrefix(ko.KeyRef, sshsig.ReferenceScheme) {
+		return signBlobSSH(ctx, ko, payloadPath, outputSignature, tlogUpload)
+	}
+	if strings.HasPrefix(ko.KeyRef, minisign.ReferenceScheme) {
+		return signBlobMinisign(ctx, ko, payloadPath, outputSignature, tlogUpload)
+	}
+	if strings.HasPrefix(ko.KeyRef, pgp.ReferenceScheme) {
+		return signBlobPGP(ctx, ko, payloadPath, outputSignature, tlogUpload)
+	}
+
 	if payloadPath == "-" {
 		payload = internal.NewHashReader(os.Stdin, sha256.New())
 	} else {
@@ -60,6 +76,7 @@ func SignBlobCmd(ro *options.RootOptions, ko options.KeyOpts, payloadPath string
 			return nil, err
 		}
 		payload = internal.NewHashReader(f, sha256.New())
+		logHelmChart(ctx, payloadPath)
 	}
 	if err != nil {
 		return nil, err
@@ -140,6 +157,11 @@ func SignBlobCmd(ro *options.RootOptions, ko options.KeyOpts, payloadPath string
 			return nil, err
 		}
 		ui.Infof(ctx, "tlog entry created with index: %d", *rekorEntry.LogIndex)
+		if err := rekor.UploadToAdditionalLogs(ctx, ko.RekorURL, ko.AdditionalRekorURLs, func(r *rekorclient.Rekor) (*models.LogEntryAnon, error) {
+			return cosign.TLogUpload(ctx, r, sig, &payload, rekorBytes)
+		}); err != nil {
+			return nil, err
+		}
 		signedPayload.Bundle = cbundle.EntryToBundle(rekorEntry)
 	}
 
@@ -147,46 +169,7 @@ func SignBlobCmd(ro *options.RootOptions, ko options.KeyOpts, payloadPath string
 	if ko.BundlePath != "" {
 		var contents []byte
 		if ko.NewBundleFormat {
-			// Determine if signature is certificate or not
-			var hint string
-			var rawCert []byte
-
-			signer, err := sv.Bytes(ctx)
-			if err != nil {
-				return nil, fmt.Errorf("error getting signer: %w", err)
-			}
-			cert, err := cryptoutils.UnmarshalCertificatesFromPEM(signer)
-			if err != nil || len(cert) == 0 {
-				pubKey, err := sv.PublicKey()
-				if err != nil {
-					return nil, err
-				}
-				pkixPubKey, err := x509.MarshalPKIXPublicKey(pubKey)
-				if err != nil {
-					return nil, err
-				}
-				hashedBytes := sha256.Sum256(pkixPubKey)
-				hint = base64.StdEncoding.EncodeToString(hashedBytes[:])
-			} else {
-				rawCert = cert[0].Raw
-			}
-
-			bundle, err := cbundle.MakeProtobufBundle(hint, rawCert, rekorEntry, timestampBytes)
-			if err != nil {
-				return nil, err
-			}
-
-			bundle.Content = &protobundle.Bundle_MessageSignature{
-				MessageSignature: &protocommon.MessageSignature{
-					MessageDigest: &protocommon.HashOutput{
-						Algorithm: protocommon.HashAlgorithm_SHA2_256,
-						Digest:    digest,
-					},
-					Signature: sig,
-				},
-			}
-
-			contents, err = protojson.Marshal(bundle)
+			contents, err = makeMessageSignatureBundle(ctx, sv, digest, sig, rekorEntry, timestampBytes)
 			if err != nil {
 				return nil, err
 			}
@@ -263,3 +246,62 @@ func extractCertificate(ctx context.Context, sv *SignerVerifier) ([]byte, error)
 	}
 	return nil, nil
 }
+
+// makeMessageSignatureBundle returns the protobuf Sigstore bundle of sig, the
+// signature by sv of a message with the SHA-256 digest, with its tlog entry
+// and RFC3161 timestamp when it has them.
+func makeMessageSignatureBundle(ctx context.Context, sv *SignerVerifier, digest, sig []byte, rekorEntry *models.LogEntryAnon, timestampBytes []byte) ([]byte, error) {
+	// Determine if signature is certificate or not
+	var hint string
+	var rawCert []byte
+
+	signer, err := sv.Bytes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signer: %w", err)
+	}
+	cert, err := cryptoutils.UnmarshalCertificatesFromPEM(signer)
+	if err != nil || len(cert) == 0 {
+		pubKey, err := sv.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		pkixPubKey, err := x509.MarshalPKIXPublicKey(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		hashedBytes := sha256.Sum256(pkixPubKey)
+		hint = base64.StdEncoding.EncodeToString(hashedBytes[:])
+	} else {
+		rawCert = cert[0].Raw
+	}
+
+	bundle, err := cbundle.MakeProtobufBundle(hint, rawCert, rekorEntry, timestampBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle.Content = &protobundle.Bundle_MessageSignature{
+		MessageSignature: &protocommon.MessageSignature{
+			MessageDigest: &protocommon.HashOutput{
+				Algorithm: protocommon.HashAlgorithm_SHA2_256,
+				Digest:    digest,
+			},
+			Signature: sig,
+		},
+	}
+
+	return protojson.Marshal(bundle)
+}
+
+// logHelmChart notes when the blob at path is a Helm chart archive, whose
+// signature verifies with verify-blob in place of helm's provenance file.
+func logHelmChart(ctx context.Context, path string) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if chart, err := helm.ReadChart(f); err == nil {
+		ui.Infof(ctx, "Signing version %s of Helm chart %s", chart.Version, chart.Name)
+	}
+}
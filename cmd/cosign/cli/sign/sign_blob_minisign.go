@@ -0,0 +1,86 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign/minisign"
+)
+
+// signBlobMinisign signs the blob at payloadPath with the minisign secret
+// key ko.KeyRef refers to, writing the signature in the format of
+// minisign -S so that minisign -V and cosign verify-blob accept it.
+func signBlobMinisign(ctx context.Context, ko options.KeyOpts, payloadPath string, outputSignature string, tlogUpload bool) ([]byte, error) {
+	if options.NOf(ko.BundlePath, ko.TSAServerURL, ko.RFC3161TimestampPath) > 0 {
+		return nil, errors.New("minisign keys cannot be used with --bundle or a timestamp authority")
+	}
+	keyPath, err := minisign.KeyPath(ko.KeyRef)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	var pass func() ([]byte, error)
+	if ko.PassFunc != nil {
+		pass = func() ([]byte, error) { return ko.PassFunc(false) }
+	}
+	key, err := minisign.ParsePrivateKey(kb, pass)
+	if err != nil {
+		return nil, fmt.Errorf("loading minisign key: %w", err)
+	}
+
+	var payload io.Reader = os.Stdin
+	fileName := "-"
+	if payloadPath != "-" {
+		ui.Infof(ctx, "Using payload from: %s", payloadPath)
+		f, err := os.Open(filepath.Clean(payloadPath))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		payload = f
+		fileName = filepath.Base(payloadPath)
+	}
+	// The trusted comment minisign -S writes by default.
+	trustedComment := fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), fileName)
+	sig, err := minisign.Sign(key, payload, "signature from cosign with minisign key "+key.KeyID.String(), trustedComment)
+	if err != nil {
+		return nil, fmt.Errorf("signing blob: %w", err)
+	}
+	if tlogUpload {
+		ui.Warnf(ctx, "minisign signatures are not uploaded to the transparency log")
+	}
+
+	if outputSignature != "" {
+		if err := os.WriteFile(outputSignature, sig, 0600); err != nil {
+			return nil, fmt.Errorf("create signature file: %w", err)
+		}
+		ui.Infof(ctx, "Wrote signature to file %s", outputSignature)
+	} else if _, err := os.Stdout.Write(sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
@@ -15,12 +15,26 @@
 package sign
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/minisign"
+	"github.com/franchb/cosign/v2/pkg/cosign/pgp"
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
 )
 
 func TestSignBlobCmd(t *testing.T) {
@@ -52,6 +66,130 @@ func TestSignBlobCmd(t *testing.T) {
 	}
 }
 
+func TestSignBlobCmdSSH(t *testing.T) {
+	td := t.TempDir()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := writeFile(t, td, string(pem.EncodeToMemory(block)), "id_ed25519")
+	blobPath := writeFile(t, td, "foo", "foo.txt")
+	sigPath := filepath.Join(td, "foo.txt.sig")
+
+	keyOpts := options.KeyOpts{KeyRef: sshsig.ReferenceScheme + keyPath, SSHNamespace: "git"}
+	if _, err := SignBlobCmd(&options.RootOptions{}, keyOpts, blobPath, true, sigPath, "", false); err != nil {
+		t.Fatalf("SignBlobCmd() = %v", err)
+	}
+	b, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sshsig.ParseSignature(b)
+	if err != nil {
+		t.Fatalf("ParseSignature() = %v", err)
+	}
+	if err := sig.Verify(strings.NewReader("foo"), "git"); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+
+	keyOpts.BundlePath = filepath.Join(td, "bundle.json")
+	if _, err := SignBlobCmd(&options.RootOptions{}, keyOpts, blobPath, true, sigPath, "", false); err == nil {
+		t.Error("SignBlobCmd() succeeded with an SSH key and a bundle")
+	}
+}
+
+func TestSignBlobCmdMinisign(t *testing.T) {
+	td := t.TempDir()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &minisign.PrivateKey{KeyID: minisign.KeyID{1, 2, 3, 4, 5, 6, 7, 8}, Key: priv}
+	// An unencrypted minisign secret key, as minisign -G -W writes.
+	keynumSK := append(append([]byte{}, key.KeyID[:]...), key.Key...)
+	checksum := blake2b.Sum256(append([]byte("Ed"), keynumSK...))
+	raw := append([]byte("Ed\x00\x00B2"), make([]byte, 32+8+8)...)
+	raw = append(append(raw, keynumSK...), checksum[:]...)
+	keyPath := writeFile(t, td, "untrusted comment: minisign secret key\n"+base64.StdEncoding.EncodeToString(raw)+"\n", "minisign.key")
+	blobPath := writeFile(t, td, "foo", "foo.txt")
+	sigPath := filepath.Join(td, "foo.txt.minisig")
+
+	keyOpts := options.KeyOpts{KeyRef: minisign.ReferenceScheme + keyPath}
+	if _, err := SignBlobCmd(&options.RootOptions{}, keyOpts, blobPath, true, sigPath, "", false); err != nil {
+		t.Fatalf("SignBlobCmd() = %v", err)
+	}
+	b, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := minisign.ParseSignature(b)
+	if err != nil {
+		t.Fatalf("ParseSignature() = %v", err)
+	}
+	if err := key.Public().Verify(sig, strings.NewReader("foo")); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if !strings.Contains(sig.TrustedComment, "\tfile:foo.txt\t") {
+		t.Errorf("trusted comment %q does not name the signed file", sig.TrustedComment)
+	}
+
+	keyOpts.BundlePath = filepath.Join(td, "bundle.json")
+	if _, err := SignBlobCmd(&options.RootOptions{}, keyOpts, blobPath, true, sigPath, "", false); err == nil {
+		t.Error("SignBlobCmd() succeeded with a minisign key and a bundle")
+	}
+}
+
+func TestSignBlobCmdPGP(t *testing.T) {
+	td := t.TempDir()
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.EncryptPrivateKeys([]byte("hunter2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	var key bytes.Buffer
+	w, err := armor.Encode(&key, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivateWithoutSigning(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	keyPath := writeFile(t, td, key.String(), "alice.key")
+	blobPath := writeFile(t, td, "foo", "foo.txt")
+	sigPath := filepath.Join(td, "foo.txt.asc")
+
+	keyOpts := options.KeyOpts{
+		KeyRef:   pgp.ReferenceScheme + keyPath,
+		PassFunc: func(bool) ([]byte, error) { return []byte("hunter2"), nil },
+	}
+	if _, err := SignBlobCmd(&options.RootOptions{}, keyOpts, blobPath, true, sigPath, "", false); err != nil {
+		t.Fatalf("SignBlobCmd() = %v", err)
+	}
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := pgp.Verify(openpgp.EntityList{entity}, sig, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if v.Identity != "Alice <alice@example.com>" {
+		t.Errorf("Verify() identity = %q", v.Identity)
+	}
+
+	keyOpts.BundlePath = filepath.Join(td, "bundle.json")
+	if _, err := SignBlobCmd(&options.RootOptions{}, keyOpts, blobPath, true, sigPath, "", false); err == nil {
+		t.Error("SignBlobCmd() succeeded with an OpenPGP key and a bundle")
+	}
+}
+
 func writeFile(t *testing.T, td string, blob string, name string) string {
 	// Write blob to disk
 	blobPath := filepath.Join(td, name)
@@ -0,0 +1,113 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa"
+	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/client"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/certprovider"
+	"github.com/franchb/cosign/v2/pkg/git"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+// SignGitCmd signs the git object of type objectType ref refers to in the
+// repository at repoDir, a commit or an annotated tag, replacing any
+// signature it has. The signed object replaces it as the target of ref,
+// and its ID is returned.
+func SignGitCmd(ro *options.RootOptions, ko options.KeyOpts, certPath, certChainPath, repoDir, objectType, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ro.Timeout)
+	defer cancel()
+
+	repo := git.Repository{Dir: repoDir}
+	id, err := repo.ResolveObject(ctx, ref, objectType)
+	if err != nil {
+		return "", err
+	}
+	content, err := repo.ReadObject(ctx, objectType, id)
+	if err != nil {
+		return "", err
+	}
+	payload, _, err := git.SplitSignature(objectType, content)
+	if err != nil && !errors.Is(err, git.ErrNotSigned) {
+		return "", err
+	}
+
+	sv, err := SignerFromKeyOpts(ctx, certPath, certChainPath, ko)
+	if err != nil {
+		return "", fmt.Errorf("getting signer: %w", err)
+	}
+	defer sv.Close()
+	if sv.Cert == nil {
+		return "", errors.New("git signatures require a certificate: sign keylessly, or pass --certificate or --issue-certificate with --key")
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(sv.Cert)
+	if err != nil {
+		return "", fmt.Errorf("reading signing certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return "", errors.New("no signing certificate")
+	}
+	var chain []*x509.Certificate
+	if len(sv.Chain) > 0 {
+		if chain, err = cryptoutils.UnmarshalCertificatesFromPEM(sv.Chain); err != nil {
+			return "", fmt.Errorf("reading certificate chain: %w", err)
+		}
+	}
+	signer, err := certprovider.CryptoSigner(sv.SignerVerifier)
+	if err != nil {
+		return "", err
+	}
+
+	var timestampFn git.TimestampFunc
+	if ko.TSAServerURL != "" {
+		tsaClient := client.NewTSAClient(ko.TSAServerURL)
+		if ko.TSAClientCACert != "" || ko.TSAClientCert != "" {
+			tsaClient = client.NewTSAClientMTLS(ko.TSAServerURL,
+				ko.TSAClientCACert,
+				ko.TSAClientCert,
+				ko.TSAClientKey,
+				ko.TSAServerName,
+			)
+		}
+		timestampFn = func(sig []byte) ([]byte, error) {
+			return tsa.GetTimestampedSignature(sig, tsaClient)
+		}
+	}
+
+	sig, err := git.Sign(payload, signer, certs[0], chain, timestampFn)
+	if err != nil {
+		return "", err
+	}
+	signed, err := git.AddSignature(objectType, payload, sig)
+	if err != nil {
+		return "", err
+	}
+	signedID, err := repo.WriteObject(ctx, objectType, signed)
+	if err != nil {
+		return "", err
+	}
+	if err := repo.UpdateRef(ctx, ref, signedID, id, "cosign: sign "+objectType); err != nil {
+		return "", err
+	}
+	ui.Infof(ctx, "Signed %s %s as %s", objectType, ref, signedID)
+	return signedID, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/git"
+)
+
+func TestSignGitCmd(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	td := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+		{"-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "tag", "-a", "-m", "Release v1.0.0", "v1.0.0"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", td}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	keyFile, certFile, chainFile, _, cert, _ := generateCertificateFiles(t, t.TempDir(), pass("foo"))
+	ro := &options.RootOptions{Timeout: time.Minute}
+	ko := options.KeyOpts{KeyRef: keyFile, PassFunc: pass("foo")}
+	repo := git.Repository{Dir: td}
+
+	for _, tc := range []struct {
+		objectType, ref string
+	}{
+		{git.ObjectCommit, "HEAD"},
+		{git.ObjectTag, "refs/tags/v1.0.0"},
+	} {
+		t.Run(tc.objectType, func(t *testing.T) {
+			id, err := SignGitCmd(ro, ko, certFile, chainFile, td, tc.objectType, tc.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resolved, err := repo.ResolveObject(ctx, tc.ref, tc.objectType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved != id {
+				t.Errorf("%s = %s, want the signed object %s", tc.ref, resolved, id)
+			}
+			content, err := repo.ReadObject(ctx, tc.objectType, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			payload, sig, err := git.SplitSignature(tc.objectType, content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := git.VerifySignature(payload, sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !s.Certificate.Equal(cert) {
+				t.Error("signature certificate is not the certificate of the key")
+			}
+			if len(s.Intermediates) != 2 {
+				t.Errorf("got %d intermediates, want the 2 certificates of the chain", len(s.Intermediates))
+			}
+
+			// Signing again replaces the signature.
+			resigned, err := SignGitCmd(ro, ko, certFile, chainFile, td, tc.objectType, tc.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err = repo.ReadObject(ctx, tc.objectType, resigned)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repayload, _, err := git.SplitSignature(tc.objectType, content); err != nil || string(repayload) != string(payload) {
+				t.Errorf("payload of the re-signed object = %q, %v, want %q", repayload, err, payload)
+			}
+		})
+	}
+
+	if _, err := SignGitCmd(ro, ko, "", "", td, git.ObjectCommit, "HEAD"); err == nil {
+		t.Error("SignGitCmd() without a certificate succeeded, want error")
+	}
+	if _, err := SignGitCmd(ro, ko, certFile, chainFile, td, git.ObjectTag, "HEAD"); err == nil {
+		t.Error("SignGitCmd() of a commit as a tag succeeded, want error")
+	}
+}
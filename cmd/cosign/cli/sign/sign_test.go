@@ -16,24 +16,37 @@
 package sign
 
 import (
+	"bytes"
 	"context"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/certprovider"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/test"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
 	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
 )
 
@@ -129,6 +142,91 @@ func TestSignCmdLocalKeyAndSk(t *testing.T) {
 	}
 }
 
+// TestSignerFromKeyOptsKeylessHardwareKey verifies that --keyless only
+// generates keys in security keys and PKCS11 tokens.
+func TestSignerFromKeyOptsKeylessHardwareKey(t *testing.T) {
+	ctx := context.Background()
+	for _, ko := range []options.KeyOpts{
+		{GenerateHardwareKey: true},
+		{GenerateHardwareKey: true, KeyRef: "cosign.key"},
+		{GenerateHardwareKey: true, KeyRef: "awskms:///alias/cosign"},
+	} {
+		_, err := SignerFromKeyOpts(ctx, "", "", ko)
+		if err == nil || !strings.Contains(err.Error(), "--keyless requires") {
+			t.Errorf("SignerFromKeyOpts(%q) = %v, want an error for the missing device", ko.KeyRef, err)
+		}
+	}
+}
+
+// testCertProvider issues certificates from a test CA, for the key it is
+// asked to or, if otherKey is set, for another one.
+type testCertProvider struct {
+	otherKey bool
+}
+
+func (p *testCertProvider) Certificate(_ context.Context, req certprovider.Request) (*certprovider.Certificate, error) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	if p.otherKey {
+		leafCert, _, err := test.GenerateLeafCert("subject", "oidc-issuer", rootCert, rootKey)
+		if err != nil {
+			return nil, err
+		}
+		return &certprovider.Certificate{CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafCert.Raw})}, nil
+	}
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		EmailAddresses: []string{"alice@example.com"},
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, rootCert, req.Signer.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+	return &certprovider.Certificate{
+		CertPEM:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		ChainPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCert.Raw}),
+	}, nil
+}
+
+func TestSignerFromKeyOptsCertificateProvider(t *testing.T) {
+	certprovider.AddProvider("test-ca://", func(_ context.Context, ref string) (certprovider.Interface, error) {
+		return &testCertProvider{otherKey: ref == "test-ca://other-key"}, nil
+	})
+	ctx := context.Background()
+	ko := options.KeyOpts{CertificateProvider: "test-ca://ca.example.com", OIDCDisableProviders: true}
+
+	sv, err := SignerFromKeyOpts(ctx, "", "", ko)
+	if err != nil {
+		t.Fatalf("SignerFromKeyOpts() = %v", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(sv.Cert)
+	if err != nil || len(certs) != 1 {
+		t.Fatalf("signer certificate %s: %v", sv.Cert, err)
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cryptoutils.EqualKeys(certs[0].PublicKey, pub); err != nil {
+		t.Errorf("certificate is not for the signing key: %v", err)
+	}
+	if len(sv.Chain) == 0 {
+		t.Error("signer has no certificate chain")
+	}
+
+	ko.CertificateProvider = "test-ca://other-key"
+	if _, err := SignerFromKeyOpts(ctx, "", "", ko); err == nil || !strings.Contains(err.Error(), "not for the signing key") {
+		t.Errorf("SignerFromKeyOpts() with a certificate for another key = %v", err)
+	}
+	ko.CertificateProvider = "unknown://ca.example.com"
+	if _, err := SignerFromKeyOpts(ctx, "", "", ko); err == nil {
+		t.Error("SignerFromKeyOpts() with an unknown certificate provider succeeded")
+	}
+}
+
 func Test_signerFromKeyRefSuccess(t *testing.T) {
 	tmpDir := t.TempDir()
 	ctx := context.Background()
@@ -229,3 +327,85 @@ func Test_ParseOCIReference(t *testing.T) {
 		}
 	}
 }
+
+func TestSignCmdSigstoreBundle(t *testing.T) {
+	digest := pushRandomImage(t, newTestRegistry(t), "app")
+
+	td := t.TempDir()
+	keys, err := cosign.GenerateKeyPair(pass("hunter2"))
+	require.NoError(t, err)
+	keyPath := filepath.Join(td, "cosign.key")
+	require.NoError(t, os.WriteFile(keyPath, keys.PrivateBytes, 0600))
+	bundlePath := filepath.Join(td, "bundle.json")
+	payloadPath := filepath.Join(td, "payload.json")
+
+	ro := &options.RootOptions{Timeout: options.DefaultTimeout}
+	ko := options.KeyOpts{KeyRef: keyPath, PassFunc: pass("hunter2"), BundlePath: bundlePath, NewBundleFormat: true}
+	so := options.SignOptions{Upload: true, OutputPayload: payloadPath}
+	require.NoError(t, SignCmd(ro, ko, so, []string{digest.String()}))
+
+	b, err := os.ReadFile(bundlePath)
+	require.NoError(t, err)
+	var bundle protobundle.Bundle
+	require.NoError(t, protojson.Unmarshal(b, &bundle))
+	require.Equal(t, "application/vnd.dev.sigstore.bundle.v0.3+json", bundle.MediaType)
+	require.NotEmpty(t, bundle.GetVerificationMaterial().GetPublicKey().GetHint())
+
+	// The bundle signs the payload of the signature attached to the image.
+	payload, err := os.ReadFile(payloadPath)
+	require.NoError(t, err)
+	ms := bundle.GetMessageSignature()
+	require.NotNil(t, ms)
+	want := sha256.Sum256(payload)
+	require.Equal(t, want[:], ms.GetMessageDigest().GetDigest())
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(keys.PublicBytes)
+	require.NoError(t, err)
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	require.NoError(t, err)
+	require.NoError(t, verifier.VerifySignature(bytes.NewReader(ms.GetSignature()), bytes.NewReader(payload)))
+}
+
+func TestSignCmdDeterministic(t *testing.T) {
+	digest := pushRandomImage(t, newTestRegistry(t), "app")
+
+	td := t.TempDir()
+	// ed25519 signs deterministically whatever Go cosign is built with.
+	keys, err := cosign.GenerateKeyPairWithOptions(cosign.KeyPairOptions{KeyType: cosign.KeyTypeED25519}, pass("hunter2"))
+	require.NoError(t, err)
+	keyPath := filepath.Join(td, "cosign.key")
+	require.NoError(t, os.WriteFile(keyPath, keys.PrivateBytes, 0600))
+
+	ro := &options.RootOptions{Timeout: options.DefaultTimeout}
+	ko := options.KeyOpts{KeyRef: keyPath, PassFunc: pass("hunter2"), Deterministic: true}
+	sign := func() (sig, payload []byte) {
+		so := options.SignOptions{
+			OutputSignature: filepath.Join(t.TempDir(), "sig"),
+			OutputPayload:   filepath.Join(t.TempDir(), "payload.json"),
+			AnnotationOptions: options.AnnotationOptions{
+				Annotations: []string{"b=2", "a=1"},
+			},
+		}
+		require.NoError(t, SignCmd(ro, ko, so, []string{digest.String()}))
+		sig, err := os.ReadFile(so.OutputSignature)
+		require.NoError(t, err)
+		payload, err = os.ReadFile(so.OutputPayload)
+		require.NoError(t, err)
+		return sig, payload
+	}
+
+	sig, payload := sign()
+	again, againPayload := sign()
+	require.Equal(t, string(sig), string(again))
+	require.Equal(t, string(payload), string(againPayload))
+	canonical, err := jsoncanonicalizer.Transform(payload)
+	require.NoError(t, err)
+	require.Equal(t, string(canonical), string(payload))
+
+	// Timestamps and keyless certificates differ every time.
+	require.Error(t, SignCmd(ro, ko, options.SignOptions{RecordCreationTimestamp: true}, []string{digest.String()}))
+	tsaKO := ko
+	tsaKO.TSAServerURL = "https://tsa.example.com/tsr"
+	require.Error(t, SignCmd(ro, tsaKO, options.SignOptions{}, []string{digest.String()}))
+	_, err = SignerFromKeyOpts(context.Background(), "", "", options.KeyOpts{Deterministic: true})
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/pkg/now"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/wasm"
+)
+
+// pushWasmModule pushes the WebAssembly module of signOpts.WasmModule to the
+// single image reference of imgs, returning the digest reference it is
+// signed at.
+func pushWasmModule(ctx context.Context, signOpts options.SignOptions, imgs []string) ([]string, error) {
+	switch {
+	case signOpts.WasmModule == "":
+		return nil, errors.New("--type wasm requires --wasm-module")
+	case len(imgs) != 1:
+		return nil, fmt.Errorf("--type wasm pushes the module to a single image reference, got %d", len(imgs))
+	case signOpts.Recursive || signOpts.Attachment != "":
+		return nil, errors.New("--recursive and --attachment cannot be used with --type wasm")
+	}
+
+	module, err := os.ReadFile(filepath.Clean(signOpts.WasmModule))
+	if err != nil {
+		return nil, fmt.Errorf("reading WebAssembly module: %w", err)
+	}
+	created, err := now.Now()
+	if err != nil {
+		return nil, err
+	}
+	img, err := wasm.NewArtifact(module, created)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signOpts.WasmModule, err)
+	}
+	ref, err := name.ParseReference(imgs[0], signOpts.Registry.NameOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing reference: %w", err)
+	}
+	if err := remote.Write(ref, img, signOpts.Registry.GetRegistryClientOpts(ctx)...); err != nil {
+		return nil, fmt.Errorf("pushing WebAssembly module: %w", err)
+	}
+	d, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	digest := ref.Context().Digest(d.String())
+	ui.Infof(ctx, "Pushed WebAssembly module %s to %s", signOpts.WasmModule, digest)
+	return []string{digest.String()}, nil
+}
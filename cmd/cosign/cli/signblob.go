@@ -53,8 +53,39 @@ func SignBlob() *cobra.Command {
   # sign a blob with a key pair stored in Google Cloud KMS
   cosign sign-blob --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] <FILE>
 
+  # sign a blob with a key pair stored in Oracle Cloud Infrastructure Vault
+  cosign sign-blob --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <FILE>
+
+  # sign a blob with a key pair stored in Alibaba Cloud KMS
+  cosign sign-blob --key alibabakms://[REGION]/[KEY_ID] <FILE>
+
+  # sign a blob with a key pair stored in Tencent Cloud KMS
+  cosign sign-blob --key tencentkms://[REGION]/[KEY_ID] <FILE>
+
   # sign a blob with a key pair stored in Hashicorp Vault
-  cosign sign-blob --key hashivault://[KEY] <FILE>`,
+  cosign sign-blob --key hashivault://[KEY] <FILE>
+
+  # sign a blob with version [VERSION] of a key pair stored in a Hashicorp Vault Enterprise namespace,
+  # logging in to Vault with an AppRole
+  VAULT_AUTH_METHOD=approle VAULT_ROLE_ID=[ROLE_ID] VAULT_SECRET_ID=[SECRET_ID] cosign sign-blob --key hashivault://[NAMESPACE]/[KEY]/versions/[VERSION] <FILE>
+
+  # sign a blob with a key held by the signer plugin cosign-signer-[NAME] in PATH
+  cosign sign-blob --key plugin://[NAME]/[KEY] <FILE>
+
+  # sign a blob, writing a Sigstore bundle that other Sigstore clients can verify
+  cosign sign-blob --key cosign.key --bundle <FILE>.sigstore.json --bundle-format sigstore <FILE>
+
+  # sign a Helm chart packaged with helm package, in place of its PGP provenance file
+  cosign sign-blob --key cosign.key --bundle <CHART>-<VERSION>.tgz.sigstore.json <CHART>-<VERSION>.tgz
+
+  # sign a blob with an SSH key, writing a signature that ssh-keygen -Y verify accepts
+  cosign sign-blob --key ssh://~/.ssh/id_ed25519 --output-signature <FILE>.sig <FILE>
+
+  # sign a blob with a minisign secret key, writing a signature that minisign -V accepts
+  cosign sign-blob --key minisign://~/.minisign/minisign.key --output-signature <FILE>.minisig <FILE>
+
+  # sign a blob with an OpenPGP secret key exported with gpg --export-secret-keys, writing a signature that gpg --verify accepts
+  cosign sign-blob --key gpg://release.key --output-signature <FILE>.asc <FILE>`,
 		Args:             cobra.MinimumNArgs(1),
 		PersistentPreRun: options.BindViper,
 		PreRunE: func(_ *cobra.Command, _ []string) error {
@@ -77,14 +108,16 @@ func SignBlob() *cobra.Command {
 				IDToken:                        o.Fulcio.IdentityToken,
 				FulcioAuthFlow:                 o.Fulcio.AuthFlow,
 				InsecureSkipFulcioVerify:       o.Fulcio.InsecureSkipFulcioVerify,
+				CertificateProvider:            o.Fulcio.CertificateProvider,
 				RekorURL:                       o.Rekor.URL,
+				AdditionalRekorURLs:            o.Rekor.AdditionalURLs,
 				OIDCIssuer:                     o.OIDC.Issuer,
 				OIDCClientID:                   o.OIDC.ClientID,
 				OIDCClientSecret:               oidcClientSecret,
 				OIDCRedirectURL:                o.OIDC.RedirectURL,
 				OIDCDisableProviders:           o.OIDC.DisableAmbientProviders,
 				BundlePath:                     o.BundlePath,
-				NewBundleFormat:                o.NewBundleFormat,
+				NewBundleFormat:                o.NewBundleFormat || o.BundleFormat == options.BundleFormatSigstore,
 				SkipConfirmation:               o.SkipConfirmation,
 				TSAClientCACert:                o.TSAClientCACert,
 				TSAClientCert:                  o.TSAClientCert,
@@ -93,6 +126,8 @@ func SignBlob() *cobra.Command {
 				TSAServerURL:                   o.TSAServerURL,
 				RFC3161TimestampPath:           o.RFC3161TimestampPath,
 				IssueCertificateForExistingKey: o.IssueCertificate,
+				GenerateHardwareKey:            o.Keyless,
+				SSHNamespace:                   o.SSHNamespace,
 			}
 
 			for _, blob := range args {
@@ -0,0 +1,138 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign"
+	"github.com/franchb/cosign/v2/pkg/git"
+)
+
+func SignGit() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign-git",
+		Short: "Sign git commits and annotated tags with x509 signatures.",
+		Long: `Sign git commits and annotated tags with x509 signatures.
+
+The signature is stored in the git object, in the S/MIME format of git
+x509 signatures (gpg.format=x509), with the certificate of the signer: a
+Fulcio certificate when signing keylessly, or the certificate of --key.
+Signing rewrites the object, so only the HEAD commit and tags can be signed.
+The signatures are not uploaded to a transparency log; timestamp them with
+--timestamp-server-url to verify them after their certificate expires.
+`,
+	}
+
+	cmd.AddCommand(
+		signGitCommit(),
+		signGitTag(),
+	)
+
+	return cmd
+}
+
+func signGitCommit() *cobra.Command {
+	o := &options.SignGitOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "commit",
+		Short: "Sign the HEAD commit, amending it with the signature.",
+		Example: `  cosign sign-git commit [--key <key path>|<kms uri> --certificate <cert path>]
+
+  # sign the last commit with the Sigstore OIDC flow
+  cosign sign-git commit
+
+  # sign the last commit with a key and its certificate, timestamping the signature
+  cosign sign-git commit --key cosign.key --certificate cosign.crt --timestamp-server-url https://freetsa.org/tsr
+
+  # sign the last commit of another repository
+  cosign sign-git commit -C <REPOSITORY>`,
+		Args:             cobra.NoArgs,
+		PersistentPreRun: options.BindViper,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return signGit(o, git.ObjectCommit, "HEAD")
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func signGitTag() *cobra.Command {
+	o := &options.SignGitOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Sign an annotated tag, replacing it with the signed tag.",
+		Example: `  cosign sign-git tag [--key <key path>|<kms uri> --certificate <cert path>] <tag name>
+
+  # sign a tag created with git tag -a, with the Sigstore OIDC flow
+  cosign sign-git tag v1.0.0
+
+  # sign a tag with a key and a certificate issued for it by Fulcio
+  cosign sign-git tag --key cosign.key --issue-certificate v1.0.0`,
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return signGit(o, git.ObjectTag, "refs/tags/"+args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func signGit(o *options.SignGitOptions, objectType, ref string) error {
+	if options.NOf(o.Key, o.SecurityKey.Use) > 1 {
+		return &options.KeyParseError{}
+	}
+	oidcClientSecret, err := o.OIDC.ClientSecret()
+	if err != nil {
+		return err
+	}
+	ko := options.KeyOpts{
+		KeyRef:                         o.Key,
+		PassFunc:                       generate.GetPass,
+		Sk:                             o.SecurityKey.Use,
+		Slot:                           o.SecurityKey.Slot,
+		FulcioURL:                      o.Fulcio.URL,
+		IDToken:                        o.Fulcio.IdentityToken,
+		FulcioAuthFlow:                 o.Fulcio.AuthFlow,
+		InsecureSkipFulcioVerify:       o.Fulcio.InsecureSkipFulcioVerify,
+		CertificateProvider:            o.Fulcio.CertificateProvider,
+		OIDCIssuer:                     o.OIDC.Issuer,
+		OIDCClientID:                   o.OIDC.ClientID,
+		OIDCClientSecret:               oidcClientSecret,
+		OIDCRedirectURL:                o.OIDC.RedirectURL,
+		OIDCDisableProviders:           o.OIDC.DisableAmbientProviders,
+		OIDCProvider:                   o.OIDC.Provider,
+		SkipConfirmation:               o.SkipConfirmation,
+		TSAClientCACert:                o.TSAClientCACert,
+		TSAClientCert:                  o.TSAClientCert,
+		TSAClientKey:                   o.TSAClientKey,
+		TSAServerName:                  o.TSAServerName,
+		TSAServerURL:                   o.TSAServerURL,
+		IssueCertificateForExistingKey: o.IssueCertificate,
+	}
+	if _, err := sign.SignGitCmd(ro, ko, o.Cert, o.CertChain, o.Repository, objectType, ref); err != nil {
+		return fmt.Errorf("signing %s %s: %w", objectType, ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/walk"
+)
+
+func Sync() *cobra.Command {
+	o := &options.SyncOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync signatures and attestations created on disk to a remote registry",
+		Long: `Sync signatures and attestations created on disk to a remote registry.
+
+The directory holds an image with its signatures and attestations in the
+layout written by 'cosign save', for example created on a disconnected build
+system. The image is pushed if the registry does not have it yet,
+and must otherwise have the same digest. Signatures and attestations the
+registry already holds are skipped, the others are added to it.`,
+		Example:          `  cosign sync --dir <path to directory> <IMAGE>`,
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return SyncCmd(cmd.Context(), *o, args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func SyncCmd(ctx context.Context, opts options.SyncOptions, imageRef string) error {
+	ref, err := name.ParseReference(imageRef, opts.Registry.NameOptions()...)
+	if err != nil {
+		return fmt.Errorf("parsing image name %s: %w", imageRef, err)
+	}
+
+	sii, err := layout.SignedImageIndex(opts.Directory)
+	if err != nil {
+		return fmt.Errorf("signed image index: %w", err)
+	}
+	se, err := savedEntity(sii)
+	if err != nil {
+		return err
+	}
+	h, err := se.(interface{ Digest() (v1.Hash, error) }).Digest()
+	if err != nil {
+		return fmt.Errorf("computing digest: %w", err)
+	}
+
+	ociremoteOpts, err := opts.Registry.ClientOpts(ctx)
+	if err != nil {
+		return err
+	}
+	if err := syncEntity(ctx, ref, h, se, opts.Registry.GetRegistryClientOpts(ctx)); err != nil {
+		return err
+	}
+
+	return walk.SignedEntity(ctx, se, func(ctx context.Context, se oci.SignedEntity) error {
+		d, err := se.(interface{ Digest() (v1.Hash, error) }).Digest()
+		if err != nil {
+			return fmt.Errorf("computing digest: %w", err)
+		}
+		return syncSignatures(ctx, ref.Context().Digest(d.String()), se, ociremoteOpts...)
+	})
+}
+
+// savedEntity returns the image or image index saved in sii.
+func savedEntity(sii oci.SignedImageIndex) (oci.SignedEntity, error) {
+	ii, err := sii.SignedImageIndex(v1.Hash{})
+	if err != nil {
+		return nil, fmt.Errorf("signed image index: %w", err)
+	}
+	if ii != nil {
+		return ii, nil
+	}
+	si, err := sii.SignedImage(v1.Hash{})
+	if err != nil {
+		return nil, fmt.Errorf("signed image: %w", err)
+	}
+	if si != nil {
+		return si, nil
+	}
+	return nil, errors.New("no image or image index saved on disk")
+}
+
+// syncEntity makes sure ref names the entity with digest h in the registry,
+// pushing se if ref does not exist yet.
+func syncEntity(ctx context.Context, ref name.Reference, h v1.Hash, se oci.SignedEntity, opts []remote.Option) error {
+	desc, err := remote.Head(ref, opts...)
+	var terr *transport.Error
+	switch {
+	case err == nil:
+		if desc.Digest != h {
+			return fmt.Errorf("%s is %s in the registry, but the image on disk is %s", ref, desc.Digest, h)
+		}
+		return nil
+	case errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound:
+	default:
+		return fmt.Errorf("getting %s: %w", ref, err)
+	}
+
+	ui.Infof(ctx, "Pushing %s", ref)
+	switch se := se.(type) {
+	case oci.SignedImageIndex:
+		return remote.WriteIndex(ref, se, opts...)
+	case oci.SignedImage:
+		return remote.Write(ref, se, opts...)
+	default:
+		return fmt.Errorf("unknown entity type %T", se)
+	}
+}
+
+// syncSignatures adds the signatures and attestations of se that the registry
+// does not hold yet to those of digest.
+func syncSignatures(ctx context.Context, digest name.Digest, se oci.SignedEntity, opts ...ociremote.Option) error {
+	h, err := v1.NewHash(digest.DigestStr())
+	if err != nil {
+		return err
+	}
+	remoteSE := ociremote.SignedUnknown(digest, opts...)
+
+	sigs, err := se.Signatures()
+	if err != nil {
+		return fmt.Errorf("reading signatures: %w", err)
+	}
+	existingSigs, err := remoteSE.Signatures()
+	if err != nil {
+		return fmt.Errorf("fetching signatures: %w", err)
+	}
+	newSigs, err := missingSignatures(sigs, existingSigs, func(sig oci.Signature) error {
+		return cosign.SimpleClaimVerifier(sig, h, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("signatures of %s: %w", digest, err)
+	}
+
+	atts, err := se.Attestations()
+	if err != nil {
+		return fmt.Errorf("reading attestations: %w", err)
+	}
+	existingAtts, err := remoteSE.Attestations()
+	if err != nil {
+		return fmt.Errorf("fetching attestations: %w", err)
+	}
+	newAtts, err := missingSignatures(atts, existingAtts, func(att oci.Signature) error {
+		return cosign.IntotoSubjectClaimVerifier(att, h, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("attestations of %s: %w", digest, err)
+	}
+
+	if len(newSigs) > 0 {
+		var signed oci.SignedEntity = remoteSE
+		for _, sig := range newSigs {
+			if signed, err = mutate.AttachSignatureToEntity(signed, sig); err != nil {
+				return err
+			}
+		}
+		if err := ociremote.WriteSignatures(digest.Repository, signed, opts...); err != nil {
+			return fmt.Errorf("writing signatures: %w", err)
+		}
+	}
+	if len(newAtts) > 0 {
+		var attested oci.SignedEntity = remoteSE
+		for _, att := range newAtts {
+			if attested, err = mutate.AttachAttestationToEntity(attested, att); err != nil {
+				return err
+			}
+		}
+		if err := ociremote.WriteAttestations(digest.Repository, attested, opts...); err != nil {
+			return fmt.Errorf("writing attestations: %w", err)
+		}
+	}
+	ui.Infof(ctx, "%s: synced %d signatures and %d attestations", digest, len(newSigs), len(newAtts))
+	return nil
+}
+
+// missingSignatures returns the signatures in local that are not in registry,
+// after checking with verifyDigest that each of them is for the expected
+// digest.
+func missingSignatures(local, registry oci.Signatures, verifyDigest func(oci.Signature) error) ([]oci.Signature, error) {
+	if local == nil {
+		return nil, nil
+	}
+	localSigs, err := local.Get()
+	if err != nil {
+		return nil, err
+	}
+	remoteSigs, err := registry.Get()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(remoteSigs))
+	for _, sig := range remoteSigs {
+		key, err := signatureKey(sig)
+		if err != nil {
+			return nil, err
+		}
+		existing[key] = true
+	}
+
+	var missing []oci.Signature
+	for _, sig := range localSigs {
+		key, err := signatureKey(sig)
+		if err != nil {
+			return nil, err
+		}
+		if existing[key] {
+			continue
+		}
+		if err := verifyDigest(sig); err != nil {
+			return nil, err
+		}
+		existing[key] = true
+		missing = append(missing, sig)
+	}
+	return missing, nil
+}
+
+// signatureKey identifies sig by its payload and signature.
+func signatureKey(sig oci.Signature) (string, error) {
+	d, err := sig.Digest()
+	if err != nil {
+		return "", err
+	}
+	b64sig, err := sig.Base64Signature()
+	if err != nil {
+		return "", err
+	}
+	return d.String() + "\x00" + b64sig, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	sigPayload "github.com/franchb/sigstore/pkg/signature/payload"
+)
+
+func TestSyncCmd(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(u.Host + "/test/image:latest")
+	require.NoError(t, err)
+
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	h, err := img.Digest()
+	require.NoError(t, err)
+	digest := ref.Context().Digest(h.String())
+
+	// writeLayout saves img signed with each of b64sigs, for payloadDigest.
+	writeLayout := func(payloadDigest name.Digest, b64sigs ...string) string {
+		pl, err := (&sigPayload.Cosign{Image: payloadDigest}).MarshalJSON()
+		require.NoError(t, err)
+		si := signed.Image(img)
+		for _, b64sig := range b64sigs {
+			sig, err := static.NewSignature(pl, b64sig)
+			require.NoError(t, err)
+			si, err = mutate.AttachSignatureToImage(si, sig)
+			require.NoError(t, err)
+		}
+		dir := t.TempDir()
+		require.NoError(t, layout.WriteSignedImage(dir, si))
+		return dir
+	}
+	registrySignatures := func() []oci.Signature {
+		sigs, err := ociremote.SignedUnknown(digest).Signatures()
+		require.NoError(t, err)
+		got, err := sigs.Get()
+		require.NoError(t, err)
+		return got
+	}
+	syncDir := func(dir string) error {
+		return SyncCmd(context.Background(), options.SyncOptions{Directory: dir}, ref.String())
+	}
+
+	// The image is pushed along with its signature.
+	require.NoError(t, syncDir(writeLayout(digest, "first")))
+	desc, err := remote.Head(ref)
+	require.NoError(t, err)
+	require.Equal(t, h, desc.Digest)
+	require.Len(t, registrySignatures(), 1)
+
+	// Signatures already in the registry are skipped, new ones are added.
+	require.NoError(t, syncDir(writeLayout(digest, "first", "second")))
+	require.Len(t, registrySignatures(), 2)
+
+	// Signatures for another digest are refused.
+	other, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	otherHash, err := other.Digest()
+	require.NoError(t, err)
+	require.Error(t, syncDir(writeLayout(ref.Context().Digest(otherHash.String()), "third")))
+	require.Len(t, registrySignatures(), 2)
+
+	// The tag must point at the saved image.
+	require.NoError(t, remote.Write(ref, other))
+	require.ErrorContains(t, syncDir(writeLayout(digest, "fourth")), "in the registry")
+}
@@ -17,8 +17,11 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 
@@ -26,6 +29,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 )
 
@@ -33,13 +39,22 @@ func Tree() *cobra.Command {
 	c := &options.TreeOptions{}
 
 	cmd := &cobra.Command{
-		Use:              "tree",
-		Short:            "Display supply chain security related artifacts for an image such as signatures, SBOMs and attestations",
-		Example:          "  cosign tree <IMAGE>",
-		Args:             cobra.ExactArgs(1),
+		Use:   "tree",
+		Short: "Display supply chain security related artifacts for an image such as signatures, SBOMs and attestations",
+		Example: `  cosign tree <IMAGE>
+
+  # list the artifacts as JSON
+  cosign tree --output json <IMAGE>
+
+  # only list the referrers of an artifact type, including those not written by cosign
+  cosign tree --type application/spdx+json <IMAGE>`,
+		Args:             argsOrOutputSchema(&c.OutputSchema, cobra.ExactArgs(1)),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return TreeCmd(cmd.Context(), c.Registry, args[0])
+			if c.OutputSchema.OutputSchema {
+				return printOutputSchema(schema.Tree)
+			}
+			return TreeCmd(cmd.Context(), c.Registry, c.Output, c.Type, args[0])
 		},
 	}
 
@@ -47,8 +62,45 @@ func Tree() *cobra.Command {
 	return cmd
 }
 
-func TreeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef string) error {
-	scsaMap := map[name.Tag][]v1.Layer{}
+// TreeOutput is the JSON output of cosign tree, see schema.Tree.
+type TreeOutput struct {
+	Image     string          `json:"image"`
+	Artifacts []TreeArtifacts `json:"artifacts"`
+}
+
+// TreeArtifacts are the artifacts of one type stored for an image.
+type TreeArtifacts struct {
+	// Type is one of "attestation", "signature", "sbom" or "referrer".
+	Type string `json:"type"`
+	// ArtifactType is the artifact type of a referrer, if it has one.
+	ArtifactType string `json:"artifactType,omitempty"`
+	// Tag is where the artifacts are stored, which is the digest of the
+	// manifest for a referrer.
+	Tag string `json:"tag"`
+	// Layers are the digests of the artifacts.
+	Layers []string `json:"layers"`
+}
+
+// treeReferrer is the type of the artifacts found through the OCI referrers
+// API, whether or not cosign wrote them.
+const treeReferrer = "referrer"
+
+// TreeCmd lists the artifacts stored for imageRef. If artifactType is set,
+// only the artifacts of that type are listed: it is one of "attestation",
+// "signature", "sbom" or "referrer", or the artifact type of referrers.
+func TreeCmd(ctx context.Context, regOpts options.RegistryOptions, output string, artifactType string, imageRef string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
+	switch {
+	case artifactType == "", artifactType == treeReferrer, isArtifactType(artifactType):
+	case artifactType == cosign.Signature, artifactType == cosign.Attestation, artifactType == cosign.SBOM:
+	default:
+		return fmt.Errorf("unsupported type %q, expected attestation, signature, sbom, referrer or an artifact type", artifactType)
+	}
+	wanted := func(typ string) bool {
+		return artifactType == "" || artifactType == typ
+	}
 	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
 	if err != nil {
 		return err
@@ -58,26 +110,45 @@ func TreeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef stri
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(os.Stdout, "📦 Supply Chain Security Related artifacts for an image: %s\n", ref.String())
+	if output == "text" {
+		fmt.Fprintf(os.Stdout, "📦 Supply Chain Security Related artifacts for an image: %s\n", ref.String())
+	}
 
 	simg, err := ociremote.SignedEntity(ref, remoteOpts...)
 	if err != nil {
 		return err
 	}
 
+	tree := TreeOutput{Image: ref.String(), Artifacts: []TreeArtifacts{}}
+	addLayers := func(typ string, t name.Tag, layers []v1.Layer) error {
+		if len(layers) == 0 {
+			return nil
+		}
+		digests := make([]string, 0, len(layers))
+		for _, l := range layers {
+			digest, err := l.Digest()
+			if err != nil {
+				return err
+			}
+			digests = append(digests, digest.String())
+		}
+		tree.Artifacts = append(tree.Artifacts, TreeArtifacts{Type: typ, Tag: t.String(), Layers: digests})
+		return nil
+	}
+
 	attRef, err := ociremote.AttestationTag(ref, remoteOpts...)
 	if err != nil {
 		return err
 	}
 
 	atts, err := simg.Attestations()
-	if err == nil {
+	if err == nil && wanted(cosign.Attestation) {
 		layers, err := atts.Layers()
 		if err != nil {
 			return err
 		}
-		if len(layers) > 0 {
-			scsaMap[attRef] = layers
+		if err := addLayers(cosign.Attestation, attRef, layers); err != nil {
+			return err
 		}
 	}
 
@@ -87,13 +158,13 @@ func TreeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef stri
 	}
 
 	sigs, err := simg.Signatures()
-	if err == nil {
+	if err == nil && wanted(cosign.Signature) {
 		layers, err := sigs.Layers()
 		if err != nil {
 			return err
 		}
-		if len(layers) > 0 {
-			scsaMap[sigRef] = layers
+		if err := addLayers(cosign.Signature, sigRef, layers); err != nil {
+			return err
 		}
 	}
 
@@ -103,54 +174,93 @@ func TreeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef stri
 	}
 
 	sbombs, err := simg.Attachment(ociremote.SBOMTagSuffix)
-	if err == nil {
+	if err == nil && wanted(cosign.SBOM) {
 		layers, err := sbombs.Layers()
 		if err != nil {
 			return err
 		}
-		if len(layers) > 0 {
-			scsaMap[sbomRef] = layers
+		if err := addLayers(cosign.SBOM, sbomRef, layers); err != nil {
+			return err
 		}
 	}
 
-	if len(scsaMap) == 0 {
-		fmt.Fprintf(os.Stdout, "No Supply Chain Security Related Artifacts artifacts found for image %s\n, start creating one with simply running"+
-			"$ cosign sign <img>", ref.String())
-		return nil
+	if artifactType == "" || artifactType == treeReferrer || isArtifactType(artifactType) {
+		filter := artifactType
+		if !isArtifactType(filter) {
+			filter = ""
+		}
+		h, err := simg.Digest()
+		if err != nil {
+			return err
+		}
+		referrers, err := ociremote.ListReferrers(ref.Context().Digest(h.String()), filter, remoteOpts...)
+		if err != nil {
+			ui.Warnf(ctx, "unable to list the referrers of %s: %v", ref, err)
+		}
+		for _, r := range referrers {
+			digests := make([]string, 0, len(r.Layers))
+			for _, l := range r.Layers {
+				digests = append(digests, l.String())
+			}
+			tree.Artifacts = append(tree.Artifacts, TreeArtifacts{
+				Type:         treeReferrer,
+				ArtifactType: r.ArtifactType,
+				Tag:          r.Digest.String(),
+				Layers:       digests,
+			})
+		}
 	}
 
-	for t, k := range scsaMap {
-		switch t {
-		case sigRef:
-			fmt.Fprintf(os.Stdout, "└── 🔐 Signatures for an image tag: %s\n", t.String())
-		case sbomRef:
-			fmt.Fprintf(os.Stdout, "└── 📦 SBOMs for an image tag: %s\n", t.String())
-		case attRef:
-			fmt.Fprintf(os.Stdout, "└── 💾 Attestations for an image tag: %s\n", t.String())
-		}
+	return printTree(os.Stdout, output, tree)
+}
 
-		if err := printLayers(k); err != nil {
+func printTree(w io.Writer, output string, tree TreeOutput) error {
+	if output == "json" {
+		b, err := json.Marshal(tree)
+		if err != nil {
 			return err
 		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
 	}
 
+	if len(tree.Artifacts) == 0 {
+		fmt.Fprintf(w, "No Supply Chain Security Related Artifacts artifacts found for image %s\n, start creating one with simply running"+
+			"$ cosign sign <img>", tree.Image)
+		return nil
+	}
+
+	for _, a := range tree.Artifacts {
+		switch a.Type {
+		case cosign.Signature:
+			fmt.Fprintf(w, "└── 🔐 Signatures for an image tag: %s\n", a.Tag)
+		case cosign.SBOM:
+			fmt.Fprintf(w, "└── 📦 SBOMs for an image tag: %s\n", a.Tag)
+		case cosign.Attestation:
+			fmt.Fprintf(w, "└── 💾 Attestations for an image tag: %s\n", a.Tag)
+		case treeReferrer:
+			fmt.Fprintf(w, "└── 🔗 Referrer of type %q: %s\n", a.ArtifactType, a.Tag)
+		}
+		printLayers(w, a.Layers)
+	}
 	return nil
 }
 
-func printLayers(layers []v1.Layer) error {
-	for i, l := range layers {
-		last := i == len(layers)-1
+func printLayers(w io.Writer, digests []string) {
+	for i, digest := range digests {
+		last := i == len(digests)-1
 		var sym string
 		if last {
 			sym = "   └──"
 		} else {
 			sym = "   ├──"
 		}
-		digest, err := l.Digest()
-		if err != nil {
-			return err
-		}
-		fmt.Printf("%s 🍒 %s\n", sym, digest)
+		fmt.Fprintf(w, "%s 🍒 %s\n", sym, digest)
 	}
-	return nil
+}
+
+// isArtifactType reports whether typ is a media type used as the artifact
+// type of referrers, rather than one of the kinds of artifacts cosign stores.
+func isArtifactType(typ string) bool {
+	return strings.Contains(typ, "/")
 }
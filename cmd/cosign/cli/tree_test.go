@@ -0,0 +1,92 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+)
+
+func TestPrintTree(t *testing.T) {
+	tree := TreeOutput{
+		Image: "example.com/app:latest",
+		Artifacts: []TreeArtifacts{{
+			Type:   cosign.Signature,
+			Tag:    "example.com/app:sha256-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.sig",
+			Layers: []string{"sha256:a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447", "sha256:3fc9b689459d738f8c88a3a48aa9e33542016b7a4052e001aaa536fca74813cb"},
+		}},
+	}
+
+	var b bytes.Buffer
+	if err := printTree(&b, "json", tree); err != nil {
+		t.Fatalf("printTree() = %v", err)
+	}
+	if err := schema.Validate(schema.Tree, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	// No artifacts is an empty array rather than null.
+	b.Reset()
+	if err := printTree(&b, "json", TreeOutput{Image: tree.Image, Artifacts: []TreeArtifacts{}}); err != nil {
+		t.Fatalf("printTree() = %v", err)
+	}
+	if err := schema.Validate(schema.Tree, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	b.Reset()
+	if err := printTree(&b, "text", tree); err != nil {
+		t.Fatalf("printTree() = %v", err)
+	}
+	want := "└── 🔐 Signatures for an image tag: " + tree.Artifacts[0].Tag + "\n" +
+		"   ├── 🍒 " + tree.Artifacts[0].Layers[0] + "\n" +
+		"   └── 🍒 " + tree.Artifacts[0].Layers[1] + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("printTree() = %q, wanted %q", got, want)
+	}
+}
+
+func TestPrintTreeReferrer(t *testing.T) {
+	tree := TreeOutput{
+		Image: "example.com/app:latest",
+		Artifacts: []TreeArtifacts{{
+			Type:         treeReferrer,
+			ArtifactType: "application/spdx+json",
+			Tag:          "example.com/app@sha256:3fc9b689459d738f8c88a3a48aa9e33542016b7a4052e001aaa536fca74813cb",
+			Layers:       []string{"sha256:a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"},
+		}},
+	}
+
+	var b bytes.Buffer
+	if err := printTree(&b, "json", tree); err != nil {
+		t.Fatalf("printTree() = %v", err)
+	}
+	if err := schema.Validate(schema.Tree, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	b.Reset()
+	if err := printTree(&b, "text", tree); err != nil {
+		t.Fatalf("printTree() = %v", err)
+	}
+	want := "└── 🔗 Referrer of type \"application/spdx+json\": " + tree.Artifacts[0].Tag + "\n" +
+		"   └── 🍒 " + tree.Artifacts[0].Layers[0] + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("printTree() = %q, wanted %q", got, want)
+	}
+}
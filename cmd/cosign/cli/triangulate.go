@@ -21,6 +21,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/triangulate"
 )
 
@@ -28,15 +29,24 @@ func Triangulate() *cobra.Command {
 	o := &options.TriangulateOptions{}
 
 	cmd := &cobra.Command{
-		Use:              "triangulate",
-		Short:            "Outputs the located cosign image reference. This is the location where cosign stores the specified artifact type.",
-		Example:          "  cosign triangulate <IMAGE>",
+		Use:   "triangulate",
+		Short: "Outputs the located cosign image reference. This is the location where cosign stores the specified artifact type.",
+		Example: `  cosign triangulate <IMAGE>
+
+  # print the located reference as JSON
+  cosign triangulate --output json <IMAGE>
+
+  # list the referrers of an artifact type, including those not written by cosign
+  cosign triangulate --type application/spdx+json <IMAGE>`,
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.OutputSchema.OutputSchema {
+				return printOutputSchema(schema.Triangulate)
+			}
 			if len(args) != 1 {
 				return flag.ErrHelp
 			}
-			return triangulate.MungeCmd(cmd.Context(), o.Registry, args[0], o.Type)
+			return triangulate.MungeCmd(cmd.Context(), o.Registry, args[0], o.Type, o.Output)
 		},
 	}
 
@@ -17,8 +17,11 @@ package triangulate
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/pkg/cosign"
@@ -26,7 +29,29 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 )
 
-func MungeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef string, attachmentType string) error {
+// Output is the JSON output of cosign triangulate, see schema.Triangulate.
+type Output struct {
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+	// Referrers are the referrers of Reference, for the "referrer" type or an
+	// artifact type.
+	Referrers []Referrer `json:"referrers,omitempty"`
+}
+
+// Referrer is an artifact whose subject is the image, whether or not cosign
+// wrote it.
+type Referrer struct {
+	Reference    string `json:"reference"`
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+// referrer is the type selecting every referrer of the image.
+const referrer = "referrer"
+
+func MungeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef string, attachmentType string, output string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
 	ref, err := name.ParseReference(imageRef, regOpts.NameOptions()...)
 	if err != nil {
 		return err
@@ -54,13 +79,56 @@ func MungeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef str
 	case cosign.Digest:
 		dstRef, err = ociremote.DigestTag(ref, ociremoteOpts...)
 		dstRefName = fmt.Sprint(dstRef.Repository.Name(), "@", dstRef.TagStr())
+	case referrer:
+		return triangulateReferrers(ref, "", attachmentType, output, ociremoteOpts...)
 	default:
+		if strings.Contains(attachmentType, "/") {
+			return triangulateReferrers(ref, attachmentType, attachmentType, output, ociremoteOpts...)
+		}
 		err = fmt.Errorf("unknown attachment type %s", attachmentType)
 	}
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(dstRefName)
-	return nil
+	return printReference(os.Stdout, output, Output{Type: attachmentType, Reference: dstRefName})
+}
+
+// triangulateReferrers prints the referrers of ref of artifactType, or all of
+// them if it is empty.
+func triangulateReferrers(ref name.Reference, artifactType, typ, output string, opts ...ociremote.Option) error {
+	d, err := ociremote.ResolveDigest(ref, opts...)
+	if err != nil {
+		return err
+	}
+	referrers, err := ociremote.ListReferrers(d, artifactType, opts...)
+	if err != nil {
+		return fmt.Errorf("listing referrers: %w", err)
+	}
+	o := Output{Type: typ, Reference: d.String(), Referrers: make([]Referrer, 0, len(referrers))}
+	for _, r := range referrers {
+		o.Referrers = append(o.Referrers, Referrer{Reference: r.Digest.String(), ArtifactType: r.ArtifactType})
+	}
+	return printReference(os.Stdout, output, o)
+}
+
+func printReference(w io.Writer, output string, o Output) error {
+	if output == "json" {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	if o.Referrers != nil {
+		for _, r := range o.Referrers {
+			if _, err := fmt.Fprintln(w, r.Reference); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(w, o.Reference)
+	return err
 }
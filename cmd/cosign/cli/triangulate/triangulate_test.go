@@ -0,0 +1,76 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triangulate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+)
+
+func TestPrintReference(t *testing.T) {
+	o := Output{
+		Type:      cosign.Signature,
+		Reference: "example.com/app:sha256-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.sig",
+	}
+
+	var b bytes.Buffer
+	if err := printReference(&b, "json", o); err != nil {
+		t.Fatalf("printReference() = %v", err)
+	}
+	if err := schema.Validate(schema.Triangulate, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	b.Reset()
+	if err := printReference(&b, "text", o); err != nil {
+		t.Fatalf("printReference() = %v", err)
+	}
+	if got, want := b.String(), o.Reference+"\n"; got != want {
+		t.Errorf("printReference() = %q, wanted %q", got, want)
+	}
+}
+
+func TestPrintReferrers(t *testing.T) {
+	o := Output{
+		Type:      "application/spdx+json",
+		Reference: "example.com/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		Referrers: []Referrer{{
+			Reference:    "example.com/app@sha256:3fc9b689459d738f8c88a3a48aa9e33542016b7a4052e001aaa536fca74813cb",
+			ArtifactType: "application/spdx+json",
+		}, {
+			Reference:    "example.com/app@sha256:a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+			ArtifactType: "application/spdx+json",
+		}},
+	}
+
+	var b bytes.Buffer
+	if err := printReference(&b, "json", o); err != nil {
+		t.Fatalf("printReference() = %v", err)
+	}
+	if err := schema.Validate(schema.Triangulate, b.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	b.Reset()
+	if err := printReference(&b, "text", o); err != nil {
+		t.Fatalf("printReference() = %v", err)
+	}
+	if got, want := b.String(), o.Referrers[0].Reference+"\n"+o.Referrers[1].Reference+"\n"; got != want {
+		t.Errorf("printReference() = %q, wanted %q", got, want)
+	}
+}
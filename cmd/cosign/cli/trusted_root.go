@@ -0,0 +1,154 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/trustroot"
+	"github.com/franchb/sigstore-go/pkg/root"
+	"github.com/spf13/cobra"
+)
+
+func TrustedRoot() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trusted-root",
+		Short: "Provides utilities for distributing the Sigstore trusted root",
+	}
+
+	cmd.AddCommand(trustedRootExport())
+
+	return cmd
+}
+
+func trustedRootExport() *cobra.Command {
+	o := &options.TrustedRootExportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the trust material used for verification in a compact CBOR format",
+		Long: `Export the trust material used for verification in a compact CBOR format.
+
+The Fulcio and timestamp authority certificate chains, the Rekor and CT log public keys and their
+validity periods are read from the same trusted root 'cosign verify' uses, and written as
+deterministic CBOR for embedded verifiers such as bootloaders and edge agents. The same trusted
+root always exports to the same bytes, so devices can be kept in sync by comparing digests.`,
+		Example: `  cosign trusted-root export --out trust.cbor
+
+  # export a private Sigstore deployment's trusted root
+  cosign trusted-root export --trusted-root trusted_root.json --out trust.cbor`,
+		Args:             cobra.NoArgs,
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return TrustedRootExportCmd(cmd.Context(), *o)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+// TrustedRootExportCmd writes the trusted root at opts.TrustedRootPath, or
+// the one distributed through the Sigstore TUF repository, in the compact
+// encoding of package trustroot.
+func TrustedRootExportCmd(_ context.Context, opts options.TrustedRootExportOptions) error {
+	var trustedRoot *root.TrustedRoot
+	var err error
+	if opts.TrustedRootPath != "" {
+		trustedRoot, err = root.NewTrustedRootFromPath(opts.TrustedRootPath)
+	} else {
+		trustedRoot, err = root.FetchTrustedRoot()
+	}
+	if err != nil {
+		return fmt.Errorf("loading trusted root: %w", err)
+	}
+
+	b, err := compactTrustRoot(trustedRoot).MarshalCBOR()
+	if err != nil {
+		return fmt.Errorf("encoding trusted root: %w", err)
+	}
+	if opts.Out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(opts.Out, b, 0600)
+}
+
+// compactTrustRoot converts trustedRoot to the trust material exported by
+// TrustedRootExportCmd.
+func compactTrustRoot(trustedRoot *root.TrustedRoot) *trustroot.TrustRoot {
+	tr := &trustroot.TrustRoot{
+		TransparencyLogs: compactLogs(trustedRoot.RekorLogs()),
+		CTLogs:           compactLogs(trustedRoot.CTLogs()),
+	}
+	for _, ca := range trustedRoot.FulcioCertificateAuthorities() {
+		fca, ok := ca.(*root.FulcioCertificateAuthority)
+		if !ok {
+			continue
+		}
+		tr.CertificateAuthorities = append(tr.CertificateAuthorities, trustroot.Authority{
+			URI:                 fca.URI,
+			Certificates:        chainFromRoot(fca.Root, fca.Intermediates, nil),
+			ValidityPeriodStart: fca.ValidityPeriodStart,
+			ValidityPeriodEnd:   fca.ValidityPeriodEnd,
+		})
+	}
+	for _, ta := range trustedRoot.TimestampingAuthorities() {
+		sta, ok := ta.(*root.SigstoreTimestampingAuthority)
+		if !ok {
+			continue
+		}
+		tr.TimestampAuthorities = append(tr.TimestampAuthorities, trustroot.Authority{
+			URI:                 sta.URI,
+			Certificates:        chainFromRoot(sta.Root, sta.Intermediates, sta.Leaf),
+			ValidityPeriodStart: sta.ValidityPeriodStart,
+			ValidityPeriodEnd:   sta.ValidityPeriodEnd,
+		})
+	}
+	return tr
+}
+
+// chainFromRoot orders the certificates of an authority starting at its
+// root. intermediates are listed closest to the leaf first, as in the
+// trusted root, and leaf may be nil.
+func chainFromRoot(rootCert *x509.Certificate, intermediates []*x509.Certificate, leaf *x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{rootCert}
+	for _, cert := range slices.Backward(intermediates) {
+		chain = append(chain, cert)
+	}
+	if leaf != nil {
+		chain = append(chain, leaf)
+	}
+	return chain
+}
+
+func compactLogs(logs map[string]*root.TransparencyLog) []trustroot.TransparencyLog {
+	compact := make([]trustroot.TransparencyLog, 0, len(logs))
+	for _, tlog := range logs {
+		compact = append(compact, trustroot.TransparencyLog{
+			BaseURL:             tlog.BaseURL,
+			ID:                  tlog.ID,
+			PublicKey:           tlog.PublicKey,
+			ValidityPeriodStart: tlog.ValidityPeriodStart,
+			ValidityPeriodEnd:   tlog.ValidityPeriodEnd,
+		})
+	}
+	return compact
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"crypto/x509"
+	"slices"
+	"testing"
+)
+
+func TestChainFromRoot(t *testing.T) {
+	rootCert := &x509.Certificate{Raw: []byte("root")}
+	inter1 := &x509.Certificate{Raw: []byte("closest to root")}
+	inter2 := &x509.Certificate{Raw: []byte("closest to leaf")}
+	leaf := &x509.Certificate{Raw: []byte("leaf")}
+
+	got := chainFromRoot(rootCert, []*x509.Certificate{inter2, inter1}, leaf)
+	if want := []*x509.Certificate{rootCert, inter1, inter2, leaf}; !slices.Equal(got, want) {
+		t.Errorf("chainFromRoot() = %v, wanted %v", got, want)
+	}
+	got = chainFromRoot(rootCert, nil, nil)
+	if want := []*x509.Certificate{rootCert}; !slices.Equal(got, want) {
+		t.Errorf("chainFromRoot() = %v, wanted %v", got, want)
+	}
+}
@@ -24,8 +24,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/verify"
 	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/embedded"
 )
 
 const ignoreTLogMessage = "Skipping tlog verification is an insecure practice that lacks of transparency and auditability verification for the %s."
@@ -37,7 +40,12 @@ func Verify() *cobra.Command {
 		Use:   "verify",
 		Short: "Verify a signature on the supplied container image",
 		Long: `Verify signature and annotations on an image by checking the claims
-against the transparency log.`,
+against the transparency log.
+
+If the public key is a file, constraints declared for it in a sidecar file
+named after the key with the suffix .constraints.json, e.g.
+cosign.pub.constraints.json, are enforced: the repositories, predicate types
+and expiry the key is valid for.`,
 		Example: `  cosign verify --key <key path>|<key url>|<kms uri> <image uri> [<image uri> ...]
 
   # verify cosign claims and signing certificates on the image with the transparency log
@@ -49,9 +57,20 @@ against the transparency log.`,
   # additionally verify specified annotations
   cosign verify -a key1=val1 -a key2=val2 <IMAGE>
 
+  # additionally compare signed annotations with numbers, timestamps or semantic versions
+  cosign verify --annotation-condition 'build>=42' --annotation-condition 'version>=1.2.0 && version<2.0.0' <IMAGE>
+
   # verify image with an on-disk public key
   cosign verify --key cosign.pub <IMAGE>
 
+  # verify image with a key ring of several public keys or a JSON manifest
+  # of keys with validity windows, accepting signatures by any currently
+  # valid key while rotating signing keys
+  cosign verify --key keyring.json <IMAGE>
+
+  # only accept the image once two of the keys of a key ring have signed it
+  cosign verify --key keyring.pub --require-signatures 2 <IMAGE>
+
   # verify image with an on-disk public key, manually specifying the
   # signature digest algorithm
   cosign verify --key cosign.pub --signature-digest-algorithm sha512 <IMAGE>
@@ -59,6 +78,28 @@ against the transparency log.`,
   # verify image with an on-disk signed image from 'cosign save'
   cosign verify --key cosign.pub --local-image <PATH>
 
+  # verify the image for a single platform of a multi-arch index
+  cosign verify --key cosign.pub --platform linux/arm64 <IMAGE>
+
+  # verify a Helm chart in an OCI registry by its provenance attestation,
+  # which must name the chart archive as a subject
+  cosign verify --key cosign.pub helm://<REGISTRY>/<REPO>/<CHART>:<VERSION>
+
+  # verify that a WebAssembly module pulled by a wasm runtime is the one signed
+  cosign verify --key cosign.pub --wasm-module hello.wasm <IMAGE URI>
+
+  # in CI, only verify the images that changed since the last run under the
+  # same policy and trust root
+  cosign verify --key cosign.pub --changed-since verify-state.json <IMAGE_1> <IMAGE_2> ...
+
+  # verify an image, reading it and its signatures through the mirrors
+  # configured in mirrors.json
+  cosign verify --key cosign.pub --registry-mirror-config mirrors.json <IMAGE>
+
+  # verify keylessly, reporting a hash of the signer's identity instead of
+  # the identity itself so that the report can be shared
+  cosign verify --certificate-identity <IDENTITY> --certificate-oidc-issuer <ISSUER> --redact subject=hash <IMAGE>
+
   # verify image with local certificate and certificate chain
   cosign verify --cert cosign.crt --cert-chain chain.crt <IMAGE>
 
@@ -79,9 +120,28 @@ against the transparency log.`,
   # verify image with public key stored in Google Cloud KMS
   cosign verify --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] <IMAGE>
 
+  # verify image signed with a KMS key and a Fulcio certificate for it, pinning both the key and the identity
+  cosign verify --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] --certificate-identity=name@example.com --certificate-oidc-issuer=https://accounts.example.com <IMAGE>
+
+  # verify image with public key stored in Oracle Cloud Infrastructure Vault
+  cosign verify --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <IMAGE>
+
+  # verify image with public key of an AWS KMS alias, or of the replica in the current Region of a multi-Region key
+  cosign verify --key awskms:///alias/[ALIAS] <IMAGE>
+  cosign verify --key awskms:///arn:aws:kms:[REGION]:[ACCOUNT]:key/mrk-[KEY_ID] <IMAGE>
+
+  # verify image with public key stored in Alibaba Cloud KMS
+  cosign verify --key alibabakms://[REGION]/[KEY_ID] <IMAGE>
+
+  # verify image with public key stored in Tencent Cloud KMS
+  cosign verify --key tencentkms://[REGION]/[KEY_ID] <IMAGE>
+
   # verify image with public key stored in Hashicorp Vault
   cosign verify --key hashivault://[KEY] <IMAGE>
 
+  # verify image with version [VERSION] of a public key stored in Hashicorp Vault
+  cosign verify --key hashivault://[KEY]/versions/[VERSION] <IMAGE>
+
   # verify image with public key stored in a Kubernetes secret
   cosign verify --key k8s://[NAMESPACE]/[KEY] <IMAGE>
 
@@ -89,11 +149,17 @@ against the transparency log.`,
   cosign verify --key gitlab://[OWNER]/[PROJECT_NAME] <IMAGE>
 
   # verify image with public key stored in GitLab with project id
-  cosign verify --key gitlab://[PROJECT_ID] <IMAGE>`,
+  cosign verify --key gitlab://[PROJECT_ID] <IMAGE>
 
-		Args:             cobra.MinimumNArgs(1),
+  # print the JSON Schema of the output
+  cosign verify --output-schema`,
+
+		Args:             argsOrOutputSchema(&o.OutputSchema, cobra.MinimumNArgs(1)),
 		PersistentPreRun: options.BindViper,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.OutputSchema.OutputSchema {
+				return printOutputSchema(schema.Verify)
+			}
 			if o.CommonVerifyOptions.PrivateInfrastructure {
 				o.CommonVerifyOptions.IgnoreTlog = true
 			}
@@ -102,12 +168,21 @@ against the transparency log.`,
 			if err != nil {
 				return err
 			}
+			annotationConditions, err := cosign.ParseAnnotationConditions(o.AnnotationConditions)
+			if err != nil {
+				return err
+			}
 
 			hashAlgorithm, err := o.SignatureDigest.HashAlgorithm()
 			if err != nil {
 				return err
 			}
 
+			redactions, err := o.Redact.Redactions()
+			if err != nil {
+				return err
+			}
+
 			v := &verify.VerifyCommand{
 				RegistryOptions:              o.Registry,
 				CertVerifyOptions:            o.CertVerify,
@@ -128,22 +203,39 @@ against the transparency log.`,
 				Slot:                         o.SecurityKey.Slot,
 				Output:                       o.Output,
 				RekorURL:                     o.Rekor.URL,
+				AdditionalRekorURLs:          o.Rekor.AdditionalURLs,
 				Attachment:                   o.Attachment,
 				Annotations:                  annotations,
+				AnnotationConditions:         annotationConditions,
+				Redactions:                   redactions,
 				HashAlgorithm:                hashAlgorithm,
 				SignatureRef:                 o.SignatureRef,
 				PayloadRef:                   o.PayloadRef,
 				LocalImage:                   o.LocalImage,
+				Recursive:                    o.Recursive,
+				Platform:                     o.Platform,
+				CheckTagDigest:               o.CheckTagDigest,
 				Offline:                      o.CommonVerifyOptions.Offline,
 				TSACertChainPath:             o.CommonVerifyOptions.TSACertChainPath,
 				IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
 				MaxWorkers:                   o.CommonVerifyOptions.MaxWorkers,
+				RequiredSignatures:           o.RequireSignatures,
 				ExperimentalOCI11:            o.CommonVerifyOptions.ExperimentalOCI11,
+				SignatureMirrors:             o.SignatureMirrors.Mirrors,
+				RegistryMirrorConfig:         o.RegistryMirrors.Config,
+				SignatureCacheDir:            o.SignatureCache.Dir,
+				SignatureCacheTTL:            o.SignatureCache.TTL,
+				ChangedSince:                 o.ChangedSince,
+				HelmProvenanceType:           o.HelmProvenanceType,
+				WasmModule:                   o.WasmModule,
 			}
 
 			if o.CommonVerifyOptions.MaxWorkers == 0 {
 				return fmt.Errorf("please set the --max-worker flag to a value that is greater than 0")
 			}
+			if o.RequireSignatures < 1 {
+				return fmt.Errorf("please set the --require-signatures flag to a value that is greater than 0")
+			}
 
 			if o.Registry.AllowInsecure {
 				v.NameOptions = append(v.NameOptions, name.Insecure)
@@ -171,7 +263,12 @@ func VerifyAttestation() *cobra.Command {
 		Use:   "verify-attestation",
 		Short: "Verify an attestation on the supplied container image",
 		Long: `Verify an attestation on an image by checking the claims
-against the transparency log.`,
+against the transparency log.
+
+If the public key is a file, constraints declared for it in a sidecar file
+named after the key with the suffix .constraints.json, e.g.
+cosign.pub.constraints.json, are enforced: the repositories, predicate types
+and expiry the key is valid for.`,
 		Example: `  cosign verify-attestation --key <key path>|<key url>|<kms uri> <image uri> [<image uri> ...]
 
   # verify cosign attestations on the image against the transparency log
@@ -189,12 +286,30 @@ against the transparency log.`,
   # verify image attestations with an on-disk signed image from 'cosign save'
   cosign verify-attestation --key cosign.pub --local-image <PATH>
 
+  # verify that the newest matching attestation was made within the last week
+  cosign verify-attestation --key cosign.pub --type slsaprovenance --max-attestation-age 168h <IMAGE>
+
+  # require provenance attestations by two distinct builders of the organization
+  cosign verify-attestation --type slsaprovenance --require-signatures 2 --certificate-identity-regexp '^https://github.com/org/' --certificate-oidc-issuer https://token.actions.githubusercontent.com <IMAGE>
+
   # verify image with public key provided by URL
   cosign verify-attestation --key https://host.for/<FILE> <IMAGE>
 
   # verify image with public key stored in Google Cloud KMS
   cosign verify-attestation --key gcpkms://projects/<PROJECT>/locations/global/keyRings/<KEYRING>/cryptoKeys/<KEY> <IMAGE>
 
+  # verify image attested with a KMS key and a Fulcio certificate for it, pinning both the key and the identity
+  cosign verify-attestation --key gcpkms://projects/<PROJECT>/locations/global/keyRings/<KEYRING>/cryptoKeys/<KEY> --certificate-identity=name@example.com --certificate-oidc-issuer=https://accounts.example.com <IMAGE>
+
+  # verify image with public key stored in Oracle Cloud Infrastructure Vault
+  cosign verify-attestation --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] <IMAGE>
+
+  # verify image with public key stored in Alibaba Cloud KMS
+  cosign verify-attestation --key alibabakms://[REGION]/[KEY_ID] <IMAGE>
+
+  # verify image with public key stored in Tencent Cloud KMS
+  cosign verify-attestation --key tencentkms://[REGION]/[KEY_ID] <IMAGE>
+
   # verify image with public key stored in Hashicorp Vault
   cosign verify-attestation --key hashivault:///<KEY> <IMAGE>
 
@@ -208,7 +323,10 @@ against the transparency log.`,
   cosign verify-attestation --key cosign.pub --type <PREDICATE_TYPE> --policy <REGO_POLICY> <IMAGE>
 
   # verify image with public key and validate attestation based on CUE policy
-  cosign verify-attestation --key cosign.pub --type <PREDICATE_TYPE> --policy <CUE_POLICY> <IMAGE>`,
+  cosign verify-attestation --key cosign.pub --type <PREDICATE_TYPE> --policy <CUE_POLICY> <IMAGE>
+
+  # verify image with public key and validate attestation with an external policy plugin
+  cosign verify-attestation --key cosign.pub --type <PREDICATE_TYPE> --policy-plugin <PLUGIN_EXECUTABLE> <IMAGE>`,
 
 		Args:             cobra.MinimumNArgs(1),
 		PersistentPreRun: options.BindViper,
@@ -217,6 +335,11 @@ against the transparency log.`,
 				o.CommonVerifyOptions.IgnoreTlog = true
 			}
 
+			redactions, err := o.Redact.Redactions()
+			if err != nil {
+				return err
+			}
+
 			v := &verify.VerifyAttestationCommand{
 				RegistryOptions:              o.Registry,
 				CheckClaims:                  o.CheckClaims,
@@ -236,20 +359,37 @@ against the transparency log.`,
 				Sk:                           o.SecurityKey.Use,
 				Slot:                         o.SecurityKey.Slot,
 				Output:                       o.Output,
+				Redactions:                   redactions,
 				RekorURL:                     o.Rekor.URL,
+				AdditionalRekorURLs:          o.Rekor.AdditionalURLs,
 				PredicateType:                o.Predicate.Type,
 				Policies:                     o.Policies,
+				PolicyPlugins:                o.PolicyPlugins,
 				LocalImage:                   o.LocalImage,
+				Platform:                     o.Platform,
+				CheckTagDigest:               o.CheckTagDigest,
+				AttestationIndex:             o.AttestationIndex,
+				MaxAttestationAge:            o.MaxAttestationAge,
+				StatementTime:                o.StatementTime,
 				NameOptions:                  o.Registry.NameOptions(),
 				Offline:                      o.CommonVerifyOptions.Offline,
 				TSACertChainPath:             o.CommonVerifyOptions.TSACertChainPath,
 				IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
 				MaxWorkers:                   o.CommonVerifyOptions.MaxWorkers,
+				RequiredSignatures:           o.RequireSignatures,
+				ExperimentalOCI11:            o.CommonVerifyOptions.ExperimentalOCI11,
+				SignatureMirrors:             o.SignatureMirrors.Mirrors,
+				RegistryMirrorConfig:         o.RegistryMirrors.Config,
+				SignatureCacheDir:            o.SignatureCache.Dir,
+				SignatureCacheTTL:            o.SignatureCache.TTL,
 			}
 
 			if o.CommonVerifyOptions.MaxWorkers == 0 {
 				return fmt.Errorf("please set the --max-worker flag to a value that is greater than 0")
 			}
+			if o.RequireSignatures < 1 {
+				return fmt.Errorf("please set the --require-signatures flag to a value that is greater than 0")
+			}
 
 			ctx, cancel := context.WithTimeout(cmd.Context(), ro.Timeout)
 			defer cancel()
@@ -301,12 +441,24 @@ The blob may be specified as a path to a file or - for stdin.`,
   # Verify a signature against Azure Key Vault
   cosign verify-blob --key azurekms://[VAULT_NAME][VAULT_URI]/[KEY] --signature $sig <blob>
 
+  # Verify a signature against version [VERSION] of a key in Azure Key Vault
+  cosign verify-blob --key azurekms://[VAULT_NAME][VAULT_URI]/[KEY]/[VERSION] --signature $sig <blob>
+
   # Verify a signature against AWS KMS
   cosign verify-blob --key awskms://[ENDPOINT]/[ID/ALIAS/ARN] --signature $sig <blob>
 
   # Verify a signature against Google Cloud KMS
   cosign verify-blob --key gcpkms://projects/[PROJECT ID]/locations/[LOCATION]/keyRings/[KEYRING]/cryptoKeys/[KEY] --signature $sig <blob>
 
+  # Verify a signature against Oracle Cloud Infrastructure Vault
+  cosign verify-blob --key ocikms://[VAULT_CRYPTO_ENDPOINT]/[KEY_OCID] --signature $sig <blob>
+
+  # Verify a signature against Alibaba Cloud KMS
+  cosign verify-blob --key alibabakms://[REGION]/[KEY_ID] --signature $sig <blob>
+
+  # Verify a signature against Tencent Cloud KMS
+  cosign verify-blob --key tencentkms://[REGION]/[KEY_ID] --signature $sig <blob>
+
   # Verify a signature against Hashicorp Vault
   cosign verify-blob --key hashivault://[KEY] --signature $sig <blob>
 
@@ -318,6 +470,15 @@ The blob may be specified as a path to a file or - for stdin.`,
 
   # Verify a signature against a certificate
   cosign verify-blob --certificate <cert> --signature $sig <blob>
+
+  # Verify an SSH signature, made with ssh-keygen -Y sign or cosign sign-blob --key ssh://, against an allowed signers file
+  cosign verify-blob --key ssh://~/.ssh/allowed_signers --ssh-identity <PRINCIPAL> --signature <blob>.sig <blob>
+
+  # Verify a signature made with minisign, signify or cosign sign-blob --key minisign://
+  cosign verify-blob --key minisign://minisign.pub --signature <blob>.minisig <blob>
+
+  # Verify an OpenPGP detached signature, made with gpg --detach-sign or cosign sign-blob --key gpg://, against a keyring exported with gpg --export
+  cosign verify-blob --key gpg://release-keys.asc --signature <blob>.asc <blob>
 `,
 
 		Args:             cobra.ExactArgs(1),
@@ -332,10 +493,12 @@ The blob may be specified as a path to a file or - for stdin.`,
 				Sk:                   o.SecurityKey.Use,
 				Slot:                 o.SecurityKey.Slot,
 				RekorURL:             o.Rekor.URL,
+				AdditionalRekorURLs:  o.Rekor.AdditionalURLs,
 				BundlePath:           o.BundlePath,
 				NewBundleFormat:      o.NewBundleFormat,
 				RFC3161TimestampPath: o.RFC3161TimestampPath,
 				TSACertChainPath:     o.CommonVerifyOptions.TSACertChainPath,
+				SSHNamespace:         o.SSHNamespace,
 			}
 			verifyBlobCmd := &verify.VerifyBlobCmd{
 				KeyOpts:                      ko,
@@ -356,6 +519,7 @@ The blob may be specified as a path to a file or - for stdin.`,
 				Offline:                      o.CommonVerifyOptions.Offline,
 				IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
 				UseSignedTimestamps:          o.CommonVerifyOptions.UseSignedTimestamps,
+				SSHIdentity:                  o.SSHIdentity,
 			}
 
 			ctx, cancel := context.WithTimeout(cmd.Context(), ro.Timeout)
@@ -373,6 +537,78 @@ The blob may be specified as a path to a file or - for stdin.`,
 	return cmd
 }
 
+func VerifyBinary() *cobra.Command {
+	o := &options.VerifyBinaryOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify-binary",
+		Short: "Verify the bundle embedded in the supplied executable",
+		Long: `Verify the sigstore bundle embedded in an ELF, Mach-O or PE executable.
+
+The bundle is read from the section reserved for it (` + embedded.ELFSection + ` in ELF, ` + embedded.MachOSection + ` in Mach-O
+and ` + embedded.PESection + ` in PE files) and verified against the executable with that section zeroed,
+as it was when it was signed. See cosign attach binary-signature.`,
+		Example: `  cosign verify-binary (--key <key path>|<key url>|<kms uri>) <executable>
+
+  # verify an executable signed keyless
+  cosign verify-binary --certificate-identity=name@example.com --certificate-oidc-issuer=https://accounts.example.com ./tool
+
+  # verify an executable whose embedded bundle is in the new bundle format
+  cosign verify-binary --new-bundle-format --key cosign.pub ./tool`,
+
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.CommonVerifyOptions.PrivateInfrastructure {
+				o.CommonVerifyOptions.IgnoreTlog = true
+			}
+
+			ko := options.KeyOpts{
+				KeyRef:              o.Key,
+				Sk:                  o.SecurityKey.Use,
+				Slot:                o.SecurityKey.Slot,
+				RekorURL:            o.Rekor.URL,
+				AdditionalRekorURLs: o.Rekor.AdditionalURLs,
+				NewBundleFormat:     o.NewBundleFormat,
+				TSACertChainPath:    o.CommonVerifyOptions.TSACertChainPath,
+			}
+			verifyBinaryCmd := &verify.VerifyBinaryCmd{
+				VerifyBlobCmd: verify.VerifyBlobCmd{
+					KeyOpts:                      ko,
+					CertVerifyOptions:            o.CertVerify,
+					CertRef:                      o.CertVerify.Cert,
+					CertChain:                    o.CertVerify.CertChain,
+					CARoots:                      o.CertVerify.CARoots,
+					CAIntermediates:              o.CertVerify.CAIntermediates,
+					TrustedRootPath:              o.TrustedRootPath,
+					CertGithubWorkflowTrigger:    o.CertVerify.CertGithubWorkflowTrigger,
+					CertGithubWorkflowSHA:        o.CertVerify.CertGithubWorkflowSha,
+					CertGithubWorkflowName:       o.CertVerify.CertGithubWorkflowName,
+					CertGithubWorkflowRepository: o.CertVerify.CertGithubWorkflowRepository,
+					CertGithubWorkflowRef:        o.CertVerify.CertGithubWorkflowRef,
+					IgnoreSCT:                    o.CertVerify.IgnoreSCT,
+					SCTRef:                       o.CertVerify.SCT,
+					Offline:                      o.CommonVerifyOptions.Offline,
+					IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
+					UseSignedTimestamps:          o.CommonVerifyOptions.UseSignedTimestamps,
+				},
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), ro.Timeout)
+			defer cancel()
+
+			if o.CommonVerifyOptions.IgnoreTlog && !o.CommonVerifyOptions.PrivateInfrastructure {
+				ui.Warnf(ctx, fmt.Sprintf(ignoreTLogMessage, "binary"))
+			}
+
+			return verifyBinaryCmd.Exec(ctx, args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
 func VerifyBlobAttestation() *cobra.Command {
 	o := &options.VerifyBlobAttestationOptions{}
 
@@ -389,6 +625,14 @@ The blob may be specified as a path to a file.`,
   # Verify a simple blob attestation with a DSSE style signature
   cosign verify-blob-attestation --key cosign.pub (--signature <sig path>|<sig url>)[path to BLOB]
 
+  # Verify a blob attestation offline with the bundle written by cosign attest-blob --bundle,
+  # which carries the certificate chain, the tlog inclusion proof and any RFC3161 timestamp
+  cosign verify-blob-attestation --key cosign.pub --bundle <bundle path> --offline --timestamp-certificate-chain ts_chain.pem [path to BLOB]
+
+  # Verify the attestation of a directory written by cosign attest-blob --directory,
+  # re-hashing its files, which must be exactly the attested ones
+  cosign verify-blob-attestation --key cosign.pub --bundle dist.bundle --directory ./dist
+
 `,
 
 		Args:             cobra.MaximumNArgs(1),
@@ -403,6 +647,7 @@ The blob may be specified as a path to a file.`,
 				Sk:                   o.SecurityKey.Use,
 				Slot:                 o.SecurityKey.Slot,
 				RekorURL:             o.Rekor.URL,
+				AdditionalRekorURLs:  o.Rekor.AdditionalURLs,
 				BundlePath:           o.BundlePath,
 				NewBundleFormat:      o.NewBundleFormat,
 				RFC3161TimestampPath: o.RFC3161TimestampPath,
@@ -429,9 +674,14 @@ The blob may be specified as a path to a file.`,
 				Offline:                      o.CommonVerifyOptions.Offline,
 				IgnoreTlog:                   o.CommonVerifyOptions.IgnoreTlog,
 				UseSignedTimestamps:          o.CommonVerifyOptions.UseSignedTimestamps,
+				Directory:                    o.Directory,
+			}
+			// A directory replaces the blob.
+			if o.Directory != "" && len(args) > 0 {
+				return fmt.Errorf("a blob cannot be verified with --directory")
 			}
 			// We only use the blob if we are checking claims.
-			if len(args) == 0 && o.CheckClaims {
+			if len(args) == 0 && o.CheckClaims && o.Directory == "" {
 				return fmt.Errorf("no path to blob passed in, run `cosign verify-blob-attestation -h` for more help")
 			}
 			var path string
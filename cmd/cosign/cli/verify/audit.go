@@ -0,0 +1,243 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/pivkey"
+	"github.com/franchb/cosign/v2/pkg/cosign/pkcs11key"
+	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// AuditCommand verifies every signature and attestation of a container
+// image and prints them as a chronological timeline.
+type AuditCommand struct {
+	options.RegistryOptions
+	options.CertVerifyOptions
+	KeyRef              string
+	Sk                  bool
+	Slot                string
+	Output              string
+	RekorURL            string
+	NameOptions         []name.Option
+	Offline             bool
+	TSACertChainPath    string
+	UseSignedTimestamps bool
+	IgnoreTlog          bool
+}
+
+// AuditTimelineEntry is one entry of the timeline printed by AuditCommand in
+// json format.
+type AuditTimelineEntry struct {
+	Time        *time.Time `json:"time,omitempty"`
+	Kind        string     `json:"kind"`
+	Identity    string     `json:"identity,omitempty"`
+	Issuer      string     `json:"issuer,omitempty"`
+	Verified    bool       `json:"verified"`
+	Error       string     `json:"error,omitempty"`
+	NotValidNow string     `json:"notValidNow,omitempty"`
+}
+
+// Exec runs the audit command
+func (c *AuditCommand) Exec(ctx context.Context, images []string) (err error) {
+	if len(images) == 0 {
+		return flag.ErrHelp
+	}
+	if c.Cert != "" || c.SCT != "" {
+		return fmt.Errorf("--certificate and --sct are not supported, the certificate of each signature is audited")
+	}
+
+	co, closeVerifier, err := c.checkOpts(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeVerifier()
+
+	for _, img := range images {
+		ref, err := name.ParseReference(img, c.NameOptions...)
+		if err != nil {
+			return fmt.Errorf("parsing reference: %w", err)
+		}
+		entries, err := cosign.AuditImage(ctx, ref, co)
+		if err != nil {
+			return fmt.Errorf("auditing %s: %w", img, err)
+		}
+		if err := PrintAuditTimeline(os.Stdout, ref.Name(), entries, c.Output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOpts returns the CheckOpts to verify signatures and attestations with,
+// and a function releasing the key they are verified with.
+func (c *AuditCommand) checkOpts(ctx context.Context) (co *cosign.CheckOpts, closeVerifier func(), err error) {
+	closeVerifier = func() {}
+
+	var identities []cosign.Identity
+	if c.KeyRef == "" {
+		identities, err = c.Identities()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ociremoteOpts, err := c.ClientOpts(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("constructing client options: %w", err)
+	}
+
+	co = &cosign.CheckOpts{
+		RegistryClientOpts: ociremoteOpts,
+		IgnoreSCT:          c.IgnoreSCT,
+		Identities:         identities,
+		Offline:            c.Offline,
+		IgnoreTlog:         c.IgnoreTlog,
+	}
+	if err := setIdentityMatching(ctx, c.CertVerifyOptions, co); err != nil {
+		return nil, nil, err
+	}
+
+	if c.TSACertChainPath != "" || c.UseSignedTimestamps {
+		tsaCertificates, err := cosign.GetTSACerts(ctx, c.TSACertChainPath, cosign.GetTufTargets)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to load TSA certificates: %w", err)
+		}
+		co.TSACertificate = tsaCertificates.LeafCert
+		co.AdditionalTSACertificates = tsaCertificates.AdditionalLeafCerts
+		co.TSARootCertificates = tsaCertificates.RootCert
+		co.TSAIntermediateCertificates = tsaCertificates.IntermediateCerts
+	}
+
+	if !c.IgnoreTlog {
+		if c.RekorURL != "" {
+			rekorClient, err := rekor.NewClient(c.RekorURL)
+			if err != nil {
+				return nil, nil, fmt.Errorf("creating Rekor client: %w", err)
+			}
+			co.RekorClient = rekorClient
+		}
+		co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting Rekor public keys: %w", err)
+		}
+	}
+
+	switch {
+	case c.KeyRef != "":
+		co.SigVerifier, err = sigs.PublicKeyFromKeyRef(ctx, c.KeyRef)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading public key: %w", err)
+		}
+		if pkcs11Key, ok := co.SigVerifier.(*pkcs11key.Key); ok {
+			closeVerifier = pkcs11Key.Close
+		}
+	case c.Sk:
+		sk, err := pivkey.GetKeyWithSlot(c.Slot)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening piv token: %w", err)
+		}
+		co.SigVerifier, err = sk.Verifier()
+		if err != nil {
+			sk.Close()
+			return nil, nil, fmt.Errorf("initializing piv token verifier: %w", err)
+		}
+		closeVerifier = sk.Close
+	default:
+		if err := loadCertsKeylessVerification(c.CertChain, c.CARoots, c.CAIntermediates, co); err != nil {
+			return nil, nil, err
+		}
+		if shouldVerifySCT(c.IgnoreSCT, c.KeyRef, c.Sk) {
+			co.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("getting ctlog public keys: %w", err)
+			}
+		}
+	}
+	return co, closeVerifier, nil
+}
+
+// PrintAuditTimeline writes the timeline of entries returned by
+// cosign.AuditImage for img to w in the output format, json or text.
+func PrintAuditTimeline(w io.Writer, img string, entries []cosign.AuditEntry, output string) error {
+	timeline := make([]AuditTimelineEntry, 0, len(entries))
+	for _, e := range entries {
+		te := AuditTimelineEntry{
+			Kind:     "signature",
+			Verified: e.Err == nil,
+		}
+		if e.Attestation {
+			te.Kind = "attestation"
+		}
+		if t := e.Time(); !t.IsZero() {
+			te.Time = &t
+		}
+		if cert, err := e.Signature.Cert(); err == nil && cert != nil {
+			if sans := cryptoutils.GetSubjectAlternateNames(cert); len(sans) > 0 {
+				te.Identity = sans[0]
+			}
+			te.Issuer = (&cosign.CertExtensions{Cert: cert}).GetIssuer()
+		}
+		if e.Err != nil {
+			te.Error = e.Err.Error()
+		}
+		if e.NotValidNow != nil {
+			te.NotValidNow = e.NotValidNow.Error()
+		}
+		timeline = append(timeline, te)
+	}
+
+	if output == "json" {
+		b, err := json.Marshal(timeline)
+		if err != nil {
+			return fmt.Errorf("marshaling timeline: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	fmt.Fprintf(w, "Trust timeline for %s:\n", img)
+	for _, te := range timeline {
+		when := "untimed"
+		if te.Time != nil {
+			when = te.Time.UTC().Format(time.RFC3339)
+		}
+		status := "verified"
+		switch {
+		case !te.Verified:
+			status = "FAILED: " + te.Error
+		case te.NotValidNow != "":
+			status = "verified, but would fail now: " + te.NotValidNow
+		}
+		who := te.Identity
+		if who == "" {
+			who = "-"
+		}
+		fmt.Fprintf(w, "%-20s  %-11s  %s  %s\n", when, te.Kind, who, status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintAuditTimeline(t *testing.T) {
+	sig, err := static.NewSignature([]byte("payload"), "")
+	require.NoError(t, err)
+	signed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []cosign.AuditEntry{{
+		Signature:   sig,
+		Timestamps:  cosign.VerifiedTimestamps{IntegratedTime: &signed},
+		NotValidNow: errors.New("certificate expired"),
+	}, {
+		Attestation: true,
+		Signature:   sig,
+		Err:         errors.New("no matching attestations"),
+	}}
+
+	var text bytes.Buffer
+	require.NoError(t, PrintAuditTimeline(&text, "example.com/image", entries, "text"))
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[1], "2024-01-02T03:04:05Z")
+	require.Contains(t, lines[1], "would fail now: certificate expired")
+	require.Contains(t, lines[2], "untimed")
+	require.Contains(t, lines[2], "FAILED: no matching attestations")
+
+	var js bytes.Buffer
+	require.NoError(t, PrintAuditTimeline(&js, "example.com/image", entries, "json"))
+	require.JSONEq(t, `[
+		{"time": "2024-01-02T03:04:05Z", "kind": "signature", "verified": true, "notValidNow": "certificate expired"},
+		{"kind": "attestation", "verified": false, "error": "no matching attestations"}
+	]`, js.String())
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"os"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+	"github.com/franchb/sigstore/pkg/tuf"
+)
+
+// verificationPolicy is what decides whether an image verifies under a
+// VerifyCommand, besides the keys cosign.PolicyHash reads from CheckOpts.
+type verificationPolicy struct {
+	CertVerify           options.CertVerifyOptions
+	CheckClaims          bool
+	Annotations          map[string]interface{}
+	AnnotationConditions cosign.AnnotationConditions
+	KeyConstraints       *cosign.KeyConstraints
+	Attachment           string
+	SignatureRef         string
+	PayloadRef           string
+	HashAlgorithm        crypto.Hash
+	Platform             string
+	Offline              bool
+	IgnoreTlog           bool
+	UseSignedTimestamps  bool
+	ExperimentalOCI11    bool
+	RequiredSignatures   int `json:",omitempty"`
+	// RootCertificates are the certificates co.RootCerts and
+	// co.IntermediateCerts were loaded from.
+	RootCertificates []byte
+}
+
+// loadVerificationState reads the state file of --changed-since, keeping the
+// images it records only if they were verified under the same policy and
+// trust material as co.
+func (c *VerifyCommand) loadVerificationState(ctx context.Context, co *cosign.CheckOpts) (*cosign.VerificationState, error) {
+	roots, err := c.rootCertificates(ctx, co)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := cosign.PolicyHash(verificationPolicy{
+		CertVerify:           c.CertVerifyOptions,
+		CheckClaims:          c.CheckClaims,
+		Annotations:          c.Annotations.Annotations,
+		AnnotationConditions: c.AnnotationConditions,
+		KeyConstraints:       co.KeyConstraints,
+		Attachment:           c.Attachment,
+		SignatureRef:         c.SignatureRef,
+		PayloadRef:           c.PayloadRef,
+		HashAlgorithm:        c.HashAlgorithm,
+		Platform:             c.Platform,
+		Offline:              c.Offline,
+		IgnoreTlog:           c.IgnoreTlog,
+		UseSignedTimestamps:  c.UseSignedTimestamps,
+		ExperimentalOCI11:    c.ExperimentalOCI11,
+		RequiredSignatures:   c.RequiredSignatures,
+		RootCertificates:     roots,
+	}, co)
+	if err != nil {
+		return nil, err
+	}
+	return cosign.LoadVerificationState(c.ChangedSince, hash)
+}
+
+// rootCertificates returns the certificates co.RootCerts and
+// co.IntermediateCerts were loaded from by loadCertsKeylessVerification,
+// which cannot be read back from the pools.
+func (c *VerifyCommand) rootCertificates(ctx context.Context, co *cosign.CheckOpts) ([]byte, error) {
+	if co.RootCerts == nil {
+		return nil, nil
+	}
+	var paths []string
+	switch {
+	case c.CertChain != "":
+		paths = []string{c.CertChain}
+	case c.CARoots != "":
+		paths = []string{c.CARoots, c.CAIntermediates}
+	case env.Getenv(env.VariableSigstoreRootFile) != "":
+		return os.ReadFile(env.Getenv(env.VariableSigstoreRootFile))
+	default:
+		return cosign.GetTufTargets(ctx, tuf.Fulcio, nil)
+	}
+	var b bytes.Buffer
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		certs, err := loadCertChainFromFileOrURL(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			b.Write(cert.Raw)
+		}
+	}
+	return b.Bytes(), nil
+}
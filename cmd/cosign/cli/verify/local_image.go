@@ -0,0 +1,54 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"fmt"
+
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+)
+
+// openLocalImages returns the layout directory for each of the local images
+// at paths, extracting those that are archives written by
+// 'cosign save --format bundle-tar'. The returned function removes the
+// extracted layouts.
+func openLocalImages(paths []string) ([]string, func(), error) {
+	dirs := make([]string, 0, len(paths))
+	var cleanups []func()
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+	for _, path := range paths {
+		isTar, err := layout.IsTar(path)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("opening local image: %w", err)
+		}
+		if !isTar {
+			dirs = append(dirs, path)
+			continue
+		}
+		dir, c, err := layout.OpenTar(path)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		dirs = append(dirs, dir)
+		cleanups = append(cleanups, c)
+	}
+	return dirs, cleanup, nil
+}
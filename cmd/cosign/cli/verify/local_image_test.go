@@ -0,0 +1,67 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestOpenLocalImages(t *testing.T) {
+	img, err := random.Image(300 /* byteSize */, 1 /* layers */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := layout.WriteSignedImage(dir, signed.Image(img)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := layout.WriteTar(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "image.tar")
+	if err := os.WriteFile(archive, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, cleanup, err := openLocalImages([]string{dir, archive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 2 || dirs[0] != dir || dirs[1] == archive {
+		t.Fatalf("openLocalImages() = %v, wanted %s and an extracted layout", dirs, dir)
+	}
+	if _, err := layout.SignedImageIndex(dirs[1]); err != nil {
+		t.Errorf("reading extracted layout: %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(dirs[1]); !os.IsNotExist(err) {
+		t.Errorf("cleanup() left %s behind", dirs[1])
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("cleanup() removed the layout directory: %v", err)
+	}
+
+	if _, _, err := openLocalImages([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("openLocalImages() succeeded for a missing path")
+	}
+}
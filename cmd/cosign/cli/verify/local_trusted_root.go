@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/sigstore-go/pkg/root"
+	"github.com/franchb/sigstore/pkg/tuf"
+)
+
+// loadLocalTrustedRoot fills in co from the trusted root stored by
+// 'cosign save' in the layouts at paths, if any, so that they can be verified
+// without network access.
+func loadLocalTrustedRoot(paths []string, co *cosign.CheckOpts) error {
+	var rootJSON []byte
+	for _, path := range paths {
+		b, err := layout.TrustedRoot(path)
+		if err != nil {
+			return fmt.Errorf("reading trusted root from %s: %w", path, err)
+		}
+		switch {
+		case b == nil:
+		case rootJSON == nil:
+			rootJSON = b
+		case !bytes.Equal(rootJSON, b):
+			return fmt.Errorf("%s holds a different trusted root than the other local images", path)
+		}
+	}
+	if rootJSON == nil {
+		return nil
+	}
+	rootJSON, witnesses, err := cosign.SplitTrustedRootWitnesses(rootJSON)
+	if err != nil {
+		return fmt.Errorf("parsing trusted root: %w", err)
+	}
+	trustedRoot, err := root.NewTrustedRootFromJSON(rootJSON)
+	if err != nil {
+		return fmt.Errorf("parsing trusted root: %w", err)
+	}
+	if err := applyTrustedRoot(trustedRoot, co); err != nil {
+		return err
+	}
+	co.CheckpointWitnesses = witnesses
+	co.Offline = true
+	return nil
+}
+
+// applyTrustedRoot sets the Fulcio, Rekor, CT log and TSA trust material in
+// co from trustedRoot.
+func applyTrustedRoot(trustedRoot *root.TrustedRoot, co *cosign.CheckOpts) error {
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	for _, ca := range trustedRoot.FulcioCertificateAuthorities() {
+		fca, ok := ca.(*root.FulcioCertificateAuthority)
+		if !ok {
+			continue
+		}
+		roots.AddCert(fca.Root)
+		for _, cert := range fca.Intermediates {
+			intermediates.AddCert(cert)
+		}
+	}
+	co.RootCerts = roots
+	co.IntermediateCerts = intermediates
+
+	var err error
+	if co.RekorPubKeys, err = transparencyLogPubKeys(trustedRoot.RekorLogs()); err != nil {
+		return fmt.Errorf("loading Rekor public keys: %w", err)
+	}
+	if co.CTLogPubKeys, err = transparencyLogPubKeys(trustedRoot.CTLogs()); err != nil {
+		return fmt.Errorf("loading CT log public keys: %w", err)
+	}
+
+	if len(co.TSARootCertificates) == 0 {
+		for _, ta := range trustedRoot.TimestampingAuthorities() {
+			sta, ok := ta.(*root.SigstoreTimestampingAuthority)
+			if !ok {
+				continue
+			}
+			co.TSARootCertificates = append(co.TSARootCertificates, sta.Root)
+			co.TSAIntermediateCertificates = append(co.TSAIntermediateCertificates, sta.Intermediates...)
+		}
+	}
+	return nil
+}
+
+// transparencyLogPubKeys converts logs to the keys used to verify entries,
+// marking logs whose validity period has ended as expired.
+func transparencyLogPubKeys(logs map[string]*root.TransparencyLog) (*cosign.TrustedTransparencyLogPubKeys, error) {
+	keys := cosign.NewTrustedTransparencyLogPubKeys()
+	for _, tlog := range logs {
+		logID := hex.EncodeToString(tlog.ID)
+		if len(tlog.ID) == 0 {
+			var err error
+			if logID, err = cosign.GetTransparencyLogID(tlog.PublicKey); err != nil {
+				return nil, err
+			}
+		}
+		status := tuf.Active
+		if !tlog.ValidityPeriodEnd.IsZero() && tlog.ValidityPeriodEnd.Before(time.Now()) {
+			status = tuf.Expired
+		}
+		keys.Keys[logID] = cosign.TransparencyLogPubKey{
+			PubKey: tlog.PublicKey,
+			Status: status,
+		}
+	}
+	return &keys, nil
+}
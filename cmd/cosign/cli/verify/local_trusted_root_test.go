@@ -0,0 +1,114 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
+	"github.com/franchb/cosign/v2/test"
+	"github.com/franchb/sigstore-go/pkg/root"
+	"github.com/franchb/sigstore/pkg/tuf"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestApplyTrustedRoot(t *testing.T) {
+	rootCert, rootKey, err := test.GenerateRootCa()
+	require.NoError(t, err)
+	subCert, _, err := test.GenerateSubordinateCa(rootCert, rootKey)
+	require.NoError(t, err)
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ctKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	trustedRoot, err := root.NewTrustedRoot(root.TrustedRootMediaType01,
+		[]root.CertificateAuthority{&root.FulcioCertificateAuthority{
+			Root:          rootCert,
+			Intermediates: []*x509.Certificate{subCert},
+		}},
+		map[string]*root.TransparencyLog{"ct": {
+			PublicKey:         ctKey.Public(),
+			ValidityPeriodEnd: time.Now().Add(-time.Hour),
+		}},
+		nil,
+		map[string]*root.TransparencyLog{"rekor": {
+			ID:        []byte{0xab, 0xcd},
+			PublicKey: rekorKey.Public(),
+		}})
+	require.NoError(t, err)
+
+	co := &cosign.CheckOpts{}
+	require.NoError(t, applyTrustedRoot(trustedRoot, co))
+
+	_, err = subCert.Verify(x509.VerifyOptions{Roots: co.RootCerts, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]cosign.TransparencyLogPubKey{
+		"abcd": {PubKey: rekorKey.Public(), Status: tuf.Active},
+	}, co.RekorPubKeys.Keys)
+
+	ctLogID, err := cosign.GetTransparencyLogID(ctKey.Public())
+	require.NoError(t, err)
+	require.Equal(t, map[string]cosign.TransparencyLogPubKey{
+		ctLogID: {PubKey: ctKey.Public(), Status: tuf.Expired},
+	}, co.CTLogPubKeys.Keys)
+}
+
+func TestLoadLocalTrustedRoot(t *testing.T) {
+	writeLayout := func(trustedRoot string) string {
+		img, err := random.Image(10, 1)
+		require.NoError(t, err)
+		dir := t.TempDir()
+		require.NoError(t, layout.WriteSignedImage(dir, signed.Image(img)))
+		if trustedRoot != "" {
+			require.NoError(t, layout.WriteTrustedRoot(dir, []byte(trustedRoot)))
+		}
+		return dir
+	}
+	withoutRoot := writeLayout("")
+	withRoot := writeLayout(`{"mediaType":"application/vnd.dev.sigstore.trustedroot+json;version=0.1"}`)
+	otherRoot := writeLayout(`{"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1"}`)
+
+	co := &cosign.CheckOpts{}
+	require.NoError(t, loadLocalTrustedRoot([]string{withoutRoot}, co))
+	require.False(t, co.Offline)
+	require.Nil(t, co.RootCerts)
+
+	co = &cosign.CheckOpts{}
+	require.NoError(t, loadLocalTrustedRoot([]string{withoutRoot, withRoot}, co))
+	require.True(t, co.Offline)
+	require.NotNil(t, co.RootCerts)
+	require.NotNil(t, co.RekorPubKeys)
+
+	co = &cosign.CheckOpts{}
+	require.Error(t, loadLocalTrustedRoot([]string{withRoot, otherRoot}, co))
+
+	_, vkey, err := note.GenerateKey(rand.Reader, "witness.example.com")
+	require.NoError(t, err)
+	withWitnesses := writeLayout(`{"mediaType":"application/vnd.dev.sigstore.trustedroot+json;version=0.1","checkpointWitnesses":{"witnesses":["` + vkey + `"]}}`)
+	co = &cosign.CheckOpts{}
+	require.NoError(t, loadLocalTrustedRoot([]string{withWitnesses}, co))
+	require.NotNil(t, co.CheckpointWitnesses)
+	require.Equal(t, []string{vkey}, co.CheckpointWitnesses.Keys)
+}
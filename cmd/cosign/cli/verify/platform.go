@@ -0,0 +1,66 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/platform"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+)
+
+// verifyFunc verifies the signatures or attestations of ref, such as
+// cosign.VerifyImageSignatures.
+type verifyFunc func(context.Context, name.Reference, *cosign.CheckOpts) ([]oci.Signature, bool, error)
+
+// resolvePlatform returns the digest of the image for platform within the
+// multiarch index ref points to.
+func resolvePlatform(ctx context.Context, ref name.Reference, platformSpec string, opts ...ociremote.Option) (name.Digest, error) {
+	se, err := ociremote.SignedEntity(ref, opts...)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("fetching %s: %w", ref, err)
+	}
+	h, err := platform.DigestForPlatform(se, platformSpec)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("resolving platform %s of %s: %w", platformSpec, ref, err)
+	}
+	child := ref.Context().Digest(h.String())
+	ui.Infof(ctx, "platform %s of %s resolved to %s", platformSpec, ref, child)
+	return child, nil
+}
+
+// verifyPlatform verifies the image for platformSpec within the index ref
+// points to using verify. If that image fails to verify but the index
+// itself verifies, the error says that only the index is signed.
+func verifyPlatform(ctx context.Context, ref name.Reference, platformSpec string, co *cosign.CheckOpts, verify verifyFunc) (name.Digest, []oci.Signature, bool, error) {
+	child, err := resolvePlatform(ctx, ref, platformSpec, co.RegistryClientOpts...)
+	if err != nil {
+		return name.Digest{}, nil, false, err
+	}
+	verified, bundleVerified, err := verify(ctx, child, co)
+	if err == nil {
+		return child, verified, bundleVerified, nil
+	}
+	if _, _, ierr := verify(ctx, ref, co); ierr == nil {
+		return name.Digest{}, nil, false, fmt.Errorf("only the index %s is signed, not the image %s for platform %s: %w", ref, child, platformSpec, err)
+	}
+	return name.Digest{}, nil, false, err
+}
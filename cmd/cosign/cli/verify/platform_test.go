@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+func TestVerifyPlatform(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+
+	var ii v1.ImageIndex = empty.Index
+	digests := map[string]v1.Hash{}
+	for _, p := range []string{"linux/amd64", "linux/arm64"} {
+		img, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		if digests[p], err = img.Digest(); err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		plat, err := v1.ParsePlatform(p)
+		if err != nil {
+			t.Fatalf("ParsePlatform() = %v", err)
+		}
+		ii = mutate.AppendManifests(ii, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: plat},
+		})
+	}
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/repo:latest")
+	if err != nil {
+		t.Fatalf("ParseReference() = %v", err)
+	}
+	if err := remote.WriteIndex(ref, ii); err != nil {
+		t.Fatalf("WriteIndex() = %v", err)
+	}
+
+	// signedVerifier verifies only the references in signed.
+	errUnsigned := errors.New("no signatures found")
+	signedVerifier := func(signed ...string) verifyFunc {
+		return func(_ context.Context, ref name.Reference, _ *cosign.CheckOpts) ([]oci.Signature, bool, error) {
+			for _, s := range signed {
+				if ref.String() == s {
+					return []oci.Signature{}, true, nil
+				}
+			}
+			return nil, false, fmt.Errorf("verifying %s: %w", ref, errUnsigned)
+		}
+	}
+	child := ref.Context().Digest(digests["linux/arm64"].String())
+
+	got, _, bundleVerified, err := verifyPlatform(context.Background(), ref, "linux/arm64", &cosign.CheckOpts{}, signedVerifier(child.String()))
+	if err != nil {
+		t.Fatalf("verifyPlatform() = %v", err)
+	}
+	if got != child || !bundleVerified {
+		t.Errorf("verifyPlatform() = %s, %v, wanted %s, true", got, bundleVerified, child)
+	}
+
+	_, _, _, err = verifyPlatform(context.Background(), ref, "linux/arm64", &cosign.CheckOpts{}, signedVerifier(ref.String()))
+	if err == nil || !strings.Contains(err.Error(), "only the index") || !errors.Is(err, errUnsigned) {
+		t.Errorf("verifyPlatform() = %v, wanted an error saying only the index is signed", err)
+	}
+
+	_, _, _, err = verifyPlatform(context.Background(), ref, "linux/arm64", &cosign.CheckOpts{}, signedVerifier())
+	if err == nil || strings.Contains(err.Error(), "only the index") {
+		t.Errorf("verifyPlatform() = %v, wanted the image's verification error", err)
+	}
+
+	if _, _, _, err := verifyPlatform(context.Background(), ref, "linux/s390x", &cosign.CheckOpts{}, signedVerifier(ref.String())); err == nil {
+		t.Error("verifyPlatform() succeeded for a platform missing from the index")
+	}
+}
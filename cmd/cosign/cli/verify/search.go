@@ -0,0 +1,134 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/policy"
+	"github.com/franchb/cosign/v2/pkg/policy/query"
+)
+
+// SearchAttestationsCommand lists the images in a repository with a verified
+// attestation whose predicate matches a query. Attestations are verified as
+// AuditCommand verifies them.
+type SearchAttestationsCommand struct {
+	AuditCommand
+	PredicateType string
+	Query         string
+}
+
+// SearchResult is an image found by SearchAttestationsCommand.
+type SearchResult struct {
+	Image string   `json:"image"`
+	Tags  []string `json:"tags"`
+}
+
+// Exec runs the search attestations command
+func (c *SearchAttestationsCommand) Exec(ctx context.Context, repository string) error {
+	if c.Output != "text" && c.Output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", c.Output)
+	}
+	q, err := query.Parse(c.Query)
+	if err != nil {
+		return err
+	}
+	repo, err := name.NewRepository(repository, c.NameOptions...)
+	if err != nil {
+		return fmt.Errorf("parsing repository: %w", err)
+	}
+
+	co, closeVerifier, err := c.checkOpts(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeVerifier()
+	co.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+
+	listed, err := ociremote.ListDigests(repo, co.RegistryClientOpts...)
+	if err != nil {
+		return fmt.Errorf("listing %s: %w", repo, err)
+	}
+	results := []SearchResult{}
+	for _, td := range listed {
+		atts, _, err := cosign.VerifyImageAttestations(ctx, td.Digest, co)
+		if err != nil {
+			ui.Infof(ctx, "skipping %s: %v", td.Digest, err)
+			continue
+		}
+		matched, err := matchAttestations(ctx, atts, c.PredicateType, q)
+		if err != nil {
+			ui.Warnf(ctx, "skipping %s: %v", td.Digest, err)
+			continue
+		}
+		if matched {
+			results = append(results, SearchResult{Image: td.Digest.String(), Tags: td.Tags})
+		}
+	}
+	return printSearchResults(os.Stdout, results, c.Output)
+}
+
+// matchAttestations reports whether the predicate of any of the verified
+// attestations atts of predicateType matches q.
+func matchAttestations(ctx context.Context, atts []oci.Signature, predicateType string, q *query.Query) (bool, error) {
+	for _, att := range atts {
+		payload, _, err := policy.AttestationToPayloadJSON(ctx, predicateType, att)
+		if err != nil {
+			return false, err
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		var statement struct {
+			Predicate interface{} `json:"predicate"`
+		}
+		if err := json.Unmarshal(payload, &statement); err != nil {
+			return false, err
+		}
+		if q.Match(statement.Predicate) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// printSearchResults writes results to w in the output format, json or text.
+func printSearchResults(w io.Writer, results []SearchResult, output string) error {
+	if output == "json" {
+		b, err := json.Marshal(results)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	for _, r := range results {
+		if _, err := fmt.Fprintf(w, "%s (%s)\n", r.Image, strings.Join(r.Tags, ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
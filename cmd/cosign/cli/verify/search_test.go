@@ -0,0 +1,82 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/pkg/policy/query"
+)
+
+// testAttestation returns an unsigned attestation of predicate with
+// predicateType.
+func testAttestation(t *testing.T, predicateType, predicate string) oci.Signature {
+	t.Helper()
+	statement := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"` + predicateType + `","subject":[],"predicate":` + predicate + `}`
+	env, err := json.Marshal(map[string]interface{}{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString([]byte(statement)),
+		"signatures":  []interface{}{},
+	})
+	require.NoError(t, err)
+	att, err := static.NewAttestation(env)
+	require.NoError(t, err)
+	return att
+}
+
+func TestMatchAttestations(t *testing.T) {
+	ctx := context.Background()
+	q, err := query.Parse(`.packages[] | .name == "openssl" and (.versionInfo | startswith("3.0."))`)
+	require.NoError(t, err)
+
+	old := testAttestation(t, "https://spdx.dev/Document", `{"packages":[{"name":"openssl","versionInfo":"1.1.1w"}]}`)
+	current := testAttestation(t, "https://spdx.dev/Document", `{"packages":[{"name":"zlib","versionInfo":"1.3.1"},{"name":"openssl","versionInfo":"3.0.13"}]}`)
+	otherType := testAttestation(t, "https://cyclonedx.org/bom", `{"packages":[{"name":"openssl","versionInfo":"3.0.13"}]}`)
+
+	matched, err := matchAttestations(ctx, []oci.Signature{old, current}, "spdxjson", q)
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	matched, err = matchAttestations(ctx, []oci.Signature{old, otherType}, "spdxjson", q)
+	require.NoError(t, err)
+	require.False(t, matched, "only attestations of the requested predicate type are searched")
+}
+
+func TestPrintSearchResults(t *testing.T) {
+	results := []SearchResult{{
+		Image: "example.com/app@sha256:abc",
+		Tags:  []string{"latest", "v1"},
+	}}
+
+	var text bytes.Buffer
+	require.NoError(t, printSearchResults(&text, results, "text"))
+	require.Equal(t, "example.com/app@sha256:abc (latest, v1)\n", text.String())
+
+	var js bytes.Buffer
+	require.NoError(t, printSearchResults(&js, results, "json"))
+	require.JSONEq(t, `[{"image": "example.com/app@sha256:abc", "tags": ["latest", "v1"]}]`, js.String())
+
+	js.Reset()
+	require.NoError(t, printSearchResults(&js, []SearchResult{}, "json"))
+	require.JSONEq(t, `[]`, js.String())
+}
@@ -27,6 +27,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/fulcio"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
@@ -38,7 +40,10 @@ import (
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/pivkey"
 	"github.com/franchb/cosign/v2/pkg/cosign/pkcs11key"
+	"github.com/franchb/cosign/v2/pkg/helm"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/cache"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 	sigs "github.com/franchb/cosign/v2/pkg/signature"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/franchb/sigstore/pkg/signature"
@@ -68,20 +73,34 @@ type VerifyCommand struct {
 	Sk                           bool
 	Slot                         string
 	Output                       string
+	Redactions                   cosign.Redactions
 	RekorURL                     string
+	AdditionalRekorURLs          []string
 	Attachment                   string
 	Annotations                  sigs.AnnotationsMap
+	AnnotationConditions         cosign.AnnotationConditions
 	SignatureRef                 string
 	PayloadRef                   string
 	HashAlgorithm                crypto.Hash
 	LocalImage                   bool
+	Recursive                    bool
+	Platform                     string
+	CheckTagDigest               bool
 	NameOptions                  []name.Option
 	Offline                      bool
 	TSACertChainPath             string
 	UseSignedTimestamps          bool
 	IgnoreTlog                   bool
 	MaxWorkers                   int
+	RequiredSignatures           int
 	ExperimentalOCI11            bool
+	SignatureMirrors             []string
+	RegistryMirrorConfig         string
+	SignatureCacheDir            string
+	SignatureCacheTTL            time.Duration
+	ChangedSince                 string
+	HelmProvenanceType           string
+	WasmModule                   string
 }
 
 func (c *VerifyCommand) loadTSACertificates(ctx context.Context) (*cosign.TSACertificates, error) {
@@ -100,6 +119,9 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 	if len(images) == 0 {
 		return flag.ErrHelp
 	}
+	if slices.ContainsFunc(images, helm.IsReference) {
+		return c.verifyHelmCharts(ctx, images)
+	}
 
 	switch c.Attachment {
 	case "sbom":
@@ -109,14 +131,24 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 	default:
 		return flag.ErrHelp
 	}
+	if c.Platform != "" && (c.LocalImage || c.Attachment != "") {
+		return fmt.Errorf("--platform cannot be used with --local-image or --attachment")
+	}
+	if c.ChangedSince != "" && c.LocalImage {
+		return fmt.Errorf("--changed-since cannot be used with --local-image")
+	}
+	if c.WasmModule != "" && (c.LocalImage || c.Attachment != "" || c.Platform != "") {
+		return fmt.Errorf("--wasm-module cannot be used with --local-image, --attachment or --platform")
+	}
 
 	// always default to sha256 if the algorithm hasn't been explicitly set
 	if c.HashAlgorithm == 0 {
 		c.HashAlgorithm = crypto.SHA256
 	}
 
+	certifiedKey := certifiedKeyVerification(c.KeyRef, c.CertVerifyOptions)
 	var identities []cosign.Identity
-	if c.KeyRef == "" {
+	if c.KeyRef == "" || certifiedKey {
 		identities, err = c.Identities()
 		if err != nil {
 			return err
@@ -127,6 +159,21 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 	if err != nil {
 		return fmt.Errorf("constructing client options: %w", err)
 	}
+	mirrorOpts, err := signatureMirrorOpts(ctx, c.SignatureMirrors, c.NameOptions...)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts = append(ociremoteOpts, mirrorOpts...)
+	mirrorConfigOpts, err := registryMirrorOpts(c.RegistryMirrorConfig)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts = append(ociremoteOpts, mirrorConfigOpts...)
+	cacheOpts, err := signatureCacheOpts(c.SignatureCacheDir, c.SignatureCacheTTL)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts = append(ociremoteOpts, cacheOpts...)
 
 	co := &cosign.CheckOpts{
 		Annotations:                  c.Annotations.Annotations,
@@ -140,13 +187,23 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 		SignatureRef:                 c.SignatureRef,
 		PayloadRef:                   c.PayloadRef,
 		Identities:                   identities,
+		RequireCertifiedKey:          certifiedKey,
 		Offline:                      c.Offline,
 		IgnoreTlog:                   c.IgnoreTlog,
 		MaxWorkers:                   c.MaxWorkers,
+		RequiredSignatures:           c.RequiredSignatures,
 		ExperimentalOCI11:            c.ExperimentalOCI11,
 	}
+	if err := setIdentityMatching(ctx, c.CertVerifyOptions, co); err != nil {
+		return err
+	}
 	if c.CheckClaims {
 		co.ClaimVerifier = cosign.SimpleClaimVerifier
+		if len(c.AnnotationConditions) > 0 {
+			co.ClaimVerifier = cosign.AnnotationConditionsClaimVerifier(c.AnnotationConditions)
+		}
+	} else if len(c.AnnotationConditions) > 0 {
+		return errors.New("annotation conditions can only be checked with --check-claims")
 	}
 
 	if c.TSACertChainPath != "" || c.UseSignedTimestamps {
@@ -155,10 +212,24 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 			return fmt.Errorf("unable to load TSA certificates: %w", err)
 		}
 		co.TSACertificate = tsaCertificates.LeafCert
+		co.AdditionalTSACertificates = tsaCertificates.AdditionalLeafCerts
 		co.TSARootCertificates = tsaCertificates.RootCert
 		co.TSAIntermediateCertificates = tsaCertificates.IntermediateCerts
 	}
 
+	var layouts []string
+	if c.LocalImage {
+		var closeLayouts func()
+		layouts, closeLayouts, err = openLocalImages(images)
+		if err != nil {
+			return err
+		}
+		defer closeLayouts()
+		if err := loadLocalTrustedRoot(layouts, co); err != nil {
+			return err
+		}
+	}
+
 	if !c.IgnoreTlog {
 		if c.RekorURL != "" {
 			rekorClient, err := rekor.NewClient(c.RekorURL)
@@ -167,14 +238,23 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 			}
 			co.RekorClient = rekorClient
 		}
-		// This performs an online fetch of the Rekor public keys, but this is needed
-		// for verifying tlog entries (both online and offline).
-		co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
-		if err != nil {
-			return fmt.Errorf("getting Rekor public keys: %w", err)
+		if len(c.AdditionalRekorURLs) > 0 {
+			additionalClients, err := rekor.NewClients(c.RekorURL, c.AdditionalRekorURLs)
+			if err != nil {
+				return err
+			}
+			co.AdditionalRekorClients = additionalClients
+		}
+		if co.RekorPubKeys == nil {
+			// This performs an online fetch of the Rekor public keys, but this is needed
+			// for verifying tlog entries (both online and offline).
+			co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
+			if err != nil {
+				return fmt.Errorf("getting Rekor public keys: %w", err)
+			}
 		}
 	}
-	if keylessVerification(c.KeyRef, c.Sk) {
+	if keylessVerification(c.KeyRef, c.Sk) || certifiedKey {
 		if err := loadCertsKeylessVerification(c.CertChain, c.CARoots, c.CAIntermediates, co); err != nil {
 			return err
 		}
@@ -184,7 +264,7 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 	certRef := c.CertRef
 
 	// Ignore Signed Certificate Timestamp if the flag is set or a key is provided
-	if shouldVerifySCT(c.IgnoreSCT, c.KeyRef, c.Sk) {
+	if (shouldVerifySCT(c.IgnoreSCT, c.KeyRef, c.Sk) || (certifiedKey && !c.IgnoreSCT)) && co.CTLogPubKeys == nil {
 		co.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx)
 		if err != nil {
 			return fmt.Errorf("getting ctlog public keys: %w", err)
@@ -199,6 +279,10 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 		if err != nil {
 			return fmt.Errorf("loading public key: %w", err)
 		}
+		co.KeyConstraints, err = cosign.LoadKeyConstraints(keyRef)
+		if err != nil {
+			return err
+		}
 		pkcs11Key, ok := pubKey.(*pkcs11key.Key)
 		if ok {
 			defer pkcs11Key.Close()
@@ -274,37 +358,149 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 	// was performed so we don't need to use this fragile logic here.
 	fulcioVerified := (co.SigVerifier == nil)
 
-	for _, img := range images {
+	var state *cosign.VerificationState
+	if c.ChangedSince != "" {
+		state, err = c.loadVerificationState(ctx, co)
+		if err != nil {
+			return err
+		}
+		// Keep the images verified so far even if a later one fails.
+		defer func() {
+			if serr := state.Save(c.ChangedSince); serr != nil && err == nil {
+				err = fmt.Errorf("saving verification state: %w", serr)
+			}
+		}()
+	}
+
+	for i, img := range images {
 		if c.LocalImage {
-			verified, bundleVerified, err := cosign.VerifyLocalImageSignatures(ctx, img, co)
+			verifyLocalImage := cosign.VerifyLocalImageSignatures
+			if c.Recursive {
+				verifyLocalImage = cosign.VerifyLocalImageSignaturesRecursive
+			}
+			verified, bundleVerified, err := verifyLocalImage(ctx, layouts[i], co)
 			if err != nil {
 				return err
 			}
 			PrintVerificationHeader(ctx, img, co, bundleVerified, fulcioVerified)
-			PrintVerification(ctx, verified, c.Output)
+			PrintRedactedVerification(ctx, verified, c.Output, c.Redactions)
 		} else {
 			ref, err := name.ParseReference(img, c.NameOptions...)
 			if err != nil {
 				return fmt.Errorf("parsing reference: %w", err)
 			}
+			if c.CheckTagDigest {
+				if err := checkTagDigest(ctx, img, c.NameOptions, ociremoteOpts); err != nil {
+					return err
+				}
+			}
 			ref, err = sign.GetAttachedImageRef(ref, c.Attachment, ociremoteOpts...)
 			if err != nil {
 				return fmt.Errorf("resolving attachment type %s for image %s: %w", c.Attachment, img, err)
 			}
+			if c.WasmModule != "" {
+				// Pin the reference to the artifact holding the module, so
+				// that the signatures verified are those of the module.
+				if ref, err = checkWasmModule(ctx, ref, c.WasmModule, ociremoteOpts...); err != nil {
+					return err
+				}
+			}
+			var digest name.Digest
+			if state != nil {
+				digest, err = ociremote.ResolveDigest(ref, ociremoteOpts...)
+				if err != nil {
+					return fmt.Errorf("resolving digest of %s: %w", img, err)
+				}
+				if t, ok := state.VerifiedAt(digest); ok {
+					ui.Infof(ctx, "%s was verified under the same policy at %s, skipping it", digest, t.Format(time.RFC3339))
+					continue
+				}
+				ref = digest
+			}
 
-			verified, bundleVerified, err := cosign.VerifyImageSignatures(ctx, ref, co)
+			var verified []oci.Signature
+			var bundleVerified bool
+			if c.Platform != "" {
+				ref, verified, bundleVerified, err = verifyPlatform(ctx, ref, c.Platform, co, cosign.VerifyImageSignatures)
+			} else {
+				verified, bundleVerified, err = cosign.VerifyImageSignatures(ctx, ref, co)
+			}
 			if err != nil {
 				return cosignError.WrapError(err)
 			}
 
 			PrintVerificationHeader(ctx, ref.Name(), co, bundleVerified, fulcioVerified)
-			PrintVerification(ctx, verified, c.Output)
+			PrintRedactedVerification(ctx, verified, c.Output, c.Redactions)
+			if state != nil {
+				state.Record(digest, time.Now())
+			}
 		}
 	}
 
 	return nil
 }
 
+// checkTagDigest fails if img has the form repo:tag@digest and the tag has
+// since been moved to another digest. Verification always uses the digest.
+func checkTagDigest(ctx context.Context, img string, nameOpts []name.Option, opts []ociremote.Option) error {
+	tag, digest, ok := ociremote.TagAndDigest(img, nameOpts...)
+	if !ok {
+		return nil
+	}
+	if err := ociremote.CheckTagDigest(tag, digest, opts...); err != nil {
+		return fmt.Errorf("checking tag of %s: %w", img, err)
+	}
+	ui.Infof(ctx, "tag %s points at %s", tag, digest.DigestStr())
+	return nil
+}
+
+// signatureMirrorOpts returns the options reading signatures from mirrors
+// when their registry fails, warning about every signature read from one.
+func signatureMirrorOpts(ctx context.Context, mirrors []string, opts ...name.Option) ([]ociremote.Option, error) {
+	if len(mirrors) == 0 {
+		return nil, nil
+	}
+	registries := make([]name.Registry, 0, len(mirrors))
+	for _, m := range mirrors {
+		r, err := name.NewRegistry(m, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("parsing signature mirror %q: %w", m, err)
+		}
+		registries = append(registries, r)
+	}
+	report := func(primary, mirror name.Reference, err error) {
+		ui.Warnf(ctx, "reading %s failed (%v), read %s from the signature mirror instead", primary, err, mirror)
+	}
+	return []ociremote.Option{ociremote.WithSignatureMirrors(report, registries...)}, nil
+}
+
+// registryMirrorOpts returns the options reading images and signatures
+// through the mirrors configured in the file at path, or none if path is
+// empty.
+func registryMirrorOpts(path string) ([]ociremote.Option, error) {
+	if path == "" {
+		return nil, nil
+	}
+	c, err := ociremote.LoadMirrorConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return []ociremote.Option{ociremote.WithMirrorConfig(c)}, nil
+}
+
+// signatureCacheOpts returns the options caching signatures in dir for ttl,
+// or none if dir is empty.
+func signatureCacheOpts(dir string, ttl time.Duration) ([]ociremote.Option, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	c, err := cache.New(dir, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return []ociremote.Option{ociremote.WithCache(c)}, nil
+}
+
 func PrintVerificationHeader(ctx context.Context, imgRef string, co *cosign.CheckOpts, bundleVerified, fulcioVerified bool) {
 	ui.Infof(ctx, "\nVerification for %s --", imgRef)
 	ui.Infof(ctx, "The following checks were performed on each of these signatures:")
@@ -316,12 +512,15 @@ func PrintVerificationHeader(ctx context.Context, imgRef string, co *cosign.Chec
 	}
 	if bundleVerified {
 		ui.Infof(ctx, "  - Existence of the claims in the transparency log was verified offline")
-	} else if co.RekorClient != nil {
+	} else if co.RekorClient != nil || len(co.AdditionalRekorClients) > 0 {
 		ui.Infof(ctx, "  - The claims were present in the transparency log")
 		ui.Infof(ctx, "  - The signatures were integrated into the transparency log when the certificate was valid")
 	}
 	if co.SigVerifier != nil {
 		ui.Infof(ctx, "  - The signatures were verified against the specified public key")
+		if id := sigs.ResolvedKeyID(co.SigVerifier); id != "" {
+			ui.Infof(ctx, "  - The specified key resolved to %s", id)
+		}
 	}
 	if fulcioVerified {
 		ui.Infof(ctx, "  - The code-signing certificate was verified using trusted certificate authority certificates")
@@ -330,6 +529,14 @@ func PrintVerificationHeader(ctx context.Context, imgRef string, co *cosign.Chec
 
 // PrintVerification logs details about the verification to stdout
 func PrintVerification(ctx context.Context, verified []oci.Signature, output string) {
+	PrintRedactedVerification(ctx, verified, output, nil)
+}
+
+// PrintRedactedVerification is PrintVerification with the certificate fields
+// of the report rewritten by redactions. Since the transparency log bundle
+// embeds the certificate, it is left out of the report if any field is
+// redacted.
+func PrintRedactedVerification(ctx context.Context, verified []oci.Signature, output string, redactions cosign.Redactions) {
 	switch output {
 	case "text":
 		for _, sig := range verified {
@@ -339,28 +546,15 @@ func PrintVerification(ctx context.Context, verified []oci.Signature, output str
 				if sans := cryptoutils.GetSubjectAlternateNames(cert); len(sans) > 0 {
 					sub = sans[0]
 				}
-				ui.Infof(ctx, "Certificate subject: %s", sub)
-				if issuerURL := ce.GetIssuer(); issuerURL != "" {
-					ui.Infof(ctx, "Certificate issuer URL: %s", issuerURL)
-				}
-
-				if githubWorkflowTrigger := ce.GetCertExtensionGithubWorkflowTrigger(); githubWorkflowTrigger != "" {
-					ui.Infof(ctx, "GitHub Workflow Trigger: %s", githubWorkflowTrigger)
-				}
-
-				if githubWorkflowSha := ce.GetExtensionGithubWorkflowSha(); githubWorkflowSha != "" {
-					ui.Infof(ctx, "GitHub Workflow SHA: %s", githubWorkflowSha)
-				}
-				if githubWorkflowName := ce.GetCertExtensionGithubWorkflowName(); githubWorkflowName != "" {
-					ui.Infof(ctx, "GitHub Workflow Name: %s", githubWorkflowName)
-				}
-
-				if githubWorkflowRepository := ce.GetCertExtensionGithubWorkflowRepository(); githubWorkflowRepository != "" {
-					ui.Infof(ctx, "GitHub Workflow Repository: %s", githubWorkflowRepository)
+				if sub, ok := redactions.Apply(cosign.RedactFieldSubject, sub); ok {
+					ui.Infof(ctx, "Certificate subject: %s", sub)
 				}
-
-				if githubWorkflowRef := ce.GetCertExtensionGithubWorkflowRef(); githubWorkflowRef != "" {
-					ui.Infof(ctx, "GitHub Workflow Ref: %s", githubWorkflowRef)
+				for _, f := range certReportFields {
+					if v := f.get(&ce); v != "" {
+						if v, ok := redactions.Apply(f.field, v); ok {
+							ui.Infof(ctx, "%s: %s", f.label, v)
+						}
+					}
 				}
 			}
 
@@ -396,36 +590,20 @@ func PrintVerification(ctx context.Context, verified []oci.Signature, output str
 				if sans := cryptoutils.GetSubjectAlternateNames(cert); len(sans) > 0 {
 					sub = sans[0]
 				}
-				ss.Optional["Subject"] = sub
-				if issuerURL := ce.GetIssuer(); issuerURL != "" {
-					ss.Optional["Issuer"] = issuerURL
-					ss.Optional[cosign.CertExtensionOIDCIssuer] = issuerURL
-				}
-				if githubWorkflowTrigger := ce.GetCertExtensionGithubWorkflowTrigger(); githubWorkflowTrigger != "" {
-					ss.Optional[cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowTrigger]] = githubWorkflowTrigger
-					ss.Optional[cosign.CertExtensionGithubWorkflowTrigger] = githubWorkflowTrigger
-				}
-
-				if githubWorkflowSha := ce.GetExtensionGithubWorkflowSha(); githubWorkflowSha != "" {
-					ss.Optional[cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowSha]] = githubWorkflowSha
-					ss.Optional[cosign.CertExtensionGithubWorkflowSha] = githubWorkflowSha
-				}
-				if githubWorkflowName := ce.GetCertExtensionGithubWorkflowName(); githubWorkflowName != "" {
-					ss.Optional[cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowName]] = githubWorkflowName
-					ss.Optional[cosign.CertExtensionGithubWorkflowName] = githubWorkflowName
-				}
-
-				if githubWorkflowRepository := ce.GetCertExtensionGithubWorkflowRepository(); githubWorkflowRepository != "" {
-					ss.Optional[cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowRepository]] = githubWorkflowRepository
-					ss.Optional[cosign.CertExtensionGithubWorkflowRepository] = githubWorkflowRepository
+				if sub, ok := redactions.Apply(cosign.RedactFieldSubject, sub); ok {
+					ss.Optional["Subject"] = sub
 				}
-
-				if githubWorkflowRef := ce.GetCertExtensionGithubWorkflowRef(); githubWorkflowRef != "" {
-					ss.Optional[cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowRef]] = githubWorkflowRef
-					ss.Optional[cosign.CertExtensionGithubWorkflowRef] = githubWorkflowRef
+				for _, f := range certReportFields {
+					if v := f.get(&ce); v != "" {
+						if v, ok := redactions.Apply(f.field, v); ok {
+							for _, k := range f.keys {
+								ss.Optional[k] = v
+							}
+						}
+					}
 				}
 			}
-			if bundle, err := sig.Bundle(); err == nil && bundle != nil {
+			if bundle, err := sig.Bundle(); err == nil && bundle != nil && len(redactions) == 0 {
 				if ss.Optional == nil {
 					ss.Optional = make(map[string]interface{})
 				}
@@ -451,6 +629,47 @@ func PrintVerification(ctx context.Context, verified []oci.Signature, output str
 	}
 }
 
+// certReportFields are the certificate extensions reported by
+// PrintVerification, with the label they are printed with as text and the
+// keys they are reported under as JSON. The OIDs are kept as keys for
+// backwards compatibility.
+var certReportFields = []struct {
+	field string
+	label string
+	keys  []string
+	get   func(*cosign.CertExtensions) string
+}{{
+	field: cosign.RedactFieldIssuer,
+	label: "Certificate issuer URL",
+	keys:  []string{"Issuer", cosign.CertExtensionOIDCIssuer},
+	get:   (*cosign.CertExtensions).GetIssuer,
+}, {
+	field: cosign.RedactFieldGithubWorkflowTrigger,
+	label: "GitHub Workflow Trigger",
+	keys:  []string{cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowTrigger], cosign.CertExtensionGithubWorkflowTrigger},
+	get:   (*cosign.CertExtensions).GetCertExtensionGithubWorkflowTrigger,
+}, {
+	field: cosign.RedactFieldGithubWorkflowSha,
+	label: "GitHub Workflow SHA",
+	keys:  []string{cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowSha], cosign.CertExtensionGithubWorkflowSha},
+	get:   (*cosign.CertExtensions).GetExtensionGithubWorkflowSha,
+}, {
+	field: cosign.RedactFieldGithubWorkflowName,
+	label: "GitHub Workflow Name",
+	keys:  []string{cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowName], cosign.CertExtensionGithubWorkflowName},
+	get:   (*cosign.CertExtensions).GetCertExtensionGithubWorkflowName,
+}, {
+	field: cosign.RedactFieldGithubWorkflowRepository,
+	label: "GitHub Workflow Repository",
+	keys:  []string{cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowRepository], cosign.CertExtensionGithubWorkflowRepository},
+	get:   (*cosign.CertExtensions).GetCertExtensionGithubWorkflowRepository,
+}, {
+	field: cosign.RedactFieldGithubWorkflowRef,
+	label: "GitHub Workflow Ref",
+	keys:  []string{cosign.CertExtensionMap[cosign.CertExtensionGithubWorkflowRef], cosign.CertExtensionGithubWorkflowRef},
+	get:   (*cosign.CertExtensions).GetCertExtensionGithubWorkflowRef,
+}}
+
 func loadCertFromFileOrURL(path string) (*x509.Certificate, error) {
 	pems, err := blob.LoadFileOrURL(path)
 	if err != nil {
@@ -489,6 +708,23 @@ func loadCertChainFromFileOrURL(path string) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
+// setIdentityMatching sets how co matches certificate identities: the
+// normalization of URI SANs and, with --certificate-identity-trace, a trace
+// of the SAN that matched.
+func setIdentityMatching(ctx context.Context, o options.CertVerifyOptions, co *cosign.CheckOpts) error {
+	n, err := cosign.ParseURINormalization(o.CertIdentityURINormalize)
+	if err != nil {
+		return err
+	}
+	co.URINormalization = n
+	if o.CertIdentityTrace {
+		co.IdentityMatchTrace = func(m cosign.IdentityMatch) {
+			ui.Infof(ctx, "%s", m)
+		}
+	}
+	return nil
+}
+
 func keylessVerification(keyRef string, sk bool) bool {
 	if keyRef != "" {
 		return false
@@ -499,6 +735,13 @@ func keylessVerification(keyRef string, sk bool) bool {
 	return true
 }
 
+// certifiedKeyVerification reports whether the key at keyRef is verified
+// along with the identity its certificate must have, such as a KMS key that
+// signed with 'cosign sign --issue-certificate'.
+func certifiedKeyVerification(keyRef string, o options.CertVerifyOptions) bool {
+	return keyRef != "" && (o.CertIdentity != "" || o.CertIdentityRegexp != "")
+}
+
 func shouldVerifySCT(ignoreSCT bool, keyRef string, sk bool) bool {
 	if keyRef != "" {
 		return false
@@ -559,6 +802,8 @@ func loadCertsKeylessVerification(certChainFile string,
 				}
 			}
 		}
+	case co.RootCerts != nil:
+		// The roots were already loaded, e.g. from the trusted root of a local image.
 	default:
 		// This performs an online fetch of the Fulcio roots from a TUF repository.
 		// This is needed for verifying keyless certificates (both online and offline).
@@ -22,21 +22,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/fulcio"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
 	"github.com/franchb/cosign/v2/internal/ui"
 	"github.com/franchb/cosign/v2/pkg/cosign"
-	"github.com/franchb/cosign/v2/pkg/cosign/cue"
 	"github.com/franchb/cosign/v2/pkg/cosign/pivkey"
 	"github.com/franchb/cosign/v2/pkg/cosign/pkcs11key"
-	"github.com/franchb/cosign/v2/pkg/cosign/rego"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
 	"github.com/franchb/cosign/v2/pkg/policy"
 	sigs "github.com/franchb/cosign/v2/pkg/signature"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
 // VerifyAttestationCommand verifies a signature on a supplied container image
@@ -60,16 +62,52 @@ type VerifyAttestationCommand struct {
 	Sk                           bool
 	Slot                         string
 	Output                       string
+	Redactions                   cosign.Redactions
 	RekorURL                     string
+	AdditionalRekorURLs          []string
 	PredicateType                string
 	Policies                     []string
+	PolicyPlugins                []string
 	LocalImage                   bool
+	Platform                     string
+	MaxAttestationAge            time.Duration
+	StatementTime                bool
+	CheckTagDigest               bool
+	AttestationIndex             string
 	NameOptions                  []name.Option
 	Offline                      bool
 	TSACertChainPath             string
 	IgnoreTlog                   bool
 	MaxWorkers                   int
+	RequiredSignatures           int
 	UseSignedTimestamps          bool
+	ExperimentalOCI11            bool
+	SignatureMirrors             []string
+	RegistryMirrorConfig         string
+	SignatureCacheDir            string
+	SignatureCacheTTL            time.Duration
+
+	// evaluators are checked against the attestations in addition to
+	// Policies and PolicyPlugins.
+	evaluators []policy.PolicyEvaluator
+}
+
+// verifyIndexAttestations verifies the attestations on c.AttestationIndex that
+// name ref as a subject.
+func (c *VerifyAttestationCommand) verifyIndexAttestations(ctx context.Context, ref name.Reference, co *cosign.CheckOpts) ([]oci.Signature, bool, error) {
+	idx, err := name.ParseReference(c.AttestationIndex, c.NameOptions...)
+	if err != nil {
+		return nil, false, err
+	}
+	digest, err := ociremote.ResolveDigest(ref, co.RegistryClientOpts...)
+	if err != nil {
+		return nil, false, err
+	}
+	h, err := v1.NewHash(digest.DigestStr())
+	if err != nil {
+		return nil, false, err
+	}
+	return cosign.VerifyIndexAttestations(ctx, idx, h, co)
 }
 
 func (c *VerifyAttestationCommand) loadTSACertificates(ctx context.Context) (*cosign.TSACertificates, error) {
@@ -88,14 +126,18 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 	if len(images) == 0 {
 		return flag.ErrHelp
 	}
+	if c.Platform != "" && c.LocalImage {
+		return fmt.Errorf("--platform cannot be used with --local-image")
+	}
 
 	// We can't have both a key and a security key
 	if options.NOf(c.KeyRef, c.Sk) > 1 {
 		return &options.KeyParseError{}
 	}
 
+	certifiedKey := certifiedKeyVerification(c.KeyRef, c.CertVerifyOptions)
 	var identities []cosign.Identity
-	if c.KeyRef == "" {
+	if c.KeyRef == "" || certifiedKey {
 		identities, err = c.Identities()
 		if err != nil {
 			return err
@@ -106,6 +148,29 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 	if err != nil {
 		return fmt.Errorf("constructing client options: %w", err)
 	}
+	mirrorOpts, err := signatureMirrorOpts(ctx, c.SignatureMirrors, c.NameOptions...)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts = append(ociremoteOpts, mirrorOpts...)
+	mirrorConfigOpts, err := registryMirrorOpts(c.RegistryMirrorConfig)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts = append(ociremoteOpts, mirrorConfigOpts...)
+	cacheOpts, err := signatureCacheOpts(c.SignatureCacheDir, c.SignatureCacheTTL)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts = append(ociremoteOpts, cacheOpts...)
+	if c.PredicateType != "" {
+		// Skip downloading attestations indexed with a different predicate type.
+		predicateURI, err := options.ParsePredicateType(c.PredicateType)
+		if err != nil {
+			return err
+		}
+		ociremoteOpts = append(ociremoteOpts, ociremote.WithPredicateTypeFilter(predicateURI))
+	}
 
 	co := &cosign.CheckOpts{
 		RegistryClientOpts:           ociremoteOpts,
@@ -116,31 +181,51 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 		CertGithubWorkflowRef:        c.CertGithubWorkflowRef,
 		IgnoreSCT:                    c.IgnoreSCT,
 		Identities:                   identities,
+		RequireCertifiedKey:          certifiedKey,
 		Offline:                      c.Offline,
 		IgnoreTlog:                   c.IgnoreTlog,
 		MaxWorkers:                   c.MaxWorkers,
+		RequiredSignatures:           c.RequiredSignatures,
+		ExperimentalOCI11:            c.ExperimentalOCI11,
+	}
+	if err := setIdentityMatching(ctx, c.CertVerifyOptions, co); err != nil {
+		return err
 	}
 	if c.CheckClaims {
 		co.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
 	}
-	// Ignore Signed Certificate Timestamp if the flag is set or a key is provided
-	if shouldVerifySCT(c.IgnoreSCT, c.KeyRef, c.Sk) {
-		co.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx)
-		if err != nil {
-			return fmt.Errorf("getting ctlog public keys: %w", err)
-		}
-	}
-
 	if c.TSACertChainPath != "" || c.UseSignedTimestamps {
 		tsaCertificates, err := c.loadTSACertificates(ctx)
 		if err != nil {
 			return fmt.Errorf("unable to load TSA certificates: %w", err)
 		}
 		co.TSACertificate = tsaCertificates.LeafCert
+		co.AdditionalTSACertificates = tsaCertificates.AdditionalLeafCerts
 		co.TSARootCertificates = tsaCertificates.RootCert
 		co.TSAIntermediateCertificates = tsaCertificates.IntermediateCerts
 	}
 
+	var layouts []string
+	if c.LocalImage {
+		var closeLayouts func()
+		layouts, closeLayouts, err = openLocalImages(images)
+		if err != nil {
+			return err
+		}
+		defer closeLayouts()
+		if err := loadLocalTrustedRoot(layouts, co); err != nil {
+			return err
+		}
+	}
+
+	// Ignore Signed Certificate Timestamp if the flag is set or a key is provided
+	if (shouldVerifySCT(c.IgnoreSCT, c.KeyRef, c.Sk) || (certifiedKey && !c.IgnoreSCT)) && co.CTLogPubKeys == nil {
+		co.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx)
+		if err != nil {
+			return fmt.Errorf("getting ctlog public keys: %w", err)
+		}
+	}
+
 	if !c.IgnoreTlog {
 		if c.RekorURL != "" {
 			rekorClient, err := rekor.NewClient(c.RekorURL)
@@ -149,15 +234,24 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 			}
 			co.RekorClient = rekorClient
 		}
-		// This performs an online fetch of the Rekor public keys, but this is needed
-		// for verifying tlog entries (both online and offline).
-		co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
-		if err != nil {
-			return fmt.Errorf("getting Rekor public keys: %w", err)
+		if len(c.AdditionalRekorURLs) > 0 {
+			additionalClients, err := rekor.NewClients(c.RekorURL, c.AdditionalRekorURLs)
+			if err != nil {
+				return err
+			}
+			co.AdditionalRekorClients = additionalClients
+		}
+		if co.RekorPubKeys == nil {
+			// This performs an online fetch of the Rekor public keys, but this is needed
+			// for verifying tlog entries (both online and offline).
+			co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
+			if err != nil {
+				return fmt.Errorf("getting Rekor public keys: %w", err)
+			}
 		}
 	}
 
-	if keylessVerification(c.KeyRef, c.Sk) {
+	if keylessVerification(c.KeyRef, c.Sk) || certifiedKey {
 		if err := loadCertsKeylessVerification(c.CertChain, c.CARoots, c.CAIntermediates, co); err != nil {
 			return err
 		}
@@ -172,6 +266,10 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 		if err != nil {
 			return fmt.Errorf("loading public key: %w", err)
 		}
+		co.KeyConstraints, err = cosign.LoadKeyConstraints(keyRef)
+		if err != nil {
+			return err
+		}
 		pkcs11Key, ok := co.SigVerifier.(*pkcs11key.Key)
 		if ok {
 			defer pkcs11Key.Close()
@@ -235,12 +333,12 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 	// was performed so we don't need to use this fragile logic here.
 	fulcioVerified := (co.SigVerifier == nil)
 
-	for _, imageRef := range images {
+	for i, imageRef := range images {
 		var verified []oci.Signature
 		var bundleVerified bool
 
 		if c.LocalImage {
-			verified, bundleVerified, err = cosign.VerifyLocalImageAttestations(ctx, imageRef, co)
+			verified, bundleVerified, err = cosign.VerifyLocalImageAttestations(ctx, layouts[i], co)
 			if err != nil {
 				return err
 			}
@@ -249,8 +347,23 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 			if err != nil {
 				return err
 			}
+			if c.CheckTagDigest {
+				if err := checkTagDigest(ctx, imageRef, c.NameOptions, ociremoteOpts); err != nil {
+					return err
+				}
+			}
 
-			verified, bundleVerified, err = cosign.VerifyImageAttestations(ctx, ref, co)
+			verify := cosign.VerifyImageAttestations
+			if c.AttestationIndex != "" {
+				verify = c.verifyIndexAttestations
+			}
+			if c.Platform != "" {
+				var child name.Digest
+				child, verified, bundleVerified, err = verifyPlatform(ctx, ref, c.Platform, co, verify)
+				imageRef = child.String()
+			} else {
+				verified, bundleVerified, err = verify(ctx, ref, co)
+			}
 			if err != nil {
 				return err
 			}
@@ -269,6 +382,23 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 			}
 		}
 
+		evaluators := slices.Clone(c.evaluators)
+		if len(cuePolicies) > 0 {
+			ui.Infof(ctx, "will be validating against CUE policies: %v", cuePolicies)
+			evaluators = append(evaluators, policy.NewCUEFileEvaluator(cuePolicies))
+		}
+		if len(regoPolicies) > 0 {
+			ui.Infof(ctx, "will be validating against Rego policies: %v", regoPolicies)
+			evaluators = append(evaluators, policy.NewRegoFileEvaluator(regoPolicies))
+		}
+		for _, plugin := range c.PolicyPlugins {
+			ui.Infof(ctx, "will be validating against policy plugin: %s", plugin)
+			evaluators = append(evaluators, policy.NewPluginEvaluator(plugin, policy.PluginContext{
+				Image:         imageRef,
+				PredicateType: c.PredicateType,
+			}))
+		}
+
 		var checked []oci.Signature
 		var validationErrors []error
 		// To aid in determining if there's a mismatch in what predicateType
@@ -286,22 +416,9 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 				continue
 			}
 
-			if len(cuePolicies) > 0 {
-				ui.Infof(ctx, "will be validating against CUE policies: %v", cuePolicies)
-				cueValidationErr := cue.ValidateJSON(payload, cuePolicies)
-				if cueValidationErr != nil {
-					validationErrors = append(validationErrors, cueValidationErr)
-					continue
-				}
-			}
-
-			if len(regoPolicies) > 0 {
-				ui.Infof(ctx, "will be validating against Rego policies: %v", regoPolicies)
-				regoValidationErrs := rego.ValidateJSON(payload, regoPolicies)
-				if len(regoValidationErrs) > 0 {
-					validationErrors = append(validationErrors, regoValidationErrs...)
-					continue
-				}
+			if errs := evaluatePolicies(ctx, evaluators, payload); len(errs) > 0 {
+				validationErrors = append(validationErrors, errs...)
+				continue
 			}
 
 			checked = append(checked, vp)
@@ -319,11 +436,36 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 			return fmt.Errorf("none of the attestations matched the predicate type: %s, found: %s", c.PredicateType, strings.Join(checkedPredicateTypes, ","))
 		}
 
+		if c.MaxAttestationAge > 0 {
+			if err := policy.CheckAttestationFreshness(checked, c.MaxAttestationAge, time.Now(), c.StatementTime); err != nil {
+				return err
+			}
+		}
+
 		// TODO: add CUE validation report to `PrintVerificationHeader`.
 		PrintVerificationHeader(ctx, imageRef, co, bundleVerified, fulcioVerified)
 		// The attestations are always JSON, so use the raw "text" mode for outputting them instead of conversion
-		PrintVerification(ctx, checked, "text")
+		PrintRedactedVerification(ctx, checked, "text", c.Redactions)
 	}
 
 	return nil
 }
+
+// evaluatePolicies runs evaluators in turn against payload, stopping at the
+// first that fails, and returns its individual errors.
+func evaluatePolicies(ctx context.Context, evaluators []policy.PolicyEvaluator, payload []byte) []error {
+	for _, evaluator := range evaluators {
+		warnings, err := evaluator.Evaluate(ctx, [][]byte{payload})
+		if warnings != nil {
+			ui.Warnf(ctx, "%v", warnings)
+		}
+		if err == nil {
+			continue
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			return joined.Unwrap()
+		}
+		return []error{err}
+	}
+	return nil
+}
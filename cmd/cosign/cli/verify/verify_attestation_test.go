@@ -15,10 +15,30 @@
 package verify
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/pkg/types"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/dsse"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 )
 
 func TestVerifyAttestationMissingSubject(t *testing.T) {
@@ -52,3 +72,70 @@ func TestVerifyAttestationMissingIssuer(t *testing.T) {
 		t.Fatal("verifyAttestation expected 'need --certificate-oidc-issuer'")
 	}
 }
+
+func TestVerifyAttestationMaxAgeIgnoresUnverifiedBundle(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyRef := filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(keyRef, pubPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://cosign.sigstore.dev/attestation/v1","predicate":{"timestamp":%q}}`,
+		time.Now().UTC().Format(time.RFC3339))
+	env, err := dsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(bytes.NewReader([]byte(statement)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bundle claims the attestation was just logged, but it is a plain
+	// annotation that is never verified when the tlog is ignored.
+	att, err := static.NewAttestation(env,
+		static.WithLayerMediaType(types.DssePayloadType),
+		static.WithBundle(&bundle.RekorBundle{Payload: bundle.RekorPayload{IntegratedTime: time.Now().Unix()}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := mutate.AttachAttestationToImage(signed.Image(img), att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imagePath := filepath.Join(td, "image")
+	if err := layout.WriteSignedImage(imagePath, si); err != nil {
+		t.Fatal(err)
+	}
+
+	verifyAttestation := VerifyAttestationCommand{
+		KeyRef:            keyRef,
+		IgnoreTlog:        true,
+		LocalImage:        true,
+		PredicateType:     "custom",
+		MaxAttestationAge: time.Hour,
+	}
+	err = verifyAttestation.Exec(ctx, []string{imagePath})
+	if err == nil || !strings.Contains(err.Error(), "unable to determine attestation time") {
+		t.Fatalf("Exec() = %v, wanted the unverified bundle to be rejected as a freshness source", err)
+	}
+
+	// Opting in to the signer-controlled statement time accepts it.
+	verifyAttestation.StatementTime = true
+	if err := verifyAttestation.Exec(ctx, []string{imagePath}); err != nil {
+		t.Fatalf("Exec() with statement time = %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/embedded"
+)
+
+// VerifyBinaryCmd verifies the bundle embedded in an executable.
+// nolint
+type VerifyBinaryCmd struct {
+	VerifyBlobCmd
+}
+
+// Exec verifies the bundle embedded in the executable at path against the
+// executable with the bundle's section zeroed, which is what was signed.
+func (c *VerifyBinaryCmd) Exec(ctx context.Context, path string) error {
+	b, err := embedded.ReadBundle(path)
+	if err != nil {
+		return fmt.Errorf("reading bundle embedded in %s: %w", path, err)
+	}
+
+	dir, err := os.MkdirTemp("", "cosign-verify-binary")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	bundlePath := filepath.Join(dir, "bundle.json")
+	if err := os.WriteFile(bundlePath, b, 0o600); err != nil {
+		return err
+	}
+	contentPath := filepath.Join(dir, filepath.Base(path))
+	if err := writeSignedContent(path, contentPath); err != nil {
+		return err
+	}
+
+	c.BundlePath = bundlePath
+	return c.VerifyBlobCmd.Exec(ctx, contentPath)
+}
+
+// writeSignedContent writes the executable at path, with the section holding
+// the embedded bundle zeroed, to dst.
+func writeSignedContent(path, dst string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	s, err := embedded.FindSection(f)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, embedded.Content(f, fi.Size(), s)); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
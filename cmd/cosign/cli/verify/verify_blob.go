@@ -27,6 +27,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/rekor"
@@ -34,8 +35,11 @@ import (
 	"github.com/franchb/cosign/v2/pkg/blob"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/cosign/minisign"
+	"github.com/franchb/cosign/v2/pkg/cosign/pgp"
 	"github.com/franchb/cosign/v2/pkg/cosign/pivkey"
 	"github.com/franchb/cosign/v2/pkg/cosign/pkcs11key"
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	sigs "github.com/franchb/cosign/v2/pkg/signature"
 
@@ -67,6 +71,7 @@ type VerifyBlobCmd struct {
 	Offline                      bool
 	UseSignedTimestamps          bool
 	IgnoreTlog                   bool
+	SSHIdentity                  string
 }
 
 func (c *VerifyBlobCmd) loadTSACertificates(ctx context.Context) (*cosign.TSACertificates, error) {
@@ -92,6 +97,16 @@ func (c *VerifyBlobCmd) Exec(ctx context.Context, blobRef string) error {
 		return &options.PubKeyParseError{}
 	}
 
+	if strings.HasPrefix(c.KeyRef, sshsig.ReferenceScheme) {
+		return c.verifySSH(ctx, blobRef)
+	}
+	if strings.HasPrefix(c.KeyRef, minisign.ReferenceScheme) {
+		return c.verifyMinisign(ctx, blobRef)
+	}
+	if strings.HasPrefix(c.KeyRef, pgp.ReferenceScheme) {
+		return c.verifyPGP(ctx, blobRef)
+	}
+
 	if c.KeyOpts.NewBundleFormat {
 		if options.NOf(c.RFC3161TimestampPath, c.TSACertChainPath, c.RekorURL, c.CertChain, c.CARoots, c.CAIntermediates, c.CertRef, c.SigRef, c.SCTRef) > 1 {
 			return fmt.Errorf("when using --new-bundle-format, please supply signed content with --bundle and verification content with --trusted-root")
@@ -138,6 +153,9 @@ func (c *VerifyBlobCmd) Exec(ctx context.Context, blobRef string) error {
 		Offline:                      c.Offline,
 		IgnoreTlog:                   c.IgnoreTlog,
 	}
+	if err := setIdentityMatching(ctx, c.CertVerifyOptions, co); err != nil {
+		return err
+	}
 	if c.RFC3161TimestampPath != "" && !(c.TSACertChainPath != "" || c.UseSignedTimestamps) {
 		return fmt.Errorf("either TSA certificate chain path must be provided or use-signed-timestamps must be set when using RFC3161 timestamp path")
 	}
@@ -147,6 +165,7 @@ func (c *VerifyBlobCmd) Exec(ctx context.Context, blobRef string) error {
 			return err
 		}
 		co.TSACertificate = tsaCertificates.LeafCert
+		co.AdditionalTSACertificates = tsaCertificates.AdditionalLeafCerts
 		co.TSARootCertificates = tsaCertificates.RootCert
 		co.TSAIntermediateCertificates = tsaCertificates.IntermediateCerts
 	}
@@ -159,6 +178,13 @@ func (c *VerifyBlobCmd) Exec(ctx context.Context, blobRef string) error {
 			}
 			co.RekorClient = rekorClient
 		}
+		if len(c.AdditionalRekorURLs) > 0 {
+			additionalClients, err := rekor.NewClients(c.RekorURL, c.AdditionalRekorURLs)
+			if err != nil {
+				return err
+			}
+			co.AdditionalRekorClients = additionalClients
+		}
 		// This performs an online fetch of the Rekor public keys, but this is needed
 		// for verifying tlog entries (both online and offline).
 		co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
@@ -308,6 +334,9 @@ func (c *VerifyBlobCmd) Exec(ctx context.Context, blobRef string) error {
 		return err
 	}
 
+	if id := sigs.ResolvedKeyID(co.SigVerifier); id != "" {
+		ui.Infof(ctx, "Verified with key %s", id)
+	}
 	ui.Infof(ctx, "Verified OK")
 	return nil
 }
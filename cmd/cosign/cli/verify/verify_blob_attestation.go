@@ -41,8 +41,13 @@ import (
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/franchb/cosign/v2/pkg/policy"
 	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	"github.com/franchb/rekor/pkg/generated/models"
+	sgbundle "github.com/franchb/sigstore-go/pkg/bundle"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/go-openapi/strfmt"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
 )
 
 // VerifyBlobAttestationCommand verifies an attestation on a supplied blob
@@ -74,6 +79,10 @@ type VerifyBlobAttestationCommand struct {
 
 	SignaturePath       string // Path to the signature
 	UseSignedTimestamps bool
+
+	// Directory is the directory whose files must be exactly the subjects
+	// of the attestation, instead of a blob.
+	Directory string
 }
 
 // Exec runs the verification command
@@ -92,11 +101,24 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 		return &options.KeyParseError{}
 	}
 
+	var files []blob.File
+	if c.Directory != "" {
+		if files, err = blob.HashDirectory(c.Directory); err != nil {
+			return err
+		}
+		// The subjects are checked against all the files once the
+		// signature is verified.
+		artifactPath = filepath.Join(c.Directory, filepath.FromSlash(files[0].Path))
+	}
+
 	if c.KeyOpts.NewBundleFormat {
 		if options.NOf(c.RFC3161TimestampPath, c.TSACertChainPath, c.RekorURL, c.CertChain, c.CARoots, c.CAIntermediates, c.CertRef, c.SCTRef) > 1 {
 			return fmt.Errorf("when using --new-bundle-format, please supply signed content with --bundle and verification content with --trusted-root")
 		}
 		err = verifyNewBundle(ctx, c.BundlePath, c.TrustedRootPath, c.KeyRef, c.Slot, c.CertVerifyOptions.CertOidcIssuer, c.CertVerifyOptions.CertOidcIssuerRegexp, c.CertVerifyOptions.CertIdentity, c.CertVerifyOptions.CertIdentityRegexp, c.CertGithubWorkflowTrigger, c.CertGithubWorkflowSHA, c.CertGithubWorkflowName, c.CertGithubWorkflowRepository, c.CertGithubWorkflowRef, artifactPath, c.Sk, c.IgnoreTlog, c.UseSignedTimestamps, c.IgnoreSCT)
+		if err == nil && files != nil {
+			err = checkBundleDirectorySubjects(c.BundlePath, files)
+		}
 		if err == nil {
 			fmt.Fprintln(os.Stderr, "Verified OK")
 		}
@@ -124,8 +146,12 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 		Offline:                      c.Offline,
 		IgnoreTlog:                   c.IgnoreTlog,
 	}
+	if err := setIdentityMatching(ctx, c.CertVerifyOptions, co); err != nil {
+		return err
+	}
+
 	var h v1.Hash
-	if c.CheckClaims {
+	if c.CheckClaims && files == nil {
 		// Get the actual digest of the blob
 		var payload internal.HashReader
 		f, err := os.Open(filepath.Clean(artifactPath))
@@ -165,6 +191,7 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 			return fmt.Errorf("unable to load or get TSA certificates: %w", err)
 		}
 		co.TSACertificate = tsaCertificates.LeafCert
+		co.AdditionalTSACertificates = tsaCertificates.AdditionalLeafCerts
 		co.TSARootCertificates = tsaCertificates.RootCert
 		co.TSAIntermediateCertificates = tsaCertificates.IntermediateCerts
 	}
@@ -177,6 +204,13 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 			}
 			co.RekorClient = rekorClient
 		}
+		if len(c.AdditionalRekorURLs) > 0 {
+			additionalClients, err := rekor.NewClients(c.RekorURL, c.AdditionalRekorURLs)
+			if err != nil {
+				return err
+			}
+			co.AdditionalRekorClients = additionalClients
+		}
 		// This performs an online fetch of the Rekor public keys, but this is needed
 		// for verifying tlog entries (both online and offline).
 		co.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
@@ -208,6 +242,7 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 
 	// Keys are optional!
 	var cert *x509.Certificate
+	var bundleChainPEM []byte
 	opts := make([]static.Option, 0)
 	switch {
 	case c.KeyRef != "":
@@ -276,6 +311,26 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 			return fmt.Errorf("decoding signature: %w", err)
 		}
 		opts = append(opts, static.WithBundle(b.Bundle))
+
+		// The inclusion proof is checked here, as the bundle on the
+		// attestation only carries the signed entry timestamp.
+		if b.InclusionProof != nil && b.Bundle != nil && !c.IgnoreTlog {
+			if err := verifyBundleInclusionProof(ctx, b, co.RekorPubKeys); err != nil {
+				return err
+			}
+		}
+		if b.CertChain != "" && c.CertChain == "" {
+			bundleChainPEM, err = base64.StdEncoding.DecodeString(b.CertChain)
+			if err != nil {
+				return fmt.Errorf("decoding certificate chain: %w", err)
+			}
+		}
+		if b.RFC3161Timestamp != nil && c.RFC3161TimestampPath == "" {
+			if co.TSARootCertificates == nil {
+				return errors.New("bundle contains an RFC3161 timestamp, please provide the TSA certificate chain with --timestamp-certificate-chain or use --use-signed-timestamps")
+			}
+			opts = append(opts, static.WithRFC3161Timestamp(b.RFC3161Timestamp))
+		}
 	}
 	if c.RFC3161TimestampPath != "" {
 		var rfc3161Timestamp bundle.RFC3161Timestamp
@@ -296,8 +351,9 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 		}
 		co.SCT = sct
 	}
-	// Set a cert chain if provided.
-	var chainPEM []byte
+	// Set a cert chain if provided. Otherwise, the chain in the bundle
+	// provides the intermediates, while the roots remain the trusted ones.
+	chainPEM := bundleChainPEM
 	if c.CertChain != "" {
 		chain, err := loadCertChainFromFileOrURL(c.CertChain)
 		if err != nil {
@@ -349,6 +405,95 @@ func (c *VerifyBlobAttestationCommand) Exec(ctx context.Context, artifactPath st
 		return fmt.Errorf("invalid predicate type, expected %s got %s", c.PredicateType, gotPredicateType)
 	}
 
+	if files != nil {
+		envelope, err := signature.Payload()
+		if err != nil {
+			return err
+		}
+		env := ssldsse.Envelope{}
+		if err := json.Unmarshal(envelope, &env); err != nil {
+			return fmt.Errorf("decoding envelope: %w", err)
+		}
+		statement, err := env.DecodeB64Payload()
+		if err != nil {
+			return fmt.Errorf("decoding envelope payload: %w", err)
+		}
+		if err := checkDirectorySubjects(statement, files); err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintln(os.Stderr, "Verified OK")
 	return nil
 }
+
+// verifyBundleInclusionProof verifies the inclusion proof of the tlog entry
+// of the bundle b against the trusted Rekor public keys, without contacting
+// the log.
+func verifyBundleInclusionProof(ctx context.Context, b *cosign.LocalSignedPayload, rekorPubKeys *cosign.TrustedTransparencyLogPubKeys) error {
+	body, ok := b.Bundle.Payload.Body.(string)
+	if !ok {
+		return errors.New("bundle tlog entry body is not a string")
+	}
+	entry := &models.LogEntryAnon{
+		Body:           body,
+		IntegratedTime: &b.Bundle.Payload.IntegratedTime,
+		LogIndex:       &b.Bundle.Payload.LogIndex,
+		LogID:          &b.Bundle.Payload.LogID,
+		Verification: &models.LogEntryAnonVerification{
+			InclusionProof:       b.InclusionProof,
+			SignedEntryTimestamp: strfmt.Base64(b.Bundle.SignedEntryTimestamp),
+		},
+	}
+	if err := cosign.VerifyTLogEntryOffline(ctx, entry, rekorPubKeys); err != nil {
+		return fmt.Errorf("verifying bundle tlog entry: %w", err)
+	}
+	return nil
+}
+
+// checkBundleDirectorySubjects checks the subjects of the attestation in the
+// bundle at bundlePath against the files of a directory.
+func checkBundleDirectorySubjects(bundlePath string, files []blob.File) error {
+	b, err := sgbundle.LoadJSONFromPath(bundlePath)
+	if err != nil {
+		return err
+	}
+	envelope := b.GetDsseEnvelope()
+	if envelope == nil {
+		return errors.New("bundle does not contain a DSSE envelope")
+	}
+	return checkDirectorySubjects(envelope.GetPayload(), files)
+}
+
+// checkDirectorySubjects checks that the subjects of the in-toto statement
+// are exactly the files of a directory, with their digests, so that files
+// added to or removed from the directory are reported as well as modified
+// ones.
+func checkDirectorySubjects(statement []byte, files []blob.File) error {
+	var s in_toto.Statement
+	if err := json.Unmarshal(statement, &s); err != nil {
+		return fmt.Errorf("decoding in-toto statement: %w", err)
+	}
+	digests := make(map[string]string, len(files))
+	for _, f := range files {
+		digests[f.Path] = f.SHA256
+	}
+	var errs []error
+	attested := make(map[string]bool, len(s.Subject))
+	for _, subject := range s.Subject {
+		attested[subject.Name] = true
+		digest, ok := digests[subject.Name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s is attested but not in the directory", subject.Name))
+		case subject.Digest["sha256"] != digest:
+			errs = append(errs, fmt.Errorf("%s does not match its attested digest", subject.Name))
+		}
+	}
+	for _, f := range files {
+		if !attested[f.Path] {
+			errs = append(errs, fmt.Errorf("%s is in the directory but not attested", f.Path))
+		}
+	}
+	return errors.Join(errs...)
+}
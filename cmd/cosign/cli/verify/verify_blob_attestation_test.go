@@ -15,18 +15,39 @@
 package verify
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/franchb/rekor/pkg/generated/models"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/dsse"
+	"github.com/franchb/sigstore/pkg/tuf"
+	"github.com/go-openapi/swag"
+	"github.com/in-toto/in-toto-golang/in_toto"
 	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
 	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	"github.com/transparency-dev/merkle/rfc6962"
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	ctypes "github.com/franchb/cosign/v2/pkg/types"
 )
 
 const pubkey = `-----BEGIN PUBLIC KEY-----
@@ -160,6 +181,112 @@ func TestVerifyBlobAttestation(t *testing.T) {
 	}
 }
 
+func TestVerifyBlobAttestationDirectory(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyRef := writeBlobFile(t, td, string(pubPEM), "cosign.pub")
+
+	files := map[string]string{
+		"README.md":   "readme",
+		"bin/tool":    "tool",
+		"bin/tool.sh": "script",
+	}
+	var subjects []in_toto.Subject
+	for name, contents := range files {
+		digest := sha256.Sum256([]byte(contents))
+		subjects = append(subjects, in_toto.Subject{Name: name, Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}})
+	}
+	stmt, err := json.Marshal(in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: "https://cosign.sigstore.dev/attestation/v1",
+			Subject:       subjects,
+		},
+		Predicate: map[string]string{"Data": "release"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := dsse.WrapSigner(signer, ctypes.IntotoPayloadType).SignMessage(bytes.NewReader(stmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigRef := writeBlobFile(t, td, string(envelope), "dist.intoto.jsonl")
+
+	writeDir := func(t *testing.T, files map[string]string) string {
+		dir := t.TempDir()
+		for name, contents := range files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	with := func(name, contents string) map[string]string {
+		m := maps.Clone(files)
+		m[name] = contents
+		return m
+	}
+	without := func(name string) map[string]string {
+		m := maps.Clone(files)
+		delete(m, name)
+		return m
+	}
+
+	for _, tc := range []struct {
+		description string
+		files       map[string]string
+		shouldErr   bool
+	}{{
+		description: "attested files",
+		files:       files,
+	}, {
+		description: "modified file",
+		files:       with("bin/tool", "modified"),
+		shouldErr:   true,
+	}, {
+		description: "added file",
+		files:       with("bin/other", "other"),
+		shouldErr:   true,
+	}, {
+		description: "removed file",
+		files:       without("README.md"),
+		shouldErr:   true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			cmd := VerifyBlobAttestationCommand{
+				KeyOpts:       options.KeyOpts{KeyRef: keyRef},
+				SignaturePath: sigRef,
+				IgnoreTlog:    true,
+				CheckClaims:   true,
+				PredicateType: "custom",
+				Directory:     writeDir(t, tc.files),
+			}
+			err := cmd.Exec(ctx, "")
+			if (err != nil) != tc.shouldErr {
+				t.Fatalf("verifyBlobAttestation()= %s, expected shouldErr=%t ", err, tc.shouldErr)
+			}
+		})
+	}
+}
+
 func TestVerifyBlobAttestationNoCheckClaims(t *testing.T) {
 	ctx := context.Background()
 	td := t.TempDir()
@@ -256,3 +383,78 @@ func makeLocalAttestNewBundle(t *testing.T, payload, payloadType, sig string) st
 	}
 	return bundlePath
 }
+
+func TestVerifyBundleInclusionProof(t *testing.T) {
+	ctx := context.Background()
+	rekorPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorSigner, err := signature.LoadECDSASignerVerifier(rekorPriv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorPubPEM, err := cryptoutils.MarshalPublicKeyToPEM(rekorPriv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorPubKeys := cosign.NewTrustedTransparencyLogPubKeys()
+	if err := rekorPubKeys.AddTransparencyLogPubKey(rekorPubPEM, tuf.Active); err != nil {
+		t.Fatal(err)
+	}
+	logID, err := getLogID(rekorPriv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A log of a single entry, whose root hash is the hash of that entry.
+	entryBytes := []byte(`{"kind":"dsse"}`)
+	payload := bundle.RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(entryBytes),
+		IntegratedTime: time.Now().Unix(),
+		LogIndex:       0,
+		LogID:          logID,
+	}
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalized, err := jsoncanonicalizer.Transform(jsonPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := rekorSigner.SignMessage(bytes.NewReader(canonicalized))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootHash := hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(entryBytes))
+
+	for _, tc := range []struct {
+		name      string
+		rootHash  string
+		shouldErr bool
+	}{{
+		name:     "valid proof",
+		rootHash: rootHash,
+	}, {
+		name:      "root hash mismatch",
+		rootHash:  hex.EncodeToString(make([]byte, 32)),
+		shouldErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &cosign.LocalSignedPayload{
+				Bundle: &bundle.RekorBundle{SignedEntryTimestamp: set, Payload: payload},
+				InclusionProof: &models.InclusionProof{
+					LogIndex: swag.Int64(0),
+					TreeSize: swag.Int64(1),
+					RootHash: swag.String(tc.rootHash),
+					Hashes:   []string{},
+				},
+			}
+			err := verifyBundleInclusionProof(ctx, b, &rekorPubKeys)
+			if (err != nil) != tc.shouldErr {
+				t.Fatalf("verifyBundleInclusionProof()= %v, expected shouldErr=%t", err, tc.shouldErr)
+			}
+		})
+	}
+}
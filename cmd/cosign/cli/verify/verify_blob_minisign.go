@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/blob"
+	"github.com/franchb/cosign/v2/pkg/cosign/minisign"
+)
+
+// verifyMinisign checks the minisign or signify signature c.SigRef of
+// blobRef against the public key c.KeyRef refers to, like minisign -V.
+// These signatures are not checked against the transparency log.
+func (c *VerifyBlobCmd) verifyMinisign(ctx context.Context, blobRef string) error {
+	if c.BundlePath != "" || c.SigRef == "" {
+		return errors.New("minisign signatures must be given with --signature")
+	}
+	path, err := minisign.KeyPath(c.KeyRef)
+	if err != nil {
+		return err
+	}
+	kb, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pk, err := minisign.ParsePublicKey(kb)
+	if err != nil {
+		return fmt.Errorf("parsing public key %s: %w", path, err)
+	}
+
+	// The signature may be given as its content.
+	sigBytes := []byte(c.SigRef)
+	if !strings.HasPrefix(strings.TrimSpace(c.SigRef), "untrusted comment:") {
+		if sigBytes, err = blob.LoadFileOrURL(c.SigRef); err != nil {
+			return err
+		}
+	}
+	sig, err := minisign.ParseSignature(sigBytes)
+	if err != nil {
+		return err
+	}
+	blobBytes, err := payloadBytes(blobRef)
+	if err != nil {
+		return err
+	}
+
+	if err := pk.Verify(sig, bytes.NewReader(blobBytes)); err != nil {
+		return fmt.Errorf("verifying minisign signature: %w", err)
+	}
+	if sig.GlobalSignature != nil {
+		ui.Infof(ctx, "Trusted comment: %s", sig.TrustedComment)
+	}
+	ui.Infof(ctx, "Verified OK")
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/minisign"
+)
+
+func TestVerifyBlobMinisign(t *testing.T) {
+	td := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &minisign.PrivateKey{KeyID: minisign.KeyID{1, 2, 3, 4, 5, 6, 7, 8}, Key: priv}
+	sig, err := minisign.Sign(key, strings.NewReader("foo"), "signature from minisign secret key", "timestamp:1700000000\tfile:foo.txt\thashed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPub := append(append([]byte("Ed"), key.KeyID[:]...), pub...)
+	pubPath := writeBlobFile(t, td, "untrusted comment: minisign public key 0807060504030201\n"+base64.StdEncoding.EncodeToString(rawPub)+"\n", "minisign.pub")
+	blobPath := writeBlobFile(t, td, "foo", "foo.txt")
+	otherBlobPath := writeBlobFile(t, td, "bar", "bar.txt")
+	sigPath := writeBlobFile(t, td, string(sig), "foo.txt.minisig")
+
+	tests := []struct {
+		name     string
+		blobPath string
+		sigRef   string
+		wantErr  bool
+	}{{
+		name:     "signature file",
+		blobPath: blobPath,
+		sigRef:   sigPath,
+	}, {
+		name:     "signature content",
+		blobPath: blobPath,
+		sigRef:   string(sig),
+	}, {
+		name:     "other blob",
+		blobPath: otherBlobPath,
+		sigRef:   sigPath,
+		wantErr:  true,
+	}, {
+		name:     "missing signature",
+		blobPath: blobPath,
+		wantErr:  true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &VerifyBlobCmd{
+				KeyOpts: options.KeyOpts{KeyRef: minisign.ReferenceScheme + pubPath},
+				SigRef:  tc.sigRef,
+			}
+			err := cmd.Exec(context.Background(), tc.blobPath)
+			if tc.wantErr && err == nil {
+				t.Error("Exec() succeeded")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("Exec() = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/blob"
+	"github.com/franchb/cosign/v2/pkg/cosign/pgp"
+)
+
+// verifyPGP checks the OpenPGP detached signature c.SigRef of blobRef
+// against the keyring c.KeyRef refers to, like gpg --verify. These
+// signatures are not checked against the transparency log.
+func (c *VerifyBlobCmd) verifyPGP(ctx context.Context, blobRef string) error {
+	if c.BundlePath != "" || c.SigRef == "" {
+		return errors.New("OpenPGP signatures must be given with --signature")
+	}
+	path, err := pgp.KeyPath(c.KeyRef)
+	if err != nil {
+		return err
+	}
+	kb, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	keyring, err := pgp.ParseKeyRing(kb)
+	if err != nil {
+		return fmt.Errorf("parsing keyring %s: %w", path, err)
+	}
+
+	// The signature may be given as its armored content.
+	sig := []byte(c.SigRef)
+	if !strings.HasPrefix(strings.TrimSpace(c.SigRef), "-----BEGIN PGP SIGNATURE") {
+		if sig, err = blob.LoadFileOrURL(c.SigRef); err != nil {
+			return err
+		}
+	}
+	blobBytes, err := payloadBytes(blobRef)
+	if err != nil {
+		return err
+	}
+
+	v, err := pgp.Verify(keyring, sig, bytes.NewReader(blobBytes))
+	if err != nil {
+		return fmt.Errorf("verifying OpenPGP signature: %w", err)
+	}
+	ui.Infof(ctx, "Good signature from %q made %s by key %s", v.Identity, v.Created.Format(time.RFC3339), v.Fingerprint)
+	ui.Infof(ctx, "Verified OK")
+	return nil
+}
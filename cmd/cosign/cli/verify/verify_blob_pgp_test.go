@@ -0,0 +1,112 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/pgp"
+)
+
+func TestVerifyBlobPGP(t *testing.T) {
+	td := t.TempDir()
+	newEntity := func(name string) *openpgp.Entity {
+		e, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	alice, bob := newEntity("alice"), newEntity("bob")
+	sig, err := pgp.Sign(alice, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := func(name string, entities ...*openpgp.Entity) string {
+		var b bytes.Buffer
+		w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entities {
+			if err := e.Serialize(w); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Close()
+		return writeBlobFile(t, td, b.String(), name)
+	}
+	releaseKeys := keyring("release-keys.asc", bob, alice)
+	otherKeys := keyring("other-keys.asc", bob)
+	blobPath := writeBlobFile(t, td, "foo", "foo.txt")
+	otherBlobPath := writeBlobFile(t, td, "bar", "bar.txt")
+	sigPath := writeBlobFile(t, td, string(sig), "foo.txt.asc")
+
+	tests := []struct {
+		name     string
+		keyring  string
+		blobPath string
+		sigRef   string
+		wantErr  bool
+	}{{
+		name:     "signature file",
+		keyring:  releaseKeys,
+		blobPath: blobPath,
+		sigRef:   sigPath,
+	}, {
+		name:     "signature content",
+		keyring:  releaseKeys,
+		blobPath: blobPath,
+		sigRef:   string(sig),
+	}, {
+		name:     "other blob",
+		keyring:  releaseKeys,
+		blobPath: otherBlobPath,
+		sigRef:   sigPath,
+		wantErr:  true,
+	}, {
+		name:     "signer not in keyring",
+		keyring:  otherKeys,
+		blobPath: blobPath,
+		sigRef:   sigPath,
+		wantErr:  true,
+	}, {
+		name:     "missing signature",
+		keyring:  releaseKeys,
+		blobPath: blobPath,
+		wantErr:  true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &VerifyBlobCmd{
+				KeyOpts: options.KeyOpts{KeyRef: pgp.ReferenceScheme + tc.keyring},
+				SigRef:  tc.sigRef,
+			}
+			err := cmd.Exec(context.Background(), tc.blobPath)
+			if tc.wantErr && err == nil {
+				t.Error("Exec() succeeded")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("Exec() = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/blob"
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
+)
+
+// verifySSH checks the SSH signature c.SigRef of blobRef against the allowed
+// signers file c.KeyRef refers to, like ssh-keygen -Y verify. SSH
+// signatures are not checked against the transparency log.
+func (c *VerifyBlobCmd) verifySSH(ctx context.Context, blobRef string) error {
+	if c.SSHIdentity == "" {
+		return errors.New("--ssh-identity is required to verify against an allowed signers file")
+	}
+	if c.BundlePath != "" || c.SigRef == "" {
+		return errors.New("SSH signatures must be given with --signature")
+	}
+	path, err := sshsig.KeyPath(c.KeyRef)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	signers, err := sshsig.ParseAllowedSigners(f)
+	if err != nil {
+		return fmt.Errorf("parsing allowed signers %s: %w", path, err)
+	}
+
+	// The signature may be given as its content.
+	sigBytes := []byte(c.SigRef)
+	if !strings.HasPrefix(strings.TrimSpace(c.SigRef), "-----BEGIN") {
+		if sigBytes, err = blob.LoadFileOrURL(c.SigRef); err != nil {
+			return err
+		}
+	}
+	sig, err := sshsig.ParseSignature(sigBytes)
+	if err != nil {
+		return err
+	}
+	blobBytes, err := payloadBytes(blobRef)
+	if err != nil {
+		return err
+	}
+
+	namespace := c.SSHNamespace
+	if namespace == "" {
+		namespace = sshsig.DefaultNamespace
+	}
+	if err := signers.Verify(sig, bytes.NewReader(blobBytes), c.SSHIdentity, namespace, time.Now()); err != nil {
+		return fmt.Errorf("verifying SSH signature: %w", err)
+	}
+	ui.Infof(ctx, "Verified OK")
+	return nil
+}
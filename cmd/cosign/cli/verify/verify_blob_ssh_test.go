@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign/sshsig"
+)
+
+func TestVerifyBlobSSH(t *testing.T) {
+	td := t.TempDir()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromSigner(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sshsig.Sign(rand.Reader, signer, strings.NewReader("foo"), sshsig.DefaultNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobPath := writeBlobFile(t, td, "foo", "foo.txt")
+	sigPath := writeBlobFile(t, td, string(sig), "foo.txt.sig")
+	allowedSigners := writeBlobFile(t, td, "alice@example.com "+string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "allowed_signers")
+
+	tests := []struct {
+		name      string
+		identity  string
+		namespace string
+		sigRef    string
+		wantErr   bool
+	}{{
+		name:      "allowed signer",
+		identity:  "alice@example.com",
+		namespace: sshsig.DefaultNamespace,
+		sigRef:    sigPath,
+	}, {
+		name:      "signature content",
+		identity:  "alice@example.com",
+		namespace: sshsig.DefaultNamespace,
+		sigRef:    string(sig),
+	}, {
+		name:      "other identity",
+		identity:  "bob@example.com",
+		namespace: sshsig.DefaultNamespace,
+		sigRef:    sigPath,
+		wantErr:   true,
+	}, {
+		name:      "other namespace",
+		identity:  "alice@example.com",
+		namespace: "git",
+		sigRef:    sigPath,
+		wantErr:   true,
+	}, {
+		name:      "missing identity",
+		namespace: sshsig.DefaultNamespace,
+		sigRef:    sigPath,
+		wantErr:   true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &VerifyBlobCmd{
+				KeyOpts: options.KeyOpts{
+					KeyRef:       sshsig.ReferenceScheme + allowedSigners,
+					SSHNamespace: tc.namespace,
+				},
+				SigRef:      tc.sigRef,
+				SSHIdentity: tc.identity,
+			}
+			err := cmd.Exec(context.Background(), blobPath)
+			if tc.wantErr && err == nil {
+				t.Error("Exec() succeeded")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("Exec() = %v", err)
+			}
+		})
+	}
+}
@@ -18,7 +18,9 @@ package verify
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	sgbundle "github.com/franchb/sigstore-go/pkg/bundle"
@@ -26,6 +28,7 @@ import (
 	"github.com/franchb/sigstore-go/pkg/root"
 	"github.com/franchb/sigstore-go/pkg/verify"
 
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/pivkey"
 	sigs "github.com/franchb/cosign/v2/pkg/signature"
 )
@@ -46,6 +49,7 @@ func verifyNewBundle(ctx context.Context, bundlePath, trustedRootPath, keyRef, s
 	}
 
 	var trustedroot *root.TrustedRoot
+	var witnesses *cosign.CheckpointWitnesses
 
 	if trustedRootPath == "" {
 		// Assume we're using public good instance; fetch via TUF
@@ -54,7 +58,7 @@ func verifyNewBundle(ctx context.Context, bundlePath, trustedRootPath, keyRef, s
 			return err
 		}
 	} else {
-		trustedroot, err = root.NewTrustedRootFromPath(trustedRootPath)
+		trustedroot, witnesses, err = loadTrustedRootWithWitnesses(trustedRootPath)
 		if err != nil {
 			return err
 		}
@@ -158,6 +162,49 @@ func verifyNewBundle(ctx context.Context, bundlePath, trustedRootPath, keyRef, s
 		return err
 	}
 
-	_, err = sev.Verify(bundle, verify.NewPolicy(verify.WithArtifact(buf), identityPolicies...))
-	return err
+	if _, err = sev.Verify(bundle, verify.NewPolicy(verify.WithArtifact(buf), identityPolicies...)); err != nil {
+		return err
+	}
+
+	if witnesses != nil && !ignoreTlog {
+		return verifyBundleWitnesses(bundle, witnesses)
+	}
+	return nil
+}
+
+// loadTrustedRootWithWitnesses reads the trusted root at path, along with
+// the checkpoint witnesses it configures, if any.
+func loadTrustedRootWithWitnesses(path string) (*root.TrustedRoot, *cosign.CheckpointWitnesses, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	rootJSON, witnesses, err := cosign.SplitTrustedRootWitnesses(b)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing trusted root: %w", err)
+	}
+	trustedroot, err := root.NewTrustedRootFromJSON(rootJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return trustedroot, witnesses, nil
+}
+
+// verifyBundleWitnesses verifies that the checkpoints of the inclusion
+// proofs of every tlog entry of bundle are cosigned by the witnesses.
+func verifyBundleWitnesses(bundle *sgbundle.Bundle, witnesses *cosign.CheckpointWitnesses) error {
+	entries := bundle.GetVerificationMaterial().GetTlogEntries()
+	if len(entries) == 0 {
+		return errors.New("bundle holds no tlog entry to verify the witness cosignatures of")
+	}
+	for _, e := range entries {
+		proof := e.GetInclusionProof()
+		if proof == nil {
+			return fmt.Errorf("tlog entry %d holds no inclusion proof to verify the witness cosignatures of", e.GetLogIndex())
+		}
+		if err := cosign.VerifyCheckpointWitnesses(proof.GetCheckpoint().GetEnvelope(), proof.GetTreeSize(), proof.GetRootHash(), witnesses); err != nil {
+			return fmt.Errorf("tlog entry %d: %w", e.GetLogIndex(), err)
+		}
+	}
+	return nil
 }
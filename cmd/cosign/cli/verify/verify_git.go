@@ -0,0 +1,167 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	tsaverification "github.com/sigstore/timestamp-authority/pkg/verification"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/git"
+	sigs "github.com/franchb/cosign/v2/pkg/signature"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+// VerifyGitCommand verifies the x509 signatures of git commits and
+// annotated tags.
+type VerifyGitCommand struct {
+	options.CertVerifyOptions
+	KeyRef              string
+	Repository          string
+	TSACertChainPath    string
+	UseSignedTimestamps bool
+}
+
+// Exec verifies the signature of the git object of type objectType rev
+// refers to, a commit or an annotated tag.
+func (c *VerifyGitCommand) Exec(ctx context.Context, objectType, rev string) error {
+	if c.Cert != "" || c.SCT != "" {
+		return errors.New("--certificate and --sct are not supported by verify-git, the certificate is part of the signature")
+	}
+
+	repo := git.Repository{Dir: c.Repository}
+	id, err := repo.ResolveObject(ctx, rev, objectType)
+	if err != nil {
+		return err
+	}
+	content, err := repo.ReadObject(ctx, objectType, id)
+	if err != nil {
+		return err
+	}
+	payload, sig, err := git.SplitSignature(objectType, content)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", objectType, id, err)
+	}
+	s, err := git.VerifySignature(payload, sig)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", objectType, id, err)
+	}
+
+	co := &cosign.CheckOpts{
+		CertGithubWorkflowTrigger:    c.CertGithubWorkflowTrigger,
+		CertGithubWorkflowSha:        c.CertGithubWorkflowSha,
+		CertGithubWorkflowName:       c.CertGithubWorkflowName,
+		CertGithubWorkflowRepository: c.CertGithubWorkflowRepository,
+		CertGithubWorkflowRef:        c.CertGithubWorkflowRef,
+		IgnoreSCT:                    c.IgnoreSCT,
+	}
+	if c.KeyRef == "" || certifiedKeyVerification(c.KeyRef, c.CertVerifyOptions) {
+		if co.Identities, err = c.Identities(); err != nil {
+			return err
+		}
+	}
+
+	if c.KeyRef != "" {
+		// The certificate of a key is not verified, only that it holds the
+		// key and, when given, the expected identity.
+		verifier, err := sigs.PublicKeyFromKeyRef(ctx, c.KeyRef)
+		if err != nil {
+			return fmt.Errorf("loading public key: %w", err)
+		}
+		pub, err := verifier.PublicKey()
+		if err != nil {
+			return err
+		}
+		if err := cryptoutils.EqualKeys(pub, s.Certificate.PublicKey); err != nil {
+			return fmt.Errorf("%s %s is not signed with the key: %w", objectType, id, err)
+		}
+		if len(co.Identities) > 0 {
+			if err := cosign.CheckCertificatePolicy(s.Certificate, co); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := loadCertsKeylessVerification(c.CertChain, c.CARoots, c.CAIntermediates, co); err != nil {
+			return err
+		}
+		// Signatures carry the intermediate certificates of their chain.
+		intermediates := x509.NewCertPool()
+		if co.IntermediateCerts != nil {
+			intermediates = co.IntermediateCerts.Clone()
+		}
+		for _, cert := range s.Intermediates {
+			intermediates.AddCert(cert)
+		}
+		if shouldVerifySCT(c.IgnoreSCT, c.KeyRef, false) {
+			if co.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx); err != nil {
+				return fmt.Errorf("getting ctlog public keys: %w", err)
+			}
+		}
+		if _, err := cosign.ValidateAndUnpackCertWithIntermediates(s.Certificate, co, intermediates); err != nil {
+			return err
+		}
+	}
+
+	if c.TSACertChainPath != "" || c.UseSignedTimestamps {
+		t, err := c.verifyGitTimestamp(ctx, s)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", objectType, id, err)
+		}
+		if err := cosign.CheckExpiry(s.Certificate, t); err != nil {
+			return fmt.Errorf("%s %s: %w", objectType, id, err)
+		}
+	} else if c.KeyRef == "" {
+		ui.Warnf(ctx, "The signature of %s %s is not verified with a timestamp, relying on the signing time %s it claims; pass --timestamp-certificate-chain or --use-signed-timestamps to verify its timestamp",
+			objectType, id, s.SigningTime.Format(time.RFC3339))
+	}
+
+	ui.Infof(ctx, "Verified OK: %s %s signed by %v", objectType, id, cryptoutils.GetSubjectAlternateNames(s.Certificate))
+	return nil
+}
+
+// verifyGitTimestamp verifies the RFC3161 timestamp of the signature s with
+// the trusted timestamp authorities, returning its time.
+func (c *VerifyGitCommand) verifyGitTimestamp(ctx context.Context, s *git.Signature) (time.Time, error) {
+	if s.Timestamp == nil {
+		return time.Time{}, errors.New("signature has no timestamp")
+	}
+	tsaCertificates, err := cosign.GetTSACerts(ctx, c.TSACertChainPath, cosign.GetTufTargets)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to load TSA certificates: %w", err)
+	}
+	tsaCerts := append([]*x509.Certificate{tsaCertificates.LeafCert}, tsaCertificates.AdditionalLeafCerts...)
+	var errs []error
+	for _, tsaCert := range tsaCerts {
+		ts, err := tsaverification.VerifyTimestampResponse(s.Timestamp, bytes.NewReader(s.Value),
+			tsaverification.VerifyOpts{
+				TSACertificate: tsaCert,
+				Intermediates:  tsaCertificates.IntermediateCerts,
+				Roots:          tsaCertificates.RootCert,
+			})
+		if err == nil {
+			return ts.Time, nil
+		}
+		errs = append(errs, err)
+	}
+	return time.Time{}, fmt.Errorf("verifying timestamp: %w", errors.Join(errs...))
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/x509"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa"
+	tsaMock "github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/mock"
+	"github.com/franchb/cosign/v2/pkg/git"
+	"github.com/franchb/cosign/v2/test"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+func TestVerifyGitCommand(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	td := t.TempDir()
+	run := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", td, "-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com"}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	repo := git.Repository{Dir: td}
+
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+	leafCert, leafKey, _ := test.GenerateLeafCert("jane@example.com", "https://accounts.example.com", subCert, subKey)
+	tsaClient, err := tsaMock.NewTSAClient(tsaMock.TSAClientOptions{Time: time.Now()})
+	require.NoError(t, err)
+	sign := func(objectType, ref string) {
+		id, err := repo.ResolveObject(ctx, ref, objectType)
+		require.NoError(t, err)
+		content, err := repo.ReadObject(ctx, objectType, id)
+		require.NoError(t, err)
+		sig, err := git.Sign(content, leafKey, leafCert, []*x509.Certificate{subCert}, func(sig []byte) ([]byte, error) {
+			return tsa.GetTimestampedSignature(sig, tsaClient)
+		})
+		require.NoError(t, err)
+		signed, err := git.AddSignature(objectType, content, sig)
+		require.NoError(t, err)
+		signedID, err := repo.WriteObject(ctx, objectType, signed)
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateRef(ctx, ref, signedID, id, "sign"))
+	}
+
+	// A signed commit with a signed tag, followed by an unsigned commit.
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	sign(git.ObjectCommit, "HEAD")
+	run("tag", "-a", "-m", "Release v1.0.0", "v1.0.0")
+	sign(git.ObjectTag, "refs/tags/v1.0.0")
+	run("commit", "-q", "--allow-empty", "-m", "Unsigned commit")
+
+	writePEM := func(name string, certs ...*x509.Certificate) string {
+		b, err := cryptoutils.MarshalCertificatesToPEM(certs)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, b, 0o600))
+		return path
+	}
+	caRoots := writePEM("roots.pem", rootCert)
+	tsaChain := writePEM("tsa.pem", tsaClient.CertChain...)
+	pubKey, err := cryptoutils.MarshalPublicKeyToPEM(leafKey.Public())
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(keyPath, pubKey, 0o600))
+	_, otherKey, _ := test.GenerateLeafCert("john@example.com", "https://accounts.example.com", subCert, subKey)
+	otherPubKey, err := cryptoutils.MarshalPublicKeyToPEM(otherKey.Public())
+	require.NoError(t, err)
+	otherKeyPath := filepath.Join(t.TempDir(), "other.pub")
+	require.NoError(t, os.WriteFile(otherKeyPath, otherPubKey, 0o600))
+
+	keyless := options.CertVerifyOptions{
+		CertIdentity:   "jane@example.com",
+		CertOidcIssuer: "https://accounts.example.com",
+		CARoots:        caRoots,
+		IgnoreSCT:      true,
+	}
+	wrongIdentity := keyless
+	wrongIdentity.CertIdentity = "john@example.com"
+
+	for _, tc := range []struct {
+		name       string
+		cmd        VerifyGitCommand
+		objectType string
+		rev        string
+		wantErr    bool
+	}{{
+		name:       "keyless commit",
+		cmd:        VerifyGitCommand{CertVerifyOptions: keyless},
+		objectType: git.ObjectCommit,
+		rev:        "v1.0.0^{commit}",
+	}, {
+		name:       "keyless tag with timestamp",
+		cmd:        VerifyGitCommand{CertVerifyOptions: keyless, TSACertChainPath: tsaChain},
+		objectType: git.ObjectTag,
+		rev:        "refs/tags/v1.0.0",
+	}, {
+		name:       "key",
+		cmd:        VerifyGitCommand{KeyRef: keyPath},
+		objectType: git.ObjectTag,
+		rev:        "refs/tags/v1.0.0",
+	}, {
+		name:       "key with identity",
+		cmd:        VerifyGitCommand{KeyRef: keyPath, CertVerifyOptions: options.CertVerifyOptions{CertIdentity: "jane@example.com", CertOidcIssuer: "https://accounts.example.com"}},
+		objectType: git.ObjectCommit,
+		rev:        "v1.0.0^{commit}",
+	}, {
+		name:       "other key",
+		cmd:        VerifyGitCommand{KeyRef: otherKeyPath},
+		objectType: git.ObjectCommit,
+		rev:        "v1.0.0^{commit}",
+		wantErr:    true,
+	}, {
+		name:       "wrong identity",
+		cmd:        VerifyGitCommand{CertVerifyOptions: wrongIdentity},
+		objectType: git.ObjectCommit,
+		rev:        "v1.0.0^{commit}",
+		wantErr:    true,
+	}, {
+		name:       "untrusted root",
+		cmd:        VerifyGitCommand{CertVerifyOptions: options.CertVerifyOptions{CertIdentity: "jane@example.com", CertOidcIssuer: "https://accounts.example.com", CARoots: tsaChain, IgnoreSCT: true}},
+		objectType: git.ObjectCommit,
+		rev:        "v1.0.0^{commit}",
+		wantErr:    true,
+	}, {
+		name:       "unsigned commit",
+		cmd:        VerifyGitCommand{CertVerifyOptions: keyless},
+		objectType: git.ObjectCommit,
+		rev:        "HEAD",
+		wantErr:    true,
+	}, {
+		name:       "certificate flag",
+		cmd:        VerifyGitCommand{KeyRef: keyPath, CertVerifyOptions: options.CertVerifyOptions{Cert: caRoots}},
+		objectType: git.ObjectTag,
+		rev:        "refs/tags/v1.0.0",
+		wantErr:    true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cmd.Repository = td
+			err := tc.cmd.Exec(ctx, tc.objectType, tc.rev)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
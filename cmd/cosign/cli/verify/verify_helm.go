@@ -0,0 +1,140 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/helm"
+)
+
+// verifyHelmCharts verifies the Helm charts of the helm:// references charts
+// in place of helm's PGP provenance files: each chart must have a verified
+// attestation of c.HelmProvenanceType whose statement names both its
+// manifest and its chart archive as subjects.
+func (c *VerifyCommand) verifyHelmCharts(ctx context.Context, charts []string) error {
+	switch {
+	case c.LocalImage, c.Recursive, c.Platform != "", c.Attachment != "", c.ChangedSince != "":
+		return errors.New("--local-image, --recursive, --platform, --attachment and --changed-since cannot be used with Helm charts")
+	case c.SignatureRef != "" || c.PayloadRef != "":
+		return errors.New("--signature and --payload cannot be used with Helm charts")
+	case len(c.Annotations.Annotations) > 0 || len(c.AnnotationConditions) > 0:
+		return errors.New("annotations cannot be checked for Helm charts, whose attestations carry none")
+	}
+
+	for _, s := range charts {
+		if !helm.IsReference(s) {
+			return fmt.Errorf("%s cannot be verified together with Helm charts", s)
+		}
+	}
+
+	ociremoteOpts, err := c.ClientOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("constructing client options: %w", err)
+	}
+	for _, s := range charts {
+		ref, err := helm.ParseReference(s, c.NameOptions...)
+		if err != nil {
+			return err
+		}
+		chart, err := helm.Resolve(ref, ociremoteOpts...)
+		if err != nil {
+			return err
+		}
+		ui.Infof(ctx, "Verifying version %s of Helm chart %s, archive %s", chart.Version, chart.Name, chart.ArchiveDigest)
+
+		vc := c.helmAttestationCommand()
+		vc.evaluators = append(vc.evaluators, archiveSubjectEvaluator(chart.ArchiveDigest))
+		if err := vc.Exec(ctx, []string{chart.Digest.String()}); err != nil {
+			return fmt.Errorf("verifying Helm chart %s: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// helmAttestationCommand returns the command verifying the provenance
+// attestations of Helm charts with the options of c.
+func (c *VerifyCommand) helmAttestationCommand() *VerifyAttestationCommand {
+	return &VerifyAttestationCommand{
+		RegistryOptions:              c.RegistryOptions,
+		CertVerifyOptions:            c.CertVerifyOptions,
+		CheckClaims:                  c.CheckClaims,
+		KeyRef:                       c.KeyRef,
+		CertRef:                      c.CertRef,
+		CertGithubWorkflowTrigger:    c.CertGithubWorkflowTrigger,
+		CertGithubWorkflowSha:        c.CertGithubWorkflowSha,
+		CertGithubWorkflowName:       c.CertGithubWorkflowName,
+		CertGithubWorkflowRepository: c.CertGithubWorkflowRepository,
+		CertGithubWorkflowRef:        c.CertGithubWorkflowRef,
+		CAIntermediates:              c.CAIntermediates,
+		CARoots:                      c.CARoots,
+		CertChain:                    c.CertChain,
+		IgnoreSCT:                    c.IgnoreSCT,
+		SCTRef:                       c.SCTRef,
+		Sk:                           c.Sk,
+		Slot:                         c.Slot,
+		Output:                       c.Output,
+		Redactions:                   c.Redactions,
+		RekorURL:                     c.RekorURL,
+		AdditionalRekorURLs:          c.AdditionalRekorURLs,
+		PredicateType:                c.HelmProvenanceType,
+		NameOptions:                  c.NameOptions,
+		Offline:                      c.Offline,
+		TSACertChainPath:             c.TSACertChainPath,
+		UseSignedTimestamps:          c.UseSignedTimestamps,
+		IgnoreTlog:                   c.IgnoreTlog,
+		MaxWorkers:                   c.MaxWorkers,
+		RequiredSignatures:           c.RequiredSignatures,
+		ExperimentalOCI11:            c.ExperimentalOCI11,
+		SignatureMirrors:             c.SignatureMirrors,
+		RegistryMirrorConfig:         c.RegistryMirrorConfig,
+		SignatureCacheDir:            c.SignatureCacheDir,
+		SignatureCacheTTL:            c.SignatureCacheTTL,
+	}
+}
+
+// archiveSubjectEvaluator fails statements that do not name the chart
+// archive with digest archiveDigest as a subject.
+type archiveSubjectEvaluator v1.Hash
+
+// Evaluate implements policy.PolicyEvaluator
+func (e archiveSubjectEvaluator) Evaluate(_ context.Context, payloads [][]byte) (error, error) {
+	var errs []error
+	for _, payload := range payloads {
+		var st in_toto.StatementHeader
+		if err := json.Unmarshal(payload, &st); err != nil {
+			errs = append(errs, fmt.Errorf("parsing statement: %w", err))
+			continue
+		}
+		found := false
+		for _, subject := range st.Subject {
+			if subject.Digest[e.Algorithm] == e.Hex {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("the statement does not name the chart archive %s as a subject", v1.Hash(e)))
+		}
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/attest"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/helm"
+)
+
+// pushHelmChart pushes version of the Helm chart app to host, returning its
+// helm:// reference and archive digest.
+func pushHelmChart(t *testing.T, host, version string) (string, v1.Hash) {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	chartYAML := "apiVersion: v2\nname: app\nversion: " + version + "\n"
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "app/Chart.yaml", Mode: 0600, Size: int64(len(chartYAML))}))
+	_, err := tw.Write([]byte(chartYAML))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer(b.Bytes(), helm.ChartLayerMediaType)})
+	require.NoError(t, err)
+	img = mutate.MediaType(img, ggcrtypes.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, helm.ConfigMediaType)
+	ref, err := name.ParseReference(host + "/charts/app:" + version)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	archiveDigest, _, err := v1.SHA256(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	return helm.Scheme + ref.String(), archiveDigest
+}
+
+func TestVerifyHelmChart(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	host := strings.TrimPrefix(s.URL, "http://")
+
+	keys, err := cosign.GenerateKeyPair(nil)
+	require.NoError(t, err)
+	keyPath := filepath.Join(td, "cosign.key")
+	require.NoError(t, os.WriteFile(keyPath, keys.PrivateBytes, 0600))
+	pubPath := filepath.Join(td, "cosign.pub")
+	require.NoError(t, os.WriteFile(pubPath, keys.PublicBytes, 0600))
+	predicatePath := filepath.Join(td, "provenance.json")
+	require.NoError(t, os.WriteFile(predicatePath, []byte(`{"builder": {"id": "https://ci.example.com"}, "buildType": "https://ci.example.com/helm-package"}`), 0600))
+
+	verifyCmd := VerifyCommand{
+		KeyRef:             pubPath,
+		CheckClaims:        true,
+		IgnoreTlog:         true,
+		MaxWorkers:         1,
+		HelmProvenanceType: "slsaprovenance",
+	}
+
+	chart, _ := pushHelmChart(t, host, "1.0.0")
+	err = verifyCmd.Exec(ctx, []string{chart})
+	require.ErrorContains(t, err, "no matching attestations")
+
+	at := attest.AttestCommand{
+		KeyOpts:        options.KeyOpts{KeyRef: keyPath},
+		PredicatePath:  predicatePath,
+		PredicateType:  "slsaprovenance",
+		RekorEntryType: "dsse",
+	}
+	require.NoError(t, at.Exec(ctx, chart))
+	require.NoError(t, verifyCmd.Exec(ctx, []string{chart}))
+
+	// Attestations of the chart manifest alone do not vouch for the archive.
+	chart, _ = pushHelmChart(t, host, "1.0.1")
+	require.NoError(t, at.Exec(ctx, strings.TrimPrefix(chart, helm.Scheme)))
+	err = verifyCmd.Exec(ctx, []string{chart})
+	require.ErrorContains(t, err, "validation errors occurred")
+
+	// Neither do provenance attestations of another type.
+	verifyCmd.HelmProvenanceType = "slsaprovenance1"
+	chart, _ = pushHelmChart(t, host, "1.0.2")
+	require.NoError(t, at.Exec(ctx, chart))
+	err = verifyCmd.Exec(ctx, []string{chart})
+	require.ErrorContains(t, err, "none of the attestations matched the predicate type")
+
+	err = verifyCmd.Exec(ctx, []string{chart, host + "/charts/app:1.0.2"})
+	require.ErrorContains(t, err, "cannot be verified together with Helm charts")
+}
+
+func TestArchiveSubjectEvaluator(t *testing.T) {
+	h, err := v1.NewHash("sha256:" + strings.Repeat("a", 64))
+	require.NoError(t, err)
+	e := archiveSubjectEvaluator(h)
+
+	_, errs := e.Evaluate(context.Background(), [][]byte{
+		[]byte(`{"subject": [{"name": "app", "digest": {"sha256": "` + strings.Repeat("b", 64) + `"}}, {"name": "app", "digest": {"sha256": "` + h.Hex + `"}}]}`),
+	})
+	require.NoError(t, errs)
+
+	_, errs = e.Evaluate(context.Background(), [][]byte{
+		[]byte(`{"subject": [{"name": "app", "digest": {"sha256": "` + strings.Repeat("b", 64) + `"}}]}`),
+		[]byte(`not json`),
+	})
+	require.ErrorContains(t, errs, "does not name the chart archive")
+	require.ErrorContains(t, errs, "parsing statement")
+}
@@ -35,6 +35,7 @@ import (
 	"testing"
 
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/schema"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/fulcio/fulcioroots"
 	"github.com/franchb/cosign/v2/internal/ui"
 	"github.com/franchb/cosign/v2/pkg/cosign"
@@ -224,6 +225,29 @@ func TestPrintVerification(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 	assert.JSONEq(t, wantPayload, string(i))
+	if err := schema.Validate(schema.Verify, []byte(out)); err != nil {
+		t.Error(err)
+	}
+
+	redactions, err := cosign.ParseRedactions([]string{"subject=hash", "github-workflow-trigger=omit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = captureOutput(func() {
+		ui.RunWithTestCtx(func(ctx context.Context, _ ui.WriteFunc) {
+			PrintRedactedVerification(ctx, []oci.Signature{ociSig}, "json", redactions)
+		})
+	})
+	var redacted []payload.SimpleContainerImage
+	if err := json.Unmarshal([]byte(out), &redacted); err != nil {
+		t.Fatal(err)
+	}
+	optional := redacted[0].Optional
+	assert.Equal(t, "sha256:a9491f4c1bf7b0cffbadcba2db8f028e4b3f2867cb59e1f3a0bc1968f3c51242", optional["Subject"])
+	assert.NotContains(t, optional, "githubWorkflowTrigger")
+	assert.NotContains(t, optional, cosign.CertExtensionGithubWorkflowTrigger)
+	assert.Equal(t, "oidc-issuer", optional["Issuer"])
+	assert.Equal(t, "myWorkflowRepository", optional["githubWorkflowRepository"])
 }
 
 func appendSlices(slices [][]byte) []byte {
@@ -0,0 +1,60 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/wasm"
+)
+
+// checkWasmModule checks that ref is the WebAssembly OCI artifact of the
+// module at modulePath, returning its digest reference.
+func checkWasmModule(ctx context.Context, ref name.Reference, modulePath string, opts ...ociremote.Option) (name.Digest, error) {
+	f, err := os.Open(filepath.Clean(modulePath))
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("opening WebAssembly module: %w", err)
+	}
+	defer f.Close()
+	want, err := wasm.FileDigest(f)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("%s: %w", modulePath, err)
+	}
+
+	digest, err := ociremote.ResolveDigest(ref, opts...)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("resolving digest of %s: %w", ref, err)
+	}
+	img, err := ociremote.SignedImage(digest, opts...)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("accessing %s: %w", digest, err)
+	}
+	got, err := wasm.ModuleDigest(img)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("%s: %w", digest, err)
+	}
+	if got != want {
+		return name.Digest{}, fmt.Errorf("%s holds WebAssembly module %s, not %s of %s", digest, got, want, modulePath)
+	}
+	ui.Infof(ctx, "%s holds WebAssembly module %s", digest, modulePath)
+	return digest, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+)
+
+func TestVerifyWasmModule(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	ref := strings.TrimPrefix(s.URL, "http://") + "/modules/hello:v1"
+
+	keys, err := cosign.GenerateKeyPair(nil)
+	require.NoError(t, err)
+	keyPath := filepath.Join(td, "cosign.key")
+	require.NoError(t, os.WriteFile(keyPath, keys.PrivateBytes, 0600))
+	pubPath := filepath.Join(td, "cosign.pub")
+	require.NoError(t, os.WriteFile(pubPath, keys.PublicBytes, 0600))
+	modulePath := filepath.Join(td, "hello.wasm")
+	require.NoError(t, os.WriteFile(modulePath, []byte("\x00asm\x01\x00\x00\x00"), 0600))
+	otherPath := filepath.Join(td, "other.wasm")
+	require.NoError(t, os.WriteFile(otherPath, []byte("\x00asm\x01\x00\x00\x00\x00\x00"), 0600))
+
+	ro := &options.RootOptions{Timeout: options.DefaultTimeout}
+	ko := options.KeyOpts{KeyRef: keyPath}
+	so := options.SignOptions{Upload: true, Type: options.SignTypeWasm}
+	require.ErrorContains(t, sign.SignCmd(ro, ko, so, []string{ref}), "requires --wasm-module")
+	so.WasmModule = modulePath
+	require.ErrorContains(t, sign.SignCmd(ro, ko, so, []string{ref, ref}), "single image reference")
+	require.NoError(t, sign.SignCmd(ro, ko, so, []string{ref}))
+
+	v := VerifyCommand{
+		KeyRef:      pubPath,
+		CheckClaims: true,
+		IgnoreTlog:  true,
+		MaxWorkers:  1,
+		WasmModule:  modulePath,
+	}
+	require.NoError(t, v.Exec(ctx, []string{ref}))
+
+	v.WasmModule = otherPath
+	require.ErrorContains(t, v.Exec(ctx, []string{ref}), "holds WebAssembly module")
+
+	v.WasmModule = keyPath
+	require.ErrorContains(t, v.Exec(ctx, []string{ref}), "not a WebAssembly module")
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
+	"github.com/franchb/cosign/v2/cmd/cosign/cli/verify"
+	"github.com/franchb/cosign/v2/pkg/git"
+)
+
+func VerifyGit() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-git",
+		Short: "Verify the x509 signatures of git commits and annotated tags.",
+		Long: `Verify the x509 signatures of git commits and annotated tags.
+
+Verifies the signatures made with 'cosign sign-git', and other x509
+signatures in the S/MIME format of git, such as those of gitsign. Keyless
+signatures must have a Fulcio certificate for the expected identity, or a
+certificate of the CA given with --certificate-chain or --ca-roots.
+`,
+	}
+
+	cmd.AddCommand(
+		verifyGitCommit(),
+		verifyGitTag(),
+	)
+
+	return cmd
+}
+
+func verifyGitCommit() *cobra.Command {
+	o := &options.VerifyGitOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "commit",
+		Short: "Verify the signature of a commit, HEAD by default.",
+		Example: `  cosign verify-git commit [--key <key path>|<kms uri>] [<revision>]
+
+  # verify the keyless signature of the last commit
+  cosign verify-git commit --certificate-identity=jane@example.com --certificate-oidc-issuer=https://accounts.example.com
+
+  # verify the signature of a commit made with a key, and its timestamp
+  cosign verify-git commit --key cosign.pub --timestamp-certificate-chain tsa.pem <COMMIT>`,
+		Args:             cobra.MaximumNArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rev := "HEAD"
+			if len(args) > 0 {
+				rev = args[0]
+			}
+			return verifyGit(cmd.Context(), o, git.ObjectCommit, rev+"^{commit}")
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func verifyGitTag() *cobra.Command {
+	o := &options.VerifyGitOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Verify the signature of an annotated tag.",
+		Example: `  cosign verify-git tag [--key <key path>|<kms uri>] <tag name>
+
+  # verify the keyless signature of a tag
+  cosign verify-git tag --certificate-identity=jane@example.com --certificate-oidc-issuer=https://accounts.example.com v1.0.0`,
+		Args:             cobra.ExactArgs(1),
+		PersistentPreRun: options.BindViper,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyGit(cmd.Context(), o, git.ObjectTag, "refs/tags/"+args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func verifyGit(ctx context.Context, o *options.VerifyGitOptions, objectType, rev string) error {
+	ctx, cancel := context.WithTimeout(ctx, ro.Timeout)
+	defer cancel()
+
+	v := &verify.VerifyGitCommand{
+		CertVerifyOptions:   o.CertVerify,
+		KeyRef:              o.Key,
+		Repository:          o.Repository,
+		TSACertChainPath:    o.TSACertChainPath,
+		UseSignedTimestamps: o.UseSignedTimestamps,
+	}
+	return v.Exec(ctx, objectType, rev)
+}
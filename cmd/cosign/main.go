@@ -26,8 +26,18 @@ import (
 	cosignError "github.com/franchb/cosign/v2/cmd/cosign/errors"
 	"github.com/franchb/cosign/v2/internal/ui"
 
+	// Register the private CAs of keyless signing
+	_ "github.com/franchb/cosign/v2/pkg/certprovider/stepca"
+	_ "github.com/franchb/cosign/v2/pkg/certprovider/vaultpki"
+
 	// Register the provider-specific plugins
-	_ "github.com/franchb/sigstore/pkg/signature/kms/hashivault"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/alibabakms"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/awskms"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/azurekms"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/hashivault"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/ocikms"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/plugin"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/tencentkms"
 	_ "github.com/franchb/sigstore/pkg/signature/kms/yckms"
 )
 
@@ -0,0 +1,49 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certext reads Fulcio certificate extensions, documented here:
+// https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md
+package certext
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+)
+
+const (
+	// OIDCIssuerOID is the deprecated OIDC issuer extension, whose value is
+	// the raw issuer string.
+	OIDCIssuerOID = "1.3.6.1.4.1.57264.1.1"
+	// OIDCIssuerV2OID is the OIDC issuer extension, whose value is a DER
+	// encoded UTF8String.
+	OIDCIssuerV2OID = "1.3.6.1.4.1.57264.1.8"
+)
+
+// OIDCIssuer returns the OIDC issuer of a Fulcio certificate, preferring the
+// deprecated extension if both are present, or the empty string if neither is.
+func OIDCIssuer(cert *x509.Certificate) string {
+	var v2 string
+	for _, ext := range cert.Extensions {
+		switch ext.Id.String() {
+		case OIDCIssuerOID:
+			return string(ext.Value)
+		case OIDCIssuerV2OID:
+			var issuer string
+			if rest, err := asn1.UnmarshalWithParams(ext.Value, &issuer, "utf8"); err == nil && len(rest) == 0 {
+				v2 = issuer
+			}
+		}
+	}
+	return v2
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certext
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestOIDCIssuer(t *testing.T) {
+	v1 := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte("https://v1.example.com")}
+	v2Value, err := asn1.MarshalWithParams("https://v2.example.com", "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2 := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: v2Value}
+	malformed := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: []byte("not DER")}
+
+	tests := []struct {
+		name string
+		exts []pkix.Extension
+		want string
+	}{
+		{name: "v1 extension", exts: []pkix.Extension{v1}, want: "https://v1.example.com"},
+		{name: "v2 extension", exts: []pkix.Extension{v2}, want: "https://v2.example.com"},
+		{name: "both extensions", exts: []pkix.Extension{v2, v1}, want: "https://v1.example.com"},
+		{name: "malformed v2 extension", exts: []pkix.Extension{malformed}, want: ""},
+		{name: "no extension", want: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := OIDCIssuer(&x509.Certificate{Extensions: tc.exts}); got != tc.want {
+				t.Errorf("OIDCIssuer() = %q, wanted %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -15,24 +15,44 @@
 package payload
 
 import (
+	"io"
+
 	"github.com/dustin/go-humanize"
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
 )
 
 const defaultMaxSize = uint64(134217728) // 128MiB
 
-func CheckSize(size uint64) error {
-	maxSize := defaultMaxSize
+func maxSize() uint64 {
 	maxSizeOverride, exists := env.LookupEnv(env.VariableMaxAttachmentSize)
 	if exists {
-		var err error
-		maxSize, err = humanize.ParseBytes(maxSizeOverride)
-		if err != nil {
-			maxSize = defaultMaxSize
+		maxSize, err := humanize.ParseBytes(maxSizeOverride)
+		if err == nil {
+			return maxSize
 		}
 	}
+	return defaultMaxSize
+}
+
+func CheckSize(size uint64) error {
+	maxSize := maxSize()
 	if size > maxSize {
 		return NewMaxLayerSizeExceeded(size, maxSize)
 	}
 	return nil
 }
+
+// ReadAll reads r until EOF, failing once more than the maximum size has
+// been read. It bounds the size of decompressed payloads, which CheckSize
+// cannot know up front.
+func ReadAll(r io.Reader) ([]byte, error) {
+	maxSize := maxSize()
+	b, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(b)) > maxSize {
+		return nil, NewMaxLayerSizeExceeded(uint64(len(b)), maxSize)
+	}
+	return b, nil
+}
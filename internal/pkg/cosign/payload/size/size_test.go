@@ -15,6 +15,8 @@
 package payload
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 )
 
@@ -108,3 +110,21 @@ func TestCheckSize(t *testing.T) {
 		})
 	}
 }
+
+func TestReadAll(t *testing.T) {
+	t.Setenv("COSIGN_MAX_ATTACHMENT_SIZE", "1024")
+
+	b, err := ReadAll(bytes.NewReader(make([]byte, 1024)))
+	if err != nil {
+		t.Fatalf("ReadAll() = %v", err)
+	}
+	if len(b) != 1024 {
+		t.Errorf("ReadAll() read %d bytes, expected 1024", len(b))
+	}
+
+	_, err = ReadAll(bytes.NewReader(make([]byte, 1025)))
+	var exceeded *MaxLayerSizeExceeded
+	if !errors.As(err, &exceeded) {
+		t.Errorf("ReadAll() = %v, expected MaxLayerSizeExceeded", err)
+	}
+}
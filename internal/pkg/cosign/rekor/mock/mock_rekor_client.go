@@ -29,9 +29,12 @@ import (
 // mClient.Entries = &logEntry
 type EntriesClient struct {
 	Entries []*models.LogEntry
+	// CreateLogEntryCalls counts the calls made to CreateLogEntry.
+	CreateLogEntryCalls int
 }
 
 func (m *EntriesClient) CreateLogEntry(_ *entries.CreateLogEntryParams, _ ...entries.ClientOption) (*entries.CreateLogEntryCreated, error) {
+	m.CreateLogEntryCalls++
 	if m.Entries != nil {
 		return &entries.CreateLogEntryCreated{
 			ETag:     "",
@@ -24,6 +24,7 @@ import (
 	"os"
 
 	"github.com/franchb/cosign/v2/internal/pkg/cosign"
+	"github.com/franchb/cosign/v2/internal/ui"
 	cosignv1 "github.com/franchb/cosign/v2/pkg/cosign"
 	cbundle "github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
@@ -36,13 +37,32 @@ import (
 
 type tlogUploadFn func(*client.Rekor, []byte) (*models.LogEntryAnon, error)
 
-func uploadToTlog(rekorBytes []byte, rClient *client.Rekor, upload tlogUploadFn) (*cbundle.RekorBundle, error) {
+func uploadToTlog(rekorBytes []byte, rClient *client.Rekor, upload tlogUploadFn) (*models.LogEntryAnon, error) {
 	entry, err := upload(rClient, rekorBytes)
 	if err != nil {
 		return nil, err
 	}
 	fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
-	return cbundle.EntryToBundle(entry), nil
+	return entry, nil
+}
+
+// AdditionalLog is a Rekor instance that receives a copy of every tlog entry.
+type AdditionalLog struct {
+	URL    string
+	Client *client.Rekor
+}
+
+// UploadToAdditionalLogs uploads an entry to each of the additional Rekor
+// instances in turn, reporting the log index created on each.
+func UploadToAdditionalLogs(ctx context.Context, logs []AdditionalLog, upload func(*client.Rekor) (*models.LogEntryAnon, error)) error {
+	for _, l := range logs {
+		entry, err := upload(l.Client)
+		if err != nil {
+			return fmt.Errorf("uploading to additional tlog %s: %w", l.URL, err)
+		}
+		ui.Infof(ctx, "tlog entry created on %s with index: %d", l.URL, *entry.LogIndex)
+	}
+	return nil
 }
 
 // signerWrapper calls a wrapped, inner signer then uploads either the Cert or Pub(licKey) of the results to Rekor, then adds the resulting `Bundle`
@@ -50,6 +70,11 @@ type signerWrapper struct {
 	inner cosign.Signer
 
 	rClient *client.Rekor
+	// additional Rekor instances that receive a copy of the entry.
+	additional []AdditionalLog
+
+	// entry is the entry of the last signature in rClient.
+	entry *models.LogEntryAnon
 }
 
 var _ cosign.Signer = (*signerWrapper)(nil)
@@ -90,18 +115,28 @@ func (rs *signerWrapper) Sign(ctx context.Context, payload io.Reader) (oci.Signa
 		return nil, nil, err
 	}
 
-	bundle, err := uploadToTlog(rekorBytes, rs.rClient, func(r *client.Rekor, b []byte) (*models.LogEntryAnon, error) {
+	upload := func(r *client.Rekor, b []byte) (*models.LogEntryAnon, error) {
 		checkSum := sha256.New()
 		if _, err := checkSum.Write(payloadBytes); err != nil {
 			return nil, err
 		}
 		return cosignv1.TLogUpload(ctx, r, sigBytes, checkSum, b)
-	})
+	}
+	entry, err := uploadToTlog(rekorBytes, rs.rClient, upload)
 	if err != nil {
 		return nil, nil, err
 	}
+	rs.entry = entry
+	// Only the entry from the primary log is attached to the signature, the
+	// additional logs are written to so that verifiers trusting either can
+	// find the entry with an online lookup.
+	if err := UploadToAdditionalLogs(ctx, rs.additional, func(r *client.Rekor) (*models.LogEntryAnon, error) {
+		return upload(r, rekorBytes)
+	}); err != nil {
+		return nil, nil, err
+	}
 
-	newSig, err := mutate.Signature(sig, mutate.WithBundle(bundle))
+	newSig, err := mutate.Signature(sig, mutate.WithBundle(cbundle.EntryToBundle(entry)))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -116,3 +151,24 @@ func NewSigner(inner cosign.Signer, rClient *client.Rekor) cosign.Signer {
 		rClient: rClient,
 	}
 }
+
+// NewSignerWithAdditionalLogs returns a `cosign.Signer` which uploads the
+// signature to rClient, attaching the resulting `Bundle`, and then to each of
+// the additional Rekor instances in order.
+func NewSignerWithAdditionalLogs(inner cosign.Signer, rClient *client.Rekor, additional []AdditionalLog) cosign.Signer {
+	return &signerWrapper{
+		inner:      inner,
+		rClient:    rClient,
+		additional: additional,
+	}
+}
+
+// LastEntry returns the entry of the last signature made by s in the primary
+// Rekor instance, including its inclusion proof which the bundle attached to
+// the signature lacks, or nil if s does not upload signatures to Rekor.
+func LastEntry(s cosign.Signer) *models.LogEntryAnon {
+	if rs, ok := s.(*signerWrapper); ok {
+		return rs.entry
+	}
+	return nil
+}
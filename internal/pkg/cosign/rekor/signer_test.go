@@ -84,4 +84,71 @@ func TestSigner(t *testing.T) {
 	if err = verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(gotPayload)); err != nil {
 		t.Errorf("VerifySignature() returned error: %v", err)
 	}
+	if entry := LastEntry(testSigner); entry == nil || *entry.LogIndex != 123 {
+		t.Errorf("LastEntry() = %v, wanted the entry with index 123", entry)
+	}
+	if entry := LastEntry(payloadSigner); entry != nil {
+		t.Errorf("LastEntry() of a signer not uploading to Rekor = %v, wanted nil", entry)
+	}
+}
+
+func TestSignerWithAdditionalLogs(t *testing.T) {
+	payloadSigner := payload.NewSigner(mustGetNewSigner(t))
+
+	var primary, additional client.Rekor
+	primaryEntries := &mock.EntriesClient{
+		Entries: []*models.LogEntry{{"123": models.LogEntryAnon{
+			LogIndex:       swag.Int64(123),
+			IntegratedTime: swag.Int64(1),
+			LogID:          swag.String("primary"),
+			Verification:   &models.LogEntryAnonVerification{},
+		}}},
+	}
+	additionalEntries := &mock.EntriesClient{
+		Entries: []*models.LogEntry{{"456": models.LogEntryAnon{
+			LogIndex: swag.Int64(456),
+		}}},
+	}
+	primary.Entries = primaryEntries
+	additional.Entries = additionalEntries
+
+	testSigner := NewSignerWithAdditionalLogs(payloadSigner, &primary, []AdditionalLog{
+		{URL: "https://rekor.example.com", Client: &additional},
+	})
+
+	ociSig, _, err := testSigner.Sign(context.Background(), strings.NewReader("test payload"))
+	if err != nil {
+		t.Fatalf("Sign() returned error: %v", err)
+	}
+	if primaryEntries.CreateLogEntryCalls != 1 {
+		t.Errorf("primary log received %d entries, wanted 1", primaryEntries.CreateLogEntryCalls)
+	}
+	if additionalEntries.CreateLogEntryCalls != 1 {
+		t.Errorf("additional log received %d entries, wanted 1", additionalEntries.CreateLogEntryCalls)
+	}
+	bundle, err := ociSig.Bundle()
+	if err != nil {
+		t.Fatalf("ociSig.Bundle() returned error: %v", err)
+	}
+	if bundle == nil {
+		t.Fatal("ociSig.Bundle() returned nil bundle")
+	}
+	// The bundle must come from the primary log.
+	if bundle.Payload.LogIndex != 123 {
+		t.Errorf("bundle log index = %d, wanted 123", bundle.Payload.LogIndex)
+	}
+
+	// An additional log that fails to accept the entry fails signing.
+	var failing client.Rekor
+	failingEntries := &mock.EntriesClient{}
+	failing.Entries = failingEntries
+	testSigner = NewSignerWithAdditionalLogs(payloadSigner, &primary, []AdditionalLog{
+		{URL: "https://rekor.example.com", Client: &failing},
+	})
+	if _, _, err := testSigner.Sign(context.Background(), strings.NewReader("test payload")); err == nil {
+		t.Error("Sign() with failing additional log succeeded, wanted error")
+	}
+	if failingEntries.CreateLogEntryCalls != 1 {
+		t.Errorf("failing log received %d entries, wanted 1", failingEntries.CreateLogEntryCalls)
+	}
 }
@@ -18,6 +18,8 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	stderrors "errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
@@ -25,6 +27,7 @@ import (
 	"github.com/digitorus/timestamp"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/client"
+	"github.com/franchb/cosign/v2/internal/ui"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/mutate"
@@ -44,11 +47,36 @@ func GetTimestampedSignature(sigBytes []byte, tsaClient client.TimestampAuthorit
 	return tsaClient.GetTimestampResponse(requestBytes)
 }
 
-// signerWrapper calls a wrapped, inner signer then uploads either the Cert or Pub(licKey) of the results to Rekor, then adds the resulting `Bundle`
+// GetTimestampedSignatures queries each timestamp authority for an RFC3161
+// timestamp of sigBytes, returning the timestamps of those that respond in
+// order. Authorities that fail are skipped with a warning, so that the
+// outage of one does not prevent signing; it fails only if all of them do.
+func GetTimestampedSignatures(ctx context.Context, sigBytes []byte, tsaClients []client.TimestampAuthorityClient) ([][]byte, error) {
+	var timestamps [][]byte
+	var errs []error
+	for i, tsaClient := range tsaClients {
+		responseBytes, err := GetTimestampedSignature(sigBytes, tsaClient)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("timestamp authority %d: %w", i+1, err))
+			continue
+		}
+		timestamps = append(timestamps, responseBytes)
+	}
+	if len(timestamps) == 0 {
+		return nil, stderrors.Join(errs...)
+	}
+	for _, err := range errs {
+		ui.Warnf(ctx, "skipping timestamp: %v", err)
+	}
+	return timestamps, nil
+}
+
+// signerWrapper calls a wrapped, inner signer then timestamps the resulting
+// signature with each timestamp authority, storing their RFC3161 timestamps.
 type signerWrapper struct {
 	inner cosign.Signer
 
-	tsaClient client.TimestampAuthorityClient
+	tsaClients []client.TimestampAuthorityClient
 }
 
 var _ cosign.Signer = (*signerWrapper)(nil)
@@ -66,12 +94,12 @@ func (rs *signerWrapper) Sign(ctx context.Context, payload io.Reader) (oci.Signa
 		return nil, nil, err
 	}
 
-	// fetch rfc3161 timestamp from timestamp authority
-	responseBytes, err := GetTimestampedSignature(rawSig, rs.tsaClient)
+	// fetch rfc3161 timestamps from the timestamp authorities
+	timestamps, err := GetTimestampedSignatures(ctx, rawSig, rs.tsaClients)
 	if err != nil {
 		return nil, nil, err
 	}
-	bundle := bundle.TimestampToRFC3161Timestamp(responseBytes)
+	bundle := bundle.TimestampsToRFC3161Timestamp(timestamps)
 
 	newSig, err := mutate.Signature(sig, mutate.WithRFC3161Timestamp(bundle))
 	if err != nil {
@@ -105,10 +133,11 @@ func createTimestampAuthorityRequest(artifactBytes []byte, hash crypto.Hash, pol
 	return timestamp.CreateRequest(bytes.NewReader(artifactBytes), reqOpts)
 }
 
-// NewSigner returns a `cosign.Signer` which uploads the signature to a TSA
-func NewSigner(inner cosign.Signer, tsaClient client.TimestampAuthorityClient) cosign.Signer {
+// NewSigner returns a `cosign.Signer` which uploads the signature to a TSA,
+// and to each of the additional TSAs
+func NewSigner(inner cosign.Signer, tsaClient client.TimestampAuthorityClient, additionalTSAClients ...client.TimestampAuthorityClient) cosign.Signer {
 	return &signerWrapper{
-		inner:     inner,
-		tsaClient: tsaClient,
+		inner:      inner,
+		tsaClients: append([]client.TimestampAuthorityClient{tsaClient}, additionalTSAClients...),
 	}
 }
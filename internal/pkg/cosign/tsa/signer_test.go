@@ -18,11 +18,13 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"errors"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/payload"
+	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/client"
 	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/mock"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/sigstore/pkg/signature"
@@ -79,3 +81,59 @@ func TestSigner(t *testing.T) {
 		t.Errorf("VerifySignature() returned error: %v", err)
 	}
 }
+
+type failingTSAClient struct{}
+
+func (failingTSAClient) GetTimestampResponse([]byte) ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestSignerAdditionalTSAs(t *testing.T) {
+	payloadSigner := payload.NewSigner(mustGetNewSigner(t))
+
+	var tsaClients []client.TimestampAuthorityClient
+	for i := 0; i < 2; i++ {
+		tsaClient, err := mock.NewTSAClient((mock.TSAClientOptions{Time: time.Now()}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tsaClients = append(tsaClients, tsaClient)
+	}
+
+	t.Run("all respond", func(t *testing.T) {
+		testSigner := NewSigner(payloadSigner, tsaClients[0], tsaClients[1])
+		ociSig, _, err := testSigner.Sign(context.Background(), strings.NewReader("test payload"))
+		if err != nil {
+			t.Fatalf("Sign() returned error: %v", err)
+		}
+		ts, err := ociSig.RFC3161Timestamp()
+		if err != nil {
+			t.Fatalf("ociSig.RFC3161Timestamp() returned error: %v", err)
+		}
+		if got := len(ts.SignedRFC3161Timestamps()); got != 2 {
+			t.Errorf("got %d timestamps, wanted 2", got)
+		}
+	})
+
+	t.Run("one is down", func(t *testing.T) {
+		testSigner := NewSigner(payloadSigner, failingTSAClient{}, tsaClients[1])
+		ociSig, _, err := testSigner.Sign(context.Background(), strings.NewReader("test payload"))
+		if err != nil {
+			t.Fatalf("Sign() returned error: %v", err)
+		}
+		ts, err := ociSig.RFC3161Timestamp()
+		if err != nil {
+			t.Fatalf("ociSig.RFC3161Timestamp() returned error: %v", err)
+		}
+		if got := len(ts.SignedRFC3161Timestamps()); got != 1 {
+			t.Errorf("got %d timestamps, wanted 1", got)
+		}
+	})
+
+	t.Run("all are down", func(t *testing.T) {
+		testSigner := NewSigner(payloadSigner, failingTSAClient{}, failingTSAClient{})
+		if _, _, err := testSigner.Sign(context.Background(), strings.NewReader("test payload")); err == nil {
+			t.Fatal("Sign() succeeded without any timestamp")
+		}
+	})
+}
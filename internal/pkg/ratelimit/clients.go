@@ -0,0 +1,82 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/go-openapi/runtime"
+	"github.com/sigstore/fulcio/pkg/api"
+)
+
+// Transport returns a Rekor client transport that waits for l before
+// submitting each operation through inner.
+func Transport(inner runtime.ClientTransport, l *Limiter) runtime.ClientTransport {
+	if l == nil {
+		return inner
+	}
+	return &transport{inner: inner, limiter: l}
+}
+
+type transport struct {
+	inner   runtime.ClientTransport
+	limiter *Limiter
+}
+
+var _ runtime.ClientTransport = (*transport)(nil)
+
+// Submit implements runtime.ClientTransport
+func (t *transport) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	ctx := op.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return t.inner.Submit(op)
+}
+
+// FulcioClient returns a Fulcio client that waits for l before each request
+// it makes through inner.
+func FulcioClient(inner api.LegacyClient, l *Limiter) api.LegacyClient {
+	if l == nil {
+		return inner
+	}
+	return &fulcioClient{inner: inner, limiter: l}
+}
+
+type fulcioClient struct {
+	inner   api.LegacyClient
+	limiter *Limiter
+}
+
+var _ api.LegacyClient = (*fulcioClient)(nil)
+
+// SigningCert implements api.LegacyClient
+func (c *fulcioClient) SigningCert(cr api.CertificateRequest, token string) (*api.CertificateResponse, error) {
+	if err := c.limiter.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.inner.SigningCert(cr, token)
+}
+
+// RootCert implements api.LegacyClient
+func (c *fulcioClient) RootCert() (*api.RootResponse, error) {
+	if err := c.limiter.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.inner.RootCert()
+}
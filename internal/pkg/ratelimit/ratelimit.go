@@ -0,0 +1,216 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit limits the rate of requests cosign makes to Rekor and
+// Fulcio, so that bulk operations stay under the rate limits of the services
+// they talk to.
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// The services whose requests are limited.
+const (
+	Rekor  = "rekor"
+	Fulcio = "fulcio"
+)
+
+// Limit is a token bucket that is refilled at Rate tokens per second and
+// holds up to Burst tokens.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// ParseLimit parses a limit written as RATE[/UNIT][:BURST], where RATE is
+// the number of requests per UNIT, which is s, m or h and defaults to s, and
+// BURST is the number of requests that may be made at once, which defaults
+// to 1. For example, "5" allows five requests per second and "300/m:10"
+// allows 300 requests per minute, ten of them in a row.
+func ParseLimit(s string) (Limit, error) {
+	spec, burst, hasBurst := strings.Cut(s, ":")
+	n, unit, hasUnit := strings.Cut(spec, "/")
+	r, err := strconv.ParseFloat(n, 64)
+	if err != nil || r <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: rate must be a positive number", s)
+	}
+	per := time.Second
+	if hasUnit {
+		switch unit {
+		case "s":
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return Limit{}, fmt.Errorf("rate limit %q: unit must be s, m or h", s)
+		}
+	}
+	l := Limit{Rate: rate.Limit(r / per.Seconds()), Burst: 1}
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst < 1 {
+			return Limit{}, fmt.Errorf("rate limit %q: burst must be a positive integer", s)
+		}
+	}
+	return l, nil
+}
+
+// Stats describe the requests made through a Limiter.
+type Stats struct {
+	// Endpoint is the service and host the Limiter is for.
+	Endpoint string `json:"endpoint"`
+	// Requests is the number of requests made.
+	Requests int64 `json:"requests"`
+	// Delayed is the number of requests that had to wait for a token.
+	Delayed int64 `json:"delayed"`
+	// Waiting is the number of requests currently waiting for a token.
+	Waiting int64 `json:"waiting"`
+	// MaxWaiting is the largest number of requests that waited at once.
+	MaxWaiting int64 `json:"maxWaiting"`
+	// WaitTime is the total time requests waited for a token.
+	WaitTime time.Duration `json:"waitTime"`
+}
+
+// Limiter limits the rate of requests to a single endpoint.
+type Limiter struct {
+	endpoint string
+	limiter  *rate.Limiter
+
+	requests   atomic.Int64
+	delayed    atomic.Int64
+	waiting    atomic.Int64
+	maxWaiting atomic.Int64
+	waitTime   atomic.Int64
+}
+
+// NewLimiter returns a Limiter for endpoint that allows requests at l.
+func NewLimiter(endpoint string, l Limit) *Limiter {
+	return &Limiter{
+		endpoint: endpoint,
+		limiter:  rate.NewLimiter(l.Rate, l.Burst),
+	}
+}
+
+// Wait blocks until a request may be made, or ctx is done. A nil Limiter
+// never blocks.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.requests.Add(1)
+	r := l.limiter.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	l.delayed.Add(1)
+	waiting := l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+	for {
+		m := l.maxWaiting.Load()
+		if waiting <= m || l.maxWaiting.CompareAndSwap(m, waiting) {
+			break
+		}
+	}
+
+	start := time.Now()
+	defer func() { l.waitTime.Add(int64(time.Since(start))) }()
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return fmt.Errorf("waiting for the rate limit of %s: %w", l.endpoint, ctx.Err())
+	}
+}
+
+// Stats returns the requests made through l so far.
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Endpoint:   l.endpoint,
+		Requests:   l.requests.Load(),
+		Delayed:    l.delayed.Load(),
+		Waiting:    l.waiting.Load(),
+		MaxWaiting: l.maxWaiting.Load(),
+		WaitTime:   time.Duration(l.waitTime.Load()),
+	}
+}
+
+var (
+	mu       sync.Mutex
+	limits   = map[string]Limit{}
+	limiters = map[string]*Limiter{}
+)
+
+func init() {
+	expvar.Publish("cosign_rate_limits", expvar.Func(func() any { return AllStats() }))
+}
+
+// SetLimit limits the requests to each endpoint of service to l. It applies
+// to the Limiters returned by For afterwards.
+func SetLimit(service string, l Limit) {
+	mu.Lock()
+	defer mu.Unlock()
+	limits[service] = l
+}
+
+// For returns the Limiter for the endpoint of service at serverURL, or nil if
+// the requests to service are not limited. Clients of the same endpoint
+// share a Limiter.
+func For(service, serverURL string) *Limiter {
+	mu.Lock()
+	defer mu.Unlock()
+	l, ok := limits[service]
+	if !ok {
+		return nil
+	}
+	host := serverURL
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	endpoint := service + " " + host
+	if limiter, ok := limiters[endpoint]; ok {
+		return limiter
+	}
+	limiter := NewLimiter(endpoint, l)
+	limiters[endpoint] = limiter
+	return limiter
+}
+
+// AllStats returns the Stats of every Limiter returned by For, ordered by
+// endpoint. They are also published with expvar as cosign_rate_limits.
+func AllStats() []Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	stats := make([]Stats, 0, len(limiters))
+	for _, l := range limiters {
+		stats = append(stats, l.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Endpoint < stats[j].Endpoint })
+	return stats
+}
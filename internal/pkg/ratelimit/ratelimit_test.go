@@ -0,0 +1,128 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"golang.org/x/time/rate"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "5", want: Limit{Rate: 5, Burst: 1}},
+		{in: "120/m", want: Limit{Rate: 2, Burst: 1}},
+		{in: "3600/h:10", want: Limit{Rate: 1, Burst: 10}},
+		{in: "0", wantErr: true},
+		{in: "fast", wantErr: true},
+		{in: "5/d", wantErr: true},
+		{in: "5:0", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseLimit(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseLimit() = %v, wanted error %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseLimit() = %+v, wanted %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter("rekor example.com", Limit{Rate: rate.Every(20 * time.Millisecond), Burst: 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := l.Stats()
+	if s.Requests != 5 || s.Delayed != 4 {
+		t.Errorf("Stats() = %+v, wanted 5 requests of which 4 delayed", s)
+	}
+	if s.Waiting != 0 || s.MaxWaiting < 1 || s.WaitTime == 0 {
+		t.Errorf("Stats() = %+v, wanted an empty queue that was used", s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.limiter.SetLimit(rate.Every(time.Hour))
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() = %v, wanted %v", err, context.Canceled)
+	}
+}
+
+func TestFor(t *testing.T) {
+	if l := For(Fulcio, "https://fulcio.example.com"); l != nil {
+		t.Fatal("For() returned a Limiter for a service without a limit")
+	}
+	SetLimit(Rekor, Limit{Rate: 1, Burst: 1})
+	a := For(Rekor, "https://rekor.example.com")
+	b := For(Rekor, "https://rekor.example.com/api/v1")
+	c := For(Rekor, "https://other.example.com")
+	if a == nil || a != b || a == c {
+		t.Fatal("For() did not return one Limiter per endpoint")
+	}
+	found := false
+	for _, s := range AllStats() {
+		found = found || s.Endpoint == "rekor rekor.example.com"
+	}
+	if !found {
+		t.Errorf("AllStats() = %v, wanted the rekor.example.com endpoint", AllStats())
+	}
+}
+
+type countingTransport struct{ n int }
+
+func (t *countingTransport) Submit(*runtime.ClientOperation) (interface{}, error) {
+	t.n++
+	return nil, nil
+}
+
+func TestTransport(t *testing.T) {
+	inner := &countingTransport{}
+	if Transport(inner, nil) != runtime.ClientTransport(inner) {
+		t.Error("Transport() wrapped a transport without a Limiter")
+	}
+	l := NewLimiter("rekor example.com", Limit{Rate: rate.Inf, Burst: 1})
+	tr := Transport(inner, l)
+	for i := 0; i < 3; i++ {
+		if _, err := tr.Submit(&runtime.ClientOperation{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.n != 3 || l.Stats().Requests != 3 {
+		t.Errorf("submitted %d operations through %d requests, wanted 3", inner.n, l.Stats().Requests)
+	}
+}
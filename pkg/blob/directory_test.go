@@ -0,0 +1,77 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHashDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"b.txt":       "b",
+		"a/z.txt":     "z",
+		"a/b/c.txt":   "",
+		"a/b/d/e.txt": "e",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := HashDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []File{
+		{Path: "a/b/c.txt", SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{Path: "a/b/d/e.txt", SHA256: "3f79bb7b435b05321651daefd374cdc681dc06faa65e374e38337b88ca046dea"},
+		{Path: "a/z.txt", SHA256: "594e519ae499312b29433b7dd8a97ff068defcba9755b6d5d00e84c524d67b06"},
+		{Path: "b.txt", SHA256: "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("HashDirectory() = %v, want %v", files, want)
+	}
+
+	if err := os.Symlink("b.txt", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("creating symlink: %v", err)
+	}
+	if _, err := HashDirectory(dir); err == nil {
+		t.Error("HashDirectory() with a symlink succeeded, want error")
+	}
+}
+
+func TestHashDirectoryEmpty(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HashDirectory(dir); err == nil {
+		t.Error("HashDirectory() of an empty tree succeeded, want error")
+	}
+	if _, err := HashDirectory(filepath.Join(dir, "missing")); err == nil {
+		t.Error("HashDirectory() of a missing directory succeeded, want error")
+	}
+}
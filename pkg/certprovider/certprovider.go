@@ -0,0 +1,218 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certprovider defines the API of the private certificate
+// authorities that can issue the short-lived certificates of keyless
+// signing in place of Fulcio, such as step-ca or the PKI secrets engine of
+// HashiCorp Vault. Providers register themselves for the scheme of the
+// references that select them, e.g. "step-ca://ca.example.com".
+package certprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+)
+
+var (
+	m         sync.Mutex
+	providers = map[string]ProviderInit{}
+)
+
+// Request is a request for the certificate of a signing key.
+type Request struct {
+	// Signer holds the key to certify. It signs the certificate signing
+	// request that proves its possession.
+	Signer crypto.Signer
+	// IDToken is the OIDC identity token of the signer, or empty if none
+	// was found.
+	IDToken string
+}
+
+// Certificate is a certificate issued by a provider.
+type Certificate struct {
+	// CertPEM is the PEM-encoded certificate of the signing key.
+	CertPEM []byte
+	// ChainPEM is the PEM-encoded chain of its issuer, from the issuing
+	// CA towards the root.
+	ChainPEM []byte
+}
+
+// Interface is what providers implement to issue certificates.
+type Interface interface {
+	// Certificate returns a certificate for the key of req.Signer.
+	Certificate(ctx context.Context, req Request) (*Certificate, error)
+}
+
+// ProviderInit returns the provider of the reference ref.
+type ProviderInit func(ctx context.Context, ref string) (Interface, error)
+
+// ProviderNotFoundError indicates that no provider handles a reference.
+type ProviderNotFoundError struct {
+	ref string
+}
+
+func (e *ProviderNotFoundError) Error() string {
+	return fmt.Sprintf("no certificate provider found for %s, supported are %s", e.ref, strings.Join(SupportedProviders(), ", "))
+}
+
+// AddProvider registers the provider of the references starting with
+// scheme.
+func AddProvider(scheme string, init ProviderInit) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := providers[scheme]; ok {
+		panic(fmt.Sprintf("duplicate certificate provider for %q", scheme))
+	}
+	providers[scheme] = init
+}
+
+// Get returns the provider of ref, or a ProviderNotFoundError if no
+// provider handles it.
+func Get(ctx context.Context, ref string) (Interface, error) {
+	m.Lock()
+	var init ProviderInit
+	for scheme, pi := range providers {
+		if strings.HasPrefix(ref, scheme) {
+			init = pi
+			break
+		}
+	}
+	m.Unlock()
+	if init == nil {
+		return nil, &ProviderNotFoundError{ref: ref}
+	}
+	return init(ctx, ref)
+}
+
+// SupportedProviders returns the schemes of the registered providers.
+func SupportedProviders() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// CertificateRequest returns the PEM-encoded certificate signing request of
+// req, for the identity its ID token names, if any: the email address of
+// the token, or else its subject.
+func CertificateRequest(req Request) ([]byte, error) {
+	template := &x509.CertificateRequest{}
+	if req.IDToken != "" {
+		claims, err := tokenClaims(req.IDToken)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case claims.Email != "":
+			template.Subject = pkix.Name{CommonName: claims.Email}
+			template.EmailAddresses = []string{claims.Email}
+		case claims.Subject != "":
+			template.Subject = pkix.Name{CommonName: claims.Subject}
+			if u, err := url.Parse(claims.Subject); err == nil && u.Scheme != "" {
+				template.URIs = []*url.URL{u}
+			}
+		}
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, req.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("creating certificate request: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+type claims struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email"`
+}
+
+// tokenClaims returns the claims of the JWT token, without verifying it:
+// the provider does.
+func tokenClaims(token string) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("identity token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("decoding identity token: %w", err)
+	}
+	c := &claims{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, fmt.Errorf("decoding identity token: %w", err)
+	}
+	return c, nil
+}
+
+// CryptoSigner returns sv as a crypto.Signer, to sign certificate requests
+// with. Signers that are not crypto.Signers sign digests with SignMessage
+// and options.WithDigest.
+func CryptoSigner(sv signature.SignerVerifier) (crypto.Signer, error) {
+	if s, ok := sv.(crypto.Signer); ok {
+		return s, nil
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &digestSigner{sv: sv, pub: pub}, nil
+}
+
+type digestSigner struct {
+	sv  signature.SignerVerifier
+	pub crypto.PublicKey
+}
+
+func (s *digestSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *digestSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.sv.SignMessage(bytes.NewReader(nil), options.WithDigest(digest), options.WithCryptoSignerOpts(opts))
+}
+
+// JoinPEM returns the PEM-encoded certificates of chain as one PEM chain.
+func JoinPEM(chain ...string) []byte {
+	var b []byte
+	for _, c := range chain {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		b = append(b, c...)
+		b = append(b, '\n')
+	}
+	return b
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certprovider
+
+import (
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+type fakeProvider struct {
+	ref string
+}
+
+func (f *fakeProvider) Certificate(context.Context, Request) (*Certificate, error) {
+	return &Certificate{CertPEM: []byte(f.ref)}, nil
+}
+
+func TestGet(t *testing.T) {
+	AddProvider("fake://", func(_ context.Context, ref string) (Interface, error) {
+		return &fakeProvider{ref: ref}, nil
+	})
+	p, err := Get(context.Background(), "fake://ca.example.com")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if got := p.(*fakeProvider).ref; got != "fake://ca.example.com" {
+		t.Errorf("provider initialized with %q", got)
+	}
+
+	var perr *ProviderNotFoundError
+	if _, err := Get(context.Background(), "other://ca.example.com"); !errors.As(err, &perr) {
+		t.Errorf("Get() of an unknown scheme = %v, wanted a ProviderNotFoundError", err)
+	}
+}
+
+// token returns an unsigned JWT with the claims of payload.
+func token(payload string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2ln"
+}
+
+func parseRequest(t *testing.T, b []byte) *x509.CertificateRequest {
+	t.Helper()
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		t.Fatalf("not a PEM certificate request: %s", b)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("CheckSignature() = %v", err)
+	}
+	return csr
+}
+
+func TestCertificateRequest(t *testing.T) {
+	sv, _, err := signature.NewECDSASignerVerifier(elliptic.P256(), rand.Reader, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := CryptoSigner(sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csr := parseRequest(t, mustRequest(t, Request{Signer: signer, IDToken: token(`{"sub":"123","email":"alice@example.com"}`)}))
+	if csr.Subject.CommonName != "alice@example.com" || len(csr.EmailAddresses) != 1 || csr.EmailAddresses[0] != "alice@example.com" {
+		t.Errorf("request for an email address has subject %v and emails %v", csr.Subject, csr.EmailAddresses)
+	}
+
+	csr = parseRequest(t, mustRequest(t, Request{Signer: signer, IDToken: token(`{"sub":"spiffe://example.com/ci"}`)}))
+	if len(csr.URIs) != 1 || csr.URIs[0].String() != "spiffe://example.com/ci" {
+		t.Errorf("request for a URI subject has URIs %v", csr.URIs)
+	}
+
+	csr = parseRequest(t, mustRequest(t, Request{Signer: signer}))
+	if csr.Subject.CommonName != "" {
+		t.Errorf("request without token has subject %v", csr.Subject)
+	}
+
+	if _, err := CertificateRequest(Request{Signer: signer, IDToken: "not a token"}); err == nil {
+		t.Error("CertificateRequest() with a malformed token succeeded")
+	}
+}
+
+func mustRequest(t *testing.T, req Request) []byte {
+	t.Helper()
+	b, err := CertificateRequest(req)
+	if err != nil {
+		t.Fatalf("CertificateRequest() = %v", err)
+	}
+	return b
+}
+
+// messageSigner hides the crypto.Signer of its SignerVerifier.
+type messageSigner struct {
+	sv signature.SignerVerifier
+}
+
+func (m messageSigner) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return m.sv.PublicKey(opts...)
+}
+
+func (m messageSigner) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	return m.sv.SignMessage(message, opts...)
+}
+
+func (m messageSigner) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	return m.sv.VerifySignature(sig, message, opts...)
+}
+
+func TestCryptoSignerOfMessageSigner(t *testing.T) {
+	sv, _, err := signature.NewECDSASignerVerifier(elliptic.P256(), rand.Reader, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := CryptoSigner(messageSigner{sv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := signer.(*digestSigner); !ok {
+		t.Fatalf("CryptoSigner() = %T, wanted a digestSigner", signer)
+	}
+	parseRequest(t, mustRequest(t, Request{Signer: signer}))
+}
+
+func TestJoinPEM(t *testing.T) {
+	got := string(JoinPEM("-----BEGIN CERTIFICATE-----\nA\n-----END CERTIFICATE-----\n", "", "  -----BEGIN CERTIFICATE-----\nB\n-----END CERTIFICATE-----"))
+	want := "-----BEGIN CERTIFICATE-----\nA\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nB\n-----END CERTIFICATE-----\n"
+	if got != want {
+		t.Errorf("JoinPEM() = %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stepca issues the certificates of keyless signing with the sign
+// API of a step-ca server, authorized by the OIDC identity token of the
+// signer through an OIDC provisioner of the CA.
+package stepca
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/franchb/cosign/v2/pkg/certprovider"
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+)
+
+func init() {
+	certprovider.AddProvider(ReferenceScheme, func(_ context.Context, ref string) (certprovider.Interface, error) {
+		return New(ref)
+	})
+}
+
+// ReferenceScheme is the scheme of references to step-ca servers, as
+// step-ca://HOST[:PORT].
+const ReferenceScheme = "step-ca://"
+
+// Provider requests certificates from a step-ca server.
+type Provider struct {
+	signURL string
+	client  *http.Client
+	// root is the PEM-encoded root certificate of the CA, if known.
+	root []byte
+}
+
+// New returns the provider of the step-ca server ref refers to. The root
+// certificate of the server is read from COSIGN_STEP_CA_ROOT if it is set,
+// and otherwise the server must be trusted by the system.
+func New(ref string) (*Provider, error) {
+	rest, ok := strings.CutPrefix(ref, ReferenceScheme)
+	if !ok {
+		return nil, fmt.Errorf("step-ca reference should be in the format %sHOST[:PORT]", ReferenceScheme)
+	}
+	u, err := url.Parse("https://" + rest)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("step-ca reference should be in the format %sHOST[:PORT]", ReferenceScheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/1.0/sign"
+
+	p := &Provider{signURL: u.String(), client: http.DefaultClient}
+	if path := env.Getenv(env.VariableStepCARoot); path != "" {
+		p.root, err = os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("reading step-ca root certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(p.root) {
+			return nil, fmt.Errorf("no certificate found in %s", path)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		p.client = &http.Client{Transport: transport}
+	}
+	return p, nil
+}
+
+type signRequest struct {
+	CSR string `json:"csr"`
+	OTT string `json:"ott"`
+}
+
+type signResponse struct {
+	Crt       string   `json:"crt"`
+	CA        string   `json:"ca"`
+	CertChain []string `json:"certChain"`
+}
+
+// Certificate implements certprovider.Interface.
+func (p *Provider) Certificate(ctx context.Context, req certprovider.Request) (*certprovider.Certificate, error) {
+	if req.IDToken == "" {
+		return nil, errors.New("step-ca issues certificates for OIDC identity tokens, none was found")
+	}
+	csr, err := certprovider.CertificateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(signRequest{CSR: string(csr), OTT: req.IDToken})
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.signURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("step-ca sign: %w", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("step-ca sign: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(b, &e) == nil && e.Message != "" {
+			return nil, fmt.Errorf("step-ca sign: %s: %s", resp.Status, e.Message)
+		}
+		return nil, fmt.Errorf("step-ca sign: %s", resp.Status)
+	}
+
+	var sr signResponse
+	if err := json.Unmarshal(b, &sr); err != nil {
+		return nil, fmt.Errorf("step-ca sign: decoding response: %w", err)
+	}
+	if sr.Crt == "" {
+		return nil, errors.New("step-ca sign: no certificate in response")
+	}
+	// certChain starts with the issued certificate.
+	chain := []string{sr.CA}
+	if len(sr.CertChain) > 1 {
+		chain = sr.CertChain[1:]
+	}
+	chain = append(chain, string(p.root))
+	return &certprovider.Certificate{
+		CertPEM:  certprovider.JoinPEM(sr.Crt),
+		ChainPEM: certprovider.JoinPEM(chain...),
+	}, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepca
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/certprovider"
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+// issue returns the PEM-encoded certificate of the request csr.
+func (ca *testCA) issue(csr string) (string, error) {
+	block, _ := pem.Decode([]byte(csr))
+	if block == nil {
+		return "", errors.New("no PEM certificate request")
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	if err := req.CheckSignature(); err != nil {
+		return "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		Subject:        req.Subject,
+		EmailAddresses: req.EmailAddresses,
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, req.PublicKey, ca.key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
+
+// token is an unsigned JWT for alice@example.com.
+const token = "eyJhbGciOiJub25lIn0.eyJlbWFpbCI6ImFsaWNlQGV4YW1wbGUuY29tIn0.c2ln"
+
+func TestCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/1.0/sign" {
+			http.NotFound(w, r)
+			return
+		}
+		var req signRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.OTT != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":401,"message":"The request lacked necessary authorization to be completed."}`))
+			return
+		}
+		crt, err := ca.issue(req.CSR)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(signResponse{Crt: crt, CA: ca.pem, CertChain: []string{crt, ca.pem}})
+	}))
+	defer srv.Close()
+	rootPath := filepath.Join(t.TempDir(), "root_ca.crt")
+	serverRoot := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(rootPath, serverRoot, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(env.VariableStepCARoot.String(), rootPath)
+
+	p, err := certprovider.Get(context.Background(), ReferenceScheme+strings.TrimPrefix(srv.URL, "https://"))
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := p.Certificate(context.Background(), certprovider.Request{Signer: key, IDToken: token})
+	if err != nil {
+		t.Fatalf("Certificate() = %v", err)
+	}
+	block, _ := pem.Decode(cert.CertPEM)
+	if block == nil {
+		t.Fatalf("Certificate() = %s, wanted a PEM certificate", cert.CertPEM)
+	}
+	issued, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issued.EmailAddresses) != 1 || issued.EmailAddresses[0] != "alice@example.com" {
+		t.Errorf("certificate issued for %v", issued.EmailAddresses)
+	}
+	if want := ca.pem + string(serverRoot); string(cert.ChainPEM) != want {
+		t.Errorf("chain = %s, wanted the issuing CA and the root", cert.ChainPEM)
+	}
+
+	if _, err := p.Certificate(context.Background(), certprovider.Request{Signer: key}); err == nil {
+		t.Error("Certificate() without identity token succeeded")
+	}
+	otherToken := "eyJhbGciOiJub25lIn0.eyJlbWFpbCI6ImJvYkBleGFtcGxlLmNvbSJ9.c2ln"
+	_, err = p.Certificate(context.Background(), certprovider.Request{Signer: key, IDToken: otherToken})
+	if err == nil || !strings.Contains(err.Error(), "lacked necessary authorization") {
+		t.Errorf("Certificate() with an unauthorized token = %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, ref := range []string{"step-ca://", "https://ca.example.com"} {
+		if _, err := New(ref); err == nil {
+			t.Errorf("New(%q) succeeded", ref)
+		}
+	}
+	p, err := New("step-ca://ca.example.com:9000/")
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	if p.signURL != "https://ca.example.com:9000/1.0/sign" {
+		t.Errorf("sign URL = %s", p.signURL)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vaultpki issues the certificates of keyless signing with a role
+// of the PKI secrets engine of HashiCorp Vault. It logs in to Vault like
+// the hashivault:// KMS provider, with VAULT_ADDR, VAULT_TOKEN or the auth
+// method VAULT_AUTH_METHOD selects.
+package vaultpki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/franchb/cosign/v2/pkg/certprovider"
+	"github.com/franchb/cosign/v2/pkg/signature/kms/hashivault"
+	vault "github.com/hashicorp/vault/api"
+)
+
+func init() {
+	certprovider.AddProvider(ReferenceScheme, func(ctx context.Context, ref string) (certprovider.Interface, error) {
+		return New(ctx, ref)
+	})
+}
+
+// ReferenceScheme is the scheme of references to roles of PKI secrets
+// engines, as vault-pki://[MOUNT/]ROLE where MOUNT is "pki" by default.
+const ReferenceScheme = "vault-pki://"
+
+const defaultMount = "pki"
+
+var errReference = errors.New("vault PKI reference should be in the format " + ReferenceScheme + "[MOUNT/]ROLE")
+
+// Provider requests certificates signed by a role of a PKI secrets engine.
+type Provider struct {
+	client   *vault.Client
+	signPath string
+}
+
+// New returns the provider of the role ref refers to, logged in to Vault.
+func New(ctx context.Context, ref string) (*Provider, error) {
+	path, ok := strings.CutPrefix(ref, ReferenceScheme)
+	path = strings.Trim(path, "/")
+	if !ok || path == "" {
+		return nil, errReference
+	}
+	mount, role := defaultMount, path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		mount, role = path[:i], path[i+1:]
+	}
+	client, err := hashivault.NewClient(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{client: client, signPath: mount + "/sign/" + role}, nil
+}
+
+// Certificate implements certprovider.Interface.
+func (p *Provider) Certificate(ctx context.Context, req certprovider.Request) (*certprovider.Certificate, error) {
+	csr, err := certprovider.CertificateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := p.client.Logical().WriteWithContext(ctx, p.signPath, map[string]any{
+		"csr":    string(csr),
+		"format": "pem",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vault sign %s: %w", p.signPath, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("vault sign %s: empty response", p.signPath)
+	}
+	cert, _ := secret.Data["certificate"].(string)
+	if cert == "" {
+		return nil, fmt.Errorf("vault sign %s: no certificate in response", p.signPath)
+	}
+	// ca_chain holds the issuing CA and the CAs above it that Vault
+	// knows of, issuing_ca only the former.
+	var chain []string
+	if caChain, ok := secret.Data["ca_chain"].([]any); ok {
+		for _, c := range caChain {
+			if s, ok := c.(string); ok {
+				chain = append(chain, s)
+			}
+		}
+	}
+	if len(chain) == 0 {
+		issuingCA, _ := secret.Data["issuing_ca"].(string)
+		chain = append(chain, issuingCA)
+	}
+	return &certprovider.Certificate{
+		CertPEM:  certprovider.JoinPEM(cert),
+		ChainPEM: certprovider.JoinPEM(chain...),
+	}, nil
+}
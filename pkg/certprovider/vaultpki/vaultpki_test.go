@@ -0,0 +1,134 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vaultpki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franchb/cosign/v2/pkg/certprovider"
+)
+
+const (
+	leafPEM         = "-----BEGIN CERTIFICATE-----\nbGVhZg==\n-----END CERTIFICATE-----"
+	intermediatePEM = "-----BEGIN CERTIFICATE-----\naW50ZXJtZWRpYXRl\n-----END CERTIFICATE-----"
+	rootPEM         = "-----BEGIN CERTIFICATE-----\ncm9vdA==\n-----END CERTIFICATE-----"
+)
+
+func TestCertificate(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		ref       string
+		path      string
+		data      map[string]any
+		wantChain string
+	}{{
+		name: "default mount",
+		ref:  "vault-pki://cosign",
+		path: "/v1/pki/sign/cosign",
+		data: map[string]any{
+			"certificate": leafPEM,
+			"issuing_ca":  intermediatePEM,
+			"ca_chain":    []string{intermediatePEM, rootPEM},
+		},
+		wantChain: intermediatePEM + "\n" + rootPEM + "\n",
+	}, {
+		name: "nested mount without chain",
+		ref:  "vault-pki://teams/release/pki_int/cosign",
+		path: "/v1/teams/release/pki_int/sign/cosign",
+		data: map[string]any{
+			"certificate": leafPEM,
+			"issuing_ca":  intermediatePEM,
+		},
+		wantChain: intermediatePEM + "\n",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.path || r.Header.Get("X-Vault-Token") != "s.token" {
+					http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+					return
+				}
+				var body map[string]string
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !strings.HasPrefix(body["csr"], "-----BEGIN CERTIFICATE REQUEST-----") {
+					http.Error(w, `{"errors":["missing csr"]}`, http.StatusBadRequest)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"data": tc.data})
+			}))
+			defer srv.Close()
+			t.Setenv("VAULT_ADDR", srv.URL)
+			t.Setenv("VAULT_TOKEN", "s.token")
+			t.Setenv("VAULT_AUTH_METHOD", "")
+
+			p, err := certprovider.Get(context.Background(), tc.ref)
+			if err != nil {
+				t.Fatalf("Get() = %v", err)
+			}
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cert, err := p.Certificate(context.Background(), certprovider.Request{Signer: key})
+			if err != nil {
+				t.Fatalf("Certificate() = %v", err)
+			}
+			if string(cert.CertPEM) != leafPEM+"\n" {
+				t.Errorf("certificate = %q", cert.CertPEM)
+			}
+			if string(cert.ChainPEM) != tc.wantChain {
+				t.Errorf("chain = %q, wanted %q", cert.ChainPEM, tc.wantChain)
+			}
+		})
+	}
+}
+
+func TestCertificateDenied(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "s.token")
+	t.Setenv("VAULT_AUTH_METHOD", "")
+
+	p, err := New(context.Background(), "vault-pki://cosign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Certificate(context.Background(), certprovider.Request{Signer: key}); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Certificate() = %v", err)
+	}
+}
+
+func TestNewReference(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	t.Setenv("VAULT_TOKEN", "s.token")
+	for _, ref := range []string{"vault-pki://", "vault-pki:///", "hashivault://cosign"} {
+		if _, err := New(context.Background(), ref); err == nil {
+			t.Errorf("New(%q) succeeded", ref)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+// AgePrivateKeyPemType is the PEM type of an ASCII armored age file. Private
+// keys protected with age recipients are stored this way, and decrypt to a
+// PEM-encoded PKCS #8 private key.
+const AgePrivateKeyPemType = "AGE ENCRYPTED FILE"
+
+// GenerateKeyPairAge generates a key pair like GenerateKeyPairWithOptions,
+// but encrypts the private key to the age recipients instead of with a
+// password.
+func GenerateKeyPairAge(opts KeyPairOptions, recipients []age.Recipient) (*KeysBytes, error) {
+	keys, err := opts.generate()
+	if err != nil {
+		return nil, err
+	}
+	return marshalKeyPairAge(keys, recipients)
+}
+
+// ImportKeyPairAge imports a key pair like ImportKeyPair, but encrypts the
+// private key to the age recipients instead of with a password.
+func ImportKeyPairAge(keyPath string, opts ImportOptions, recipients []age.Recipient) (*KeysBytes, error) {
+	imported, err := readImportedKey(keyPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return marshalKeyPairAge(imported.keys(), recipients)
+}
+
+func marshalKeyPairAge(keypair Keys, recipients []age.Recipient) (*KeysBytes, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("at least one age recipient is required")
+	}
+	x509Encoded, err := keypair.marshalPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("x509 encoding private key: %w", err)
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("age encrypting private key: %w", err)
+	}
+	if err := pem.Encode(w, &pem.Block{Type: PrivateKeyPemType, Bytes: x509Encoded}); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+
+	pubBytes, err := keypair.marshalPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	certBytes, chainBytes, err := keypair.marshalCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeysBytes{
+		PrivateBytes:          buf.Bytes(),
+		PublicBytes:           pubBytes,
+		CertificateBytes:      certBytes,
+		CertificateChainBytes: chainBytes,
+	}, nil
+}
+
+// IsAgeEncrypted reports whether key is a private key encrypted with age, to
+// be loaded with LoadAgePrivateKey rather than LoadPrivateKey.
+func IsAgeEncrypted(key []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(key), []byte(armor.Header))
+}
+
+// LoadAgePrivateKey decrypts a private key encrypted to age recipients with
+// one of identities, and returns a SignerVerifier instance.
+func LoadAgePrivateKey(key []byte, identities []age.Identity) (signature.SignerVerifier, error) {
+	x509Encoded, err := decryptAgePrivateKey(key, identities)
+	if err != nil {
+		return nil, err
+	}
+	return loadPKCS8PrivateKey(x509Encoded)
+}
+
+// decryptAgePrivateKey decrypts a private key encrypted to age recipients
+// with one of identities, and returns the PKCS #8 encoded private key.
+func decryptAgePrivateKey(key []byte, identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(bytes.TrimSpace(key))), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("age decrypt: %w", err)
+	}
+	pemBytes, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("age decrypt: %w", err)
+	}
+	p, _ := pem.Decode(pemBytes)
+	if p == nil {
+		return nil, errors.New("invalid pem block")
+	}
+	if p.Type != PrivateKeyPemType {
+		return nil, fmt.Errorf("unsupported pem type: %s", p.Type)
+	}
+	return p.Bytes, nil
+}
+
+// ParseAgeRecipients parses age recipients, each given either as an
+// "age1..." public key or as the path of a recipients file with one such key
+// per line.
+func ParseAgeRecipients(recipients []string) ([]age.Recipient, error) {
+	var out []age.Recipient
+	for _, r := range recipients {
+		if strings.HasPrefix(r, "age1") {
+			recipient, err := age.ParseX25519Recipient(r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, recipient)
+			continue
+		}
+		f, err := os.Open(filepath.Clean(r))
+		if err != nil {
+			return nil, fmt.Errorf("reading age recipients: %w", err)
+		}
+		parsed, err := age.ParseRecipients(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing age recipients from %s: %w", r, err)
+		}
+		out = append(out, parsed...)
+	}
+	return out, nil
+}
+
+// LoadAgeIdentities reads the age identities from the identity file at path,
+// as written by age-keygen.
+func LoadAgeIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading age identities: %w", err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing age identities from %s: %w", path, err)
+	}
+	return identities, nil
+}
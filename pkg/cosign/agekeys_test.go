@@ -0,0 +1,117 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKeyPairAge(t *testing.T) {
+	alice, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	bob, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	mallory, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	keys, err := GenerateKeyPairAge(KeyPairOptions{}, []age.Recipient{alice.Recipient(), bob.Recipient()})
+	require.NoError(t, err)
+	require.True(t, IsAgeEncrypted(keys.PrivateBytes))
+	require.Empty(t, keys.Password())
+
+	// Every recipient can load the key, and it signs for the public key.
+	for _, id := range []age.Identity{alice, bob} {
+		sv, err := LoadAgePrivateKey(keys.PrivateBytes, []age.Identity{id})
+		require.NoError(t, err)
+		pub, err := PemToECDSAKey(keys.PublicBytes)
+		require.NoError(t, err)
+		svPub, err := sv.PublicKey()
+		require.NoError(t, err)
+		require.True(t, pub.Equal(svPub))
+
+		sig, err := sv.SignMessage(bytes.NewReader([]byte("hello")))
+		require.NoError(t, err)
+		require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("hello"))))
+	}
+
+	if _, err := LoadAgePrivateKey(keys.PrivateBytes, []age.Identity{mallory}); err == nil {
+		t.Error("LoadAgePrivateKey() succeeded with an identity that is not a recipient")
+	}
+	if _, err := LoadPrivateKey(keys.PrivateBytes, nil); err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("LoadPrivateKey() = %v, wanted an error about age", err)
+	}
+	if _, err := GenerateKeyPairAge(KeyPairOptions{}, nil); err == nil {
+		t.Error("GenerateKeyPairAge() succeeded without recipients")
+	}
+}
+
+func TestImportKeyPairAge(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	td := t.TempDir()
+	keyFile := filepath.Join(td, "ed25519.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(ed25519key), 0600))
+
+	keys, err := ImportKeyPairAge(keyFile, ImportOptions{}, []age.Recipient{id.Recipient()})
+	require.NoError(t, err)
+	_, err = LoadAgePrivateKey(keys.PrivateBytes, []age.Identity{id})
+	require.NoError(t, err)
+
+	invalidFile := filepath.Join(td, "invalid.key")
+	require.NoError(t, os.WriteFile(invalidFile, []byte(invalidkey), 0600))
+	_, err = ImportKeyPairAge(invalidFile, ImportOptions{}, []age.Recipient{id.Recipient()})
+	require.EqualError(t, err, "invalid pem block")
+}
+
+func TestAgeRecipientsAndIdentities(t *testing.T) {
+	alice, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	bob, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	td := t.TempDir()
+	recipientsFile := filepath.Join(td, "recipients.txt")
+	require.NoError(t, os.WriteFile(recipientsFile, []byte("# bob\n"+bob.Recipient().String()+"\n"), 0600))
+	identityFile := filepath.Join(td, "key.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte("# created: 2024-01-01T00:00:00Z\n"+bob.String()+"\n"), 0600))
+
+	recipients, err := ParseAgeRecipients([]string{alice.Recipient().String(), recipientsFile})
+	require.NoError(t, err)
+	require.Len(t, recipients, 2)
+
+	keys, err := GenerateKeyPairAge(KeyPairOptions{}, recipients)
+	require.NoError(t, err)
+	identities, err := LoadAgeIdentities(identityFile)
+	require.NoError(t, err)
+	_, err = LoadAgePrivateKey(keys.PrivateBytes, identities)
+	require.NoError(t, err)
+
+	for _, bad := range [][]string{{"age1invalid"}, {filepath.Join(td, "missing.txt")}, {identityFile}} {
+		if _, err := ParseAgeRecipients(bad); err == nil {
+			t.Errorf("ParseAgeRecipients(%q) succeeded", bad)
+		}
+	}
+	if _, err := LoadAgeIdentities(recipientsFile); err == nil {
+		t.Error("LoadAgeIdentities() succeeded for a recipients file")
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// annotationOperators are the operators of an AnnotationCondition, longest
+// first so that ">=" is not mistaken for ">".
+var annotationOperators = []string{"!=", ">=", "<=", "=", ">", "<"}
+
+// AnnotationCondition compares the value of a signed annotation with Value
+// using Op, one of =, !=, >, >=, < and <=. = and != compare strings, while
+// the ordering operators compare numbers, RFC 3339 timestamps or semantic
+// versions, depending on what Value parses as.
+type AnnotationCondition struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// String returns the condition as it is parsed by ParseAnnotationCondition.
+func (c AnnotationCondition) String() string {
+	return c.Key + c.Op + c.Value
+}
+
+// ParseAnnotationCondition parses a condition written as KEY OP VALUE, such
+// as "build>=42", "created<2024-06-01T00:00:00Z" or "version>=v1.2.0".
+func ParseAnnotationCondition(s string) (AnnotationCondition, error) {
+	s = strings.TrimSpace(s)
+	for i := range s {
+		for _, op := range annotationOperators {
+			if !strings.HasPrefix(s[i:], op) {
+				continue
+			}
+			c := AnnotationCondition{
+				Key:   strings.TrimSpace(s[:i]),
+				Op:    op,
+				Value: strings.TrimSpace(s[i+len(op):]),
+			}
+			if c.Key == "" {
+				return AnnotationCondition{}, fmt.Errorf("annotation condition %q has no key", s)
+			}
+			if c.Op != "=" && c.Op != "!=" {
+				if _, err := parseOrdered(c.Value); err != nil {
+					return AnnotationCondition{}, fmt.Errorf("annotation condition %q: %w", s, err)
+				}
+			}
+			return c, nil
+		}
+	}
+	return AnnotationCondition{}, fmt.Errorf("annotation condition %q has no operator, expected one of %s", s, strings.Join(annotationOperators, " "))
+}
+
+// Holds reports whether annotations satisfy c. A missing annotation
+// satisfies only !=.
+func (c AnnotationCondition) Holds(annotations map[string]interface{}) (bool, error) {
+	v, ok := annotations[c.Key]
+	if !ok {
+		return c.Op == "!=", nil
+	}
+	have := annotationString(v)
+	switch c.Op {
+	case "=":
+		return have == c.Value, nil
+	case "!=":
+		return have != c.Value, nil
+	}
+	want, err := parseOrdered(c.Value)
+	if err != nil {
+		return false, err
+	}
+	cmp, err := want.compare(have)
+	if err != nil {
+		return false, fmt.Errorf("annotation %s: %w", c.Key, err)
+	}
+	switch c.Op {
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	default:
+		return cmp <= 0, nil
+	}
+}
+
+// AnnotationExpression holds if any of its alternatives does, and an
+// alternative holds if all of its conditions do.
+type AnnotationExpression [][]AnnotationCondition
+
+// ParseAnnotationExpression parses alternatives separated by "||", each of
+// conditions separated by "&&", as in
+// "version>=1.2.0 && version<2.0.0 || env=dev".
+func ParseAnnotationExpression(expr string) (AnnotationExpression, error) {
+	var e AnnotationExpression
+	for _, alt := range strings.Split(expr, "||") {
+		var conds []AnnotationCondition
+		for _, s := range strings.Split(alt, "&&") {
+			c, err := ParseAnnotationCondition(s)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, c)
+		}
+		e = append(e, conds)
+	}
+	return e, nil
+}
+
+// String returns the expression as it is parsed by ParseAnnotationExpression.
+func (e AnnotationExpression) String() string {
+	alts := make([]string, 0, len(e))
+	for _, conds := range e {
+		ss := make([]string, 0, len(conds))
+		for _, c := range conds {
+			ss = append(ss, c.String())
+		}
+		alts = append(alts, strings.Join(ss, " && "))
+	}
+	return strings.Join(alts, " || ")
+}
+
+// Holds reports whether annotations satisfy e.
+func (e AnnotationExpression) Holds(annotations map[string]interface{}) (bool, error) {
+alternatives:
+	for _, conds := range e {
+		for _, c := range conds {
+			ok, err := c.Holds(annotations)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue alternatives
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// AnnotationConditions are expressions that must all hold.
+type AnnotationConditions []AnnotationExpression
+
+// ParseAnnotationConditions parses exprs with ParseAnnotationExpression.
+func ParseAnnotationConditions(exprs []string) (AnnotationConditions, error) {
+	conds := make(AnnotationConditions, 0, len(exprs))
+	for _, expr := range exprs {
+		e, err := ParseAnnotationExpression(expr)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, e)
+	}
+	return conds, nil
+}
+
+// Check returns an error naming the first expression that annotations do not
+// satisfy.
+func (conds AnnotationConditions) Check(annotations map[string]interface{}) error {
+	for _, e := range conds {
+		ok, err := e.Holds(annotations)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("annotations do not satisfy %s", e)
+		}
+	}
+	return nil
+}
+
+// orderedValue is the value of an ordering comparison.
+type orderedValue struct {
+	number  *float64
+	time    *time.Time
+	version string
+}
+
+// parseOrdered parses s as a number, an RFC 3339 timestamp or a semantic
+// version, in that order.
+func parseOrdered(s string) (orderedValue, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return orderedValue{number: &f}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return orderedValue{time: &t}, nil
+	}
+	if v := canonicalVersion(s); v != "" {
+		return orderedValue{version: v}, nil
+	}
+	return orderedValue{}, fmt.Errorf("%q is not a number, RFC 3339 timestamp or semantic version", s)
+}
+
+// compare compares have, parsed as the same kind of value as o, with o.
+func (o orderedValue) compare(have string) (int, error) {
+	switch {
+	case o.number != nil:
+		f, err := strconv.ParseFloat(have, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", have)
+		}
+		switch {
+		case f < *o.number:
+			return -1, nil
+		case f > *o.number:
+			return 1, nil
+		}
+		return 0, nil
+	case o.time != nil:
+		t, err := time.Parse(time.RFC3339, have)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an RFC 3339 timestamp", have)
+		}
+		return t.Compare(*o.time), nil
+	default:
+		v := canonicalVersion(have)
+		if v == "" {
+			return 0, fmt.Errorf("%q is not a semantic version", have)
+		}
+		return semver.Compare(v, o.version), nil
+	}
+}
+
+// canonicalVersion returns s as a semantic version with a leading v, or the
+// empty string if it is not one.
+func canonicalVersion(s string) string {
+	if !strings.HasPrefix(s, "v") {
+		s = "v" + s
+	}
+	if !semver.IsValid(s) {
+		return ""
+	}
+	return s
+}
+
+// annotationString returns an annotation value as a string. Numbers are
+// formatted without exponent, as they were likely written.
+func annotationString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotationConditions(t *testing.T) {
+	annotations := map[string]interface{}{
+		"build":   float64(42),
+		"created": "2024-05-01T12:00:00Z",
+		"version": "v1.4.2",
+		"env":     "prod",
+	}
+	tests := []struct {
+		name       string
+		exprs      []string
+		wantErrSub string
+	}{{
+		name:  "string equality",
+		exprs: []string{"env=prod", "env!=dev"},
+	}, {
+		name:  "numeric",
+		exprs: []string{"build>=42", "build<100"},
+	}, {
+		name:       "numeric fails",
+		exprs:      []string{"build>42"},
+		wantErrSub: "annotations do not satisfy build>42",
+	}, {
+		name:  "timestamps",
+		exprs: []string{"created>2024-01-01T00:00:00Z", "created<=2024-05-01T12:00:00Z"},
+	}, {
+		name:       "timestamp fails",
+		exprs:      []string{"created<2024-01-01T00:00:00Z"},
+		wantErrSub: "annotations do not satisfy",
+	}, {
+		name:  "semver range",
+		exprs: []string{"version>=1.2.0 && version<2.0.0"},
+	}, {
+		name:       "semver range fails",
+		exprs:      []string{"version>=1.5.0 && version<2.0.0"},
+		wantErrSub: "annotations do not satisfy version>=1.5.0 && version<2.0.0",
+	}, {
+		name:  "any alternative",
+		exprs: []string{"version>=2.0.0 || env=prod"},
+	}, {
+		name:       "every expression",
+		exprs:      []string{"env=prod", "env=dev || build<1"},
+		wantErrSub: "annotations do not satisfy env=dev || build<1",
+	}, {
+		name:       "missing annotation",
+		exprs:      []string{"team=security"},
+		wantErrSub: "annotations do not satisfy team=security",
+	}, {
+		name:  "missing annotation differs",
+		exprs: []string{"team!=security"},
+	}, {
+		name:       "mismatched types",
+		exprs:      []string{"env>1"},
+		wantErrSub: "annotation env: \"prod\" is not a number",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conds, err := ParseAnnotationConditions(tc.exprs)
+			if err != nil {
+				t.Fatalf("ParseAnnotationConditions() = %v", err)
+			}
+			err = conds.Check(annotations)
+			switch {
+			case tc.wantErrSub == "" && err != nil:
+				t.Errorf("Check() = %v, wanted no error", err)
+			case tc.wantErrSub != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErrSub)):
+				t.Errorf("Check() = %v, wanted error containing %q", err, tc.wantErrSub)
+			}
+		})
+	}
+}
+
+func TestParseAnnotationConditionErrors(t *testing.T) {
+	for _, s := range []string{"env", "=prod", "build>soon"} {
+		if _, err := ParseAnnotationCondition(s); err == nil {
+			t.Errorf("ParseAnnotationCondition(%q) succeeded, wanted an error", s)
+		}
+	}
+}
@@ -100,6 +100,12 @@ type GenerateOpts struct {
 	Digest string
 	// Repo context of the reference.
 	Repo string
+	// AdditionalDigests are the digests of further subjects in Repo, such as
+	// the images of the image index named by Digest.
+	AdditionalDigests []string
+	// Subjects, when set, are the subjects of the statement instead of
+	// those named by Repo and the digests, such as the files of a directory.
+	Subjects []in_toto.Subject
 
 	// Function to return the time to set
 	Time func() time.Time
@@ -113,33 +119,34 @@ func GenerateStatement(opts GenerateOpts) (interface{}, error) {
 		return nil, err
 	}
 
+	subjects := statementSubjects(opts)
 	switch opts.Type {
 	case "slsaprovenance":
-		return generateSLSAProvenanceStatementSLSA02(predicate, opts.Digest, opts.Repo)
+		return generateSLSAProvenanceStatementSLSA02(predicate, subjects)
 	case "slsaprovenance02":
-		return generateSLSAProvenanceStatementSLSA02(predicate, opts.Digest, opts.Repo)
+		return generateSLSAProvenanceStatementSLSA02(predicate, subjects)
 	case "slsaprovenance1":
-		return generateSLSAProvenanceStatementSLSA1(predicate, opts.Digest, opts.Repo)
+		return generateSLSAProvenanceStatementSLSA1(predicate, subjects)
 	case "spdx":
-		return generateSPDXStatement(predicate, opts.Digest, opts.Repo, false)
+		return generateSPDXStatement(predicate, subjects, false)
 	case "spdxjson":
-		return generateSPDXStatement(predicate, opts.Digest, opts.Repo, true)
+		return generateSPDXStatement(predicate, subjects, true)
 	case "cyclonedx":
-		return generateCycloneDXStatement(predicate, opts.Digest, opts.Repo)
+		return generateCycloneDXStatement(predicate, subjects)
 	case "link":
-		return generateLinkStatement(predicate, opts.Digest, opts.Repo)
+		return generateLinkStatement(predicate, subjects)
 	case "vuln":
-		return generateVulnStatement(predicate, opts.Digest, opts.Repo)
+		return generateVulnStatement(predicate, subjects)
 	case "openvex":
-		return generateOpenVexStatement(predicate, opts.Digest, opts.Repo)
+		return generateOpenVexStatement(predicate, subjects)
 	default:
 		stamp := timestamp(opts)
 		predicateType := customType(opts)
-		return generateCustomStatement(predicate, predicateType, opts.Digest, opts.Repo, stamp)
+		return generateCustomStatement(predicate, predicateType, subjects, stamp)
 	}
 }
 
-func generateVulnStatement(predicate []byte, digest string, repo string) (interface{}, error) {
+func generateVulnStatement(predicate []byte, subjects []in_toto.Subject) (interface{}, error) {
 	var vuln CosignVulnPredicate
 
 	err := json.Unmarshal(predicate, &vuln)
@@ -148,7 +155,7 @@ func generateVulnStatement(predicate []byte, digest string, repo string) (interf
 	}
 
 	return in_toto.Statement{
-		StatementHeader: generateStatementHeader(digest, repo, CosignVulnProvenanceV01),
+		StatementHeader: generateStatementHeader(subjects, CosignVulnProvenanceV01),
 		Predicate:       vuln,
 	}, nil
 }
@@ -168,29 +175,40 @@ func customType(opts GenerateOpts) string {
 	return CosignCustomProvenanceV01
 }
 
-func generateStatementHeader(digest, repo, predicateType string) in_toto.StatementHeader {
+func generateStatementHeader(subjects []in_toto.Subject, predicateType string) in_toto.StatementHeader {
 	return in_toto.StatementHeader{
 		Type:          in_toto.StatementInTotoV01,
 		PredicateType: predicateType,
-		Subject: []in_toto.Subject{
-			{
-				Name: repo,
-				Digest: map[string]string{
-					"sha256": digest,
-				},
+		Subject:       subjects,
+	}
+}
+
+// statementSubjects returns the subjects named by opts: Subjects if set,
+// otherwise Digest followed by AdditionalDigests, all in Repo.
+func statementSubjects(opts GenerateOpts) []in_toto.Subject {
+	if len(opts.Subjects) > 0 {
+		return opts.Subjects
+	}
+	subjects := make([]in_toto.Subject, 0, 1+len(opts.AdditionalDigests))
+	for _, digest := range append([]string{opts.Digest}, opts.AdditionalDigests...) {
+		subjects = append(subjects, in_toto.Subject{
+			Name: opts.Repo,
+			Digest: map[string]string{
+				"sha256": digest,
 			},
-		},
+		})
 	}
+	return subjects
 }
 
-func generateCustomStatement(rawPayload []byte, customType, digest, repo, timestamp string) (interface{}, error) {
+func generateCustomStatement(rawPayload []byte, customType string, subjects []in_toto.Subject, timestamp string) (interface{}, error) {
 	payload, err := generateCustomPredicate(rawPayload, customType, timestamp)
 	if err != nil {
 		return nil, err
 	}
 
 	return in_toto.Statement{
-		StatementHeader: generateStatementHeader(digest, repo, customType),
+		StatementHeader: generateStatementHeader(subjects, customType),
 		Predicate:       payload,
 	}, nil
 }
@@ -211,7 +229,7 @@ func generateCustomPredicate(rawPayload []byte, customType, timestamp string) (i
 	return result, nil
 }
 
-func generateSLSAProvenanceStatementSLSA02(rawPayload []byte, digest string, repo string) (interface{}, error) {
+func generateSLSAProvenanceStatementSLSA02(rawPayload []byte, subjects []in_toto.Subject) (interface{}, error) {
 	var predicate slsa02.ProvenancePredicate
 	err := checkRequiredJSONFields(rawPayload, reflect.TypeOf(predicate))
 	if err != nil {
@@ -222,12 +240,12 @@ func generateSLSAProvenanceStatementSLSA02(rawPayload []byte, digest string, rep
 		return "", fmt.Errorf("unmarshal Provenance predicate: %w", err)
 	}
 	return in_toto.ProvenanceStatementSLSA02{
-		StatementHeader: generateStatementHeader(digest, repo, slsa02.PredicateSLSAProvenance),
+		StatementHeader: generateStatementHeader(subjects, slsa02.PredicateSLSAProvenance),
 		Predicate:       predicate,
 	}, nil
 }
 
-func generateSLSAProvenanceStatementSLSA1(rawPayload []byte, digest string, repo string) (interface{}, error) {
+func generateSLSAProvenanceStatementSLSA1(rawPayload []byte, subjects []in_toto.Subject) (interface{}, error) {
 	var predicate slsa1.ProvenancePredicate
 	err := checkRequiredJSONFields(rawPayload, reflect.TypeOf(predicate))
 	if err != nil {
@@ -238,12 +256,12 @@ func generateSLSAProvenanceStatementSLSA1(rawPayload []byte, digest string, repo
 		return "", fmt.Errorf("unmarshal Provenance predicate: %w", err)
 	}
 	return in_toto.ProvenanceStatementSLSA1{
-		StatementHeader: generateStatementHeader(digest, repo, slsa1.PredicateSLSAProvenance),
+		StatementHeader: generateStatementHeader(subjects, slsa1.PredicateSLSAProvenance),
 		Predicate:       predicate,
 	}, nil
 }
 
-func generateLinkStatement(rawPayload []byte, digest string, repo string) (interface{}, error) {
+func generateLinkStatement(rawPayload []byte, subjects []in_toto.Subject) (interface{}, error) {
 	var link in_toto.Link
 	err := checkRequiredJSONFields(rawPayload, reflect.TypeOf(link))
 	if err != nil {
@@ -254,23 +272,23 @@ func generateLinkStatement(rawPayload []byte, digest string, repo string) (inter
 		return "", fmt.Errorf("unmarshal Link statement: %w", err)
 	}
 	return in_toto.LinkStatement{
-		StatementHeader: generateStatementHeader(digest, repo, in_toto.PredicateLinkV1),
+		StatementHeader: generateStatementHeader(subjects, in_toto.PredicateLinkV1),
 		Predicate:       link,
 	}, nil
 }
 
-func generateOpenVexStatement(rawPayload []byte, digest string, repo string) (interface{}, error) {
+func generateOpenVexStatement(rawPayload []byte, subjects []in_toto.Subject) (interface{}, error) {
 	var data interface{}
 	if err := json.Unmarshal(rawPayload, &data); err != nil {
 		return nil, err
 	}
 	return in_toto.Statement{
-		StatementHeader: generateStatementHeader(digest, repo, OpenVexNamespace),
+		StatementHeader: generateStatementHeader(subjects, OpenVexNamespace),
 		Predicate:       data,
 	}, nil
 }
 
-func generateSPDXStatement(rawPayload []byte, digest string, repo string, parseJSON bool) (interface{}, error) {
+func generateSPDXStatement(rawPayload []byte, subjects []in_toto.Subject, parseJSON bool) (interface{}, error) {
 	var data interface{}
 	if parseJSON {
 		if err := json.Unmarshal(rawPayload, &data); err != nil {
@@ -280,18 +298,18 @@ func generateSPDXStatement(rawPayload []byte, digest string, repo string, parseJ
 		data = string(rawPayload)
 	}
 	return in_toto.SPDXStatement{
-		StatementHeader: generateStatementHeader(digest, repo, in_toto.PredicateSPDX),
+		StatementHeader: generateStatementHeader(subjects, in_toto.PredicateSPDX),
 		Predicate:       data,
 	}, nil
 }
 
-func generateCycloneDXStatement(rawPayload []byte, digest string, repo string) (interface{}, error) {
+func generateCycloneDXStatement(rawPayload []byte, subjects []in_toto.Subject) (interface{}, error) {
 	var data interface{}
 	if err := json.Unmarshal(rawPayload, &data); err != nil {
 		return nil, err
 	}
 	return in_toto.SPDXStatement{
-		StatementHeader: generateStatementHeader(digest, repo, in_toto.PredicateCycloneDX),
+		StatementHeader: generateStatementHeader(subjects, in_toto.PredicateCycloneDX),
 		Predicate:       data,
 	}, nil
 }
@@ -0,0 +1,170 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/pkg/errors"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+// AuditEntry is the outcome of verifying one signature or attestation of an
+// image with AuditImage.
+type AuditEntry struct {
+	// Attestation is whether Signature is an attestation.
+	Attestation bool
+	// Signature is the signature or attestation.
+	Signature oci.Signature
+	// Timestamps are the timestamps accepted by verification.
+	Timestamps VerifiedTimestamps
+	// Err is why verification failed, nil if it succeeded.
+	Err error
+	// NotValidNow is why the certificate of a verified signature would no
+	// longer be accepted if the signature were made now, e.g. because it or
+	// its issuer has expired since.
+	NotValidNow error
+}
+
+// Time returns when the entry was signed according to its verified
+// timestamps, preferring the transparency log, or the zero time if none
+// were verified.
+func (e *AuditEntry) Time() time.Time {
+	switch {
+	case e.Timestamps.IntegratedTime != nil:
+		return *e.Timestamps.IntegratedTime
+	case e.Timestamps.RFC3161Time != nil:
+		return *e.Timestamps.RFC3161Time
+	default:
+		return time.Time{}
+	}
+}
+
+// AuditImage verifies every signature and attestation of signedImgRef,
+// rather than stopping at the first that verifies, and returns the outcome
+// of each in chronological order. Entries without a verified timestamp come
+// last. co.ClaimVerifier is ignored: signatures are checked with
+// SimpleClaimVerifier and attestations with IntotoSubjectClaimVerifier.
+func AuditImage(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) ([]AuditEntry, error) {
+	// Enforce this up front.
+	if co.RootCerts == nil && co.SigVerifier == nil {
+		return nil, errors.New("one of verifier or root certs is required")
+	}
+
+	digest, err := ociremote.ResolveDigest(signedImgRef, co.RegistryClientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	h, err := v1.NewHash(digest.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	se := ociremote.SignedUnknown(digest, co.RegistryClientOpts...)
+
+	sigs, err := se.Signatures()
+	if err != nil {
+		return nil, fmt.Errorf("fetching signatures: %w", err)
+	}
+	sigCO := *co
+	sigCO.ClaimVerifier = SimpleClaimVerifier
+	entries, err := auditSignatures(ctx, sigs, h, false, verifyOCISignature, &sigCO)
+	if err != nil {
+		return nil, err
+	}
+
+	atts, err := se.Attestations()
+	if err != nil {
+		return nil, fmt.Errorf("fetching attestations: %w", err)
+	}
+	attCO := *co
+	attCO.ClaimVerifier = IntotoSubjectClaimVerifier
+	attEntries, err := auditSignatures(ctx, atts, h, true, verifyOCIAttestation, &attCO)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, attEntries...)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, tj := entries[i].Time(), entries[j].Time()
+		if ti.IsZero() || tj.IsZero() {
+			return !ti.IsZero() && tj.IsZero()
+		}
+		return ti.Before(tj)
+	})
+	return entries, nil
+}
+
+func auditSignatures(ctx context.Context, sigs oci.Signatures, h v1.Hash, attestation bool, verifyFn signatureVerificationFn, co *CheckOpts) ([]AuditEntry, error) {
+	sl, err := sigs.Get()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := make([]AuditEntry, 0, len(sl))
+	for _, sig := range sl {
+		sig, err := static.Copy(sig)
+		if err != nil {
+			return nil, err
+		}
+		entry := AuditEntry{
+			Attestation: attestation,
+			Signature:   sig,
+		}
+		_, entry.Timestamps, entry.Err = verifyInternalWithTimestamps(ctx, sig, h, verifyFn, co)
+		if entry.Err == nil {
+			entry.NotValidNow = certNotValidAt(sig, now, co)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// certNotValidAt returns why the certificate of sig, if any, would not be
+// accepted at t, or nil if it would.
+func certNotValidAt(sig oci.Signature, t time.Time, co *CheckOpts) error {
+	cert, err := sig.Cert()
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return nil
+	}
+	if t.After(cert.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+	}
+	if co.RootCerts == nil {
+		return nil
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		CurrentTime:   t,
+		Roots:         co.RootCerts,
+		Intermediates: co.IntermediateCerts,
+		KeyUsages: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageCodeSigning,
+		},
+	}); err != nil {
+		return fmt.Errorf("certificate chain is no longer valid: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/test"
+)
+
+func TestAuditImage(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	digest, verifier := writeSignedTestImage(t, s, nil)
+
+	entries, err := AuditImage(context.Background(), digest, &CheckOpts{
+		SigVerifier: verifier,
+		IgnoreTlog:  true,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NoError(t, entries[0].Err)
+	require.NoError(t, entries[0].NotValidNow)
+	require.False(t, entries[0].Attestation)
+	require.True(t, entries[0].Time().IsZero())
+
+	// Failures are reported per entry rather than failing the audit.
+	_, other := writeSignedTestImage(t, s, nil)
+	entries, err = AuditImage(context.Background(), digest, &CheckOpts{
+		SigVerifier: other,
+		IgnoreTlog:  true,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Error(t, entries[0].Err)
+}
+
+func TestCertNotValidAt(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+	leafCert, _, _ := test.GenerateLeafCert("subject@mail.com", "oidc-issuer", subCert, subKey)
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	expiredCert, err := test.GenerateLeafCertWithExpiration("subject@mail.com", "oidc-issuer", time.Now().Add(-time.Hour), leafKey, subCert, subKey)
+	require.NoError(t, err)
+
+	rootPool := x509.NewCertPool()
+	rootPool.AddCert(rootCert)
+	subPool := x509.NewCertPool()
+	subPool.AddCert(subCert)
+	co := &CheckOpts{
+		RootCerts:         rootPool,
+		IntermediateCerts: subPool,
+	}
+
+	for _, tc := range []struct {
+		name    string
+		cert    *x509.Certificate
+		wantErr bool
+	}{{
+		name: "valid",
+		cert: leafCert,
+	}, {
+		name:    "expired",
+		cert:    expiredCert,
+		wantErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pemLeaf, err := cryptoutils.MarshalCertificateToPEM(tc.cert)
+			require.NoError(t, err)
+			sig, err := static.NewSignature([]byte("payload"), "", static.WithCertChain(pemLeaf, nil))
+			require.NoError(t, err)
+			err = certNotValidAt(sig, time.Now(), co)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
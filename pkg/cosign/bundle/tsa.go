@@ -21,6 +21,16 @@ type RFC3161Timestamp struct {
 	// Clients MUST verify the hashed message in the message imprint,
 	// typically using the artifact signature.
 	SignedRFC3161Timestamp []byte
+	// AdditionalSignedRFC3161Timestamps contains the DER encoded
+	// TimeStampResponses of further timestamp authorities, any of which
+	// may vouch for the time of the signature.
+	AdditionalSignedRFC3161Timestamps [][]byte `json:",omitempty"`
+}
+
+// SignedRFC3161Timestamps returns the timestamp and the additional
+// timestamps.
+func (t *RFC3161Timestamp) SignedRFC3161Timestamps() [][]byte {
+	return append([][]byte{t.SignedRFC3161Timestamp}, t.AdditionalSignedRFC3161Timestamps...)
 }
 
 // TimestampToRFC3161Timestamp receives a base64 encoded RFC3161 timestamp.
@@ -32,3 +42,17 @@ func TimestampToRFC3161Timestamp(timestampRFC3161 []byte) *RFC3161Timestamp {
 	}
 	return nil
 }
+
+// TimestampsToRFC3161Timestamp receives the RFC3161 timestamps of several
+// timestamp authorities, the first of which becomes the timestamp of the
+// bundle and the rest its additional timestamps.
+func TimestampsToRFC3161Timestamp(timestampsRFC3161 [][]byte) *RFC3161Timestamp {
+	if len(timestampsRFC3161) == 0 {
+		return nil
+	}
+	ts := TimestampToRFC3161Timestamp(timestampsRFC3161[0])
+	if ts != nil && len(timestampsRFC3161) > 1 {
+		ts.AdditionalSignedRFC3161Timestamps = timestampsRFC3161[1:]
+	}
+	return ts
+}
@@ -46,3 +46,39 @@ func TestRFC3161Timestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestRFC3161Timestamps(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		timestamps               [][]byte
+		expectedRFC3161Timestamp *RFC3161Timestamp
+	}{{
+		name:                     "no timestamps",
+		timestamps:               nil,
+		expectedRFC3161Timestamp: nil,
+	}, {
+		name:       "single timestamp",
+		timestamps: [][]byte{[]byte("first")},
+		expectedRFC3161Timestamp: &RFC3161Timestamp{
+			SignedRFC3161Timestamp: []byte("first"),
+		},
+	}, {
+		name:       "several timestamps",
+		timestamps: [][]byte{[]byte("first"), []byte("second"), []byte("third")},
+		expectedRFC3161Timestamp: &RFC3161Timestamp{
+			SignedRFC3161Timestamp:            []byte("first"),
+			AdditionalSignedRFC3161Timestamps: [][]byte{[]byte("second"), []byte("third")},
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotBundle := TimestampsToRFC3161Timestamp(tc.timestamps)
+			if !reflect.DeepEqual(gotBundle, tc.expectedRFC3161Timestamp) {
+				t.Fatalf("TimestampsToRFC3161Timestamp returned %v, wanted %v", gotBundle, tc.expectedRFC3161Timestamp)
+			}
+			if gotBundle != nil && !reflect.DeepEqual(gotBundle.SignedRFC3161Timestamps(), tc.timestamps) {
+				t.Errorf("SignedRFC3161Timestamps returned %v, wanted %v", gotBundle.SignedRFC3161Timestamps(), tc.timestamps)
+			}
+		})
+	}
+}
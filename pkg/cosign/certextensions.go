@@ -17,6 +17,8 @@ package cosign
 
 import (
 	"crypto/x509"
+
+	"github.com/franchb/cosign/v2/internal/pkg/certext"
 )
 
 type CertExtensions struct {
@@ -25,7 +27,8 @@ type CertExtensions struct {
 
 var (
 	// Fulcio cert-extensions, documented here: https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md
-	CertExtensionOIDCIssuer               = "1.3.6.1.4.1.57264.1.1"
+	CertExtensionOIDCIssuer               = certext.OIDCIssuerOID
+	CertExtensionOIDCIssuerV2             = certext.OIDCIssuerV2OID
 	CertExtensionGithubWorkflowTrigger    = "1.3.6.1.4.1.57264.1.2"
 	CertExtensionGithubWorkflowSha        = "1.3.6.1.4.1.57264.1.3"
 	CertExtensionGithubWorkflowName       = "1.3.6.1.4.1.57264.1.4"
@@ -55,9 +58,10 @@ func (ce *CertExtensions) certExtensions() map[string]string {
 	return extensions
 }
 
-// GetIssuer returns the issuer for a Certificate
+// GetIssuer returns the issuer for a Certificate, read from either the
+// deprecated or the current OIDC issuer extension.
 func (ce *CertExtensions) GetIssuer() string {
-	return ce.certExtensions()["oidcIssuer"]
+	return certext.OIDCIssuer(ce.Cert)
 }
 
 // GetCertExtensionGithubWorkflowTrigger returns the GitHub Workflow Trigger for a Certificate
@@ -0,0 +1,94 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"sync/atomic"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// VerificationCoalescer deduplicates concurrent verifications of the same
+// image digest against the same policy, so that a burst of identical
+// requests, e.g. to an admission controller, makes a single round trip to the
+// registry and transparency log. Callers waiting on a verification share its
+// result and must not modify the returned signatures. It is safe for
+// concurrent use.
+type VerificationCoalescer struct {
+	group     singleflight.Group
+	calls     atomic.Int64
+	coalesced atomic.Int64
+}
+
+// CoalescerStats counts the verifications seen by a VerificationCoalescer.
+type CoalescerStats struct {
+	// Calls is the number of verifications requested.
+	Calls int64
+	// Coalesced is how many of Calls were served by waiting on a
+	// verification already in flight instead of performing their own.
+	Coalesced int64
+}
+
+// NewVerificationCoalescer returns a VerificationCoalescer to share between
+// the CheckOpts of concurrent verifications.
+func NewVerificationCoalescer() *VerificationCoalescer {
+	return &VerificationCoalescer{}
+}
+
+// Stats returns the verifications counted so far.
+func (c *VerificationCoalescer) Stats() CoalescerStats {
+	return CoalescerStats{
+		Calls:     c.calls.Load(),
+		Coalesced: c.coalesced.Load(),
+	}
+}
+
+type coalescedResult struct {
+	verified       []oci.Signature
+	bundleVerified bool
+}
+
+// verifyCoalesced runs verify, unless a verification of the same kind of ref
+// against co.PolicyKey is already in flight in co.Coalescer, in which case
+// its result is returned instead. Only references by digest are coalesced,
+// since a tag may move between verifications.
+func verifyCoalesced(kind string, ref name.Reference, co *CheckOpts, verify func() ([]oci.Signature, bool, error)) ([]oci.Signature, bool, error) {
+	if co.Coalescer == nil {
+		return verify()
+	}
+	key := verificationKey(kind, ref, co)
+	if key == "" {
+		return verify()
+	}
+	c := co.Coalescer
+	c.calls.Add(1)
+	ran := false
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		ran = true
+		verified, bundleVerified, err := verify()
+		return coalescedResult{verified: verified, bundleVerified: bundleVerified}, err
+	})
+	if !ran {
+		c.coalesced.Add(1)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	r := v.(coalescedResult)
+	return r.verified, r.bundleVerified, nil
+}
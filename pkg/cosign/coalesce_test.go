@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+func TestVerifyCoalesced(t *testing.T) {
+	const callers = 5
+	ref, err := name.NewDigest("example.com/test@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	require.NoError(t, err)
+
+	c := NewVerificationCoalescer()
+	co := &CheckOpts{Coalescer: c, PolicyKey: "policy"}
+
+	var runs atomic.Int64
+	verify := func() ([]oci.Signature, bool, error) {
+		runs.Add(1)
+		// Hold the verification in flight until every caller joined it.
+		for c.Stats().Calls < callers {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil, true, errors.New("verification failed")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := verifyCoalesced("signatures", ref, co, verify); err == nil {
+				t.Error("verifyCoalesced() succeeded, wanted the shared error")
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(1), runs.Load())
+	require.Equal(t, CoalescerStats{Calls: callers, Coalesced: callers - 1}, c.Stats())
+
+	// Other policies and tags are verified on their own.
+	other := *co
+	other.PolicyKey = "other"
+	_, _, _ = verifyCoalesced("signatures", ref, &other, func() ([]oci.Signature, bool, error) {
+		return nil, false, nil
+	})
+	tag, err := name.NewTag("example.com/test:latest")
+	require.NoError(t, err)
+	_, _, _ = verifyCoalesced("signatures", tag, co, func() ([]oci.Signature, bool, error) {
+		return nil, false, nil
+	})
+	require.Equal(t, CoalescerStats{Calls: callers + 1, Coalesced: callers - 1}, c.Stats())
+}
+
+func TestVerifyImageSignaturesCoalesced(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	digest, verifier := writeSignedTestImage(t, s, nil)
+
+	c := NewVerificationCoalescer()
+	co := &CheckOpts{
+		SigVerifier:   verifier,
+		IgnoreTlog:    true,
+		ClaimVerifier: SimpleClaimVerifier,
+		Coalescer:     c,
+	}
+	verified, _, err := VerifyImageSignatures(context.Background(), digest, co)
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+	require.Equal(t, CoalescerStats{Calls: 1}, c.Stats())
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+// LoadDeterministicPrivateKey is like LoadPrivateKey, but the returned
+// SignerVerifier signs the same message with the same signature: ECDSA
+// signatures use the nonces of RFC 6979, while Ed25519 and RSA PKCS #1 v1.5
+// signatures are deterministic already. RSA-PSS keys are rejected as their
+// signatures are randomized.
+func LoadDeterministicPrivateKey(key []byte, pass []byte) (signature.SignerVerifier, error) {
+	x509Encoded, err := decryptPrivateKeyPEM(key, pass)
+	if err != nil {
+		return nil, err
+	}
+	return loadDeterministicPKCS8PrivateKey(x509Encoded)
+}
+
+// LoadDeterministicAgePrivateKey is like LoadAgePrivateKey, but signs
+// deterministically, see LoadDeterministicPrivateKey.
+func LoadDeterministicAgePrivateKey(key []byte, identities []age.Identity) (signature.SignerVerifier, error) {
+	x509Encoded, err := decryptAgePrivateKey(key, identities)
+	if err != nil {
+		return nil, err
+	}
+	return loadDeterministicPKCS8PrivateKey(x509Encoded)
+}
+
+func loadDeterministicPKCS8PrivateKey(x509Encoded []byte) (signature.SignerVerifier, error) {
+	if _, _, ok, _ := parseRSAPSSPrivateKey(x509Encoded); ok {
+		return nil, errors.New("RSA-PSS signatures are randomized, use an ECDSA, Ed25519 or RSA PKCS #1 v1.5 key to sign deterministically")
+	}
+	sv, err := loadPKCS8PrivateKey(x509Encoded)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaSV, ok := sv.(*signature.ECDSASignerVerifier)
+	if !ok {
+		return sv, nil
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(x509Encoded)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	return &deterministicECDSASignerVerifier{ECDSASignerVerifier: ecdsaSV, priv: pk.(*ecdsa.PrivateKey)}, nil
+}
+
+// deterministicECDSASignerVerifier signs with the nonces of RFC 6979.
+type deterministicECDSASignerVerifier struct {
+	*signature.ECDSASignerVerifier
+	priv *ecdsa.PrivateKey
+}
+
+// SignMessage signs message with the nonce RFC 6979 derives from the key and
+// the digest of message.
+func (d *deterministicECDSASignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	digest, hashFunc, err := signature.ComputeDigestForSigning(message, crypto.SHA256,
+		[]crypto.Hash{crypto.SHA256, crypto.SHA512, crypto.SHA384, crypto.SHA224}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return signRFC6979(d.priv, digest, hashFunc)
+}
+
+// Sign implements crypto.Signer. The source of entropy is ignored.
+func (d *deterministicECDSASignerVerifier) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := crypto.SHA256
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	return signRFC6979(d.priv, digest, hashFunc)
+}
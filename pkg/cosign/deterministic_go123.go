@@ -0,0 +1,29 @@
+//go:build !go1.24
+
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+)
+
+// signRFC6979 is unavailable before Go 1.24, whose ecdsa package first
+// derives the nonces of RFC 6979.
+func signRFC6979(*ecdsa.PrivateKey, []byte, crypto.Hash) ([]byte, error) {
+	return nil, errors.New("deterministic ECDSA signatures require cosign to be built with Go 1.24 or later")
+}
@@ -0,0 +1,28 @@
+//go:build go1.24
+
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+)
+
+// signRFC6979 signs digest with the nonce of RFC 6979, which ecdsa derives
+// when no source of entropy is given.
+func signRFC6979(priv *ecdsa.PrivateKey, digest []byte, hashFunc crypto.Hash) ([]byte, error) {
+	return priv.Sign(nil, digest, hashFunc)
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+func TestLoadDeterministicPrivateKey(t *testing.T) {
+	pf := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	for _, opts := range []KeyPairOptions{
+		{KeyType: KeyTypeECDSAP256},
+		{KeyType: KeyTypeED25519},
+		{KeyType: KeyTypeRSA3072, RSAScheme: RSASchemePKCS1v15},
+	} {
+		t.Run(opts.KeyType, func(t *testing.T) {
+			if opts.KeyType == KeyTypeECDSAP256 && !rfc6979 {
+				t.Skip("deterministic ECDSA signatures require Go 1.24")
+			}
+			keys, err := GenerateKeyPairWithOptions(opts, pf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sv, err := LoadDeterministicPrivateKey(keys.PrivateBytes, []byte("hunter2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertDeterministic(t, sv)
+		})
+	}
+
+	keys, err := GenerateKeyPairWithOptions(KeyPairOptions{KeyType: KeyTypeRSA3072, RSAScheme: RSASchemePSS}, pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDeterministicPrivateKey(keys.PrivateBytes, []byte("hunter2")); err == nil {
+		t.Error("LoadDeterministicPrivateKey() of an RSA-PSS key succeeded, want error")
+	}
+}
+
+func TestLoadDeterministicAgePrivateKey(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := GenerateKeyPairAge(KeyPairOptions{KeyType: KeyTypeED25519}, []age.Recipient{identity.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := LoadDeterministicAgePrivateKey(keys.PrivateBytes, []age.Identity{identity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeterministic(t, sv)
+}
+
+func assertDeterministic(t *testing.T, sv signature.SignerVerifier) {
+	t.Helper()
+	message := []byte("payload")
+	sig, err := sv.SignMessage(bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := sv.SignMessage(bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, again) {
+		t.Error("signing the same message twice gave different signatures")
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)); err != nil {
+		t.Errorf("verifying the deterministic signature: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package embedded reads and writes sigstore bundles embedded in a section of
+// an ELF, Mach-O or PE executable.
+//
+// The section must be reserved, zero filled, when the executable is built.
+// The executable is signed as built, e.g. with cosign sign-blob --bundle, and
+// the bundle is then written into the section, padded with zeros. Content
+// returns the executable with the section zeroed again, which is what the
+// bundle was made for, so embedding a bundle does not invalidate it.
+package embedded
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// ELFSection is the name of the section holding the bundle in ELF files.
+	ELFSection = ".sigstore"
+	// MachOSection is the name of the section holding the bundle in Mach-O
+	// files, in any segment.
+	MachOSection = "__sigstore"
+	// PESection is the name of the section holding the bundle in PE files,
+	// whose section names are limited to 8 bytes.
+	PESection = ".sigstor"
+)
+
+// ErrNoBundle is returned by ReadBundle if the section holds no bundle.
+var ErrNoBundle = errors.New("no bundle embedded in the section")
+
+// Section locates the section reserved for the bundle in an executable.
+type Section struct {
+	// Format is "elf", "macho" or "pe".
+	Format string
+	// Offset is where the section starts in the file.
+	Offset int64
+	// Size is the size of the section in the file.
+	Size int64
+}
+
+// FindSection returns the section reserved for the bundle in the executable
+// r, or an error if r is not an ELF, Mach-O or PE file or lacks the section.
+func FindSection(r io.ReaderAt) (*Section, error) {
+	if f, err := elf.NewFile(r); err == nil {
+		defer f.Close()
+		s := f.Section(ELFSection)
+		if s == nil || s.Type == elf.SHT_NOBITS {
+			return nil, fmt.Errorf("no %s section in the ELF file", ELFSection)
+		}
+		return &Section{Format: "elf", Offset: int64(s.Offset), Size: int64(s.FileSize)}, nil
+	}
+	if f, err := macho.NewFile(r); err == nil {
+		defer f.Close()
+		for _, s := range f.Sections {
+			if s.Name == MachOSection {
+				return &Section{Format: "macho", Offset: int64(s.Offset), Size: int64(s.Size)}, nil
+			}
+		}
+		return nil, fmt.Errorf("no %s section in the Mach-O file", MachOSection)
+	}
+	if f, err := pe.NewFile(r); err == nil {
+		defer f.Close()
+		s := f.Section(PESection)
+		if s == nil {
+			return nil, fmt.Errorf("no %s section in the PE file", PESection)
+		}
+		return &Section{Format: "pe", Offset: int64(s.Offset), Size: int64(s.Size)}, nil
+	}
+	return nil, errors.New("not an ELF, Mach-O or PE file")
+}
+
+// ReadBundle returns the bundle embedded in the executable at path.
+func ReadBundle(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := FindSection(f)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, s.Size)
+	if _, err := f.ReadAt(b, s.Offset); err != nil {
+		return nil, fmt.Errorf("reading %s section: %w", s.Format, err)
+	}
+	b = bytes.TrimRight(b, "\x00")
+	if len(b) == 0 {
+		return nil, ErrNoBundle
+	}
+	return b, nil
+}
+
+// WriteBundle embeds bundle in the executable at path, replacing any bundle
+// embedded before. It fails if bundle does not fit in the section.
+func WriteBundle(path string, bundle []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s, err := FindSection(f)
+	if err != nil {
+		return err
+	}
+	if int64(len(bundle)) > s.Size {
+		return fmt.Errorf("bundle of %d bytes does not fit in the %d bytes of the %s section", len(bundle), s.Size, s.Format)
+	}
+	b := make([]byte, s.Size)
+	copy(b, bundle)
+	if _, err := f.WriteAt(b, s.Offset); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Content returns the executable r of size bytes with the section s zeroed,
+// which is the content the embedded bundle signs.
+func Content(r io.ReaderAt, size int64, s *Section) io.Reader {
+	end := s.Offset + s.Size
+	return io.MultiReader(
+		io.NewSectionReader(r, 0, s.Offset),
+		io.LimitReader(zeros{}, s.Size),
+		io.NewSectionReader(r, end, size-end),
+	)
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeELF writes a minimal ELF file with a zero filled .sigstore section of
+// size bytes and returns its path.
+func writeELF(t *testing.T, size int) string {
+	t.Helper()
+	const ehsize, shentsize = 64, 64
+	shstrtab := []byte("\x00" + ELFSection + "\x00.shstrtab\x00")
+	dataOff := uint64(ehsize)
+	strOff := dataOff + uint64(size)
+	shOff := (strOff + uint64(len(shstrtab)) + 7) &^ 7
+
+	var b bytes.Buffer
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     shOff,
+		Ehsize:    ehsize,
+		Shentsize: shentsize,
+		Shnum:     3,
+		Shstrndx:  2,
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	sections := []elf.Section64{{}, {
+		Name:      1,
+		Type:      uint32(elf.SHT_PROGBITS),
+		Off:       dataOff,
+		Size:      uint64(size),
+		Addralign: 1,
+	}, {
+		Name:      uint32(len(ELFSection) + 2),
+		Type:      uint32(elf.SHT_STRTAB),
+		Off:       strOff,
+		Size:      uint64(len(shstrtab)),
+		Addralign: 1,
+	}}
+	if err := binary.Write(&b, binary.LittleEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+	b.Write(make([]byte, size))
+	b.Write(shstrtab)
+	b.Write(make([]byte, shOff-uint64(b.Len())))
+	if err := binary.Write(&b, binary.LittleEndian, sections); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, b.Bytes(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteAndReadBundle(t *testing.T) {
+	path := writeELF(t, 256)
+	signed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadBundle(path); !errors.Is(err, ErrNoBundle) {
+		t.Fatalf("ReadBundle() = %v, wanted ErrNoBundle", err)
+	}
+
+	for _, bundle := range []string{strings.Repeat("a", 200), `{"mediaType":"application/vnd.dev.sigstore.bundle+json;version=0.3"}`} {
+		if err := WriteBundle(path, []byte(bundle)); err != nil {
+			t.Fatalf("WriteBundle() = %v", err)
+		}
+		got, err := ReadBundle(path)
+		if err != nil {
+			t.Fatalf("ReadBundle() = %v", err)
+		}
+		// A shorter bundle replaces a longer one completely.
+		if string(got) != bundle {
+			t.Errorf("ReadBundle() = %q, wanted %q", got, bundle)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := FindSection(f)
+	if err != nil {
+		t.Fatalf("FindSection() = %v", err)
+	}
+	if s.Format != "elf" || s.Offset != 64 || s.Size != 256 {
+		t.Errorf("FindSection() = %+v, wanted the 256 bytes at 64 of an elf file", s)
+	}
+	content, err := io.ReadAll(Content(f, fi.Size(), s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, signed) {
+		t.Error("Content() is not the executable as it was signed")
+	}
+}
+
+func TestWriteBundleTooLarge(t *testing.T) {
+	path := writeELF(t, 16)
+	if err := WriteBundle(path, make([]byte, 17)); err == nil {
+		t.Error("WriteBundle() succeeded for a bundle larger than the section")
+	}
+}
+
+func TestFindSectionErrors(t *testing.T) {
+	if _, err := FindSection(strings.NewReader("not an executable")); err == nil {
+		t.Error("FindSection() succeeded for a file that is not an executable")
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := FindSection(f); err == nil {
+		t.Error("FindSection() succeeded for an executable without the section")
+	}
+}
@@ -47,11 +47,21 @@ const (
 	VariableExperimental            Variable = "COSIGN_EXPERIMENTAL"
 	VariableDockerMediaTypes        Variable = "COSIGN_DOCKER_MEDIA_TYPES"
 	VariablePassword                Variable = "COSIGN_PASSWORD"
+	VariableAgeIdentityFile         Variable = "COSIGN_AGE_IDENTITY_FILE"
+	VariablePKCS12Password          Variable = "COSIGN_PKCS12_PASSWORD"
 	VariablePKCS11Pin               Variable = "COSIGN_PKCS11_PIN"
 	VariablePKCS11ModulePath        Variable = "COSIGN_PKCS11_MODULE_PATH"
 	VariablePKCS11IgnoreCertificate Variable = "COSIGN_PKCS11_IGNORE_CERTIFICATE"
+	VariablePIVSkipAttestation      Variable = "COSIGN_PIV_SKIP_ATTESTATION"
+	VariablePIVManagementKey        Variable = "COSIGN_PIV_MANAGEMENT_KEY"
+	VariableSPIFFEID                Variable = "COSIGN_SPIFFE_ID"
 	VariableRepository              Variable = "COSIGN_REPOSITORY"
+	VariableRepositoryConfig        Variable = "COSIGN_REPOSITORY_CONFIG"
 	VariableMaxAttachmentSize       Variable = "COSIGN_MAX_ATTACHMENT_SIZE"
+	VariableMaxSignatureLayers      Variable = "COSIGN_MAX_SIGNATURE_LAYERS"
+	VariableKMSCacheTTL             Variable = "COSIGN_KMS_CACHE_TTL"
+	VariableKMSCacheDir             Variable = "COSIGN_KMS_CACHE_DIR"
+	VariableStepCARoot              Variable = "COSIGN_STEP_CA_ROOT"
 
 	// Sigstore environment variables
 	VariableSigstoreCTLogPublicKeyFile Variable = "SIGSTORE_CT_LOG_PUBLIC_KEY_FILE"
@@ -91,6 +101,16 @@ var (
 			Expects:     "string with a password (asks on stdin by default)",
 			Sensitive:   true,
 		},
+		VariableAgeIdentityFile: {
+			Description: "path to an age identity file used to decrypt private keys encrypted with age",
+			Expects:     "path to an identity file as written by age-keygen",
+			Sensitive:   false,
+		},
+		VariablePKCS12Password: {
+			Description: "password of PKCS #12 bundles imported with import-key-pair",
+			Expects:     "string with a password (asks on the terminal by default)",
+			Sensitive:   true,
+		},
 		VariablePKCS11Pin: {
 			Description: "to be used if PKCS11 PIN is not provided",
 			Expects:     "string with a PIN",
@@ -106,16 +126,56 @@ var (
 			Expects:     "1 if loading certificates should be disabled (0 by default)",
 			Sensitive:   false,
 		},
+		VariablePIVSkipAttestation: {
+			Description: "uses PIV keys without YubiKey attestation, reading their public keys from slot certificates",
+			Expects:     "1 if attestation should be skipped for non-Yubico PIV devices (0 by default)",
+			Sensitive:   false,
+		},
+		VariablePIVManagementKey: {
+			Description: "management key of the PIV device that keyless signing generates keys in",
+			Expects:     "string with the management key, up to 24 characters (the default management key by default)",
+			Sensitive:   true,
+		},
+		VariableSPIFFEID: {
+			Description: "selects the SPIFFE ID of the JWT-SVID to sign with when a workload is entitled to several",
+			Expects:     "string with a SPIFFE ID, such as spiffe://example.org/ns/default/sa/builder",
+			Sensitive:   false,
+		},
 		VariableRepository: {
 			Description: "can be used to store signatures in an alternate location",
 			Expects:     "string with a repository",
 			Sensitive:   false,
 		},
+		VariableRepositoryConfig: {
+			Description: "maps image repositories to the repositories their signatures are stored in, overridden by COSIGN_REPOSITORY",
+			Expects:     "path to a JSON file with a list of source patterns and target repositories",
+			Sensitive:   false,
+		},
 		VariableMaxAttachmentSize: {
 			Description: "maximum attachment size to download (default 128MiB)",
 			Expects:     "human-readable unit of memory, e.g. 5120, 20K, 3M, 45MiB, 1GB",
 			Sensitive:   false,
 		},
+		VariableMaxSignatureLayers: {
+			Description: "maximum number of signature or attestation layers to process (default 1000)",
+			Expects:     "positive integer",
+			Sensitive:   false,
+		},
+		VariableKMSCacheTTL: {
+			Description: "how long the public keys of KMS keys are cached to verify signatures with before they are fetched again (default 10m), 0 disables caching",
+			Expects:     "duration, e.g. 30s, 10m, 1h",
+			Sensitive:   false,
+		},
+		VariableKMSCacheDir: {
+			Description: "directory to also cache the public keys of KMS keys in, so that they are shared between cosign processes",
+			Expects:     "string with a directory path",
+			Sensitive:   false,
+		},
+		VariableStepCARoot: {
+			Description: "root certificate of the step-ca server that a step-ca:// certificate provider connects to, also added to the certificate chain of signatures",
+			Expects:     "path to a PEM-encoded root certificate, as step ca root writes",
+			Sensitive:   false,
+		},
 
 		VariableSigstoreCTLogPublicKeyFile: {
 			Description: "overrides what is used to validate the SCT coming back from Fulcio",
@@ -131,7 +191,7 @@ var (
 		},
 		VariableSigstoreRekorPublicKey: {
 			Description: "if specified, you can specify an oob Public Key that Rekor uses",
-			Expects:     "path to the public key",
+			Expects:     "path to the public key, or a list of paths separated by the OS path list separator",
 			Sensitive:   false,
 			External:    true,
 		},
@@ -167,7 +227,7 @@ var (
 		},
 		VariableSPIFFEEndpointSocket: {
 			Description: "allows you to specify non-default SPIFFE socket to use.",
-			Expects:     "string with SPIFFE socket path",
+			Expects:     "string with SPIFFE socket path, or a unix:// or tcp:// Workload API address",
 			Sensitive:   false,
 			External:    true,
 		},
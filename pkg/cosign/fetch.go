@@ -29,6 +29,7 @@ import (
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/rekor/pkg/generated/models"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"golang.org/x/sync/errgroup"
@@ -49,6 +50,12 @@ type LocalSignedPayload struct {
 	Base64Signature string              `json:"base64Signature"`
 	Cert            string              `json:"cert,omitempty"`
 	Bundle          *bundle.RekorBundle `json:"rekorBundle,omitempty"`
+	// CertChain is the base64 encoded PEM chain of the certificate, without
+	// which intermediates cannot be verified offline.
+	CertChain string `json:"certChain,omitempty"`
+	// InclusionProof proves the inclusion of the entry of Bundle in the log.
+	InclusionProof   *models.InclusionProof   `json:"inclusionProof,omitempty"`
+	RFC3161Timestamp *bundle.RFC3161Timestamp `json:"rfc3161Timestamp,omitempty"`
 }
 
 type Signatures struct {
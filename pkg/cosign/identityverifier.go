@@ -0,0 +1,37 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import "crypto/x509"
+
+// IdentityVerifier decides whether the identity a certificate was issued to
+// may sign, for identity schemes that Identities cannot express, see
+// CheckOpts.IdentityVerifier.
+type IdentityVerifier interface {
+	// VerifyIdentity returns whether cert is allowed, along with the reason
+	// for the decision. ext reads the Fulcio extensions of cert. The reason
+	// is reported in the verification failure when cert is denied.
+	VerifyIdentity(cert *x509.Certificate, ext CertExtensions) (allow bool, reason string)
+}
+
+// IdentityVerifierFunc adapts a function to an IdentityVerifier.
+type IdentityVerifierFunc func(cert *x509.Certificate, ext CertExtensions) (allow bool, reason string)
+
+var _ IdentityVerifier = IdentityVerifierFunc(nil)
+
+// VerifyIdentity implements IdentityVerifier
+func (f IdentityVerifierFunc) VerifyIdentity(cert *x509.Certificate, ext CertExtensions) (bool, string) {
+	return f(cert, ext)
+}
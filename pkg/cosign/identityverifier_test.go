@@ -0,0 +1,95 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckCertificatePolicyIdentityVerifier(t *testing.T) {
+	issuer := "https://issuer.example.com"
+	cert := generateLeafCertWithSANs(t, issuer, uriGeneralName("https://example.com/org/repo"))
+
+	// Only allow certificates issued by issuer to an identity of the org.
+	verifier := IdentityVerifierFunc(func(cert *x509.Certificate, ext CertExtensions) (bool, string) {
+		if ext.GetIssuer() != issuer {
+			return false, "unexpected issuer " + ext.GetIssuer()
+		}
+		for _, uri := range cert.URIs {
+			if strings.HasPrefix(uri.Path, "/org/") {
+				return true, "member of org"
+			}
+		}
+		return false, "not a member of org"
+	})
+
+	tests := []struct {
+		name       string
+		identities []Identity
+		verifier   IdentityVerifier
+		wantErr    string
+		wantTrace  bool
+	}{{
+		name:     "allowed",
+		verifier: verifier,
+	}, {
+		name:       "allowed and traced",
+		identities: []Identity{{Issuer: issuer}},
+		verifier:   verifier,
+		wantTrace:  true,
+	}, {
+		name: "denied",
+		verifier: IdentityVerifierFunc(func(*x509.Certificate, CertExtensions) (bool, string) {
+			return false, "not on the allowlist"
+		}),
+		identities: []Identity{{Issuer: issuer}},
+		wantErr:    "certificate identity rejected: not on the allowlist",
+	}, {
+		name:       "identities checked first",
+		identities: []Identity{{Issuer: "https://other.example.com"}},
+		verifier: IdentityVerifierFunc(func(*x509.Certificate, CertExtensions) (bool, string) {
+			t.Error("IdentityVerifier consulted for a certificate failing Identities")
+			return true, ""
+		}),
+		wantErr: "none of the expected identities matched",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			traced := false
+			co := &CheckOpts{
+				Identities:         tc.identities,
+				IdentityVerifier:   tc.verifier,
+				IdentityMatchTrace: func(IdentityMatch) { traced = true },
+			}
+			err := CheckCertificatePolicy(cert, co)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckCertificatePolicy() = %v", err)
+				}
+			} else {
+				var vf *VerificationFailure
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) || !errors.As(err, &vf) {
+					t.Fatalf("CheckCertificatePolicy() = %v, wanted a verification failure containing %q", err, tc.wantErr)
+				}
+			}
+			if traced != tc.wantTrace {
+				t.Errorf("IdentityMatchTrace called: %v, wanted %v", traced, tc.wantTrace)
+			}
+		})
+	}
+}
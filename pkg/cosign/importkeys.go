@@ -0,0 +1,158 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/go-jose/go-jose/v4"
+)
+
+// ImportOptions configure how ImportKeyPairWithOptions and ImportKeyPairAge
+// read the imported private key.
+type ImportOptions struct {
+	// BundlePassFunc provides the password of PKCS #12 bundles. If nil,
+	// bundles are opened with an empty password.
+	BundlePassFunc PassFunc
+	// KeyID selects the key of a JWK set by its "kid" parameter. It may be
+	// empty for sets holding a single private key.
+	KeyID string
+}
+
+// importedKey is a private key read by readImportedKey.
+type importedKey struct {
+	// ptype is the PEM type the key was read from, if any
+	ptype string
+	key   crypto.Signer
+	// certs are the certificate of key followed by its chain
+	certs []*x509.Certificate
+}
+
+func (k *importedKey) keys() Keys {
+	return Keys{private: k.key, public: k.key.Public(), certificates: k.certs}
+}
+
+// marshalCertificates returns the PEM encoding of the certificate of keypair
+// and of the rest of its chain, which are empty if it has none.
+func (keypair Keys) marshalCertificates() (cert, chain []byte, err error) {
+	if len(keypair.certificates) == 0 {
+		return nil, nil, nil
+	}
+	cert, err = cryptoutils.MarshalCertificatesToPEM(keypair.certificates[:1])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(keypair.certificates) > 1 {
+		if chain, err = cryptoutils.MarshalCertificatesToPEM(keypair.certificates[1:]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cert, chain, nil
+}
+
+// isJWK reports whether kb looks like a JSON document, and so a JWK or JWK set.
+func isJWK(kb []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(kb), []byte("{"))
+}
+
+// isPKCS12 reports whether the file at keyPath holding kb is a PKCS #12 bundle,
+// either by its extension or as DER rather than PEM.
+func isPKCS12(keyPath string, kb []byte) bool {
+	switch strings.ToLower(filepath.Ext(keyPath)) {
+	case ".p12", ".pfx":
+		return true
+	}
+	return len(kb) > 0 && kb[0] == 0x30 // DER SEQUENCE
+}
+
+// readImportedPKCS12 reads the private key and certificates of the PKCS #12
+// bundle kb, opened with the password provided by pf.
+func readImportedPKCS12(kb []byte, pf PassFunc) (*importedKey, error) {
+	var password []byte
+	if pf != nil {
+		var err error
+		if password, err = pf(false); err != nil {
+			return nil, err
+		}
+	}
+	pk, certs, err := decodePKCS12(kb, string(password))
+	if err != nil {
+		return nil, err
+	}
+	return newImportedKey(pk, certs)
+}
+
+// readImportedJWK reads the private key of the JWK, or JWK set, kb. Keys of a
+// set are selected by keyID, which may be empty if it holds one private key.
+func readImportedJWK(kb []byte, keyID string) (*importedKey, error) {
+	var probe struct {
+		Keys json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(kb, &probe); err != nil {
+		return nil, fmt.Errorf("error parsing jwk: %w", err)
+	}
+	var set jose.JSONWebKeySet
+	if probe.Keys != nil {
+		if err := json.Unmarshal(kb, &set); err != nil {
+			return nil, fmt.Errorf("error parsing jwk set: %w", err)
+		}
+	} else {
+		var jwk jose.JSONWebKey
+		if err := json.Unmarshal(kb, &jwk); err != nil {
+			return nil, fmt.Errorf("error parsing jwk: %w", err)
+		}
+		set.Keys = []jose.JSONWebKey{jwk}
+	}
+
+	var found *jose.JSONWebKey
+	for i, k := range set.Keys {
+		if k.IsPublic() || (keyID != "" && k.KeyID != keyID) {
+			continue
+		}
+		if found != nil {
+			return nil, errors.New("jwk set holds more than one private key, select one by its key id")
+		}
+		found = &set.Keys[i]
+	}
+	if found == nil {
+		if keyID != "" {
+			return nil, fmt.Errorf("no private key with key id %q in jwk", keyID)
+		}
+		return nil, errors.New("no private key in jwk")
+	}
+	if !found.Valid() {
+		return nil, errors.New("invalid jwk")
+	}
+	return newImportedKey(found.Key, found.Certificates)
+}
+
+func newImportedKey(pk crypto.PrivateKey, certs []*x509.Certificate) (*importedKey, error) {
+	signer, err := validateImportedKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	if certs, err = orderCertificates(signer.Public(), certs); err != nil {
+		return nil, err
+	}
+	return &importedKey{key: signer, certs: certs}, nil
+}
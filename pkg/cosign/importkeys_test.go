@@ -0,0 +1,152 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportKeyPairPKCS12(t *testing.T) {
+	leaf, err := os.ReadFile("testdata/pkcs12/leaf.crt")
+	require.NoError(t, err)
+	ca, err := os.ReadFile("testdata/pkcs12/ca.crt")
+	require.NoError(t, err)
+	ed, err := os.ReadFile("testdata/pkcs12/ed.crt")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		file      string
+		password  PassFunc
+		wantCert  []byte
+		wantChain []byte
+		wantErr   error
+	}{{
+		name:      "pbes2",
+		file:      "ecdsa.p12",
+		password:  pass("hello"),
+		wantCert:  leaf,
+		wantChain: ca,
+	}, {
+		name:      "legacy",
+		file:      "legacy.p12",
+		password:  pass("hello"),
+		wantCert:  leaf,
+		wantChain: ca,
+	}, {
+		name:     "ed25519 without password",
+		file:     "ed25519.pfx",
+		wantCert: ed,
+	}, {
+		name:     "pbes2 wrong password",
+		file:     "ecdsa.p12",
+		password: pass("nope"),
+		wantErr:  ErrIncorrectBundlePassword,
+	}, {
+		name:     "legacy wrong password",
+		file:     "legacy.p12",
+		password: pass("nope"),
+		wantErr:  ErrIncorrectBundlePassword,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := ImportKeyPairWithOptions(filepath.Join("testdata/pkcs12", tc.file), ImportOptions{BundlePassFunc: tc.password}, pass("cosign"))
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, string(tc.wantCert), string(keys.CertificateBytes))
+			require.Equal(t, string(tc.wantChain), string(keys.CertificateChainBytes))
+			_, err = LoadPrivateKey(keys.PrivateBytes, []byte("cosign"))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestImportKeyPairJWK(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jwk signer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	td := t.TempDir()
+	write := func(name string, v any) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		f := filepath.Join(td, name)
+		require.NoError(t, os.WriteFile(f, b, 0600))
+		return f
+	}
+	jwk := write("key.jwk", jose.JSONWebKey{Key: priv, KeyID: "a", Certificates: []*x509.Certificate{cert}})
+	set := write("keys.json", jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: priv, KeyID: "a"},
+		{Key: other, KeyID: "b"},
+		{Key: other.Public(), KeyID: "c"},
+	}})
+	public := write("public.jwk", jose.JSONWebKey{Key: priv.Public(), KeyID: "a"})
+
+	certPEM, err := cryptoutils.MarshalCertificateToPEM(cert)
+	require.NoError(t, err)
+	keys, err := ImportKeyPair(jwk, pass("cosign"))
+	require.NoError(t, err)
+	require.Equal(t, string(certPEM), string(keys.CertificateBytes))
+	require.Empty(t, keys.CertificateChainBytes)
+	sv, err := LoadPrivateKey(keys.PrivateBytes, []byte("cosign"))
+	require.NoError(t, err)
+	pub, err := sv.PublicKey()
+	require.NoError(t, err)
+	require.True(t, priv.PublicKey.Equal(pub))
+
+	_, err = ImportKeyPair(set, pass("cosign"))
+	require.ErrorContains(t, err, "more than one private key")
+
+	keys, err = ImportKeyPairWithOptions(set, ImportOptions{KeyID: "b"}, pass("cosign"))
+	require.NoError(t, err)
+	sv, err = LoadPrivateKey(keys.PrivateBytes, []byte("cosign"))
+	require.NoError(t, err)
+	pub, err = sv.PublicKey()
+	require.NoError(t, err)
+	require.True(t, other.PublicKey.Equal(pub))
+
+	_, err = ImportKeyPairWithOptions(set, ImportOptions{KeyID: "c"}, pass("cosign"))
+	require.EqualError(t, err, `no private key with key id "c" in jwk`)
+	_, err = ImportKeyPair(public, pass("cosign"))
+	require.EqualError(t, err, "no private key in jwk")
+}
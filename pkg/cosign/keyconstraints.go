@@ -0,0 +1,151 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// KeyConstraintsSuffix is appended to the path of a public key file to find
+// the sidecar file declaring its KeyConstraints.
+const KeyConstraintsSuffix = ".constraints.json"
+
+// KeyConstraints restricts what a public key may be used to verify. They are
+// distributed next to the key so that every verifier enforces them without
+// having to pass matching flags.
+type KeyConstraints struct {
+	// Repositories holds patterns, in the syntax of path.Match, that the
+	// repository of a verified image must match, e.g. "ghcr.io/org/*".
+	// Empty allows any repository.
+	Repositories []string `json:"repositories,omitempty"`
+	// PredicateTypes holds the predicate types of the attestations the key
+	// may verify. Empty allows any predicate type.
+	PredicateTypes []string `json:"predicateTypes,omitempty"`
+	// Expires is when the key stops being valid. Signatures made after it,
+	// according to their verified timestamps or else the current time, are
+	// rejected.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// LoadKeyConstraints reads the KeyConstraints declared for the public key
+// file at keyPath. It returns nil if there is no sidecar file, which is
+// always the case for keys that are not files, such as KMS URIs.
+func LoadKeyConstraints(keyPath string) (*KeyConstraints, error) {
+	b, err := os.ReadFile(keyPath + KeyConstraintsSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	kc := &KeyConstraints{}
+	if err := json.Unmarshal(b, kc); err != nil {
+		return nil, fmt.Errorf("parsing key constraints %s: %w", keyPath+KeyConstraintsSuffix, err)
+	}
+	return kc, nil
+}
+
+// checkRepository fails if the key may not verify images in the repository
+// of ref.
+func (kc *KeyConstraints) checkRepository(ref name.Reference) error {
+	if kc == nil || len(kc.Repositories) == 0 {
+		return nil
+	}
+	repo := ref.Context().Name()
+	for _, pattern := range kc.Repositories {
+		if ok, err := path.Match(pattern, repo); err != nil {
+			return fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		} else if ok {
+			return nil
+		}
+	}
+	return &VerificationFailure{
+		fmt.Errorf("key is not allowed to verify images in %s", repo),
+	}
+}
+
+// checkExpiry fails if the key had expired when sig was made, according to
+// its verified timestamps, or now if it has none.
+func (kc *KeyConstraints) checkExpiry(timestamps VerifiedTimestamps) error {
+	if kc == nil || kc.Expires == nil {
+		return nil
+	}
+	t := time.Now()
+	switch {
+	case timestamps.RFC3161Time != nil:
+		t = *timestamps.RFC3161Time
+	case timestamps.IntegratedTime != nil:
+		t = *timestamps.IntegratedTime
+	}
+	if t.After(*kc.Expires) {
+		return &VerificationFailure{
+			fmt.Errorf("key expired at %s, before the signature time %s",
+				kc.Expires.Format(time.RFC3339), t.Format(time.RFC3339)),
+		}
+	}
+	return nil
+}
+
+// checkPredicateType fails if the key may not verify attestations with the
+// predicate type of att.
+func (kc *KeyConstraints) checkPredicateType(att oci.Signature) error {
+	if kc == nil || len(kc.PredicateTypes) == 0 {
+		return nil
+	}
+	st, err := statementHeader(att)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(kc.PredicateTypes, st.PredicateType) {
+		return &VerificationFailure{
+			fmt.Errorf("key is not allowed to verify attestations with predicate type %s", st.PredicateType),
+		}
+	}
+	return nil
+}
+
+// statementHeader decodes the header of the in-toto statement in the DSSE
+// envelope of att.
+func statementHeader(att oci.Signature) (in_toto.StatementHeader, error) {
+	st := in_toto.StatementHeader{}
+	p, err := att.Payload()
+	if err != nil {
+		return st, err
+	}
+	e := dsse.Envelope{}
+	if err := json.Unmarshal(p, &e); err != nil {
+		return st, err
+	}
+	stBytes, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(stBytes, &st); err != nil {
+		return st, err
+	}
+	return st, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+func TestLoadKeyConstraints(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "cosign.pub")
+
+	kc, err := LoadKeyConstraints(keyPath)
+	require.NoError(t, err)
+	require.Nil(t, kc)
+
+	require.NoError(t, os.WriteFile(keyPath+KeyConstraintsSuffix, []byte(`{
+		"repositories": ["ghcr.io/org/*"],
+		"predicateTypes": ["https://slsa.dev/provenance/v1"],
+		"expires": "2030-01-01T00:00:00Z"
+	}`), 0o600))
+	kc, err = LoadKeyConstraints(keyPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ghcr.io/org/*"}, kc.Repositories)
+	require.Equal(t, []string{"https://slsa.dev/provenance/v1"}, kc.PredicateTypes)
+	require.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), kc.Expires.UTC())
+
+	require.NoError(t, os.WriteFile(keyPath+KeyConstraintsSuffix, []byte(`not json`), 0o600))
+	_, err = LoadKeyConstraints(keyPath)
+	require.Error(t, err)
+}
+
+func TestKeyConstraintsExpiry(t *testing.T) {
+	expires := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	kc := &KeyConstraints{Expires: &expires}
+
+	before := expires.Add(-time.Hour)
+	after := expires.Add(time.Hour)
+	require.NoError(t, kc.checkExpiry(VerifiedTimestamps{IntegratedTime: &before}))
+	require.NoError(t, kc.checkExpiry(VerifiedTimestamps{RFC3161Time: &before, IntegratedTime: &after}))
+	require.Error(t, kc.checkExpiry(VerifiedTimestamps{IntegratedTime: &after}))
+	// Without timestamps the key is checked against the current time.
+	require.Error(t, kc.checkExpiry(VerifiedTimestamps{}))
+
+	var none *KeyConstraints
+	require.NoError(t, none.checkExpiry(VerifiedTimestamps{}))
+}
+
+func TestKeyConstraintsPredicateType(t *testing.T) {
+	statement := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://cosign.sigstore.dev/attestation/v1","subject":[]}`
+	envelope := `{"payloadType":"application/vnd.in-toto+json","payload":"` + base64.StdEncoding.EncodeToString([]byte(statement)) + `","signatures":[]}`
+	att, err := static.NewAttestation([]byte(envelope))
+	require.NoError(t, err)
+
+	kc := &KeyConstraints{PredicateTypes: []string{"https://cosign.sigstore.dev/attestation/v1"}}
+	require.NoError(t, kc.checkPredicateType(att))
+
+	kc.PredicateTypes = []string{"https://slsa.dev/provenance/v1"}
+	require.Error(t, kc.checkPredicateType(att))
+}
+
+func TestVerifyImageSignaturesKeyConstraints(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	digest, verifier := writeSignedTestImage(t, s, nil)
+
+	co := &CheckOpts{
+		SigVerifier:   verifier,
+		IgnoreTlog:    true,
+		ClaimVerifier: SimpleClaimVerifier,
+		KeyConstraints: &KeyConstraints{
+			Repositories: []string{digest.Context().RegistryStr() + "/test/*"},
+		},
+	}
+	_, _, err := VerifyImageSignatures(context.Background(), digest, co)
+	require.NoError(t, err)
+
+	co.KeyConstraints.Repositories = []string{digest.Context().RegistryStr() + "/other/*"}
+	_, _, err = VerifyImageSignatures(context.Background(), digest, co)
+	require.ErrorContains(t, err, "not allowed to verify images")
+
+	expired := time.Now().Add(-time.Hour)
+	co.KeyConstraints = &KeyConstraints{Expires: &expired}
+	_, _, err = VerifyImageSignatures(context.Background(), digest, co)
+	require.Error(t, err)
+}
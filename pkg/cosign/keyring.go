@@ -0,0 +1,210 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+// KeyRing is a set of public keys, each optionally valid only within a time
+// window. A signature verifies against the key ring if any key that is
+// currently valid verifies it, so that a signer can rotate keys without
+// breaking verification of either old or new signatures.
+//
+// A key ring is read from a file of several PEM-encoded public keys, which
+// are always valid, or from a JSON manifest such as:
+//
+//	{
+//	  "keys": [
+//	    {"publicKey": "-----BEGIN PUBLIC KEY-----\n...", "notAfter": "2025-01-31T00:00:00Z"},
+//	    {"publicKey": "-----BEGIN PUBLIC KEY-----\n...", "notBefore": "2025-01-01T00:00:00Z"}
+//	  ]
+//	}
+type KeyRing struct {
+	keys []keyRingKey
+}
+
+type keyRingKey struct {
+	verifier  signature.Verifier
+	der       []byte
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+// keyRingManifest is the JSON form of a KeyRing.
+type keyRingManifest struct {
+	Keys []keyRingManifestKey `json:"keys"`
+}
+
+type keyRingManifestKey struct {
+	PublicKey string     `json:"publicKey"`
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+}
+
+var _ signature.Verifier = (*KeyRing)(nil)
+
+// IsKeyRing reports whether b holds a key ring rather than a single public
+// key: a JSON key ring manifest or more than one PEM block.
+func IsKeyRing(b []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return true
+	}
+	blocks := 0
+	for rest := b; ; {
+		var p *pem.Block
+		if p, rest = pem.Decode(rest); p == nil {
+			break
+		}
+		blocks++
+	}
+	return blocks > 1
+}
+
+// LoadKeyRing parses a key ring, see KeyRing. RSA and ECDSA keys verify
+// digests computed with hashFunc, as with LoadPublicKeyPEM.
+func LoadKeyRing(b []byte, hashFunc crypto.Hash) (*KeyRing, error) {
+	var manifest keyRingManifest
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("parsing key ring manifest: %w", err)
+		}
+	} else {
+		for rest := b; ; {
+			var p *pem.Block
+			if p, rest = pem.Decode(rest); p == nil {
+				break
+			}
+			manifest.Keys = append(manifest.Keys, keyRingManifestKey{PublicKey: string(pem.EncodeToMemory(p))})
+		}
+	}
+	if len(manifest.Keys) == 0 {
+		return nil, errors.New("key ring holds no keys")
+	}
+
+	r := &KeyRing{}
+	for i, k := range manifest.Keys {
+		v, err := LoadPublicKeyPEM([]byte(k.PublicKey), hashFunc)
+		if err != nil {
+			return nil, fmt.Errorf("key %d of key ring: %w", i, err)
+		}
+		pub, err := v.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d of key ring: %w", i, err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, fmt.Errorf("key %d of key ring: %w", i, err)
+		}
+		key := keyRingKey{verifier: v, der: der}
+		if k.NotBefore != nil {
+			key.notBefore = *k.NotBefore
+		}
+		if k.NotAfter != nil {
+			key.notAfter = *k.NotAfter
+		}
+		if !key.notBefore.IsZero() && !key.notAfter.IsZero() && !key.notBefore.Before(key.notAfter) {
+			return nil, fmt.Errorf("key %d of key ring: notBefore %s is not before notAfter %s", i, key.notBefore.Format(time.RFC3339), key.notAfter.Format(time.RFC3339))
+		}
+		r.keys = append(r.keys, key)
+	}
+	return r, nil
+}
+
+// Verifiers returns the verifiers of the keys valid at t, in the order of
+// the key ring.
+func (r *KeyRing) Verifiers(t time.Time) []signature.Verifier {
+	var out []signature.Verifier
+	for _, k := range r.keys {
+		if !k.notBefore.IsZero() && t.Before(k.notBefore) {
+			continue
+		}
+		if !k.notAfter.IsZero() && t.After(k.notAfter) {
+			continue
+		}
+		out = append(out, k.verifier)
+	}
+	return out
+}
+
+// PublicKey returns the public key of the first currently valid key.
+func (r *KeyRing) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	verifiers := r.Verifiers(time.Now())
+	if len(verifiers) == 0 {
+		return nil, errNoValidKeyRingKey
+	}
+	return verifiers[0].PublicKey(opts...)
+}
+
+// VerifySignature verifies the signature against every currently valid key,
+// succeeding if any of them verifies it.
+func (r *KeyRing) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	verifiers := r.Verifiers(time.Now())
+	if len(verifiers) == 0 {
+		return errNoValidKeyRingKey
+	}
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+	msg, err := io.ReadAll(message)
+	if err != nil {
+		return fmt.Errorf("reading message: %w", err)
+	}
+	var errs []error
+	for _, v := range verifiers {
+		err := v.VerifySignature(bytes.NewReader(sigBytes), bytes.NewReader(msg), opts...)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return keyRingError(errs)
+}
+
+// keyRingError reports that no key of a key ring verified a signature,
+// wrapping the error of each key.
+func keyRingError(errs []error) error {
+	return fmt.Errorf("no key of the key ring verifies the signature: %w", errors.Join(errs...))
+}
+
+// marshal returns a canonical encoding of the keys and validity windows of
+// the key ring.
+func (r *KeyRing) marshal() ([]byte, error) {
+	type key struct {
+		Key       []byte
+		NotBefore time.Time
+		NotAfter  time.Time
+	}
+	keys := make([]key, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, key{Key: k.der, NotBefore: k.notBefore.UTC(), NotAfter: k.notAfter.UTC()})
+	}
+	return json.Marshal(keys)
+}
+
+var errNoValidKeyRingKey = errors.New("no key of the key ring is currently valid")
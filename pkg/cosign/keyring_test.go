@@ -0,0 +1,191 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+func newKeyRingSigner(t *testing.T) (*signature.ECDSASignerVerifier, string) {
+	t.Helper()
+	sv, _, err := signature.NewECDSASignerVerifier(elliptic.P256(), rand.Reader, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sv, string(pemBytes)
+}
+
+func TestIsKeyRing(t *testing.T) {
+	_, one := newKeyRingSigner(t)
+	_, two := newKeyRingSigner(t)
+	if IsKeyRing([]byte(one)) {
+		t.Error("IsKeyRing() = true for a single public key")
+	}
+	if !IsKeyRing([]byte(one + two)) {
+		t.Error("IsKeyRing() = false for two public keys")
+	}
+	if !IsKeyRing([]byte(` {"keys": []}`)) {
+		t.Error("IsKeyRing() = false for a manifest")
+	}
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	oldSV, oldPEM := newKeyRingSigner(t)
+	newSV, newPEM := newKeyRingSigner(t)
+	_, expiredPEM := newKeyRingSigner(t)
+	payload := []byte("payload")
+	sign := func(sv signature.Signer) []byte {
+		sig, err := sv.SignMessage(bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	verify := func(r *KeyRing, sig []byte) error {
+		return r.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload))
+	}
+
+	// Several PEM blocks are always valid.
+	r, err := LoadKeyRing([]byte(oldPEM+newPEM), crypto.SHA256)
+	if err != nil {
+		t.Fatalf("LoadKeyRing() = %v", err)
+	}
+	for _, sv := range []signature.Signer{oldSV, newSV} {
+		if err := verify(r, sign(sv)); err != nil {
+			t.Errorf("VerifySignature() = %v", err)
+		}
+	}
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	manifest, err := json.Marshal(keyRingManifest{Keys: []keyRingManifestKey{
+		{PublicKey: oldPEM, NotAfter: &future},
+		{PublicKey: newPEM, NotBefore: &past},
+		{PublicKey: expiredPEM, NotAfter: &past},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = LoadKeyRing(manifest, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("LoadKeyRing() = %v", err)
+	}
+	if got := len(r.Verifiers(now)); got != 2 {
+		t.Errorf("Verifiers() returned %d keys, wanted 2", got)
+	}
+	if got := len(r.Verifiers(future.Add(time.Minute))); got != 1 {
+		t.Errorf("Verifiers() after rotation returned %d keys, wanted 1", got)
+	}
+	if err := verify(r, sign(newSV)); err != nil {
+		t.Errorf("VerifySignature() = %v", err)
+	}
+	pub, err := r.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey() = %v", err)
+	}
+	if err := cryptoutils.EqualKeys(pub, oldSV.Public()); err != nil {
+		t.Errorf("PublicKey() is not the first valid key: %v", err)
+	}
+
+	// Only the expired key verifies this signature.
+	_, unrelatedPEM := newKeyRingSigner(t)
+	expiredOnly, err := json.Marshal(keyRingManifest{Keys: []keyRingManifestKey{
+		{PublicKey: unrelatedPEM},
+		{PublicKey: oldPEM, NotAfter: &past},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = LoadKeyRing(expiredOnly, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("LoadKeyRing() = %v", err)
+	}
+	if err := verify(r, sign(oldSV)); err == nil {
+		t.Error("VerifySignature() succeeded with an expired key")
+	}
+
+	for _, bad := range []string{
+		`{"keys": []}`,
+		`{"keys": [{"publicKey": "not a key"}]}`,
+		`{"keys": [], "unknown": true}`,
+		`{"keys": [{"publicKey": ` + string(mustJSON(t, oldPEM)) + `, "notBefore": "2025-01-02T00:00:00Z", "notAfter": "2025-01-01T00:00:00Z"}]}`,
+	} {
+		if _, err := LoadKeyRing([]byte(bad), crypto.SHA256); err == nil {
+			t.Errorf("LoadKeyRing(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestVerifyImageSignatureWithKeyRing(t *testing.T) {
+	oldSV, oldPEM := newKeyRingSigner(t)
+	_, newPEM := newKeyRingSigner(t)
+	payload := []byte{1, 2, 3, 4}
+	sig, err := oldSV.SignMessage(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSig, err := static.NewSignature(payload, base64.StdEncoding.EncodeToString(sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key comes first, the signature is still verified by the old one.
+	r, err := LoadKeyRing([]byte(newPEM+oldPEM), crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyImageSignature(context.Background(), ociSig, v1.Hash{}, &CheckOpts{SigVerifier: r, IgnoreTlog: true}); err != nil {
+		t.Errorf("VerifyImageSignature() = %v", err)
+	}
+
+	r, err = LoadKeyRing([]byte(newPEM+newPEM), crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyImageSignature(context.Background(), ociSig, v1.Hash{}, &CheckOpts{SigVerifier: r, IgnoreTlog: true}); err == nil {
+		t.Error("VerifyImageSignature() succeeded without the signing key in the key ring")
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -29,11 +29,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/franchb/sigstore/pkg/signature"
-	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
 )
 
 const (
@@ -56,12 +56,22 @@ type PassFunc func(bool) ([]byte, error)
 type Keys struct {
 	private crypto.PrivateKey
 	public  crypto.PublicKey
+	// rsaPSS marks RSA keys that sign with RSA-PSS
+	rsaPSS bool
+	// certificates are the certificate of an imported key and its chain
+	certificates []*x509.Certificate
 }
 
 type KeysBytes struct {
 	PrivateBytes []byte
 	PublicBytes  []byte
-	password     []byte
+	// CertificateBytes is the PEM-encoded certificate of an imported key, if
+	// it was imported along with one.
+	CertificateBytes []byte
+	// CertificateChainBytes holds the PEM-encoded certificates of the chain
+	// of CertificateBytes found along with the imported key, if any.
+	CertificateChainBytes []byte
+	password              []byte
 }
 
 func (k *KeysBytes) Password() []byte {
@@ -73,18 +83,44 @@ func GeneratePrivateKey() (*ecdsa.PrivateKey, error) {
 	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 }
 
-// ImportKeyPair imports a key pair from a file containing a PEM-encoded
-// private key encoded with a password provided by the 'pf' function.
+// ImportKeyPair imports a key pair from a file containing a private key,
+// encrypting it with a password provided by the 'pf' function.
 // The private key can be in one of the following formats:
 // - RSA private key (PKCS #1)
 // - ECDSA private key
-// - PKCS #8 private key (RSA, ECDSA or ED25519).
+// - PKCS #8 private key (RSA, ECDSA or ED25519)
+// - PKCS #12 bundle with an empty password
+// - JWK, or JWK set holding a single private key.
 func ImportKeyPair(keyPath string, pf PassFunc) (*KeysBytes, error) {
+	return ImportKeyPairWithOptions(keyPath, ImportOptions{}, pf)
+}
+
+// ImportKeyPairWithOptions imports a key pair like ImportKeyPair, reading
+// PKCS #12 bundles and JWK sets as configured by opts. The certificate chain
+// found along with the key, if any, is returned as well.
+func ImportKeyPairWithOptions(keyPath string, opts ImportOptions, pf PassFunc) (*KeysBytes, error) {
+	imported, err := readImportedKey(keyPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return marshalKeyPair(imported.ptype, imported.keys(), pf)
+}
+
+// readImportedKey reads the private key at keyPath, in one of the formats
+// accepted by ImportKeyPair.
+func readImportedKey(keyPath string, opts ImportOptions) (*importedKey, error) {
 	kb, err := os.ReadFile(filepath.Clean(keyPath))
 	if err != nil {
 		return nil, err
 	}
 
+	switch {
+	case isJWK(kb):
+		return readImportedJWK(kb, opts.KeyID)
+	case isPKCS12(keyPath, kb):
+		return readImportedPKCS12(kb, opts.BundlePassFunc)
+	}
+
 	p, _ := pem.Decode(kb)
 	if p == nil {
 		return nil, fmt.Errorf("invalid pem block")
@@ -116,33 +152,56 @@ func ImportKeyPair(keyPath string, pf PassFunc) (*KeysBytes, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error parsing pkcs #8 private key")
 		}
-		switch k := pkcs8Pk.(type) {
-		case *rsa.PrivateKey:
-			if err = cryptoutils.ValidatePubKey(k.Public()); err != nil {
-				return nil, fmt.Errorf("error validating rsa key: %w", err)
-			}
-			pk = k
-		case *ecdsa.PrivateKey:
-			if err = cryptoutils.ValidatePubKey(k.Public()); err != nil {
-				return nil, fmt.Errorf("error validating ecdsa key: %w", err)
-			}
-			pk = k
-		case ed25519.PrivateKey:
-			if err = cryptoutils.ValidatePubKey(k.Public()); err != nil {
-				return nil, fmt.Errorf("error validating ed25519 key: %w", err)
-			}
-			pk = k
-		default:
-			return nil, fmt.Errorf("unexpected private key")
+		if pk, err = validateImportedKey(pkcs8Pk); err != nil {
+			return nil, err
 		}
 	default:
 		return nil, fmt.Errorf("unsupported private key")
 	}
-	return marshalKeyPair(p.Type, Keys{pk, pk.Public()}, pf)
+	return &importedKey{ptype: p.Type, key: pk}, nil
+}
+
+// validateImportedKey checks that pk is a private key cosign can sign with.
+func validateImportedKey(pk crypto.PrivateKey) (crypto.Signer, error) {
+	switch k := pk.(type) {
+	case *rsa.PrivateKey:
+		if err := cryptoutils.ValidatePubKey(k.Public()); err != nil {
+			return nil, fmt.Errorf("error validating rsa key: %w", err)
+		}
+		return k, nil
+	case *ecdsa.PrivateKey:
+		if err := cryptoutils.ValidatePubKey(k.Public()); err != nil {
+			return nil, fmt.Errorf("error validating ecdsa key: %w", err)
+		}
+		return k, nil
+	case ed25519.PrivateKey:
+		if err := cryptoutils.ValidatePubKey(k.Public()); err != nil {
+			return nil, fmt.Errorf("error validating ed25519 key: %w", err)
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unexpected private key")
+	}
+}
+
+// marshalPrivateKey returns the PKCS #8 encoding of the private key of keypair.
+func (keypair Keys) marshalPrivateKey() ([]byte, error) {
+	if priv, ok := keypair.private.(*rsa.PrivateKey); ok && keypair.rsaPSS {
+		return marshalRSAPSSPrivateKey(priv)
+	}
+	return x509.MarshalPKCS8PrivateKey(keypair.private)
+}
+
+// marshalPublicKey returns the PEM encoding of the public key of keypair.
+func (keypair Keys) marshalPublicKey() ([]byte, error) {
+	if pub, ok := keypair.public.(*rsa.PublicKey); ok && keypair.rsaPSS {
+		return MarshalRSAPSSPublicKeyToPEM(pub)
+	}
+	return cryptoutils.MarshalPublicKeyToPEM(keypair.public)
 }
 
 func marshalKeyPair(ptype string, keypair Keys, pf PassFunc) (key *KeysBytes, err error) {
-	x509Encoded, err := x509.MarshalPKCS8PrivateKey(keypair.private)
+	x509Encoded, err := keypair.marshalPrivateKey()
 	if err != nil {
 		return nil, fmt.Errorf("x509 encoding private key: %w", err)
 	}
@@ -155,7 +214,11 @@ func marshalKeyPair(ptype string, keypair Keys, pf PassFunc) (key *KeysBytes, er
 		}
 	}
 
-	encBytes, err := encrypted.Encrypt(x509Encoded, password)
+	keyID, err := PublicKeyID(keypair.public)
+	if err != nil {
+		return nil, err
+	}
+	encBytes, err := encryptPrivateKey(x509Encoded, password, KeyMetadata{Created: time.Now().UTC(), KeyID: keyID})
 	if err != nil {
 		return nil, err
 	}
@@ -172,26 +235,29 @@ func marshalKeyPair(ptype string, keypair Keys, pf PassFunc) (key *KeysBytes, er
 	})
 
 	// Now do the public key
-	pubBytes, err := cryptoutils.MarshalPublicKeyToPEM(keypair.public)
+	pubBytes, err := keypair.marshalPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	certBytes, chainBytes, err := keypair.marshalCertificates()
 	if err != nil {
 		return nil, err
 	}
 
 	return &KeysBytes{
-		PrivateBytes: privBytes,
-		PublicBytes:  pubBytes,
-		password:     password,
+		PrivateBytes:          privBytes,
+		PublicBytes:           pubBytes,
+		CertificateBytes:      certBytes,
+		CertificateChainBytes: chainBytes,
+		password:              password,
 	}, nil
 }
 
+// GenerateKeyPair generates an ECDSA P-256 key pair, encrypting the private
+// key with the password provided by pf.
 func GenerateKeyPair(pf PassFunc) (*KeysBytes, error) {
-	priv, err := GeneratePrivateKey()
-	if err != nil {
-		return nil, err
-	}
-
-	// Emit SIGSTORE keys by default
-	return marshalKeyPair(SigstorePrivateKeyPemType, Keys{priv, priv.Public()}, pf)
+	return GenerateKeyPairWithOptions(KeyPairOptions{}, pf)
 }
 
 // PemToECDSAKey marshals and returns the PEM-encoded ECDSA public key.
@@ -210,19 +276,43 @@ func PemToECDSAKey(pemBytes []byte) (*ecdsa.PublicKey, error) {
 // LoadPrivateKey loads a cosign PEM private key encrypted with the given passphrase,
 // and returns a SignerVerifier instance. The private key must be in the PKCS #8 format.
 func LoadPrivateKey(key []byte, pass []byte) (signature.SignerVerifier, error) {
-	// Decrypt first
+	x509Encoded, err := decryptPrivateKeyPEM(key, pass)
+	if err != nil {
+		return nil, err
+	}
+	return loadPKCS8PrivateKey(x509Encoded)
+}
+
+// decryptPrivateKeyPEM decrypts a cosign PEM private key with the given
+// passphrase, and returns the PKCS #8 encoded private key.
+func decryptPrivateKeyPEM(key []byte, pass []byte) ([]byte, error) {
 	p, _ := pem.Decode(key)
 	if p == nil {
 		return nil, errors.New("invalid pem block")
 	}
+	if p.Type == AgePrivateKeyPemType {
+		return nil, errors.New("private key is encrypted with age and requires an age identity to load")
+	}
 	if p.Type != CosignPrivateKeyPemType && p.Type != SigstorePrivateKeyPemType {
 		return nil, fmt.Errorf("unsupported pem type: %s", p.Type)
 	}
 
-	x509Encoded, err := encrypted.Decrypt(p.Bytes, pass)
+	x509Encoded, _, err := decryptPrivateKey(p.Bytes, pass)
 	if err != nil {
 		return nil, fmt.Errorf("decrypt: %w", err)
 	}
+	return x509Encoded, nil
+}
+
+// loadPKCS8PrivateKey returns a SignerVerifier for the PKCS #8 encoded
+// private key x509Encoded.
+func loadPKCS8PrivateKey(x509Encoded []byte) (signature.SignerVerifier, error) {
+	if priv, opts, ok, err := parseRSAPSSPrivateKey(x509Encoded); ok {
+		if err != nil {
+			return nil, err
+		}
+		return signature.LoadRSAPSSSignerVerifier(priv, opts.Hash, opts)
+	}
 	pk, err := x509.ParsePKCS8PrivateKey(x509Encoded)
 	if err != nil {
 		return nil, fmt.Errorf("parsing private key: %w", err)
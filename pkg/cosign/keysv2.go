@@ -0,0 +1,261 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Encrypted private keys are stored in one of two formats, both JSON in the
+// ENCRYPTED SIGSTORE PRIVATE KEY (or COSIGN) PEM block:
+//
+//   - the original format of go-securesystemslib/encrypted, deriving the
+//     key with scrypt and encrypting with NaCl secretbox, which is still read;
+//   - version 2, deriving the key with Argon2id and encrypting with
+//     XChaCha20-Poly1305, authenticating the key derivation parameters and
+//     the KeyMetadata stored in the clear alongside the ciphertext.
+const (
+	privateKeyFormatV2 = 2
+
+	kdfArgon2id         = "argon2id"
+	cipherXChaCha20Poly = "xchacha20-poly1305"
+)
+
+// Argon2id parameters of new keys, the second recommended option of RFC 9106.
+var defaultArgon2idParams = argon2idParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// Upper bounds of the Argon2id parameters of keys that are read, so that a
+// crafted key cannot make cosign spend unbounded time or memory.
+const (
+	maxArgon2idTime    = 16
+	maxArgon2idMemory  = 4 * 1024 * 1024 // KiB
+	maxArgon2idThreads = 64
+)
+
+// KeyMetadata describes an encrypted private key, and can be read without
+// its password.
+type KeyMetadata struct {
+	// Created is when the key was generated, imported or upgraded to the
+	// current format.
+	Created time.Time `json:"created"`
+	// KeyID is the hex-encoded SHA-256 digest of the DER-encoded PKIX
+	// public key.
+	KeyID string `json:"keyID"`
+}
+
+type argon2idParams struct {
+	Time    uint32 `json:"t"`
+	Memory  uint32 `json:"m"`
+	Threads uint8  `json:"p"`
+}
+
+type encryptedKeyV2 struct {
+	Version int `json:"version"`
+	KDF     struct {
+		Name   string         `json:"name"`
+		Params argon2idParams `json:"params"`
+		Salt   []byte         `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Metadata   KeyMetadata `json:"metadata"`
+	Ciphertext []byte      `json:"ciphertext,omitempty"`
+}
+
+// additionalData returns the fields of k the ciphertext authenticates: all
+// but the ciphertext itself.
+func (k encryptedKeyV2) additionalData() ([]byte, error) {
+	k.Ciphertext = nil
+	return json.Marshal(k)
+}
+
+// PublicKeyID returns the key ID of KeyMetadata for pub.
+func PublicKeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// encryptPrivateKey encrypts the PKCS #8 private key x509Encoded with
+// password in the version 2 format.
+func encryptPrivateKey(x509Encoded, password []byte, md KeyMetadata) ([]byte, error) {
+	k := encryptedKeyV2{Version: privateKeyFormatV2, Metadata: md}
+	k.KDF.Name = kdfArgon2id
+	k.KDF.Params = defaultArgon2idParams
+	k.KDF.Salt = make([]byte, 32)
+	if _, err := rand.Read(k.KDF.Salt); err != nil {
+		return nil, err
+	}
+	k.Cipher.Name = cipherXChaCha20Poly
+	k.Cipher.Nonce = make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(k.Cipher.Nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := k.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := k.additionalData()
+	if err != nil {
+		return nil, err
+	}
+	k.Ciphertext = aead.Seal(nil, k.Cipher.Nonce, x509Encoded, ad)
+	return json.Marshal(k)
+}
+
+func (k *encryptedKeyV2) aead(password []byte) (interface {
+	Seal(dst, nonce, plaintext, additionalData []byte) []byte
+	Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error)
+}, error) {
+	p := k.KDF.Params
+	key := argon2.IDKey(password, k.KDF.Salt, p.Time, p.Memory, p.Threads, chacha20poly1305.KeySize)
+	return chacha20poly1305.NewX(key)
+}
+
+// parseEncryptedKeyV2 parses data if it is a key in the version 2 format,
+// reporting whether it is.
+func parseEncryptedKeyV2(data []byte) (*encryptedKeyV2, bool, error) {
+	var k encryptedKeyV2
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, false, fmt.Errorf("parsing encrypted key: %w", err)
+	}
+	switch k.Version {
+	case 0:
+		// The original format has no version.
+		return nil, false, nil
+	case privateKeyFormatV2:
+	default:
+		return nil, false, fmt.Errorf("unsupported encrypted key version %d", k.Version)
+	}
+	if k.KDF.Name != kdfArgon2id {
+		return nil, false, fmt.Errorf("unsupported key derivation function %q", k.KDF.Name)
+	}
+	if k.Cipher.Name != cipherXChaCha20Poly {
+		return nil, false, fmt.Errorf("unsupported cipher %q", k.Cipher.Name)
+	}
+	p := k.KDF.Params
+	if p.Time < 1 || p.Time > maxArgon2idTime || p.Memory < 8*uint32(p.Threads) || p.Memory > maxArgon2idMemory ||
+		p.Threads < 1 || p.Threads > maxArgon2idThreads {
+		return nil, false, fmt.Errorf("unsupported argon2id parameters t=%d m=%d p=%d", p.Time, p.Memory, p.Threads)
+	}
+	if len(k.Cipher.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, false, errors.New("invalid nonce")
+	}
+	return &k, true, nil
+}
+
+// decryptPrivateKey decrypts the contents of an encrypted private key PEM
+// block in either format, returning the metadata of version 2 keys.
+func decryptPrivateKey(data, password []byte) ([]byte, *KeyMetadata, error) {
+	k, ok, err := parseEncryptedKeyV2(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		x509Encoded, err := encrypted.Decrypt(data, password)
+		return x509Encoded, nil, err
+	}
+	aead, err := k.aead(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	ad, err := k.additionalData()
+	if err != nil {
+		return nil, nil, err
+	}
+	x509Encoded, err := aead.Open(nil, k.Cipher.Nonce, k.Ciphertext, ad)
+	if err != nil {
+		return nil, nil, errors.New("decryption failed")
+	}
+	return x509Encoded, &k.Metadata, nil
+}
+
+// PrivateKeyMetadata returns the metadata of an encrypted private key, which
+// is nil for keys in the original format.
+func PrivateKeyMetadata(key []byte) (*KeyMetadata, error) {
+	p, _ := pem.Decode(key)
+	if p == nil {
+		return nil, errors.New("invalid pem block")
+	}
+	if p.Type != CosignPrivateKeyPemType && p.Type != SigstorePrivateKeyPemType {
+		return nil, fmt.Errorf("unsupported pem type: %s", p.Type)
+	}
+	k, ok, err := parseEncryptedKeyV2(p.Bytes)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &k.Metadata, nil
+}
+
+// UpgradePrivateKey decrypts an encrypted private key in either format with
+// password, and encrypts it again with the same password in the version 2
+// format, keeping its PEM type.
+func UpgradePrivateKey(key, password []byte) (*KeysBytes, error) {
+	p, _ := pem.Decode(key)
+	if p == nil {
+		return nil, errors.New("invalid pem block")
+	}
+	if p.Type != CosignPrivateKeyPemType && p.Type != SigstorePrivateKeyPemType {
+		return nil, fmt.Errorf("unsupported pem type: %s", p.Type)
+	}
+	x509Encoded, _, err := decryptPrivateKey(p.Bytes, password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	keys, err := parsePKCS8Keys(x509Encoded)
+	if err != nil {
+		return nil, err
+	}
+	return marshalKeyPair(p.Type, keys, func(bool) ([]byte, error) { return password, nil })
+}
+
+// parsePKCS8Keys parses the PKCS #8 private key x509Encoded, keeping RSA-PSS
+// keys marked as such.
+func parsePKCS8Keys(x509Encoded []byte) (Keys, error) {
+	if priv, _, ok, err := parseRSAPSSPrivateKey(x509Encoded); ok {
+		if err != nil {
+			return Keys{}, err
+		}
+		return Keys{private: priv, public: priv.Public(), rsaPSS: true}, nil
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(x509Encoded)
+	if err != nil {
+		return Keys{}, fmt.Errorf("parsing private key: %w", err)
+	}
+	signer, ok := pk.(crypto.Signer)
+	if !ok {
+		return Keys{}, errors.New("unsupported key type")
+	}
+	return Keys{private: signer, public: signer.Public()}, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateKeyV2(t *testing.T) {
+	keys, err := GenerateKeyPair(pass("hello"))
+	require.NoError(t, err)
+
+	md, err := PrivateKeyMetadata(keys.PrivateBytes)
+	require.NoError(t, err)
+	require.NotNil(t, md)
+	require.WithinDuration(t, time.Now(), md.Created, time.Minute)
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(keys.PublicBytes)
+	require.NoError(t, err)
+	keyID, err := PublicKeyID(pub)
+	require.NoError(t, err)
+	require.Equal(t, keyID, md.KeyID)
+
+	sv, err := LoadPrivateKey(keys.PrivateBytes, []byte("hello"))
+	require.NoError(t, err)
+	svPub, err := sv.PublicKey()
+	require.NoError(t, err)
+	require.NoError(t, cryptoutils.EqualKeys(pub, svPub))
+	_, err = LoadPrivateKey(keys.PrivateBytes, []byte("wrong"))
+	require.Error(t, err)
+
+	// The metadata is authenticated along with the private key.
+	tamper := func(f func(*encryptedKeyV2)) []byte {
+		p, _ := pem.Decode(keys.PrivateBytes)
+		var k encryptedKeyV2
+		require.NoError(t, json.Unmarshal(p.Bytes, &k))
+		f(&k)
+		b, err := json.Marshal(k)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: p.Type, Bytes: b})
+	}
+	_, err = LoadPrivateKey(tamper(func(k *encryptedKeyV2) { k.Metadata.KeyID = "0000" }), []byte("hello"))
+	require.ErrorContains(t, err, "decryption failed")
+	_, err = LoadPrivateKey(tamper(func(k *encryptedKeyV2) { k.Metadata.Created = time.Time{} }), []byte("hello"))
+	require.ErrorContains(t, err, "decryption failed")
+
+	// Key derivation parameters are bounded.
+	_, err = LoadPrivateKey(tamper(func(k *encryptedKeyV2) { k.KDF.Params.Memory = 1 << 30 }), []byte("hello"))
+	require.ErrorContains(t, err, "unsupported argon2id parameters")
+	_, err = LoadPrivateKey(tamper(func(k *encryptedKeyV2) { k.Version = 3 }), []byte("hello"))
+	require.ErrorContains(t, err, "unsupported encrypted key version 3")
+}
+
+func TestUpgradePrivateKey(t *testing.T) {
+	// Keys in the original scrypt format are still read, without metadata.
+	md, err := PrivateKeyMetadata([]byte(pemsigstorekey))
+	require.NoError(t, err)
+	require.Nil(t, md)
+	old, err := LoadPrivateKey([]byte(pemsigstorekey), []byte("hello"))
+	require.NoError(t, err)
+	oldPub, err := old.PublicKey()
+	require.NoError(t, err)
+
+	_, err = UpgradePrivateKey([]byte(pemsigstorekey), []byte("wrong"))
+	require.Error(t, err)
+
+	keys, err := UpgradePrivateKey([]byte(pemsigstorekey), []byte("hello"))
+	require.NoError(t, err)
+	p, _ := pem.Decode(keys.PrivateBytes)
+	require.Equal(t, SigstorePrivateKeyPemType, p.Type)
+	md, err = PrivateKeyMetadata(keys.PrivateBytes)
+	require.NoError(t, err)
+	require.NotNil(t, md)
+	keyID, err := PublicKeyID(oldPub)
+	require.NoError(t, err)
+	require.Equal(t, keyID, md.KeyID)
+
+	sv, err := LoadPrivateKey(keys.PrivateBytes, []byte("hello"))
+	require.NoError(t, err)
+	pub, err := sv.PublicKey()
+	require.NoError(t, err)
+	require.NoError(t, cryptoutils.EqualKeys(oldPub, pub))
+
+	// The PEM type of COSIGN keys is kept.
+	keys, err = UpgradePrivateKey([]byte(pemcosignkey), []byte("hello"))
+	require.NoError(t, err)
+	p, _ = pem.Decode(keys.PrivateBytes)
+	require.Equal(t, CosignPrivateKeyPemType, p.Type)
+	_, err = LoadPrivateKey(keys.PrivateBytes, []byte("hello"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+)
+
+// Types of keys GenerateKeyPairWithOptions generates.
+const (
+	KeyTypeECDSAP256 = "ecdsa-p256"
+	KeyTypeED25519   = "ed25519"
+	KeyTypeRSA3072   = "rsa-3072"
+	KeyTypeRSA4096   = "rsa-4096"
+)
+
+// Signature schemes of RSA keys.
+const (
+	RSASchemePKCS1v15 = "pkcs1v15"
+	RSASchemePSS      = "pss"
+)
+
+// KeyTypes are the types of keys GenerateKeyPairWithOptions generates.
+var KeyTypes = []string{KeyTypeECDSAP256, KeyTypeED25519, KeyTypeRSA3072, KeyTypeRSA4096}
+
+// RSASchemes are the signature schemes of RSA keys.
+var RSASchemes = []string{RSASchemePKCS1v15, RSASchemePSS}
+
+// KeyPairOptions select the key pair to generate.
+type KeyPairOptions struct {
+	// KeyType is one of KeyTypes, KeyTypeECDSAP256 if empty.
+	KeyType string
+	// RSAScheme is one of RSASchemes, RSASchemePKCS1v15 if empty. It may
+	// only be set for RSA key types. RSA-PSS keys sign with SHA-256 and are
+	// marked as RSA-PSS keys, so verifiers use that scheme automatically.
+	RSAScheme string
+}
+
+// generate generates the private key selected by o.
+func (o KeyPairOptions) generate() (Keys, error) {
+	rsaBits := 0
+	switch o.KeyType {
+	case "", KeyTypeECDSAP256, KeyTypeED25519:
+	case KeyTypeRSA3072:
+		rsaBits = 3072
+	case KeyTypeRSA4096:
+		rsaBits = 4096
+	default:
+		return Keys{}, fmt.Errorf("unsupported key type %q, must be one of %v", o.KeyType, KeyTypes)
+	}
+	switch o.RSAScheme {
+	case "", RSASchemePKCS1v15, RSASchemePSS:
+	default:
+		return Keys{}, fmt.Errorf("unsupported RSA signature scheme %q, must be one of %v", o.RSAScheme, RSASchemes)
+	}
+	if o.RSAScheme != "" && rsaBits == 0 {
+		return Keys{}, fmt.Errorf("an RSA signature scheme requires an RSA key type, not %q", o.KeyType)
+	}
+
+	var (
+		priv crypto.Signer
+		err  error
+	)
+	switch {
+	case rsaBits != 0:
+		priv, err = rsa.GenerateKey(rand.Reader, rsaBits)
+	case o.KeyType == KeyTypeED25519:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		priv, err = GeneratePrivateKey()
+	}
+	if err != nil {
+		return Keys{}, err
+	}
+	return Keys{private: priv, public: priv.Public(), rsaPSS: o.RSAScheme == RSASchemePSS}, nil
+}
+
+// GenerateKeyPairWithOptions generates a key pair like GenerateKeyPair, of
+// the type selected by opts.
+func GenerateKeyPairWithOptions(opts KeyPairOptions, pf PassFunc) (*KeysBytes, error) {
+	keys, err := opts.generate()
+	if err != nil {
+		return nil, err
+	}
+	// Emit SIGSTORE keys by default
+	return marshalKeyPair(SigstorePrivateKeyPemType, keys, pf)
+}
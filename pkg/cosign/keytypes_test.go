@@ -0,0 +1,144 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+func TestGenerateKeyPairWithOptions(t *testing.T) {
+	pf := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	tests := []struct {
+		opts         KeyPairOptions
+		wantVerifier signature.Verifier
+		wantSigner   signature.SignerVerifier
+	}{{
+		opts:         KeyPairOptions{},
+		wantVerifier: &signature.ECDSAVerifier{},
+		wantSigner:   &signature.ECDSASignerVerifier{},
+	}, {
+		opts:         KeyPairOptions{KeyType: KeyTypeED25519},
+		wantVerifier: &signature.ED25519Verifier{},
+		wantSigner:   &signature.ED25519SignerVerifier{},
+	}, {
+		opts:         KeyPairOptions{KeyType: KeyTypeRSA3072},
+		wantVerifier: &signature.RSAPKCS1v15Verifier{},
+		wantSigner:   &signature.RSAPKCS1v15SignerVerifier{},
+	}, {
+		opts:         KeyPairOptions{KeyType: KeyTypeRSA3072, RSAScheme: RSASchemePSS},
+		wantVerifier: &signature.RSAPSSVerifier{},
+		wantSigner:   &signature.RSAPSSSignerVerifier{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.opts.KeyType+"/"+tt.opts.RSAScheme, func(t *testing.T) {
+			keys, err := GenerateKeyPairWithOptions(tt.opts, pf)
+			if err != nil {
+				t.Fatalf("GenerateKeyPairWithOptions() = %v", err)
+			}
+			sv, err := LoadPrivateKey(keys.PrivateBytes, []byte("hunter2"))
+			if err != nil {
+				t.Fatalf("LoadPrivateKey() = %v", err)
+			}
+			if got, want := fmt.Sprintf("%T", sv), fmt.Sprintf("%T", tt.wantSigner); got != want {
+				t.Errorf("LoadPrivateKey() = %s, wanted %s", got, want)
+			}
+			msg := []byte("payload")
+			sig, err := sv.SignMessage(bytes.NewReader(msg))
+			if err != nil {
+				t.Fatalf("SignMessage() = %v", err)
+			}
+
+			verifier, err := LoadPublicKeyPEM(keys.PublicBytes, crypto.SHA256)
+			if err != nil {
+				t.Fatalf("LoadPublicKeyPEM() = %v", err)
+			}
+			if got, want := fmt.Sprintf("%T", verifier), fmt.Sprintf("%T", tt.wantVerifier); got != want {
+				t.Errorf("LoadPublicKeyPEM() = %s, wanted %s", got, want)
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Errorf("VerifySignature() = %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateKeyPairWithOptionsInvalid(t *testing.T) {
+	for _, opts := range []KeyPairOptions{
+		{KeyType: "dsa"},
+		{KeyType: KeyTypeRSA3072, RSAScheme: "x931"},
+		{RSAScheme: RSASchemePSS},
+		{KeyType: KeyTypeED25519, RSAScheme: RSASchemePKCS1v15},
+	} {
+		if _, err := GenerateKeyPairWithOptions(opts, nil); err == nil {
+			t.Errorf("GenerateKeyPairWithOptions(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestLoadPublicKeyPEMRSAPSSWithoutParameters(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys from "openssl genpkey -algorithm RSA-PSS" have no parameters
+	// unless restricted with -pkeyopt.
+	der, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSASSAPSS},
+		PublicKey: asn1.BitString{Bytes: x509.MarshalPKCS1PublicKey(&priv.PublicKey)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	digest := sha256.Sum256([]byte("payload"))
+	sig, err := rsa.SignPSS(rand.Reader, priv, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := LoadPublicKeyPEM(pub, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("LoadPublicKeyPEM() = %v", err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("payload"))); err != nil {
+		t.Errorf("VerifySignature() = %v", err)
+	}
+
+	// The key must not verify PKCS #1 v1.5 signatures.
+	sig, err = rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("payload"))); err == nil {
+		t.Error("VerifySignature() accepted a PKCS #1 v1.5 signature for an RSA-PSS key")
+	}
+
+	// Nor digests other than SHA-256.
+	if _, err := LoadPublicKeyPEM(pub, crypto.SHA384); err == nil {
+		t.Error("LoadPublicKeyPEM() of an RSA-PSS key with SHA-384 succeeded, want error")
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package minisign signs and verifies messages in the formats of minisign
+// (https://jedisct1.github.io/minisign/) and of OpenBSD's signify, which
+// share their Ed25519 public keys and, without minisign's trusted comments,
+// their signatures.
+package minisign
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// ReferenceScheme prefixes the paths of minisign and signify keys given
+	// as key references, e.g. "minisign://~/.minisign/minisign.key".
+	ReferenceScheme = "minisign://"
+
+	untrustedCommentPrefix = "untrusted comment: "
+	trustedCommentPrefix   = "trusted comment: "
+
+	keyIDSize = 8
+)
+
+var (
+	// algEd signs messages themselves, as signify and legacy minisign do.
+	algEd = [2]byte{'E', 'd'}
+	// algEdPrehashed signs the BLAKE2b-512 digest of messages.
+	algEdPrehashed = [2]byte{'E', 'D'}
+
+	kdfScrypt  = [2]byte{'S', 'c'}
+	kdfNone    = [2]byte{0, 0}
+	kdfBcrypt  = [2]byte{'B', 'K'} // of signify secret keys
+	chkBLAKE2b = [2]byte{'B', '2'}
+
+	errNotSecretKey = errors.New("not a minisign secret key")
+)
+
+// KeyPath returns the file path of the key reference keyRef, without its
+// ReferenceScheme and with a leading "~/" replaced by the home directory.
+func KeyPath(keyRef string) (string, error) {
+	p := strings.TrimPrefix(keyRef, ReferenceScheme)
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(home, rest)
+	}
+	return p, nil
+}
+
+// KeyID identifies a key pair in its signatures.
+type KeyID [keyIDSize]byte
+
+// String formats id like minisign does, as a little-endian hex integer.
+func (id KeyID) String() string {
+	return strings.ToUpper(fmt.Sprintf("%016x", binary.LittleEndian.Uint64(id[:])))
+}
+
+// PublicKey is a minisign or signify public key.
+type PublicKey struct {
+	KeyID KeyID
+	Key   ed25519.PublicKey
+}
+
+// ParsePublicKey parses a public key file, or the base64 public key line
+// of one as given to minisign -P.
+func ParsePublicKey(b []byte) (*PublicKey, error) {
+	lines := readLines(b)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return nil, errors.New("invalid public key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) != 2+keyIDSize+ed25519.PublicKeySize || !bytes.Equal(raw[:2], algEd[:]) {
+		return nil, errors.New("invalid public key")
+	}
+	pk := &PublicKey{Key: ed25519.PublicKey(raw[2+keyIDSize:])}
+	copy(pk.KeyID[:], raw[2:])
+	return pk, nil
+}
+
+// Signature is a parsed minisign or signify signature.
+type Signature struct {
+	// Algorithm is "Ed" for signatures of messages and "ED" for
+	// signatures of their BLAKE2b-512 digest.
+	Algorithm [2]byte
+	KeyID     KeyID
+	Signature []byte
+	// UntrustedComment is not signed.
+	UntrustedComment string
+	// TrustedComment is signed along with the signature by the global
+	// signature. Signify signatures have neither.
+	TrustedComment  string
+	GlobalSignature []byte
+}
+
+// ParseSignature parses the minisign or signify signature b.
+func ParseSignature(b []byte) (*Signature, error) {
+	lines := readLines(b)
+	if len(lines) != 2 && len(lines) != 4 {
+		return nil, errors.New("not a minisign or signify signature")
+	}
+	s := &Signature{}
+	var ok bool
+	if s.UntrustedComment, ok = strings.CutPrefix(lines[0], untrustedCommentPrefix); !ok {
+		return nil, errors.New("missing untrusted comment")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+keyIDSize+ed25519.SignatureSize {
+		return nil, errors.New("invalid signature")
+	}
+	copy(s.Algorithm[:], raw)
+	copy(s.KeyID[:], raw[2:])
+	s.Signature = raw[2+keyIDSize:]
+	if s.Algorithm != algEd && s.Algorithm != algEdPrehashed {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", s.Algorithm[:])
+	}
+	if len(lines) == 4 {
+		if s.TrustedComment, ok = strings.CutPrefix(lines[2], trustedCommentPrefix); !ok {
+			return nil, errors.New("missing trusted comment")
+		}
+		s.GlobalSignature, err = base64.StdEncoding.DecodeString(lines[3])
+		if err != nil || len(s.GlobalSignature) != ed25519.SignatureSize {
+			return nil, errors.New("invalid global signature")
+		}
+	}
+	return s, nil
+}
+
+// Verify checks that s is a signature of message by pk, and that its
+// trusted comment, if any, was signed by pk. Signatures of BLAKE2b-512
+// digests must have a trusted comment, as minisign always writes one.
+func (pk *PublicKey) Verify(s *Signature, message io.Reader) error {
+	if s.KeyID != pk.KeyID {
+		return fmt.Errorf("signature was made with key %s, not %s", s.KeyID, pk.KeyID)
+	}
+	data, err := signedMessage(s.Algorithm, message)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pk.Key, data, s.Signature) {
+		return errors.New("invalid signature")
+	}
+	if s.GlobalSignature == nil {
+		if s.Algorithm == algEdPrehashed {
+			return errors.New("missing trusted comment")
+		}
+		return nil
+	}
+	if !ed25519.Verify(pk.Key, append(bytes.Clone(s.Signature), s.TrustedComment...), s.GlobalSignature) {
+		return errors.New("invalid global signature of the trusted comment")
+	}
+	return nil
+}
+
+// signedMessage returns what the key signs for message with alg.
+func signedMessage(alg [2]byte, message io.Reader) ([]byte, error) {
+	if alg == algEd {
+		return io.ReadAll(message)
+	}
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// PrivateKey is a minisign secret key.
+type PrivateKey struct {
+	KeyID KeyID
+	Key   ed25519.PrivateKey
+}
+
+// Public returns the public key of k.
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{KeyID: k.KeyID, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// ParsePrivateKey parses the minisign secret key file b, calling pass for
+// its password if it is encrypted.
+func ParsePrivateKey(b []byte, pass func() ([]byte, error)) (*PrivateKey, error) {
+	lines := readLines(b)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return nil, errNotSecretKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) < 4 || !bytes.Equal(raw[:2], algEd[:]) {
+		return nil, errNotSecretKey
+	}
+	if bytes.Equal(raw[2:4], kdfBcrypt[:]) {
+		return nil, errors.New("signify secret keys are not supported, sign with a minisign secret key")
+	}
+
+	// The layout of minisign's SeckeyStruct.
+	const (
+		saltSize     = 32
+		keynumSKSize = keyIDSize + ed25519.PrivateKeySize + blake2b.Size256
+		size         = 2 + 2 + 2 + saltSize + 8 + 8 + keynumSKSize
+	)
+	if len(raw) != size || !bytes.Equal(raw[4:6], chkBLAKE2b[:]) {
+		return nil, errors.New("invalid minisign secret key")
+	}
+	kdf := [2]byte(raw[2:4])
+	salt := raw[6 : 6+saltSize]
+	opsLimit := binary.LittleEndian.Uint64(raw[6+saltSize:])
+	memLimit := binary.LittleEndian.Uint64(raw[6+saltSize+8:])
+	keynumSK := bytes.Clone(raw[size-keynumSKSize:])
+
+	switch kdf {
+	case kdfNone:
+	case kdfScrypt:
+		if pass == nil {
+			return nil, errors.New("minisign secret key is encrypted and requires a password")
+		}
+		password, err := pass()
+		if err != nil {
+			return nil, err
+		}
+		stream, err := scryptStream(password, salt, opsLimit, memLimit, len(keynumSK))
+		if err != nil {
+			return nil, err
+		}
+		subtle.XORBytes(keynumSK, keynumSK, stream)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %q", kdf[:])
+	}
+
+	k := &PrivateKey{Key: ed25519.PrivateKey(keynumSK[keyIDSize : keyIDSize+ed25519.PrivateKeySize])}
+	copy(k.KeyID[:], keynumSK)
+	if subtle.ConstantTimeCompare(keyChecksum(k), keynumSK[keyIDSize+ed25519.PrivateKeySize:]) != 1 {
+		if kdf == kdfScrypt {
+			return nil, errors.New("wrong password for minisign secret key")
+		}
+		return nil, errors.New("invalid minisign secret key checksum")
+	}
+	return k, nil
+}
+
+// keyChecksum returns the checksum minisign stores in secret keys.
+func keyChecksum(k *PrivateKey) []byte {
+	h, _ := blake2b.New256(nil)
+	h.Write(algEd[:])
+	h.Write(k.KeyID[:])
+	h.Write(k.Key)
+	return h.Sum(nil)
+}
+
+// Limits of the scrypt parameters of secret keys that are read, well above
+// minisign's defaults of 2^20 and 8.
+const (
+	maxScryptLogN = 21
+	maxScryptP    = 64
+)
+
+// scryptStream derives the n bytes encrypting a secret key, choosing the
+// scrypt parameters from the limits like libsodium's
+// crypto_pwhash_scryptsalsa208sha256.
+func scryptStream(password, salt []byte, opsLimit, memLimit uint64, n int) ([]byte, error) {
+	const r = 8
+	if opsLimit < 32768 {
+		opsLimit = 32768
+	}
+	logN := func(maxN uint64) int {
+		l := 1
+		for ; l < 63; l++ {
+			if uint64(1)<<l > maxN/2 {
+				break
+			}
+		}
+		return l
+	}
+	var nLog2 int
+	p := uint64(1)
+	if opsLimit < memLimit/32 {
+		nLog2 = logN(opsLimit / (r * 4))
+	} else {
+		nLog2 = logN(memLimit / (r * 128))
+		maxRP := (opsLimit / 4) / (uint64(1) << nLog2)
+		if maxRP > 0x3fffffff {
+			maxRP = 0x3fffffff
+		}
+		p = maxRP / r
+	}
+	if nLog2 > maxScryptLogN || p < 1 || p > maxScryptP {
+		return nil, fmt.Errorf("unsupported scrypt parameters N=2^%d r=%d p=%d", nLog2, r, p)
+	}
+	return scrypt.Key(password, salt, 1<<nLog2, r, int(p), n)
+}
+
+// Sign signs the BLAKE2b-512 digest of message with k like minisign -S,
+// returning the signature with trustedComment signed along with it.
+func Sign(k *PrivateKey, message io.Reader, untrustedComment, trustedComment string) ([]byte, error) {
+	if strings.ContainsAny(untrustedComment+trustedComment, "\r\n") {
+		return nil, errors.New("comments must be on a single line")
+	}
+	data, err := signedMessage(algEdPrehashed, message)
+	if err != nil {
+		return nil, err
+	}
+	sig := ed25519.Sign(k.Key, data)
+	global := ed25519.Sign(k.Key, append(bytes.Clone(sig), trustedComment...))
+
+	raw := append(append(algEdPrehashed[:], k.KeyID[:]...), sig...)
+	var buf bytes.Buffer
+	buf.WriteString(untrustedCommentPrefix + untrustedComment + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(raw) + "\n")
+	buf.WriteString(trustedCommentPrefix + trustedComment + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(global) + "\n")
+	return buf.Bytes(), nil
+}
+
+// readLines returns the non-empty lines of b, without line endings.
+func readLines(b []byte) []string {
+	var lines []string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		if l := strings.TrimRight(s.Text(), "\r"); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) *PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k := &PrivateKey{Key: priv}
+	_, err = rand.Read(k.KeyID[:])
+	require.NoError(t, err)
+	return k
+}
+
+// marshalPrivateKey encodes k like minisign -G, encrypting it with password
+// unless it is nil.
+func marshalPrivateKey(t *testing.T, k *PrivateKey, password []byte, opsLimit, memLimit uint64) []byte {
+	t.Helper()
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+	keynumSK := append(append(bytes.Clone(k.KeyID[:]), k.Key...), keyChecksum(k)...)
+	kdf := kdfNone
+	if password != nil {
+		kdf = kdfScrypt
+		stream, err := scryptStream(password, salt, opsLimit, memLimit, len(keynumSK))
+		require.NoError(t, err)
+		subtle.XORBytes(keynumSK, keynumSK, stream)
+	}
+	raw := append(append(append(algEd[:], kdf[:]...), chkBLAKE2b[:]...), salt...)
+	raw = binary.LittleEndian.AppendUint64(raw, opsLimit)
+	raw = binary.LittleEndian.AppendUint64(raw, memLimit)
+	raw = append(raw, keynumSK...)
+	return []byte("untrusted comment: minisign encrypted secret key\n" + base64.StdEncoding.EncodeToString(raw) + "\n")
+}
+
+func marshalPublicKey(pk *PublicKey) string {
+	raw := append(append(algEd[:], pk.KeyID[:]...), pk.Key...)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestSignVerify(t *testing.T) {
+	k := newKey(t)
+	message := []byte("hello, minisign")
+	b, err := Sign(k, bytes.NewReader(message), "signature from cosign secret key", "timestamp:1700000000\tfile:message.txt\thashed")
+	require.NoError(t, err)
+
+	sig, err := ParseSignature(b)
+	require.NoError(t, err)
+	require.Equal(t, algEdPrehashed, sig.Algorithm)
+	require.Equal(t, "timestamp:1700000000\tfile:message.txt\thashed", sig.TrustedComment)
+
+	pk, err := ParsePublicKey([]byte("untrusted comment: minisign public key " + k.KeyID.String() + "\n" + marshalPublicKey(k.Public()) + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, pk.Verify(sig, bytes.NewReader(message)))
+	require.ErrorContains(t, pk.Verify(sig, bytes.NewReader([]byte("something else"))), "invalid signature")
+
+	// The trusted comment is signed.
+	tampered := *sig
+	tampered.TrustedComment = "timestamp:1800000000\tfile:message.txt\thashed"
+	require.ErrorContains(t, pk.Verify(&tampered, bytes.NewReader(message)), "invalid global signature")
+	tampered = *sig
+	tampered.GlobalSignature = nil
+	require.ErrorContains(t, pk.Verify(&tampered, bytes.NewReader(message)), "missing trusted comment")
+
+	// Signatures name the key they were made with.
+	other := newKey(t)
+	err = other.Public().Verify(sig, bytes.NewReader(message))
+	require.ErrorContains(t, err, "signature was made with key "+k.KeyID.String())
+
+	_, err = Sign(k, bytes.NewReader(message), "two\nlines", "")
+	require.Error(t, err)
+}
+
+func TestVerifySignify(t *testing.T) {
+	k := newKey(t)
+	message := []byte("hello, signify")
+	raw := append(append(algEd[:], k.KeyID[:]...), ed25519.Sign(k.Key, message)...)
+	b := []byte("untrusted comment: verify with key.pub\n" + base64.StdEncoding.EncodeToString(raw) + "\n")
+
+	sig, err := ParseSignature(b)
+	require.NoError(t, err)
+	require.Empty(t, sig.GlobalSignature)
+	// Public keys may be given as their base64 line alone.
+	pk, err := ParsePublicKey([]byte(marshalPublicKey(k.Public())))
+	require.NoError(t, err)
+	require.NoError(t, pk.Verify(sig, bytes.NewReader(message)))
+	require.Error(t, pk.Verify(sig, bytes.NewReader([]byte("something else"))))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, b := range []string{
+		"",
+		"untrusted comment: x\nnot base64\n",
+		"untrusted comment: x\n" + base64.StdEncoding.EncodeToString([]byte("Ed0123456789")) + "\n",
+		"comment: x\n" + base64.StdEncoding.EncodeToString(make([]byte, 74)) + "\n",
+	} {
+		if _, err := ParseSignature([]byte(b)); err == nil {
+			t.Errorf("ParseSignature(%q) succeeded", b)
+		}
+		if _, err := ParsePublicKey([]byte(b)); err == nil {
+			t.Errorf("ParsePublicKey(%q) succeeded", b)
+		}
+	}
+	raw := append([]byte("EX"), make([]byte, 72)...)
+	_, err := ParseSignature([]byte("untrusted comment: x\n" + base64.StdEncoding.EncodeToString(raw)))
+	require.ErrorContains(t, err, `unsupported signature algorithm "EX"`)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	k := newKey(t)
+	password := []byte("correct horse")
+	pass := func() ([]byte, error) { return password, nil }
+
+	// Small limits keep scrypt fast.
+	encrypted := marshalPrivateKey(t, k, password, 32768, 16<<20)
+	got, err := ParsePrivateKey(encrypted, pass)
+	require.NoError(t, err)
+	require.Equal(t, k.KeyID, got.KeyID)
+	require.True(t, k.Key.Equal(got.Key))
+
+	_, err = ParsePrivateKey(encrypted, func() ([]byte, error) { return []byte("wrong"), nil })
+	require.ErrorContains(t, err, "wrong password")
+	_, err = ParsePrivateKey(encrypted, nil)
+	require.ErrorContains(t, err, "requires a password")
+
+	unencrypted := marshalPrivateKey(t, k, nil, 0, 0)
+	got, err = ParsePrivateKey(unencrypted, nil)
+	require.NoError(t, err)
+	require.True(t, k.Key.Equal(got.Key))
+
+	// Limits requiring unreasonable memory are refused.
+	lines := strings.Split(strings.TrimSpace(string(marshalPrivateKey(t, k, nil, 1<<40, 1<<40))), "\n")
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	require.NoError(t, err)
+	copy(raw[2:4], kdfScrypt[:])
+	_, err = ParsePrivateKey([]byte(base64.StdEncoding.EncodeToString(raw)), pass)
+	require.ErrorContains(t, err, "unsupported scrypt parameters")
+
+	signify := append([]byte("EdBK"), make([]byte, 100)...)
+	_, err = ParsePrivateKey([]byte(base64.StdEncoding.EncodeToString(signify)), pass)
+	require.ErrorContains(t, err, "signify secret keys are not supported")
+	_, err = ParsePrivateKey([]byte(marshalPublicKey(k.Public())), pass)
+	require.Error(t, err)
+}
+
+func TestKeyIDString(t *testing.T) {
+	id := KeyID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0xab}
+	require.Equal(t, "AB07060504030201", id.String())
+}
+
+func TestKeyPath(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+	for ref, want := range map[string]string{
+		"minisign://~/.minisign/minisign.key": "/home/alice/.minisign/minisign.key",
+		"minisign:///etc/signify/key.pub":     "/etc/signify/key.pub",
+		"minisign://key.pub":                  "key.pub",
+	} {
+		got, err := KeyPath(ref)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
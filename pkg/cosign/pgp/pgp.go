@@ -0,0 +1,175 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pgp signs blobs with OpenPGP keys and verifies the detached
+// signatures of gpg --detach-sign against keyrings, see RFC 4880.
+package pgp
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// ReferenceScheme prefixes the paths of OpenPGP keys and keyrings given as
+// key references, e.g. "gpg://release-keys.asc".
+const ReferenceScheme = "gpg://"
+
+var armorStart = []byte("-----BEGIN PGP ")
+
+// KeyPath returns the file path of the key reference keyRef, without its
+// ReferenceScheme and with a leading "~/" replaced by the home directory.
+func KeyPath(keyRef string) (string, error) {
+	p := strings.TrimPrefix(keyRef, ReferenceScheme)
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(home, rest)
+	}
+	return p, nil
+}
+
+// ParseKeyRing parses the keys of a keyring, binary as gpg --export writes
+// or armored as gpg --export --armor writes. Armored keyrings may hold
+// several concatenated key blocks.
+func ParseKeyRing(b []byte) (openpgp.EntityList, error) {
+	if !bytes.Contains(b, armorStart) {
+		keyring, err := openpgp.ReadKeyRing(bytes.NewReader(b))
+		if err == nil && len(keyring) == 0 {
+			err = errors.New("no keys found")
+		}
+		return keyring, err
+	}
+	var keyring openpgp.EntityList
+	for rest := b; ; {
+		i := bytes.Index(rest, armorStart)
+		if i < 0 {
+			break
+		}
+		rest = rest[i:]
+		block, err := armor.Decode(bytes.NewReader(rest))
+		if err != nil {
+			return nil, err
+		}
+		if block.Type != openpgp.PublicKeyType && block.Type != openpgp.PrivateKeyType {
+			return nil, fmt.Errorf("unexpected %s block in keyring", block.Type)
+		}
+		entities, err := openpgp.ReadKeyRing(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		keyring = append(keyring, entities...)
+		rest = rest[len(armorStart):]
+	}
+	return keyring, nil
+}
+
+// SigningEntity returns the first key of keys that holds a private key,
+// as gpg --export-secret-keys writes, decrypting its private keys with the
+// passphrase pass returns if they are encrypted.
+func SigningEntity(keys openpgp.EntityList, pass func() ([]byte, error)) (*openpgp.Entity, error) {
+	for _, e := range keys {
+		if e.PrivateKey == nil {
+			continue
+		}
+		if !isEncrypted(e) {
+			return e, nil
+		}
+		if pass == nil {
+			return nil, errors.New("private key is encrypted")
+		}
+		p, err := pass()
+		if err != nil {
+			return nil, err
+		}
+		if err := e.DecryptPrivateKeys(p); err != nil {
+			return nil, fmt.Errorf("decrypting private key: %w", err)
+		}
+		return e, nil
+	}
+	return nil, errors.New("no private key found")
+}
+
+func isEncrypted(e *openpgp.Entity) bool {
+	if e.PrivateKey.Encrypted {
+		return true
+	}
+	for _, sub := range e.Subkeys {
+		if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the armored detached signature of message by the signing
+// key of signer, as gpg --detach-sign --armor writes.
+func Sign(signer *openpgp.Entity, message io.Reader) ([]byte, error) {
+	var b bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&b, signer, message, &packet.Config{DefaultHash: crypto.SHA256}); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// Verification describes a good signature.
+type Verification struct {
+	// Fingerprint is the fingerprint of the primary key of the signer.
+	Fingerprint string
+	// Identity is the primary user ID of the signer.
+	Identity string
+	// Created is when the signature was made.
+	Created time.Time
+}
+
+// Verify checks the detached signature, armored or binary, of message
+// against the keys of keyring, as gpg --verify does.
+func Verify(keyring openpgp.EntityList, signature []byte, message io.Reader) (*Verification, error) {
+	sig := io.Reader(bytes.NewReader(signature))
+	if bytes.HasPrefix(bytes.TrimSpace(signature), armorStart) {
+		block, err := armor.Decode(sig)
+		if err != nil {
+			return nil, err
+		}
+		if block.Type != openpgp.SignatureType {
+			return nil, fmt.Errorf("expected a signature, got a %s block", block.Type)
+		}
+		sig = block.Body
+	}
+	s, signer, err := openpgp.VerifyDetachedSignature(keyring, message, sig, nil)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verification{
+		Fingerprint: strings.ToUpper(fmt.Sprintf("%x", signer.PrimaryKey.Fingerprint)),
+		Created:     s.CreationTime,
+	}
+	if id := signer.PrimaryIdentity(); id != nil {
+		v.Identity = id.Name
+	}
+	return v, nil
+}
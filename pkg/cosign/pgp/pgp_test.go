@@ -0,0 +1,135 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgp
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+)
+
+const (
+	aliceFingerprint = "6206F3F0A1C40AE7305D2255F7D86216C6C5346E"
+	bobFingerprint   = "F01CCFB3C5B42DD6614F2B31E0F586E54A2017E7"
+)
+
+func readFile(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func keyRing(t *testing.T, names ...string) openpgp.EntityList {
+	t.Helper()
+	var b []byte
+	for _, name := range names {
+		b = append(b, readFile(t, name)...)
+	}
+	keyring, err := ParseKeyRing(b)
+	if err != nil {
+		t.Fatalf("ParseKeyRing() = %v", err)
+	}
+	return keyring
+}
+
+func TestVerifyGPGSignatures(t *testing.T) {
+	keyring := keyRing(t, "alice.asc", "bob.asc")
+	if len(keyring) != 2 {
+		t.Fatalf("ParseKeyRing() read %d keys, wanted 2", len(keyring))
+	}
+	message := readFile(t, "message.txt")
+
+	for sig, want := range map[string]string{
+		"message.txt.asc": aliceFingerprint, // armored
+		"message.txt.sig": bobFingerprint,   // binary
+	} {
+		v, err := Verify(keyring, readFile(t, sig), bytes.NewReader(message))
+		if err != nil {
+			t.Fatalf("Verify(%s) = %v", sig, err)
+		}
+		if v.Fingerprint != want {
+			t.Errorf("Verify(%s) signer = %s, wanted %s", sig, v.Fingerprint, want)
+		}
+		if _, err := Verify(keyring, readFile(t, sig), bytes.NewReader([]byte("something else"))); err == nil {
+			t.Errorf("Verify(%s) of another message succeeded", sig)
+		}
+	}
+
+	v, err := Verify(keyring, readFile(t, "message.txt.asc"), bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Identity != "Alice Release <alice@example.com>" {
+		t.Errorf("Verify() identity = %q", v.Identity)
+	}
+
+	// Signatures of keys outside of the keyring are not trusted.
+	_, err = Verify(keyRing(t, "bob.asc"), readFile(t, "message.txt.asc"), bytes.NewReader(message))
+	if !errors.Is(err, pgperrors.ErrUnknownIssuer) {
+		t.Errorf("Verify() with another keyring = %v", err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	keys := keyRing(t, "alice.key")
+	if _, err := SigningEntity(keys, nil); err == nil {
+		t.Error("SigningEntity() of an encrypted key without passphrase succeeded")
+	}
+	if _, err := SigningEntity(keyRing(t, "alice.key"), func() ([]byte, error) { return []byte("wrong"), nil }); err == nil {
+		t.Error("SigningEntity() with a wrong passphrase succeeded")
+	}
+	signer, err := SigningEntity(keys, func() ([]byte, error) { return []byte("hunter2"), nil })
+	if err != nil {
+		t.Fatalf("SigningEntity() = %v", err)
+	}
+	if _, err := SigningEntity(keyRing(t, "alice.asc"), nil); err == nil {
+		t.Error("SigningEntity() of a public key succeeded")
+	}
+
+	message := []byte("hello, cosign")
+	sig, err := Sign(signer, bytes.NewReader(message))
+	if err != nil {
+		t.Fatalf("Sign() = %v", err)
+	}
+	if !bytes.HasPrefix(sig, []byte("-----BEGIN PGP SIGNATURE-----")) {
+		t.Errorf("Sign() = %s, wanted an armored signature", sig)
+	}
+	v, err := Verify(keyRing(t, "alice.asc"), sig, bytes.NewReader(message))
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if v.Fingerprint != aliceFingerprint {
+		t.Errorf("Verify() signer = %s, wanted %s", v.Fingerprint, aliceFingerprint)
+	}
+}
+
+func TestParseKeyRingErrors(t *testing.T) {
+	for _, b := range [][]byte{
+		nil,
+		[]byte("not a key"),
+		readFile(t, "message.txt.asc"),
+	} {
+		if _, err := ParseKeyRing(b); err == nil {
+			t.Errorf("ParseKeyRing(%q) succeeded", b)
+		}
+	}
+}
@@ -44,6 +44,8 @@ func (k *Key) Authenticate(pin string) {} //nolint: revive
 
 func (k *Key) SetSlot(slot string) {} //nolint: revive
 
+func (k *Key) SetSkipAttestation(skip bool) {} //nolint: revive
+
 func (k *Key) Attest() (*x509.Certificate, error) {
 	return nil, errors.New("unimplemented")
 }
@@ -76,6 +78,14 @@ func (k *Key) GenerateKey(mgmtKey [24]byte, slot *empty, opts *empty) (*empty, e
 	return nil, errors.New("unimplemented")
 }
 
+func (k *Key) GenerateEphemeralKey() error {
+	return errors.New("unimplemented")
+}
+
+func (k *Key) SetSelfSignedCertificate(mgmtKey [24]byte, slot *empty, pub *empty, pinPolicy *empty) error { //nolint
+	return errors.New("unimplemented")
+}
+
 func (k *Key) Verifier() (signature.Verifier, error) {
 	return nil, errors.New("unimplemented")
 }
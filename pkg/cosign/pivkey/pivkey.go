@@ -25,15 +25,19 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/go-piv/piv-go/piv"
 	"golang.org/x/term"
 
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
 	"github.com/franchb/sigstore/pkg/signature"
 )
 
@@ -49,6 +53,9 @@ type Key struct {
 	card *piv.YubiKey
 	slot *piv.Slot
 	pin  string
+	// skipAttestation reads public keys from slot certificates instead of
+	// YubiKey attestations, for PIV devices from other vendors
+	skipAttestation bool
 }
 
 func GetKey() (*Key, error) {
@@ -66,7 +73,8 @@ func GetKey() (*Key, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Key{card: yk}, nil
+	skipAttestation := env.Getenv(env.VariablePIVSkipAttestation) == "1"
+	return &Key{card: yk, skipAttestation: skipAttestation}, nil
 }
 
 func GetKeyWithSlot(slot string) (*Key, error) {
@@ -97,6 +105,29 @@ func (k *Key) SetSlot(slot string) {
 	k.slot = SlotForName(slot)
 }
 
+// SetSkipAttestation sets whether the key is used without YubiKey
+// attestations, reading its public key from the certificate of its slot.
+func (k *Key) SetSkipAttestation(skip bool) {
+	k.skipAttestation = skip
+}
+
+// slotPublicKey returns the public key of the slot, from its attestation or,
+// when attestation is skipped, from the certificate stored in the slot.
+func (k *Key) slotPublicKey() (crypto.PublicKey, error) {
+	if k.skipAttestation {
+		cert, err := k.card.Certificate(*k.slot)
+		if err != nil {
+			return nil, fmt.Errorf("get slot certificate: %w", err)
+		}
+		return cert.PublicKey, nil
+	}
+	cert, err := k.card.Attest(*k.slot)
+	if err != nil {
+		return nil, err
+	}
+	return cert.PublicKey, nil
+}
+
 func (k *Key) Attest() (*x509.Certificate, error) {
 	if k.card == nil {
 		return nil, KeyNotInitialized
@@ -161,6 +192,89 @@ func (k *Key) GenerateKey(mgmtKey [24]byte, slot piv.Slot, opts piv.Key) (crypto
 	return k.card.GenerateKey(mgmtKey, slot, opts)
 }
 
+// GenerateEphemeralKey replaces the key in the slot with a new ECDSA P-256
+// key generated on the device, so that a certificate issued for keyless
+// signing binds a key that never leaves it. The device is authenticated with
+// the management key in COSIGN_PIV_MANAGEMENT_KEY, or the default one.
+func (k *Key) GenerateEphemeralKey() error {
+	if k.card == nil {
+		return KeyNotInitialized
+	}
+	if k.slot == nil {
+		return SlotNotSet
+	}
+
+	mgmtKey := piv.DefaultManagementKey
+	if v := env.Getenv(env.VariablePIVManagementKey); v != "" {
+		if len(v) > len(mgmtKey) {
+			return fmt.Errorf("%s is too long, must be at most %d characters", env.VariablePIVManagementKey, len(mgmtKey))
+		}
+		mgmtKey = [24]byte{}
+		copy(mgmtKey[:], v)
+	}
+	pinPolicy := PINPolicyForName("", *k.slot)
+	pub, err := k.card.GenerateKey(mgmtKey, *k.slot, piv.Key{
+		Algorithm:   piv.AlgorithmEC256,
+		PINPolicy:   pinPolicy,
+		TouchPolicy: TouchPolicyForName("", *k.slot),
+	})
+	if err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+	if k.skipAttestation {
+		if err := k.SetSelfSignedCertificate(mgmtKey, *k.slot, pub, pinPolicy); err != nil {
+			return fmt.Errorf("store certificate of generated key: %w", err)
+		}
+	}
+	k.Pub = pub
+	return nil
+}
+
+// SetSelfSignedCertificate stores a self-signed certificate for the key in
+// the slot, so that devices without attestation can read its public key back.
+func (k *Key) SetSelfSignedCertificate(mgmtKey [24]byte, slot piv.Slot, pub crypto.PublicKey, pinPolicy piv.PINPolicy) error {
+	if k.card == nil {
+		return KeyNotInitialized
+	}
+
+	auth := piv.KeyAuth{PINPolicy: pinPolicy}
+	if k.pin == "" {
+		auth.PINPrompt = getPin
+	} else {
+		auth.PIN = k.pin
+	}
+	priv, err := k.card.PrivateKey(slot, pub, auth)
+	if err != nil {
+		return err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return errors.New("private key is not a signer")
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "cosign"},
+		NotBefore:    now,
+		NotAfter:     now.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, signer)
+	if err != nil {
+		return fmt.Errorf("create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	return k.card.SetCertificate(mgmtKey, slot, cert)
+}
+
 func (k *Key) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
 	return k.Pub, nil
 }
@@ -176,11 +290,19 @@ func (k *Key) VerifySignature(signature, message io.Reader, opts ...signature.Ve
 	}
 	digest := sha256.Sum256(msg)
 
-	att, err := k.Attest()
-	if err != nil {
-		return fmt.Errorf("get attestation: %w", err)
+	pub := k.Pub
+	if pub == nil {
+		if k.card == nil {
+			return KeyNotInitialized
+		}
+		if k.slot == nil {
+			return SlotNotSet
+		}
+		if pub, err = k.slotPublicKey(); err != nil {
+			return fmt.Errorf("get public key: %w", err)
+		}
 	}
-	switch kt := att.PublicKey.(type) {
+	switch kt := pub.(type) {
 	case *ecdsa.PublicKey:
 		if ecdsa.VerifyASN1(kt, digest[:], sig) {
 			return nil
@@ -190,7 +312,7 @@ func (k *Key) VerifySignature(signature, message io.Reader, opts ...signature.Ve
 		return rsa.VerifyPKCS1v15(kt, crypto.SHA256, digest[:], sig)
 	}
 
-	return fmt.Errorf("unsupported key type: %T", att.PublicKey)
+	return fmt.Errorf("unsupported key type: %T", pub)
 }
 
 func getPin() (string, error) {
@@ -212,11 +334,11 @@ func (k *Key) Verifier() (signature.Verifier, error) {
 	if k.slot == nil {
 		return nil, SlotNotSet
 	}
-	cert, err := k.card.Attest(*k.slot)
+	pub, err := k.slotPublicKey()
 	if err != nil {
 		return nil, err
 	}
-	k.Pub = cert.PublicKey
+	k.Pub = pub
 
 	return k, nil
 }
@@ -239,11 +361,11 @@ func (k *Key) SignerVerifier() (signature.SignerVerifier, error) {
 	if k.slot == nil {
 		return nil, SlotNotSet
 	}
-	cert, err := k.card.Attest(*k.slot)
+	pub, err := k.slotPublicKey()
 	if err != nil {
 		return nil, err
 	}
-	k.Pub = cert.PublicKey
+	k.Pub = pub
 
 	var auth piv.KeyAuth
 	if k.pin == "" {
@@ -251,7 +373,11 @@ func (k *Key) SignerVerifier() (signature.SignerVerifier, error) {
 	} else {
 		auth.PIN = k.pin
 	}
-	privKey, err := k.card.PrivateKey(*k.slot, cert.PublicKey, auth)
+	if k.skipAttestation {
+		// piv-go otherwise reads the PIN policy from the attestation.
+		auth.PINPolicy = piv.PINPolicyAlways
+	}
+	privKey, err := k.card.PrivateKey(*k.slot, pub, auth)
 	if err != nil {
 		return nil, err
 	}
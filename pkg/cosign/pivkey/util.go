@@ -18,6 +18,9 @@
 package pivkey
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/go-piv/piv-go/piv"
 )
 
@@ -34,10 +37,27 @@ func SlotForName(slotName string) *piv.Slot {
 	case "key-management":
 		return &piv.SlotKeyManagement
 	default:
+		if slot, ok := retiredSlotForName(slotName); ok {
+			return &slot
+		}
 		return nil
 	}
 }
 
+// retiredSlotForName returns the retired key management slot named
+// retired-82 to retired-95, after its key reference.
+func retiredSlotForName(slotName string) (piv.Slot, bool) {
+	ref, ok := strings.CutPrefix(slotName, "retired-")
+	if !ok {
+		return piv.Slot{}, false
+	}
+	key, err := strconv.ParseUint(ref, 16, 32)
+	if err != nil {
+		return piv.Slot{}, false
+	}
+	return piv.RetiredKeyManagementSlot(uint32(key))
+}
+
 func PINPolicyForName(policyName string, slot piv.Slot) piv.PINPolicy {
 	switch policyName {
 	case "":
@@ -73,6 +93,9 @@ func defaultPINPolicyForSlot(slot piv.Slot) piv.PINPolicy {
 	// Defaults from https://developers.yubico.com/PIV/Introduction/Certificate_slots.html
 	//
 
+	if _, ok := piv.RetiredKeyManagementSlot(slot.Key); ok {
+		slot = piv.SlotKeyManagement
+	}
 	switch slot {
 	case piv.SlotAuthentication:
 		return piv.PINPolicyOnce
@@ -93,6 +116,9 @@ func defaultTouchPolicyForSlot(slot piv.Slot) piv.TouchPolicy {
 	// Defaults from https://developers.yubico.com/PIV/Introduction/Certificate_slots.html
 	//
 
+	if _, ok := piv.RetiredKeyManagementSlot(slot.Key); ok {
+		slot = piv.SlotKeyManagement
+	}
 	switch slot {
 	case piv.SlotAuthentication:
 		return piv.TouchPolicyCached
@@ -0,0 +1,67 @@
+//go:build pivkey && cgo
+// +build pivkey,cgo
+
+// Copyright 2024 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pivkey
+
+import (
+	"testing"
+
+	"github.com/go-piv/piv-go/piv"
+)
+
+func TestSlotForName(t *testing.T) {
+	retired82, _ := piv.RetiredKeyManagementSlot(0x82)
+	retired95, _ := piv.RetiredKeyManagementSlot(0x95)
+	tests := []struct {
+		name string
+		want *piv.Slot
+	}{
+		{name: "", want: &piv.SlotSignature},
+		{name: "key-management", want: &piv.SlotKeyManagement},
+		{name: "retired-82", want: &retired82},
+		{name: "retired-95", want: &retired95},
+		{name: "retired-81"},
+		{name: "retired-96"},
+		{name: "retired-xx"},
+		{name: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SlotForName(tt.name)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("SlotForName(%q) = %v, wanted nil", tt.name, got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Errorf("SlotForName(%q) = %v, wanted %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetiredSlotPolicies(t *testing.T) {
+	slot := SlotForName("retired-8a")
+	if slot == nil {
+		t.Fatal("SlotForName(retired-8a) = nil")
+	}
+	// Retired slots default to the policies of the key management slot.
+	if got := PINPolicyForName("", *slot); got != piv.PINPolicyOnce {
+		t.Errorf("PINPolicyForName() = %v, wanted %v", got, piv.PINPolicyOnce)
+	}
+	if got := TouchPolicyForName("", *slot); got != piv.TouchPolicyCached {
+		t.Errorf("TouchPolicyForName() = %v, wanted %v", got, piv.TouchPolicyCached)
+	}
+}
@@ -37,6 +37,14 @@ func GetKeyWithURIConfig(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*Key,
 	return nil, errors.New("unimplemented")
 }
 
+func GenerateKeyWithURIConfig(config *Pkcs11UriConfig) (*Key, error) { //nolint: revive
+	return nil, errors.New("unimplemented")
+}
+
+func (k *Key) RSAPSS() bool {
+	return false
+}
+
 func (k *Key) Certificate() (*x509.Certificate, error) {
 	return nil, errors.New("unimplemented")
 }
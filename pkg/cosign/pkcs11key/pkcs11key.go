@@ -21,6 +21,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -33,7 +34,9 @@ import (
 	"syscall"
 
 	"github.com/ThalesIgnite/crypto11"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/franchb/sigstore/pkg/signature"
 	"github.com/miekg/pkcs11"
 	"golang.org/x/term"
@@ -49,9 +52,122 @@ type Key struct {
 	ctx    *crypto11.Context
 	signer crypto.Signer
 	cert   *x509.Certificate
+	// pss signs with RSA-PSS rather than PKCS #1 v1.5
+	pss bool
 }
 
 func GetKeyWithURIConfig(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*Key, error) {
+	ctx, err := configure(config, askForPinIfNeeded)
+	if err != nil {
+		return nil, err
+	}
+
+	// If both keyID and keyLabel are set, keyID has priority.
+	var signer crypto11.Signer
+	if len(config.KeyID) != 0 {
+		signer, err = ctx.FindKeyPair(config.KeyID, nil)
+	} else if len(config.KeyLabel) != 0 {
+		signer, err = ctx.FindKeyPair(nil, config.KeyLabel)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signer == nil {
+		return nil, errors.New("key not found in PKCS11 token")
+	}
+
+	pss := config.RSAScheme == cosign.RSASchemePSS
+	switch pub := signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		// P-256, P-384 and P-521 keys all sign SHA-256 digests, which is what
+		// cosign verifies ECDSA signatures with by default.
+		if err := cryptoutils.ValidatePubKey(pub); err != nil {
+			return nil, fmt.Errorf("unsupported ecdsa key: %w", err)
+		}
+		if pss {
+			return nil, errors.New("x-rsa-scheme is only supported for RSA keys")
+		}
+	case *rsa.PublicKey:
+		if err := cryptoutils.ValidatePubKey(pub); err != nil {
+			return nil, fmt.Errorf("unsupported rsa key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T", pub)
+	}
+
+	// Key's corresponding cert might not exist,
+	// therefore, we do not fail if it is the case.
+	var cert *x509.Certificate
+
+	ignoreCert := env.Getenv(env.VariablePKCS11IgnoreCertificate) == "1"
+
+	if !ignoreCert {
+		if len(config.KeyID) != 0 {
+			cert, _ = ctx.FindCertificate(config.KeyID, nil, nil)
+		} else if len(config.KeyLabel) != 0 {
+			cert, _ = ctx.FindCertificate(nil, config.KeyLabel, nil)
+		}
+	}
+
+	return &Key{ctx: ctx, signer: signer, cert: cert, pss: pss}, nil
+}
+
+// GenerateKeyWithURIConfig replaces the key pair of config with a new ECDSA
+// P-256 key pair generated on the token, so that a certificate issued for
+// keyless signing binds a key that never leaves it. The previous key pair
+// and its certificate are deleted.
+func GenerateKeyWithURIConfig(config *Pkcs11UriConfig) (*Key, error) {
+	if config.RSAScheme == cosign.RSASchemePSS {
+		return nil, errors.New("x-rsa-scheme is only supported for RSA keys")
+	}
+	ctx, err := configure(config, true)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := generateKeyPair(ctx, config)
+	if err != nil {
+		ctx.Close()
+		return nil, err
+	}
+	return &Key{ctx: ctx, signer: signer}, nil
+}
+
+func generateKeyPair(ctx *crypto11.Context, config *Pkcs11UriConfig) (crypto11.Signer, error) {
+	// Like GetKeyWithURIConfig, keyID has priority over keyLabel.
+	id, label := config.KeyID, config.KeyLabel
+	if len(id) != 0 {
+		label = nil
+	}
+	previous, err := ctx.FindKeyPairs(id, label)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range previous {
+		if err := p.Delete(); err != nil {
+			return nil, fmt.Errorf("delete previous key: %w", err)
+		}
+	}
+	if err := ctx.DeleteCertificate(id, label, nil); err != nil {
+		return nil, fmt.Errorf("delete previous certificate: %w", err)
+	}
+
+	id = config.KeyID
+	if len(id) == 0 {
+		// Key pairs are only found by label if they have an ID.
+		id = make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.KeyLabel) == 0 {
+		return ctx.GenerateECDSAKeyPair(id, elliptic.P256())
+	}
+	return ctx.GenerateECDSAKeyPairWithLabel(id, config.KeyLabel, elliptic.P256())
+}
+
+// configure opens the token of config, asking for its PIN if needed and
+// askForPinIfNeeded is set.
+func configure(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*crypto11.Context, error) {
 	conf := &crypto11.Config{
 		Path: config.ModulePath,
 		Pin:  config.Pin,
@@ -62,9 +178,9 @@ func GetKeyWithURIConfig(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*Key,
 		return nil, errors.New("one of keyLabel and keyID must be set")
 	}
 
-	// At least one of token and slot-id must be specified.
-	if config.TokenLabel == "" && config.SlotID == nil {
-		return nil, errors.New("one of token and slot id must be set")
+	// At least one of token, serial and slot-id must be specified.
+	if config.TokenLabel == "" && config.TokenSerial == "" && config.SlotID == nil {
+		return nil, errors.New("one of token, serial and slot id must be set")
 	}
 
 	// modulePath must be specified and must point to the absolute path of the PKCS11 module.
@@ -117,7 +233,7 @@ func GetKeyWithURIConfig(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*Key,
 						if err != nil {
 							return fmt.Errorf("get token info: %w", err)
 						}
-						if currentTokenInfo.Label == config.TokenLabel {
+						if matchesToken(currentTokenInfo, config) {
 							tokenInfo = currentTokenInfo
 							bTokenFound = true
 							break
@@ -125,12 +241,12 @@ func GetKeyWithURIConfig(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*Key,
 					}
 
 					if !bTokenFound {
-						return fmt.Errorf("could not find a slot for the token '%s'", config.TokenLabel)
+						return fmt.Errorf("could not find a slot for the token '%s'", tokenName(config))
 					}
 				}
 
 				if tokenInfo.Flags&pkcs11.CKF_LOGIN_REQUIRED == pkcs11.CKF_LOGIN_REQUIRED {
-					fmt.Fprintf(os.Stderr, "Enter PIN for key '%s' in PKCS11 token '%s': ", config.KeyLabel, config.TokenLabel)
+					fmt.Fprintf(os.Stderr, "Enter PIN for key '%s' in PKCS11 token '%s': ", config.KeyLabel, tokenName(config))
 					// Unnecessary convert of syscall.Stdin on *nix, but Windows is a uintptr
 					// nolint:unconvert
 					b, err := term.ReadPassword(int(syscall.Stdin))
@@ -150,45 +266,48 @@ func GetKeyWithURIConfig(config *Pkcs11UriConfig, askForPinIfNeeded bool) (*Key,
 		}
 	}
 
-	// We must set one SlotID or tokenLabel, never both.
-	// SlotID has priority over tokenLabel.
-	if config.SlotID != nil {
+	// We must set one of SlotID, tokenLabel and tokenSerial, never more.
+	// SlotID has priority over tokenLabel, which has priority over tokenSerial.
+	switch {
+	case config.SlotID != nil:
 		conf.SlotNumber = config.SlotID
-	} else if config.TokenLabel != "" {
+	case config.TokenLabel != "":
 		conf.TokenLabel = config.TokenLabel
+	default:
+		conf.TokenSerial = config.TokenSerial
 	}
 
-	ctx, err := crypto11.Configure(conf)
-	if err != nil {
-		return nil, err
-	}
+	return crypto11.Configure(conf)
+}
 
-	// If both keyID and keyLabel are set, keyID has priority.
-	var signer crypto11.Signer
-	if len(config.KeyID) != 0 {
-		signer, err = ctx.FindKeyPair(config.KeyID, nil)
-	} else if len(config.KeyLabel) != 0 {
-		signer, err = ctx.FindKeyPair(nil, config.KeyLabel)
-	}
-	if err != nil {
-		return nil, err
+// matchesToken reports whether the token described by tokenInfo is selected
+// by the token label or serial number of config.
+func matchesToken(tokenInfo pkcs11.TokenInfo, config *Pkcs11UriConfig) bool {
+	if config.TokenLabel != "" {
+		return tokenInfo.Label == config.TokenLabel
 	}
+	return tokenInfo.SerialNumber == config.TokenSerial
+}
 
-	// Key's corresponding cert might not exist,
-	// therefore, we do not fail if it is the case.
-	var cert *x509.Certificate
+// tokenName names the token selected by config in messages.
+func tokenName(config *Pkcs11UriConfig) string {
+	if config.TokenLabel != "" {
+		return config.TokenLabel
+	}
+	return config.TokenSerial
+}
 
-	ignoreCert := env.Getenv(env.VariablePKCS11IgnoreCertificate) == "1"
+// RSAPSS reports whether the key signs with RSA-PSS.
+func (k *Key) RSAPSS() bool {
+	return k.pss
+}
 
-	if !ignoreCert {
-		if len(config.KeyID) != 0 {
-			cert, _ = ctx.FindCertificate(config.KeyID, nil, nil)
-		} else if len(config.KeyLabel) != 0 {
-			cert, _ = ctx.FindCertificate(nil, config.KeyLabel, nil)
-		}
+// signerOpts returns the options the key signs SHA-256 digests with.
+func (k *Key) signerOpts() crypto.SignerOpts {
+	if k.pss {
+		return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
 	}
-
-	return &Key{ctx: ctx, signer: signer, cert: cert}, nil
+	return crypto.SHA256
 }
 
 func (k *Key) Certificate() (*x509.Certificate, error) {
@@ -217,6 +336,9 @@ func (k *Key) VerifySignature(signature, message io.Reader, opts ...signature.Ve
 		}
 		return errors.New("invalid ecdsa signature")
 	case *rsa.PublicKey:
+		if k.pss {
+			return rsa.VerifyPSS(kt, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
 		return rsa.VerifyPKCS1v15(kt, crypto.SHA256, digest[:], sig)
 	}
 
@@ -235,7 +357,7 @@ func (k *Key) Verifier() (signature.Verifier, error) {
 
 func (k *Key) Sign(ctx context.Context, rawPayload []byte) ([]byte, []byte, error) {
 	h := sha256.Sum256(rawPayload)
-	sig, err := k.signer.Sign(rand.Reader, h[:], crypto.SHA256)
+	sig, err := k.signer.Sign(rand.Reader, h[:], k.signerOpts())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -247,7 +369,7 @@ func (k *Key) SignMessage(message io.Reader, opts ...signature.SignOption) ([]by
 	if _, err := io.Copy(h, message); err != nil {
 		return nil, err
 	}
-	sig, err := k.signer.Sign(rand.Reader, h.Sum(nil), crypto.SHA256)
+	sig, err := k.signer.Sign(rand.Reader, h.Sum(nil), k.signerOpts())
 	if err != nil {
 		return nil, err
 	}
@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
 )
 
@@ -83,12 +84,16 @@ type Pkcs11UriConfig struct {
 	uriPathAttributes  url.Values
 	uriQueryAttributes url.Values
 
-	ModulePath string
-	SlotID     *int
-	TokenLabel string
-	KeyLabel   []byte
-	KeyID      []byte
-	Pin        string
+	ModulePath  string
+	SlotID      *int
+	TokenLabel  string
+	TokenSerial string
+	KeyLabel    []byte
+	KeyID       []byte
+	Pin         string
+	// RSAScheme is the signature scheme of RSA keys, cosign.RSASchemePKCS1v15
+	// if empty. It is set by the vendor specific "x-rsa-scheme" query attribute.
+	RSAScheme string
 }
 
 func NewPkcs11UriConfig() *Pkcs11UriConfig {
@@ -138,14 +143,22 @@ func (conf *Pkcs11UriConfig) Parse(uriString string) error {
 	}
 	modulePath := uriQueryAttributes.Get("module-path")
 	pinValue := uriQueryAttributes.Get("pin-value")
+	rsaScheme := uriQueryAttributes.Get("x-rsa-scheme")
 	tokenLabel := uriPathAttributes.Get("token")
+	tokenSerial := uriPathAttributes.Get("serial")
 	slotIDStr := uriPathAttributes.Get("slot-id")
 	keyLabel := uriPathAttributes.Get("object")
 	keyID := uriPathAttributes.Get("id")
 
-	// At least one of token and slot-id must be specified.
-	if tokenLabel == "" && slotIDStr == "" {
-		return errors.New("invalid uri: one of token and slot-id must be set")
+	// At least one of token, serial and slot-id must be specified.
+	if tokenLabel == "" && tokenSerial == "" && slotIDStr == "" {
+		return errors.New("invalid uri: one of token, serial and slot-id must be set")
+	}
+
+	switch rsaScheme {
+	case "", cosign.RSASchemePKCS1v15, cosign.RSASchemePSS:
+	default:
+		return fmt.Errorf("invalid uri: x-rsa-scheme '%s' must be one of %v", rsaScheme, cosign.RSASchemes)
 	}
 
 	// slot-id, if specified, should be a number.
@@ -180,16 +193,18 @@ func (conf *Pkcs11UriConfig) Parse(uriString string) error {
 	conf.uriQueryAttributes = uriQueryAttributes
 	conf.ModulePath = modulePath
 	conf.TokenLabel = tokenLabel
+	conf.TokenSerial = tokenSerial
 	conf.SlotID = slotID
 	conf.KeyLabel = []byte(keyLabel)
 	conf.KeyID = []byte(keyID) // url.ParseQuery() already calls url.QueryUnescape() on the id, so we only need to cast the result into byte array
 	conf.Pin = pin
+	conf.RSAScheme = rsaScheme
 
 	return nil
 }
 
 func (conf *Pkcs11UriConfig) Construct() (string, error) {
-	var modulePath, pinValue, tokenLabel, slotID, keyID, keyLabel string
+	var modulePath, pinValue, tokenLabel, tokenSerial, slotID, keyID, keyLabel string
 	var err error
 
 	uriString := "pkcs11:"
@@ -204,23 +219,32 @@ func (conf *Pkcs11UriConfig) Construct() (string, error) {
 		return "", errors.New("one of keyLabel and keyID must be set")
 	}
 
-	// At least one of tokenLabel and slotID must be specified.
-	if conf.TokenLabel == "" && conf.SlotID == nil {
-		return "", errors.New("one of tokenLabel and slotID must be set")
+	// At least one of tokenLabel, tokenSerial and slotID must be specified.
+	if conf.TokenLabel == "" && conf.TokenSerial == "" && conf.SlotID == nil {
+		return "", errors.New("one of tokenLabel, tokenSerial and slotID must be set")
 	}
 
 	// Construct the URI.
+	var pathAttributes []string
 	if conf.TokenLabel != "" {
 		tokenLabel, err = EncodeURIComponent(conf.TokenLabel, true, true)
 		if err != nil {
 			return "", fmt.Errorf("encode token label: %w", err)
 		}
-		uriString += "token=" + tokenLabel
+		pathAttributes = append(pathAttributes, "token="+tokenLabel)
+	}
+	if conf.TokenSerial != "" {
+		tokenSerial, err = EncodeURIComponent(conf.TokenSerial, true, true)
+		if err != nil {
+			return "", fmt.Errorf("encode token serial: %w", err)
+		}
+		pathAttributes = append(pathAttributes, "serial="+tokenSerial)
 	}
 	if conf.SlotID != nil {
 		slotID = fmt.Sprintf("%d", *conf.SlotID)
-		uriString += ";slot-id=" + slotID
+		pathAttributes = append(pathAttributes, "slot-id="+slotID)
 	}
+	uriString += strings.Join(pathAttributes, ";")
 	if len(conf.KeyID) != 0 {
 		keyID = percentEncode(conf.KeyID)
 		uriString += ";id=" + keyID
@@ -244,6 +268,9 @@ func (conf *Pkcs11UriConfig) Construct() (string, error) {
 		}
 		uriString += "&pin-value=" + pinValue
 	}
+	if conf.RSAScheme != "" {
+		uriString += "&x-rsa-scheme=" + conf.RSAScheme
+	}
 
 	return uriString, nil
 }
@@ -0,0 +1,46 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTokenSerialAndRSAScheme(t *testing.T) {
+	conf := NewPkcs11UriConfig()
+	require.NoError(t, conf.Parse("pkcs11:serial=0123456789abcdef;object=my%20key?module-path=/usr/lib/libmodule.so&x-rsa-scheme=pss"))
+	require.Equal(t, "0123456789abcdef", conf.TokenSerial)
+	require.Empty(t, conf.TokenLabel)
+	require.Nil(t, conf.SlotID)
+	require.Equal(t, "pss", conf.RSAScheme)
+
+	uri, err := conf.Construct()
+	require.NoError(t, err)
+	require.Equal(t, "pkcs11:serial=0123456789abcdef;object=my%20key?module-path=/usr/lib/libmodule.so&x-rsa-scheme=pss", uri)
+
+	err = NewPkcs11UriConfig().Parse("pkcs11:serial=0123456789abcdef;object=key?module-path=/usr/lib/libmodule.so&x-rsa-scheme=oaep")
+	require.ErrorContains(t, err, "x-rsa-scheme")
+	err = NewPkcs11UriConfig().Parse("pkcs11:object=key?module-path=/usr/lib/libmodule.so")
+	require.EqualError(t, err, "invalid uri: one of token, serial and slot-id must be set")
+}
+
+func TestConstructSlotIDOnly(t *testing.T) {
+	slotID := 2
+	uri, err := NewPkcs11UriConfigFromInput("/usr/lib/libmodule.so", &slotID, "", []byte("key"), nil, "").Construct()
+	require.NoError(t, err)
+	require.Equal(t, "pkcs11:slot-id=2;object=key?module-path=/usr/lib/libmodule.so", uri)
+}
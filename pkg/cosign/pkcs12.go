@@ -0,0 +1,85 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ErrIncorrectBundlePassword is returned when a PKCS #12 bundle cannot be
+// opened with the password provided.
+var ErrIncorrectBundlePassword = errors.New("incorrect pkcs #12 bundle password")
+
+// decodePKCS12 returns the private key and the certificates of the PKCS #12
+// bundle der, opened with password. Bundles written by OpenSSL 3 (PBES2) and
+// those using the older PKCS #12 PBE schemes (3DES and RC2) are supported.
+func decodePKCS12(der []byte, password string) (crypto.PrivateKey, []*x509.Certificate, error) {
+	key, cert, chain, err := pkcs12.DecodeChain(der, password)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			return nil, nil, ErrIncorrectBundlePassword
+		}
+		return nil, nil, fmt.Errorf("error reading pkcs #12 bundle: %w", err)
+	}
+	return key, append([]*x509.Certificate{cert}, chain...), nil
+}
+
+// orderCertificates returns the certificate of pub in certs followed by its
+// chain, each certificate followed by its issuer where it is found in certs.
+func orderCertificates(pub crypto.PublicKey, certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	if len(certs) == 0 {
+		return nil, nil
+	}
+	k, ok := pub.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return nil, fmt.Errorf("unexpected public key type %T", pub)
+	}
+	rest := make([]*x509.Certificate, 0, len(certs))
+	var leaf *x509.Certificate
+	for _, c := range certs {
+		if leaf == nil && k.Equal(c.PublicKey) {
+			leaf = c
+			continue
+		}
+		rest = append(rest, c)
+	}
+	if leaf == nil {
+		return nil, errors.New("no certificate matches the private key")
+	}
+
+	ordered := []*x509.Certificate{leaf}
+	for len(rest) > 0 {
+		last := ordered[len(ordered)-1]
+		i := 0
+		for ; i < len(rest); i++ {
+			if bytes.Equal(rest[i].RawSubject, last.RawIssuer) {
+				break
+			}
+		}
+		if i == len(rest) {
+			// Keep certificates not part of the chain in their original order.
+			return append(ordered, rest...), nil
+		}
+		ordered = append(ordered, rest[i])
+		rest = append(rest[:i], rest[i+1:]...)
+	}
+	return ordered, nil
+}
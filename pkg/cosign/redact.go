@@ -0,0 +1,95 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RedactionMode is how a field of a verification report is redacted.
+type RedactionMode string
+
+const (
+	// RedactOmit leaves the field out of the report.
+	RedactOmit RedactionMode = "omit"
+	// RedactHash replaces the field with the hex SHA-256 digest of its value,
+	// so that a recipient who already knows the value can still match it.
+	RedactHash RedactionMode = "hash"
+)
+
+// The fields of a verification report that Redactions apply to.
+const (
+	RedactFieldSubject                  = "subject"
+	RedactFieldIssuer                   = "issuer"
+	RedactFieldGithubWorkflowTrigger    = "github-workflow-trigger"
+	RedactFieldGithubWorkflowSha        = "github-workflow-sha"
+	RedactFieldGithubWorkflowName       = "github-workflow-name"
+	RedactFieldGithubWorkflowRepository = "github-workflow-repository"
+	RedactFieldGithubWorkflowRef        = "github-workflow-ref"
+)
+
+var redactFields = []string{
+	RedactFieldSubject,
+	RedactFieldIssuer,
+	RedactFieldGithubWorkflowTrigger,
+	RedactFieldGithubWorkflowSha,
+	RedactFieldGithubWorkflowName,
+	RedactFieldGithubWorkflowRepository,
+	RedactFieldGithubWorkflowRef,
+}
+
+// Redactions maps fields of a verification report to how they are redacted.
+// Fields that are not in the map are reported as they are.
+type Redactions map[string]RedactionMode
+
+// ParseRedactions parses specs written as FIELD=MODE, such as
+// "subject=hash" or "github-workflow-trigger=omit".
+func ParseRedactions(specs []string) (Redactions, error) {
+	r := make(Redactions, len(specs))
+	for _, spec := range specs {
+		field, mode, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("redaction %q is not of the form FIELD=MODE", spec)
+		}
+		if !slices.Contains(redactFields, field) {
+			return nil, fmt.Errorf("redaction %q: unknown field %q, expected one of %s", spec, field, strings.Join(redactFields, ", "))
+		}
+		switch m := RedactionMode(mode); m {
+		case RedactOmit, RedactHash:
+			r[field] = m
+		default:
+			return nil, fmt.Errorf("redaction %q: unknown mode %q, expected %s or %s", spec, mode, RedactOmit, RedactHash)
+		}
+	}
+	return r, nil
+}
+
+// Apply returns value as field is to be reported, and false if field is to
+// be left out of the report.
+func (r Redactions) Apply(field, value string) (string, bool) {
+	switch r[field] {
+	case RedactOmit:
+		return "", false
+	case RedactHash:
+		h := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(h[:]), true
+	default:
+		return value, true
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import "testing"
+
+func TestParseRedactions(t *testing.T) {
+	r, err := ParseRedactions([]string{"subject=hash", "github-workflow-trigger=omit"})
+	if err != nil {
+		t.Fatalf("ParseRedactions() = %v", err)
+	}
+	if got, ok := r.Apply(RedactFieldSubject, "jdoe@example.com"); !ok || got != "sha256:a8af8341993604f29cd4e0e5a5a4b5d48c575436c38b28abbfd7d481f345d5db" {
+		t.Errorf("Apply(subject) = %q, %v", got, ok)
+	}
+	if _, ok := r.Apply(RedactFieldGithubWorkflowTrigger, "push"); ok {
+		t.Error("Apply(github-workflow-trigger) kept an omitted field")
+	}
+	if got, ok := r.Apply(RedactFieldIssuer, "https://accounts.example.com"); !ok || got != "https://accounts.example.com" {
+		t.Errorf("Apply(issuer) = %q, %v, wanted it unchanged", got, ok)
+	}
+
+	for _, spec := range []string{"subject", "email=hash", "subject=mask"} {
+		if _, err := ParseRedactions([]string{spec}); err == nil {
+			t.Errorf("ParseRedactions(%q) succeeded", spec)
+		}
+	}
+}
@@ -16,6 +16,7 @@
 package remote
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"os"
@@ -94,6 +95,10 @@ func (f *file) String() string {
 type MediaTypeGetter func(b []byte) types.MediaType
 
 func DefaultMediaTypeGetter(b []byte) types.MediaType {
+	// http.DetectContentType recognizes gzip but not zstd.
+	if bytes.HasPrefix(b, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		return "application/zstd"
+	}
 	return types.MediaType(strings.Split(http.DetectContentType(b), ";")[0])
 }
 
@@ -28,6 +28,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/registry"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
 func TestFilesFromFlagList(t *testing.T) {
@@ -310,3 +311,30 @@ func checkAnnotations(t *testing.T, ref name.Reference, annotations map[string]s
 	}
 	return false
 }
+
+func TestDefaultMediaTypeGetter(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want types.MediaType
+	}{{
+		name: "text",
+		b:    []byte("hello"),
+		want: "text/plain",
+	}, {
+		name: "gzip",
+		b:    []byte{0x1f, 0x8b, 0x08, 0x00},
+		want: "application/x-gzip",
+	}, {
+		name: "zstd",
+		b:    []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00},
+		want: "application/zstd",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DefaultMediaTypeGetter(tc.b); got != tc.want {
+				t.Errorf("DefaultMediaTypeGetter() = %s, wanted %s", got, tc.want)
+			}
+		})
+	}
+}
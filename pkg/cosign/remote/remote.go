@@ -34,12 +34,30 @@ func NewDupeDetector(v signature.Verifier) mutate.DupeDetector {
 	return &dd{verifier: v}
 }
 
+// NewPayloadDupeDetector is like NewDupeDetector, but ignores the annotations
+// that cosign records anew each time it signs (certificate, chain, tlog bundle
+// and RFC3161 timestamp), so that signing an unchanged payload again with the
+// same key is detected as a duplicate.
+func NewPayloadDupeDetector(v signature.Verifier) mutate.DupeDetector {
+	return &dd{
+		verifier: v,
+		ignore: map[string]bool{
+			static.CertificateAnnotationKey:      true,
+			static.ChainAnnotationKey:            true,
+			static.BundleAnnotationKey:           true,
+			static.RFC3161TimestampAnnotationKey: true,
+		},
+	}
+}
+
 func NewReplaceOp(predicateURI string) mutate.ReplaceOp {
 	return &ro{predicateURI: predicateURI}
 }
 
 type dd struct {
 	verifier signature.Verifier
+	// ignore holds annotations that may differ between duplicates.
+	ignore map[string]bool
 }
 
 type ro struct {
@@ -80,6 +98,9 @@ LayerLoop:
 			if a == static.SignatureAnnotationKey {
 				continue // Ignore the signature key, we check it with custom logic below.
 			}
+			if dd.ignore[a] {
+				continue
+			}
 			if val, ok := existingAnnotations[a]; !ok || val != value {
 				continue LayerLoop
 			}
@@ -0,0 +1,169 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// CachedResult is the outcome of a successful verification.
+type CachedResult struct {
+	// Signatures are the signatures or attestations that verified.
+	Signatures []oci.Signature
+	// BundleVerified is whether a tlog bundle was verified.
+	BundleVerified bool
+	// VerifiedAt is when the verification was performed.
+	VerifiedAt time.Time
+}
+
+// VerificationResultCache stores successful verifications keyed by the image
+// digest and policy they were made for. Implementations must be safe for
+// concurrent use and must not return entries that have expired.
+type VerificationResultCache interface {
+	// Get returns the unexpired result stored under key, if any.
+	Get(key string) (*CachedResult, bool)
+	// Add stores r under key, replacing any previous result.
+	Add(key string, r *CachedResult)
+}
+
+// NewMemoryResultCache returns a VerificationResultCache that holds results in
+// memory for ttl after they were verified.
+func NewMemoryResultCache(ttl time.Duration) VerificationResultCache {
+	return &memoryResultCache{
+		ttl:     ttl,
+		now:     time.Now,
+		results: make(map[string]*CachedResult),
+	}
+}
+
+type memoryResultCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	results map[string]*CachedResult
+}
+
+var _ VerificationResultCache = (*memoryResultCache)(nil)
+
+// Get implements VerificationResultCache
+func (c *memoryResultCache) Get(key string) (*CachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.results[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(r.VerifiedAt) > c.ttl {
+		delete(c.results, key)
+		return nil, false
+	}
+	return r, true
+}
+
+// Add implements VerificationResultCache
+func (c *memoryResultCache) Add(key string, r *CachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[key] = r
+}
+
+// staleSignature is an oci.Signature served from a VerificationResultCache
+// because the registry could not be reached to verify it again.
+type staleSignature struct {
+	ociSignature
+}
+
+// IsStale reports whether sig was served from CheckOpts.ResultCache instead
+// of being verified against the registry, see CheckOpts.StaleIfUnavailable.
+func IsStale(sig oci.Signature) bool {
+	_, ok := sig.(*staleSignature)
+	return ok
+}
+
+// resultCacheKey returns the key results for ref are cached under, or the
+// empty string if they must not be cached. Only references by digest are
+// cached, since a tag cannot be resolved while the registry is unavailable.
+func resultCacheKey(kind string, ref name.Reference, co *CheckOpts) string {
+	if co.ResultCache == nil {
+		return ""
+	}
+	return verificationKey(kind, ref, co)
+}
+
+// verificationKey identifies the verification of kind of ref against
+// co.PolicyKey, or is the empty string if ref is not a digest.
+func verificationKey(kind string, ref name.Reference, co *CheckOpts) string {
+	d, ok := ref.(name.Digest)
+	if !ok {
+		return ""
+	}
+	return kind + "\x00" + d.String() + "\x00" + co.PolicyKey
+}
+
+// verifyWithResultCache runs verify, recording successful results in
+// co.ResultCache. If verify fails because the registry is unavailable and
+// co.StaleIfUnavailable is set, a cached result is returned instead, with
+// every signature flagged by IsStale.
+func verifyWithResultCache(ctx context.Context, kind string, ref name.Reference, co *CheckOpts, verify func() ([]oci.Signature, bool, error)) ([]oci.Signature, bool, error) {
+	key := resultCacheKey(kind, ref, co)
+	if key == "" {
+		return verify()
+	}
+	verified, bundleVerified, err := verify()
+	if err == nil {
+		co.ResultCache.Add(key, &CachedResult{
+			Signatures:     verified,
+			BundleVerified: bundleVerified,
+			VerifiedAt:     time.Now(),
+		})
+		return verified, bundleVerified, nil
+	}
+	if !co.StaleIfUnavailable || !isRegistryUnavailable(err) {
+		return nil, false, err
+	}
+	cached, ok := co.ResultCache.Get(key)
+	if !ok {
+		return nil, false, err
+	}
+	ui.Warnf(ctx, "registry unavailable (%v), using %s of %s verified at %s", err, kind, ref, cached.VerifiedAt.Format(time.RFC3339))
+	stale := make([]oci.Signature, 0, len(cached.Signatures))
+	for _, sig := range cached.Signatures {
+		stale = append(stale, &staleSignature{ociSignature: sig})
+	}
+	return stale, cached.BundleVerified, nil
+}
+
+// isRegistryUnavailable reports whether err shows that the registry could
+// not be reached or failed to serve the request, as opposed to answering
+// that verification inputs are missing or invalid.
+func isRegistryUnavailable(err error) bool {
+	if terr := (&transport.Error{}); errors.As(err, &terr) {
+		return terr.StatusCode >= http.StatusInternalServerError || terr.StatusCode == http.StatusTooManyRequests
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr)
+}
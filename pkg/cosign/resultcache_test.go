@@ -0,0 +1,120 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryResultCacheExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := NewMemoryResultCache(time.Hour).(*memoryResultCache)
+	c.now = func() time.Time { return now }
+
+	c.Add("k", &CachedResult{VerifiedAt: now})
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("Get() missed a fresh entry")
+	}
+	if _, ok := c.Get("other"); ok {
+		t.Fatal("Get() found an entry that was never added")
+	}
+	now = now.Add(time.Hour + time.Second)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("Get() returned an expired entry")
+	}
+}
+
+func TestIsRegistryUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "server error",
+		err:  fmt.Errorf("fetching: %w", &transport.Error{StatusCode: http.StatusBadGateway}),
+		want: true,
+	}, {
+		name: "rate limited",
+		err:  &transport.Error{StatusCode: http.StatusTooManyRequests},
+		want: true,
+	}, {
+		name: "not found",
+		err:  &transport.Error{StatusCode: http.StatusNotFound},
+	}, {
+		name: "verification failure",
+		err:  errors.New("no matching signatures"),
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRegistryUnavailable(tc.err); got != tc.want {
+				t.Errorf("isRegistryUnavailable() = %v, wanted %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyImageSignaturesStaleIfUnavailable(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	digest, verifier := writeSignedTestImage(t, s, nil)
+
+	cache := NewMemoryResultCache(time.Hour)
+	co := &CheckOpts{
+		SigVerifier:        verifier,
+		IgnoreTlog:         true,
+		ClaimVerifier:      SimpleClaimVerifier,
+		ResultCache:        cache,
+		PolicyKey:          "policy-a",
+		StaleIfUnavailable: true,
+	}
+	verified, _, err := VerifyImageSignatures(context.Background(), digest, co)
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+	require.False(t, IsStale(verified[0]))
+
+	s.Close()
+
+	verified, _, err = VerifyImageSignatures(context.Background(), digest, co)
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+	require.True(t, IsStale(verified[0]))
+
+	// The cached result is only for the policy it was verified against.
+	other := *co
+	other.PolicyKey = "policy-b"
+	_, _, err = VerifyImageSignatures(context.Background(), digest, &other)
+	require.Error(t, err)
+
+	// Strict mode is the default.
+	strict := *co
+	strict.StaleIfUnavailable = false
+	_, _, err = VerifyImageSignatures(context.Background(), digest, &strict)
+	require.Error(t, err)
+
+	// Attestations are cached separately from signatures.
+	_, _, err = VerifyImageAttestations(context.Background(), digest, co)
+	require.Error(t, err)
+}
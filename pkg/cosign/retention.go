@@ -0,0 +1,133 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+)
+
+// RetentionPolicy declares the attestations of the images of a repository
+// that are kept as audit evidence, which cosign clean refuses to remove
+// unless forced to.
+type RetentionPolicy struct {
+	// Repositories holds patterns, in the syntax of path.Match, that the
+	// repository must match for the policy to apply, e.g. "ghcr.io/org/*".
+	// Empty applies it to any repository.
+	Repositories []string `json:"repositories,omitempty"`
+	// PredicateTypes holds the predicate types of the attestations to keep.
+	// Empty keeps every attestation.
+	PredicateTypes []string `json:"predicateTypes,omitempty"`
+}
+
+// LoadRetentionPolicy reads the RetentionPolicy in the JSON file at path.
+func LoadRetentionPolicy(path string) (*RetentionPolicy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &RetentionPolicy{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("parsing retention policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Retains reports whether the policy keeps att, an attestation of an image
+// of repo. A nil policy keeps nothing.
+func (p *RetentionPolicy) Retains(repo name.Repository, att oci.Signature) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+	applies := len(p.Repositories) == 0
+	for _, pattern := range p.Repositories {
+		ok, err := path.Match(pattern, repo.Name())
+		if err != nil {
+			return false, fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		}
+		applies = applies || ok
+	}
+	if !applies {
+		return false, nil
+	}
+	if len(p.PredicateTypes) == 0 {
+		return true, nil
+	}
+	st, err := statementHeader(att)
+	if err != nil {
+		return false, fmt.Errorf("decoding attestation: %w", err)
+	}
+	return slices.Contains(p.PredicateTypes, st.PredicateType), nil
+}
+
+// AttestationReference is an attestation whose in-toto statement names a
+// digest as one of its subjects.
+type AttestationReference struct {
+	// Tag is the tag holding the attestation.
+	Tag name.Tag
+	// Digest is the digest of the layer of the attestation.
+	Digest v1.Hash
+}
+
+// ListAttestationReferences reads the attestations stored for the image
+// manifests of repo, see ociremote.ListAttestationTags, and returns the ones
+// naming each digest as a subject. Attestations name one another this way,
+// such as an attestation stored for the image holding another, and removing
+// the one named destroys evidence that the other one relies on.
+//
+// Attestations that are not in-toto statements name no subjects.
+func ListAttestationReferences(repo name.Repository, opts ...ociremote.Option) (map[v1.Hash][]AttestationReference, error) {
+	tags, err := ociremote.ListAttestationTags(repo, opts...)
+	if err != nil {
+		return nil, err
+	}
+	refs := map[v1.Hash][]AttestationReference{}
+	for _, tag := range tags {
+		sigs, err := ociremote.Signatures(tag, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", tag, err)
+		}
+		atts, err := sigs.Get()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", tag, err)
+		}
+		for _, att := range atts {
+			st, err := statementHeader(att)
+			if err != nil {
+				continue
+			}
+			d, err := att.Digest()
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range st.Subject {
+				for alg, hex := range s.Digest {
+					h := v1.Hash{Algorithm: alg, Hex: hex}
+					refs[h] = append(refs[h], AttestationReference{Tag: tag, Digest: d})
+				}
+			}
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/empty"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	"github.com/franchb/cosign/v2/pkg/oci/ocitest"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+// retentionTestAttestation returns an unsigned attestation with the given
+// predicate type over subject.
+func retentionTestAttestation(t *testing.T, predicateType string, subject v1.Hash) oci.Signature {
+	t.Helper()
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":%q,"subject":[{"name":"x","digest":{%q:%q}}],"predicate":{}}`,
+		predicateType, subject.Algorithm, subject.Hex)
+	envelope := `{"payloadType":"application/vnd.in-toto+json","payload":"` + base64.StdEncoding.EncodeToString([]byte(statement)) + `","signatures":[]}`
+	att, err := static.NewAttestation([]byte(envelope))
+	require.NoError(t, err)
+	return att
+}
+
+func TestRetentionPolicyRetains(t *testing.T) {
+	repo, err := name.NewRepository("ghcr.io/org/app")
+	require.NoError(t, err)
+	slsa := retentionTestAttestation(t, "https://slsa.dev/provenance/v1", v1.Hash{Algorithm: "sha256", Hex: "00"})
+	vuln := retentionTestAttestation(t, "https://cosign.sigstore.dev/attestation/vuln/v1", v1.Hash{Algorithm: "sha256", Hex: "00"})
+
+	var none *RetentionPolicy
+	retained, err := none.Retains(repo, slsa)
+	require.NoError(t, err)
+	require.False(t, retained)
+
+	path := filepath.Join(t.TempDir(), "retention.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"repositories":["ghcr.io/org/*"],"predicateTypes":["https://slsa.dev/provenance/v1"]}`), 0o600))
+	p, err := LoadRetentionPolicy(path)
+	require.NoError(t, err)
+
+	retained, err = p.Retains(repo, slsa)
+	require.NoError(t, err)
+	require.True(t, retained)
+	retained, err = p.Retains(repo, vuln)
+	require.NoError(t, err)
+	require.False(t, retained)
+
+	other, err := name.NewRepository("ghcr.io/other/app")
+	require.NoError(t, err)
+	retained, err = p.Retains(other, slsa)
+	require.NoError(t, err)
+	require.False(t, retained)
+
+	// Without predicate types, every attestation is retained.
+	p.PredicateTypes = nil
+	retained, err = p.Retains(repo, vuln)
+	require.NoError(t, err)
+	require.True(t, retained)
+}
+
+func TestListAttestationReferences(t *testing.T) {
+	repo := ocitest.New(t).Repository("app")
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	require.NoError(t, err)
+	imgDigest, err := img.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Tag("latest"), img))
+
+	// The image has a scan attestation, whose image in turn has an
+	// attestation naming the scan.
+	scan := retentionTestAttestation(t, "https://cosign.sigstore.dev/attestation/vuln/v1", imgDigest)
+	scans, err := mutate.AppendSignatures(empty.Signatures(), false, scan)
+	require.NoError(t, err)
+	scanTag, err := ociremote.AttestationTag(repo.Digest(imgDigest.String()))
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(scanTag, scans))
+
+	scanDigest, err := scan.Digest()
+	require.NoError(t, err)
+	scansDigest, err := scans.Digest()
+	require.NoError(t, err)
+	review := retentionTestAttestation(t, "https://example.com/review/v1", scanDigest)
+	reviews, err := mutate.AppendSignatures(empty.Signatures(), false, review)
+	require.NoError(t, err)
+	reviewTag, err := ociremote.AttestationTag(repo.Digest(scansDigest.String()))
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(reviewTag, reviews))
+
+	refs, err := ListAttestationReferences(repo)
+	require.NoError(t, err)
+	reviewDigest, err := review.Digest()
+	require.NoError(t, err)
+	require.Equal(t, []AttestationReference{{Tag: scanTag, Digest: scanDigest}}, refs[imgDigest])
+	require.Equal(t, []AttestationReference{{Tag: reviewTag, Digest: reviewDigest}}, refs[scanDigest])
+	require.Len(t, refs, 2)
+}
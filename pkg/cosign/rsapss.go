@@ -0,0 +1,186 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+// RSA-PSS keys are marked as such by the id-RSASSA-PSS algorithm identifier
+// of RFC 4055 in their SubjectPublicKeyInfo and PKCS #8 encodings, the way
+// "openssl genpkey -algorithm RSA-PSS" writes them, so that verifiers pick
+// the PSS scheme from the public key alone. The x509 package does not
+// support this identifier, so it is handled here.
+var (
+	oidRSASSAPSS = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidMGF1      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
+	oidSHA256    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// pssParameters is RSASSA-PSS-params of RFC 4055. Only SHA-256 with MGF1
+// SHA-256 is supported, the trailer field is always 1.
+type pssParameters struct {
+	Hash         pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	MGF          pkix.AlgorithmIdentifier `asn1:"explicit,tag:1"`
+	SaltLength   int                      `asn1:"explicit,tag:2"`
+	TrailerField int                      `asn1:"optional,explicit,tag:3,default:1"`
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+	// optional attributes omitted.
+}
+
+// pssAlgorithm is the id-RSASSA-PSS algorithm identifier of the keys cosign
+// generates, which sign with SHA-256 and a salt as long as the hash.
+func pssAlgorithm() (pkix.AlgorithmIdentifier, error) {
+	sha256 := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	mgfParams, err := asn1.Marshal(sha256)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+	params, err := asn1.Marshal(pssParameters{
+		Hash:         sha256,
+		MGF:          pkix.AlgorithmIdentifier{Algorithm: oidMGF1, Parameters: asn1.RawValue{FullBytes: mgfParams}},
+		SaltLength:   crypto.SHA256.Size(),
+		TrailerField: 1,
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidRSASSAPSS, Parameters: asn1.RawValue{FullBytes: params}}, nil
+}
+
+// parsePSSAlgorithm returns the options to verify signatures of a key with the
+// id-RSASSA-PSS algorithm identifier alg. Keys without parameters may sign
+// with any salt length.
+func parsePSSAlgorithm(alg pkix.AlgorithmIdentifier) (*rsa.PSSOptions, error) {
+	if len(alg.Parameters.FullBytes) == 0 {
+		return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: crypto.SHA256}, nil
+	}
+	var params pssParameters
+	if rest, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("parsing RSASSA-PSS parameters: %w", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after RSASSA-PSS parameters")
+	}
+	var mgfHash pkix.AlgorithmIdentifier
+	if _, err := asn1.Unmarshal(params.MGF.Parameters.FullBytes, &mgfHash); err != nil {
+		return nil, fmt.Errorf("parsing RSASSA-PSS mask generation function: %w", err)
+	}
+	if !params.Hash.Algorithm.Equal(oidSHA256) || !params.MGF.Algorithm.Equal(oidMGF1) || !mgfHash.Algorithm.Equal(oidSHA256) {
+		return nil, errors.New("unsupported RSASSA-PSS parameters, only SHA-256 with MGF1 SHA-256 is supported")
+	}
+	if params.SaltLength < 0 || params.TrailerField != 1 {
+		return nil, errors.New("invalid RSASSA-PSS parameters")
+	}
+	return &rsa.PSSOptions{SaltLength: params.SaltLength, Hash: crypto.SHA256}, nil
+}
+
+// MarshalRSAPSSPublicKeyToPEM encodes pub as a PEM public key restricted to
+// RSA-PSS signatures with SHA-256.
+func MarshalRSAPSSPublicKeyToPEM(pub *rsa.PublicKey) ([]byte, error) {
+	alg, err := pssAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: alg,
+		PublicKey: asn1.BitString{Bytes: x509.MarshalPKCS1PublicKey(pub)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: string(cryptoutils.PublicKeyPEMType), Bytes: der}), nil
+}
+
+// marshalRSAPSSPrivateKey encodes priv as a PKCS #8 private key restricted to
+// RSA-PSS signatures with SHA-256.
+func marshalRSAPSSPrivateKey(priv *rsa.PrivateKey) ([]byte, error) {
+	alg, err := pssAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{Algo: alg, PrivateKey: x509.MarshalPKCS1PrivateKey(priv)})
+}
+
+// parseRSAPSSPrivateKey parses the PKCS #8 private key der if it is an RSA-PSS
+// key. ok is false if der is another kind of key.
+func parseRSAPSSPrivateKey(der []byte) (priv *rsa.PrivateKey, opts *rsa.PSSOptions, ok bool, err error) {
+	var key pkcs8
+	if _, err := asn1.Unmarshal(der, &key); err != nil || !key.Algo.Algorithm.Equal(oidRSASSAPSS) {
+		return nil, nil, false, nil
+	}
+	if opts, err = parsePSSAlgorithm(key.Algo); err != nil {
+		return nil, nil, true, err
+	}
+	if priv, err = x509.ParsePKCS1PrivateKey(key.PrivateKey); err != nil {
+		return nil, nil, true, fmt.Errorf("parsing RSA-PSS private key: %w", err)
+	}
+	// Sign with the salt length verifiers of the key expect.
+	if opts.SaltLength == rsa.PSSSaltLengthAuto {
+		opts.SaltLength = rsa.PSSSaltLengthEqualsHash
+	}
+	return priv, opts, true, nil
+}
+
+// LoadPublicKeyPEM returns a verifier for the PEM public key pemBytes, which
+// uses hashFunc for digests. The scheme of RSA keys is negotiated from the
+// key: keys marked as RSA-PSS keys are verified with RSA-PSS and SHA-256,
+// which hashFunc must then be, other RSA keys with PKCS #1 v1.5.
+func LoadPublicKeyPEM(pemBytes []byte, hashFunc crypto.Hash) (signature.Verifier, error) {
+	if p, _ := pem.Decode(pemBytes); p != nil && p.Type == string(cryptoutils.PublicKeyPEMType) {
+		var spki subjectPublicKeyInfo
+		if _, err := asn1.Unmarshal(p.Bytes, &spki); err == nil && spki.Algorithm.Algorithm.Equal(oidRSASSAPSS) {
+			opts, err := parsePSSAlgorithm(spki.Algorithm)
+			if err != nil {
+				return nil, err
+			}
+			if hashFunc != opts.Hash {
+				return nil, fmt.Errorf("RSA-PSS key is restricted to %v digests, not %v", opts.Hash, hashFunc)
+			}
+			pub, err := x509.ParsePKCS1PublicKey(spki.PublicKey.RightAlign())
+			if err != nil {
+				return nil, fmt.Errorf("parsing RSA-PSS public key: %w", err)
+			}
+			if err := cryptoutils.ValidatePubKey(pub); err != nil {
+				return nil, err
+			}
+			return signature.LoadRSAPSSVerifier(pub, opts.Hash, opts)
+		}
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+	return signature.LoadVerifier(pub, hashFunc)
+}
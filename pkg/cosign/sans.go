@@ -0,0 +1,210 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+// SANType is the kind of a subject alternative name.
+type SANType string
+
+const (
+	SANDNS       SANType = "dns"
+	SANEmail     SANType = "email"
+	SANIP        SANType = "ip"
+	SANURI       SANType = "uri"
+	SANOtherName SANType = "othername"
+)
+
+// SubjectAlternativeName is a subject alternative name of a certificate.
+type SubjectAlternativeName struct {
+	Type SANType
+	// OID is the type-id of an OtherName, such as 1.3.6.1.4.1.57264.1.7 for
+	// the username of Fulcio certificates or 1.3.6.1.4.1.311.20.2.3 for a
+	// Microsoft user principal name. It is nil for other types.
+	OID   asn1.ObjectIdentifier
+	Value string
+}
+
+// String returns the SAN prefixed with its type, e.g. uri:https://example.com.
+func (s SubjectAlternativeName) String() string {
+	if s.Type == SANOtherName {
+		return fmt.Sprintf("%s(%s):%s", s.Type, s.OID, s.Value)
+	}
+	return fmt.Sprintf("%s:%s", s.Type, s.Value)
+}
+
+// SubjectAlternativeNames returns the SANs of cert. Unlike
+// cryptoutils.GetSubjectAlternateNames, it returns every OtherName whose value
+// is a string, whatever its type-id.
+func SubjectAlternativeNames(cert *x509.Certificate) []SubjectAlternativeName {
+	var sans []SubjectAlternativeName
+	for _, dns := range cert.DNSNames {
+		sans = append(sans, SubjectAlternativeName{Type: SANDNS, Value: dns})
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, SubjectAlternativeName{Type: SANEmail, Value: email})
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, SubjectAlternativeName{Type: SANIP, Value: ip.String()})
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, SubjectAlternativeName{Type: SANURI, Value: uri.String()})
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(cryptoutils.SANOID) {
+			sans = append(sans, otherNames(ext.Value)...)
+		}
+	}
+	return sans
+}
+
+// otherNames returns the OtherNames with a string value in the DER encoded
+// GeneralNames der, skipping any it cannot parse.
+//
+//	OtherName ::= SEQUENCE {
+//	    type-id    OBJECT IDENTIFIER,
+//	    value      [0] EXPLICIT ANY DEFINED BY type-id }
+func otherNames(der []byte) []SubjectAlternativeName {
+	var seq asn1.RawValue
+	if rest, err := asn1.Unmarshal(der, &seq); err != nil || len(rest) != 0 || !seq.IsCompound || seq.Tag != asn1.TagSequence {
+		return nil
+	}
+	var sans []SubjectAlternativeName
+	for rest := seq.Bytes; len(rest) > 0; {
+		var gn asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &gn); err != nil {
+			return sans
+		}
+		if gn.Class != asn1.ClassContextSpecific || gn.Tag != 0 || !gn.IsCompound {
+			continue
+		}
+		var oid asn1.ObjectIdentifier
+		v, err := asn1.Unmarshal(gn.Bytes, &oid)
+		if err != nil {
+			continue
+		}
+		var explicit asn1.RawValue
+		if _, err := asn1.Unmarshal(v, &explicit); err != nil || explicit.Class != asn1.ClassContextSpecific || explicit.Tag != 0 {
+			continue
+		}
+		var value asn1.RawValue
+		if _, err := asn1.Unmarshal(explicit.Bytes, &value); err != nil || value.Class != asn1.ClassUniversal {
+			continue
+		}
+		switch value.Tag {
+		case asn1.TagUTF8String, asn1.TagIA5String, asn1.TagPrintableString:
+			sans = append(sans, SubjectAlternativeName{Type: SANOtherName, OID: oid, Value: string(value.Bytes)})
+		}
+	}
+	return sans
+}
+
+// URINormalization relaxes how URI SANs are compared with the expected
+// subject of an Identity. The SAN and, for exact matches, the expected
+// subject are normalized the same way before they are compared.
+type URINormalization struct {
+	// IgnoreCase compares URIs case-insensitively.
+	IgnoreCase bool
+	// IgnoreTrailingSlash ignores a trailing slash of the URI path.
+	IgnoreTrailingSlash bool
+	// IgnoreDefaultPort ignores the port of http and https URIs if it is
+	// the default one of their scheme.
+	IgnoreDefaultPort bool
+}
+
+// Normalize returns uri normalized as configured by n. uri is returned
+// unchanged if it cannot be parsed.
+func (n URINormalization) Normalize(uri string) string {
+	if n == (URINormalization{}) {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	if n.IgnoreDefaultPort {
+		port := u.Port()
+		scheme := strings.ToLower(u.Scheme)
+		if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+			u.Host = u.Hostname()
+		}
+	}
+	if n.IgnoreTrailingSlash {
+		u.Path = strings.TrimRight(u.Path, "/")
+		if u.RawPath != "" {
+			u.RawPath = strings.TrimRight(u.RawPath, "/")
+		}
+	}
+	s := u.String()
+	if n.IgnoreCase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// ParseURINormalization parses the names of URI normalizations, which are
+// "case", "trailing-slash" and "port".
+func ParseURINormalization(names []string) (URINormalization, error) {
+	var n URINormalization
+	for _, name := range names {
+		switch name {
+		case "case":
+			n.IgnoreCase = true
+		case "trailing-slash":
+			n.IgnoreTrailingSlash = true
+		case "port":
+			n.IgnoreDefaultPort = true
+		default:
+			return URINormalization{}, fmt.Errorf("unknown URI normalization %q, expected case, trailing-slash or port", name)
+		}
+	}
+	return n, nil
+}
+
+// IdentityMatch records which SAN of a certificate satisfied an Identity,
+// see CheckOpts.IdentityMatchTrace.
+type IdentityMatch struct {
+	Identity Identity
+	// SAN is the subject alternative name that matched, or nil if the
+	// identity does not constrain the subject.
+	SAN *SubjectAlternativeName
+	// Issuer is the OIDC issuer of the certificate.
+	Issuer string
+}
+
+// Pattern returns the expected subject the SAN matched.
+func (m IdentityMatch) Pattern() string {
+	if m.Identity.SubjectRegExp != "" {
+		return m.Identity.SubjectRegExp
+	}
+	return m.Identity.Subject
+}
+
+// String describes the match, e.g. for a trace of the verification.
+func (m IdentityMatch) String() string {
+	if m.SAN == nil {
+		return fmt.Sprintf("certificate with issuer %s matched an identity without subject constraint", m.Issuer)
+	}
+	return fmt.Sprintf("SAN %s matched expected identity %q with issuer %s", m.SAN, m.Pattern(), m.Issuer)
+}
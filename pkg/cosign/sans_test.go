@@ -0,0 +1,208 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/franchb/cosign/v2/test"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+var (
+	oidFulcioUsername = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 7}
+	oidMicrosoftUPN   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}
+)
+
+func marshalOtherName(t *testing.T, oid asn1.ObjectIdentifier, value string) asn1.RawValue {
+	t.Helper()
+	v, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(value)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explicit, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeID, err := asn1.Marshal(oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: append(typeID, explicit...)}
+}
+
+// generateLeafCertWithSANs returns a certificate with the OtherNames and URIs
+// given as generalNames.
+func generateLeafCertWithSANs(t *testing.T, issuer string, generalNames ...asn1.RawValue) *x509.Certificate {
+	t.Helper()
+	value, err := asn1.Marshal(generalNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	leafCert, _, err := test.GenerateLeafCert("unused", issuer, rootCert, rootKey, pkix.Extension{Id: cryptoutils.SANOID, Critical: true, Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leafCert
+}
+
+func uriGeneralName(uri string) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(uri)}
+}
+
+func TestSubjectAlternativeNames(t *testing.T) {
+	cert := generateLeafCertWithSANs(t, "https://issuer.example.com",
+		marshalOtherName(t, oidFulcioUsername, "jdoe!example.com"),
+		marshalOtherName(t, oidMicrosoftUPN, "jdoe@corp.example.com"),
+		uriGeneralName("https://example.com/repo"))
+
+	want := []string{
+		"uri:https://example.com/repo",
+		"othername(1.3.6.1.4.1.57264.1.7):jdoe!example.com",
+		"othername(1.3.6.1.4.1.311.20.2.3):jdoe@corp.example.com",
+	}
+	got := SubjectAlternativeNames(cert)
+	if len(got) != len(want) {
+		t.Fatalf("SubjectAlternativeNames() = %v, wanted %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("SubjectAlternativeNames()[%d] = %s, wanted %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestURINormalization(t *testing.T) {
+	tests := []struct {
+		name string
+		norm []string
+		uri  string
+		want string
+	}{{
+		name: "none",
+		uri:  "https://Example.com:443/repo/",
+		want: "https://Example.com:443/repo/",
+	}, {
+		name: "case",
+		norm: []string{"case"},
+		uri:  "https://Example.com/Repo",
+		want: "https://example.com/repo",
+	}, {
+		name: "trailing slash",
+		norm: []string{"trailing-slash"},
+		uri:  "https://example.com/repo//",
+		want: "https://example.com/repo",
+	}, {
+		name: "default https port",
+		norm: []string{"port"},
+		uri:  "https://example.com:443/repo",
+		want: "https://example.com/repo",
+	}, {
+		name: "other port is kept",
+		norm: []string{"port"},
+		uri:  "https://example.com:8443/repo",
+		want: "https://example.com:8443/repo",
+	}, {
+		name: "all",
+		norm: []string{"case", "trailing-slash", "port"},
+		uri:  "HTTP://Example.com:80/repo/",
+		want: "http://example.com/repo",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := ParseURINormalization(tc.norm)
+			if err != nil {
+				t.Fatalf("ParseURINormalization() = %v", err)
+			}
+			if got := n.Normalize(tc.uri); got != tc.want {
+				t.Errorf("Normalize() = %s, wanted %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := ParseURINormalization([]string{"query"}); err == nil {
+		t.Error("ParseURINormalization() succeeded for an unknown normalization")
+	}
+}
+
+func TestCheckCertificatePolicySANs(t *testing.T) {
+	issuer := "https://issuer.example.com"
+	cert := generateLeafCertWithSANs(t, issuer,
+		marshalOtherName(t, oidMicrosoftUPN, "jdoe@corp.example.com"),
+		uriGeneralName("https://Example.com:443/org/repo/"))
+
+	tests := []struct {
+		name      string
+		identity  Identity
+		norm      URINormalization
+		wantErr   bool
+		wantMatch string
+	}{{
+		name:      "othername exact",
+		identity:  Identity{Subject: "jdoe@corp.example.com", Issuer: issuer},
+		wantMatch: "othername(1.3.6.1.4.1.311.20.2.3):jdoe@corp.example.com",
+	}, {
+		name:      "othername regexp",
+		identity:  Identity{SubjectRegExp: "^jdoe@corp\\.", Issuer: issuer},
+		wantMatch: "othername(1.3.6.1.4.1.311.20.2.3):jdoe@corp.example.com",
+	}, {
+		name:     "uri without normalization",
+		identity: Identity{Subject: "https://example.com/org/repo", Issuer: issuer},
+		wantErr:  true,
+	}, {
+		name:      "uri normalized",
+		identity:  Identity{Subject: "https://example.com/org/repo", Issuer: issuer},
+		norm:      URINormalization{IgnoreCase: true, IgnoreTrailingSlash: true, IgnoreDefaultPort: true},
+		wantMatch: "uri:https://Example.com:443/org/repo/",
+	}, {
+		name:      "uri regexp normalized",
+		identity:  Identity{SubjectRegExp: "^https://example\\.com/org/[^/]+$", Issuer: issuer},
+		norm:      URINormalization{IgnoreCase: true, IgnoreTrailingSlash: true, IgnoreDefaultPort: true},
+		wantMatch: "uri:https://Example.com:443/org/repo/",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var matches []IdentityMatch
+			co := &CheckOpts{
+				Identities:         []Identity{tc.identity},
+				URINormalization:   tc.norm,
+				IdentityMatchTrace: func(m IdentityMatch) { matches = append(matches, m) },
+			}
+			err := CheckCertificatePolicy(cert, co)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CheckCertificatePolicy() = %v, wanted error: %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				if len(matches) != 0 {
+					t.Errorf("IdentityMatchTrace called for a failed match: %v", matches)
+				}
+				return
+			}
+			if len(matches) != 1 || matches[0].SAN == nil {
+				t.Fatalf("IdentityMatchTrace calls = %v, wanted one match", matches)
+			}
+			if got := matches[0].SAN.String(); got != tc.wantMatch {
+				t.Errorf("matched SAN = %s, wanted %s", got, tc.wantMatch)
+			}
+			if matches[0].Issuer != issuer {
+				t.Errorf("matched issuer = %s, wanted %s", matches[0].Issuer, issuer)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshsig
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// AllowedSigner is an entry of an allowed signers file, see the ALLOWED
+// SIGNERS section of ssh-keygen(1).
+type AllowedSigner struct {
+	// Principals are the patterns of the identities the key may sign for.
+	Principals []string
+	// CertAuthority is whether Key is a CA trusted to certify the keys of
+	// the principals, rather than their key.
+	CertAuthority bool
+	// Namespaces are the patterns of the namespaces the key may sign in,
+	// any if empty.
+	Namespaces []string
+	// ValidAfter and ValidBefore bound when the key may be used, if not
+	// zero.
+	ValidAfter  time.Time
+	ValidBefore time.Time
+	// Key is the public key of the principals or of their CA.
+	Key ssh.PublicKey
+}
+
+// AllowedSigners are the entries of an allowed signers file.
+type AllowedSigners []AllowedSigner
+
+// ParseAllowedSigners parses an allowed signers file.
+func ParseAllowedSigners(r io.Reader) (AllowedSigners, error) {
+	var signers AllowedSigners
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		a, err := parseAllowedSigner(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		signers = append(signers, *a)
+	}
+	return signers, s.Err()
+}
+
+func parseAllowedSigner(line string) (*AllowedSigner, error) {
+	principals, rest := nextField(line)
+	if rest == "" {
+		return nil, errors.New("missing key")
+	}
+	a := &AllowedSigner{
+		Principals: strings.Split(strings.Trim(principals, `"`), ","),
+	}
+	key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(rest))
+	if err != nil {
+		return nil, err
+	}
+	a.Key = key
+	for _, o := range options {
+		name, value, _ := strings.Cut(o, "=")
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			a.CertAuthority = true
+		case "namespaces":
+			a.Namespaces = strings.Split(value, ",")
+		case "valid-after":
+			if a.ValidAfter, err = parseValidity(value); err != nil {
+				return nil, err
+			}
+		case "valid-before":
+			if a.ValidBefore, err = parseValidity(value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+	}
+	return a, nil
+}
+
+// nextField splits the first field, which may be quoted, from line.
+func nextField(line string) (string, string) {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case (c == ' ' || c == '\t') && !quoted:
+			return line[:i], strings.TrimSpace(line[i:])
+		}
+	}
+	return line, ""
+}
+
+// parseValidity parses a time as YYYYMMDD[HHMM[SS]], in UTC if followed by
+// "Z" and in local time otherwise.
+func parseValidity(s string) (time.Time, error) {
+	loc := time.Local
+	if t, ok := strings.CutSuffix(s, "Z"); ok {
+		s, loc = t, time.UTC
+	}
+	for _, layout := range []string{"20060102", "200601021504", "20060102150405"} {
+		if len(s) == len(layout) {
+			return time.ParseInLocation(layout, s, loc)
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// Verify checks that sig is a signature of message in namespace by a key
+// the signers allow principal to use at time t.
+func (s AllowedSigners) Verify(sig *Signature, message io.Reader, principal, namespace string, t time.Time) error {
+	if err := s.allow(sig.PublicKey, principal, namespace, t); err != nil {
+		return err
+	}
+	return sig.Verify(message, namespace)
+}
+
+// allow checks that key may sign in namespace for principal at time t.
+func (s AllowedSigners) allow(key ssh.PublicKey, principal, namespace string, t time.Time) error {
+	cert, isCert := key.(*ssh.Certificate)
+	var certErr error
+	for _, a := range s {
+		if !matchPatternList(a.Principals, principal) {
+			continue
+		}
+		if len(a.Namespaces) > 0 && !matchPatternList(a.Namespaces, namespace) {
+			continue
+		}
+		if (!a.ValidAfter.IsZero() && t.Before(a.ValidAfter)) || (!a.ValidBefore.IsZero() && !t.Before(a.ValidBefore)) {
+			continue
+		}
+		switch {
+		case a.CertAuthority && isCert:
+			if !bytes.Equal(cert.SignatureKey.Marshal(), a.Key.Marshal()) || cert.CertType != ssh.UserCert {
+				continue
+			}
+			checker := &ssh.CertChecker{Clock: func() time.Time { return t }}
+			if certErr = checker.CheckCert(principal, cert); certErr == nil {
+				return nil
+			}
+		case !a.CertAuthority && !isCert:
+			if bytes.Equal(key.Marshal(), a.Key.Marshal()) {
+				return nil
+			}
+		}
+	}
+	if certErr != nil {
+		return fmt.Errorf("checking certificate: %w", certErr)
+	}
+	return fmt.Errorf("%s key %s is not allowed to sign for %q in namespace %q", key.Type(), ssh.FingerprintSHA256(key), principal, namespace)
+}
+
+// matchPatternList reports whether s matches the patterns, which may be
+// negated with "!", like OpenSSH's match_pattern_list: s matches if it
+// matches a pattern and no negated one.
+func matchPatternList(patterns []string, s string) bool {
+	matched := false
+	for _, p := range patterns {
+		if neg, ok := strings.CutPrefix(p, "!"); ok {
+			if matchPattern(neg, s) {
+				return false
+			}
+		} else if matchPattern(p, s) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchPattern reports whether s matches p, in which "*" matches any
+// sequence of characters and "?" any one character.
+func matchPattern(p, s string) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if matchPattern(p[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != p[0] {
+				return false
+			}
+		}
+		p, s = p[1:], s[1:]
+	}
+	return s == ""
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sshsig signs and verifies messages with SSH keys in the format of
+// OpenSSH's PROTOCOL.sshsig, as written by `ssh-keygen -Y sign` and checked
+// by `ssh-keygen -Y verify`.
+package sshsig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// ReferenceScheme prefixes the paths of SSH keys and allowed signers
+	// files given as key references, e.g. "ssh://~/.ssh/id_ed25519".
+	ReferenceScheme = "ssh://"
+	// DefaultNamespace is the namespace of signatures of files, as used by
+	// ssh-keygen.
+	DefaultNamespace = "file"
+
+	magicPreamble = "SSHSIG"
+	sigVersion    = 1
+	pemType       = "SSH SIGNATURE"
+)
+
+// KeyPath returns the file path of the key reference keyRef, without its
+// ReferenceScheme and with a leading "~/" replaced by the home directory.
+func KeyPath(keyRef string) (string, error) {
+	p := strings.TrimPrefix(keyRef, ReferenceScheme)
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(home, rest)
+	}
+	return p, nil
+}
+
+// ParsePrivateKey returns a signer for the OpenSSH private key b, calling
+// pass for the passphrase if the key is encrypted.
+func ParsePrivateKey(b []byte, pass func() ([]byte, error)) (ssh.Signer, error) {
+	s, err := ssh.ParsePrivateKey(b)
+	var perr *ssh.PassphraseMissingError
+	if !errors.As(err, &perr) || pass == nil {
+		return s, err
+	}
+	p, err := pass()
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKeyWithPassphrase(b, p)
+}
+
+// Signature is a parsed SSH signature.
+type Signature struct {
+	// PublicKey is the key, or certificate, the signature was made with.
+	PublicKey ssh.PublicKey
+	// Namespace is the domain the signature is for, e.g. "file" or "git".
+	Namespace string
+	// HashAlgorithm is the hash the message was signed with, "sha256" or
+	// "sha512".
+	HashAlgorithm string
+	// Signature is the signature of the message.
+	Signature *ssh.Signature
+}
+
+// wireSignature is the binary form of a Signature.
+type wireSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// signedData is what the key signs for a message.
+type signedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// Sign signs message in namespace with signer, returning the armored
+// signature as written by ssh-keygen -Y sign. RSA keys sign with
+// rsa-sha2-512.
+func Sign(rand io.Reader, signer ssh.Signer, message io.Reader, namespace string) ([]byte, error) {
+	if namespace == "" {
+		return nil, errors.New("missing namespace")
+	}
+	const hashAlgorithm = "sha512"
+	data, err := messageData(message, namespace, hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	var sig *ssh.Signature
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand, data, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := append([]byte(magicPreamble), ssh.Marshal(wireSignature{
+		Version:       sigVersion,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: hashAlgorithm,
+		Signature:     ssh.Marshal(sig),
+	})...)
+	return armor(b), nil
+}
+
+// ParseSignature parses the armored SSH signature b.
+func ParseSignature(b []byte) (*Signature, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != pemType {
+		return nil, errors.New("not an SSH signature")
+	}
+	raw, ok := bytes.CutPrefix(block.Bytes, []byte(magicPreamble))
+	if !ok {
+		return nil, errors.New("missing SSHSIG preamble")
+	}
+	var w wireSignature
+	if err := ssh.Unmarshal(raw, &w); err != nil {
+		return nil, fmt.Errorf("parsing SSH signature: %w", err)
+	}
+	if w.Version != sigVersion {
+		return nil, fmt.Errorf("unsupported SSH signature version %d", w.Version)
+	}
+	pub, err := ssh.ParsePublicKey(w.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	sig := &ssh.Signature{}
+	if err := ssh.Unmarshal(w.Signature, sig); err != nil {
+		return nil, fmt.Errorf("parsing signature: %w", err)
+	}
+	return &Signature{
+		PublicKey:     pub,
+		Namespace:     w.Namespace,
+		HashAlgorithm: w.HashAlgorithm,
+		Signature:     sig,
+	}, nil
+}
+
+// Verify checks that s is a signature of message in namespace by
+// s.PublicKey. It does not check whether the key is trusted, see
+// AllowedSigners.
+func (s *Signature) Verify(message io.Reader, namespace string) error {
+	if s.Namespace != namespace {
+		return fmt.Errorf("signature is for namespace %q, not %q", s.Namespace, namespace)
+	}
+	// Like OpenSSH, refuse RSA signatures over SHA-1.
+	if s.Signature.Format == ssh.KeyAlgoRSA {
+		return errors.New("RSA signatures with SHA-1 are not supported")
+	}
+	data, err := messageData(message, namespace, s.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	return s.PublicKey.Verify(data, s.Signature)
+}
+
+// messageData returns the data signed for message in namespace.
+func messageData(message io.Reader, namespace, hashAlgorithm string) ([]byte, error) {
+	var h hash.Hash
+	switch hashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", hashAlgorithm)
+	}
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+	return append([]byte(magicPreamble), ssh.Marshal(signedData{
+		Namespace:     namespace,
+		HashAlgorithm: hashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...), nil
+}
+
+// armor encodes b like ssh-keygen, in lines of 70 characters.
+func armor(b []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("-----BEGIN " + pemType + "-----\n")
+	enc := []byte(base64.StdEncoding.EncodeToString(b))
+	for len(enc) > 70 {
+		buf.Write(enc[:70])
+		buf.WriteByte('\n')
+		enc = enc[70:]
+	}
+	buf.Write(enc)
+	buf.WriteString("\n-----END " + pemType + "-----\n")
+	return buf.Bytes()
+}
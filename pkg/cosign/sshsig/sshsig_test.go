@@ -0,0 +1,286 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSignMatchesSSHKeygen(t *testing.T) {
+	signer := loadSigner(t, "alice")
+	message, err := os.ReadFile("testdata/message.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/alice.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ed25519 signatures are deterministic.
+	got, err := Sign(rand.Reader, signer, bytes.NewReader(message), DefaultNamespace)
+	if err != nil {
+		t.Fatalf("Sign() = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Sign() =\n%s\nwanted, as signed by ssh-keygen,\n%s", got, want)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []crypto.Signer{edKey, ecKey, rsaKey} {
+		signer, err := ssh.NewSignerFromSigner(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(signer.PublicKey().Type(), func(t *testing.T) {
+			armored, err := Sign(rand.Reader, signer, strings.NewReader("message"), "git")
+			if err != nil {
+				t.Fatalf("Sign() = %v", err)
+			}
+			sig, err := ParseSignature(armored)
+			if err != nil {
+				t.Fatalf("ParseSignature() = %v", err)
+			}
+			if !bytes.Equal(sig.PublicKey.Marshal(), signer.PublicKey().Marshal()) {
+				t.Error("ParseSignature() returned another public key")
+			}
+			if err := sig.Verify(strings.NewReader("message"), "git"); err != nil {
+				t.Errorf("Verify() = %v", err)
+			}
+			if err := sig.Verify(strings.NewReader("message"), "file"); err == nil {
+				t.Error("Verify() succeeded in another namespace")
+			}
+			if err := sig.Verify(strings.NewReader("tampered"), "git"); err == nil {
+				t.Error("Verify() succeeded for another message")
+			}
+		})
+	}
+}
+
+func TestAllowedSignersVerify(t *testing.T) {
+	f, err := os.Open("testdata/allowed_signers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	signers, err := ParseAllowedSigners(f)
+	if err != nil {
+		t.Fatalf("ParseAllowedSigners() = %v", err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		sig       string
+		principal string
+		namespace string
+		time      time.Time
+		wantErr   bool
+	}{{
+		name:      "key",
+		sig:       "alice.sig",
+		principal: "alice@example.com",
+		namespace: "file",
+		time:      now,
+	}, {
+		name:      "key of another principal",
+		sig:       "alice.sig",
+		principal: "bob@example.com",
+		namespace: "file",
+		time:      now,
+		wantErr:   true,
+	}, {
+		name:      "namespace not allowed",
+		sig:       "alice.sig",
+		principal: "alice@example.com",
+		namespace: "git",
+		time:      now,
+		wantErr:   true,
+	}, {
+		name:      "key before valid-before",
+		sig:       "bob.sig",
+		principal: "bob@example.org",
+		namespace: "file",
+		time:      time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:      "expired key",
+		sig:       "bob.sig",
+		principal: "bob@example.com",
+		namespace: "file",
+		time:      now,
+		wantErr:   true,
+	}, {
+		name:      "certificate",
+		sig:       "carol.sig",
+		principal: "carol@example.com",
+		namespace: "file",
+		time:      now,
+	}, {
+		name:      "certificate for another principal",
+		sig:       "carol.sig",
+		principal: "dave@example.com",
+		namespace: "file",
+		time:      now,
+		wantErr:   true,
+	}, {
+		name:      "excluded principal",
+		sig:       "carol.sig",
+		principal: "mallory@example.com",
+		namespace: "file",
+		time:      now,
+		wantErr:   true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tc.sig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := ParseSignature(b)
+			if err != nil {
+				t.Fatalf("ParseSignature() = %v", err)
+			}
+			message, err := os.Open("testdata/message.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer message.Close()
+			err = signers.Verify(sig, message, tc.principal, tc.namespace, tc.time)
+			if tc.wantErr && err == nil {
+				t.Error("Verify() succeeded")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("Verify() = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseAllowedSignersErrors(t *testing.T) {
+	for _, line := range []string{
+		"alice@example.com",
+		"alice@example.com ssh-ed25519 not-base64",
+		`alice@example.com unknown-option ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIC0TKs8x0Ek5PSPLMZJYj0KUtZl0fQvyKWsIYzOtalt/`,
+		`alice@example.com valid-after="tomorrow" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIC0TKs8x0Ek5PSPLMZJYj0KUtZl0fQvyKWsIYzOtalt/`,
+	} {
+		if _, err := ParseAllowedSigners(strings.NewReader(line)); err == nil {
+			t.Errorf("ParseAllowedSigners(%q) succeeded", line)
+		}
+	}
+}
+
+func TestParsePrivateKeyPassphrase(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := pem.EncodeToMemory(block)
+
+	if _, err := ParsePrivateKey(b, nil); err == nil {
+		t.Error("ParsePrivateKey() succeeded without a passphrase")
+	}
+	signer, err := ParsePrivateKey(b, func() ([]byte, error) { return []byte("secret"), nil })
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() = %v", err)
+	}
+	if signer.PublicKey().Type() != ssh.KeyAlgoED25519 {
+		t.Errorf("ParsePrivateKey() key type = %s", signer.PublicKey().Type())
+	}
+}
+
+func TestMatchPatternList(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		s        string
+		want     bool
+	}{
+		{[]string{"alice@example.com"}, "alice@example.com", true},
+		{[]string{"alice@example.com"}, "alice@example.co", false},
+		{[]string{"*@example.com"}, "bob@example.com", true},
+		{[]string{"*@example.com"}, "bob@example.org", false},
+		{[]string{"?ob@example.com"}, "bob@example.com", true},
+		{[]string{"*@example.com", "!mallory@*"}, "mallory@example.com", false},
+		{[]string{"!mallory@*"}, "bob@example.com", false},
+	}
+	for _, tc := range tests {
+		if got := matchPatternList(tc.patterns, tc.s); got != tc.want {
+			t.Errorf("matchPatternList(%q, %q) = %v, wanted %v", tc.patterns, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestKeyPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	if got, want := mustKeyPath(t, "ssh://~/.ssh/id_ed25519"), filepath.Join(home, ".ssh/id_ed25519"); got != want {
+		t.Errorf("KeyPath() = %s, wanted %s", got, want)
+	}
+	if got, want := mustKeyPath(t, "ssh:///etc/ssh/key"), "/etc/ssh/key"; got != want {
+		t.Errorf("KeyPath() = %s, wanted %s", got, want)
+	}
+}
+
+func mustKeyPath(t *testing.T, ref string) string {
+	t.Helper()
+	p, err := KeyPath(ref)
+	if err != nil {
+		t.Fatalf("KeyPath(%s) = %v", ref, err)
+	}
+	return p
+}
+
+func loadSigner(t *testing.T, name string) ssh.Signer {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ParsePrivateKey(b, nil)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() = %v", err)
+	}
+	return signer
+}
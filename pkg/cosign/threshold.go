@@ -0,0 +1,106 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/signature"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// checkRequiredSignatures checks that the verified signatures sigs were made
+// by at least co.RequiredSignatures distinct signers. verifyFn is the
+// function sigs were verified with.
+func checkRequiredSignatures(ctx context.Context, sigs []oci.Signature, verifyFn signatureVerificationFn, co *CheckOpts) error {
+	if co.RequiredSignatures <= 1 {
+		return nil
+	}
+	signers := map[string]bool{}
+	for _, sig := range sigs {
+		signer, err := signerOf(ctx, sig, verifyFn, co)
+		if err != nil {
+			return err
+		}
+		signers[signer] = true
+	}
+	if len(signers) < co.RequiredSignatures {
+		return fmt.Errorf("valid signatures by %d distinct signers, %d required", len(signers), co.RequiredSignatures)
+	}
+	return nil
+}
+
+// signerOf identifies who made sig, a signature that verified under co: by
+// the key of co.SigVerifier that verifies it or, when verifying with
+// certificates, by the identity its certificate was issued to. Keyless
+// certificates hold a new key for every signature, so only their identities
+// tell signers apart.
+func signerOf(ctx context.Context, sig oci.Signature, verifyFn signatureVerificationFn, co *CheckOpts) (string, error) {
+	switch v := co.SigVerifier.(type) {
+	case nil:
+		cert, err := sig.Cert()
+		if err != nil {
+			return "", err
+		}
+		if cert == nil {
+			return "", errors.New("signature has no certificate to identify its signer")
+		}
+		return certificateSigner(cert), nil
+	case *KeyRing:
+		for _, kv := range v.Verifiers(time.Now()) {
+			if verifyFn(ctx, kv, sig) == nil {
+				return keySigner(kv)
+			}
+		}
+		return "", errNoValidKeyRingKey
+	default:
+		return keySigner(v)
+	}
+}
+
+// keySigner identifies a signer by the digest of its public key.
+func keySigner(v signature.Verifier) (string, error) {
+	pub, err := v.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshaling public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return "key:" + hex.EncodeToString(sum[:]), nil
+}
+
+// certificateSigner identifies a signer by the OIDC issuer and subject
+// alternative names of its certificate.
+func certificateSigner(cert *x509.Certificate) string {
+	var sans []string
+	for _, san := range SubjectAlternativeNames(cert) {
+		sans = append(sans, san.String())
+	}
+	slices.Sort(sans)
+	ce := CertExtensions{Cert: cert}
+	return "identity:" + ce.GetIssuer() + "\n" + strings.Join(sans, "\n")
+}
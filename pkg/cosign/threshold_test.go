@@ -0,0 +1,125 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/test"
+	"github.com/franchb/sigstore/pkg/signature"
+)
+
+func TestVerifySignaturesRequiredSignaturesKeyRing(t *testing.T) {
+	svA, pemA := newKeyRingSigner(t)
+	svB, pemB := newKeyRingSigner(t)
+	_, pemC := newKeyRingSigner(t)
+	ring, err := LoadKeyRing([]byte(pemA+pemB+pemC), crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(sv signature.Signer, payload []byte) oci.Signature {
+		sig, err := sv.SignMessage(bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ociSig, err := static.NewSignature(payload, base64.StdEncoding.EncodeToString(sig))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ociSig
+	}
+
+	co := &CheckOpts{SigVerifier: ring, IgnoreTlog: true, RequiredSignatures: 2, FirstMatch: true}
+	sigs := &fakeOCISignatures{signatures: []oci.Signature{sign(svA, []byte("one")), sign(svA, []byte("two"))}}
+	_, _, err = verifySignatures(context.Background(), sigs, v1.Hash{}, co)
+	var e *ErrNoMatchingSignatures
+	if !errors.As(err, &e) {
+		t.Fatalf("verifySignatures() with signatures of one key = %v, wanted %T", err, e)
+	}
+
+	sigs = &fakeOCISignatures{signatures: []oci.Signature{sign(svA, []byte("one")), sign(svB, []byte("two"))}}
+	verified, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co)
+	if err != nil {
+		t.Fatalf("verifySignatures() = %v", err)
+	}
+	if len(verified) != 2 {
+		t.Errorf("verifySignatures() returned %d signatures, wanted 2", len(verified))
+	}
+
+	co.RequiredSignatures = 3
+	if _, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co); err == nil {
+		t.Error("verifySignatures() succeeded with signatures of 2 of 3 required keys")
+	}
+}
+
+func TestVerifySignaturesRequiredSignaturesIdentities(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	pemRoot := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCert.Raw})
+	rootPool := x509.NewCertPool()
+	rootPool.AddCert(rootCert)
+
+	sign := func(subject string) oci.Signature {
+		leafCert, privKey, err := test.GenerateLeafCert(subject, "oidc-issuer", rootCert, rootKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pemLeaf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafCert.Raw})
+		payload := []byte(subject)
+		h := sha256.Sum256(payload)
+		sig, err := privKey.Sign(rand.Reader, h[:], crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ociSig, err := static.NewSignature(payload, base64.StdEncoding.EncodeToString(sig), static.WithCertChain(pemLeaf, pemRoot))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ociSig
+	}
+
+	co := &CheckOpts{
+		RootCerts:          rootPool,
+		IgnoreSCT:          true,
+		IgnoreTlog:         true,
+		RequiredSignatures: 2,
+		Identities: []Identity{
+			{Subject: "alice@example.com", Issuer: "oidc-issuer"},
+			{Subject: "bob@example.com", Issuer: "oidc-issuer"},
+		},
+	}
+	// Each keyless signature has its own key, but both are by alice.
+	sigs := &fakeOCISignatures{signatures: []oci.Signature{sign("alice@example.com"), sign("alice@example.com")}}
+	if _, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co); err == nil {
+		t.Error("verifySignatures() succeeded with signatures of a single identity")
+	}
+
+	sigs = &fakeOCISignatures{signatures: []oci.Signature{sign("alice@example.com"), sign("bob@example.com")}}
+	if _, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co); err != nil {
+		t.Errorf("verifySignatures() = %v", err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"hash"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -118,18 +119,23 @@ func intotoEntry(ctx context.Context, signature, pubKey []byte) (models.Proposed
 // TUF root. If expired, makes a network call to retrieve the updated targets.
 // There are two Env variable that can be used to override this behaviour:
 // SIGSTORE_REKOR_PUBLIC_KEY - If specified, location of the file that contains
-// the Rekor Public Key on local filesystem
+// the Rekor Public Key on local filesystem, or a list of such files separated
+// by the OS path list separator
 func GetRekorPubs(ctx context.Context) (*TrustedTransparencyLogPubKeys, error) {
 	publicKeys := NewTrustedTransparencyLogPubKeys()
 	altRekorPub := env.Getenv(env.VariableSigstoreRekorPublicKey)
 
 	if altRekorPub != "" {
-		raw, err := os.ReadFile(altRekorPub)
-		if err != nil {
-			return nil, fmt.Errorf("error reading alternate Rekor public key file: %w", err)
-		}
-		if err := publicKeys.AddTransparencyLogPubKey(raw, tuf.Active); err != nil {
-			return nil, fmt.Errorf("AddRekorPubKey: %w", err)
+		// Multiple keys may be trusted at once, e.g. while migrating between
+		// Rekor instances, by listing several files.
+		for _, p := range filepath.SplitList(altRekorPub) {
+			raw, err := os.ReadFile(p)
+			if err != nil {
+				return nil, fmt.Errorf("error reading alternate Rekor public key file: %w", err)
+			}
+			if err := publicKeys.AddTransparencyLogPubKey(raw, tuf.Active); err != nil {
+				return nil, fmt.Errorf("AddRekorPubKey: %w", err)
+			}
 		}
 	} else {
 		tufClient, err := tuf.NewFromEnv(ctx)
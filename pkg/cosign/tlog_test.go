@@ -17,11 +17,15 @@ package cosign
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -56,6 +60,40 @@ func TestGetRekorPubKeys(t *testing.T) {
 	}
 }
 
+func TestGetRekorPubKeysFromMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.pub", "b.pub"} {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pub, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	t.Setenv("SIGSTORE_REKOR_PUBLIC_KEY", strings.Join(paths, string(os.PathListSeparator)))
+	keys, err := GetRekorPubs(context.Background())
+	if err != nil {
+		t.Fatalf("GetRekorPubs() = %v", err)
+	}
+	if len(keys.Keys) != 2 {
+		t.Errorf("GetRekorPubs() returned %d keys, wanted 2", len(keys.Keys))
+	}
+
+	t.Setenv("SIGSTORE_REKOR_PUBLIC_KEY", strings.Join([]string{paths[0], filepath.Join(dir, "missing.pub")}, string(os.PathListSeparator)))
+	if _, err := GetRekorPubs(context.Background()); err == nil {
+		t.Error("GetRekorPubs() with a missing key file succeeded, wanted error")
+	}
+}
+
 func TestExpectedRekorResponse(t *testing.T) {
 	validUUID := "f794467401d57241b7903737211c721cb3315648d077a9f02ceefb6e404a05de"
 	validUUID1 := "7794467401d57241b7903737211c721cb3315648d077a9f02ceefb6e404a05de"
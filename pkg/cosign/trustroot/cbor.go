@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustroot
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// CBOR major types, see RFC 8949 section 3.1.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+)
+
+// tagEpochTime marks an integer as seconds since the Unix epoch.
+const tagEpochTime = 1
+
+// item is a CBOR data item. Items are always written in the deterministic
+// encoding of RFC 8949 section 4.2: arguments are as short as possible and
+// lengths are never indefinite.
+type item interface {
+	encode(b *bytes.Buffer)
+}
+
+type (
+	intItem   int64
+	bytesItem []byte
+	textItem  string
+	arrayItem []item
+	tagItem   struct {
+		tag   uint64
+		value item
+	}
+)
+
+// mapItem is a CBOR map keyed by small unsigned integers. Entries must be in
+// increasing key order, which is the deterministic order for such keys.
+type mapItem []mapEntry
+
+type mapEntry struct {
+	key   uint64
+	value item
+}
+
+func (i intItem) encode(b *bytes.Buffer) {
+	if i < 0 {
+		writeHead(b, majorNegInt, uint64(-1-i))
+		return
+	}
+	writeHead(b, majorUint, uint64(i))
+}
+
+func (i bytesItem) encode(b *bytes.Buffer) {
+	writeHead(b, majorBytes, uint64(len(i)))
+	b.Write(i)
+}
+
+func (i textItem) encode(b *bytes.Buffer) {
+	writeHead(b, majorText, uint64(len(i)))
+	b.WriteString(string(i))
+}
+
+func (i arrayItem) encode(b *bytes.Buffer) {
+	writeHead(b, majorArray, uint64(len(i)))
+	for _, v := range i {
+		v.encode(b)
+	}
+}
+
+func (i mapItem) encode(b *bytes.Buffer) {
+	writeHead(b, majorMap, uint64(len(i)))
+	for _, e := range i {
+		writeHead(b, majorUint, e.key)
+		e.value.encode(b)
+	}
+}
+
+func (i tagItem) encode(b *bytes.Buffer) {
+	writeHead(b, majorTag, i.tag)
+	i.value.encode(b)
+}
+
+// writeHead writes the initial byte of a data item of type major, with its
+// argument n in the shortest form that holds it.
+func writeHead(b *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		b.WriteByte(major | byte(n))
+	case n <= 0xff:
+		b.Write([]byte{major | 24, byte(n)})
+	case n <= 0xffff:
+		b.WriteByte(major | 25)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= 0xffffffff:
+		b.WriteByte(major | 26)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		b.WriteByte(major | 27)
+		b.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// marshal returns the encoding of i.
+func marshal(i item) []byte {
+	var b bytes.Buffer
+	i.encode(&b)
+	return b.Bytes()
+}
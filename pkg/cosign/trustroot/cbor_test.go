@@ -0,0 +1,52 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustroot
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Vectors from RFC 8949 appendix A.
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		item item
+		want string
+	}{
+		{"0", intItem(0), "00"},
+		{"23", intItem(23), "17"},
+		{"24", intItem(24), "1818"},
+		{"1000", intItem(1000), "1903e8"},
+		{"1000000", intItem(1000000), "1a000f4240"},
+		{"1000000000000", intItem(1000000000000), "1b000000e8d4a51000"},
+		{"-1", intItem(-1), "20"},
+		{"-1000", intItem(-1000), "3903e7"},
+		{"empty bytes", bytesItem{}, "40"},
+		{"bytes", bytesItem{1, 2, 3, 4}, "4401020304"},
+		{"text", textItem("IETF"), "6449455446"},
+		{"empty array", arrayItem{}, "80"},
+		{"nested array", arrayItem{intItem(1), arrayItem{intItem(2), intItem(3)}}, "8201820203"},
+		{"map", mapItem{{1, intItem(2)}, {3, intItem(4)}}, "a201020304"},
+		{"epoch time", tagItem{tag: tagEpochTime, value: intItem(1363896240)}, "c11a514b67b0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hex.EncodeToString(marshal(tc.item)); got != tc.want {
+				t.Errorf("marshal() = %s, wanted %s", got, tc.want)
+			}
+		})
+	}
+}
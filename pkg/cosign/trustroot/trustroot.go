@@ -0,0 +1,196 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trustroot encodes Sigstore trust material in a compact CBOR form
+// for verifiers that cannot parse the trusted root JSON, such as bootloaders
+// and edge agents.
+//
+// The encoding is deterministic (RFC 8949 section 4.2), so the same trust
+// material always encodes to the same bytes. It is a map keyed by small
+// integers:
+//
+//	1: format version, FormatVersion
+//	2: certificate authorities, an array of authorities
+//	3: transparency logs, an array of logs
+//	4: certificate transparency logs, an array of logs
+//	5: timestamp authorities, an array of authorities
+//
+// An authority is a map of
+//
+//	1: URI, a text string
+//	2: certificate chain, an array of DER certificates starting at the root
+//	3: start of the validity period, an epoch time (tag 1)
+//	4: end of the validity period, an epoch time (tag 1)
+//
+// and a log is a map of
+//
+//	1: base URL, a text string
+//	2: log ID, a byte string
+//	3: public key, a DER SubjectPublicKeyInfo
+//	4: start of the validity period, an epoch time (tag 1)
+//	5: end of the validity period, an epoch time (tag 1)
+//
+// Empty arrays, empty strings and unbounded validity periods are omitted.
+package trustroot
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// FormatVersion is the version of the encoding written by MarshalCBOR.
+const FormatVersion = 1
+
+// TrustRoot is the trust material verification relies on.
+type TrustRoot struct {
+	// CertificateAuthorities issue the certificates of keyless signers.
+	CertificateAuthorities []Authority
+	// TransparencyLogs are the Rekor instances signatures are logged to.
+	TransparencyLogs []TransparencyLog
+	// CTLogs are the certificate transparency logs certificates are logged to.
+	CTLogs []TransparencyLog
+	// TimestampAuthorities issue RFC 3161 timestamps over signatures.
+	TimestampAuthorities []Authority
+}
+
+// Authority is a certificate or timestamp authority.
+type Authority struct {
+	URI string
+	// Certificates is the chain of the authority, starting at its root.
+	Certificates        []*x509.Certificate
+	ValidityPeriodStart time.Time
+	ValidityPeriodEnd   time.Time
+}
+
+// TransparencyLog is a log signing its entries with PublicKey.
+type TransparencyLog struct {
+	BaseURL string
+	// ID identifies the log. The SHA-256 digest of its DER public key is
+	// used if unset.
+	ID                  []byte
+	PublicKey           crypto.PublicKey
+	ValidityPeriodStart time.Time
+	ValidityPeriodEnd   time.Time
+}
+
+// MarshalCBOR returns the compact encoding of tr described in the package
+// documentation. Logs are ordered by ID so that the encoding does not depend
+// on the order they were listed in.
+func (tr *TrustRoot) MarshalCBOR() ([]byte, error) {
+	m := mapItem{{1, intItem(FormatVersion)}}
+	cas, err := authoritiesItem(tr.CertificateAuthorities)
+	if err != nil {
+		return nil, fmt.Errorf("certificate authority: %w", err)
+	}
+	tlogs, err := logsItem(tr.TransparencyLogs)
+	if err != nil {
+		return nil, fmt.Errorf("transparency log: %w", err)
+	}
+	ctlogs, err := logsItem(tr.CTLogs)
+	if err != nil {
+		return nil, fmt.Errorf("CT log: %w", err)
+	}
+	tsas, err := authoritiesItem(tr.TimestampAuthorities)
+	if err != nil {
+		return nil, fmt.Errorf("timestamp authority: %w", err)
+	}
+	for i, v := range []arrayItem{cas, tlogs, ctlogs, tsas} {
+		if len(v) > 0 {
+			m = append(m, mapEntry{uint64(i + 2), v})
+		}
+	}
+	return marshal(m), nil
+}
+
+func authoritiesItem(authorities []Authority) (arrayItem, error) {
+	items := make(arrayItem, 0, len(authorities))
+	for _, a := range authorities {
+		if len(a.Certificates) == 0 {
+			return nil, fmt.Errorf("%s has no certificates", a.URI)
+		}
+		chain := make(arrayItem, 0, len(a.Certificates))
+		for _, cert := range a.Certificates {
+			if cert == nil {
+				return nil, fmt.Errorf("%s has a missing certificate", a.URI)
+			}
+			chain = append(chain, bytesItem(cert.Raw))
+		}
+		var m mapItem
+		if a.URI != "" {
+			m = append(m, mapEntry{1, textItem(a.URI)})
+		}
+		m = append(m, mapEntry{2, chain})
+		m = appendValidity(m, 3, a.ValidityPeriodStart, a.ValidityPeriodEnd)
+		items = append(items, m)
+	}
+	return items, nil
+}
+
+func logsItem(logs []TransparencyLog) (arrayItem, error) {
+	type encodedLog struct {
+		id   []byte
+		item mapItem
+	}
+	encoded := make([]encodedLog, 0, len(logs))
+	for _, l := range logs {
+		if l.PublicKey == nil {
+			return nil, fmt.Errorf("%s has no public key", l.BaseURL)
+		}
+		der, err := x509.MarshalPKIXPublicKey(l.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling public key of %s: %w", l.BaseURL, err)
+		}
+		id := l.ID
+		if len(id) == 0 {
+			sum := sha256.Sum256(der)
+			id = sum[:]
+		}
+		var m mapItem
+		if l.BaseURL != "" {
+			m = append(m, mapEntry{1, textItem(l.BaseURL)})
+		}
+		m = append(m, mapEntry{2, bytesItem(id)}, mapEntry{3, bytesItem(der)})
+		m = appendValidity(m, 4, l.ValidityPeriodStart, l.ValidityPeriodEnd)
+		encoded = append(encoded, encodedLog{id: id, item: m})
+	}
+	slices.SortStableFunc(encoded, func(a, b encodedLog) int {
+		return bytes.Compare(a.id, b.id)
+	})
+	items := make(arrayItem, 0, len(encoded))
+	for _, l := range encoded {
+		items = append(items, l.item)
+	}
+	return items, nil
+}
+
+// appendValidity appends the bounds of a validity period that are set to m,
+// under the keys key and key+1.
+func appendValidity(m mapItem, key uint64, start, end time.Time) mapItem {
+	if !start.IsZero() {
+		m = append(m, mapEntry{key, epochTime(start)})
+	}
+	if !end.IsZero() {
+		m = append(m, mapEntry{key + 1, epochTime(end)})
+	}
+	return m
+}
+
+func epochTime(t time.Time) item {
+	return tagItem{tag: tagEpochTime, value: intItem(t.Unix())}
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustroot
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/test"
+)
+
+func TestMarshalCBOR(t *testing.T) {
+	rootCert, rootKey, err := test.GenerateRootCa()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subCert, _, err := test.GenerateSubordinateCa(rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorDER, err := x509.MarshalPKIXPublicKey(rekorKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorID := sha256.Sum256(rekorDER)
+	start := time.Unix(1700000000, 0)
+	end := time.Unix(1800000000, 0)
+
+	tr := &TrustRoot{
+		CertificateAuthorities: []Authority{{
+			URI:                 "https://fulcio.example.com",
+			Certificates:        []*x509.Certificate{rootCert, subCert},
+			ValidityPeriodStart: start,
+		}},
+		TransparencyLogs: []TransparencyLog{{
+			ID:                  []byte{0xff},
+			PublicKey:           rekorKey.Public(),
+			ValidityPeriodStart: start,
+			ValidityPeriodEnd:   end,
+		}, {
+			BaseURL:   "https://rekor.example.com",
+			PublicKey: rekorKey.Public(),
+		}},
+	}
+	got, err := tr.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("MarshalCBOR() = %v", err)
+	}
+
+	want := marshal(mapItem{
+		{1, intItem(FormatVersion)},
+		{2, arrayItem{mapItem{
+			{1, textItem("https://fulcio.example.com")},
+			{2, arrayItem{bytesItem(rootCert.Raw), bytesItem(subCert.Raw)}},
+			{3, tagItem{tag: tagEpochTime, value: intItem(1700000000)}},
+		}}},
+		// Logs are ordered by ID, the second one defaulting to the digest
+		// of its key.
+		{3, arrayItem{mapItem{
+			{1, textItem("https://rekor.example.com")},
+			{2, bytesItem(rekorID[:])},
+			{3, bytesItem(rekorDER)},
+		}, mapItem{
+			{2, bytesItem{0xff}},
+			{3, bytesItem(rekorDER)},
+			{4, tagItem{tag: tagEpochTime, value: intItem(1700000000)}},
+			{5, tagItem{tag: tagEpochTime, value: intItem(1800000000)}},
+		}}},
+	})
+	if !bytes.Equal(got, want) {
+		t.Errorf("MarshalCBOR() = %x, wanted %x", got, want)
+	}
+
+	// The encoding does not depend on the order logs are listed in.
+	tr.TransparencyLogs[0], tr.TransparencyLogs[1] = tr.TransparencyLogs[1], tr.TransparencyLogs[0]
+	if again, err := tr.MarshalCBOR(); err != nil || !bytes.Equal(again, got) {
+		t.Errorf("MarshalCBOR() = %x, %v after reordering logs, wanted %x", again, err, got)
+	}
+}
+
+func TestMarshalCBOREmpty(t *testing.T) {
+	got, err := (&TrustRoot{}).MarshalCBOR()
+	if err != nil {
+		t.Fatalf("MarshalCBOR() = %v", err)
+	}
+	if want := []byte{0xa1, 0x01, FormatVersion}; !bytes.Equal(got, want) {
+		t.Errorf("MarshalCBOR() = %x, wanted %x", got, want)
+	}
+}
+
+func TestMarshalCBORInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		tr   TrustRoot
+	}{{
+		name: "authority without certificates",
+		tr:   TrustRoot{CertificateAuthorities: []Authority{{URI: "https://fulcio.example.com"}}},
+	}, {
+		name: "missing certificate",
+		tr:   TrustRoot{TimestampAuthorities: []Authority{{Certificates: []*x509.Certificate{nil}}}},
+	}, {
+		name: "log without public key",
+		tr:   TrustRoot{CTLogs: []TransparencyLog{{BaseURL: "https://ctfe.example.com"}}},
+	}, {
+		name: "unsupported public key",
+		tr:   TrustRoot{TransparencyLogs: []TransparencyLog{{PublicKey: "not a key"}}},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.tr.MarshalCBOR(); err == nil {
+				t.Error("MarshalCBOR() succeeded, wanted an error")
+			}
+		})
+	}
+}
@@ -33,9 +33,12 @@ const (
 )
 
 type TSACertificates struct {
-	LeafCert          *x509.Certificate
-	IntermediateCerts []*x509.Certificate
-	RootCert          []*x509.Certificate
+	LeafCert *x509.Certificate
+	// AdditionalLeafCerts are the leaf certificates of further TSAs, when
+	// the chain holds the chains of several.
+	AdditionalLeafCerts []*x509.Certificate
+	IntermediateCerts   []*x509.Certificate
+	RootCert            []*x509.Certificate
 }
 
 type GetTargetStub func(ctx context.Context, usage tuf.UsageKind, names []string) ([]byte, error)
@@ -114,8 +117,8 @@ func GetTSACerts(ctx context.Context, certChainPath string, fn GetTargetStub) (*
 		return nil, fmt.Errorf("error splitting TSA certificates: %w", err)
 	}
 
-	if len(leaves) != 1 {
-		return nil, fmt.Errorf("TSA certificate chain must contain exactly one leaf certificate")
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("TSA certificate chain must contain at least one leaf certificate")
 	}
 
 	if len(roots) == 0 {
@@ -123,9 +126,10 @@ func GetTSACerts(ctx context.Context, certChainPath string, fn GetTargetStub) (*
 	}
 
 	return &TSACertificates{
-		LeafCert:          leaves[0],
-		IntermediateCerts: intermediates,
-		RootCert:          roots,
+		LeafCert:            leaves[0],
+		AdditionalLeafCerts: leaves[1:],
+		IntermediateCerts:   intermediates,
+		RootCert:            roots,
 	}, nil
 }
 
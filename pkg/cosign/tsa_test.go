@@ -96,6 +96,22 @@ func TestGetTSACertsFromPath(t *testing.T) {
 	require.Len(t, tsaCerts.RootCert, 1)
 }
 
+func TestGetTSACertsMultipleLeaves(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "tsa_cert_chain_path.pem")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+
+	// The chains of two TSAs, sharing the same root.
+	_, err = tempFile.Write([]byte(testLeafCert + "\n" + testRootCert + "\n" + testLeafCert))
+	require.NoError(t, err)
+
+	tsaCerts, err := GetTSACerts(context.Background(), tempFile.Name(), GetTufTargets)
+	require.NoError(t, err)
+	require.NotNil(t, tsaCerts.LeafCert)
+	require.Len(t, tsaCerts.AdditionalLeafCerts, 1)
+	require.Len(t, tsaCerts.RootCert, 1)
+}
+
 func TestGetTSACertsFromTUF(t *testing.T) {
 	originalValue := os.Getenv("SIGSTORE_TSA_CERTIFICATE_FILE")
 	os.Unsetenv("SIGSTORE_TSA_CERTIFICATE_FILE")
@@ -31,28 +31,46 @@ import (
 
 // SimpleClaimVerifier verifies that sig.Payload() is a SimpleContainerImage payload which references the given image digest and contains the given annotations.
 func SimpleClaimVerifier(sig oci.Signature, imageDigest v1.Hash, annotations map[string]interface{}) error {
+	_, err := verifySimpleClaims(sig, imageDigest, annotations)
+	return err
+}
+
+// AnnotationConditionsClaimVerifier returns a claim verifier that checks the
+// claims as SimpleClaimVerifier does and the signed annotations with conds.
+func AnnotationConditionsClaimVerifier(conds AnnotationConditions) func(oci.Signature, v1.Hash, map[string]interface{}) error {
+	return func(sig oci.Signature, imageDigest v1.Hash, annotations map[string]interface{}) error {
+		ss, err := verifySimpleClaims(sig, imageDigest, annotations)
+		if err != nil {
+			return err
+		}
+		return conds.Check(ss.Optional)
+	}
+}
+
+// verifySimpleClaims is SimpleClaimVerifier, returning the verified payload.
+func verifySimpleClaims(sig oci.Signature, imageDigest v1.Hash, annotations map[string]interface{}) (*payload.SimpleContainerImage, error) {
 	p, err := sig.Payload()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	ss := &payload.SimpleContainerImage{}
 	if err := json.Unmarshal(p, ss); err != nil {
-		return err
+		return nil, err
 	}
 
 	foundDgst := ss.Critical.Image.DockerManifestDigest
 	if foundDgst != imageDigest.String() {
-		return fmt.Errorf("invalid or missing digest in claim: %s", foundDgst)
+		return nil, fmt.Errorf("invalid or missing digest in claim: %s", foundDgst)
 	}
 
 	if annotations != nil {
 		if !correctAnnotations(annotations, ss.Optional) {
-			return errors.New("missing or incorrect annotation")
+			return nil, errors.New("missing or incorrect annotation")
 		}
 	}
 
-	return nil
+	return ss, nil
 }
 
 // IntotoSubjectClaimVerifier verifies that sig.Payload() is an Intoto statement which references the given image digest.
@@ -26,12 +26,17 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
+	stderrors "errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"net/http"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -56,6 +61,7 @@ import (
 	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/layout"
 	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/walk"
 	"github.com/franchb/rekor/pkg/generated/client"
 	"github.com/franchb/rekor/pkg/generated/models"
 	rekor_types "github.com/franchb/rekor/pkg/types"
@@ -84,6 +90,12 @@ type Identity struct {
 }
 
 // CheckOpts are the options for checking signatures.
+//
+// Verification never modifies CheckOpts, so the same options may be used by
+// concurrent verifications as long as no one modifies them meanwhile; use
+// Clone to derive options for a single verification. Callbacks such as
+// ClaimVerifier, IdentityMatchTrace and IdentityVerifier may then be called
+// concurrently.
 type CheckOpts struct {
 	// RegistryClientOpts are the options for interacting with the container registry.
 	RegistryClientOpts []ociremote.Option
@@ -102,11 +114,28 @@ type CheckOpts struct {
 	// Note that even though the type is of crypto.PublicKey, Rekor only allows
 	// for ecdsa.PublicKey: https://github.com/franchb/cosign/issues/2540
 	RekorPubKeys *TrustedTransparencyLogPubKeys
+	// AdditionalRekorClients, if set, are consulted in turn for an online tlog
+	// lookup when no entry is found using RekorClient. They are keyed by URL,
+	// which is used when reporting per-log results. This allows accepting
+	// entries from any of a set of logs while migrating between Rekor instances.
+	// Entries must still be signed by a key in RekorPubKeys.
+	AdditionalRekorClients map[string]*client.Rekor
+	// CheckpointWitnesses, if set, only accepts tlog entries whose inclusion
+	// proof is for a checkpoint cosigned by enough of these witnesses. As
+	// bundles only hold a signed entry timestamp, the entries are then
+	// looked up online.
+	CheckpointWitnesses *CheckpointWitnesses
 
 	// SigVerifier is used to verify signatures.
 	SigVerifier signature.Verifier
 	// PKOpts are the options provided to `SigVerifier.PublicKey()`.
 	PKOpts []signature.PublicKeyOption
+	// RequireCertifiedKey, if set with SigVerifier, also requires signatures
+	// to carry a certificate for the public key of SigVerifier that chains
+	// up to RootCerts and satisfies Identities, such as one Fulcio issued
+	// for a long-lived KMS key. Both the key and the identity are then
+	// pinned.
+	RequireCertifiedKey bool
 
 	// RootCerts are the root CA certs used to verify a signature's chained certificate.
 	RootCerts *x509.CertPool
@@ -141,6 +170,16 @@ type CheckOpts struct {
 	// Identities is an array of Identity (Subject, Issuer) matchers that have
 	// to be met for the signature to ve valid.
 	Identities []Identity
+	// URINormalization relaxes how URI SANs are matched with Identities.
+	URINormalization URINormalization
+	// IdentityMatchTrace, if set, is called with the SAN and identity that
+	// satisfied Identities.
+	IdentityMatchTrace func(IdentityMatch)
+	// IdentityVerifier, if set, is consulted for every certificate that
+	// chains up to a trusted root and satisfies Identities, and may reject
+	// it. It lets identity schemes that Identities cannot express be checked
+	// without giving up the rest of the verification.
+	IdentityVerifier IdentityVerifier
 
 	// Force offline verification of the signature
 	Offline bool
@@ -148,6 +187,9 @@ type CheckOpts struct {
 	// Set of flags to verify an RFC3161 timestamp used for trusted timestamping
 	// TSACertificate is the certificate used to sign the timestamp. Optional, if provided in the timestamp
 	TSACertificate *x509.Certificate
+	// AdditionalTSACertificates are the certificates of further trusted TSAs,
+	// any of which may have signed one of the timestamps of a signature.
+	AdditionalTSACertificates []*x509.Certificate
 	// TSARootCertificates are the set of roots to verify the TSA certificate
 	TSARootCertificates []*x509.Certificate
 	// TSAIntermediateCertificates are the set of intermediates for chain building
@@ -159,10 +201,75 @@ type CheckOpts struct {
 	// The amount of maximum workers for parallel executions.
 	// Defaults to 10.
 	MaxWorkers int
+	// FirstMatch, if set, stops at the first signature or attestation that
+	// verifies. They are then streamed with oci.Iterate and verified one at
+	// a time, so the remaining ones are neither fetched nor held in memory.
+	// It is ignored when RequiredSignatures asks for more than one signer.
+	FirstMatch bool
+	// RequiredSignatures, if greater than one, only accepts an image if its
+	// signatures or attestations that verify were made by at least that
+	// many distinct signers: distinct keys of a KeyRing SigVerifier, or
+	// distinct identities (OIDC issuer and subject alternative names) of the
+	// certificates when verifying without a key.
+	RequiredSignatures int
 
 	// Should the experimental OCI 1.1 behaviour be enabled or not.
 	// Defaults to false.
 	ExperimentalOCI11 bool
+
+	// ResultCache, if set, records successful verifications of images
+	// referenced by digest, keyed by the digest and PolicyKey.
+	ResultCache VerificationResultCache
+	// PolicyKey identifies the policy these options enforce. Cached results
+	// are only reused for the same PolicyKey, so it must change whenever any
+	// option affecting the verification outcome changes.
+	PolicyKey string
+	// StaleIfUnavailable, if set, returns the result cached in ResultCache
+	// when the registry is unreachable, with every signature flagged by
+	// IsStale. By default verification fails when the registry is unavailable.
+	StaleIfUnavailable bool
+
+	// Coalescer, if set, shares the result of a verification of an image
+	// digest with concurrent verifications of it for the same PolicyKey, see
+	// VerificationCoalescer. As with ResultCache, PolicyKey must differ
+	// between options that may verify differently.
+	Coalescer *VerificationCoalescer
+
+	// KeyConstraints, if set, restricts what SigVerifier may verify, see
+	// LoadKeyConstraints. They are ignored when verifying with certificates.
+	KeyConstraints *KeyConstraints
+}
+
+// Clone returns a copy of co that can be modified without affecting co. The
+// slices, maps and certificate pools it holds are copied, while verifiers,
+// clients and caches are shared.
+func (co *CheckOpts) Clone() *CheckOpts {
+	c := *co
+	c.RegistryClientOpts = slices.Clone(co.RegistryClientOpts)
+	c.Annotations = maps.Clone(co.Annotations)
+	c.AdditionalRekorClients = maps.Clone(co.AdditionalRekorClients)
+	c.PKOpts = slices.Clone(co.PKOpts)
+	if co.RootCerts != nil {
+		c.RootCerts = co.RootCerts.Clone()
+	}
+	if co.IntermediateCerts != nil {
+		c.IntermediateCerts = co.IntermediateCerts.Clone()
+	}
+	c.SCT = slices.Clone(co.SCT)
+	c.Identities = slices.Clone(co.Identities)
+	c.AdditionalTSACertificates = slices.Clone(co.AdditionalTSACertificates)
+	c.TSARootCertificates = slices.Clone(co.TSARootCertificates)
+	c.TSAIntermediateCertificates = slices.Clone(co.TSAIntermediateCertificates)
+	return &c
+}
+
+// keyConstraints returns the KeyConstraints to enforce, or nil if they do not
+// apply because no key is used.
+func (co *CheckOpts) keyConstraints() *KeyConstraints {
+	if co.SigVerifier == nil {
+		return nil
+	}
+	return co.KeyConstraints
 }
 
 // This is a substitutable signature verification function that can be used for verifying
@@ -309,8 +416,28 @@ func CheckCertificatePolicy(cert *x509.Certificate, co *CheckOpts) error {
 	if err := validateCertExtensions(ce, co); err != nil {
 		return err
 	}
+	match, err := matchIdentities(cert, ce, co)
+	if err != nil {
+		return err
+	}
+	if co.IdentityVerifier != nil {
+		if allow, reason := co.IdentityVerifier.VerifyIdentity(cert, ce); !allow {
+			return &VerificationFailure{
+				fmt.Errorf("certificate identity rejected: %s", reason),
+			}
+		}
+	}
+	if match != nil && co.IdentityMatchTrace != nil {
+		co.IdentityMatchTrace(*match)
+	}
+	return nil
+}
+
+// matchIdentities checks that cert matches one of co.Identities, if any,
+// returning the match.
+func matchIdentities(cert *x509.Certificate, ce CertExtensions, co *CheckOpts) (*IdentityMatch, error) {
 	oidcIssuer := ce.GetIssuer()
-	sans := cryptoutils.GetSubjectAlternateNames(cert)
+	sans := SubjectAlternativeNames(cert)
 	// If there are identities given, go through them and if one of them
 	// matches, call that good, otherwise, return an error.
 	if len(co.Identities) > 0 {
@@ -320,7 +447,7 @@ func CheckCertificatePolicy(cert *x509.Certificate, co *CheckOpts) error {
 			// Check the issuer first
 			case identity.IssuerRegExp != "":
 				if regex, err := regexp.Compile(identity.IssuerRegExp); err != nil {
-					return fmt.Errorf("malformed issuer in identity: %s : %w", identity.IssuerRegExp, err)
+					return nil, fmt.Errorf("malformed issuer in identity: %s : %w", identity.IssuerRegExp, err)
 				} else if regex.MatchString(oidcIssuer) {
 					issuerMatches = true
 				}
@@ -334,23 +461,24 @@ func CheckCertificatePolicy(cert *x509.Certificate, co *CheckOpts) error {
 			}
 
 			// Then the subject
+			var matched *SubjectAlternativeName
 			subjectMatches := false
 			switch {
 			case identity.SubjectRegExp != "":
 				regex, err := regexp.Compile(identity.SubjectRegExp)
 				if err != nil {
-					return fmt.Errorf("malformed subject in identity: %s : %w", identity.SubjectRegExp, err)
+					return nil, fmt.Errorf("malformed subject in identity: %s : %w", identity.SubjectRegExp, err)
 				}
-				for _, san := range sans {
-					if regex.MatchString(san) {
-						subjectMatches = true
+				for i, san := range sans {
+					if regex.MatchString(san.Value) || (san.Type == SANURI && regex.MatchString(co.URINormalization.Normalize(san.Value))) {
+						matched, subjectMatches = &sans[i], true
 						break
 					}
 				}
 			case identity.Subject != "":
-				for _, san := range sans {
-					if san == identity.Subject {
-						subjectMatches = true
+				for i, san := range sans {
+					if san.Value == identity.Subject || (san.Type == SANURI && co.URINormalization.Normalize(san.Value) == co.URINormalization.Normalize(identity.Subject)) {
+						matched, subjectMatches = &sans[i], true
 						break
 					}
 				}
@@ -360,14 +488,18 @@ func CheckCertificatePolicy(cert *x509.Certificate, co *CheckOpts) error {
 			}
 			if subjectMatches && issuerMatches {
 				// If both issuer / subject match, return verified
-				return nil
+				return &IdentityMatch{Identity: identity, SAN: matched, Issuer: oidcIssuer}, nil
 			}
 		}
-		return &VerificationFailure{
-			fmt.Errorf("none of the expected identities matched what was in the certificate, got subjects [%s] with issuer %s", strings.Join(sans, ", "), oidcIssuer),
+		got := make([]string, 0, len(sans))
+		for _, san := range sans {
+			got = append(got, san.Value)
+		}
+		return nil, &VerificationFailure{
+			fmt.Errorf("none of the expected identities matched what was in the certificate, got subjects [%s] with issuer %s", strings.Join(got, ", "), oidcIssuer),
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 func validateCertExtensions(ce CertExtensions, co *CheckOpts) error {
@@ -420,21 +552,21 @@ func ValidateAndUnpackCertWithChain(cert *x509.Certificate, chain []*x509.Certif
 	if len(chain) == 0 {
 		return nil, errors.New("no chain provided to validate certificate")
 	}
-	rootPool := x509.NewCertPool()
-	rootPool.AddCert(chain[len(chain)-1])
-	co.RootCerts = rootPool
+	// Validate against the chain with a copy, leaving co as it was.
+	chainOpts := *co
+	chainOpts.RootCerts = x509.NewCertPool()
+	chainOpts.RootCerts.AddCert(chain[len(chain)-1])
 
-	subPool := x509.NewCertPool()
+	chainOpts.IntermediateCerts = x509.NewCertPool()
 	for _, c := range chain[:len(chain)-1] {
-		subPool.AddCert(c)
+		chainOpts.IntermediateCerts.AddCert(c)
 	}
-	co.IntermediateCerts = subPool
 
-	return ValidateAndUnpackCert(cert, co)
+	return ValidateAndUnpackCert(cert, &chainOpts)
 }
 
 func tlogValidateEntry(ctx context.Context, client *client.Rekor, rekorPubKeys *TrustedTransparencyLogPubKeys,
-	sig oci.Signature, pem []byte) (*models.LogEntryAnon, error) {
+	witnesses *CheckpointWitnesses, sig oci.Signature, pem []byte) (*models.LogEntryAnon, error) {
 	b64sig, err := sig.Base64Signature()
 	if err != nil {
 		return nil, err
@@ -461,6 +593,12 @@ func tlogValidateEntry(ctx context.Context, client *client.Rekor, rekorPubKeys *
 			entryVerificationErrs = append(entryVerificationErrs, err.Error())
 			continue
 		}
+		if witnesses != nil {
+			if err := verifyTLogEntryWitnesses(&entry, witnesses); err != nil {
+				entryVerificationErrs = append(entryVerificationErrs, err.Error())
+				continue
+			}
+		}
 		entryTime := time.Unix(*entry.IntegratedTime, 0)
 		if earliestLogEntryTime == nil || entryTime.Before(*earliestLogEntryTime) {
 			earliestLogEntryTime = &entryTime
@@ -473,6 +611,40 @@ func tlogValidateEntry(ctx context.Context, client *client.Rekor, rekorPubKeys *
 	return &earliestLogEntry, nil
 }
 
+// tlogValidateEntryFromLogs looks up and validates the tlog entry for sig,
+// first using co.RekorClient and then each of co.AdditionalRekorClients,
+// returning the first valid entry found. If none of the logs has a valid
+// entry, the returned error contains the result for each log.
+func tlogValidateEntryFromLogs(ctx context.Context, co *CheckOpts, sig oci.Signature, pem []byte) (*models.LogEntryAnon, error) {
+	if len(co.AdditionalRekorClients) == 0 {
+		return tlogValidateEntry(ctx, co.RekorClient, co.RekorPubKeys, co.CheckpointWitnesses, sig, pem)
+	}
+
+	var logErrs []string
+	if co.RekorClient != nil {
+		e, err := tlogValidateEntry(ctx, co.RekorClient, co.RekorPubKeys, co.CheckpointWitnesses, sig, pem)
+		if err == nil {
+			return e, nil
+		}
+		logErrs = append(logErrs, fmt.Sprintf("primary log: %v", err))
+	}
+
+	urls := make([]string, 0, len(co.AdditionalRekorClients))
+	for url := range co.AdditionalRekorClients {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		e, err := tlogValidateEntry(ctx, co.AdditionalRekorClients[url], co.RekorPubKeys, co.CheckpointWitnesses, sig, pem)
+		if err == nil {
+			ui.Infof(ctx, "tlog entry verified using %s", url)
+			return e, nil
+		}
+		logErrs = append(logErrs, fmt.Sprintf("%s: %v", url, err))
+	}
+	return nil, fmt.Errorf("no valid tlog entries found in any log: %s", strings.Join(logErrs, "; "))
+}
+
 type fakeOCISignatures struct {
 	oci.Signatures
 	signatures []oci.Signature
@@ -482,11 +654,78 @@ func (fos *fakeOCISignatures) Get() ([]oci.Signature, error) {
 	return fos.signatures, nil
 }
 
+// signatureFetchOpts returns the registry options used to fetch signature and
+// attestation layers. When every accepted identity is an exact issuer and
+// subject pair, layers indexed with a different identity are skipped before
+// they are downloaded.
+func signatureFetchOpts(co *CheckOpts) []ociremote.Option {
+	if len(co.Identities) == 0 {
+		return co.RegistryClientOpts
+	}
+	hashes := make([]string, 0, len(co.Identities))
+	for _, id := range co.Identities {
+		if id.Issuer == "" || id.Subject == "" || id.IssuerRegExp != "" || id.SubjectRegExp != "" {
+			return co.RegistryClientOpts
+		}
+		hashes = append(hashes, static.IdentityHash(id.Issuer, id.Subject))
+	}
+	opts := make([]ociremote.Option, 0, len(co.RegistryClientOpts)+1)
+	opts = append(opts, co.RegistryClientOpts...)
+	return append(opts, ociremote.WithIdentityHashFilter(hashes...))
+}
+
+// verifyIndexed fetches the signatures or attestations tagged st, skipping
+// layers whose index annotations rule them out, and verifies them. The index
+// annotations are not signed and may be wrong, so if nothing verifies after
+// layers were skipped, verification is retried against every layer.
+func verifyIndexed(ctx context.Context, st name.Reference, co *CheckOpts, verify func(oci.Signatures) ([]oci.Signature, bool, error)) ([]oci.Signature, bool, error) {
+	sigs, err := ociremote.Signatures(st, signatureFetchOpts(co)...)
+	if err != nil {
+		return nil, false, err
+	}
+	checked, bundleVerified, err := verify(sigs)
+	skipped := ociremote.SkippedByIndex(sigs)
+	if skipped == 0 {
+		return checked, bundleVerified, err
+	}
+	ui.Infof(ctx, "skipped %d layers of %s whose identity or predicate type index annotations did not match", skipped, st)
+	if err == nil {
+		return checked, bundleVerified, nil
+	}
+
+	ui.Warnf(ctx, "no layers verified after skipping by index annotations, retrying with all %d skipped layers included", skipped)
+	opts := make([]ociremote.Option, 0, len(co.RegistryClientOpts)+1)
+	opts = append(opts, co.RegistryClientOpts...)
+	sigs, err = ociremote.Signatures(st, append(opts, ociremote.WithoutIndexFilters())...)
+	if err != nil {
+		return nil, false, err
+	}
+	checked, bundleVerified, err = verify(sigs)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w (also checked %d layers first skipped by their index annotations)", err, skipped)
+	}
+	return checked, bundleVerified, nil
+}
+
 // VerifyImageSignatures does all the main cosign checks in a loop, returning the verified signatures.
 // If there were no valid signatures, we return an error.
 // Note that if co.ExperimentlOCI11 is set, we will attempt to verify
 // signatures using the experimental OCI 1.1 behavior.
+// See CheckOpts.StaleIfUnavailable for serving cached results during registry
+// outages, and CheckOpts.Coalescer for sharing concurrent verifications.
 func VerifyImageSignatures(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) (checkedSignatures []oci.Signature, bundleVerified bool, err error) {
+	return verifyCoalesced("signatures", signedImgRef, co, func() ([]oci.Signature, bool, error) {
+		return verifyWithResultCache(ctx, "signatures", signedImgRef, co, func() ([]oci.Signature, bool, error) {
+			return verifyImageSignatures(ctx, signedImgRef, co)
+		})
+	})
+}
+
+func verifyImageSignatures(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) ([]oci.Signature, bool, error) {
+	if err := co.keyConstraints().checkRepository(signedImgRef); err != nil {
+		return nil, false, err
+	}
+
 	// Try first using OCI 1.1 behavior if experimental flag is set.
 	if co.ExperimentalOCI11 {
 		verified, bundleVerified, err := verifyImageSignaturesExperimentalOCI(ctx, signedImgRef, co)
@@ -516,22 +755,19 @@ func VerifyImageSignatures(ctx context.Context, signedImgRef name.Reference, co
 		return nil, false, err
 	}
 
-	var sigs oci.Signatures
 	sigRef := co.SignatureRef
 	if sigRef == "" {
 		st, err := ociremote.SignatureTag(digest, co.RegistryClientOpts...)
 		if err != nil {
 			return nil, false, err
 		}
-		sigs, err = ociremote.Signatures(st, co.RegistryClientOpts...)
-		if err != nil {
-			return nil, false, err
-		}
-	} else {
-		sigs, err = loadSignatureFromFile(ctx, sigRef, signedImgRef, co)
-		if err != nil {
-			return nil, false, err
-		}
+		return verifyIndexed(ctx, st, co, func(sigs oci.Signatures) ([]oci.Signature, bool, error) {
+			return verifySignatures(ctx, sigs, h, co)
+		})
+	}
+	sigs, err := loadSignatureFromFile(ctx, sigRef, signedImgRef, co)
+	if err != nil {
+		return nil, false, err
 	}
 
 	return verifySignatures(ctx, sigs, h, co)
@@ -586,7 +822,61 @@ func VerifyLocalImageSignatures(ctx context.Context, path string, co *CheckOpts)
 	return verifySignatures(ctx, sigs, h, co)
 }
 
+// VerifyLocalImageSignaturesRecursive verifies the signatures of the image or
+// image index saved at path by `cosign save`. For an image index, every image
+// and image index within it must also carry a verified signature; up to
+// co.MaxWorkers of them are verified at once.
+func VerifyLocalImageSignaturesRecursive(ctx context.Context, path string, co *CheckOpts) (checkedSignatures []oci.Signature, bundleVerified bool, err error) {
+	// Enforce this up front.
+	if co.RootCerts == nil && co.SigVerifier == nil {
+		return nil, false, errors.New("one of verifier or root certs is required")
+	}
+
+	se, err := layout.SignedImageIndex(path)
+	if err != nil {
+		return nil, false, err
+	}
+	ii, err := se.SignedImageIndex(v1.Hash{})
+	if err != nil {
+		return nil, false, err
+	}
+	if ii == nil {
+		return VerifyLocalImageSignatures(ctx, path, co)
+	}
+
+	bundleVerified = true
+	var mu sync.Mutex
+	if err := walk.SignedEntity(ctx, ii, func(ctx context.Context, se oci.SignedEntity) error {
+		h, err := se.(interface{ Digest() (v1.Hash, error) }).Digest()
+		if err != nil {
+			return err
+		}
+		sigs, err := se.Signatures()
+		if err != nil {
+			return err
+		}
+		if sigs == nil {
+			return fmt.Errorf("no signatures associated with %s saved in %s", h, path)
+		}
+		verified, bv, err := verifySignatures(ctx, sigs, h, co)
+		if err != nil {
+			return fmt.Errorf("verifying %s: %w", h, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		checkedSignatures = append(checkedSignatures, verified...)
+		bundleVerified = bundleVerified && bv
+		return nil
+	}, walk.WithConcurrency(co.MaxWorkers)); err != nil {
+		return nil, false, err
+	}
+	return checkedSignatures, bundleVerified, nil
+}
+
 func verifySignatures(ctx context.Context, sigs oci.Signatures, h v1.Hash, co *CheckOpts) (checkedSignatures []oci.Signature, bundleVerified bool, err error) {
+	if co != nil && co.FirstMatch && co.RequiredSignatures <= 1 {
+		return verifyFirstSignature(ctx, sigs, h, co)
+	}
 	sl, err := sigs.Get()
 	if err != nil {
 		return nil, false, err
@@ -650,10 +940,63 @@ func verifySignatures(ctx context.Context, sigs oci.Signatures, h v1.Hash, co *C
 			fmt.Errorf("no matching signatures: %s", strings.Join(combinedErrors, "\n ")),
 		}
 	}
+	if err := checkRequiredSignatures(ctx, checkedSignatures, verifyOCISignature, co); err != nil {
+		return nil, false, &ErrNoMatchingSignatures{
+			fmt.Errorf("no matching signatures: %w", err),
+		}
+	}
 
 	return checkedSignatures, bundleVerified, nil
 }
 
+// verifyFirstSignature is verifySignatures for co.FirstMatch.
+func verifyFirstSignature(ctx context.Context, sigs oci.Signatures, h v1.Hash, co *CheckOpts) ([]oci.Signature, bool, error) {
+	verified, bundleVerified, seen, errs, err := verifyFirst(sigs, func(sig oci.Signature) (oci.Signature, bool, error) {
+		sig, err := static.Copy(sig)
+		if err != nil {
+			return nil, false, err
+		}
+		bundleVerified, err := VerifyImageSignature(ctx, sig, h, co)
+		return sig, bundleVerified, err
+	})
+	switch {
+	case err != nil:
+		return nil, false, err
+	case seen == 0:
+		return nil, false, &ErrNoSignaturesFound{
+			errors.New("no signatures found"),
+		}
+	case verified == nil:
+		return nil, false, &ErrNoMatchingSignatures{
+			fmt.Errorf("no matching signatures: %s", strings.Join(errs, "\n ")),
+		}
+	}
+	return []oci.Signature{verified}, bundleVerified, nil
+}
+
+// verifyFirst streams sigs through verify until one of them verifies,
+// returning it along with the number of signatures seen and the errors of
+// those that did not verify. err is set only if sigs could not be read.
+func verifyFirst(sigs oci.Signatures, verify func(oci.Signature) (oci.Signature, bool, error)) (verified oci.Signature, bundleVerified bool, seen int, errs []string, err error) {
+	it, err := oci.Iterate(sigs)
+	if err != nil {
+		return nil, false, 0, nil, err
+	}
+	for sig, ok := it.Next(); ok; sig, ok = it.Next() {
+		seen++
+		v, bv, err := verify(sig)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		return v, bv, seen, nil, nil
+	}
+	if err := it.Err(); err != nil {
+		return nil, false, seen, nil, err
+	}
+	return nil, false, seen, errs, nil
+}
+
 // verifyInternal holds the main verification flow for signatures and attestations.
 //  1. Verifies the signature using the provided verifier.
 //  2. Checks for transparency log entry presence:
@@ -664,11 +1007,47 @@ func verifySignatures(ctx context.Context, sigs oci.Signatures, h v1.Hash, co *C
 func verifyInternal(ctx context.Context, sig oci.Signature, h v1.Hash,
 	verifyFn signatureVerificationFn, co *CheckOpts) (
 	bundleVerified bool, err error) {
+	bundleVerified, _, err = verifyInternalWithTimestamps(ctx, sig, h, verifyFn, co)
+	return bundleVerified, err
+}
+
+// verifyWithKeyRing verifies sig against each currently valid key of ring in
+// turn, accepting it as soon as one of them verifies it. Each key is checked
+// on its own, so that the transparency log is searched for the key that
+// signed.
+func verifyWithKeyRing(ctx context.Context, sig oci.Signature, h v1.Hash,
+	verifyFn signatureVerificationFn, co *CheckOpts, ring *KeyRing) (
+	bool, VerifiedTimestamps, error) {
+	verifiers := ring.Verifiers(time.Now())
+	if len(verifiers) == 0 {
+		return false, VerifiedTimestamps{}, &VerificationFailure{errNoValidKeyRingKey}
+	}
+	var errs []error
+	for _, v := range verifiers {
+		keyCo := co.Clone()
+		keyCo.SigVerifier = v
+		bundleVerified, timestamps, err := verifyInternalWithTimestamps(ctx, sig, h, verifyFn, keyCo)
+		if err == nil {
+			return bundleVerified, timestamps, nil
+		}
+		errs = append(errs, err)
+	}
+	return false, VerifiedTimestamps{}, keyRingError(errs)
+}
+
+// verifyInternalWithTimestamps is verifyInternal, also returning the
+// timestamps of the signature that were verified and accepted.
+func verifyInternalWithTimestamps(ctx context.Context, sig oci.Signature, h v1.Hash,
+	verifyFn signatureVerificationFn, co *CheckOpts) (
+	bundleVerified bool, timestamps VerifiedTimestamps, err error) {
+	if ring, ok := co.SigVerifier.(*KeyRing); ok {
+		return verifyWithKeyRing(ctx, sig, h, verifyFn, co, ring)
+	}
 	var acceptableRFC3161Time, acceptableRekorBundleTime *time.Time // Timestamps for the signature we accept, or nil if not applicable.
 
 	acceptableRFC3161Timestamp, err := VerifyRFC3161Timestamp(sig, co)
 	if err != nil {
-		return false, fmt.Errorf("unable to verify RFC3161 timestamp bundle: %w", err)
+		return false, VerifiedTimestamps{}, fmt.Errorf("unable to verify RFC3161 timestamp bundle: %w", err)
 	}
 	if acceptableRFC3161Timestamp != nil {
 		acceptableRFC3161Time = &acceptableRFC3161Timestamp.Time
@@ -677,36 +1056,39 @@ func verifyInternal(ctx context.Context, sig oci.Signature, h v1.Hash,
 	if !co.IgnoreTlog {
 		bundleVerified, err = VerifyBundle(sig, co)
 		if err != nil {
-			return false, fmt.Errorf("error verifying bundle: %w", err)
+			return false, VerifiedTimestamps{}, fmt.Errorf("error verifying bundle: %w", err)
 		}
 
-		if bundleVerified {
+		if bundleVerified && co.CheckpointWitnesses == nil {
 			// Update with the verified bundle's integrated time.
 			t, err := getBundleIntegratedTime(sig)
 			if err != nil {
-				return false, fmt.Errorf("error getting bundle integrated time: %w", err)
+				return false, VerifiedTimestamps{}, fmt.Errorf("error getting bundle integrated time: %w", err)
 			}
 			acceptableRekorBundleTime = &t
 		} else {
 			// If the --offline flag was specified, fail here. bundleVerified returns false with
 			// no error when there was no bundle provided.
 			if co.Offline {
-				return false, fmt.Errorf("offline verification failed")
+				if bundleVerified {
+					return false, VerifiedTimestamps{}, fmt.Errorf("offline verification failed: the bundle holds no checkpoint to verify the witness cosignatures of")
+				}
+				return false, VerifiedTimestamps{}, fmt.Errorf("offline verification failed")
 			}
 
 			// no Rekor client provided for an online lookup
-			if co.RekorClient == nil {
-				return false, fmt.Errorf("rekor client not provided for online verification")
+			if co.RekorClient == nil && len(co.AdditionalRekorClients) == 0 {
+				return false, VerifiedTimestamps{}, fmt.Errorf("rekor client not provided for online verification")
 			}
 
 			pemBytes, err := keyBytes(sig, co)
 			if err != nil {
-				return false, err
+				return false, VerifiedTimestamps{}, err
 			}
 
-			e, err := tlogValidateEntry(ctx, co.RekorClient, co.RekorPubKeys, sig, pemBytes)
+			e, err := tlogValidateEntryFromLogs(ctx, co, sig, pemBytes)
 			if err != nil {
-				return false, err
+				return false, VerifiedTimestamps{}, err
 			}
 			t := time.Unix(*e.IntegratedTime, 0)
 			acceptableRekorBundleTime = &t
@@ -717,57 +1099,32 @@ func verifyInternal(ctx context.Context, sig oci.Signature, h v1.Hash,
 	verifier := co.SigVerifier
 	if verifier == nil {
 		// If we don't have a public key to check against, we can try a root cert.
-		cert, err := sig.Cert()
-		if err != nil {
-			return false, err
-		}
-		if cert == nil {
-			return false, &ErrNoCertificateFoundOnSignature{
-				fmt.Errorf("no certificate found on signature"),
-			}
-		}
-		// Create a certificate pool for intermediate CA certificates, excluding the root
-		chain, err := sig.Chain()
+		verifier, err = certVerifier(sig, co)
 		if err != nil {
-			return false, err
-		}
-		// If there is no chain annotation present, we preserve the pools set in the CheckOpts.
-		var pool *x509.CertPool
-		if len(chain) > 1 {
-			if co.IntermediateCerts == nil {
-				// If the intermediate certs have not been loaded in by TUF
-				pool = x509.NewCertPool()
-				for _, cert := range chain[:len(chain)-1] {
-					pool.AddCert(cert)
-				}
-			}
+			return false, VerifiedTimestamps{}, err
 		}
-		// In case pool is not set than set it from co.IntermediateCerts
-		if pool == nil {
-			pool = co.IntermediateCerts
-		}
-		verifier, err = ValidateAndUnpackCertWithIntermediates(cert, co, pool)
-		if err != nil {
-			return false, err
+	} else if co.RequireCertifiedKey {
+		if err := verifyCertifiedKey(sig, co); err != nil {
+			return false, VerifiedTimestamps{}, err
 		}
 	}
 
 	// 1. Perform cryptographic verification of the signature using the certificate's public key.
 	if err := verifyFn(ctx, verifier, sig); err != nil {
-		return false, err
+		return false, VerifiedTimestamps{}, err
 	}
 
 	// We can't check annotations without claims, both require unmarshalling the payload.
 	if co.ClaimVerifier != nil {
 		if err := co.ClaimVerifier(sig, h, co.Annotations); err != nil {
-			return false, err
+			return false, VerifiedTimestamps{}, err
 		}
 	}
 
 	// 2. if a certificate was used, verify the certificate expiration against a time
 	cert, err := sig.Cert()
 	if err != nil {
-		return false, err
+		return false, VerifiedTimestamps{}, err
 	}
 	if cert != nil {
 		// use the provided Rekor bundle or RFC3161 timestamp to check certificate expiration
@@ -776,14 +1133,14 @@ func verifyInternal(ctx context.Context, sig oci.Signature, h v1.Hash,
 		if acceptableRFC3161Time != nil {
 			// Verify the cert against the timestamp time.
 			if err := CheckExpiry(cert, *acceptableRFC3161Time); err != nil {
-				return false, fmt.Errorf("checking expiry on certificate with timestamp: %w", err)
+				return false, VerifiedTimestamps{}, fmt.Errorf("checking expiry on certificate with timestamp: %w", err)
 			}
 			expirationChecked = true
 		}
 
 		if acceptableRekorBundleTime != nil {
 			if err := CheckExpiry(cert, *acceptableRekorBundleTime); err != nil {
-				return false, fmt.Errorf("checking expiry on certificate with bundle: %w", err)
+				return false, VerifiedTimestamps{}, fmt.Errorf("checking expiry on certificate with bundle: %w", err)
 			}
 			expirationChecked = true
 		}
@@ -793,16 +1150,81 @@ func verifyInternal(ctx context.Context, sig oci.Signature, h v1.Hash,
 			if err := CheckExpiry(cert, time.Now()); err != nil {
 				// If certificate is expired and not signed timestamp was provided then error the following message. Otherwise throw an expiration error.
 				if co.IgnoreTlog && acceptableRFC3161Time == nil {
-					return false, &VerificationFailure{
+					return false, VerifiedTimestamps{}, &VerificationFailure{
 						fmt.Errorf("expected a signed timestamp to verify an expired certificate"),
 					}
 				}
-				return false, fmt.Errorf("checking expiry on certificate with bundle: %w", err)
+				return false, VerifiedTimestamps{}, fmt.Errorf("checking expiry on certificate with bundle: %w", err)
 			}
 		}
 	}
 
-	return bundleVerified, nil
+	timestamps = VerifiedTimestamps{
+		IntegratedTime: acceptableRekorBundleTime,
+		RFC3161Time:    acceptableRFC3161Time,
+	}
+	if err := co.keyConstraints().checkExpiry(timestamps); err != nil {
+		return false, VerifiedTimestamps{}, err
+	}
+	return bundleVerified, timestamps, nil
+}
+
+// certVerifier returns the verifier for the certificate on sig, once it is
+// validated against the roots and identities in co.
+func certVerifier(sig oci.Signature, co *CheckOpts) (signature.Verifier, error) {
+	cert, err := sig.Cert()
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, &ErrNoCertificateFoundOnSignature{
+			fmt.Errorf("no certificate found on signature"),
+		}
+	}
+	// Create a certificate pool for intermediate CA certificates, excluding the root
+	chain, err := sig.Chain()
+	if err != nil {
+		return nil, err
+	}
+	// If there is no chain annotation present, we preserve the pools set in the CheckOpts.
+	var pool *x509.CertPool
+	if len(chain) > 1 {
+		if co.IntermediateCerts == nil {
+			// If the intermediate certs have not been loaded in by TUF
+			pool = x509.NewCertPool()
+			for _, cert := range chain[:len(chain)-1] {
+				pool.AddCert(cert)
+			}
+		}
+	}
+	// In case pool is not set than set it from co.IntermediateCerts
+	if pool == nil {
+		pool = co.IntermediateCerts
+	}
+	return ValidateAndUnpackCertWithIntermediates(cert, co, pool)
+}
+
+// verifyCertifiedKey checks that sig carries a valid certificate for the
+// public key of co.SigVerifier, see CheckOpts.RequireCertifiedKey.
+func verifyCertifiedKey(sig oci.Signature, co *CheckOpts) error {
+	verifier, err := certVerifier(sig, co)
+	if err != nil {
+		return err
+	}
+	certKey, err := verifier.PublicKey()
+	if err != nil {
+		return err
+	}
+	pinnedKey, err := co.SigVerifier.PublicKey(co.PKOpts...)
+	if err != nil {
+		return err
+	}
+	if err := cryptoutils.EqualKeys(pinnedKey, certKey); err != nil {
+		return &VerificationFailure{
+			fmt.Errorf("certificate on signature is not for the provided key: %w", err),
+		}
+	}
+	return nil
 }
 
 func keyBytes(sig oci.Signature, co *CheckOpts) ([]byte, error) {
@@ -810,8 +1232,9 @@ func keyBytes(sig oci.Signature, co *CheckOpts) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	// We have a public key.
-	if co.SigVerifier != nil {
+	// We have a public key, unless it is certified by the certificate the
+	// signature was logged with.
+	if co.SigVerifier != nil && (!co.RequireCertifiedKey || cert == nil) {
 		pub, err := co.SigVerifier.PublicKey(co.PKOpts...)
 		if err != nil {
 			return nil, err
@@ -879,10 +1302,48 @@ func loadSignatureFromFile(ctx context.Context, sigRef string, signedImgRef name
 // VerifyImageAttestations does all the main cosign checks in a loop, returning the verified attestations.
 // If there were no valid attestations, we return an error.
 func VerifyImageAttestations(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) (checkedAttestations []oci.Signature, bundleVerified bool, err error) {
+	return verifyCoalesced("attestations", signedImgRef, co, func() ([]oci.Signature, bool, error) {
+		return verifyWithResultCache(ctx, "attestations", signedImgRef, co, func() ([]oci.Signature, bool, error) {
+			return verifyImageAttestations(ctx, signedImgRef, co)
+		})
+	})
+}
+
+// VerifyIndexAttestations verifies the attestations attached to the image
+// index idx whose statements name image, one of the index's images, as a
+// subject. This lets a single statement covering every platform of a
+// multi-arch image satisfy verification of each platform's image.
+func VerifyIndexAttestations(ctx context.Context, idx name.Reference, image v1.Hash, co *CheckOpts) (checkedAttestations []oci.Signature, bundleVerified bool, err error) {
+	indexCO := *co
+	claimVerifier := co.ClaimVerifier
+	if claimVerifier == nil {
+		claimVerifier = IntotoSubjectClaimVerifier
+	}
+	indexCO.ClaimVerifier = func(sig oci.Signature, _ v1.Hash, annotations map[string]interface{}) error {
+		return claimVerifier(sig, image, annotations)
+	}
+	// Results differ per image even though they come from the same index.
+	indexCO.PolicyKey = co.PolicyKey + "\x00" + image.String()
+	return VerifyImageAttestations(ctx, idx, &indexCO)
+}
+
+func verifyImageAttestations(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) ([]oci.Signature, bool, error) {
 	// Enforce this up front.
 	if co.RootCerts == nil && co.SigVerifier == nil {
 		return nil, false, errors.New("one of verifier or root certs is required")
 	}
+	if err := co.keyConstraints().checkRepository(signedImgRef); err != nil {
+		return nil, false, err
+	}
+
+	// Try first using OCI 1.1 behavior if experimental flag is set, falling
+	// back to the attestation tag where registries lack referrers support.
+	if co.ExperimentalOCI11 {
+		verified, bundleVerified, err := verifyImageAttestationsExperimentalOCI(ctx, signedImgRef, co)
+		if err == nil {
+			return verified, bundleVerified, nil
+		}
+	}
 
 	// This is a carefully optimized sequence for fetching the attestations of
 	// the entity that minimizes registry requests when supplied with a digest
@@ -899,12 +1360,9 @@ func VerifyImageAttestations(ctx context.Context, signedImgRef name.Reference, c
 	if err != nil {
 		return nil, false, err
 	}
-	atts, err := ociremote.Signatures(st, co.RegistryClientOpts...)
-	if err != nil {
-		return nil, false, err
-	}
-
-	return VerifyImageAttestation(ctx, atts, h, co)
+	return verifyIndexed(ctx, st, co, func(atts oci.Signatures) ([]oci.Signature, bool, error) {
+		return VerifyImageAttestation(ctx, atts, h, co)
+	})
 }
 
 // VerifyLocalImageAttestations verifies attestations from a saved, local image, without any network calls,
@@ -959,6 +1417,9 @@ func VerifyBlobAttestation(ctx context.Context, att oci.Signature, h v1.Hash, co
 }
 
 func VerifyImageAttestation(ctx context.Context, atts oci.Signatures, h v1.Hash, co *CheckOpts) (checkedAttestations []oci.Signature, bundleVerified bool, err error) {
+	if co.FirstMatch && co.RequiredSignatures <= 1 {
+		return verifyFirstAttestation(ctx, atts, h, co)
+	}
 	sl, err := atts.Get()
 	if err != nil {
 		return nil, false, err
@@ -974,20 +1435,12 @@ func VerifyImageAttestation(ctx context.Context, atts oci.Signatures, h v1.Hash,
 	t := throttler.New(workers, len(sl))
 	for i, att := range sl {
 		go func(att oci.Signature, index int) {
-			att, err := static.Copy(att)
+			att, verified, err := verifyImageAttestation(ctx, att, h, co)
 			if err != nil {
 				t.Done(err)
 				return
 			}
-			if err := func(att oci.Signature) error {
-				verified, err := verifyInternal(ctx, att, h, verifyOCIAttestation, co)
-				bundlesVerified[index] = verified
-				return err
-			}(att); err != nil {
-				t.Done(err)
-				return
-			}
-
+			bundlesVerified[index] = verified
 			attestations[index] = att
 			t.Done(nil)
 		}(att, i)
@@ -1016,10 +1469,48 @@ func VerifyImageAttestation(ctx context.Context, atts oci.Signatures, h v1.Hash,
 			fmt.Errorf("no matching attestations: %s", strings.Join(combinedErrors, "\n ")),
 		}
 	}
+	if err := checkRequiredSignatures(ctx, checkedAttestations, verifyOCIAttestation, co); err != nil {
+		return nil, false, &ErrNoMatchingAttestations{
+			fmt.Errorf("no matching attestations: %w", err),
+		}
+	}
 
 	return checkedAttestations, bundleVerified, nil
 }
 
+// verifyFirstAttestation is VerifyImageAttestation for co.FirstMatch.
+func verifyFirstAttestation(ctx context.Context, atts oci.Signatures, h v1.Hash, co *CheckOpts) ([]oci.Signature, bool, error) {
+	verified, bundleVerified, _, errs, err := verifyFirst(atts, func(att oci.Signature) (oci.Signature, bool, error) {
+		return verifyImageAttestation(ctx, att, h, co)
+	})
+	switch {
+	case err != nil:
+		return nil, false, err
+	case verified == nil:
+		return nil, false, &ErrNoMatchingAttestations{
+			fmt.Errorf("no matching attestations: %s", strings.Join(errs, "\n ")),
+		}
+	}
+	return []oci.Signature{verified}, bundleVerified, nil
+}
+
+// verifyImageAttestation verifies a single attestation of an image,
+// returning it with the timestamps that were verified.
+func verifyImageAttestation(ctx context.Context, att oci.Signature, h v1.Hash, co *CheckOpts) (oci.Signature, bool, error) {
+	att, err := static.Copy(att)
+	if err != nil {
+		return nil, false, err
+	}
+	verified, timestamps, err := verifyInternalWithTimestamps(ctx, att, h, verifyOCIAttestation, co)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := co.keyConstraints().checkPredicateType(att); err != nil {
+		return nil, false, err
+	}
+	return &verifiedSignature{ociSignature: att, timestamps: timestamps}, verified, nil
+}
+
 // CheckExpiry confirms the time provided is within the valid period of the cert
 func CheckExpiry(cert *x509.Certificate, it time.Time) error {
 	ft := func(t time.Time) string {
@@ -1040,6 +1531,44 @@ func CheckExpiry(cert *x509.Certificate, it time.Time) error {
 	return nil
 }
 
+// VerifiedTimestamps are the times a signature was made that verification
+// checked and accepted. A field is nil when that kind of timestamp was absent
+// or not verified, e.g. the tlog entry when transparency log verification is
+// skipped.
+type VerifiedTimestamps struct {
+	// IntegratedTime is the integrated time of the verified tlog entry, from
+	// the bundle or an online lookup.
+	IntegratedTime *time.Time
+	// RFC3161Time is the time of the verified RFC3161 timestamp.
+	RFC3161Time *time.Time
+}
+
+// ociSignature names oci.Signature so that it can be embedded without the
+// field hiding its Signature method.
+type ociSignature = oci.Signature
+
+// verifiedSignature is an oci.Signature returned by verification, carrying
+// the timestamps that verification accepted.
+type verifiedSignature struct {
+	ociSignature
+	timestamps VerifiedTimestamps
+}
+
+// GetVerifiedTimestamps returns the timestamps accepted when verifying sig,
+// which must have been returned by VerifyImageAttestation,
+// VerifyImageAttestations or VerifyLocalImageAttestations. ok is false for any
+// other signature.
+func GetVerifiedTimestamps(sig oci.Signature) (timestamps VerifiedTimestamps, ok bool) {
+	if ss, stale := sig.(*staleSignature); stale {
+		sig = ss.ociSignature
+	}
+	vs, ok := sig.(*verifiedSignature)
+	if !ok {
+		return VerifiedTimestamps{}, false
+	}
+	return vs.timestamps, true
+}
+
 func getBundleIntegratedTime(sig oci.Signature) (time.Time, error) {
 	bundle, err := sig.Bundle()
 	if err != nil {
@@ -1153,12 +1682,31 @@ func VerifyRFC3161Timestamp(sig oci.Signature, co *CheckOpts) (*timestamp.Timest
 		tsBytes = rawSig
 	}
 
-	return tsaverification.VerifyTimestampResponse(ts.SignedRFC3161Timestamp, bytes.NewReader(tsBytes),
-		tsaverification.VerifyOpts{
-			TSACertificate: co.TSACertificate,
-			Intermediates:  co.TSAIntermediateCertificates,
-			Roots:          co.TSARootCertificates,
-		})
+	// A signature may carry the timestamps of several TSAs, any of which
+	// is accepted if it was signed by one of the trusted TSAs.
+	tsaCerts := []*x509.Certificate{co.TSACertificate}
+	if len(co.AdditionalTSACertificates) > 0 {
+		tsaCerts = append(tsaCerts, co.AdditionalTSACertificates...)
+	}
+	var errs []error
+	for _, signedTimestamp := range ts.SignedRFC3161Timestamps() {
+		for _, tsaCert := range tsaCerts {
+			t, err := tsaverification.VerifyTimestampResponse(signedTimestamp, bytes.NewReader(tsBytes),
+				tsaverification.VerifyOpts{
+					TSACertificate: tsaCert,
+					Intermediates:  co.TSAIntermediateCertificates,
+					Roots:          co.TSARootCertificates,
+				})
+			if err == nil {
+				return t, nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("no timestamp verified against the trusted TSAs: %w", stderrors.Join(errs...))
 }
 
 // compare bundle signature to the signature we are verifying
@@ -1356,6 +1904,49 @@ func correctAnnotations(wanted, have map[string]interface{}) bool {
 	return true
 }
 
+// latestReferrer returns the last referrer of digest with the artifactType of
+// the attachment attName.
+func latestReferrer(ctx context.Context, digest name.Digest, attName string, co *CheckOpts) (name.Reference, error) {
+	artifactType := ociexperimental.ArtifactType(attName)
+	index, err := ociremote.Referrers(digest, artifactType, co.RegistryClientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	results := index.Manifests
+	numResults := len(results)
+	if numResults == 0 {
+		return nil, fmt.Errorf("unable to locate reference with artifactType %s", artifactType)
+	} else if numResults > 1 {
+		// TODO: if there is more than 1 result.. what does that even mean?
+		ui.Warnf(ctx, "there were a total of %d references with artifactType %s\n", numResults, artifactType)
+	}
+	// TODO: do this smarter using "created" annotations
+	lastResult := results[numResults-1]
+	return name.ParseReference(fmt.Sprintf("%s@%s", digest.Repository, lastResult.Digest.String()))
+}
+
+// verifyImageAttestationsExperimentalOCI verifies the attestations stored as
+// an OCI 1.1 referrer of signedImgRef.
+func verifyImageAttestationsExperimentalOCI(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) (checkedAttestations []oci.Signature, bundleVerified bool, err error) {
+	digest, err := ociremote.ResolveDigest(signedImgRef, co.RegistryClientOpts...)
+	if err != nil {
+		return nil, false, err
+	}
+	h, err := v1.NewHash(digest.Identifier())
+	if err != nil {
+		return nil, false, err
+	}
+	st, err := latestReferrer(ctx, digest, "att", co)
+	if err != nil {
+		return nil, false, err
+	}
+	atts, err := ociremote.Signatures(st, co.RegistryClientOpts...)
+	if err != nil {
+		return nil, false, err
+	}
+	return VerifyImageAttestation(ctx, atts, h, co)
+}
+
 // verifyImageSignaturesExperimentalOCI does all the main cosign checks in a loop, returning the verified signatures.
 // If there were no valid signatures, we return an error, using OCI 1.1+ behavior.
 func verifyImageSignaturesExperimentalOCI(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) (checkedSignatures []oci.Signature, bundleVerified bool, err error) {
@@ -1378,22 +1969,7 @@ func verifyImageSignaturesExperimentalOCI(ctx context.Context, signedImgRef name
 	var sigs oci.Signatures
 	sigRef := co.SignatureRef
 	if sigRef == "" {
-		artifactType := ociexperimental.ArtifactType("sig")
-		index, err := ociremote.Referrers(digest, artifactType, co.RegistryClientOpts...)
-		if err != nil {
-			return nil, false, err
-		}
-		results := index.Manifests
-		numResults := len(results)
-		if numResults == 0 {
-			return nil, false, fmt.Errorf("unable to locate reference with artifactType %s", artifactType)
-		} else if numResults > 1 {
-			// TODO: if there is more than 1 result.. what does that even mean?
-			ui.Warnf(ctx, "there were a total of %d references with artifactType %s\n", numResults, artifactType)
-		}
-		// TODO: do this smarter using "created" annotations
-		lastResult := results[numResults-1]
-		st, err := name.ParseReference(fmt.Sprintf("%s@%s", digest.Repository, lastResult.Digest.String()))
+		st, err := latestReferrer(ctx, digest, "sig", co)
 		if err != nil {
 			return nil, false, err
 		}
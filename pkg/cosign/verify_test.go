@@ -31,9 +31,12 @@ import (
 	"encoding/pem"
 	"errors"
 	"io"
+	"log"
 	"net"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -44,6 +47,10 @@ import (
 	tsaMock "github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/mock"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/layout"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/franchb/cosign/v2/pkg/types"
 	"github.com/franchb/cosign/v2/test"
@@ -54,11 +61,17 @@ import (
 	hashedrekord_v001 "github.com/franchb/rekor/pkg/types/hashedrekord/v0.0.1"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	"github.com/franchb/sigstore/pkg/signature"
+	sigdsse "github.com/franchb/sigstore/pkg/signature/dsse"
 	"github.com/franchb/sigstore/pkg/signature/options"
+	sigPayload "github.com/franchb/sigstore/pkg/signature/payload"
 	"github.com/franchb/sigstore/pkg/tuf"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/stretchr/testify/assert"
@@ -281,6 +294,69 @@ func Test_verifySignaturesErrNoMatchingSignatures(t *testing.T) {
 	}
 }
 
+// iterableOCISignatures streams its signatures, counting how many were read.
+type iterableOCISignatures struct {
+	fakeOCISignatures
+	read int
+}
+
+func (ios *iterableOCISignatures) Iterate() (oci.SignatureIterator, error) {
+	return &countingIterator{SignatureIterator: oci.NewSliceIterator(ios.signatures), read: &ios.read}, nil
+}
+
+type countingIterator struct {
+	oci.SignatureIterator
+	read *int
+}
+
+func (it *countingIterator) Next() (oci.Signature, bool) {
+	sig, ok := it.SignatureIterator.Next()
+	if ok {
+		*it.read++
+	}
+	return sig, ok
+}
+
+func TestVerifySignaturesFirstMatch(t *testing.T) {
+	sv, _, err := signature.NewECDSASignerVerifier(elliptic.P256(), rand.Reader, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("creating signer: %v", err)
+	}
+	payload := []byte{1, 2, 3, 4}
+	sig, err := sv.SignMessage(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("SignMessage() = %v", err)
+	}
+	bad, _ := static.NewSignature(payload, base64.StdEncoding.EncodeToString([]byte("not a signature")))
+	good, _ := static.NewSignature(payload, base64.StdEncoding.EncodeToString(sig))
+
+	sigs := &iterableOCISignatures{fakeOCISignatures: fakeOCISignatures{signatures: []oci.Signature{bad, good, good}}}
+	co := &CheckOpts{SigVerifier: sv, IgnoreTlog: true, FirstMatch: true}
+	verified, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co)
+	if err != nil {
+		t.Fatalf("verifySignatures() = %v", err)
+	}
+	if len(verified) != 1 {
+		t.Errorf("verifySignatures() returned %d signatures, wanted 1", len(verified))
+	}
+	if sigs.read != 2 {
+		t.Errorf("verifySignatures() read %d signatures, wanted 2", sigs.read)
+	}
+
+	sigs = &iterableOCISignatures{fakeOCISignatures: fakeOCISignatures{signatures: []oci.Signature{bad}}}
+	_, _, err = verifySignatures(context.Background(), sigs, v1.Hash{}, co)
+	var e *ErrNoMatchingSignatures
+	if !errors.As(err, &e) {
+		t.Fatalf("%T{%q} is not a %T", err, err, &ErrNoMatchingSignatures{})
+	}
+
+	_, _, err = verifySignatures(context.Background(), &iterableOCISignatures{}, v1.Hash{}, co)
+	var nf *ErrNoSignaturesFound
+	if !errors.As(err, &nf) {
+		t.Fatalf("%T{%q} is not a %T", err, err, &ErrNoSignaturesFound{})
+	}
+}
+
 func TestVerifyImageSignatureWithNoChain(t *testing.T) {
 	ctx := context.Background()
 	rootCert, rootKey, _ := test.GenerateRootCa()
@@ -324,6 +400,96 @@ func TestVerifyImageSignatureWithNoChain(t *testing.T) {
 		t.Fatalf("expected verified=true, got verified=false")
 	}
 }
+func TestVerifyImageSignatureWithCertifiedKey(t *testing.T) {
+	ctx := context.Background()
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	sv, _, err := signature.NewECDSASignerVerifier(elliptic.P256(), rand.Reader, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("creating signer: %v", err)
+	}
+
+	// The leaf certificate binds a long-lived key, such as one in KMS, to an identity.
+	leafCert, privKey, _ := test.GenerateLeafCert("subject@mail.com", "oidc-issuer", rootCert, rootKey)
+	pemLeaf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafCert.Raw})
+	pinnedKey, err := signature.LoadVerifier(privKey.Public(), crypto.SHA256)
+	if err != nil {
+		t.Fatalf("loading verifier: %v", err)
+	}
+	otherKey, _, err := signature.NewECDSASignerVerifier(elliptic.P256(), rand.Reader, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("creating signer: %v", err)
+	}
+
+	rootPool := x509.NewCertPool()
+	rootPool.AddCert(rootCert)
+
+	payload := []byte{1, 2, 3, 4}
+	h := sha256.Sum256(payload)
+	sig, _ := privKey.Sign(rand.Reader, h[:], crypto.SHA256)
+	b64sig := base64.StdEncoding.EncodeToString(sig)
+
+	// The signature is logged with the certificate rather than the key.
+	pe, _ := proposedEntries(b64sig, payload, pemLeaf)
+	entry, _ := rtypes.UnmarshalEntry(pe[0])
+	leaf, _ := entry.Canonicalize(ctx)
+	rekorBundle := CreateTestBundle(ctx, t, sv, leaf)
+	pemBytes, _ := cryptoutils.MarshalPublicKeyToPEM(sv.Public())
+	rekorPubKeys := NewTrustedTransparencyLogPubKeys()
+	rekorPubKeys.AddTransparencyLogPubKey(pemBytes, tuf.Active)
+
+	certified, _ := static.NewSignature(payload, b64sig, static.WithCertChain(pemLeaf, []byte{}), static.WithBundle(rekorBundle))
+	uncertified, _ := static.NewSignature(payload, b64sig)
+
+	tests := []struct {
+		name       string
+		sig        oci.Signature
+		key        signature.Verifier
+		identities []Identity
+		ignoreTlog bool
+		wantErr    bool
+	}{{
+		name:       "key and identity match",
+		sig:        certified,
+		key:        pinnedKey,
+		identities: []Identity{{Subject: "subject@mail.com", Issuer: "oidc-issuer"}},
+	}, {
+		name:       "identity does not match",
+		sig:        certified,
+		key:        pinnedKey,
+		identities: []Identity{{Subject: "other@mail.com", Issuer: "oidc-issuer"}},
+		wantErr:    true,
+	}, {
+		name:       "certificate is for another key",
+		sig:        certified,
+		key:        otherKey,
+		identities: []Identity{{Subject: "subject@mail.com", Issuer: "oidc-issuer"}},
+		wantErr:    true,
+	}, {
+		name:       "no certificate",
+		sig:        uncertified,
+		key:        pinnedKey,
+		identities: []Identity{{Subject: "subject@mail.com", Issuer: "oidc-issuer"}},
+		ignoreTlog: true,
+		wantErr:    true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := VerifyImageSignature(ctx, tc.sig, v1.Hash{},
+				&CheckOpts{
+					SigVerifier:         tc.key,
+					RequireCertifiedKey: true,
+					RootCerts:           rootPool,
+					IgnoreSCT:           true,
+					IgnoreTlog:          tc.ignoreTlog,
+					Identities:          tc.identities,
+					RekorPubKeys:        &rekorPubKeys})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("VerifyImageSignature() = %v, wanted error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func TestVerifyImageSignatureWithInvalidPublicKeyType(t *testing.T) {
 	ctx := context.Background()
 	rootCert, rootKey, _ := test.GenerateRootCa()
@@ -637,6 +803,94 @@ func TestImageSignatureVerificationWithRekor(t *testing.T) {
 	}
 }
 
+func TestTlogValidateEntryFromLogs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	signer, publicKey := generateSigner(t)
+	blob, blobSignature, blobSignatureBase64 := generateBlobSignature(t, signer)
+	ociSignature, err := static.NewSignature(blob, blobSignatureBase64)
+	require.NoError(t, err, "error creating OCI signature")
+	pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(publicKey)
+	require.NoError(t, err)
+
+	rekorSigner, rekorPublicKey := generateSigner(t)
+	logID := calculateLogID(t, rekorPublicKey)
+	rekorEntry := createRekorEntry(ctx, t, logID, rekorSigner, blob, blobSignature, publicKey)
+	rekorPubKeys := &TrustedTransparencyLogPubKeys{
+		Keys: map[string]TransparencyLogPubKey{
+			logID: {PubKey: rekorPublicKey, Status: tuf.Active},
+		},
+	}
+
+	withEntry := &client.Rekor{
+		Entries: &mockEntriesClient{
+			searchLogQueryFunc: func(_ *entries.SearchLogQueryParams, _ ...entries.ClientOption) (*entries.SearchLogQueryOK, error) {
+				return &entries.SearchLogQueryOK{Payload: []models.LogEntry{*rekorEntry}}, nil
+			},
+		},
+	}
+	empty := &client.Rekor{
+		Entries: &mockEntriesClient{
+			searchLogQueryFunc: func(_ *entries.SearchLogQueryParams, _ ...entries.ClientOption) (*entries.SearchLogQueryOK, error) {
+				return &entries.SearchLogQueryOK{Payload: []models.LogEntry{}}, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		primary    *client.Rekor
+		additional map[string]*client.Rekor
+		wantErrs   []string
+	}{{
+		name:    "primary log only",
+		primary: withEntry,
+	}, {
+		name:       "entry in primary log",
+		primary:    withEntry,
+		additional: map[string]*client.Rekor{"https://b.example.com": empty},
+	}, {
+		name:       "falls back to additional log",
+		primary:    empty,
+		additional: map[string]*client.Rekor{"https://a.example.com": empty, "https://b.example.com": withEntry},
+	}, {
+		name:       "no primary log",
+		additional: map[string]*client.Rekor{"https://b.example.com": withEntry},
+	}, {
+		name:       "no log has the entry",
+		primary:    empty,
+		additional: map[string]*client.Rekor{"https://a.example.com": empty, "https://b.example.com": empty},
+		wantErrs:   []string{"no valid tlog entries found in any log", "primary log:", "https://a.example.com:", "https://b.example.com:"},
+	}, {
+		name:       "no primary log and no entry",
+		additional: map[string]*client.Rekor{"https://b.example.com": empty},
+		wantErrs:   []string{"no valid tlog entries found in any log", "https://b.example.com:"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			co := &CheckOpts{
+				RekorClient:            tt.primary,
+				AdditionalRekorClients: tt.additional,
+				RekorPubKeys:           rekorPubKeys,
+			}
+			e, err := tlogValidateEntryFromLogs(ctx, co, ociSignature, pemBytes)
+			if len(tt.wantErrs) > 0 {
+				require.Error(t, err)
+				for _, want := range tt.wantErrs {
+					assert.Contains(t, err.Error(), want)
+				}
+				if tt.primary == nil {
+					assert.NotContains(t, err.Error(), "primary log:")
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, e)
+		})
+	}
+}
+
 func TestVerifyImageSignatureWithSigVerifierAndTSA(t *testing.T) {
 	client, err := tsaMock.NewTSAClient((tsaMock.TSAClientOptions{Time: time.Now()}))
 	if err != nil {
@@ -1575,6 +1829,78 @@ func TestVerifyRFC3161Timestamp(t *testing.T) {
 	}
 }
 
+func TestVerifyRFC3161TimestampMultipleTSAs(t *testing.T) {
+	payload := []byte{1, 2, 3, 4}
+	h := sha256.Sum256(payload)
+	privKey, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, _ := privKey.Sign(rand.Reader, h[:], crypto.SHA256)
+
+	// Three TSAs, each with its own chain, of which the first two timestamp
+	// the signature.
+	type tsaCerts struct {
+		leaves, intermediates, roots []*x509.Certificate
+	}
+	var certs []tsaCerts
+	var timestamps [][]byte
+	for i := 0; i < 3; i++ {
+		client, err := tsaMock.NewTSAClient((tsaMock.TSAClientOptions{Time: time.Now()}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		certChainPEM, err := cryptoutils.MarshalCertificatesToPEM(client.CertChain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaves, intermediates, roots, err := tsa.SplitPEMCertificateChain(certChainPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, tsaCerts{leaves, intermediates, roots})
+		if i < 2 {
+			tsBytes, err := tsa.GetTimestampedSignature(signature, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			timestamps = append(timestamps, tsBytes)
+		}
+	}
+	ociSig, _ := static.NewSignature(payload,
+		base64.StdEncoding.EncodeToString(signature),
+		static.WithRFC3161Timestamp(bundle.TimestampsToRFC3161Timestamp(timestamps)))
+
+	// success, trusting only the TSA of the additional timestamp
+	if _, err := VerifyRFC3161Timestamp(ociSig, &CheckOpts{
+		TSACertificate:              certs[1].leaves[0],
+		TSAIntermediateCertificates: certs[1].intermediates,
+		TSARootCertificates:         certs[1].roots,
+	}); err != nil {
+		t.Fatalf("unexpected error verifying additional timestamp: %v", err)
+	}
+
+	// success, trusting a TSA that did not timestamp and one that did
+	if _, err := VerifyRFC3161Timestamp(ociSig, &CheckOpts{
+		TSACertificate:              certs[2].leaves[0],
+		AdditionalTSACertificates:   certs[1].leaves,
+		TSAIntermediateCertificates: append(certs[2].intermediates, certs[1].intermediates...),
+		TSARootCertificates:         append(certs[2].roots, certs[1].roots...),
+	}); err != nil {
+		t.Fatalf("unexpected error verifying with an additional TSA: %v", err)
+	}
+
+	// failure, trusting only a TSA that did not timestamp
+	_, err = VerifyRFC3161Timestamp(ociSig, &CheckOpts{
+		TSACertificate:              certs[2].leaves[0],
+		TSAIntermediateCertificates: certs[2].intermediates,
+		TSARootCertificates:         certs[2].roots,
+	})
+	if err == nil || !strings.Contains(err.Error(), "no timestamp verified against the trusted TSAs") {
+		t.Fatalf("expected error verifying with an untrusted TSA, got: %v", err)
+	}
+}
+
 // Mock Rekor client
 type mockEntriesClient struct {
 	entries.ClientService
@@ -1679,3 +2005,283 @@ func calculateLogID(t *testing.T, pub crypto.PublicKey) string {
 	digest := sha256.Sum256(pubBytes)
 	return hex.EncodeToString(digest[:])
 }
+
+// writeSignedTestImage pushes a random image to a test registry served by s
+// and attaches a signature over it by a fresh key, with the given layer
+// annotations. It returns the image digest and a verifier for the key.
+func writeSignedTestImage(t *testing.T, s *httptest.Server, annotations map[string]string) (name.Digest, signature.Verifier) {
+	t.Helper()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(u.Host + "/test/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := ref.Context().Digest(h.String())
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := signature.LoadECDSAVerifier(&privKey.PublicKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl, err := (&sigPayload.Cosign{Image: digest}).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ph := sha256.Sum256(pl)
+	sig, err := privKey.Sign(rand.Reader, ph[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSig, err := static.NewSignature(pl, base64.StdEncoding.EncodeToString(sig), static.WithAnnotations(annotations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	se, err := mutate.AttachSignatureToEntity(ociremote.SignedUnknown(digest), ociSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ociremote.WriteSignatures(digest.Repository, se); err != nil {
+		t.Fatal(err)
+	}
+	return digest, verifier
+}
+
+func TestVerifyImageSignaturesFallsBackWhenIndexHidesLayers(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+
+	// The index annotation is unsigned, so a wrong value must not hide the
+	// signature from verification.
+	digest, verifier := writeSignedTestImage(t, s, map[string]string{
+		static.IdentityHashAnnotationKey: static.IdentityHash("other-issuer", "other@mail.com"),
+	})
+
+	verified, _, err := VerifyImageSignatures(context.Background(), digest, &CheckOpts{
+		SigVerifier:   verifier,
+		IgnoreTlog:    true,
+		ClaimVerifier: SimpleClaimVerifier,
+		Identities:    []Identity{{Issuer: "oidc-issuer", Subject: "subject@mail.com"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+}
+
+func TestVerifyIndexAttestations(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+
+	idx, err := random.Index(1024, 1, 2)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(u.Host + "/test/index:latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, idx))
+	h, err := idx.Digest()
+	require.NoError(t, err)
+	idxDigest := ref.Context().Digest(h.String())
+	im, err := idx.IndexManifest()
+	require.NoError(t, err)
+	covered, uncovered := im.Manifests[0].Digest, im.Manifests[1].Digest
+
+	// One statement covers the index and its first image.
+	stmt, err := json.Marshal(in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: "https://example.com/predicate",
+			Subject: []in_toto.Subject{
+				{Name: ref.Context().String(), Digest: map[string]string{"sha256": h.Hex}},
+				{Name: ref.Context().String(), Digest: map[string]string{"sha256": covered.Hex}},
+			},
+		},
+		Predicate: map[string]string{},
+	})
+	require.NoError(t, err)
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sv, err := signature.LoadECDSASignerVerifier(privKey, crypto.SHA256)
+	require.NoError(t, err)
+	env, err := sigdsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(bytes.NewReader(stmt))
+	require.NoError(t, err)
+	att, err := static.NewAttestation(env)
+	require.NoError(t, err)
+	se, err := mutate.AttachAttestationToEntity(ociremote.SignedUnknown(idxDigest), att)
+	require.NoError(t, err)
+	require.NoError(t, ociremote.WriteAttestations(idxDigest.Repository, se))
+
+	co := &CheckOpts{
+		SigVerifier:   sv,
+		IgnoreTlog:    true,
+		ClaimVerifier: IntotoSubjectClaimVerifier,
+	}
+	verified, _, err := VerifyIndexAttestations(context.Background(), idxDigest, covered, co)
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+
+	_, _, err = VerifyIndexAttestations(context.Background(), idxDigest, uncovered, co)
+	require.Error(t, err)
+
+	// The subject is checked even without a claim verifier.
+	_, _, err = VerifyIndexAttestations(context.Background(), idxDigest, uncovered, &CheckOpts{
+		SigVerifier: sv,
+		IgnoreTlog:  true,
+	})
+	require.Error(t, err)
+}
+
+// ociSignedImageIndex lets localTestIndex embed an oci.SignedImageIndex,
+// whose SignedImageIndex method would otherwise clash with the field name.
+type ociSignedImageIndex = oci.SignedImageIndex
+
+// localTestIndex overrides the images of an oci.SignedImageIndex, so that
+// they can carry signatures of their own.
+type localTestIndex struct {
+	ociSignedImageIndex
+	images map[v1.Hash]oci.SignedImage
+}
+
+func (l *localTestIndex) SignedImage(h v1.Hash) (oci.SignedImage, error) {
+	if si, ok := l.images[h]; ok {
+		return si, nil
+	}
+	return l.ociSignedImageIndex.SignedImage(h)
+}
+
+func TestVerifyLocalImageSignaturesRecursive(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	verifier, err := signature.LoadECDSAVerifier(&privKey.PublicKey, crypto.SHA256)
+	require.NoError(t, err)
+	repo, err := name.NewRepository("example.com/test/index")
+	require.NoError(t, err)
+	sign := func(h v1.Hash) oci.Signature {
+		pl, err := (&sigPayload.Cosign{Image: repo.Digest(h.String())}).MarshalJSON()
+		require.NoError(t, err)
+		ph := sha256.Sum256(pl)
+		sig, err := privKey.Sign(rand.Reader, ph[:], crypto.SHA256)
+		require.NoError(t, err)
+		ociSig, err := static.NewSignature(pl, base64.StdEncoding.EncodeToString(sig))
+		require.NoError(t, err)
+		return ociSig
+	}
+
+	idx, err := random.Index(1024, 1, 2)
+	require.NoError(t, err)
+	h, err := idx.Digest()
+	require.NoError(t, err)
+	im, err := idx.IndexManifest()
+	require.NoError(t, err)
+	writeLayout := func(signedImages int) string {
+		sii, err := mutate.AttachSignatureToImageIndex(signed.ImageIndex(idx), sign(h))
+		require.NoError(t, err)
+		images := map[v1.Hash]oci.SignedImage{}
+		for _, desc := range im.Manifests[:signedImages] {
+			img, err := idx.Image(desc.Digest)
+			require.NoError(t, err)
+			images[desc.Digest], err = mutate.AttachSignatureToImage(signed.Image(img), sign(desc.Digest))
+			require.NoError(t, err)
+		}
+		path := t.TempDir()
+		require.NoError(t, layout.WriteSignedImageIndex(path, &localTestIndex{ociSignedImageIndex: sii, images: images}))
+		return path
+	}
+
+	co := &CheckOpts{
+		SigVerifier:   verifier,
+		IgnoreTlog:    true,
+		ClaimVerifier: SimpleClaimVerifier,
+	}
+	verified, _, err := VerifyLocalImageSignaturesRecursive(context.Background(), writeLayout(2), co)
+	require.NoError(t, err)
+	require.Len(t, verified, 3)
+
+	partial := writeLayout(1)
+	_, _, err = VerifyLocalImageSignatures(context.Background(), partial, co)
+	require.NoError(t, err)
+	_, _, err = VerifyLocalImageSignaturesRecursive(context.Background(), partial, co)
+	require.ErrorContains(t, err, im.Manifests[1].Digest.String())
+}
+
+func TestValidateAndUnpackCertWithChainLeavesOptions(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	leafCert, _, _ := test.GenerateLeafCert("email@email", "https://accounts.google.com", rootCert, rootKey)
+
+	co := &CheckOpts{
+		Identities: []Identity{{Subject: "email@email", Issuer: "https://accounts.google.com"}},
+		IgnoreSCT:  true,
+	}
+	if _, err := ValidateAndUnpackCertWithChain(leafCert, []*x509.Certificate{rootCert}, co); err != nil {
+		t.Fatalf("ValidateAndUnpackCertWithChain() = %v", err)
+	}
+	if co.RootCerts != nil || co.IntermediateCerts != nil {
+		t.Error("ValidateAndUnpackCertWithChain() modified the CheckOpts it was given")
+	}
+}
+
+func TestCheckOptsClone(t *testing.T) {
+	rootCert, _, _ := test.GenerateRootCa()
+	co := &CheckOpts{
+		Annotations: map[string]interface{}{"foo": "bar"},
+		Identities:  []Identity{{Subject: "a", Issuer: "b"}},
+		RootCerts:   x509.NewCertPool(),
+		PolicyKey:   "policy",
+	}
+	c := co.Clone()
+	c.Annotations["foo"] = "baz"
+	c.Identities[0].Subject = "c"
+	c.RootCerts.AddCert(rootCert)
+
+	if co.Annotations["foo"] != "bar" || co.Identities[0].Subject != "a" || !co.RootCerts.Equal(x509.NewCertPool()) {
+		t.Error("modifying a clone modified the original CheckOpts")
+	}
+	if c.PolicyKey != co.PolicyKey {
+		t.Errorf("Clone().PolicyKey = %q, wanted %q", c.PolicyKey, co.PolicyKey)
+	}
+}
+
+func TestVerifyImageSignaturesConcurrently(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	digest, verifier := writeSignedTestImage(t, s, nil)
+
+	co := &CheckOpts{
+		SigVerifier:   verifier,
+		IgnoreTlog:    true,
+		ClaimVerifier: SimpleClaimVerifier,
+		Annotations:   map[string]interface{}{},
+		ResultCache:   NewMemoryResultCache(time.Hour),
+		PolicyKey:     "policy",
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = VerifyImageSignatures(context.Background(), digest, co)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("VerifyImageSignatures() = %v", err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// VerificationState records the images verified under a policy, so that
+// verifying a set of images again only verifies the ones that changed.
+type VerificationState struct {
+	// PolicyHash identifies the policy and trust material the images were
+	// verified under, see PolicyHash.
+	PolicyHash string `json:"policyHash"`
+	// Verified maps the digest references of the verified images to when
+	// they were verified.
+	Verified map[string]time.Time `json:"verified"`
+}
+
+// LoadVerificationState reads the state stored at path by
+// VerificationState.Save. Images recorded under another policy than
+// policyHash are dropped, as are all of them if path does not exist yet.
+func LoadVerificationState(path, policyHash string) (*VerificationState, error) {
+	s := &VerificationState{
+		PolicyHash: policyHash,
+		Verified:   map[string]time.Time{},
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var stored VerificationState
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, fmt.Errorf("parsing verification state %s: %w", path, err)
+	}
+	if stored.PolicyHash == policyHash && stored.Verified != nil {
+		s.Verified = stored.Verified
+	}
+	return s, nil
+}
+
+// VerifiedAt returns when d was verified under s.PolicyHash, if it was.
+func (s *VerificationState) VerifiedAt(d name.Digest) (time.Time, bool) {
+	t, ok := s.Verified[d.String()]
+	return t, ok
+}
+
+// Record records that d was verified under s.PolicyHash at t.
+func (s *VerificationState) Record(d name.Digest, t time.Time) {
+	s.Verified[d.String()] = t
+}
+
+// Save replaces the state stored at path with s.
+func (s *VerificationState) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// PolicyHash returns a digest of policy, a JSON-serializable description of
+// the verification checks, together with the keys and certificates in co
+// they are checked against. Any change to either changes the digest.
+//
+// Certificate pools cannot be enumerated, so the RootCerts and
+// IntermediateCerts of co are not covered: callers must include the
+// certificates those were loaded from in policy.
+func PolicyHash(policy any, co *CheckOpts) (string, error) {
+	type logKey struct {
+		ID     string
+		Key    []byte
+		Status any
+	}
+	logKeys := func(keys *TrustedTransparencyLogPubKeys) ([]logKey, error) {
+		if keys == nil {
+			return nil, nil
+		}
+		var out []logKey
+		for id, k := range keys.Keys {
+			der, err := x509.MarshalPKIXPublicKey(k.PubKey)
+			if err != nil {
+				return nil, fmt.Errorf("marshaling key of log %s: %w", id, err)
+			}
+			out = append(out, logKey{ID: id, Key: der, Status: k.Status})
+		}
+		slices.SortFunc(out, func(a, b logKey) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		return out, nil
+	}
+	raw := func(certs ...*x509.Certificate) [][]byte {
+		var out [][]byte
+		for _, c := range certs {
+			if c != nil {
+				out = append(out, c.Raw)
+			}
+		}
+		return out
+	}
+
+	var material struct {
+		Policy           any
+		Key              []byte
+		KeyRing          []byte `json:",omitempty"`
+		RekorKeys        []logKey
+		CTLogKeys        []logKey
+		TSACertificates  [][]byte
+		TSARoots         [][]byte
+		TSAIntermediates [][]byte
+		Witnesses        *CheckpointWitnesses `json:",omitempty"`
+	}
+	material.Policy = policy
+	if ring, ok := co.SigVerifier.(*KeyRing); ok {
+		var err error
+		if material.KeyRing, err = ring.marshal(); err != nil {
+			return "", fmt.Errorf("marshaling key ring: %w", err)
+		}
+	} else if co.SigVerifier != nil {
+		pub, err := co.SigVerifier.PublicKey()
+		if err != nil {
+			return "", err
+		}
+		if material.Key, err = x509.MarshalPKIXPublicKey(pub); err != nil {
+			return "", fmt.Errorf("marshaling public key: %w", err)
+		}
+	}
+	var err error
+	if material.RekorKeys, err = logKeys(co.RekorPubKeys); err != nil {
+		return "", err
+	}
+	if material.CTLogKeys, err = logKeys(co.CTLogPubKeys); err != nil {
+		return "", err
+	}
+	material.TSACertificates = raw(append([]*x509.Certificate{co.TSACertificate}, co.AdditionalTSACertificates...)...)
+	material.TSARoots = raw(co.TSARootCertificates...)
+	material.TSAIntermediates = raw(co.TSAIntermediateCertificates...)
+	material.Witnesses = co.CheckpointWitnesses
+
+	b, err := json.Marshal(material)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/tuf"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+func TestVerificationState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	d, err := name.NewDigest("registry.example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifiedAt := time.Unix(1700000000, 0).UTC()
+
+	s, err := LoadVerificationState(path, "policy-a")
+	if err != nil {
+		t.Fatalf("LoadVerificationState() = %v", err)
+	}
+	if _, ok := s.VerifiedAt(d); ok {
+		t.Fatal("VerifiedAt() found an image in a new state")
+	}
+	s.Record(d, verifiedAt)
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	s, err = LoadVerificationState(path, "policy-a")
+	if err != nil {
+		t.Fatalf("LoadVerificationState() = %v", err)
+	}
+	if got, ok := s.VerifiedAt(d); !ok || !got.Equal(verifiedAt) {
+		t.Errorf("VerifiedAt() = %v, %v, wanted %v", got, ok, verifiedAt)
+	}
+	other, err := name.NewDigest("registry.example.com/other@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.VerifiedAt(other); ok {
+		t.Error("VerifiedAt() found the digest in another repository")
+	}
+
+	// Images verified under another policy are verified again.
+	s, err = LoadVerificationState(path, "policy-b")
+	if err != nil {
+		t.Fatalf("LoadVerificationState() = %v", err)
+	}
+	if _, ok := s.VerifiedAt(d); ok {
+		t.Error("VerifiedAt() found an image verified under another policy")
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVerificationState(path, "policy-a"); err == nil {
+		t.Error("LoadVerificationState() succeeded for a corrupt state")
+	}
+}
+
+func TestPolicyHash(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	key, rekor1, rekor2 := newKey(), newKey(), newKey()
+	verifier, err := signature.LoadECDSAVerifier(&key.PublicKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorKeys := func(keys map[string]*ecdsa.PrivateKey) *TrustedTransparencyLogPubKeys {
+		pubs := NewTrustedTransparencyLogPubKeys()
+		for id, k := range keys {
+			pubs.Keys[id] = TransparencyLogPubKey{PubKey: k.Public(), Status: tuf.Active}
+		}
+		return &pubs
+	}
+	policy := map[string]string{"identity": "release@example.com"}
+	co := &CheckOpts{
+		SigVerifier:  verifier,
+		RekorPubKeys: rekorKeys(map[string]*ecdsa.PrivateKey{"a": rekor1, "b": rekor2}),
+	}
+	want, err := PolicyHash(policy, co)
+	if err != nil {
+		t.Fatalf("PolicyHash() = %v", err)
+	}
+	if got, err := PolicyHash(map[string]string{"identity": "release@example.com"}, co); err != nil || got != want {
+		t.Errorf("PolicyHash() = %s, %v for the same policy, wanted %s", got, err, want)
+	}
+
+	for name, tc := range map[string]struct {
+		policy any
+		co     *CheckOpts
+	}{
+		"policy":     {map[string]string{"identity": "other@example.com"}, co},
+		"no key":     {policy, &CheckOpts{RekorPubKeys: co.RekorPubKeys}},
+		"rekor keys": {policy, &CheckOpts{SigVerifier: verifier, RekorPubKeys: rekorKeys(map[string]*ecdsa.PrivateKey{"a": rekor1})}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := PolicyHash(tc.policy, tc.co)
+			if err != nil {
+				t.Fatalf("PolicyHash() = %v", err)
+			}
+			if got == want {
+				t.Error("PolicyHash() did not change")
+			}
+		})
+	}
+}
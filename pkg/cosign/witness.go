@@ -0,0 +1,147 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/franchb/rekor/pkg/generated/models"
+	"github.com/franchb/rekor/pkg/util"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// trustedRootWitnessesField is the field of a Sigstore trusted root holding
+// the witnesses trusted to cosign Rekor checkpoints. It is not part of the
+// trusted root schema, so it is removed before the trusted root is parsed.
+const trustedRootWitnessesField = "checkpointWitnesses"
+
+// CheckpointWitnesses are the witnesses trusted to cosign the checkpoints of
+// Rekor, so that an inclusion proof is only trusted if independent parties
+// have seen the same log state as the one the proof is for.
+type CheckpointWitnesses struct {
+	// Keys are the note verifier keys of the witnesses, in the
+	// <name>+<hash>+<base64 key> format of golang.org/x/mod/sumdb/note.
+	Keys []string `json:"witnesses"`
+	// Threshold is the number of distinct witnesses that must have cosigned
+	// a checkpoint. Zero requires all of them.
+	Threshold int `json:"threshold,omitempty"`
+
+	verifiers note.Verifiers
+}
+
+// NewCheckpointWitnesses parses the note verifier keys of the witnesses,
+// threshold of which must cosign a checkpoint, or all of them if threshold
+// is zero.
+func NewCheckpointWitnesses(keys []string, threshold int) (*CheckpointWitnesses, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no checkpoint witnesses provided")
+	}
+	if threshold < 0 || threshold > len(keys) {
+		return nil, fmt.Errorf("witness threshold %d must be between 1 and the %d witnesses", threshold, len(keys))
+	}
+	verifiers := make([]note.Verifier, 0, len(keys))
+	for _, k := range keys {
+		v, err := note.NewVerifier(k)
+		if err != nil {
+			return nil, fmt.Errorf("parsing witness key %q: %w", k, err)
+		}
+		verifiers = append(verifiers, v)
+	}
+	return &CheckpointWitnesses{
+		Keys:      keys,
+		Threshold: threshold,
+		verifiers: note.VerifierList(verifiers...),
+	}, nil
+}
+
+// SplitTrustedRootWitnesses returns rootJSON without the checkpoint
+// witnesses it configures, which are returned separately, or nil if it
+// configures none.
+func SplitTrustedRootWitnesses(rootJSON []byte) ([]byte, *CheckpointWitnesses, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rootJSON, &fields); err != nil {
+		return nil, nil, err
+	}
+	raw, ok := fields[trustedRootWitnessesField]
+	if !ok {
+		return rootJSON, nil, nil
+	}
+	var config CheckpointWitnesses
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", trustedRootWitnessesField, err)
+	}
+	witnesses, err := NewCheckpointWitnesses(config.Keys, config.Threshold)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", trustedRootWitnessesField, err)
+	}
+	delete(fields, trustedRootWitnessesField)
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, witnesses, nil
+}
+
+// VerifyCheckpointWitnesses verifies that checkpoint, the signed note of a
+// Rekor checkpoint, commits to the tree of treeSize entries with rootHash
+// an inclusion proof was verified against, and that it is cosigned by at
+// least the threshold of witnesses.
+func VerifyCheckpointWitnesses(checkpoint string, treeSize int64, rootHash []byte, witnesses *CheckpointWitnesses) error {
+	if checkpoint == "" {
+		return errors.New("no checkpoint to verify the witness cosignatures of")
+	}
+	var sth util.SignedCheckpoint
+	if err := sth.UnmarshalText([]byte(checkpoint)); err != nil {
+		return fmt.Errorf("parsing checkpoint: %w", err)
+	}
+	if treeSize < 0 || sth.Size != uint64(treeSize) || !bytes.Equal(sth.Hash, rootHash) {
+		return errors.New("checkpoint does not match the tree of the inclusion proof")
+	}
+
+	n, err := note.Open([]byte(checkpoint), witnesses.verifiers)
+	var unverified *note.UnverifiedNoteError
+	switch {
+	case errors.As(err, &unverified):
+		n = unverified.Note
+	case err != nil:
+		return fmt.Errorf("verifying checkpoint cosignatures: %w", err)
+	}
+	threshold := witnesses.Threshold
+	if threshold == 0 {
+		threshold = len(witnesses.Keys)
+	}
+	if len(n.Sigs) < threshold {
+		return fmt.Errorf("checkpoint is cosigned by %d witnesses, %d required", len(n.Sigs), threshold)
+	}
+	return nil
+}
+
+// verifyTLogEntryWitnesses verifies the witness cosignatures of the
+// checkpoint in the inclusion proof of e, which must have been verified.
+func verifyTLogEntryWitnesses(e *models.LogEntryAnon, witnesses *CheckpointWitnesses) error {
+	proof := e.Verification.InclusionProof
+	if proof.Checkpoint == nil || proof.TreeSize == nil || proof.RootHash == nil {
+		return errors.New("inclusion proof holds no checkpoint to verify the witness cosignatures of")
+	}
+	rootHash, err := hex.DecodeString(*proof.RootHash)
+	if err != nil {
+		return fmt.Errorf("decoding inclusion proof root hash: %w", err)
+	}
+	return VerifyCheckpointWitnesses(*proof.Checkpoint, *proof.TreeSize, rootHash, witnesses)
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/franchb/rekor/pkg/generated/models"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestVerifyCheckpointWitnesses(t *testing.T) {
+	newSigner := func(name string) (note.Signer, string) {
+		skey, vkey, err := note.GenerateKey(rand.Reader, name)
+		require.NoError(t, err)
+		s, err := note.NewSigner(skey)
+		require.NoError(t, err)
+		return s, vkey
+	}
+	logSigner, _ := newSigner("rekor.example.com")
+	witness1, vkey1 := newSigner("witness1.example.com")
+	witness2, vkey2 := newSigner("witness2.example.com")
+	_, vkey3 := newSigner("witness3.example.com")
+
+	rootHash := sha256.Sum256([]byte("root"))
+	body := fmt.Sprintf("rekor.example.com - 42\n10\n%s\n", base64.StdEncoding.EncodeToString(rootHash[:]))
+	checkpoint := func(signers ...note.Signer) string {
+		b, err := note.Sign(&note.Note{Text: body}, append([]note.Signer{logSigner}, signers...)...)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	twoOfThree, err := NewCheckpointWitnesses([]string{vkey1, vkey2, vkey3}, 2)
+	require.NoError(t, err)
+	all, err := NewCheckpointWitnesses([]string{vkey1, vkey2}, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, VerifyCheckpointWitnesses(checkpoint(witness1, witness2), 10, rootHash[:], twoOfThree))
+	require.NoError(t, VerifyCheckpointWitnesses(checkpoint(witness1, witness2), 10, rootHash[:], all))
+	require.ErrorContains(t, VerifyCheckpointWitnesses(checkpoint(witness1), 10, rootHash[:], twoOfThree), "cosigned by 1 witnesses, 2 required")
+	require.ErrorContains(t, VerifyCheckpointWitnesses(checkpoint(witness1), 10, rootHash[:], all), "cosigned by 1 witnesses, 2 required")
+	require.ErrorContains(t, VerifyCheckpointWitnesses(checkpoint(), 10, rootHash[:], twoOfThree), "cosigned by 0 witnesses")
+	// The same witness cosigning twice counts once.
+	require.Error(t, VerifyCheckpointWitnesses(checkpoint(witness1, witness1), 10, rootHash[:], twoOfThree))
+
+	// The checkpoint must be for the tree of the inclusion proof.
+	require.ErrorContains(t, VerifyCheckpointWitnesses(checkpoint(witness1, witness2), 11, rootHash[:], twoOfThree), "does not match")
+	otherHash := sha256.Sum256([]byte("other"))
+	require.ErrorContains(t, VerifyCheckpointWitnesses(checkpoint(witness1, witness2), 10, otherHash[:], twoOfThree), "does not match")
+	require.Error(t, VerifyCheckpointWitnesses("", 10, rootHash[:], twoOfThree))
+
+	// An invalid cosignature of a known witness fails verification.
+	invalidSig := binary.BigEndian.AppendUint32(nil, witness1.KeyHash())
+	invalidSig = append(invalidSig, make([]byte, 64)...)
+	tampered := checkpoint(witness2) + "— witness1.example.com " + base64.StdEncoding.EncodeToString(invalidSig) + "\n"
+	require.ErrorContains(t, VerifyCheckpointWitnesses(tampered, 10, rootHash[:], twoOfThree), "invalid signature")
+
+	// Entries are checked against the checkpoint of their inclusion proof.
+	cp := checkpoint(witness1, witness2)
+	treeSize := int64(10)
+	hexRootHash := hex.EncodeToString(rootHash[:])
+	entry := &models.LogEntryAnon{Verification: &models.LogEntryAnonVerification{InclusionProof: &models.InclusionProof{
+		Checkpoint: &cp,
+		TreeSize:   &treeSize,
+		RootHash:   &hexRootHash,
+	}}}
+	require.NoError(t, verifyTLogEntryWitnesses(entry, twoOfThree))
+	entry.Verification.InclusionProof.Checkpoint = nil
+	require.Error(t, verifyTLogEntryWitnesses(entry, twoOfThree))
+}
+
+func TestNewCheckpointWitnesses(t *testing.T) {
+	_, vkey, err := note.GenerateKey(rand.Reader, "witness.example.com")
+	require.NoError(t, err)
+
+	_, err = NewCheckpointWitnesses(nil, 0)
+	require.Error(t, err)
+	_, err = NewCheckpointWitnesses([]string{vkey}, 2)
+	require.Error(t, err)
+	_, err = NewCheckpointWitnesses([]string{"not a key"}, 1)
+	require.Error(t, err)
+}
+
+func TestSplitTrustedRootWitnesses(t *testing.T) {
+	_, vkey, err := note.GenerateKey(rand.Reader, "witness.example.com")
+	require.NoError(t, err)
+
+	rootJSON := []byte(`{"mediaType":"application/vnd.dev.sigstore.trustedroot+json;version=0.1"}`)
+	b, witnesses, err := SplitTrustedRootWitnesses(rootJSON)
+	require.NoError(t, err)
+	require.Nil(t, witnesses)
+	require.Equal(t, rootJSON, b)
+
+	b, witnesses, err = SplitTrustedRootWitnesses([]byte(`{"mediaType":"application/vnd.dev.sigstore.trustedroot+json;version=0.1","checkpointWitnesses":{"witnesses":["` + vkey + `"],"threshold":1}}`))
+	require.NoError(t, err)
+	require.JSONEq(t, string(rootJSON), string(b))
+	require.Equal(t, []string{vkey}, witnesses.Keys)
+	require.Equal(t, 1, witnesses.Threshold)
+
+	_, _, err = SplitTrustedRootWitnesses([]byte(`{"checkpointWitnesses":{"witnesses":[]}}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package git reads and writes the signatures of git commits and annotated
+// tags, in the x509 CMS format git verifies with gpg.format=x509.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// ObjectCommit is the type of git commit objects.
+	ObjectCommit = "commit"
+	// ObjectTag is the type of git annotated tag objects.
+	ObjectTag = "tag"
+
+	// signatureHeader is the header of commits holding their signature,
+	// continued on the following lines prefixed with a space.
+	signatureHeader = "gpgsig "
+)
+
+// signatureStarts are the first lines of the signatures git appends to the
+// message of annotated tags, in each of its signature formats.
+var signatureStarts = [][]byte{
+	[]byte("-----BEGIN PGP SIGNATURE-----"),
+	[]byte("-----BEGIN PGP MESSAGE-----"),
+	[]byte("-----BEGIN SIGNED MESSAGE-----"),
+	[]byte("-----BEGIN SSH SIGNATURE-----"),
+}
+
+// ErrNotSigned is returned for objects without a signature.
+var ErrNotSigned = errors.New("object is not signed")
+
+// Repository is a git repository, operated on with the git command.
+type Repository struct {
+	// Dir is a directory of the repository, the current directory if empty.
+	Dir string
+}
+
+// git runs git with args in the repository, returning its output.
+func (r Repository) git(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	subcommand := args[0]
+	if r.Dir != "" {
+		args = append([]string{"-C", r.Dir}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", subcommand, err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", subcommand, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// ResolveObject returns the ID of the object rev refers to, which must be
+// of type objectType. Annotated tags are not peeled, so that rev may name
+// the tag object itself.
+func (r Repository) ResolveObject(ctx context.Context, rev, objectType string) (string, error) {
+	out, err := r.git(ctx, nil, "rev-parse", "--verify", "--end-of-options", rev)
+	if err != nil {
+		return "", err
+	}
+	id := strings.TrimSpace(string(out))
+	out, err = r.git(ctx, nil, "cat-file", "-t", id)
+	if err != nil {
+		return "", err
+	}
+	if t := strings.TrimSpace(string(out)); t != objectType {
+		return "", fmt.Errorf("%s is a %s, not a %s", rev, t, objectType)
+	}
+	return id, nil
+}
+
+// ReadObject returns the content of the object id of type objectType.
+func (r Repository) ReadObject(ctx context.Context, objectType, id string) ([]byte, error) {
+	return r.git(ctx, nil, "cat-file", objectType, id)
+}
+
+// WriteObject writes an object of type objectType with content to the
+// repository, returning its ID.
+func (r Repository) WriteObject(ctx context.Context, objectType string, content []byte) (string, error) {
+	out, err := r.git(ctx, content, "hash-object", "-t", objectType, "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// UpdateRef points ref to the object newID, if it still points to oldID.
+func (r Repository) UpdateRef(ctx context.Context, ref, newID, oldID, reason string) error {
+	_, err := r.git(ctx, nil, "update-ref", "-m", reason, ref, newID, oldID)
+	return err
+}
+
+// SplitSignature returns the content of the object of type objectType
+// without its signature, which is what the signature signs, and the
+// signature. It returns ErrNotSigned if the object has no signature.
+func SplitSignature(objectType string, content []byte) (payload, signature []byte, err error) {
+	switch objectType {
+	case ObjectCommit:
+		payload, signature = splitCommitSignature(content)
+	case ObjectTag:
+		payload, signature = splitTagSignature(content)
+	default:
+		return nil, nil, fmt.Errorf("unsupported object type %q", objectType)
+	}
+	if signature == nil {
+		return payload, nil, ErrNotSigned
+	}
+	return payload, signature, nil
+}
+
+// AddSignature returns the content of the object of type objectType signed
+// with signature, which must be the signature of payload, its content
+// without signature.
+func AddSignature(objectType string, payload, signature []byte) ([]byte, error) {
+	switch objectType {
+	case ObjectCommit:
+		// The signature is the last header, each of its lines after the
+		// first continued with a space.
+		headers, message, ok := bytes.Cut(payload, []byte("\n\n"))
+		if !ok {
+			return nil, errors.New("commit has no message separator")
+		}
+		var b bytes.Buffer
+		b.Write(headers)
+		b.WriteString("\n" + signatureHeader)
+		b.Write(bytes.ReplaceAll(bytes.TrimSuffix(signature, []byte("\n")), []byte("\n"), []byte("\n ")))
+		b.WriteString("\n\n")
+		b.Write(message)
+		return b.Bytes(), nil
+	case ObjectTag:
+		return append(append([]byte{}, payload...), signature...), nil
+	default:
+		return nil, fmt.Errorf("unsupported object type %q", objectType)
+	}
+}
+
+// splitCommitSignature removes the gpgsig header from the headers of a
+// commit, returning its value without the continuation prefixes.
+func splitCommitSignature(content []byte) (payload, signature []byte) {
+	headers, message, ok := bytes.Cut(content, []byte("\n\n"))
+	if !ok {
+		return content, nil
+	}
+	var b bytes.Buffer
+	inSignature := false
+	for _, line := range bytes.SplitAfter(headers, []byte("\n")) {
+		switch {
+		case inSignature && bytes.HasPrefix(line, []byte(" ")):
+			signature = append(signature, line[1:]...)
+			continue
+		case bytes.HasPrefix(line, []byte(signatureHeader)):
+			inSignature = true
+			signature = append(signature, line[len(signatureHeader):]...)
+			continue
+		}
+		inSignature = false
+		b.Write(line)
+	}
+	if signature != nil && !bytes.HasSuffix(signature, []byte("\n")) {
+		signature = append(signature, '\n')
+	}
+	// The headers lost their last newline to the message separator, which
+	// the signature ends with when it was the last header.
+	payload = append(bytes.TrimSuffix(b.Bytes(), []byte("\n")), "\n\n"...)
+	return append(payload, message...), signature
+}
+
+// splitTagSignature splits the signature git appends to the message of an
+// annotated tag, starting at the last line that begins a signature.
+func splitTagSignature(content []byte) (payload, signature []byte) {
+	start := -1
+	for i := 0; i < len(content); {
+		line := content[i:]
+		for _, s := range signatureStarts {
+			if bytes.HasPrefix(line, s) {
+				start = i
+			}
+		}
+		next := bytes.IndexByte(line, '\n')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if start < 0 {
+		return content, nil
+	}
+	return content[:start], content[start:]
+}
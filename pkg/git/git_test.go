@@ -0,0 +1,188 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const (
+	testCommit = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"committer Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"\n" +
+		"Initial commit\n"
+	testTag = "object 0123456789abcdef0123456789abcdef01234567\n" +
+		"type commit\n" +
+		"tag v1.0.0\n" +
+		"tagger Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"\n" +
+		"Release v1.0.0\n"
+	testSignature = "-----BEGIN SIGNED MESSAGE-----\n" +
+		"MIIBsignature\n" +
+		"-----END SIGNED MESSAGE-----\n"
+)
+
+func TestSignatureRoundTrip(t *testing.T) {
+	for _, objectType := range []string{ObjectCommit, ObjectTag} {
+		t.Run(objectType, func(t *testing.T) {
+			payload := testCommit
+			if objectType == ObjectTag {
+				payload = testTag
+			}
+			if _, _, err := SplitSignature(objectType, []byte(payload)); !errors.Is(err, ErrNotSigned) {
+				t.Fatalf("SplitSignature() of unsigned object error = %v, want ErrNotSigned", err)
+			}
+
+			signed, err := AddSignature(objectType, []byte(payload), []byte(testSignature))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotPayload, gotSig, err := SplitSignature(objectType, signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(gotPayload) != payload {
+				t.Errorf("payload = %q, want %q", gotPayload, payload)
+			}
+			if string(gotSig) != testSignature {
+				t.Errorf("signature = %q, want %q", gotSig, testSignature)
+			}
+		})
+	}
+}
+
+func TestAddSignatureCommitFormat(t *testing.T) {
+	signed, err := AddSignature(ObjectCommit, []byte(testCommit), []byte(testSignature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"committer Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"gpgsig -----BEGIN SIGNED MESSAGE-----\n" +
+		" MIIBsignature\n" +
+		" -----END SIGNED MESSAGE-----\n" +
+		"\n" +
+		"Initial commit\n"
+	if string(signed) != want {
+		t.Errorf("AddSignature() = %q, want %q", signed, want)
+	}
+}
+
+func TestSplitSignatureKeepsOtherHeaders(t *testing.T) {
+	// A merge of a signed tag keeps the tag, and its signature, in a
+	// mergetag header that is part of the signed payload.
+	payload := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"parent 0123456789abcdef0123456789abcdef01234567\n" +
+		"mergetag object 0123456789abcdef0123456789abcdef01234567\n" +
+		" type commit\n" +
+		" -----BEGIN PGP SIGNATURE-----\n" +
+		" -----END PGP SIGNATURE-----\n" +
+		"author Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"committer Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"\n" +
+		"Merge tag v1.0.0\n"
+	signed := strings.Replace(payload, "author", "gpgsig -----BEGIN SIGNED MESSAGE-----\n -----END SIGNED MESSAGE-----\nauthor", 1)
+	gotPayload, gotSig, err := SplitSignature(ObjectCommit, []byte(signed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotPayload) != payload {
+		t.Errorf("payload = %q, want %q", gotPayload, payload)
+	}
+	if want := "-----BEGIN SIGNED MESSAGE-----\n-----END SIGNED MESSAGE-----\n"; string(gotSig) != want {
+		t.Errorf("signature = %q, want %q", gotSig, want)
+	}
+}
+
+func TestSplitSignatureTagUsesLastSignature(t *testing.T) {
+	payload := testTag + "Quoting a signature:\n-----BEGIN PGP SIGNATURE-----\n\n"
+	gotPayload, gotSig, err := SplitSignature(ObjectTag, []byte(payload+testSignature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotPayload) != payload || string(gotSig) != testSignature {
+		t.Errorf("SplitSignature() = %q, %q", gotPayload, gotSig)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+		{"-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "tag", "-a", "-m", "Release v1.0.0", "v1.0.0"},
+		{"tag", "lightweight"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	repo := Repository{Dir: dir}
+
+	if _, err := repo.ResolveObject(ctx, "refs/tags/lightweight", ObjectTag); err == nil {
+		t.Error("ResolveObject() of a lightweight tag succeeded, want error")
+	}
+	if _, err := repo.ResolveObject(ctx, "refs/tags/v1.0.0", ObjectTag); err != nil {
+		t.Errorf("ResolveObject() of an annotated tag: %v", err)
+	}
+
+	id, err := repo.ResolveObject(ctx, "HEAD", ObjectCommit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := repo.ReadObject(ctx, ObjectCommit, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := AddSignature(ObjectCommit, content, []byte(testSignature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedID, err := repo.WriteObject(ctx, ObjectCommit, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateRef(ctx, "HEAD", signedID, id, "sign"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateRef(ctx, "HEAD", signedID, id, "sign"); err == nil {
+		t.Error("UpdateRef() from an outdated object succeeded, want error")
+	}
+
+	head, err := repo.ResolveObject(ctx, "HEAD", ObjectCommit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != signedID {
+		t.Errorf("HEAD = %s, want %s", head, signedID)
+	}
+	content, err = repo.ReadObject(ctx, ObjectCommit, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, sig, err := SplitSignature(ObjectCommit, content); err != nil || string(sig) != testSignature {
+		t.Errorf("SplitSignature() = %q, %v", sig, err)
+	}
+}
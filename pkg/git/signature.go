@@ -0,0 +1,154 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/digitorus/pkcs7"
+	"github.com/digitorus/timestamp"
+)
+
+// SignaturePEMType is the PEM type of x509 signatures in git objects, the
+// S/MIME signatures of gpgsm and gitsign.
+const SignaturePEMType = "SIGNED MESSAGE"
+
+// oidTimeStampToken is the unsigned attribute holding an RFC3161 timestamp
+// of the signature, id-aa-timeStampToken in RFC3161 appendix A.
+var oidTimeStampToken = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+
+// TimestampFunc returns an RFC3161 timestamp response for signature.
+type TimestampFunc func(signature []byte) ([]byte, error)
+
+// Sign returns the detached CMS signature of payload by signer, whose
+// certificate is cert with the intermediate certificates chain, PEM-encoded
+// for git objects. The signature is timestamped with timestampFn unless it
+// is nil.
+func Sign(payload []byte, signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, timestampFn TimestampFunc) ([]byte, error) {
+	sd, err := pkcs7.NewSignedData(payload)
+	if err != nil {
+		return nil, err
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSignerChain(cert, signer, chain, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, fmt.Errorf("signing: %w", err)
+	}
+	if timestampFn != nil {
+		signerInfo := &sd.GetSignedData().SignerInfos[0]
+		resp, err := timestampFn(signerInfo.EncryptedDigest)
+		if err != nil {
+			return nil, fmt.Errorf("timestamping: %w", err)
+		}
+		ts, err := timestamp.ParseResponse(resp)
+		if err != nil {
+			return nil, fmt.Errorf("parsing timestamp: %w", err)
+		}
+		if err := signerInfo.SetUnauthenticatedAttributes([]pkcs7.Attribute{{
+			Type:  oidTimeStampToken,
+			Value: asn1.RawValue{FullBytes: ts.RawToken},
+		}}); err != nil {
+			return nil, err
+		}
+	}
+	sd.Detach()
+	der, err := sd.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: SignaturePEMType, Bytes: der}), nil
+}
+
+// Signature is a verified CMS signature of a git object.
+type Signature struct {
+	// Certificate is the certificate of the signer, and Intermediates the
+	// other certificates of the signature.
+	Certificate   *x509.Certificate
+	Intermediates []*x509.Certificate
+	// SigningTime is the time the signer claims to have signed at, within
+	// the validity of Certificate.
+	SigningTime time.Time
+	// Value is the signature value, which Timestamp timestamps.
+	Value []byte
+	// Timestamp is the RFC3161 timestamp response of the signature, nil if
+	// it has none.
+	Timestamp []byte
+}
+
+// VerifySignature verifies that sig is a CMS signature of payload made with
+// the key of the certificate it holds, returning it. The certificate itself
+// is not verified.
+func VerifySignature(payload, sig []byte) (*Signature, error) {
+	block, _ := pem.Decode(sig)
+	if block == nil || block.Type != SignaturePEMType {
+		return nil, fmt.Errorf("not an x509 signature: expected a PEM %q block", SignaturePEMType)
+	}
+	p7, err := pkcs7.Parse(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signature: %w", err)
+	}
+	if len(p7.Signers) != 1 {
+		return nil, fmt.Errorf("expected 1 signer, got %d", len(p7.Signers))
+	}
+	p7.Content = payload
+	if err := p7.VerifyWithOpts(x509.VerifyOptions{}); err != nil {
+		return nil, fmt.Errorf("verifying signature: %w", err)
+	}
+	cert := p7.GetOnlySigner()
+	if cert == nil {
+		return nil, errors.New("no certificate for signer")
+	}
+	s := &Signature{
+		Certificate: cert,
+		Value:       p7.Signers[0].EncryptedDigest,
+	}
+	for _, c := range p7.Certificates {
+		if c != cert {
+			s.Intermediates = append(s.Intermediates, c)
+		}
+	}
+	if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &s.SigningTime); err != nil {
+		return nil, fmt.Errorf("reading signing time: %w", err)
+	}
+	for _, attr := range p7.Signers[0].UnauthenticatedAttributes {
+		if attr.Type.Equal(oidTimeStampToken) {
+			if s.Timestamp, err = timestampResponse(attr.Value.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// timestampResponse wraps the RFC3161 timestamp token in a timestamp
+// response, which timestamp verification takes.
+func timestampResponse(token []byte) ([]byte, error) {
+	resp := struct {
+		Status struct {
+			Status int
+		}
+		TimeStampToken asn1.RawValue
+	}{TimeStampToken: asn1.RawValue{FullBytes: token}}
+	b, err := asn1.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("reading timestamp: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	tsaverification "github.com/sigstore/timestamp-authority/pkg/verification"
+
+	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa"
+	tsaMock "github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/mock"
+	"github.com/franchb/cosign/v2/test"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+	leafCert, leafKey, _ := test.GenerateLeafCert("jane@example.com", "https://accounts.example.com", subCert, subKey)
+
+	payload := []byte(testCommit)
+	sig, err := Sign(payload, leafKey, leafCert, []*x509.Certificate{subCert, rootCert}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(sig, []byte("-----BEGIN SIGNED MESSAGE-----\n")) {
+		t.Errorf("signature is not a PEM signed message: %s", sig)
+	}
+
+	s, err := VerifySignature(payload, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Certificate.Equal(leafCert) {
+		t.Error("signature certificate is not the leaf certificate")
+	}
+	if len(s.Intermediates) != 2 {
+		t.Errorf("got %d intermediates, want 2", len(s.Intermediates))
+	}
+	if time.Since(s.SigningTime) > time.Minute {
+		t.Errorf("signing time %v is not now", s.SigningTime)
+	}
+	if s.Timestamp != nil {
+		t.Error("signature without timestamp has a timestamp")
+	}
+
+	if _, err := VerifySignature([]byte(testTag), sig); err == nil {
+		t.Error("VerifySignature() of another payload succeeded, want error")
+	}
+	if _, err := VerifySignature(payload, []byte(testSignature)); err == nil {
+		t.Error("VerifySignature() of a malformed signature succeeded, want error")
+	}
+}
+
+func TestSignTimestamp(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	leafCert, leafKey, _ := test.GenerateLeafCert("jane@example.com", "https://accounts.example.com", rootCert, rootKey)
+	client, err := tsaMock.NewTSAClient(tsaMock.TSAClientOptions{Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(testTag)
+	sig, err := Sign(payload, leafKey, leafCert, nil, func(signature []byte) ([]byte, error) {
+		return tsa.GetTimestampedSignature(signature, client)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := VerifySignature(payload, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Timestamp == nil {
+		t.Fatal("signature has no timestamp")
+	}
+
+	if _, err := tsaverification.VerifyTimestampResponse(s.Timestamp, bytes.NewReader(s.Value), tsaverification.VerifyOpts{
+		TSACertificate: client.CertChain[0],
+		Intermediates:  client.CertChain[1 : len(client.CertChain)-1],
+		Roots:          []*x509.Certificate{client.CertChain[len(client.CertChain)-1]},
+	}); err != nil {
+		t.Errorf("verifying timestamp: %v", err)
+	}
+}
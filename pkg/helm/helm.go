@@ -0,0 +1,198 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package helm resolves Helm charts stored in OCI registries and reads the
+// metadata of Helm chart archives.
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociremote "github.com/franchb/cosign/v2/pkg/oci/remote"
+)
+
+const (
+	// Scheme prefixes the references of Helm charts, e.g.
+	// helm://registry.example.com/charts/app:1.2.3.
+	Scheme = "helm://"
+
+	// ConfigMediaType is the media type of the config of Helm charts in OCI
+	// registries.
+	ConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+	// ChartLayerMediaType is the media type of the layer holding the chart
+	// archive.
+	ChartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	// maxChartYAMLSize bounds the Chart.yaml read from an archive.
+	maxChartYAMLSize = 1 << 20
+)
+
+// ErrNotChart is returned for archives and OCI artifacts that are not Helm
+// charts.
+var ErrNotChart = errors.New("not a Helm chart")
+
+// Chart is the metadata of a Helm chart, from its Chart.yaml.
+type Chart struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ResolvedChart is a Helm chart in an OCI registry.
+type ResolvedChart struct {
+	Chart
+	// Digest is the chart manifest, which signatures and attestations are
+	// attached to.
+	Digest name.Digest
+	// ArchiveDigest is the digest of the chart archive, the .tgz file
+	// `helm package` writes.
+	ArchiveDigest v1.Hash
+}
+
+// IsReference returns whether s is a helm:// reference.
+func IsReference(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// ParseReference parses the helm:// reference s into the OCI reference of the
+// chart. Its tag is the chart version, whose "+" is stored as "_" in OCI tags
+// as Helm does.
+func ParseReference(s string, opts ...name.Option) (name.Reference, error) {
+	if !IsReference(s) {
+		return nil, fmt.Errorf("%q is not a %s reference", s, Scheme)
+	}
+	s = strings.TrimPrefix(s, Scheme)
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") && !strings.Contains(s, "@") {
+		s = s[:i] + strings.ReplaceAll(s[i:], "+", "_")
+	}
+	ref, err := name.ParseReference(s, append(opts, name.StrictValidation)...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing chart reference: %w", err)
+	}
+	return ref, nil
+}
+
+// ReadChart returns the metadata of the Helm chart archive read from r, or
+// ErrNotChart if r is not a gzipped tarball with a top-level chart directory
+// holding a Chart.yaml.
+func ReadChart(r io.Reader) (*Chart, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrNotChart
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNotChart
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotChart, err)
+		}
+		dir, file := path.Split(path.Clean(hdr.Name))
+		if file != "Chart.yaml" || dir == "" || strings.Count(dir, "/") != 1 {
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(tr, maxChartYAMLSize))
+		if err != nil {
+			return nil, fmt.Errorf("reading Chart.yaml: %w", err)
+		}
+		var c Chart
+		if err := yaml.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("parsing Chart.yaml: %w", err)
+		}
+		if c.Name == "" || c.Version == "" {
+			return nil, fmt.Errorf("%w: Chart.yaml has no name or version", ErrNotChart)
+		}
+		return &c, nil
+	}
+}
+
+// Resolve resolves the Helm chart at ref to its manifest and archive
+// digests. The chart archive is read to check that it holds the chart the
+// tag of ref names.
+func Resolve(ref name.Reference, opts ...ociremote.Option) (*ResolvedChart, error) {
+	digest, err := ociremote.ResolveDigest(ref, opts...)
+	if err != nil {
+		return nil, err
+	}
+	se, err := ociremote.SignedEntity(digest, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("accessing chart: %w", err)
+	}
+	img, ok := se.(oci.SignedImage)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", ref, ErrNotChart)
+	}
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading chart manifest: %w", err)
+	}
+	if m.Config.MediaType != ConfigMediaType {
+		return nil, fmt.Errorf("%s: %w, its config has media type %q", ref, ErrNotChart, m.Config.MediaType)
+	}
+	var archiveDigest *v1.Hash
+	for _, l := range m.Layers {
+		if l.MediaType == ChartLayerMediaType {
+			archiveDigest = &l.Digest
+			break
+		}
+	}
+	if archiveDigest == nil {
+		return nil, fmt.Errorf("%s: %w, it has no %s layer", ref, ErrNotChart, ChartLayerMediaType)
+	}
+
+	layer, err := img.LayerByDigest(*archiveDigest)
+	if err != nil {
+		return nil, fmt.Errorf("accessing chart archive: %w", err)
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("reading chart archive: %w", err)
+	}
+	defer rc.Close()
+	// The archive is read whole so that its digest is verified.
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading chart archive: %w", err)
+	}
+	chart, err := ReadChart(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("reading chart archive: %w", err)
+	}
+	if tag, ok := ref.(name.Tag); ok && tag.TagStr() != strings.ReplaceAll(chart.Version, "+", "_") {
+		return nil, fmt.Errorf("%s holds version %s of chart %s", ref, chart.Version, chart.Name)
+	}
+	if chart.Name != path.Base(ref.Context().RepositoryStr()) {
+		return nil, fmt.Errorf("%s holds chart %s", ref, chart.Name)
+	}
+
+	return &ResolvedChart{
+		Chart:         *chart,
+		Digest:        digest,
+		ArchiveDigest: *archiveDigest,
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+)
+
+// chartArchive returns a chart archive with the files, keyed by path.
+func chartArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return b.Bytes()
+}
+
+func TestReadChart(t *testing.T) {
+	archive := chartArchive(t, map[string]string{
+		"app/values.yaml":             "replicas: 1\n",
+		"app/charts/dep/Chart.yaml":   "name: dep\nversion: 0.1.0\n",
+		"app/Chart.yaml":              "apiVersion: v2\nname: app\nversion: 1.2.3+build.4\n",
+		"app/templates/configmap.yml": "kind: ConfigMap\n",
+	})
+	chart, err := ReadChart(bytes.NewReader(archive))
+	require.NoError(t, err)
+	require.Equal(t, &Chart{Name: "app", Version: "1.2.3+build.4"}, chart)
+
+	for name, b := range map[string][]byte{
+		"not gzipped":   []byte("hello"),
+		"no Chart.yaml": chartArchive(t, map[string]string{"app/values.yaml": "{}"}),
+		"nested only":   chartArchive(t, map[string]string{"app/charts/dep/Chart.yaml": "name: dep\nversion: 0.1.0\n"}),
+		"no version":    chartArchive(t, map[string]string{"app/Chart.yaml": "name: app\n"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadChart(bytes.NewReader(b))
+			require.ErrorIs(t, err, ErrNotChart)
+		})
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "helm://registry.example.com/charts/app:1.2.3", want: "registry.example.com/charts/app:1.2.3"},
+		{in: "helm://registry.example.com:5000/app:1.2.3+build.4", want: "registry.example.com:5000/app:1.2.3_build.4"},
+		{in: "helm://registry.example.com/app@sha256:" + strings.Repeat("a", 64), want: "registry.example.com/app@sha256:" + strings.Repeat("a", 64)},
+		{in: "registry.example.com/app:1.2.3", wantErr: true},
+		{in: "helm://registry.example.com/App:1.2.3", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			ref, err := ParseReference(tc.in)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ref.String())
+		})
+	}
+}
+
+// pushChart pushes archive as a Helm chart to ref.
+func pushChart(t *testing.T, ref name.Reference, archive []byte) v1.Hash {
+	t.Helper()
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer(archive, ChartLayerMediaType)})
+	require.NoError(t, err)
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, ConfigMediaType)
+	require.NoError(t, remote.Write(ref, img))
+	d, err := img.Digest()
+	require.NoError(t, err)
+	return d
+}
+
+func TestResolve(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	host := strings.TrimPrefix(s.URL, "http://")
+
+	archive := chartArchive(t, map[string]string{"app/Chart.yaml": "name: app\nversion: 1.2.3+build.4\n"})
+	ref, err := ParseReference(Scheme + host + "/charts/app:1.2.3+build.4")
+	require.NoError(t, err)
+	manifestDigest := pushChart(t, ref, archive)
+
+	chart, err := Resolve(ref)
+	require.NoError(t, err)
+	require.Equal(t, Chart{Name: "app", Version: "1.2.3+build.4"}, chart.Chart)
+	require.Equal(t, manifestDigest.String(), chart.Digest.DigestStr())
+	archiveDigest, _, err := v1.SHA256(bytes.NewReader(archive))
+	require.NoError(t, err)
+	require.Equal(t, archiveDigest, chart.ArchiveDigest)
+
+	// The tag must name the version of the chart it holds.
+	moved, err := name.ParseReference(host + "/charts/app:2.0.0")
+	require.NoError(t, err)
+	pushChart(t, moved, archive)
+	_, err = Resolve(moved)
+	require.ErrorContains(t, err, "holds version 1.2.3+build.4")
+
+	// So must the repository its name.
+	other, err := name.ParseReference(host + "/charts/other:1.2.3_build.4")
+	require.NoError(t, err)
+	pushChart(t, other, archive)
+	_, err = Resolve(other)
+	require.ErrorContains(t, err, "holds chart app")
+
+	imageRef, err := name.ParseReference(host + "/image:latest")
+	require.NoError(t, err)
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(imageRef, img))
+	_, err = Resolve(imageRef)
+	require.True(t, errors.Is(err, ErrNotChart), "got %v", err)
+}
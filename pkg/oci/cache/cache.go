@@ -0,0 +1,226 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache stores the signature and attestation images fetched from a
+// registry on disk, so that verifying an image again does not download them
+// again.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// refsDir is the directory under the cache root holding, for each cached
+// reference, the digest of its manifest.
+const refsDir = "refs"
+
+// Cache is an on-disk store of signature and attestation images. Their
+// manifests, configs and layers are kept as OCI image layout blobs, and each
+// reference they were read from records the manifest it resolved to.
+// Signature and attestation tags name the digest of the image they are for,
+// so entries are keyed by that digest. Entries expire after a TTL so that
+// signatures added to the registry since are picked up.
+type Cache struct {
+	path layout.Path
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// New returns a Cache storing images under dir, creating it if needed, that
+// serves them for ttl after they were fetched.
+func New(dir string, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, refsDir), 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	return &Cache{
+		path: layout.Path(dir),
+		ttl:  ttl,
+		now:  time.Now,
+	}, nil
+}
+
+// Get returns the image ref resolved to, if it was stored less than the TTL
+// ago and all of its blobs are still present.
+func (c *Cache) Get(ref name.Reference) (v1.Image, bool) {
+	file := c.refPath(ref)
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, false
+	}
+	if c.now().Sub(fi.ModTime()) > c.ttl {
+		_ = os.Remove(file)
+		return nil, false
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	h, err := v1.NewHash(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, false
+	}
+	img, err := c.image(h)
+	if err != nil {
+		return nil, false
+	}
+	return img, true
+}
+
+// Put stores img as the image ref resolved to, and returns the stored copy.
+func (c *Cache) Put(ref name.Reference, img v1.Image) (v1.Image, error) {
+	if err := c.path.WriteImage(img); err != nil {
+		return nil, fmt.Errorf("caching %s: %w", ref, err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(c.refPath(ref), []byte(h.String())); err != nil {
+		return nil, fmt.Errorf("caching %s: %w", ref, err)
+	}
+	return c.image(h)
+}
+
+// refPath returns the file recording the manifest ref resolved to.
+func (c *Cache) refPath(ref name.Reference) string {
+	sum := sha256.Sum256([]byte(ref.Name()))
+	return filepath.Join(string(c.path), refsDir, hex.EncodeToString(sum[:]))
+}
+
+// blobPath returns the file holding the blob h.
+func (c *Cache) blobPath(h v1.Hash) string {
+	return filepath.Join(string(c.path), "blobs", h.Algorithm, h.Hex)
+}
+
+// image returns the stored image with the manifest h, failing if any of its
+// blobs are missing.
+func (c *Cache) image(h v1.Hash) (v1.Image, error) {
+	raw, err := c.path.Bytes(h)
+	if err != nil {
+		return nil, err
+	}
+	m, err := v1.ParseManifest(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	for _, desc := range append([]v1.Descriptor{m.Config}, m.Layers...) {
+		if _, err := os.Stat(c.blobPath(desc.Digest)); err != nil {
+			return nil, err
+		}
+	}
+	return partial.CompressedToImage(&image{
+		cache:    c,
+		raw:      raw,
+		manifest: m,
+	})
+}
+
+// writeFile replaces the contents of file with b, so that concurrent readers
+// never see a partial write.
+func writeFile(file string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+type image struct {
+	cache    *Cache
+	raw      []byte
+	manifest *v1.Manifest
+}
+
+var _ partial.CompressedImageCore = (*image)(nil)
+
+// RawManifest implements partial.CompressedImageCore
+func (i *image) RawManifest() ([]byte, error) {
+	return i.raw, nil
+}
+
+// MediaType implements partial.CompressedImageCore
+func (i *image) MediaType() (types.MediaType, error) {
+	if i.manifest.MediaType == "" {
+		return types.OCIManifestSchema1, nil
+	}
+	return i.manifest.MediaType, nil
+}
+
+// RawConfigFile implements partial.CompressedImageCore
+func (i *image) RawConfigFile() ([]byte, error) {
+	return i.cache.path.Bytes(i.manifest.Config.Digest)
+}
+
+// LayerByDigest implements partial.CompressedImageCore
+func (i *image) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	if h == i.manifest.Config.Digest {
+		return &blob{cache: i.cache, desc: i.manifest.Config}, nil
+	}
+	for _, desc := range i.manifest.Layers {
+		if desc.Digest == h {
+			return &blob{cache: i.cache, desc: desc}, nil
+		}
+	}
+	return nil, fmt.Errorf("blob %v not found", h)
+}
+
+// blob is a layer read from the cache.
+type blob struct {
+	cache *Cache
+	desc  v1.Descriptor
+}
+
+var _ partial.CompressedLayer = (*blob)(nil)
+
+// Digest implements partial.CompressedLayer
+func (b *blob) Digest() (v1.Hash, error) {
+	return b.desc.Digest, nil
+}
+
+// Compressed implements partial.CompressedLayer
+func (b *blob) Compressed() (io.ReadCloser, error) {
+	return b.cache.path.Blob(b.desc.Digest)
+}
+
+// Size implements partial.CompressedLayer
+func (b *blob) Size() (int64, error) {
+	return b.desc.Size, nil
+}
+
+// MediaType implements partial.CompressedLayer
+func (b *blob) MediaType() (types.MediaType, error) {
+	return b.desc.MediaType, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c, err := New(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	c.now = func() time.Time { return now }
+
+	ref := name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.sig")
+	if _, ok := c.Get(ref); ok {
+		t.Fatal("Get() found an image that was never stored")
+	}
+
+	img, err := random.Image(100, 3)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	if _, err := c.Put(ref, img); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	got, ok := c.Get(ref)
+	if !ok {
+		t.Fatal("Get() missed a stored image")
+	}
+	wantDigest, _ := img.Digest()
+	if d, err := got.Digest(); err != nil || d != wantDigest {
+		t.Errorf("Digest() = %v, %v, wanted %v", d, err, wantDigest)
+	}
+	layers, err := got.Layers()
+	if err != nil {
+		t.Fatalf("Layers() = %v", err)
+	}
+	if len(layers) != 3 {
+		t.Fatalf("got %d layers, wanted 3", len(layers))
+	}
+	for _, l := range layers {
+		if _, err := l.Compressed(); err != nil {
+			t.Errorf("Compressed() = %v", err)
+		}
+	}
+
+	other := name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.att")
+	if _, ok := c.Get(other); ok {
+		t.Error("Get() found an image stored under another reference")
+	}
+
+	// A stored image whose blobs were removed is a miss.
+	m, _ := img.Manifest()
+	if err := os.Remove(c.blobPath(m.Layers[0].Digest)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(ref); ok {
+		t.Error("Get() returned an image with a missing layer")
+	}
+	if _, err := c.Put(ref, img); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	now = now.Add(time.Hour + time.Minute)
+	if _, ok := c.Get(ref); ok {
+		t.Error("Get() returned an expired image")
+	}
+	if _, err := os.Stat(c.refPath(ref)); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed: %v", err)
+	}
+}
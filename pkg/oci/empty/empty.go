@@ -46,3 +46,13 @@ var _ oci.Signatures = (*emptyImage)(nil)
 func (*emptyImage) Get() ([]oci.Signature, error) {
 	return nil, nil
 }
+
+// Annotations implements oci.Signatures
+func (*emptyImage) Annotations() (map[string]string, error) {
+	return nil, nil
+}
+
+// Subject implements oci.Signatures
+func (*emptyImage) Subject() (*v1.Descriptor, error) {
+	return nil, nil
+}
@@ -44,6 +44,14 @@ func (se *signedImage) Attachment(name string) (oci.File, error) { //nolint: rev
 	return nil, errors.New("no attachments")
 }
 
+func (se *signedImage) Annotations() (map[string]string, error) {
+	return nil, nil
+}
+
+func (se *signedImage) Subject() (*v1.Descriptor, error) {
+	return nil, nil
+}
+
 func (se *signedImage) Digest() (v1.Hash, error) {
 	if se.digest.Hex == "" {
 		return v1.Hash{}, fmt.Errorf("digest not available")
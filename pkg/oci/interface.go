@@ -33,4 +33,12 @@ type SignedEntity interface {
 
 	// Attachment returns a named entity associated with this entity, or error if not found.
 	Attachment(name string) (File, error)
+
+	// Annotations returns the annotations of this entity's manifest, such as
+	// its creation time, or nil if it has none or its manifest is unknown.
+	Annotations() (map[string]string, error)
+
+	// Subject returns the descriptor of the manifest this entity refers to
+	// as an OCI 1.1 referrer, or nil if it has none or its manifest is unknown.
+	Subject() (*v1.Descriptor, error)
 }
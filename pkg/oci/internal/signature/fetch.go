@@ -0,0 +1,101 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// Fetch returns the signatures of the layers described by descs, which
+// layer looks up by digest. With more than one worker, up to workers layers
+// are fetched at once and read ahead as stored, so that their Payload is
+// served from memory. A layer whose content cannot be read ahead is returned
+// as is, so that the error surfaces from its Payload as it would otherwise,
+// and so is a layer that was not found.
+func Fetch(descs []v1.Descriptor, layer func(v1.Hash) (v1.Layer, error), workers int) ([]oci.Signature, error) {
+	signatures := make([]oci.Signature, len(descs))
+	if workers <= 1 {
+		for i, desc := range descs {
+			l, err := layer(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+			signatures[i] = New(l, desc)
+		}
+		return signatures, nil
+	}
+
+	var g errgroup.Group
+	g.SetLimit(workers)
+	for i, desc := range descs {
+		g.Go(func() error {
+			l, err := layer(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if l == nil {
+				signatures[i] = New(l, desc)
+				return nil
+			}
+			sig := &sigLayer{Layer: l, desc: desc}
+			if raw, err := sig.raw(); err == nil {
+				sig.Layer = static.NewLayer(raw, desc.MediaType)
+			}
+			signatures[i] = sig
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+// Iterate returns an iterator over the signatures of the layers described by
+// descs, which layer looks up by digest. Each layer is looked up only when
+// the iterator reaches it, and is not read ahead.
+func Iterate(descs []v1.Descriptor, layer func(v1.Hash) (v1.Layer, error)) oci.SignatureIterator {
+	return &iterator{descs: descs, layer: layer}
+}
+
+type iterator struct {
+	descs []v1.Descriptor
+	layer func(v1.Hash) (v1.Layer, error)
+	err   error
+}
+
+// Next implements oci.SignatureIterator
+func (it *iterator) Next() (oci.Signature, bool) {
+	if it.err != nil || len(it.descs) == 0 {
+		return nil, false
+	}
+	desc := it.descs[0]
+	it.descs = it.descs[1:]
+	l, err := it.layer(desc.Digest)
+	if err != nil {
+		it.err = err
+		return nil, false
+	}
+	return New(l, desc), true
+}
+
+// Err implements oci.SignatureIterator
+func (it *iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+
+	ctypes "github.com/franchb/cosign/v2/pkg/types"
+)
+
+// countingLayer counts how often its content is read.
+type countingLayer struct {
+	v1.Layer
+	reads *atomic.Int32
+}
+
+func (l *countingLayer) Compressed() (io.ReadCloser, error) {
+	l.reads.Add(1)
+	return l.Layer.Compressed()
+}
+
+func TestFetch(t *testing.T) {
+	var reads atomic.Int32
+	layers := map[v1.Hash]v1.Layer{}
+	var descs []v1.Descriptor
+	for i := 0; i < 20; i++ {
+		l := static.NewLayer([]byte(fmt.Sprintf("payload %d", i)), types.MediaType("text/plain"))
+		h, err := l.Digest()
+		if err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		layers[h] = &countingLayer{Layer: l, reads: &reads}
+		descs = append(descs, v1.Descriptor{Digest: h, MediaType: "text/plain"})
+	}
+	lookup := func(h v1.Hash) (v1.Layer, error) {
+		return layers[h], nil
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			reads.Store(0)
+			sigs, err := Fetch(descs, lookup, workers)
+			if err != nil {
+				t.Fatalf("Fetch() = %v", err)
+			}
+			if len(sigs) != len(descs) {
+				t.Fatalf("Fetch() returned %d signatures, wanted %d", len(sigs), len(descs))
+			}
+			for i, sig := range sigs {
+				// Twice, so that read-ahead payloads are served from memory.
+				for j := 0; j < 2; j++ {
+					got, err := sig.Payload()
+					if err != nil {
+						t.Fatalf("Payload() = %v", err)
+					}
+					if want := []byte(fmt.Sprintf("payload %d", i)); !bytes.Equal(got, want) {
+						t.Errorf("Payload() = %s, wanted %s", got, want)
+					}
+				}
+			}
+			wantReads := int32(len(descs))
+			if workers == 1 {
+				wantReads *= 2
+			}
+			if got := reads.Load(); got != wantReads {
+				t.Errorf("layers read %d times, wanted %d", got, wantReads)
+			}
+		})
+	}
+
+	t.Run("lookup error", func(t *testing.T) {
+		want := errors.New("boom")
+		_, err := Fetch(descs, func(v1.Hash) (v1.Layer, error) { return nil, want }, 4)
+		if !errors.Is(err, want) {
+			t.Errorf("Fetch() = %v, wanted %v", err, want)
+		}
+	})
+}
+
+func TestFetchZstd(t *testing.T) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("NewWriter() = %v", err)
+	}
+	mt := types.MediaType("text/plain" + ctypes.ZstdMediaTypeSuffix)
+	l := static.NewLayer(enc.EncodeAll([]byte("payload"), nil), mt)
+	h, err := l.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	descs := []v1.Descriptor{{Digest: h, MediaType: mt}, {Digest: h, MediaType: mt}}
+	sigs, err := Fetch(descs, func(v1.Hash) (v1.Layer, error) { return l, nil }, 2)
+	if err != nil {
+		t.Fatalf("Fetch() = %v", err)
+	}
+	for _, sig := range sigs {
+		if got, err := sig.Payload(); err != nil || string(got) != "payload" {
+			t.Errorf("Payload() = %s, %v, wanted payload", got, err)
+		}
+		if got, err := sig.Digest(); err != nil || got != h {
+			t.Errorf("Digest() = %v, %v, wanted %v", got, err, h)
+		}
+	}
+}
+
+func TestIterate(t *testing.T) {
+	layers := map[v1.Hash]v1.Layer{}
+	var descs []v1.Descriptor
+	for i := 0; i < 3; i++ {
+		l := static.NewLayer([]byte(fmt.Sprintf("payload %d", i)), types.MediaType("text/plain"))
+		h, err := l.Digest()
+		if err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		layers[h] = l
+		descs = append(descs, v1.Descriptor{Digest: h, MediaType: "text/plain"})
+	}
+	var lookups int
+	want := errors.New("boom")
+	it := Iterate(descs, func(h v1.Hash) (v1.Layer, error) {
+		lookups++
+		if h == descs[1].Digest {
+			return nil, want
+		}
+		return layers[h], nil
+	})
+
+	sig, ok := it.Next()
+	if !ok {
+		t.Fatalf("Next() = false, wanted the first signature: %v", it.Err())
+	}
+	if got, err := sig.Payload(); err != nil || string(got) != "payload 0" {
+		t.Errorf("Payload() = %s, %v, wanted payload 0", got, err)
+	}
+	if lookups != 1 {
+		t.Errorf("looked up %d layers before they were reached, wanted 1", lookups)
+	}
+	if _, ok := it.Next(); ok {
+		t.Fatal("Next() = true, wanted the lookup error to end the iteration")
+	}
+	if !errors.Is(it.Err(), want) {
+		t.Errorf("Err() = %v, wanted %v", it.Err(), want)
+	}
+	if _, ok := it.Next(); ok || lookups != 2 {
+		t.Errorf("Next() continued after an error, %d lookups", lookups)
+	}
+}
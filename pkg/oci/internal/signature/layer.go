@@ -16,6 +16,7 @@
 package signature
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -26,8 +27,10 @@ import (
 	payloadsize "github.com/franchb/cosign/v2/internal/pkg/cosign/payload/size"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	ctypes "github.com/franchb/cosign/v2/pkg/types"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
@@ -59,6 +62,23 @@ func (s *sigLayer) Annotations() (map[string]string, error) {
 
 // Payload implements oci.Signature
 func (s *sigLayer) Payload() ([]byte, error) {
+	raw, err := s.raw()
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(string(s.desc.MediaType), ctypes.ZstdMediaTypeSuffix) {
+		d, err := zstd.NewReader(bytes.NewReader(raw), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer d.Close()
+		return payloadsize.ReadAll(d)
+	}
+	return raw, nil
+}
+
+// raw returns the content of the layer as stored in the registry.
+func (s *sigLayer) raw() ([]byte, error) {
 	size, err := s.Layer.Size()
 	if err != nil {
 		return nil, err
@@ -73,11 +93,7 @@ func (s *sigLayer) Payload() ([]byte, error) {
 		return nil, err
 	}
 	defer r.Close()
-	payload, err := io.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
-	return payload, nil
+	return io.ReadAll(r)
 }
 
 // Signature implements oci.Signature
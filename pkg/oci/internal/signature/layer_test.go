@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/google/go-cmp/cmp"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/random"
@@ -505,3 +506,34 @@ func (m *mockLayer) Digest() (v1.Hash, error)             { panic("not implement
 func (m *mockLayer) DiffID() (v1.Hash, error)             { panic("not implemented") }
 func (m *mockLayer) Uncompressed() (io.ReadCloser, error) { panic("not implemented") }
 func (m *mockLayer) MediaType() (types.MediaType, error)  { panic("not implemented") }
+
+func TestSignatureZstd(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"spdxVersion":"SPDX-2.3"}`, 100))
+	layer, err := static.NewAttestation(payload, static.WithLayerMediaType("application/vnd.dsse.envelope.v1+json"), static.WithZstdCompression())
+	if err != nil {
+		t.Fatalf("NewAttestation() = %v", err)
+	}
+	mt, err := layer.MediaType()
+	if err != nil {
+		t.Fatalf("MediaType() = %v", err)
+	}
+	digest, err := layer.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+
+	sig := New(layer, v1.Descriptor{MediaType: mt, Digest: digest})
+	got, err := sig.Payload()
+	if err != nil {
+		t.Fatalf("Payload() = %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("Payload() is not the decompressed payload")
+	}
+
+	// Decompression is bounded by the maximum attachment size.
+	t.Setenv("COSIGN_MAX_ATTACHMENT_SIZE", "1KiB")
+	if _, err := sig.Payload(); err == nil {
+		t.Error("Payload() succeeded for a payload above the maximum size")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+// SignatureIterator streams a set of signatures one at a time, so that
+// callers need not hold all of them at once and may stop early.
+type SignatureIterator interface {
+	// Next returns the next signature. It returns false once the signatures
+	// are exhausted or an error occurred, which Err then returns.
+	Next() (Signature, bool)
+
+	// Err returns the error that ended the iteration, if any.
+	Err() error
+}
+
+// IterableSignatures is implemented by Signatures that can stream their
+// signatures instead of materializing them all in Get.
+type IterableSignatures interface {
+	Signatures
+
+	// Iterate returns an iterator over the signatures that Get would return,
+	// in the same order.
+	Iterate() (SignatureIterator, error)
+}
+
+// Iterate returns an iterator over the signatures of sigs. The signatures
+// are streamed if sigs implements IterableSignatures, and are otherwise
+// retrieved with Get up front.
+func Iterate(sigs Signatures) (SignatureIterator, error) {
+	if is, ok := sigs.(IterableSignatures); ok {
+		return is.Iterate()
+	}
+	sl, err := sigs.Get()
+	if err != nil {
+		return nil, err
+	}
+	return NewSliceIterator(sl), nil
+}
+
+// NewSliceIterator returns a SignatureIterator over sl.
+func NewSliceIterator(sl []Signature) SignatureIterator {
+	return &sliceIterator{sl: sl}
+}
+
+type sliceIterator struct {
+	sl []Signature
+}
+
+// Next implements SignatureIterator
+func (it *sliceIterator) Next() (Signature, bool) {
+	if len(it.sl) == 0 {
+		return nil, false
+	}
+	sig := it.sl[0]
+	it.sl = it.sl[1:]
+	return sig, true
+}
+
+// Err implements SignatureIterator
+func (*sliceIterator) Err() error {
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import "testing"
+
+type fakeSignatures struct {
+	Signatures
+	sl []Signature
+}
+
+func (fs *fakeSignatures) Get() ([]Signature, error) {
+	return fs.sl, nil
+}
+
+func TestIterateFallsBackToGet(t *testing.T) {
+	sl := []Signature{nil, nil}
+	it, err := Iterate(&fakeSignatures{sl: sl})
+	if err != nil {
+		t.Fatalf("Iterate() = %v", err)
+	}
+	n := 0
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+		n++
+	}
+	if n != len(sl) {
+		t.Errorf("iterated over %d signatures, wanted %d", n, len(sl))
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
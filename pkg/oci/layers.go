@@ -0,0 +1,42 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"strconv"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+)
+
+// DefaultMaxLayers is the number of signature or attestation layers cosign
+// processes before aborting with MaxLayersExceeded.
+const DefaultMaxLayers = 1000
+
+// DefaultFetchWorkers is the number of signature or attestation layers
+// fetched at once by the Get method of oci.Signatures read from a registry or
+// layout.
+const DefaultFetchWorkers = 8
+
+// MaxLayers returns the maximum number of signature or attestation layers to
+// process: the value of COSIGN_MAX_SIGNATURE_LAYERS if it is a positive
+// integer, and DefaultMaxLayers otherwise.
+func MaxLayers() int64 {
+	if v, ok := env.LookupEnv(env.VariableMaxSignatureLayers); ok {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultMaxLayers
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"testing"
+)
+
+func TestMaxLayers(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int64
+	}{
+		{env: "", want: DefaultMaxLayers},
+		{env: "5000", want: 5000},
+		{env: "0", want: DefaultMaxLayers},
+		{env: "-1", want: DefaultMaxLayers},
+		{env: "many", want: DefaultMaxLayers},
+	}
+	for _, tc := range tests {
+		t.Run(tc.env, func(t *testing.T) {
+			t.Setenv("COSIGN_MAX_SIGNATURE_LAYERS", tc.env)
+			if got := MaxLayers(); got != tc.want {
+				t.Errorf("MaxLayers() = %d, wanted %d", got, tc.want)
+			}
+		})
+	}
+}
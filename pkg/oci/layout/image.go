@@ -0,0 +1,71 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"errors"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/empty"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+type image struct {
+	v1.Image
+
+	// layout is the index.json of the layout holding the signatures and
+	// attestations.
+	layout *index
+	// digest is the digest of this image within a saved image index, or
+	// empty if it is the saved entity itself.
+	digest string
+}
+
+var _ oci.SignedImage = (*image)(nil)
+
+// Signatures implements oci.SignedImage
+func (i *image) Signatures() (oci.Signatures, error) {
+	return orEmpty(i.layout.signatures(sigsAnnotation, i.digest))
+}
+
+// Attestations implements oci.SignedImage
+func (i *image) Attestations() (oci.Signatures, error) {
+	return orEmpty(i.layout.signatures(attsAnnotation, i.digest))
+}
+
+// Attachment implements oci.SignedImage
+func (i *image) Attachment(name string) (oci.File, error) { //nolint: revive
+	return nil, errors.New("not yet implemented")
+}
+
+// Annotations implements oci.SignedImage
+func (i *image) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(i.Image)
+}
+
+// Subject implements oci.SignedImage
+func (i *image) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(i.Image)
+}
+
+func orEmpty(sigs oci.Signatures, err error) (oci.Signatures, error) {
+	if err != nil {
+		return nil, err
+	}
+	if sigs == nil {
+		return empty.Signatures(), nil
+	}
+	return sigs, nil
+}
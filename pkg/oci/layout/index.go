@@ -19,7 +19,6 @@ import (
 	"fmt"
 
 	"github.com/franchb/cosign/v2/pkg/oci"
-	"github.com/franchb/cosign/v2/pkg/oci/signed"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/layout"
 )
@@ -30,10 +29,18 @@ const (
 	imageIndexAnnotation = "dev.cosignproject.cosign/imageIndex"
 	sigsAnnotation       = "dev.cosignproject.cosign/sigs"
 	attsAnnotation       = "dev.cosignproject.cosign/atts"
+
+	trustedRootAnnotation = "dev.cosignproject.cosign/trustedRoot"
+	publicKeyAnnotation   = "dev.cosignproject.cosign/publicKey"
+
+	// digestAnnotation holds the digest of the image within a saved image
+	// index that signatures or attestations belong to. It is absent for those
+	// of the saved image or image index itself.
+	digestAnnotation = "dev.cosignproject.cosign/digest"
 )
 
 // SignedImageIndex provides access to a local index reference, and its signatures.
-func SignedImageIndex(path string) (oci.SignedImageIndex, error) {
+func SignedImageIndex(path string, opts ...Option) (oci.SignedImageIndex, error) {
 	p, err := layout.FromPath(path)
 	if err != nil {
 		return nil, err
@@ -42,8 +49,11 @@ func SignedImageIndex(path string) (oci.SignedImageIndex, error) {
 	if err != nil {
 		return nil, err
 	}
+	o := makeOptions(opts...)
 	return &index{
-		v1Index: ii,
+		v1Index:      ii,
+		maxLayers:    o.MaxLayers,
+		fetchWorkers: o.FetchWorkers,
 	}, nil
 }
 
@@ -53,32 +63,27 @@ type v1Index v1.ImageIndex
 
 type index struct {
 	v1Index
+	maxLayers    int64
+	fetchWorkers int
+
+	// layout is the index.json of the layout holding the signatures and
+	// attestations, or nil if this is it.
+	layout *index
+	// digest is the digest of this image index within a saved image index,
+	// or empty if it is the saved entity itself.
+	digest string
 }
 
 var _ oci.SignedImageIndex = (*index)(nil)
 
 // Signatures implements oci.SignedImageIndex
 func (i *index) Signatures() (oci.Signatures, error) {
-	img, err := i.imageByAnnotation(sigsAnnotation)
-	if err != nil {
-		return nil, err
-	}
-	if img == nil {
-		return nil, nil
-	}
-	return &sigs{img}, nil
+	return i.root().signatures(sigsAnnotation, i.digest)
 }
 
 // Attestations implements oci.SignedImageIndex
 func (i *index) Attestations() (oci.Signatures, error) {
-	img, err := i.imageByAnnotation(attsAnnotation)
-	if err != nil {
-		return nil, err
-	}
-	if img == nil {
-		return nil, nil
-	}
-	return &sigs{img}, nil
+	return i.root().signatures(attsAnnotation, i.digest)
 }
 
 // Attestations implements oci.SignedImage
@@ -86,6 +91,16 @@ func (i *index) Attachment(name string) (oci.File, error) { //nolint: revive
 	return nil, fmt.Errorf("not yet implemented")
 }
 
+// Annotations implements oci.SignedImageIndex
+func (i *index) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(i.v1Index)
+}
+
+// Subject implements oci.SignedImageIndex
+func (i *index) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(i.v1Index)
+}
+
 // SignedImage implements oci.SignedImageIndex
 // if an empty hash is passed in, return the original image that was signed
 func (i *index) SignedImage(h v1.Hash) (oci.SignedImage, error) {
@@ -102,7 +117,47 @@ func (i *index) SignedImage(h v1.Hash) (oci.SignedImage, error) {
 	if img == nil {
 		return nil, nil
 	}
-	return signed.Image(img), nil
+	return &image{
+		Image:  img,
+		layout: i.root(),
+		digest: i.childDigest(h),
+	}, nil
+}
+
+// root returns the index.json of the layout.
+func (i *index) root() *index {
+	if i.layout == nil {
+		return i
+	}
+	return i.layout
+}
+
+// childDigest returns the digest that signatures of the entity h within i are
+// annotated with, which is empty for the saved entity itself.
+func (i *index) childDigest(h v1.Hash) string {
+	if i.layout == nil {
+		return ""
+	}
+	return h.String()
+}
+
+// signatures returns the signatures or attestations, depending on kind, of
+// the entity with the given digest, or nil if there are none.
+func (i *index) signatures(kind, digest string) (oci.Signatures, error) {
+	manifest, err := i.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manifest.Manifests {
+		if m.Annotations[kindAnnotation] == kind && m.Annotations[digestAnnotation] == digest {
+			img, err := i.Image(m.Digest)
+			if err != nil {
+				return nil, err
+			}
+			return &sigs{Image: img, maxLayers: i.maxLayers, fetchWorkers: i.fetchWorkers}, nil
+		}
+	}
+	return nil, nil
 }
 
 // imageByAnnotation searches through all manifests in the index.json
@@ -149,6 +204,10 @@ func (i *index) SignedImageIndex(h v1.Hash) (oci.SignedImageIndex, error) {
 		return nil, nil
 	}
 	return &index{
-		v1Index: ii,
+		v1Index:      ii,
+		maxLayers:    i.maxLayers,
+		fetchWorkers: i.fetchWorkers,
+		layout:       i.root(),
+		digest:       i.childDigest(h),
 	}, nil
 }
@@ -0,0 +1,69 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// Option is a functional option for reading from or writing to a layout.
+type Option func(*options)
+
+type options struct {
+	MaxLayers    int64
+	FetchWorkers int
+	Jobs         int
+}
+
+func makeOptions(opts ...Option) *options {
+	o := &options{
+		MaxLayers:    oci.MaxLayers(),
+		FetchWorkers: oci.DefaultFetchWorkers,
+		Jobs:         1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMaxLayers is a functional option for overriding the maximum number of
+// signature or attestation layers to process, which defaults to
+// oci.MaxLayers(). Signatures with more layers fail with MaxLayersExceeded.
+func WithMaxLayers(n int64) Option {
+	return func(o *options) {
+		o.MaxLayers = n
+	}
+}
+
+// WithFetchWorkers is a functional option for overriding how many signature
+// or attestation layers are read at once, which defaults to
+// oci.DefaultFetchWorkers. With 1, layers are read one by one as their
+// payloads are.
+func WithFetchWorkers(n int) Option {
+	return func(o *options) {
+		o.FetchWorkers = n
+	}
+}
+
+// WithJobs is a functional option for overriding how many of the image,
+// signatures and attestations WriteSignedImage and WriteSignedImageIndex
+// write at once, which defaults to 1. Whatever the number, they are listed
+// in index.json, and their errors reported, in the same order.
+func WithJobs(n int) Option {
+	return func(o *options) {
+		o.Jobs = n
+	}
+}
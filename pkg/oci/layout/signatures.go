@@ -21,31 +21,51 @@ import (
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
-const maxLayers = 1000
-
 type sigs struct {
 	v1.Image
+	maxLayers    int64
+	fetchWorkers int
 }
 
-var _ oci.Signatures = (*sigs)(nil)
+var _ oci.IterableSignatures = (*sigs)(nil)
 
 // Get implements oci.Signatures
 func (s *sigs) Get() ([]oci.Signature, error) {
+	layers, err := s.layers()
+	if err != nil {
+		return nil, err
+	}
+	return signature.Fetch(layers, s.Image.LayerByDigest, s.fetchWorkers)
+}
+
+// Annotations implements oci.Signatures
+func (s *sigs) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(s.Image)
+}
+
+// Subject implements oci.Signatures
+func (s *sigs) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(s.Image)
+}
+
+// Iterate implements oci.IterableSignatures
+func (s *sigs) Iterate() (oci.SignatureIterator, error) {
+	layers, err := s.layers()
+	if err != nil {
+		return nil, err
+	}
+	return signature.Iterate(layers, s.Image.LayerByDigest), nil
+}
+
+// layers returns the descriptors of the signature layers.
+func (s *sigs) layers() ([]v1.Descriptor, error) {
 	manifest, err := s.Image.Manifest()
 	if err != nil {
 		return nil, err
 	}
 	numLayers := int64(len(manifest.Layers))
-	if numLayers > maxLayers {
-		return nil, oci.NewMaxLayersExceeded(numLayers, maxLayers)
-	}
-	signatures := make([]oci.Signature, 0, numLayers)
-	for _, desc := range manifest.Layers {
-		l, err := s.Image.LayerByDigest(desc.Digest)
-		if err != nil {
-			return nil, err
-		}
-		signatures = append(signatures, signature.New(l, desc))
+	if numLayers > s.maxLayers {
+		return nil, oci.NewMaxLayersExceeded(numLayers, s.maxLayers)
 	}
-	return signatures, nil
+	return manifest.Layers, nil
 }
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/franchb/cosign/v2/pkg/oci"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/fake"
 )
@@ -26,18 +27,33 @@ func TestGet(t *testing.T) {
 	tests := []struct {
 		name      string
 		layers    int
+		maxLayers int64
 		wantError error
 	}{
 		{
 			name:      "within limit",
 			layers:    23,
+			maxLayers: oci.DefaultMaxLayers,
 			wantError: nil,
 		},
 		{
 			name:      "exceeds limit",
 			layers:    4242,
+			maxLayers: oci.DefaultMaxLayers,
 			wantError: errors.New("number of layers (4242) exceeded the limit (1000)"),
 		},
+		{
+			name:      "within raised limit",
+			layers:    4242,
+			maxLayers: 5000,
+			wantError: nil,
+		},
+		{
+			name:      "exceeds lowered limit",
+			layers:    23,
+			maxLayers: 10,
+			wantError: errors.New("number of layers (23) exceeded the limit (10)"),
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -49,6 +65,7 @@ func TestGet(t *testing.T) {
 						}, nil
 					},
 				},
+				maxLayers: test.maxLayers,
 			}
 			_, err := s.Get()
 			if test.wantError != nil && test.wantError.Error() != err.Error() {
@@ -0,0 +1,140 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+func TestWriteTarRoundTrip(t *testing.T) {
+	si := randomSignedImage(t)
+	tmp := t.TempDir()
+	if err := WriteSignedImage(tmp, si); err != nil {
+		t.Fatal(err)
+	}
+	trustedRoot := []byte(`{"mediaType":"application/vnd.dev.sigstore.trustedroot+json;version=0.1"}`)
+	if err := WriteTrustedRoot(tmp, trustedRoot); err != nil {
+		t.Fatal(err)
+	}
+	publicKey := []byte("-----BEGIN PUBLIC KEY-----\n-----END PUBLIC KEY-----\n")
+	if err := WritePublicKey(tmp, publicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTar(tmp, &buf); err != nil {
+		t.Fatal(err)
+	}
+	// the archive only depends on the contents of the layout
+	var again bytes.Buffer
+	if err := WriteTar(tmp, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("WriteTar() is not deterministic")
+	}
+
+	archive := filepath.Join(t.TempDir(), "image.tar")
+	if err := os.WriteFile(archive, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := IsTar(archive); err != nil || !ok {
+		t.Fatalf("IsTar(archive) = %v, %v", ok, err)
+	}
+	if ok, err := IsTar(tmp); err != nil || ok {
+		t.Fatalf("IsTar(layout) = %v, %v", ok, err)
+	}
+
+	dir, cleanup, err := OpenTar(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	imageIndex, err := SignedImageIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := imageIndex.SignedImage(v1.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareDigests(t, si, got)
+	sigs, err := got.Signatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl, err := sigs.Get(); err != nil || len(sl) != 6 {
+		t.Fatalf("Signatures() = %d, %v, wanted 6", len(sl), err)
+	}
+
+	gotRoot, err := TrustedRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(trustedRoot), string(gotRoot)); diff != "" {
+		t.Errorf("TrustedRoot() mismatch (-want +got):\n%s", diff)
+	}
+	gotKey, err := PublicKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(publicKey), string(gotKey)); diff != "" {
+		t.Errorf("PublicKey() mismatch (-want +got):\n%s", diff)
+	}
+
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("cleanup() left %s behind: %v", dir, err)
+	}
+}
+
+func TestExtractTarRejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  *tar.Header
+	}{{
+		name: "parent directory",
+		hdr:  &tar.Header{Typeflag: tar.TypeReg, Name: "../escape", Mode: 0o644},
+	}, {
+		name: "absolute path",
+		hdr:  &tar.Header{Typeflag: tar.TypeReg, Name: "/escape", Mode: 0o644},
+	}, {
+		name: "symlink",
+		hdr:  &tar.Header{Typeflag: tar.TypeSymlink, Name: "blobs", Linkname: "/etc"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			if err := tw.WriteHeader(tc.hdr); err != nil {
+				t.Fatal(err)
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := ExtractTar(&buf, t.TempDir()); err == nil {
+				t.Error("ExtractTar() succeeded for an unsafe entry")
+			}
+		})
+	}
+}
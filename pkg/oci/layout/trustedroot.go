@@ -0,0 +1,112 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// TrustedRootMediaType is the media type of the layer holding a Sigstore
+// trusted root in a layout.
+const TrustedRootMediaType types.MediaType = "application/vnd.dev.sigstore.trustedroot+json;version=0.1"
+
+// PublicKeyMediaType is the media type of the layer holding a PEM encoded
+// public key in a layout.
+const PublicKeyMediaType types.MediaType = "application/vnd.dev.cosign.publickey+pem"
+
+// WriteTrustedRoot adds the Sigstore trusted root JSON to the layout at path,
+// so that signatures in it can be verified without fetching trust material
+// over the network. The layout must already have been written.
+func WriteTrustedRoot(path string, trustedRoot []byte) error {
+	if err := writeFile(path, trustedRoot, TrustedRootMediaType, trustedRootAnnotation); err != nil {
+		return fmt.Errorf("appending trusted root: %w", err)
+	}
+	return nil
+}
+
+// TrustedRoot returns the Sigstore trusted root JSON stored in the layout at
+// path by WriteTrustedRoot, or nil if there is none.
+func TrustedRoot(path string) ([]byte, error) {
+	return readFile(path, trustedRootAnnotation, "trusted root")
+}
+
+// WritePublicKey adds the PEM encoded public key signatures in the layout at
+// path are verified with, so that it travels with them. Verification does
+// not trust it by itself: it must be checked against, or be, a key the
+// verifier already trusts. The layout must already have been written.
+func WritePublicKey(path string, pemKey []byte) error {
+	if err := writeFile(path, pemKey, PublicKeyMediaType, publicKeyAnnotation); err != nil {
+		return fmt.Errorf("appending public key: %w", err)
+	}
+	return nil
+}
+
+// PublicKey returns the PEM encoded public key stored in the layout at path
+// by WritePublicKey, or nil if there is none.
+func PublicKey(path string) ([]byte, error) {
+	return readFile(path, publicKeyAnnotation, "public key")
+}
+
+// writeFile adds b as a single layer image of mediaType to the layout at
+// path, marked with annotation.
+func writeFile(path string, b []byte, mediaType types.MediaType, annotation string) error {
+	layoutPath, err := layout.FromPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := static.NewFile(b, static.WithLayerMediaType(mediaType))
+	if err != nil {
+		return err
+	}
+	return appendImage(layoutPath, f, annotation, "")
+}
+
+// readFile returns the contents of the file written by writeFile with
+// annotation to the layout at path, or nil if there is none.
+func readFile(path, annotation, kind string) ([]byte, error) {
+	layoutPath, err := layout.FromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	ii, err := layoutPath.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	img, err := (&index{v1Index: ii}).imageByAnnotation(annotation)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, nil
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) != 1 {
+		return nil, fmt.Errorf("expected exactly one %s layer, got %d", kind, len(layers))
+	}
+	rc, err := layers[0].Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -16,78 +16,226 @@
 package layout
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/franchb/cosign/v2/pkg/oci"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"golang.org/x/sync/errgroup"
 )
 
 // WriteSignedImage writes the image and all related signatures, attestations and attachments
-func WriteSignedImage(path string, si oci.SignedImage) error {
+func WriteSignedImage(path string, si oci.SignedImage, opts ...Option) error {
 	// First, write an empty index
 	layoutPath, err := layout.Write(path, empty.Index)
 	if err != nil {
 		return err
 	}
+	w := newLayoutWriter(layoutPath, opts...)
 	// write the image
-	if err := appendImage(layoutPath, si, imageAnnotation); err != nil {
-		return fmt.Errorf("appending signed image: %w", err)
-	}
-	return writeSignedEntity(layoutPath, si)
+	w.add(func() ([]v1.Descriptor, error) {
+		desc, err := writeImage(layoutPath, si, imageAnnotation, "")
+		if err != nil {
+			return nil, fmt.Errorf("appending signed image: %w", err)
+		}
+		return []v1.Descriptor{desc}, nil
+	})
+	w.addSignedEntity(func() (oci.SignedEntity, error) { return si, nil }, "")
+	return w.run()
 }
 
 // WriteSignedImageIndex writes the image index and all related signatures, attestations and attachments
-func WriteSignedImageIndex(path string, si oci.SignedImageIndex) error {
+func WriteSignedImageIndex(path string, si oci.SignedImageIndex, opts ...Option) error {
 	// First, write an empty index
 	layoutPath, err := layout.Write(path, empty.Index)
 	if err != nil {
 		return err
 	}
+	w := newLayoutWriter(layoutPath, opts...)
 	// write the image index
-	if err := layoutPath.AppendIndex(si, layout.WithAnnotations(
-		map[string]string{kindAnnotation: imageIndexAnnotation},
-	)); err != nil {
-		return fmt.Errorf("appending signed image index: %w", err)
+	w.add(func() ([]v1.Descriptor, error) {
+		if err := layoutPath.WriteIndex(si); err != nil {
+			return nil, fmt.Errorf("appending signed image index: %w", err)
+		}
+		desc, err := describe(si, imageIndexAnnotation, "")
+		if err != nil {
+			return nil, fmt.Errorf("appending signed image index: %w", err)
+		}
+		return []v1.Descriptor{desc}, nil
+	})
+	w.addSignedEntity(func() (oci.SignedEntity, error) { return si, nil }, "")
+	if err := w.addChildSignatures(si); err != nil {
+		return err
+	}
+	return w.run()
+}
+
+// layoutWriter writes the images making up a signed entity to a layout. The
+// blobs of up to jobs of them are written at once, but they are listed in
+// index.json in the order they were added, and the errors of all of them are
+// reported in that order too, however long each took to write.
+type layoutWriter struct {
+	path  layout.Path
+	jobs  int
+	tasks []func() ([]v1.Descriptor, error)
+}
+
+func newLayoutWriter(path layout.Path, opts ...Option) *layoutWriter {
+	o := makeOptions(opts...)
+	return &layoutWriter{path: path, jobs: max(o.Jobs, 1)}
+}
+
+// add queues task, which writes blobs to w.path and returns the descriptors
+// to list in index.json for them.
+func (w *layoutWriter) add(task func() ([]v1.Descriptor, error)) {
+	w.tasks = append(w.tasks, task)
+}
+
+// run runs the queued tasks and, if all of them succeed, lists what they
+// wrote in index.json.
+func (w *layoutWriter) run() error {
+	descs := make([][]v1.Descriptor, len(w.tasks))
+	errs := make([]error, len(w.tasks))
+	var g errgroup.Group
+	g.SetLimit(w.jobs)
+	for i, task := range w.tasks {
+		g.Go(func() error {
+			descs[i], errs[i] = task()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	for _, ds := range descs {
+		for _, desc := range ds {
+			if err := w.path.AppendDescriptor(desc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addChildSignatures queues the signatures and attestations of each image
+// and image index within sii, annotated with its digest.
+func (w *layoutWriter) addChildSignatures(sii oci.SignedImageIndex) error {
+	manifest, err := sii.IndexManifest()
+	if err != nil {
+		return err
+	}
+	for _, desc := range manifest.Manifests {
+		switch {
+		case desc.MediaType.IsImage():
+			w.addSignedEntity(func() (oci.SignedEntity, error) {
+				si, err := sii.SignedImage(desc.Digest)
+				if err != nil {
+					return nil, fmt.Errorf("getting image %s: %w", desc.Digest, err)
+				}
+				return si, nil
+			}, desc.Digest.String())
+		case desc.MediaType.IsIndex():
+			child, err := sii.SignedImageIndex(desc.Digest)
+			if err != nil {
+				return fmt.Errorf("getting image index %s: %w", desc.Digest, err)
+			}
+			w.addSignedEntity(func() (oci.SignedEntity, error) { return child, nil }, desc.Digest.String())
+			if err := w.addChildSignatures(child); err != nil {
+				return err
+			}
+		}
 	}
-	return writeSignedEntity(layoutPath, si)
+	return nil
 }
 
-func writeSignedEntity(path layout.Path, se oci.SignedEntity) error {
+// addSignedEntity queues the signatures and attestations of the entity
+// returned by get, annotating them with digest unless it is empty.
+func (w *layoutWriter) addSignedEntity(get func() (oci.SignedEntity, error), digest string) {
+	w.add(func() ([]v1.Descriptor, error) {
+		se, err := get()
+		if err != nil {
+			return nil, err
+		}
+		return writeSignedEntity(w.path, se, digest)
+	})
+}
+
+// writeSignedEntity writes the signatures and attestations of se, annotating
+// them with digest unless it is empty, and returns their descriptors.
+func writeSignedEntity(path layout.Path, se oci.SignedEntity, digest string) ([]v1.Descriptor, error) {
+	var descs []v1.Descriptor
 	// write the signatures
 	sigs, err := se.Signatures()
 	if err != nil {
-		return fmt.Errorf("getting signatures: %w", err)
+		return nil, fmt.Errorf("getting signatures: %w", err)
 	}
 	if !isEmpty(sigs) {
-		if err := appendImage(path, sigs, sigsAnnotation); err != nil {
-			return fmt.Errorf("appending signatures: %w", err)
+		desc, err := writeImage(path, sigs, sigsAnnotation, digest)
+		if err != nil {
+			return nil, fmt.Errorf("appending signatures: %w", err)
 		}
+		descs = append(descs, desc)
 	}
 
 	// write attestations
 	atts, err := se.Attestations()
 	if err != nil {
-		return fmt.Errorf("getting atts")
+		return nil, fmt.Errorf("getting atts")
 	}
 	if !isEmpty(atts) {
-		if err := appendImage(path, atts, attsAnnotation); err != nil {
-			return fmt.Errorf("appending atts: %w", err)
+		desc, err := writeImage(path, atts, attsAnnotation, digest)
+		if err != nil {
+			return nil, fmt.Errorf("appending atts: %w", err)
 		}
+		descs = append(descs, desc)
 	}
 	// TODO (priyawadhwa@) and attachments
-	return nil
+	return descs, nil
 }
 
 // isEmpty returns true if the signatures or attestations are empty
 func isEmpty(s oci.Signatures) bool {
+	if s == nil {
+		return true
+	}
 	ss, _ := s.Get()
 	return ss == nil
 }
 
-func appendImage(path layout.Path, img v1.Image, annotation string) error {
-	return path.AppendImage(img, layout.WithAnnotations(
-		map[string]string{kindAnnotation: annotation},
-	))
+func appendImage(path layout.Path, img v1.Image, annotation, digest string) error {
+	desc, err := writeImage(path, img, annotation, digest)
+	if err != nil {
+		return err
+	}
+	return path.AppendDescriptor(desc)
+}
+
+// writeImage writes the blobs of img to path without listing it in
+// index.json, and returns the descriptor to list it with.
+func writeImage(path layout.Path, img v1.Image, annotation, digest string) (v1.Descriptor, error) {
+	if err := path.WriteImage(img); err != nil {
+		return v1.Descriptor{}, err
+	}
+	return describe(img, annotation, digest)
+}
+
+// describe returns the descriptor of d, annotated with annotation and,
+// unless it is empty, digest.
+func describe(d partial.Describable, annotation, digest string) (v1.Descriptor, error) {
+	desc, err := partial.Descriptor(d)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	if desc.Annotations == nil {
+		desc.Annotations = make(map[string]string)
+	}
+	desc.Annotations[kindAnnotation] = annotation
+	if digest != "" {
+		desc.Annotations[digestAnnotation] = digest
+	}
+	return *desc, nil
 }
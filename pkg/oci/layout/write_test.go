@@ -16,7 +16,13 @@
 package layout
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -25,8 +31,13 @@ import (
 	"github.com/franchb/cosign/v2/pkg/oci/signed"
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/compression"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	v1mutate "github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
 func TestReadWrite(t *testing.T) {
@@ -94,6 +105,320 @@ func TestReadWrite(t *testing.T) {
 	}
 }
 
+// signedImageIndex names oci.SignedImageIndex so that it can be embedded
+// without the field hiding its SignedImageIndex method.
+type signedImageIndex = oci.SignedImageIndex
+
+// childSignedIndex overrides the images of an oci.SignedImageIndex, so that
+// they can carry signatures of their own.
+type childSignedIndex struct {
+	signedImageIndex
+	images map[v1.Hash]oci.SignedImage
+	errs   map[v1.Hash]error
+}
+
+func (c *childSignedIndex) SignedImage(h v1.Hash) (oci.SignedImage, error) {
+	if err, ok := c.errs[h]; ok {
+		return nil, err
+	}
+	if si, ok := c.images[h]; ok {
+		return si, nil
+	}
+	return c.signedImageIndex.SignedImage(h)
+}
+
+func TestReadWriteImageIndex(t *testing.T) {
+	idx, err := random.Index(300 /* byteSize */, 1 /* layers */, 2 /* count */)
+	if err != nil {
+		t.Fatalf("random.Index() = %v", err)
+	}
+	indexSig, err := static.NewSignature(nil, "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sii, err := mutate.AttachSignatureToImageIndex(signed.ImageIndex(idx), indexSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sign the first image only
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedDigest, unsignedDigest := manifest.Manifests[0].Digest, manifest.Manifests[1].Digest
+	img, err := idx.Image(signedDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imageSig, err := static.NewSignature(nil, "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := mutate.AttachSignatureToImage(signed.Image(img), imageSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sii = &childSignedIndex{
+		signedImageIndex: sii,
+		images:           map[v1.Hash]oci.SignedImage{signedDigest: si},
+	}
+
+	tmp := t.TempDir()
+	if err := WriteSignedImageIndex(tmp, sii); err != nil {
+		t.Fatal(err)
+	}
+
+	layoutIndex, err := SignedImageIndex(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotIndex, err := layoutIndex.SignedImageIndex(v1.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSignatures := func(se oci.SignedEntity, want ...string) {
+		t.Helper()
+		sigs, err := se.Signatures()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := sigs.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d signatures, wanted %d", len(got), len(want))
+		}
+		for i, sig := range got {
+			b64sig, err := sig.Base64Signature()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b64sig != want[i] {
+				t.Errorf("signature %d = %q, wanted %q", i, b64sig, want[i])
+			}
+		}
+	}
+	checkSignatures(layoutIndex, "index")
+	checkSignatures(gotIndex, "index")
+
+	gotImage, err := gotIndex.SignedImage(signedDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSignatures(gotImage, "image")
+
+	gotImage, err = gotIndex.SignedImage(unsignedDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSignatures(gotImage)
+}
+
+// randomSignedImageIndex returns an image index of count images, each with a
+// signature of its own.
+func randomSignedImageIndex(t *testing.T, count int) *childSignedIndex {
+	idx, err := random.Index(300 /* byteSize */, 1 /* layers */, int64(count))
+	if err != nil {
+		t.Fatalf("random.Index() = %v", err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sii := &childSignedIndex{
+		signedImageIndex: signed.ImageIndex(idx),
+		images:           map[v1.Hash]oci.SignedImage{},
+	}
+	for i, desc := range manifest.Manifests {
+		img, err := idx.Image(desc.Digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := static.NewSignature(nil, fmt.Sprintf("image-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sii.images[desc.Digest], err = mutate.AttachSignatureToImage(signed.Image(img), sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sii
+}
+
+func TestWriteSignedImageIndexJobs(t *testing.T) {
+	sii := randomSignedImageIndex(t, 8)
+
+	var want []byte
+	for _, jobs := range []int{1, 4, 16} {
+		tmp := t.TempDir()
+		if err := WriteSignedImageIndex(tmp, sii, WithJobs(jobs)); err != nil {
+			t.Fatalf("WriteSignedImageIndex(jobs=%d) = %v", jobs, err)
+		}
+		got, err := os.ReadFile(filepath.Join(tmp, "index.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = got
+		} else if d := cmp.Diff(string(want), string(got)); d != "" {
+			t.Errorf("index.json with %d jobs differs from 1 job: %s", jobs, d)
+		}
+	}
+}
+
+func TestWriteSignedImageIndexJobsErrors(t *testing.T) {
+	sii := randomSignedImageIndex(t, 8)
+	manifest, err := sii.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := manifest.Manifests[1].Digest, manifest.Manifests[6].Digest
+	sii.errs = map[v1.Hash]error{
+		first: errors.New("first"),
+		last:  errors.New("last"),
+	}
+
+	want := fmt.Sprintf("getting image %s: first\ngetting image %s: last", first, last)
+	for _, jobs := range []int{1, 4, 16} {
+		err := WriteSignedImageIndex(t.TempDir(), sii, WithJobs(jobs))
+		if err == nil || err.Error() != want {
+			t.Errorf("WriteSignedImageIndex(jobs=%d) = %v, wanted %q", jobs, err, want)
+		}
+	}
+}
+
+func TestWriteTrustedRoot(t *testing.T) {
+	tmp := t.TempDir()
+	if err := WriteSignedImage(tmp, randomSignedImage(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := TrustedRoot(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("TrustedRoot() = %s, wanted nil before one was written", got)
+	}
+
+	want := []byte(`{"mediaType":"application/vnd.dev.sigstore.trustedroot+json;version=0.1"}`)
+	if err := WriteTrustedRoot(tmp, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err = TrustedRoot(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("TrustedRoot() mismatch (-want +got):\n%s", diff)
+	}
+
+	// the signed image is still found alongside the trusted root
+	imageIndex, err := SignedImageIndex(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := imageIndex.SignedImage(v1.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadWriteCompressedLayers(t *testing.T) {
+	// Each layer holds a different file, as images find layers by diff ID.
+	opener := func(name string) tarball.Opener {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		content := []byte("hello")
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+		}
+	}
+	zstdLayer, err := tarball.LayerFromOpener(opener("zstd.txt"), tarball.WithCompression(compression.ZStd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An estargz layer is a gzip layer annotated with the digest of its table
+	// of contents. Describe one rather than build it with tarball.WithEstargz,
+	// whose footer does not fit the gzip headers written by newer Go releases.
+	estargzLayer, err := tarball.LayerFromOpener(opener("estargz.txt"), tarball.WithMediaType(types.OCILayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	toc, _, err := v1.SHA256(bytes.NewReader([]byte(`{"version":1,"entries":[]}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := v1mutate.Append(v1mutate.MediaType(empty.Image, types.OCIManifestSchema1), v1mutate.Addendum{
+		Layer: zstdLayer,
+	}, v1mutate.Addendum{
+		Layer:       estargzLayer,
+		Annotations: map[string]string{"containerd.io/snapshot/stargz/toc.digest": toc.String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare with the manifest as written, which omits empty annotations.
+	raw, err := img.RawManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := v1.ParseManifest(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	if err := WriteSignedImage(tmp, signed.Image(img)); err != nil {
+		t.Fatal(err)
+	}
+	imageIndex, err := SignedImageIndex(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotImage, err := imageIndex.SignedImage(v1.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gotImage.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The layers keep their media types and estargz annotations.
+	if diff := cmp.Diff(want.Layers, got.Layers); diff != "" {
+		t.Errorf("layers differ (-want +got): %s", diff)
+	}
+	if _, ok := got.Layers[1].Annotations["containerd.io/snapshot/stargz/toc.digest"]; !ok {
+		t.Error("estargz layer lost its TOC digest annotation")
+	}
+	layers, err := gotImage.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range layers {
+		rc, err := l.Uncompressed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(rc)
+		if _, err := tr.Next(); err != nil {
+			t.Errorf("layer %d: reading uncompressed tar: %v", i, err)
+		}
+		rc.Close()
+	}
+}
+
 func randomSignedImage(t *testing.T) oci.SignedImage {
 	i, err := random.Image(300 /* byteSize */, 7 /* layers */)
 	if err != nil {
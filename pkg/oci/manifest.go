@@ -0,0 +1,66 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/json"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// RawManifester is implemented by v1.Image and v1.ImageIndex.
+type RawManifester interface {
+	RawManifest() ([]byte, error)
+}
+
+// manifestHeader holds the fields shared by image manifests and indexes.
+type manifestHeader struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Subject     *v1.Descriptor    `json:"subject,omitempty"`
+}
+
+func readManifestHeader(m RawManifester) (*manifestHeader, error) {
+	raw, err := m.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	var h manifestHeader
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// ManifestAnnotations returns the annotations of the manifest of m. It lets
+// implementations of SignedEntity and Signatures backed by an image or index
+// implement Annotations.
+func ManifestAnnotations(m RawManifester) (map[string]string, error) {
+	h, err := readManifestHeader(m)
+	if err != nil {
+		return nil, err
+	}
+	return h.Annotations, nil
+}
+
+// ManifestSubject returns the subject of the manifest of m. It lets
+// implementations of SignedEntity and Signatures backed by an image or index
+// implement Subject.
+func ManifestSubject(m RawManifester) (*v1.Descriptor, error) {
+	h, err := readManifestHeader(m)
+	if err != nil {
+		return nil, err
+	}
+	return h.Subject, nil
+}
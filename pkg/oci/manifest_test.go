@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestManifestAnnotationsAndSubject(t *testing.T) {
+	img, err := random.Image(300, 1)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	idx, err := random.Index(300, 1, 1)
+	if err != nil {
+		t.Fatalf("random.Index() = %v", err)
+	}
+	subject := v1.Descriptor{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Size:      42,
+		Digest:    v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	ann := map[string]string{"org.opencontainers.image.created": "2024-01-02T03:04:05Z"}
+
+	for name, m := range map[string]RawManifester{
+		"image": mutate.Subject(mutate.Annotations(img, ann).(v1.Image), subject).(v1.Image),
+		"index": mutate.Subject(mutate.Annotations(idx, ann).(v1.ImageIndex), subject).(v1.ImageIndex),
+	} {
+		t.Run(name, func(t *testing.T) {
+			gotAnn, err := ManifestAnnotations(m)
+			if err != nil {
+				t.Fatalf("ManifestAnnotations() = %v", err)
+			}
+			if diff := cmp.Diff(ann, gotAnn); diff != "" {
+				t.Errorf("ManifestAnnotations() mismatch (-want +got):\n%s", diff)
+			}
+			gotSubject, err := ManifestSubject(m)
+			if err != nil {
+				t.Fatalf("ManifestSubject() = %v", err)
+			}
+			if gotSubject == nil || gotSubject.Digest != subject.Digest || gotSubject.Size != subject.Size {
+				t.Errorf("ManifestSubject() = %v, wanted %v", gotSubject, subject)
+			}
+		})
+	}
+
+	for name, m := range map[string]RawManifester{"image": img, "index": idx} {
+		t.Run(name+" without", func(t *testing.T) {
+			if got, err := ManifestAnnotations(m); err != nil || got != nil {
+				t.Errorf("ManifestAnnotations() = %v, %v, wanted none", got, err)
+			}
+			if got, err := ManifestSubject(m); err != nil || got != nil {
+				t.Errorf("ManifestSubject() = %v, %v, wanted none", got, err)
+			}
+		})
+	}
+}
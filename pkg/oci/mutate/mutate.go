@@ -78,6 +78,16 @@ func (i *indexWrapper) Attestations() (oci.Signatures, error) {
 	return empty.Signatures(), nil
 }
 
+// Annotations implements oci.SignedImageIndex
+func (i *indexWrapper) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(i.v1Index)
+}
+
+// Subject implements oci.SignedImageIndex
+func (i *indexWrapper) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(i.v1Index)
+}
+
 // Attachment implements oci.SignedImage
 func (*indexWrapper) Attachment(name string) (oci.File, error) { //nolint: revive
 	return nil, errors.New("unimplemented")
@@ -362,6 +372,13 @@ func (so *signOpts) dedupeAndReplace(sig oci.Signature, basefn func() (oci.Signa
 	} else if sig == nil {
 		return base, nil
 	}
+	if so.noDupes {
+		if identical, err := hasIdenticalSignature(base, sig); err != nil {
+			return nil, err
+		} else if identical {
+			return base, nil
+		}
+	}
 	if so.dd != nil {
 		if existing, err := so.dd.Find(base, sig); err != nil {
 			return nil, err
@@ -379,3 +396,36 @@ func (so *signOpts) dedupeAndReplace(sig oci.Signature, basefn func() (oci.Signa
 	}
 	return AppendSignatures(base, so.rct, sig)
 }
+
+// hasIdenticalSignature reports whether base already holds a layer with the
+// same payload, media type and signature as sig.
+func hasIdenticalSignature(base oci.Signatures, sig oci.Signature) (bool, error) {
+	digest, err := sig.Digest()
+	if err != nil {
+		return false, err
+	}
+	mt, err := sig.MediaType()
+	if err != nil {
+		return false, err
+	}
+	b64sig, err := sig.Base64Signature()
+	if err != nil {
+		return false, err
+	}
+	sigs, err := base.Get()
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range sigs {
+		if d, err := existing.Digest(); err != nil || d != digest {
+			continue
+		}
+		if m, err := existing.MediaType(); err != nil || m != mt {
+			continue
+		}
+		if s, err := existing.Base64Signature(); err == nil && s == b64sig {
+			return true, nil
+		}
+	}
+	return false, nil
+}
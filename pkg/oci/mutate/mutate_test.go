@@ -425,6 +425,42 @@ func TestSignEntity(t *testing.T) {
 		}
 	})
 
+	t.Run("with no duplicates", func(t *testing.T) {
+		for _, se := range []oci.SignedEntity{si, sii, sunk} {
+			for i := 0; i < 3; i++ {
+				// Identical payload and signature, with an annotation that
+				// differs on every attach.
+				sig, err := static.NewSignature([]byte("payload"), "sig",
+					static.WithAnnotations(map[string]string{"run": fmt.Sprintf("%d", i)}))
+				if err != nil {
+					t.Fatalf("static.NewSignature() = %v", err)
+				}
+				se, err = AttachSignatureToEntity(se, sig, WithNoDuplicates(true))
+				if err != nil {
+					t.Fatalf("AttachSignatureToEntity() = %v", err)
+				}
+			}
+			other, err := static.NewSignature([]byte("payload"), "other")
+			if err != nil {
+				t.Fatalf("static.NewSignature() = %v", err)
+			}
+			se, err = AttachSignatureToEntity(se, other, WithNoDuplicates(true))
+			if err != nil {
+				t.Fatalf("AttachSignatureToEntity() = %v", err)
+			}
+
+			sigs, err := se.Signatures()
+			if err != nil {
+				t.Fatalf("Signatures() = %v", err)
+			}
+			if sl, err := sigs.Get(); err != nil {
+				t.Fatalf("Get() = %v", err)
+			} else if len(sl) != 2 {
+				t.Errorf("len(Get()) = %d, wanted %d", len(sl), 2)
+			}
+		}
+	})
+
 	t.Run("with replace op (attestation)", func(t *testing.T) {
 		for _, se := range []oci.SignedEntity{si, sii, sunk} {
 			orig, err := static.NewAttestation([]byte("blah"))
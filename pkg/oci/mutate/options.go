@@ -33,9 +33,10 @@ type ReplaceOp interface {
 type SignOption func(*signOpts)
 
 type signOpts struct {
-	dd  DupeDetector
-	ro  ReplaceOp
-	rct bool
+	dd      DupeDetector
+	ro      ReplaceOp
+	rct     bool
+	noDupes bool
 }
 
 func makeSignOpts(opts ...SignOption) *signOpts {
@@ -54,6 +55,15 @@ func WithDupeDetector(dd DupeDetector) SignOption {
 	}
 }
 
+// WithNoDuplicates configures Sign* to skip attaching a signature when a
+// layer with an identical payload and signature is already present,
+// regardless of annotations such as the tlog bundle that differ between runs.
+func WithNoDuplicates(noDupes bool) SignOption {
+	return func(so *signOpts) {
+		so.noDupes = noDupes
+	}
+}
+
 func WithReplaceOp(ro ReplaceOp) SignOption {
 	return func(so *signOpts) {
 		so.ro = ro
@@ -0,0 +1,143 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutate
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// ErrSignatureNotFound is returned by RemoveSignature when no signature has
+// the digest to remove.
+var ErrSignatureNotFound = errors.New("no signature with the given digest")
+
+// FilterSignatures produces a new oci.Signatures holding only the signatures
+// of base for which keep returns true, in their original order.
+func FilterSignatures(base oci.Signatures, keep func(oci.Signature) (bool, error)) (oci.Signatures, error) {
+	sigs, err := base.Get()
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]oci.Signature, 0, len(sigs))
+	for _, sig := range sigs {
+		ok, err := keep(sig)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, sig)
+		}
+	}
+	return newSignatures(kept)
+}
+
+// KeepLatestSignatures produces a new oci.Signatures holding only the n
+// signatures most recently appended to base.
+func KeepLatestSignatures(base oci.Signatures, n int) (oci.Signatures, error) {
+	sigs, err := base.Get()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		n = 0
+	}
+	if len(sigs) > n {
+		sigs = sigs[len(sigs)-n:]
+	}
+	return newSignatures(sigs)
+}
+
+// PruneSignaturesBefore produces a new oci.Signatures without the signatures
+// of base that were recorded in the transparency log before cutoff.
+// Signatures without a tlog bundle have no known age and are kept.
+func PruneSignaturesBefore(base oci.Signatures, cutoff time.Time) (oci.Signatures, error) {
+	return FilterSignatures(base, func(sig oci.Signature) (bool, error) {
+		b, err := sig.Bundle()
+		if err != nil {
+			return false, err
+		}
+		if b == nil {
+			return true, nil
+		}
+		return !time.Unix(b.Payload.IntegratedTime, 0).Before(cutoff), nil
+	})
+}
+
+// RemoveSignature produces a new oci.Signatures without the signature of
+// base identified by digest, which is either the digest of its layer or the
+// SHA-256 digest of its raw signature. Signatures of the same payload share a
+// layer digest, so it fails unless exactly one signature matches.
+func RemoveSignature(base oci.Signatures, digest v1.Hash) (oci.Signatures, error) {
+	sigs, err := base.Get()
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]bool, len(sigs))
+	n := 0
+	for i, sig := range sigs {
+		if matches[i], err = signatureHasDigest(sig, digest); err != nil {
+			return nil, err
+		}
+		if matches[i] {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return nil, fmt.Errorf("%w: %s", ErrSignatureNotFound, digest)
+	case n > 1:
+		return nil, fmt.Errorf("%d signatures have the digest %s, use the SHA-256 digest of the raw signature to remove one of them", n, digest)
+	}
+	kept := make([]oci.Signature, 0, len(sigs)-1)
+	for i, sig := range sigs {
+		if !matches[i] {
+			kept = append(kept, sig)
+		}
+	}
+	return newSignatures(kept)
+}
+
+// signatureHasDigest reports whether digest is the digest of the layer of sig
+// or the SHA-256 digest of its raw signature.
+func signatureHasDigest(sig oci.Signature, digest v1.Hash) (bool, error) {
+	d, err := sig.Digest()
+	if err != nil {
+		return false, err
+	}
+	if d == digest {
+		return true, nil
+	}
+	if digest.Algorithm != "sha256" {
+		return false, nil
+	}
+	b64, err := sig.Base64Signature()
+	if err != nil {
+		return false, err
+	}
+	// A signature that is not base64 encoded only matches by layer.
+	if raw, err := base64.StdEncoding.DecodeString(b64); err == nil {
+		h := sha256.Sum256(raw)
+		return hex.EncodeToString(h[:]) == digest.Hex, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutate
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/empty"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// testSignatures returns signatures "s0".."s<n-1>" appended in order, where
+// sN was recorded in the tlog N hours after start.
+func testSignatures(t *testing.T, start time.Time, n int) oci.Signatures {
+	t.Helper()
+	sigs := make([]oci.Signature, 0, n)
+	for i := 0; i < n; i++ {
+		sig, err := static.NewSignature([]byte{}, fmt.Sprintf("s%d", i), static.WithBundle(&bundle.RekorBundle{
+			Payload: bundle.RekorPayload{IntegratedTime: start.Add(time.Duration(i) * time.Hour).Unix()},
+		}))
+		if err != nil {
+			t.Fatalf("NewSignature() = %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	base, err := AppendSignatures(empty.Signatures(), false, sigs...)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	return base
+}
+
+func signatureNames(t *testing.T, sigs oci.Signatures) []string {
+	t.Helper()
+	sl, err := sigs.Get()
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	names := make([]string, 0, len(sl))
+	for _, sig := range sl {
+		b64, err := sig.Base64Signature()
+		if err != nil {
+			t.Fatalf("Base64Signature() = %v", err)
+		}
+		names = append(names, b64)
+	}
+	return names
+}
+
+func TestKeepLatestSignatures(t *testing.T) {
+	base := testSignatures(t, time.Unix(1700000000, 0), 4)
+	for _, tc := range []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "[]"},
+		{n: 2, want: "[s2 s3]"},
+		{n: 10, want: "[s0 s1 s2 s3]"},
+	} {
+		pruned, err := KeepLatestSignatures(base, tc.n)
+		if err != nil {
+			t.Fatalf("KeepLatestSignatures() = %v", err)
+		}
+		names := signatureNames(t, pruned)
+		if got := fmt.Sprint(names); got != tc.want {
+			t.Errorf("KeepLatestSignatures(%d) = %s, wanted %s", tc.n, got, tc.want)
+		}
+		if m, err := pruned.Manifest(); err != nil {
+			t.Fatalf("Manifest() = %v", err)
+		} else if got, want := len(m.Layers), len(names); got != want {
+			t.Errorf("len(Manifest().Layers) = %d, wanted %d", got, want)
+		}
+	}
+}
+
+func TestPruneSignaturesBefore(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	base := testSignatures(t, start, 3)
+	untimed, err := static.NewSignature([]byte{}, "untimed")
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+	base, err = AppendSignatures(base, false, untimed)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+
+	pruned, err := PruneSignaturesBefore(base, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PruneSignaturesBefore() = %v", err)
+	}
+	if got, want := fmt.Sprint(signatureNames(t, pruned)), "[s1 s2 untimed]"; got != want {
+		t.Errorf("PruneSignaturesBefore() = %s, wanted %s", got, want)
+	}
+}
+
+func TestRemoveSignature(t *testing.T) {
+	var sigs []oci.Signature
+	for _, s := range []struct{ payload, sig string }{
+		{"payload", "sig0"},
+		{"payload", "sig1"},
+		{"other payload", "sig2"},
+	} {
+		sig, err := static.NewSignature([]byte(s.payload), base64.StdEncoding.EncodeToString([]byte(s.sig)))
+		if err != nil {
+			t.Fatalf("NewSignature() = %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	base, err := AppendSignatures(empty.Signatures(), false, sigs...)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	digestOf := func(s string) v1.Hash {
+		h := sha256.Sum256([]byte(s))
+		return v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(h[:])}
+	}
+	names := func(sigs oci.Signatures) string {
+		var names []string
+		for _, b64 := range signatureNames(t, sigs) {
+			raw, err := base64.StdEncoding.DecodeString(b64)
+			if err != nil {
+				t.Fatalf("DecodeString() = %v", err)
+			}
+			names = append(names, string(raw))
+		}
+		return fmt.Sprint(names)
+	}
+
+	// Signatures of the same payload share their layer digest.
+	if _, err := RemoveSignature(base, digestOf("payload")); err == nil || !strings.Contains(err.Error(), "2 signatures have the digest") {
+		t.Errorf("RemoveSignature(shared layer digest) = %v, wanted an ambiguity error", err)
+	}
+
+	removed, err := RemoveSignature(base, digestOf("other payload"))
+	if err != nil {
+		t.Fatalf("RemoveSignature(layer digest) = %v", err)
+	}
+	if got, want := names(removed), "[sig0 sig1]"; got != want {
+		t.Errorf("RemoveSignature(layer digest) = %s, wanted %s", got, want)
+	}
+
+	removed, err = RemoveSignature(removed, digestOf("sig1"))
+	if err != nil {
+		t.Fatalf("RemoveSignature(signature digest) = %v", err)
+	}
+	if got, want := names(removed), "[sig0]"; got != want {
+		t.Errorf("RemoveSignature(signature digest) = %s, wanted %s", got, want)
+	}
+
+	if _, err := RemoveSignature(removed, digestOf("sig1")); !errors.Is(err, ErrSignatureNotFound) {
+		t.Errorf("RemoveSignature() = %v, wanted %v", err, ErrSignatureNotFound)
+	}
+}
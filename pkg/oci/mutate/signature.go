@@ -153,7 +153,7 @@ func Signature(original oci.Signature, opts ...SignatureOption) (oci.Signature,
 	if so.annotations != nil {
 		newAnn = copyAnnotations(so.annotations)
 		newAnn[static.SignatureAnnotationKey] = oldAnn[static.SignatureAnnotationKey]
-		for _, key := range []string{static.BundleAnnotationKey, static.CertificateAnnotationKey, static.ChainAnnotationKey, static.RFC3161TimestampAnnotationKey} {
+		for _, key := range []string{static.BundleAnnotationKey, static.CertificateAnnotationKey, static.ChainAnnotationKey, static.RFC3161TimestampAnnotationKey, static.IdentityHashAnnotationKey} {
 			if val, isSet := oldAnn[key]; isSet {
 				newAnn[key] = val
 			} else {
@@ -193,6 +193,11 @@ func Signature(original oci.Signature, opts ...SignatureOption) (oci.Signature,
 		newAnn[static.CertificateAnnotationKey] = string(so.cert)
 		cert = certs[0]
 
+		delete(newAnn, static.IdentityHashAnnotationKey)
+		if hashes := static.IdentityHashes(so.cert); hashes != "" {
+			newAnn[static.IdentityHashAnnotationKey] = hashes
+		}
+
 		delete(newAnn, static.ChainAnnotationKey)
 		if so.chain != nil {
 			chain, err = cryptoutils.LoadCertificatesFromPEM(bytes.NewReader(so.chain))
@@ -16,15 +16,19 @@
 package mutate
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/franchb/cosign/v2/internal/pkg/now"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	ociempty "github.com/franchb/cosign/v2/pkg/oci/empty"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 )
 
-const maxLayers = 1000
-
 // AppendSignatures produces a new oci.Signatures with the provided signatures
 // appended to the provided base signatures.
 func AppendSignatures(base oci.Signatures, recordCreationTimestamp bool, sigs ...oci.Signature) (oci.Signatures, error) {
@@ -44,6 +48,10 @@ func AppendSignatures(base oci.Signatures, recordCreationTimestamp bool, sigs ..
 	if err != nil {
 		return nil, err
 	}
+	img, err = indexManifest(img)
+	if err != nil {
+		return nil, err
+	}
 
 	if recordCreationTimestamp {
 		t, err := now.Now()
@@ -72,6 +80,47 @@ func ReplaceSignatures(base oci.Signatures) (oci.Signatures, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newSignatures(sigs)
+}
+
+// ReplaceSignatureByDigest produces a new oci.Signatures from base in which
+// the signature layer with digest old is swapped for sig, keeping its
+// position. Unlike appending, the signature image does not grow, so an
+// expiring signature can be refreshed in a single write.
+func ReplaceSignatureByDigest(base oci.Signatures, old v1.Hash, sig oci.Signature) (oci.Signatures, error) {
+	sigs, err := base.Get()
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range sigs {
+		d, err := s.Digest()
+		if err != nil {
+			return nil, err
+		}
+		if d == old {
+			replaced := slices.Clone(sigs)
+			replaced[i] = sig
+			return newSignatures(replaced)
+		}
+	}
+	return nil, fmt.Errorf("no signature with digest %s", old)
+}
+
+// ReplaceByDigest returns a ReplaceOp that swaps the signature with digest
+// old for the one being attached, see ReplaceSignatureByDigest.
+func ReplaceByDigest(old v1.Hash) ReplaceOp {
+	return digestReplacer(old)
+}
+
+type digestReplacer v1.Hash
+
+// Replace implements ReplaceOp
+func (r digestReplacer) Replace(base oci.Signatures, sig oci.Signature) (oci.Signatures, error) {
+	return ReplaceSignatureByDigest(base, v1.Hash(r), sig)
+}
+
+// newSignatures produces an oci.Signatures holding exactly sigs, in order.
+func newSignatures(sigs []oci.Signature) (oci.Signatures, error) {
 	adds := make([]mutate.Addendum, 0, len(sigs))
 	for _, sig := range sigs {
 		ann, err := sig.Annotations()
@@ -87,13 +136,49 @@ func ReplaceSignatures(base oci.Signatures) (oci.Signatures, error) {
 	if err != nil {
 		return nil, err
 	}
+	img, err = indexManifest(img)
+	if err != nil {
+		return nil, err
+	}
 	return &sigAppender{
 		Image: img,
-		base:  base,
-		sigs:  []oci.Signature{},
+		base:  ociempty.Signatures(),
+		sigs:  sigs,
 	}, nil
 }
 
+// indexManifest annotates the signatures manifest with the identity hashes and
+// predicate types indexed on its layers, so that manifests listed without
+// being fetched, e.g. through the OCI referrers API, can be filtered too.
+func indexManifest(img v1.Image) (v1.Image, error) {
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	ann := map[string]string{}
+	for _, key := range []string{static.IdentityHashAnnotationKey, static.PredicateTypeAnnotationKey} {
+		var values []string
+		for _, desc := range m.Layers {
+			v, ok := desc.Annotations[key]
+			if !ok {
+				continue
+			}
+			for _, value := range strings.Split(v, ",") {
+				if !slices.Contains(values, value) {
+					values = append(values, value)
+				}
+			}
+		}
+		if len(values) > 0 {
+			ann[key] = strings.Join(values, ",")
+		}
+	}
+	if len(ann) == 0 {
+		return img, nil
+	}
+	return mutate.Annotations(img, ann).(v1.Image), nil
+}
+
 type sigAppender struct {
 	v1.Image
 	base oci.Signatures
@@ -109,8 +194,18 @@ func (sa *sigAppender) Get() ([]oci.Signature, error) {
 		return nil, err
 	}
 	sumLayers := int64(len(sl) + len(sa.sigs))
-	if sumLayers > maxLayers {
+	if maxLayers := oci.MaxLayers(); sumLayers > maxLayers {
 		return nil, oci.NewMaxLayersExceeded(sumLayers, maxLayers)
 	}
 	return append(sl, sa.sigs...), nil
 }
+
+// Annotations implements oci.Signatures
+func (sa *sigAppender) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(sa.Image)
+}
+
+// Subject implements oci.Signatures
+func (sa *sigAppender) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(sa.Image)
+}
@@ -87,6 +87,60 @@ func TestAppendSignatures(t *testing.T) {
 	}
 }
 
+func TestAppendSignaturesIndexesManifest(t *testing.T) {
+	att := func(predicateType string) oci.Signature {
+		t.Helper()
+		s, err := static.NewAttestation([]byte("{}"), static.WithAnnotations(map[string]string{
+			static.PredicateTypeAnnotationKey: predicateType,
+		}))
+		if err != nil {
+			t.Fatalf("NewAttestation() = %v", err)
+		}
+		return s
+	}
+	plain, err := static.NewSignature([]byte{}, "s1")
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+
+	sigs, err := AppendSignatures(empty.Signatures(), false, plain)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	if m, err := sigs.Manifest(); err != nil {
+		t.Fatalf("Manifest() = %v", err)
+	} else if len(m.Annotations) != 0 {
+		t.Errorf("Manifest().Annotations = %v, wanted none for unindexed layers", m.Annotations)
+	}
+
+	sigs, err = AppendSignatures(sigs, false, att("https://slsa.dev/provenance/v1"), att("https://spdx.dev/Document"), att("https://slsa.dev/provenance/v1"))
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	m, err := sigs.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest() = %v", err)
+	}
+	if got, want := m.Annotations[static.PredicateTypeAnnotationKey], "https://slsa.dev/provenance/v1,https://spdx.dev/Document"; got != want {
+		t.Errorf("Manifest().Annotations[%q] = %q, wanted %q", static.PredicateTypeAnnotationKey, got, want)
+	}
+
+	// The signatures returned by Get carry the same annotations as their layers.
+	sl, err := sigs.Get()
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	for i, sig := range sl {
+		ann, err := sig.Annotations()
+		if err != nil {
+			t.Fatalf("Annotations() = %v", err)
+		}
+		if got, want := ann[static.PredicateTypeAnnotationKey], m.Layers[i].Annotations[static.PredicateTypeAnnotationKey]; got != want {
+			t.Errorf("Get()[%d] predicate type = %q, layer has %q", i, got, want)
+		}
+	}
+}
+
 func TestGet(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -146,3 +200,73 @@ type mockOCISignatures struct {
 func (m *mockOCISignatures) Get() ([]oci.Signature, error) {
 	return m.signatures, nil
 }
+
+func (m *mockOCISignatures) Annotations() (map[string]string, error) {
+	return nil, nil
+}
+
+func (m *mockOCISignatures) Subject() (*v1.Descriptor, error) {
+	return nil, nil
+}
+
+func TestReplaceSignatureByDigest(t *testing.T) {
+	s1, err := static.NewSignature([]byte("s1 payload"), "s1")
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+	s2, err := static.NewSignature([]byte("s2 payload"), "s2")
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+	fresh, err := static.NewSignature([]byte("fresh payload"), "fresh")
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+
+	base, err := AppendSignatures(empty.Signatures(), false, s1, s2)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	old, err := s1.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+
+	replaced, err := ReplaceSignatureByDigest(base, old, fresh)
+	if err != nil {
+		t.Fatalf("ReplaceSignatureByDigest() = %v", err)
+	}
+	sl, err := replaced.Get()
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if got, want := len(sl), 2; got != want {
+		t.Fatalf("len(Get()) = %d, wanted %d", got, want)
+	}
+	for i, want := range []string{"fresh", "s2"} {
+		if got, err := sl[i].Base64Signature(); err != nil {
+			t.Fatalf("Base64Signature() = %v", err)
+		} else if got != want {
+			t.Errorf("Get()[%d] = %q, wanted %q", i, got, want)
+		}
+	}
+	if m, err := replaced.Manifest(); err != nil {
+		t.Fatalf("Manifest() = %v", err)
+	} else if got, want := len(m.Layers), 2; got != want {
+		t.Errorf("len(Manifest().Layers) = %d, wanted %d", got, want)
+	}
+
+	if _, err := ReplaceSignatureByDigest(replaced, old, fresh); err == nil {
+		t.Error("ReplaceSignatureByDigest() succeeded for a digest that is not present")
+	}
+
+	viaOp, err := ReplaceByDigest(old).Replace(base, fresh)
+	if err != nil {
+		t.Fatalf("Replace() = %v", err)
+	}
+	if sl, err := viaOp.Get(); err != nil {
+		t.Fatalf("Get() = %v", err)
+	} else if got, want := len(sl), 2; got != want {
+		t.Errorf("len(Get()) = %d, wanted %d", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Match selects the requests to a Registry a Fault applies to.
+type Match func(*http.Request) bool
+
+var (
+	blobPath      = regexp.MustCompile(`^/v2/.+/blobs/[a-z0-9]+:[a-f0-9]+$`)
+	uploadPath    = regexp.MustCompile(`^/v2/.+/blobs/uploads/`)
+	manifestPath  = regexp.MustCompile(`^/v2/.+/manifests/[^/]+$`)
+	referrersPath = regexp.MustCompile(`^/v2/.+/referrers/[a-z0-9]+:[a-f0-9]+$`)
+)
+
+// AnyRequest matches every request.
+func AnyRequest(*http.Request) bool {
+	return true
+}
+
+// Blobs matches the requests reading or deleting blobs, such as layers.
+func Blobs(r *http.Request) bool {
+	return blobPath.MatchString(r.URL.Path)
+}
+
+// Uploads matches the requests uploading blobs.
+func Uploads(r *http.Request) bool {
+	return uploadPath.MatchString(r.URL.Path)
+}
+
+// Manifests matches the requests reading, writing or deleting manifests,
+// whether by tag or by digest.
+func Manifests(r *http.Request) bool {
+	return manifestPath.MatchString(r.URL.Path)
+}
+
+// Referrers matches the requests to the OCI 1.1 referrers API.
+func Referrers(r *http.Request) bool {
+	return referrersPath.MatchString(r.URL.Path)
+}
+
+// Method matches the requests of match made with method, such as
+// Method(http.MethodGet, Blobs).
+func Method(method string, match Match) Match {
+	return func(r *http.Request) bool {
+		return r.Method == method && match(r)
+	}
+}
+
+// Fault answers a request to a Registry in place of the registry, which it
+// may still pass the request on to as next.
+type Fault func(w http.ResponseWriter, r *http.Request, next http.Handler)
+
+// Status answers with an error of the registry API with status code.
+func Status(code int) Fault {
+	return func(w http.ResponseWriter, _ *http.Request, _ http.Handler) {
+		writeError(w, code, "UNKNOWN", http.StatusText(code))
+	}
+}
+
+// TooManyRequests answers with the 429 registries rate limit clients with,
+// asking them to retry after retryAfter, to the second.
+func TooManyRequests(retryAfter time.Duration) Fault {
+	return func(w http.ResponseWriter, _ *http.Request, _ http.Handler) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		writeError(w, http.StatusTooManyRequests, "TOOMANYREQUESTS", "rate limit exceeded")
+	}
+}
+
+// Truncated serves the response of the registry, but drops the connection
+// after half of its body, as happens when a download is interrupted.
+func Truncated() Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		rec := serve(r, next)
+		body := rec.Body.Bytes()
+		copyHeader(w, rec)
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(body[:len(body)/2])
+		// Send what was written before the connection is dropped.
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// WrongDigest serves the response of the registry with a byte of its body
+// changed, so that its content no longer matches its digest, as when a blob
+// is corrupted in storage or tampered with.
+func WrongDigest() Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		rec := serve(r, next)
+		body := rec.Body.Bytes()
+		if len(body) > 0 {
+			body[len(body)-1] ^= 0xff
+		}
+		copyHeader(w, rec)
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(body)
+	}
+}
+
+func serve(r *http.Request, next http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, r)
+	return rec
+}
+
+func copyHeader(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+}
+
+// writeError writes an error response of the registry API, see
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#error-codes
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"errors":[{"code":%q,"message":%q}]}`, code, message)
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ocitest provides an in-memory OCI registry to test signing and
+// verification flows against, which can be made to fail the ways real
+// registries do.
+package ocitest
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+// Registry is an in-memory OCI registry served over HTTP until the end of
+// the test that created it.
+type Registry struct {
+	t      testing.TB
+	server *httptest.Server
+
+	mu       sync.Mutex
+	faults   []*injected
+	requests []*http.Request
+}
+
+// Option is a functional option for New.
+type Option func(*options)
+
+type options struct {
+	Referrers bool
+	Logger    *log.Logger
+}
+
+// WithoutReferrers is a functional option for serving the registry without
+// the OCI 1.1 referrers API, so that clients fall back to the referrers tag
+// schema.
+func WithoutReferrers() Option {
+	return func(o *options) {
+		o.Referrers = false
+	}
+}
+
+// WithLogger is a functional option for logging the requests the registry
+// serves to l, which discards them by default.
+func WithLogger(l *log.Logger) Option {
+	return func(o *options) {
+		o.Logger = l
+	}
+}
+
+// New starts a Registry, which is shut down when t and its subtests finish.
+func New(t testing.TB, opts ...Option) *Registry {
+	t.Helper()
+	o := &options{
+		Referrers: true,
+		Logger:    log.New(io.Discard, "", 0),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	r := &Registry{t: t}
+	next := registry.New(registry.Logger(o.Logger), registry.WithReferrersSupport(o.Referrers))
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if fault := r.record(req); fault != nil {
+			fault(w, req, next)
+			return
+		}
+		next.ServeHTTP(w, req)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// Host is the host and port the registry is served on, with which
+// references to its repositories start.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+// Repository returns the repository called repo of the registry, failing
+// the test if repo is not a valid repository name.
+func (r *Registry) Repository(repo string) name.Repository {
+	r.t.Helper()
+	ref, err := name.NewRepository(r.Host() + "/" + repo)
+	if err != nil {
+		r.t.Fatalf("NewRepository(%q) = %v", repo, err)
+	}
+	return ref
+}
+
+// Close shuts the registry down before the end of the test, so that
+// requests to it fail as if it were unreachable.
+func (r *Registry) Close() {
+	r.server.Close()
+}
+
+// Inject answers the next n requests that match with fault rather than
+// serving them, or all of them if n is negative. Faults are applied in the
+// order they were injected, the first one matching a request winning.
+func (r *Registry) Inject(match Match, n int, fault Fault) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.faults = append(r.faults, &injected{match: match, left: n, fault: fault})
+}
+
+// Requests returns how many of the requests the registry received so far
+// match, including those answered with a fault.
+func (r *Registry) Requests(match Match) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, req := range r.requests {
+		if match(req) {
+			n++
+		}
+	}
+	return n
+}
+
+// injected is a fault injected into a Registry.
+type injected struct {
+	match Match
+	// left is how many more requests to answer with fault, or negative for
+	// all of them.
+	left  int
+	fault Fault
+}
+
+// record records req, returning the fault to answer it with, if any.
+func (r *Registry) record(req *http.Request) Fault {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	for _, f := range r.faults {
+		if f.left == 0 || !f.match(req) {
+			continue
+		}
+		if f.left > 0 {
+			f.left--
+		}
+		return f.fault
+	}
+	return nil
+}
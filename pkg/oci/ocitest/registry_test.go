@@ -0,0 +1,139 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocitest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New(t)
+	ref := r.Repository("repo").Tag("latest")
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := layers[0].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := ref.Context().Digest(h.String())
+	readBlob := func() error {
+		l, err := remote.Layer(blob)
+		if err != nil {
+			return err
+		}
+		rc, err := l.Compressed()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.ReadAll(rc)
+		if err == nil {
+			err = rc.Close()
+		}
+		return err
+	}
+	if err := readBlob(); err != nil {
+		t.Fatalf("reading blob = %v", err)
+	}
+	if n := r.Requests(Uploads); n == 0 {
+		t.Error("Requests(Uploads) = 0, wanted the uploads of Write()")
+	}
+
+	tests := []struct {
+		name  string
+		match Match
+		fault Fault
+		read  func() error
+		check func(error) bool
+	}{{
+		name:  "too many requests",
+		match: Manifests,
+		fault: TooManyRequests(time.Second),
+		read: func() error {
+			_, err := remote.Head(ref)
+			return err
+		},
+		check: func(err error) bool {
+			var terr *transport.Error
+			return errors.As(err, &terr) && terr.StatusCode == http.StatusTooManyRequests
+		},
+	}, {
+		name:  "status",
+		match: Manifests,
+		fault: Status(http.StatusForbidden),
+		read: func() error {
+			_, err := remote.Get(ref)
+			return err
+		},
+		check: func(err error) bool {
+			var terr *transport.Error
+			return errors.As(err, &terr) && terr.StatusCode == http.StatusForbidden
+		},
+	}, {
+		name:  "truncated",
+		match: Method(http.MethodGet, Blobs),
+		fault: Truncated(),
+		read:  readBlob,
+		check: func(err error) bool { return err != nil },
+	}, {
+		name:  "wrong digest",
+		match: Method(http.MethodGet, Blobs),
+		fault: WrongDigest(),
+		read:  readBlob,
+		check: func(err error) bool { return err != nil },
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := r.Requests(tc.match)
+			r.Inject(tc.match, 1, tc.fault)
+			if err := tc.read(); !tc.check(err) {
+				t.Errorf("with fault = %v", err)
+			}
+			if r.Requests(tc.match) == before {
+				t.Error("the fault was injected without a matching request")
+			}
+			// The fault was only injected once.
+			if err := tc.read(); err != nil {
+				t.Errorf("after fault = %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistryClose(t *testing.T) {
+	r := New(t)
+	ref := r.Repository("repo").Tag("latest")
+	r.Close()
+	if _, err := remote.Head(ref); err == nil {
+		t.Error("Head() succeeded once the registry was closed")
+	}
+}
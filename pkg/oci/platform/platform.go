@@ -19,7 +19,9 @@ import (
 	"strings"
 
 	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 )
 
 type List []struct {
@@ -94,40 +96,129 @@ func SignedEntityForPlatform(se oci.SignedEntity, platform string) (oci.SignedEn
 		// Copy all platforms
 		return se, nil
 	}
+	h, err := DigestForPlatform(se, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	nse, err := se.(oci.SignedImageIndex).SignedImage(h)
+	if err != nil {
+		return nil, fmt.Errorf("searching for %s image: %w", h.String(), err)
+	}
+	if nse == nil {
+		return nil, fmt.Errorf("unable to find image %s", h.String())
+	}
+
+	return nse, nil
+}
+
+// DigestForPlatform returns the digest of the single image within the
+// multiarch index se that matches platform, such as "linux/arm64".
+func DigestForPlatform(se oci.SignedEntity, platform string) (v1.Hash, error) {
 	idx, isIndex := se.(oci.SignedImageIndex)
 
 	// We only allow --platform on multiarch indexes
 	if !isIndex {
-		return nil, fmt.Errorf("specified reference is not a multiarch image")
+		return v1.Hash{}, fmt.Errorf("specified reference is not a multiarch image")
 	}
 
 	targetPlatform, err := v1.ParsePlatform(platform)
 	if err != nil {
-		return nil, fmt.Errorf("parsing platform: %w", err)
+		return v1.Hash{}, fmt.Errorf("parsing platform: %w", err)
 	}
 	platforms, err := GetIndexPlatforms(idx)
 	if err != nil {
-		return nil, fmt.Errorf("getting available platforms: %w", err)
+		return v1.Hash{}, fmt.Errorf("getting available platforms: %w", err)
 	}
 
 	platforms = matchPlatform(targetPlatform, platforms)
 	if len(platforms) == 0 {
-		return nil, fmt.Errorf("unable to find an entity for %s", targetPlatform.String())
+		return v1.Hash{}, fmt.Errorf("unable to find an entity for %s", targetPlatform.String())
 	}
 	if len(platforms) > 1 {
-		return nil, fmt.Errorf(
+		return v1.Hash{}, fmt.Errorf(
 			"platform spec matches more than one image architecture: %s",
 			platforms.String(),
 		)
 	}
+	return platforms[0].Hash, nil
+}
 
-	nse, err := idx.SignedImage(platforms[0].Hash)
+// referenceDigestAnnotation is how the buildkit attestation manifests of an
+// index name the image they are about.
+const referenceDigestAnnotation = "vnd.docker.reference.digest"
+
+// FilterSignedImageIndex returns the multiarch index se with only the images
+// matching one of platforms, a comma separated list such as
+// "linux/amd64,linux/arm64", in the order se lists them. Manifests that are
+// about the images kept, such as their buildkit attestations, are kept too.
+//
+// The images keep their signatures and attestations, but the index returned
+// has a digest of its own, which has none.
+func FilterSignedImageIndex(se oci.SignedEntity, platforms string) (oci.SignedImageIndex, error) {
+	idx, isIndex := se.(oci.SignedImageIndex)
+	if !isIndex {
+		return nil, fmt.Errorf("specified reference is not a multiarch image")
+	}
+	var targets []*v1.Platform
+	for _, p := range strings.Split(platforms, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return nil, fmt.Errorf("empty platform in %q", platforms)
+		}
+		target, err := v1.ParsePlatform(p)
+		if err != nil {
+			return nil, fmt.Errorf("parsing platform: %w", err)
+		}
+		targets = append(targets, target)
+	}
+	available, err := GetIndexPlatforms(idx)
 	if err != nil {
-		return nil, fmt.Errorf("searching for %s image: %w", platforms[0].Hash.String(), err)
+		return nil, fmt.Errorf("getting available platforms: %w", err)
 	}
-	if nse == nil {
-		return nil, fmt.Errorf("unable to find image %s", platforms[0].Hash.String())
+
+	keep := map[v1.Hash]bool{}
+	for _, target := range targets {
+		for _, p := range matchPlatform(target, available) {
+			keep[p.Hash] = true
+		}
+	}
+	if len(keep) == 0 {
+		return nil, fmt.Errorf("unable to find an entity for %s", platforms)
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("fetching index manifest: %w", err)
+	}
+	for _, m := range im.Manifests {
+		if h, err := v1.NewHash(m.Annotations[referenceDigestAnnotation]); err == nil && keep[h] {
+			keep[m.Digest] = true
+		}
 	}
 
-	return nse, nil
+	filtered := mutate.RemoveManifests(idx, func(desc v1.Descriptor) bool {
+		return !keep[desc.Digest]
+	})
+	return &filteredIndex{signedImageIndex: signed.ImageIndex(filtered), src: idx}, nil
+}
+
+// signedImageIndex names oci.SignedImageIndex so that it can be embedded
+// without the field hiding its SignedImageIndex method.
+type signedImageIndex = oci.SignedImageIndex
+
+// filteredIndex is an image index holding some of the images of src, whose
+// signatures and attestations are read from src.
+type filteredIndex struct {
+	signedImageIndex
+	src oci.SignedImageIndex
+}
+
+// SignedImage implements oci.SignedImageIndex
+func (f *filteredIndex) SignedImage(h v1.Hash) (oci.SignedImage, error) {
+	return f.src.SignedImage(h)
+}
+
+// SignedImageIndex implements oci.SignedImageIndex
+func (f *filteredIndex) SignedImageIndex(h v1.Hash) (oci.SignedImageIndex, error) {
+	return f.src.SignedImageIndex(h)
 }
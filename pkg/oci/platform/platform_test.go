@@ -0,0 +1,159 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
+)
+
+func TestDigestForPlatform(t *testing.T) {
+	var ii v1.ImageIndex = empty.Index
+	want := map[string]v1.Hash{}
+	for _, p := range []string{"linux/amd64", "linux/arm64", "linux/arm/v7", "linux/arm/v6"} {
+		img, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		h, err := img.Digest()
+		if err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		plat, err := v1.ParsePlatform(p)
+		if err != nil {
+			t.Fatalf("ParsePlatform() = %v", err)
+		}
+		ii = mutate.AppendManifests(ii, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: plat},
+		})
+		want[p] = h
+	}
+	sii := signed.ImageIndex(ii)
+
+	for _, p := range []string{"linux/amd64", "linux/arm64", "linux/arm/v7"} {
+		got, err := DigestForPlatform(sii, p)
+		if err != nil {
+			t.Fatalf("DigestForPlatform(%s) = %v", p, err)
+		}
+		if got != want[p] {
+			t.Errorf("DigestForPlatform(%s) = %s, wanted %s", p, got, want[p])
+		}
+	}
+
+	for _, p := range []string{"linux/arm", "windows/amd64", "linux/"} {
+		if got, err := DigestForPlatform(sii, p); err == nil {
+			t.Errorf("DigestForPlatform(%s) = %s, wanted an error", p, got)
+		}
+	}
+
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	if _, err := DigestForPlatform(signed.Image(img), "linux/amd64"); err == nil {
+		t.Error("DigestForPlatform() succeeded for an image that is not an index")
+	}
+}
+
+func TestFilterSignedImageIndex(t *testing.T) {
+	var ii v1.ImageIndex = empty.Index
+	digests := map[string]v1.Hash{}
+	for _, p := range []string{"linux/amd64", "linux/arm64", "linux/arm/v7", "linux/arm/v6", "unknown/unknown"} {
+		img, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		h, err := img.Digest()
+		if err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		plat, err := v1.ParsePlatform(p)
+		if err != nil {
+			t.Fatalf("ParsePlatform() = %v", err)
+		}
+		desc := v1.Descriptor{Platform: plat}
+		if p == "unknown/unknown" {
+			// The buildkit attestations of the linux/arm64 image.
+			desc.Annotations = map[string]string{referenceDigestAnnotation: digests["linux/arm64"].String()}
+		}
+		ii = mutate.AppendManifests(ii, mutate.IndexAddendum{Add: img, Descriptor: desc})
+		digests[p] = h
+	}
+	sii := signed.ImageIndex(ii)
+
+	tests := []struct {
+		platforms string
+		want      []string
+	}{{
+		platforms: "linux/amd64",
+		want:      []string{"linux/amd64"},
+	}, {
+		platforms: "linux/arm/v7,linux/arm64",
+		want:      []string{"linux/arm64", "linux/arm/v7", "unknown/unknown"},
+	}, {
+		platforms: "linux/arm",
+		want:      []string{"linux/arm/v7", "linux/arm/v6"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.platforms, func(t *testing.T) {
+			filtered, err := FilterSignedImageIndex(sii, tc.platforms)
+			if err != nil {
+				t.Fatalf("FilterSignedImageIndex() = %v", err)
+			}
+			im, err := filtered.IndexManifest()
+			if err != nil {
+				t.Fatalf("IndexManifest() = %v", err)
+			}
+			if len(im.Manifests) != len(tc.want) {
+				t.Fatalf("FilterSignedImageIndex() kept %d manifests, wanted %v", len(im.Manifests), tc.want)
+			}
+			for i, p := range tc.want {
+				if im.Manifests[i].Digest != digests[p] {
+					t.Errorf("manifest %d = %s, wanted that of %s", i, im.Manifests[i].Digest, p)
+				}
+				if _, err := filtered.SignedImage(digests[p]); err != nil {
+					t.Errorf("SignedImage(%s) = %v", p, err)
+				}
+			}
+			sigs, err := filtered.Signatures()
+			if err != nil {
+				t.Fatalf("Signatures() = %v", err)
+			}
+			if got, err := sigs.Get(); err != nil || len(got) != 0 {
+				t.Errorf("Signatures() = %v, %v, wanted none", got, err)
+			}
+		})
+	}
+
+	for _, p := range []string{"windows/amd64", "linux/amd64,"} {
+		if _, err := FilterSignedImageIndex(sii, p); err == nil {
+			t.Errorf("FilterSignedImageIndex(%s) succeeded, wanted an error", p)
+		}
+	}
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	if _, err := FilterSignedImageIndex(signed.Image(img), "linux/amd64"); err == nil {
+		t.Error("FilterSignedImageIndex() succeeded for an image that is not an index")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	payloadsize "github.com/franchb/cosign/v2/internal/pkg/cosign/payload/size"
+	"github.com/franchb/cosign/v2/pkg/oci/cache"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// WithCache is a functional option for reading signatures and attestations
+// through c, which serves them from disk until they expire. It is meant for
+// read-only paths such as verification, since signatures written meanwhile
+// are not seen until the cached copy expires.
+func WithCache(c *cache.Cache) Option {
+	return func(o *options) {
+		o.Cache = c
+	}
+}
+
+// cachedSignaturesImage reads the signatures image ref from the configured
+// cache, or from the registry, storing it in the cache.
+func (o *options) cachedSignaturesImage(ref name.Reference) (v1.Image, error) {
+	if o.Cache == nil {
+		return o.signaturesImage(ref)
+	}
+	if img, ok := o.Cache.Get(ref); ok {
+		return img, nil
+	}
+	img, err := o.signaturesImage(ref)
+	if err != nil {
+		return nil, err
+	}
+	// Storing the image downloads all of its layers, so leave images that
+	// reading signatures would reject to fail as they would uncached.
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(m.Layers)) > o.MaxLayers {
+		return img, nil
+	}
+	for _, desc := range m.Layers {
+		if payloadsize.CheckSize(uint64(desc.Size)) != nil {
+			return img, nil
+		}
+	}
+	return o.Cache.Put(ref, img)
+}
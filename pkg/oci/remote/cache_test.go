@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/oci/cache"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestSignaturesCache(t *testing.T) {
+	ri := remoteImage
+	t.Cleanup(func() {
+		remoteImage = ri
+	})
+
+	img, err := random.Image(100, 2)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	fetches := 0
+	remoteImage = func(_ name.Reference, _ ...remote.Option) (v1.Image, error) {
+		fetches++
+		return img, nil
+	}
+
+	c, err := cache.New(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("cache.New() = %v", err)
+	}
+	ref := name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.sig")
+	want, _ := img.Digest()
+	for i := 0; i < 2; i++ {
+		sigs, err := Signatures(ref, WithCache(c))
+		if err != nil {
+			t.Fatalf("Signatures() = %v", err)
+		}
+		if got, err := sigs.Digest(); err != nil || got != want {
+			t.Errorf("Digest() = %v, %v, wanted %v", got, err, want)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched the signatures %d times, wanted once", fetches)
+	}
+
+	// Images with more layers than allowed are not cached.
+	fetches = 0
+	other := name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.att")
+	for i := 0; i < 2; i++ {
+		if _, err := Signatures(other, WithCache(c), WithMaxLayers(1)); err != nil {
+			t.Fatalf("Signatures() = %v", err)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("fetched the signatures %d times, wanted twice", fetches)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/walk"
+)
+
+// Artifact is a kind of artifact copied along with an image, see CopyTasks.
+type Artifact string
+
+const (
+	// ArtifactImage is the image or image index itself.
+	ArtifactImage Artifact = "image"
+	// ArtifactSignature is the signatures of the image.
+	ArtifactSignature Artifact = "sig"
+	// ArtifactAttestation is the attestations of the image.
+	ArtifactAttestation Artifact = "att"
+	// ArtifactSBOM is the SBOM attached to the image.
+	ArtifactSBOM Artifact = "sbom"
+)
+
+// Artifacts are all the kinds of Artifact.
+var Artifacts = []Artifact{ArtifactSignature, ArtifactAttestation, ArtifactSBOM, ArtifactImage}
+
+// ParseArtifacts parses a comma separated list of Artifacts, such as
+// "sig,att".
+func ParseArtifacts(s string) ([]Artifact, error) {
+	var artifacts []Artifact
+	for _, a := range strings.Split(s, ",") {
+		artifact := Artifact(strings.TrimSpace(a))
+		if !slices.Contains(Artifacts, artifact) {
+			return nil, fmt.Errorf("unknown artifact %q, expected one of sig, att, sbom or image", a)
+		}
+		if !slices.Contains(artifacts, artifact) {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts, nil
+}
+
+// CopyTask is the copy of an artifact of an image or image index to another
+// repository.
+type CopyTask struct {
+	Artifact Artifact
+	// Subject is the image or image index the artifact is of.
+	Subject name.Digest
+	// Src is where the artifact is copied from, which may not exist.
+	Src name.Reference
+	// Dst is the tag the artifact is copied to.
+	Dst name.Tag
+}
+
+// CopyTasks calls fn with the copies of artifacts, of se stored in src and
+// of each image and image index within it, to dst. Indexes come before their
+// children, and the artifacts of each in the order of artifacts.
+//
+// Signatures, attestations and SBOMs are copied from where they are stored
+// for src, see WithTargetRepository, and images to tags named after their
+// digest, so that the images of an index are kept in dst.
+func CopyTasks(ctx context.Context, se oci.SignedEntity, src, dst name.Repository, artifacts []Artifact, fn func(CopyTask) error, opts ...Option) error {
+	o := makeOptions(src, opts...)
+	return walk.SignedEntity(ctx, se, func(_ context.Context, se oci.SignedEntity) error {
+		h, err := se.Digest()
+		if err != nil {
+			return err
+		}
+		subject := src.Digest(h.String())
+		for _, a := range artifacts {
+			t := CopyTask{Artifact: a, Subject: subject}
+			if a == ArtifactImage {
+				t.Src, t.Dst = subject, dst.Tag(normalize(h, o.TagPrefix, ""))
+			} else {
+				tag, err := artifactTag(subject, a, o)
+				if err != nil {
+					return err
+				}
+				t.Src, t.Dst = tag, dst.Tag(tag.TagStr())
+			}
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// artifactTag returns the tag the signatures, attestations or SBOM of
+// subject are stored under.
+func artifactTag(subject name.Digest, a Artifact, o *options) (name.Tag, error) {
+	switch a {
+	case ArtifactSignature:
+		return suffixTag(subject, o.SignatureSuffix, "-", o)
+	case ArtifactAttestation:
+		return suffixTag(subject, o.AttestationSuffix, "-", o)
+	case ArtifactSBOM:
+		return suffixTag(subject, o.SBOMSuffix, "-", o)
+	default:
+		return name.Tag{}, fmt.Errorf("unknown artifact %q", a)
+	}
+}
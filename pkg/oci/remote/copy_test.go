@@ -0,0 +1,88 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+
+	"github.com/franchb/cosign/v2/pkg/oci/signed"
+)
+
+func TestParseArtifacts(t *testing.T) {
+	got, err := ParseArtifacts("att, sig,att")
+	if err != nil {
+		t.Fatalf("ParseArtifacts() = %v", err)
+	}
+	if want := []Artifact{ArtifactAttestation, ArtifactSignature}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ParseArtifacts() = %v, wanted %v", got, want)
+	}
+	for _, s := range []string{"", "sign", "sig,"} {
+		if _, err := ParseArtifacts(s); err == nil {
+			t.Errorf("ParseArtifacts(%q) succeeded, wanted an error", s)
+		}
+	}
+}
+
+func TestCopyTasks(t *testing.T) {
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	idx := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: img})
+	imgDigest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxDigest, err := idx.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := name.MustParseReference("example.com/src").Context()
+	sigs := name.MustParseReference("example.com/sigs").Context()
+	dst := name.MustParseReference("mirror.example.com/dst").Context()
+
+	var got []string
+	err = CopyTasks(context.Background(), signed.ImageIndex(idx), src, dst, []Artifact{ArtifactAttestation, ArtifactImage}, func(t CopyTask) error {
+		got = append(got, string(t.Artifact)+" "+t.Subject.DigestStr()+" "+t.Src.String()+" -> "+t.Dst.String())
+		return nil
+	}, WithTargetRepository(sigs), WithPrefix("p-"))
+	if err != nil {
+		t.Fatalf("CopyTasks() = %v", err)
+	}
+	tag := func(h v1.Hash, suffix string) string { return normalize(h, "p-", suffix) }
+	want := []string{
+		"att " + idxDigest.String() + " " + sigs.Tag(tag(idxDigest, "att")).String() + " -> " + dst.Tag(tag(idxDigest, "att")).String(),
+		"image " + idxDigest.String() + " " + src.Digest(idxDigest.String()).String() + " -> " + dst.Tag(tag(idxDigest, "")).String(),
+		"att " + imgDigest.String() + " " + sigs.Tag(tag(imgDigest, "att")).String() + " -> " + dst.Tag(tag(imgDigest, "att")).String(),
+		"image " + imgDigest.String() + " " + src.Digest(imgDigest.String()).String() + " -> " + dst.Tag(tag(imgDigest, "")).String(),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CopyTasks() =\n%s\nwanted\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	stop := errors.New("stop")
+	if err := CopyTasks(context.Background(), signed.Image(img), src, dst, Artifacts, func(CopyTask) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("CopyTasks() = %v, wanted the error of fn", err)
+	}
+}
@@ -15,6 +15,9 @@
 package remote
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/go-containerregistry/pkg/name"
 )
 
@@ -27,9 +30,58 @@ func ResolveDigest(ref name.Reference, opts ...Option) (name.Digest, error) {
 	if d, ok := ref.(name.Digest); ok {
 		return d, nil
 	}
-	desc, err := remoteGet(ref, o.ROpt...)
+	desc, err := o.get(ref)
 	if err != nil {
 		return name.Digest{}, err
 	}
 	return ref.Context().Digest(desc.Digest.String()), nil
 }
+
+// TagAndDigest splits a reference of the form repo:tag@sha256:..., which
+// name.ParseReference reduces to its digest, into its tag and digest. ok is
+// false unless ref names both.
+func TagAndDigest(ref string, opts ...name.Option) (tag name.Tag, digest name.Digest, ok bool) {
+	base, _, found := strings.Cut(ref, "@")
+	if !found {
+		return name.Tag{}, name.Digest{}, false
+	}
+	digest, err := name.NewDigest(ref, opts...)
+	if err != nil {
+		return name.Tag{}, name.Digest{}, false
+	}
+	// name.NewTag defaults to "latest", so only accept tags spelled out.
+	if i := strings.LastIndex(base, ":"); i < 0 || strings.Contains(base[i:], "/") {
+		return name.Tag{}, name.Digest{}, false
+	}
+	tag, err = name.NewTag(base, opts...)
+	if err != nil {
+		return name.Tag{}, name.Digest{}, false
+	}
+	return tag, digest, true
+}
+
+// TagDriftError is returned by CheckTagDigest when a tag no longer points at
+// the digest it was given with, e.g. because it was moved after signing.
+type TagDriftError struct {
+	Tag    name.Tag
+	Digest name.Digest
+	// Current is the digest the tag points at now.
+	Current name.Digest
+}
+
+func (e *TagDriftError) Error() string {
+	return fmt.Sprintf("tag %s points at %s, not at the requested digest %s", e.Tag, e.Current.DigestStr(), e.Digest.DigestStr())
+}
+
+// CheckTagDigest looks up tag in the registry and returns a *TagDriftError if
+// it does not point at digest.
+func CheckTagDigest(tag name.Tag, digest name.Digest, opts ...Option) error {
+	current, err := ResolveDigest(tag, opts...)
+	if err != nil {
+		return err
+	}
+	if current.DigestStr() != digest.DigestStr() {
+		return &TagDriftError{Tag: tag, Digest: digest, Current: current}
+	}
+	return nil
+}
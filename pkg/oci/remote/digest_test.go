@@ -84,3 +84,66 @@ func TestResolveDigest(t *testing.T) {
 		}
 	})
 }
+
+func TestTagAndDigest(t *testing.T) {
+	const d = "sha256:be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4"
+	tests := []struct {
+		ref     string
+		wantOK  bool
+		wantTag string
+	}{
+		{ref: "gcr.io/distroless/static:nonroot@" + d, wantOK: true, wantTag: "gcr.io/distroless/static:nonroot"},
+		{ref: "localhost:5000/static:v1@" + d, wantOK: true, wantTag: "localhost:5000/static:v1"},
+		{ref: "localhost:5000/static@" + d},
+		{ref: "gcr.io/distroless/static@" + d},
+		{ref: "gcr.io/distroless/static:nonroot"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.ref, func(t *testing.T) {
+			tag, digest, ok := TagAndDigest(tc.ref)
+			if ok != tc.wantOK {
+				t.Fatalf("TagAndDigest() ok = %v, wanted %v", ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if tag.String() != tc.wantTag {
+				t.Errorf("TagAndDigest() tag = %s, wanted %s", tag, tc.wantTag)
+			}
+			if digest.DigestStr() != d {
+				t.Errorf("TagAndDigest() digest = %s, wanted %s", digest.DigestStr(), d)
+			}
+		})
+	}
+}
+
+func TestCheckTagDigest(t *testing.T) {
+	rg := remoteGet
+	defer func() {
+		remoteGet = rg
+	}()
+
+	const current = "be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4"
+	remoteGet = func(_ name.Reference, _ ...remote.Option) (*remote.Descriptor, error) {
+		return &remote.Descriptor{
+			Descriptor: v1.Descriptor{
+				Digest: v1.Hash{Algorithm: "sha256", Hex: current},
+			},
+		}, nil
+	}
+
+	tag := name.MustParseReference("gcr.io/distroless/static:nonroot").(name.Tag)
+	if err := CheckTagDigest(tag, tag.Context().Digest("sha256:"+current)); err != nil {
+		t.Errorf("CheckTagDigest() = %v", err)
+	}
+
+	moved := tag.Context().Digest("sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	err := CheckTagDigest(tag, moved)
+	var drift *TagDriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("CheckTagDigest() = %v, wanted a TagDriftError", err)
+	}
+	if drift.Current.DigestStr() != "sha256:"+current {
+		t.Errorf("TagDriftError.Current = %s, wanted sha256:%s", drift.Current.DigestStr(), current)
+	}
+}
@@ -23,15 +23,21 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
 var ErrImageNotFound = errors.New("image not found in registry")
 
 // SignedImage provides access to a remote image reference, and its signatures.
+// A legacy Docker schema 1 image only provides its digest, signatures and
+// attestations.
 func SignedImage(ref name.Reference, options ...Option) (oci.SignedImage, error) {
 	o := makeOptions(ref.Context(), options...)
-	ri, err := remoteImage(ref, o.ROpt...)
+	ri, err := o.image(ref)
+	if errors.Is(err, remote.ErrSchema1) {
+		ri, err = o.schema1Image(ref)
+	}
 	var te *transport.Error
 	if errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
 		return nil, ErrImageNotFound
@@ -71,3 +77,13 @@ func (i *image) Attestations() (oci.Signatures, error) {
 func (i *image) Attachment(name string) (oci.File, error) {
 	return attachment(i, name, i.opt)
 }
+
+// Annotations implements oci.SignedImage
+func (i *image) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(i.Image)
+}
+
+// Subject implements oci.SignedImage
+func (i *image) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(i.Image)
+}
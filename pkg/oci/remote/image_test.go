@@ -20,6 +20,7 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
@@ -69,6 +70,50 @@ func TestSignedImage(t *testing.T) {
 	}
 }
 
+func TestSignedImageAnnotations(t *testing.T) {
+	ri := remote.Image
+	t.Cleanup(func() {
+		remoteImage = ri
+	})
+	ann := map[string]string{"org.opencontainers.image.created": "2024-01-02T03:04:05Z"}
+	remoteImage = func(_ name.Reference, _ ...remote.Option) (v1.Image, error) {
+		img, err := random.Image(300 /* byteSize */, 1)
+		if err != nil {
+			return nil, err
+		}
+		return mutate.Annotations(img, ann).(v1.Image), nil
+	}
+
+	ref, err := name.ParseReference("gcr.io/distroless/static:nonroot")
+	if err != nil {
+		t.Fatalf("ParseRef() = %v", err)
+	}
+	si, err := SignedImage(ref)
+	if err != nil {
+		t.Fatalf("SignedImage() = %v", err)
+	}
+	sigs, err := si.Signatures()
+	if err != nil {
+		t.Fatalf("Signatures() = %v", err)
+	}
+
+	for name, e := range map[string]interface {
+		Annotations() (map[string]string, error)
+		Subject() (*v1.Descriptor, error)
+	}{"image": si, "signatures": sigs} {
+		got, err := e.Annotations()
+		if err != nil {
+			t.Fatalf("%s Annotations() = %v", name, err)
+		}
+		if got["org.opencontainers.image.created"] != "2024-01-02T03:04:05Z" {
+			t.Errorf("%s Annotations() = %v, wanted %v", name, got, ann)
+		}
+		if subject, err := e.Subject(); err != nil || subject != nil {
+			t.Errorf("%s Subject() = %v, %v, wanted none", name, subject, err)
+		}
+	}
+}
+
 func TestSignedImageWithAttachment(t *testing.T) {
 	ri := remote.Image
 	t.Cleanup(func() {
@@ -22,13 +22,14 @@ import (
 	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
 // SignedImageIndex provides access to a remote index reference, and its signatures.
 func SignedImageIndex(ref name.Reference, options ...Option) (oci.SignedImageIndex, error) {
 	o := makeOptions(ref.Context(), options...)
-	ri, err := remoteIndex(ref, o.ROpt...)
+	ri, err := o.index(ref)
 	var te *transport.Error
 	if errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
 		return nil, errors.New("index not found in registry")
@@ -69,9 +70,22 @@ func (i *index) Attachment(name string) (oci.File, error) {
 	return attachment(i, name, i.opt)
 }
 
+// Annotations implements oci.SignedImageIndex
+func (i *index) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(i.v1Index)
+}
+
+// Subject implements oci.SignedImageIndex
+func (i *index) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(i.v1Index)
+}
+
 // SignedImage implements oci.SignedImageIndex
 func (i *index) SignedImage(h v1.Hash) (oci.SignedImage, error) {
 	img, err := i.Image(h)
+	if errors.Is(err, remote.ErrSchema1) && i.ref != nil {
+		img, err = i.opt.schema1Image(i.ref.Context().Digest(h.String()))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -87,8 +101,13 @@ func (i *index) SignedImageIndex(h v1.Hash) (oci.SignedImageIndex, error) {
 	if err != nil {
 		return nil, err
 	}
+	var ref name.Reference
+	if i.ref != nil {
+		ref = i.ref.Context().Digest(h.String())
+	}
 	return &index{
 		v1Index: ii,
+		ref:     ref,
 		opt:     i.opt,
 	}, nil
 }
@@ -0,0 +1,37 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// schema1Image returns the Docker schema 1 image ref points to. Such legacy
+// images only partly implement v1.Image: their manifest and config cannot be
+// read, but they have a digest, so their signatures and attestations can
+// still be listed and verified.
+func (o *options) schema1Image(ref name.Reference) (v1.Image, error) {
+	d, err := o.get(ref)
+	if err != nil {
+		return nil, err
+	}
+	if !d.MediaType.IsSchema1() {
+		return nil, fmt.Errorf("%s is not a schema 1 image: %s", ref, d.MediaType)
+	}
+	return d.Schema1()
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+	ociempty "github.com/franchb/cosign/v2/pkg/oci/empty"
+	ocimutate "github.com/franchb/cosign/v2/pkg/oci/mutate"
+	ocistatic "github.com/franchb/cosign/v2/pkg/oci/static"
+)
+
+// signLegacy writes a signature for the image with digest h in repo.
+func signLegacy(t *testing.T, repo name.Repository, h v1.Hash) {
+	t.Helper()
+	sig, err := ocistatic.NewSignature([]byte("payload"), "sig")
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+	sigs, err := ocimutate.AppendSignatures(ociempty.Signatures(), false, sig)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	if err := remote.Write(repo.Tag(normalize(h, "", "sig")), sigs); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+}
+
+// checkLegacySignatures checks that se has digest h and one signature.
+func checkLegacySignatures(t *testing.T, se oci.SignedEntity, h v1.Hash) {
+	t.Helper()
+	if got, err := se.Digest(); err != nil {
+		t.Fatalf("Digest() = %v", err)
+	} else if got != h {
+		t.Errorf("Digest() = %s, wanted %s", got, h)
+	}
+	sigs, err := se.Signatures()
+	if err != nil {
+		t.Fatalf("Signatures() = %v", err)
+	}
+	if sl, err := sigs.Get(); err != nil {
+		t.Fatalf("Get() = %v", err)
+	} else if len(sl) != 1 {
+		t.Errorf("len(Get()) = %d, wanted 1", len(sl))
+	}
+}
+
+func TestSchema1Signatures(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/legacy:latest")
+	if err != nil {
+		t.Fatalf("ParseReference() = %v", err)
+	}
+	manifest := []byte(`{"schemaVersion": 1, "name": "legacy", "tag": "latest", "architecture": "amd64", "fsLayers": [], "history": []}`)
+	if err := remote.Put(ref, &taggableManifest{raw: manifest, mediaType: types.DockerManifestSchema1}); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	h, _, err := v1.SHA256(strings.NewReader(string(manifest)))
+	if err != nil {
+		t.Fatalf("SHA256() = %v", err)
+	}
+	signLegacy(t, ref.Context(), h)
+
+	se, err := SignedEntity(ref)
+	if err != nil {
+		t.Fatalf("SignedEntity() = %v", err)
+	}
+	checkLegacySignatures(t, se, h)
+
+	si, err := SignedImage(ref)
+	if err != nil {
+		t.Fatalf("SignedImage() = %v", err)
+	}
+	checkLegacySignatures(t, si, h)
+}
+
+func TestForeignLayerSignatures(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/foreign:latest")
+	if err != nil {
+		t.Fatalf("ParseReference() = %v", err)
+	}
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	// The foreign layer is not pushed, and cannot be fetched from its URL.
+	img, err = mutate.Append(img, mutate.Addendum{
+		Layer:     static.NewLayer([]byte("foreign"), types.DockerForeignLayer),
+		URLs:      []string{"https://foreign.invalid/layer"},
+		MediaType: types.DockerForeignLayer,
+	})
+	if err != nil {
+		t.Fatalf("Append() = %v", err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	signLegacy(t, ref.Context(), h)
+
+	se, err := SignedEntity(ref)
+	if err != nil {
+		t.Fatalf("SignedEntity() = %v", err)
+	}
+	checkLegacySignatures(t, se, h)
+}
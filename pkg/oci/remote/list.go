@@ -0,0 +1,247 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// cosignTag matches the tags signatures, attestations and attachments are
+// stored under, see normalize.
+var cosignTag = regexp.MustCompile(`^sha(256|512)-[0-9a-f]+(\..+)?$`)
+
+// attachmentTag matches the tags of signatures, attestations and attachments,
+// capturing the digest of their subject and the suffix.
+var attachmentTag = regexp.MustCompile(`^(sha256|sha512)-([0-9a-f]+)\.(.+)$`)
+
+// TaggedDigest is an image or image index in a repository and the tags
+// pointing to it.
+type TaggedDigest struct {
+	Digest name.Digest
+	Tags   []string
+}
+
+// ListDigests lists the images and image indexes the tags of repo point to,
+// in the order of their first tag. The tags cosign stores signatures,
+// attestations and attachments under are skipped.
+func ListDigests(repo name.Repository, opts ...Option) ([]TaggedDigest, error) {
+	o := makeOptions(repo, opts...)
+	tags, err := listTags(o, repo)
+	if err != nil {
+		return nil, err
+	}
+	return listDigests(o, repo, tags)
+}
+
+func listDigests(o *options, repo name.Repository, tags []string) ([]TaggedDigest, error) {
+	var listed []TaggedDigest
+	index := map[name.Digest]int{}
+	for _, tag := range tags {
+		if t, ok := strings.CutPrefix(tag, o.TagPrefix); ok && cosignTag.MatchString(t) {
+			continue
+		}
+		digest, err := withRetries(o, func() (string, error) {
+			d, err := remote.Head(repo.Tag(tag), o.ROpt...)
+			if err != nil {
+				return "", err
+			}
+			return d.Digest.String(), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		d := repo.Digest(digest)
+		if i, ok := index[d]; ok {
+			listed[i].Tags = append(listed[i].Tags, tag)
+			continue
+		}
+		index[d] = len(listed)
+		listed = append(listed, TaggedDigest{Digest: d, Tags: []string{tag}})
+	}
+	return listed, nil
+}
+
+// OrphanedTag is a tag holding the signatures, attestations or SBOM of an
+// image manifest that no longer exists.
+type OrphanedTag struct {
+	Tag name.Tag
+	// Suffix tells what the tag holds, such as SignatureTagSuffix.
+	Suffix string
+	// Subject is the missing image manifest.
+	Subject name.Digest
+}
+
+// ListOrphanedTags lists the tags holding the signatures, attestations and
+// SBOMs of image manifests that are no longer in repo. The tags are looked
+// for where the signatures of repo are stored, see WithTargetRepository.
+func ListOrphanedTags(repo name.Repository, opts ...Option) ([]OrphanedTag, error) {
+	o := makeOptions(repo, opts...)
+	tags, err := listTags(o, o.TargetRepository)
+	if err != nil {
+		return nil, err
+	}
+	return listOrphanedTags(o, repo, tags)
+}
+
+func listOrphanedTags(o *options, repo name.Repository, tags []string) ([]OrphanedTag, error) {
+	var orphaned []OrphanedTag
+	for _, tag := range tags {
+		subject, suffix, ok := attachmentSubject(o, tag)
+		if !ok {
+			continue
+		}
+		d := repo.Digest(subject)
+		exists, err := withRetries(o, func() (bool, error) {
+			_, err := remote.Head(d, o.ROpt...)
+			if terr := (&transport.Error{}); errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			orphaned = append(orphaned, OrphanedTag{Tag: o.TargetRepository.Tag(tag), Suffix: suffix, Subject: d})
+		}
+	}
+	return orphaned, nil
+}
+
+// ListAttestationTags lists the tags holding the attestations of the image
+// manifests of repo, whether or not the manifests still exist. The tags are
+// looked for where the signatures of repo are stored, see
+// WithTargetRepository, which may not exist yet.
+func ListAttestationTags(repo name.Repository, opts ...Option) ([]name.Tag, error) {
+	o := makeOptions(repo, opts...)
+	tags, err := listTags(o, o.TargetRepository)
+	if terr := (&transport.Error{}); errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var atts []name.Tag
+	for _, tag := range tags {
+		if _, suffix, ok := attachmentSubject(o, tag); ok && suffix == o.AttestationSuffix {
+			atts = append(atts, o.TargetRepository.Tag(tag))
+		}
+	}
+	return atts, nil
+}
+
+// Drift is how the signatures, attestations and SBOMs stored for the images
+// of a repository have drifted apart from them, see CheckDrift.
+type Drift struct {
+	// SignatureRepository is where the signatures of the repository are
+	// stored, see WithTargetRepository.
+	SignatureRepository name.Repository
+	// Orphaned are the tags holding the signatures, attestations and SBOMs
+	// of image manifests that are no longer in the repository.
+	Orphaned []OrphanedTag
+	// Unsigned are the tagged images and image indexes of the repository
+	// without a signature where its signatures are stored.
+	Unsigned []TaggedDigest
+	// Misplaced are the tags of the repository holding signatures,
+	// attestations or SBOMs although they are stored in another one, and so
+	// are not found by cosign.
+	Misplaced []name.Tag
+}
+
+// CheckDrift compares the images of repo with the signatures, attestations
+// and SBOMs stored for them where the signatures of repo are stored, see
+// WithTargetRepository, finding those left behind by images that were
+// deleted and the images left without a signature.
+func CheckDrift(repo name.Repository, opts ...Option) (*Drift, error) {
+	o := makeOptions(repo, opts...)
+	tags, err := listTags(o, repo)
+	if err != nil {
+		return nil, err
+	}
+	targetTags := tags
+	if o.TargetRepository != repo {
+		// The repository signatures are stored in is only created with the
+		// first of them.
+		targetTags, err = listTags(o, o.TargetRepository)
+		if terr := (&transport.Error{}); errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			targetTags, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	drift := &Drift{SignatureRepository: o.TargetRepository}
+	if drift.Orphaned, err = listOrphanedTags(o, repo, targetTags); err != nil {
+		return nil, err
+	}
+
+	signed := map[string]bool{}
+	for _, tag := range targetTags {
+		if subject, suffix, ok := attachmentSubject(o, tag); ok && suffix == o.SignatureSuffix {
+			signed[subject] = true
+		}
+	}
+	digests, err := listDigests(o, repo, tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, td := range digests {
+		if !signed[td.Digest.DigestStr()] {
+			drift.Unsigned = append(drift.Unsigned, td)
+		}
+	}
+
+	if o.TargetRepository != repo {
+		for _, tag := range tags {
+			if _, _, ok := attachmentSubject(o, tag); ok {
+				drift.Misplaced = append(drift.Misplaced, repo.Tag(tag))
+			}
+		}
+	}
+	return drift, nil
+}
+
+// attachmentSubject returns the digest of the image manifest whose
+// signatures, attestations or SBOMs tag holds, and which of them it holds.
+func attachmentSubject(o *options, tag string) (string, string, bool) {
+	t, ok := strings.CutPrefix(tag, o.TagPrefix)
+	if !ok {
+		return "", "", false
+	}
+	m := attachmentTag.FindStringSubmatch(t)
+	if m == nil {
+		return "", "", false
+	}
+	switch m[3] {
+	case o.SignatureSuffix, o.AttestationSuffix, o.SBOMSuffix:
+		return m[1] + ":" + m[2], m[3], true
+	default:
+		return "", "", false
+	}
+}
+
+func listTags(o *options, repo name.Repository) ([]string, error) {
+	return withRetries(o, func() ([]string, error) {
+		return remote.List(repo, o.ROpt...)
+	})
+}
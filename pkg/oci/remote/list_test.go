@@ -0,0 +1,222 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/franchb/cosign/v2/pkg/oci/ocitest"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestListDigests(t *testing.T) {
+	repo := ocitest.New(t).Repository("repo")
+	want := map[string][]string{}
+	for _, tags := range [][]string{{"latest", "v1"}, {"v0"}} {
+		img, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		h, err := img.Digest()
+		if err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		for _, tag := range tags {
+			if err := remote.Write(repo.Tag(tag), img); err != nil {
+				t.Fatalf("Write() = %v", err)
+			}
+		}
+		// The signatures are not listed.
+		if err := remote.Write(repo.Tag(normalize(h, "", "sig")), img); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+		want[repo.Digest(h.String()).String()] = tags
+	}
+
+	listed, err := ListDigests(repo)
+	if err != nil {
+		t.Fatalf("ListDigests() = %v", err)
+	}
+	if len(listed) != len(want) {
+		t.Fatalf("ListDigests() = %v, wanted %v", listed, want)
+	}
+	for _, td := range listed {
+		if got, want := strings.Join(td.Tags, ","), strings.Join(want[td.Digest.String()], ","); got != want {
+			t.Errorf("tags of %s = %s, wanted %s", td.Digest, got, want)
+		}
+	}
+}
+
+func TestListOrphanedTags(t *testing.T) {
+	repo := ocitest.New(t).Repository("repo")
+	live, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	gone, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	liveDigest, err := live.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	goneDigest, err := gone.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	// Only the image the signatures of gone were attached to is missing.
+	for _, tag := range []string{
+		"latest",
+		normalize(liveDigest, "", "sig"),
+		normalize(liveDigest, "", "att"),
+		normalize(goneDigest, "", "sig"),
+		normalize(goneDigest, "", "sbom"),
+		normalize(goneDigest, "", "unknown"),
+	} {
+		if err := remote.Write(repo.Tag(tag), live); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+	}
+
+	orphaned, err := ListOrphanedTags(repo)
+	if err != nil {
+		t.Fatalf("ListOrphanedTags() = %v", err)
+	}
+	var got []string
+	for _, o := range orphaned {
+		if o.Subject.DigestStr() != goneDigest.String() {
+			t.Errorf("Subject = %s, wanted %s", o.Subject, goneDigest)
+		}
+		if !strings.HasSuffix(o.Tag.TagStr(), "."+o.Suffix) {
+			t.Errorf("Suffix = %s, wanted that of %s", o.Suffix, o.Tag)
+		}
+		got = append(got, o.Tag.TagStr())
+	}
+	want := []string{normalize(goneDigest, "", "sbom"), normalize(goneDigest, "", "sig")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ListOrphanedTags() = %v, wanted %v", got, want)
+	}
+}
+
+func TestListAttestationTags(t *testing.T) {
+	repo := ocitest.New(t).Repository("repo")
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	gone := v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("0", 64)}
+	for _, tag := range []string{
+		"latest",
+		normalize(h, "", "sig"),
+		normalize(h, "", "att"),
+		normalize(gone, "", "att"),
+		normalize(gone, "", "sbom"),
+	} {
+		if err := remote.Write(repo.Tag(tag), img); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+	}
+
+	tags, err := ListAttestationTags(repo)
+	if err != nil {
+		t.Fatalf("ListAttestationTags() = %v", err)
+	}
+	var got []string
+	for _, tag := range tags {
+		got = append(got, tag.TagStr())
+	}
+	want := []string{normalize(gone, "", "att"), normalize(h, "", "att")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ListAttestationTags() = %v, wanted %v", got, want)
+	}
+
+	// Nothing is stored in a repository that does not exist yet.
+	if tags, err := ListAttestationTags(repo, WithTargetRepository(repo.Registry.Repo("sigs"))); err != nil || len(tags) != 0 {
+		t.Errorf("ListAttestationTags() = %v, %v, wanted none", tags, err)
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	r := ocitest.New(t)
+	repo, sigRepo := r.Repository("repo"), r.Repository("sigs")
+	digests := map[string]v1.Hash{}
+	for _, tag := range []string{"signed", "unsigned", "gone"} {
+		img, err := random.Image(300 /* bytes */, 1 /* layers */)
+		if err != nil {
+			t.Fatalf("random.Image() = %v", err)
+		}
+		if digests[tag], err = img.Digest(); err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		// The image of gone was deleted.
+		if tag == "gone" {
+			continue
+		}
+		if err := remote.Write(repo.Tag(tag), img); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+	}
+	sig, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	for _, tag := range []name.Tag{
+		sigRepo.Tag(normalize(digests["signed"], "", "sig")),
+		sigRepo.Tag(normalize(digests["gone"], "", "sig")),
+		sigRepo.Tag(normalize(digests["gone"], "", "att")),
+		// Signed before signatures were stored in sigRepo.
+		repo.Tag(normalize(digests["unsigned"], "", "sig")),
+	} {
+		if err := remote.Write(tag, sig); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+	}
+
+	drift, err := CheckDrift(repo, WithTargetRepository(sigRepo))
+	if err != nil {
+		t.Fatalf("CheckDrift() = %v", err)
+	}
+	var orphaned []string
+	for _, o := range drift.Orphaned {
+		orphaned = append(orphaned, o.Tag.String())
+	}
+	if got, want := strings.Join(orphaned, ","), sigRepo.Tag(normalize(digests["gone"], "", "att")).String()+","+sigRepo.Tag(normalize(digests["gone"], "", "sig")).String(); got != want {
+		t.Errorf("Orphaned = %s, wanted %s", got, want)
+	}
+	if len(drift.Unsigned) != 1 || drift.Unsigned[0].Digest.DigestStr() != digests["unsigned"].String() {
+		t.Errorf("Unsigned = %v, wanted only %s", drift.Unsigned, digests["unsigned"])
+	}
+	if len(drift.Misplaced) != 1 || drift.Misplaced[0] != repo.Tag(normalize(digests["unsigned"], "", "sig")) {
+		t.Errorf("Misplaced = %v, wanted only the signature of %s", drift.Misplaced, digests["unsigned"])
+	}
+
+	// Before anything is signed, the signature repository does not exist.
+	drift, err = CheckDrift(repo, WithTargetRepository(r.Repository("empty")))
+	if err != nil {
+		t.Fatalf("CheckDrift() = %v", err)
+	}
+	if len(drift.Orphaned) != 0 || len(drift.Unsigned) != 2 {
+		t.Errorf("CheckDrift() = %+v, wanted both images unsigned", drift)
+	}
+}
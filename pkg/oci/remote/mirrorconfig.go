@@ -0,0 +1,325 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// The capabilities of a MirrorHost, as in containerd's hosts.toml.
+const (
+	// MirrorCapabilityPull allows fetching manifests and blobs by digest
+	// from a mirror.
+	MirrorCapabilityPull = "pull"
+	// MirrorCapabilityResolve allows resolving tags, including the tags
+	// signatures and attestations are stored under, on a mirror.
+	MirrorCapabilityResolve = "resolve"
+)
+
+// DefaultMirrorRegistry is the key of MirrorConfig.Registries whose mirrors
+// are used for the registries that are not listed.
+const DefaultMirrorRegistry = "*"
+
+// MirrorConfig configures the mirrors images, signatures and attestations
+// are read from instead of their registry, e.g. internal pull-through
+// caches, in the manner of containerd's hosts.toml.
+type MirrorConfig struct {
+	// Registries maps the name of a registry, e.g. "docker.io", or
+	// DefaultMirrorRegistry, to its mirrors.
+	Registries map[string]RegistryMirrors `json:"registries"`
+
+	registries map[string]*RegistryMirrors
+}
+
+// RegistryMirrors are the mirrors of a registry.
+type RegistryMirrors struct {
+	// Mirrors are tried in order before the registry itself.
+	Mirrors []MirrorHost `json:"mirrors"`
+	// MirrorsOnly fails reads that no mirror serves instead of falling back
+	// to the registry, e.g. where it cannot be reached.
+	MirrorsOnly bool `json:"mirrorsOnly,omitempty"`
+}
+
+// MirrorHost is a registry mirroring the repositories of another.
+type MirrorHost struct {
+	// Host is the mirror registry, e.g. "mirror.example.com:5000".
+	Host string `json:"host"`
+	// Prefix is prepended to repository paths on the mirror, e.g.
+	// "dockerhub" for a pull-through cache serving docker.io/library/busybox
+	// as mirror.example.com/dockerhub/library/busybox.
+	Prefix string `json:"prefix,omitempty"`
+	// Capabilities are what the mirror may be used for, MirrorCapabilityPull
+	// and MirrorCapabilityResolve. Both are allowed if it lists none.
+	Capabilities []string `json:"capabilities,omitempty"`
+	// PlainHTTP allows reaching the mirror without TLS.
+	PlainHTTP bool `json:"plainHTTP,omitempty"`
+	// TLS configures the TLS connections to the mirror.
+	TLS *MirrorTLS `json:"tls,omitempty"`
+	// Auth holds the credentials for the mirror. Without them, credentials
+	// are looked up for Host like for any registry.
+	Auth *MirrorAuth `json:"auth,omitempty"`
+
+	registry  name.Registry
+	transport http.RoundTripper
+	auth      authn.Authenticator
+}
+
+// MirrorTLS configures the TLS connections to a mirror. Relative paths are
+// relative to the directory of the configuration file.
+type MirrorTLS struct {
+	// CAFile is a PEM file of the certificates trusted to authenticate the
+	// mirror, in addition to the system roots.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile and KeyFile are the PEM client certificate and key presented
+	// to the mirror.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// InsecureSkipVerify disables the verification of the mirror's
+	// certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// MirrorAuth holds the credentials for a mirror, either a username and
+// password or a registry token.
+type MirrorAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	RegistryToken string `json:"registryToken,omitempty"`
+}
+
+// LoadMirrorConfig reads a MirrorConfig from the JSON file at path.
+func LoadMirrorConfig(path string) (*MirrorConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseMirrorConfig(b, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("parsing mirror config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseMirrorConfig parses a MirrorConfig from JSON, checking its registries
+// and loading the TLS files of its mirrors relative to the working
+// directory.
+func ParseMirrorConfig(b []byte) (*MirrorConfig, error) {
+	return parseMirrorConfig(b, "")
+}
+
+func parseMirrorConfig(b []byte, dir string) (*MirrorConfig, error) {
+	c := &MirrorConfig{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	c.registries = make(map[string]*RegistryMirrors, len(c.Registries))
+	for reg, rm := range c.Registries {
+		key := reg
+		if reg != DefaultMirrorRegistry {
+			r, err := name.NewRegistry(reg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid registry %q: %w", reg, err)
+			}
+			key = r.RegistryStr()
+		}
+		if _, ok := c.registries[key]; ok {
+			return nil, fmt.Errorf("registry %q is listed more than once", reg)
+		}
+		for i := range rm.Mirrors {
+			if err := rm.Mirrors[i].init(dir); err != nil {
+				return nil, fmt.Errorf("mirror %q of %s: %w", rm.Mirrors[i].Host, reg, err)
+			}
+		}
+		c.registries[key] = &rm
+	}
+	return c, nil
+}
+
+// init checks h and prepares the registry, transport and credentials it is
+// read with.
+func (h *MirrorHost) init(dir string) error {
+	if h.Host == "" {
+		return errors.New("missing host")
+	}
+	var opts []name.Option
+	if h.PlainHTTP {
+		opts = append(opts, name.Insecure)
+	}
+	r, err := name.NewRegistry(h.Host, opts...)
+	if err != nil {
+		return err
+	}
+	h.registry = r
+	for _, c := range h.Capabilities {
+		if c != MirrorCapabilityPull && c != MirrorCapabilityResolve {
+			return fmt.Errorf("unknown capability %q", c)
+		}
+	}
+	if h.TLS != nil {
+		cfg, err := h.TLS.config(dir)
+		if err != nil {
+			return err
+		}
+		t := remote.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = cfg
+		h.transport = t
+	}
+	if a := h.Auth; a != nil {
+		switch {
+		case a.Username != "" && a.Password != "":
+			h.auth = &authn.Basic{Username: a.Username, Password: a.Password}
+		case a.RegistryToken != "":
+			h.auth = &authn.Bearer{Token: a.RegistryToken}
+		default:
+			return errors.New("auth needs a username and password or a registry token")
+		}
+	}
+	return nil
+}
+
+// config returns the TLS configuration t describes, reading its files
+// relative to dir.
+func (t *MirrorTLS) config(dir string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify, // #nosec G402
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(resolvePath(dir, t.CAFile))
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(resolvePath(dir, t.CertFile), resolvePath(dir, t.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func resolvePath(dir, p string) string {
+	if dir == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// can reports whether h may serve ref, which needs MirrorCapabilityResolve
+// unless ref is a digest.
+func (h *MirrorHost) can(ref name.Reference) bool {
+	if len(h.Capabilities) == 0 {
+		return true
+	}
+	if _, ok := ref.(name.Digest); ok {
+		return slices.Contains(h.Capabilities, MirrorCapabilityPull)
+	}
+	return slices.Contains(h.Capabilities, MirrorCapabilityResolve)
+}
+
+// reference returns ref on h.
+func (h *MirrorHost) reference(ref name.Reference) name.Reference {
+	return inRepository(ref, h.registry.Repo(path.Join(h.Prefix, ref.Context().RepositoryStr())))
+}
+
+// remoteOptions returns ropt with the transport and credentials of h.
+func (h *MirrorHost) remoteOptions(ropt []remote.Option) []remote.Option {
+	if h.transport == nil && h.auth == nil {
+		return ropt
+	}
+	// A reused puller would keep the transport and credentials of ropt.
+	opts := append(slices.Clip(ropt), remote.Reuse[*remote.Puller](nil))
+	if h.transport != nil {
+		opts = append(opts, remote.WithTransport(h.transport))
+	}
+	if h.auth != nil {
+		opts = append(opts, remote.WithAuthFromKeychain(nil), remote.WithAuth(h.auth))
+	}
+	return opts
+}
+
+// mirrors returns the mirrors of the registry of ref, if any.
+func (c *MirrorConfig) mirrors(ref name.Reference) *RegistryMirrors {
+	if rm, ok := c.registries[ref.Context().RegistryStr()]; ok {
+		return rm
+	}
+	return c.registries[DefaultMirrorRegistry]
+}
+
+// WithMirrorConfig is a functional option for reading images, signatures
+// and attestations from the mirrors c configures for their registry, before
+// falling back to the registry itself. It is meant for read-only paths such
+// as verification, since mirrors may lag behind their registry.
+func WithMirrorConfig(c *MirrorConfig) Option {
+	return func(o *options) {
+		o.MirrorConfig = c
+	}
+}
+
+// viaMirrors calls f with ref on each mirror of its registry able to serve
+// it, returning the first success, and then with ref itself unless the
+// registry is only read through its mirrors.
+func viaMirrors[T any](o *options, ref name.Reference, f func(name.Reference, []remote.Option) (T, error)) (T, error) {
+	if o.MirrorConfig == nil {
+		return f(ref, o.ROpt)
+	}
+	rm := o.MirrorConfig.mirrors(ref)
+	if rm == nil {
+		return f(ref, o.ROpt)
+	}
+	var errs []error
+	for i := range rm.Mirrors {
+		h := &rm.Mirrors[i]
+		if !h.can(ref) {
+			continue
+		}
+		v, err := f(h.reference(ref), h.remoteOptions(o.ROpt))
+		if err == nil {
+			return v, nil
+		}
+		errs = append(errs, fmt.Errorf("mirror %s: %w", h.Host, err))
+	}
+	if rm.MirrorsOnly {
+		var zero T
+		if len(errs) == 0 {
+			return zero, fmt.Errorf("no mirror of %s can serve %s", ref.Context().RegistryStr(), ref)
+		}
+		return zero, errors.Join(errs...)
+	}
+	return f(ref, o.ROpt)
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestParseMirrorConfig(t *testing.T) {
+	c, err := ParseMirrorConfig([]byte(`{"registries": {
+		"docker.io": {"mirrors": [{"host": "mirror.example.com", "prefix": "dockerhub"}]},
+		"*": {"mirrors": [{"host": "cache.example.com", "capabilities": ["pull"]}], "mirrorsOnly": true}
+	}}`))
+	if err != nil {
+		t.Fatalf("ParseMirrorConfig() = %v", err)
+	}
+
+	ref := name.MustParseReference("busybox:latest")
+	rm := c.mirrors(ref)
+	if rm == nil || len(rm.Mirrors) != 1 {
+		t.Fatalf("mirrors(%s) = %v, wanted the docker.io mirror", ref, rm)
+	}
+	if got, want := rm.Mirrors[0].reference(ref).String(), "mirror.example.com/dockerhub/library/busybox:latest"; got != want {
+		t.Errorf("reference() = %s, wanted %s", got, want)
+	}
+
+	ref = name.MustParseReference("ghcr.io/example/app:v1")
+	rm = c.mirrors(ref)
+	if rm == nil || !rm.MirrorsOnly {
+		t.Fatalf("mirrors(%s) = %v, wanted the default mirrors", ref, rm)
+	}
+	if rm.Mirrors[0].can(ref) {
+		t.Error("a mirror without the resolve capability can resolve a tag")
+	}
+	if !rm.Mirrors[0].can(ref.Context().Digest("sha256:" + strings.Repeat("a", 64))) {
+		t.Error("a mirror with the pull capability cannot pull a digest")
+	}
+}
+
+func TestParseMirrorConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{{
+		name:   "invalid registry",
+		config: `{"registries": {"Not A Registry": {}}}`,
+	}, {
+		name:   "registry listed twice",
+		config: `{"registries": {"docker.io": {}, "index.docker.io": {}}}`,
+	}, {
+		name:   "invalid host",
+		config: `{"registries": {"docker.io": {"mirrors": [{"host": ""}]}}}`,
+	}, {
+		name:   "unknown capability",
+		config: `{"registries": {"docker.io": {"mirrors": [{"host": "mirror.example.com", "capabilities": ["push"]}]}}}`,
+	}, {
+		name:   "incomplete auth",
+		config: `{"registries": {"docker.io": {"mirrors": [{"host": "mirror.example.com", "auth": {"username": "user"}}]}}}`,
+	}, {
+		name:   "missing CA file",
+		config: `{"registries": {"docker.io": {"mirrors": [{"host": "mirror.example.com", "tls": {"caFile": "does-not-exist.pem"}}]}}}`,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseMirrorConfig([]byte(tc.config)); err == nil {
+				t.Errorf("ParseMirrorConfig(%s) succeeded", tc.config)
+			}
+		})
+	}
+}
+
+func TestMirrorConfigReads(t *testing.T) {
+	upstream := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(upstream.Close)
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mirror"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(mirror.Close)
+	upstreamHost := strings.TrimPrefix(upstream.URL, "http://")
+	mirrorHost := strings.TrimPrefix(mirror.URL, "http://")
+
+	// mirrored is only in the mirror, and upstreamOnly only upstream.
+	mirrored := writeRandomImage(t, mirrorHost+"/proxy/app:v1", remote.WithAuth(&authn.Basic{Username: "user", Password: "pass"}))
+	upstreamOnly := writeRandomImage(t, upstreamHost+"/app:v2")
+
+	config := func(capabilities string, mirrorsOnly bool) *MirrorConfig {
+		t.Helper()
+		c, err := ParseMirrorConfig([]byte(fmt.Sprintf(`{"registries": {%q: {"mirrors": [{
+			"host": %q, "prefix": "proxy", "capabilities": %s, "auth": {"username": "user", "password": "pass"}
+		}], "mirrorsOnly": %t}}}`, upstreamHost, mirrorHost, capabilities, mirrorsOnly)))
+		if err != nil {
+			t.Fatalf("ParseMirrorConfig() = %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		config  *MirrorConfig
+		want    v1.Hash
+		wantErr bool
+	}{{
+		name:   "digest from mirror",
+		ref:    upstreamHost + "/app@" + mirrored.String(),
+		config: config(`["pull"]`, false),
+		want:   mirrored,
+	}, {
+		name:   "tag from mirror",
+		ref:    upstreamHost + "/app:v1",
+		config: config(`[]`, false),
+		want:   mirrored,
+	}, {
+		name:    "tag not resolved by a pull-only mirror",
+		ref:     upstreamHost + "/app:v1",
+		config:  config(`["pull"]`, false),
+		wantErr: true,
+	}, {
+		name:   "fallback to upstream",
+		ref:    upstreamHost + "/app:v2",
+		config: config(`[]`, false),
+		want:   upstreamOnly,
+	}, {
+		name:    "no fallback with mirrorsOnly",
+		ref:     upstreamHost + "/app:v2",
+		config:  config(`[]`, true),
+		wantErr: true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := name.ParseReference(tc.ref)
+			if err != nil {
+				t.Fatalf("ParseReference() = %v", err)
+			}
+			si, err := SignedImage(ref, WithMirrorConfig(tc.config))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("SignedImage(%s) succeeded", tc.ref)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SignedImage(%s) = %v", tc.ref, err)
+			}
+			if got, err := si.Digest(); err != nil || got != tc.want {
+				t.Errorf("Digest() = %v, %v, wanted %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestMirrorConfigTLS(t *testing.T) {
+	upstream := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(upstream.Close)
+	mirror := httptest.NewTLSServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(mirror.Close)
+	upstreamHost := strings.TrimPrefix(upstream.URL, "http://")
+	mirrorHost := strings.TrimPrefix(mirror.URL, "https://")
+
+	want := writeRandomImage(t, mirrorHost+"/app:v1", remote.WithTransport(mirror.Client().Transport))
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mirror.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.pem"), ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "mirrors.json")
+	config := fmt.Sprintf(`{"registries": {%q: {"mirrors": [{"host": %q, "tls": {"caFile": "ca.pem"}}], "mirrorsOnly": true}}}`, upstreamHost, mirrorHost)
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadMirrorConfig(path)
+	if err != nil {
+		t.Fatalf("LoadMirrorConfig() = %v", err)
+	}
+
+	ref, err := name.ParseReference(upstreamHost + "/app:v1")
+	if err != nil {
+		t.Fatalf("ParseReference() = %v", err)
+	}
+	si, err := SignedImage(ref, WithMirrorConfig(c))
+	if err != nil {
+		t.Fatalf("SignedImage() = %v", err)
+	}
+	if got, err := si.Digest(); err != nil || got != want {
+		t.Errorf("Digest() = %v, %v, wanted %v", got, err, want)
+	}
+}
+
+// writeRandomImage writes a random image to ref, returning its digest.
+func writeRandomImage(t *testing.T, ref string, opts ...remote.Option) v1.Hash {
+	t.Helper()
+	img, err := random.Image(300, 1)
+	if err != nil {
+		t.Fatalf("random.Image() = %v", err)
+	}
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("ParseReference() = %v", err)
+	}
+	if err := remote.Write(r, img, opts...); err != nil {
+		t.Fatalf("remote.Write() = %v", err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	return h
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// MirrorReport is called when a mirror served signatures or attestations
+// that could not be read from their registry. primary is the reference that
+// failed with err, and mirror the reference that was read instead.
+type MirrorReport func(primary, mirror name.Reference, err error)
+
+// WithSignatureMirrors is a functional option for reading signatures and
+// attestations from the first of mirrors that has them, under the same
+// repository path, when their registry fails with a server error. It is
+// meant for read-only paths such as verification, and report, if not nil,
+// is told which mirror served them.
+func WithSignatureMirrors(report MirrorReport, mirrors ...name.Registry) Option {
+	return func(o *options) {
+		o.Mirrors = append(o.Mirrors, mirrors...)
+		o.MirrorReport = report
+	}
+}
+
+// signaturesImage reads the signatures image ref, falling back to the
+// configured mirrors if its registry fails with a server error.
+func (o *options) signaturesImage(ref name.Reference) (v1.Image, error) {
+	img, err := o.image(ref)
+	if err == nil || len(o.Mirrors) == 0 || !isServerError(err) {
+		return img, err
+	}
+	for _, m := range o.Mirrors {
+		mref := onRegistry(ref, m)
+		mimg, merr := o.image(mref)
+		if merr != nil {
+			continue
+		}
+		if o.MirrorReport != nil {
+			o.MirrorReport(ref, mref, err)
+		}
+		return mimg, nil
+	}
+	return nil, err
+}
+
+// isServerError reports whether err is a registry response with a 5xx status.
+func isServerError(err error) bool {
+	var te *transport.Error
+	return errors.As(err, &te) && te.StatusCode >= http.StatusInternalServerError
+}
+
+// onRegistry returns ref with its registry replaced by r.
+func onRegistry(ref name.Reference, r name.Registry) name.Reference {
+	return inRepository(ref, r.Repo(ref.Context().RepositoryStr()))
+}
+
+// inRepository returns ref with its repository replaced by repo.
+func inRepository(ref name.Reference, repo name.Repository) name.Reference {
+	if d, ok := ref.(name.Digest); ok {
+		return repo.Digest(d.DigestStr())
+	}
+	return repo.Tag(ref.Identifier())
+}
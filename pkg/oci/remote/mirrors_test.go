@@ -0,0 +1,86 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+func TestSignaturesMirrorFallback(t *testing.T) {
+	ri := remoteImage
+	t.Cleanup(func() {
+		remoteImage = ri
+	})
+
+	primaryErr := &transport.Error{StatusCode: http.StatusServiceUnavailable}
+	status := map[string]int{
+		"gcr.io":              http.StatusServiceUnavailable,
+		"mirror1.example.com": http.StatusNotFound,
+		"mirror2.example.com": http.StatusOK,
+	}
+	remoteImage = func(ref name.Reference, _ ...remote.Option) (v1.Image, error) {
+		switch code := status[ref.Context().RegistryStr()]; code {
+		case http.StatusOK:
+			return empty.Image, nil
+		case http.StatusServiceUnavailable:
+			return nil, primaryErr
+		default:
+			return nil, &transport.Error{StatusCode: code}
+		}
+	}
+
+	ref := name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.sig")
+	var mirrors []name.Registry
+	for _, m := range []string{"mirror1.example.com", "mirror2.example.com"} {
+		r, err := name.NewRegistry(m)
+		if err != nil {
+			t.Fatalf("NewRegistry() = %v", err)
+		}
+		mirrors = append(mirrors, r)
+	}
+	var served name.Reference
+	report := func(primary, mirror name.Reference, err error) {
+		if primary != ref || !errors.Is(err, primaryErr) {
+			t.Errorf("report(%v, %v, %v), wanted %v failing with %v", primary, mirror, err, ref, primaryErr)
+		}
+		served = mirror
+	}
+	if _, err := Signatures(ref, WithSignatureMirrors(report, mirrors...)); err != nil {
+		t.Fatalf("Signatures() = %v", err)
+	}
+	if want := "mirror2.example.com/distroless/static:sha256-deadbeef.sig"; served == nil || served.String() != want {
+		t.Errorf("served by %v, wanted %s", served, want)
+	}
+
+	// Without a mirror that has them, the registry error is returned.
+	if _, err := Signatures(ref, WithSignatureMirrors(report, mirrors[0])); !errors.Is(err, primaryErr) {
+		t.Errorf("Signatures() = %v, wanted %v", err, primaryErr)
+	}
+
+	// Mirrors are not consulted for client errors.
+	status["gcr.io"] = http.StatusUnauthorized
+	served = nil
+	if _, err := Signatures(ref, WithSignatureMirrors(report, mirrors...)); err == nil || served != nil {
+		t.Errorf("Signatures() = %v served by %v, wanted the registry error", err, served)
+	}
+}
@@ -16,9 +16,13 @@
 package remote
 
 import (
+	"context"
 	"fmt"
+	"slices"
 
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/cache"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -42,8 +46,21 @@ type options struct {
 	SBOMSuffix        string
 	TagPrefix         string
 	TargetRepository  name.Repository
+	RepositoryMapping *RepositoryMapping
 	ROpt              []remote.Option
 	NameOpts          []name.Option
+	IdentityHashes    []string
+	PredicateTypes    []string
+	Annotations       map[string]string
+	MaxLayers         int64
+	FetchWorkers      int
+	RetryPolicy       *RetryPolicy
+	Mirrors           []name.Registry
+	MirrorReport      MirrorReport
+	MirrorConfig      *MirrorConfig
+	Cache             *cache.Cache
+	ChunkedUploads    *ChunkedUploads
+	UploadContext     context.Context
 	OriginalOptions   []Option
 }
 
@@ -60,6 +77,8 @@ func makeOptions(target name.Repository, opts ...Option) *options {
 		TagPrefix:         CustomTagPrefix,
 		TargetRepository:  target,
 		ROpt:              defaultOptions,
+		MaxLayers:         oci.MaxLayers(),
+		FetchWorkers:      oci.DefaultFetchWorkers,
 
 		// Keep the original options around for things that want
 		// to call something that takes options!
@@ -69,6 +88,14 @@ func makeOptions(target name.Repository, opts ...Option) *options {
 	for _, option := range opts {
 		option(o)
 	}
+	if o.RepositoryMapping != nil && o.TargetRepository == target {
+		if repo, ok := o.RepositoryMapping.Lookup(target); ok {
+			o.TargetRepository = repo
+		}
+	}
+	if o.RetryPolicy != nil {
+		o.ROpt = append(slices.Clip(o.ROpt), o.RetryPolicy.RemoteOptions()...)
+	}
 
 	return o
 }
@@ -121,6 +148,68 @@ func WithTargetRepository(repo name.Repository) Option {
 	}
 }
 
+// WithIdentityHashFilter is a functional option for skipping signature layers
+// whose identity index annotation lists none of the given hashes (see
+// static.IdentityHash). Layers without the annotation are always fetched.
+func WithIdentityHashFilter(hashes ...string) Option {
+	return func(o *options) {
+		o.IdentityHashes = append(o.IdentityHashes, hashes...)
+	}
+}
+
+// WithPredicateTypeFilter is a functional option for skipping attestation
+// layers whose predicate type annotation is not one of the given types.
+// Layers without the annotation are always fetched.
+func WithPredicateTypeFilter(types ...string) Option {
+	return func(o *options) {
+		o.PredicateTypes = append(o.PredicateTypes, types...)
+	}
+}
+
+// WithAnnotationFilter is a functional option for fetching only the
+// signature or attestation layers whose annotations hold every given key with
+// the given value, e.g. the predicate type of the attestation sought. Unlike
+// the index filters, it skips layers lacking an annotation, and it is kept by
+// WithoutIndexFilters.
+func WithAnnotationFilter(ann map[string]string) Option {
+	return func(o *options) {
+		if o.Annotations == nil {
+			o.Annotations = make(map[string]string, len(ann))
+		}
+		for k, v := range ann {
+			o.Annotations[k] = v
+		}
+	}
+}
+
+// WithMaxLayers is a functional option for overriding the maximum number of
+// signature or attestation layers to process, which defaults to
+// oci.MaxLayers(). Signatures with more layers fail with MaxLayersExceeded.
+func WithMaxLayers(n int64) Option {
+	return func(o *options) {
+		o.MaxLayers = n
+	}
+}
+
+// WithFetchWorkers is a functional option for overriding how many signature
+// or attestation layers are fetched at once, which defaults to
+// oci.DefaultFetchWorkers. With 1, layers are fetched one by one as their
+// payloads are read.
+func WithFetchWorkers(n int) Option {
+	return func(o *options) {
+		o.FetchWorkers = n
+	}
+}
+
+// WithoutIndexFilters is a functional option for dropping any identity or
+// predicate type filter set by earlier options.
+func WithoutIndexFilters() Option {
+	return func(o *options) {
+		o.IdentityHashes = nil
+		o.PredicateTypes = nil
+	}
+}
+
 // GetEnvTargetRepository returns the Repository specified by
 // `os.Getenv(RepoOverrideEnvKey)`, or the empty value if not set.
 // Returns an error if the value is set but cannot be parsed.
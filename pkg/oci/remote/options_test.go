@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -54,6 +55,8 @@ func TestOptions(t *testing.T) {
 			SBOMSuffix:        SBOMTagSuffix,
 			TargetRepository:  repo,
 			ROpt:              defaultOptions,
+			MaxLayers:         oci.DefaultMaxLayers,
+			FetchWorkers:      oci.DefaultFetchWorkers,
 		},
 	}, {
 		name: "signature option",
@@ -64,6 +67,8 @@ func TestOptions(t *testing.T) {
 			SBOMSuffix:        SBOMTagSuffix,
 			TargetRepository:  repo,
 			ROpt:              defaultOptions,
+			MaxLayers:         oci.DefaultMaxLayers,
+			FetchWorkers:      oci.DefaultFetchWorkers,
 		},
 	}, {
 		name: "attestation option",
@@ -74,6 +79,8 @@ func TestOptions(t *testing.T) {
 			SBOMSuffix:        SBOMTagSuffix,
 			TargetRepository:  repo,
 			ROpt:              defaultOptions,
+			MaxLayers:         oci.DefaultMaxLayers,
+			FetchWorkers:      oci.DefaultFetchWorkers,
 		},
 	}, {
 		name: "sbom option",
@@ -84,6 +91,8 @@ func TestOptions(t *testing.T) {
 			SBOMSuffix:        "pig",
 			TargetRepository:  repo,
 			ROpt:              defaultOptions,
+			MaxLayers:         oci.DefaultMaxLayers,
+			FetchWorkers:      oci.DefaultFetchWorkers,
 		},
 	}, {
 		name: "target repo option",
@@ -94,6 +103,8 @@ func TestOptions(t *testing.T) {
 			SBOMSuffix:        SBOMTagSuffix,
 			TargetRepository:  overrideRepo,
 			ROpt:              defaultOptions,
+			MaxLayers:         oci.DefaultMaxLayers,
+			FetchWorkers:      oci.DefaultFetchWorkers,
 		},
 	}, {
 		name: "remote options option",
@@ -104,6 +115,20 @@ func TestOptions(t *testing.T) {
 			SBOMSuffix:        SBOMTagSuffix,
 			TargetRepository:  repo,
 			ROpt:              otherROpt,
+			MaxLayers:         oci.DefaultMaxLayers,
+			FetchWorkers:      oci.DefaultFetchWorkers,
+		},
+	}, {
+		name: "max layers option",
+		opts: []Option{WithMaxLayers(5000)},
+		want: &options{
+			SignatureSuffix:   SignatureTagSuffix,
+			AttestationSuffix: AttestationTagSuffix,
+			SBOMSuffix:        SBOMTagSuffix,
+			TargetRepository:  repo,
+			ROpt:              defaultOptions,
+			MaxLayers:         5000,
+			FetchWorkers:      oci.DefaultFetchWorkers,
 		},
 	}}
 
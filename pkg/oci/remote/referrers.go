@@ -16,19 +16,78 @@
 package remote
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
-// Referrers fetches references using registry options.
+// Referrers fetches references using registry options. Registries without
+// the OCI 1.1 referrers API are queried through the fallback tag. An empty
+// artifactType returns every referrer.
 func Referrers(d name.Digest, artifactType string, opts ...Option) (*v1.IndexManifest, error) {
 	o := makeOptions(name.Repository{}, opts...)
 	rOpt := o.ROpt
-	rOpt = append(rOpt, remote.WithFilter("artifactType", artifactType))
+	if artifactType != "" {
+		rOpt = append(rOpt, remote.WithFilter("artifactType", artifactType))
+	}
 	idx, err := remote.Referrers(d, rOpt...)
 	if err != nil {
 		return nil, err
 	}
 	return idx.IndexManifest()
 }
+
+// Referrer is an artifact whose subject is an image, whether or not cosign
+// wrote it.
+type Referrer struct {
+	// Digest is where the referrer's manifest is stored.
+	Digest name.Digest
+	// ArtifactType is the artifact type of the referrer, if it has one.
+	ArtifactType string
+	// Layers are the digests of the referrer's layers, or of its manifests
+	// if the referrer is an index.
+	Layers []v1.Hash
+}
+
+// ListReferrers returns the referrers of d, as Referrers does, along with
+// the contents of each.
+func ListReferrers(d name.Digest, artifactType string, opts ...Option) ([]Referrer, error) {
+	idx, err := Referrers(d, artifactType, opts...)
+	if err != nil {
+		return nil, err
+	}
+	o := makeOptions(d.Repository, opts...)
+	referrers := make([]Referrer, 0, len(idx.Manifests))
+	for _, desc := range idx.Manifests {
+		r := Referrer{
+			Digest:       d.Context().Digest(desc.Digest.String()),
+			ArtifactType: desc.ArtifactType,
+		}
+		rd, err := o.get(r.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("fetching referrer %s: %w", r.Digest, err)
+		}
+		if rd.MediaType.IsIndex() {
+			var im v1.IndexManifest
+			if err := json.Unmarshal(rd.Manifest, &im); err != nil {
+				return nil, fmt.Errorf("parsing referrer %s: %w", r.Digest, err)
+			}
+			for _, m := range im.Manifests {
+				r.Layers = append(r.Layers, m.Digest)
+			}
+		} else {
+			var m v1.Manifest
+			if err := json.Unmarshal(rd.Manifest, &m); err != nil {
+				return nil, fmt.Errorf("parsing referrer %s: %w", r.Digest, err)
+			}
+			for _, l := range m.Layers {
+				r.Layers = append(r.Layers, l.Digest)
+			}
+		}
+		referrers = append(referrers, r)
+	}
+	return referrers, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	"github.com/franchb/cosign/v2/pkg/oci/ocitest"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	v1mutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestListReferrers(t *testing.T) {
+	const sbomType = "application/spdx+json"
+	for _, referrersAPI := range []bool{true, false} {
+		t.Run(fmt.Sprintf("referrersAPI=%v", referrersAPI), func(t *testing.T) {
+			var opts []ocitest.Option
+			if !referrersAPI {
+				opts = append(opts, ocitest.WithoutReferrers())
+			}
+			r := ocitest.New(t, opts...)
+
+			img, err := random.Image(300, 1)
+			if err != nil {
+				t.Fatalf("random.Image() = %v", err)
+			}
+			ref, err := name.ParseReference(r.Host() + "/repo:latest")
+			if err != nil {
+				t.Fatalf("ParseReference() = %v", err)
+			}
+			if err := remote.Write(ref, img); err != nil {
+				t.Fatalf("remote.Write() = %v", err)
+			}
+			desc, err := partial.Descriptor(img)
+			if err != nil {
+				t.Fatalf("Descriptor() = %v", err)
+			}
+			d := ref.Context().Digest(desc.Digest.String())
+
+			// A referrer that cosign did not write.
+			sbom, err := random.Image(100, 2)
+			if err != nil {
+				t.Fatalf("random.Image() = %v", err)
+			}
+			sbom = v1mutate.Subject(v1mutate.ConfigMediaType(sbom, types.MediaType(sbomType)), *desc).(v1.Image)
+			sbomDigest, err := sbom.Digest()
+			if err != nil {
+				t.Fatalf("Digest() = %v", err)
+			}
+			if err := remote.Write(ref.Context().Digest(sbomDigest.String()), sbom); err != nil {
+				t.Fatalf("remote.Write() = %v", err)
+			}
+
+			att, err := static.NewAttestation([]byte(`{"payloadType":"application/vnd.in-toto+json"}`))
+			if err != nil {
+				t.Fatalf("static.NewAttestation() = %v", err)
+			}
+			se, err := mutate.AttachAttestationToEntity(SignedUnknown(d), att)
+			if err != nil {
+				t.Fatalf("AttachAttestationToEntity() = %v", err)
+			}
+			if err := WriteAttestationsExperimentalOCI(d, se); err != nil {
+				t.Fatalf("WriteAttestationsExperimentalOCI() = %v", err)
+			}
+
+			all, err := ListReferrers(d, "")
+			if err != nil {
+				t.Fatalf("ListReferrers() = %v", err)
+			}
+			if len(all) != 2 {
+				t.Fatalf("ListReferrers() = %d referrers, wanted 2", len(all))
+			}
+
+			sboms, err := ListReferrers(d, sbomType)
+			if err != nil {
+				t.Fatalf("ListReferrers() = %v", err)
+			}
+			if len(sboms) != 1 {
+				t.Fatalf("ListReferrers(%q) = %d referrers, wanted 1", sbomType, len(sboms))
+			}
+			got := sboms[0]
+			if got.Digest.DigestStr() != sbomDigest.String() {
+				t.Errorf("Digest = %s, wanted %s", got.Digest.DigestStr(), sbomDigest)
+			}
+			if got.ArtifactType != sbomType {
+				t.Errorf("ArtifactType = %q, wanted %q", got.ArtifactType, sbomType)
+			}
+			if len(got.Layers) != 2 {
+				t.Errorf("Layers = %v, wanted 2 layers", got.Layers)
+			}
+
+			none, err := ListReferrers(d, "application/vnd.example.unknown")
+			if err != nil {
+				t.Fatalf("ListReferrers() = %v", err)
+			}
+			if len(none) != 0 {
+				t.Errorf("ListReferrers() = %v, wanted no referrers of an unknown type", none)
+			}
+		})
+	}
+}
@@ -56,11 +56,12 @@ func NewEntityNotFoundError(err error) error {
 }
 
 // SignedEntity provides access to a remote reference, and its signatures.
-// The SignedEntity will be one of SignedImage or SignedImageIndex.
+// The SignedEntity will be one of SignedImage or SignedImageIndex. A legacy
+// Docker schema 1 image is provided as a SignedImage, see SignedImage.
 func SignedEntity(ref name.Reference, options ...Option) (oci.SignedEntity, error) {
 	o := makeOptions(ref.Context(), options...)
 
-	got, err := remoteGet(ref, o.ROpt...)
+	got, err := o.get(ref)
 	var te *transport.Error
 	if errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
 		return nil, NewEntityNotFoundError(err)
@@ -90,6 +91,16 @@ func SignedEntity(ref name.Reference, options ...Option) (oci.SignedEntity, erro
 			opt:   o,
 		}, nil
 
+	case types.DockerManifestSchema1, types.DockerManifestSchema1Signed:
+		i, err := got.Schema1()
+		if err != nil {
+			return nil, err
+		}
+		return &image{
+			Image: i,
+			opt:   o,
+		}, nil
+
 	default:
 		return nil, fmt.Errorf("unknown mime type: %v", got.MediaType)
 	}
@@ -128,6 +139,13 @@ func SBOMTag(ref name.Reference, opts ...Option) (name.Tag, error) {
 	return suffixTag(ref, o.SBOMSuffix, "-", o)
 }
 
+// AttachmentTag returns the name.Tag that associates the attachment attName
+// with a particular digest.
+func AttachmentTag(ref name.Reference, attName string, opts ...Option) (name.Tag, error) {
+	o := makeOptions(ref.Context(), opts...)
+	return suffixTag(ref, attName, "-", o)
+}
+
 // DigestTag returns the name.Tag that associated SBOMs with a particular digest.
 func DigestTag(ref name.Reference, opts ...Option) (name.Tag, error) {
 	o := makeOptions(ref.Context(), opts...)
@@ -140,7 +158,7 @@ func DigestTag(ref name.Reference, opts ...Option) (name.Tag, error) {
 // and https://github.com/distribution/distribution/issues/1579
 func DockerContentDigest(ref name.Tag, opts ...Option) (name.Tag, error) {
 	o := makeOptions(ref.Context(), opts...)
-	desc, err := remoteGet(ref, o.ROpt...)
+	desc, err := o.get(ref)
 	if err != nil {
 		return name.Tag{}, err
 	}
@@ -157,7 +175,7 @@ func suffixTag(ref name.Reference, suffix string, algorithmSeparator string, o *
 			return name.Tag{}, err
 		}
 	} else {
-		desc, err := remoteGet(ref, o.ROpt...)
+		desc, err := o.get(ref)
 		if err != nil {
 			return name.Tag{}, err
 		}
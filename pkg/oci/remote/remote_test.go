@@ -99,6 +99,13 @@ func TestTagMethods(t *testing.T) {
 		fn:   SBOMTag,
 		ref:  name.MustParseReference("gcr.io/distroless/static@sha256:be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4"),
 		want: name.MustParseReference("gcr.io/distroless/static:sha256-be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4.sbom"),
+	}, {
+		name: "attachment passed a tag",
+		fn: func(ref name.Reference, opts ...Option) (name.Tag, error) {
+			return AttachmentTag(ref, "license", opts...)
+		},
+		ref:  name.MustParseReference("gcr.io/distroless/static:nonroot"),
+		want: name.MustParseReference("gcr.io/distroless/static:sha256-be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4.license"),
 	}}
 
 	for _, test := range tests {
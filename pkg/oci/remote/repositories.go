@@ -0,0 +1,124 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+)
+
+// RepositoryMapping maps image repositories to the repositories their
+// signatures and attestations are stored in, e.g. to keep the signatures of
+// images in mirrors in one central repository.
+type RepositoryMapping struct {
+	// Repositories are tried in order, the first whose Source matches an
+	// image repository deciding its target.
+	Repositories []RepositoryMappingRule `json:"repositories"`
+}
+
+// RepositoryMappingRule stores the signatures of the image repositories
+// matching Source in Target.
+type RepositoryMappingRule struct {
+	// Source is a pattern, in the syntax of path.Match, matched with the
+	// fully qualified name of an image repository, e.g.
+	// "mirror.example.com/*".
+	Source string `json:"source"`
+	// Target is the repository the signatures are stored in, e.g.
+	// "registry.example.com/signatures".
+	Target string `json:"target"`
+
+	target name.Repository
+}
+
+// LoadRepositoryMapping reads a RepositoryMapping from the JSON file at
+// path.
+func LoadRepositoryMapping(path string) (*RepositoryMapping, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseRepositoryMapping(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing repository mapping %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ParseRepositoryMapping parses a RepositoryMapping from JSON, checking its
+// patterns and target repositories.
+func ParseRepositoryMapping(b []byte) (*RepositoryMapping, error) {
+	m := &RepositoryMapping{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	for i, r := range m.Repositories {
+		if _, err := path.Match(r.Source, ""); err != nil {
+			return nil, fmt.Errorf("invalid source pattern %q: %w", r.Source, err)
+		}
+		target, err := name.NewRepository(r.Target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target repository %q: %w", r.Target, err)
+		}
+		m.Repositories[i].target = target
+	}
+	return m, nil
+}
+
+// Lookup returns the repository the signatures of repo are stored in, and
+// whether any rule matched. A repository that is itself the target of a
+// rule is never remapped, so that looking up a target returns it.
+func (m *RepositoryMapping) Lookup(repo name.Repository) (name.Repository, bool) {
+	for _, r := range m.Repositories {
+		if r.target.Name() == repo.Name() {
+			return name.Repository{}, false
+		}
+	}
+	for _, r := range m.Repositories {
+		// The patterns were checked when parsing.
+		if ok, _ := path.Match(r.Source, repo.Name()); ok {
+			return r.target, true
+		}
+	}
+	return name.Repository{}, false
+}
+
+// WithRepositoryMapping is a functional option for storing signatures and
+// attestations in the repository m maps the target repository to. It is
+// ignored if WithTargetRepository overrides the target repository.
+func WithRepositoryMapping(m *RepositoryMapping) Option {
+	return func(o *options) {
+		o.RepositoryMapping = m
+	}
+}
+
+// GetEnvRepositoryMapping returns the RepositoryMapping read from the file
+// named by $COSIGN_REPOSITORY_CONFIG, or nil if it is not set.
+func GetEnvRepositoryMapping() (*RepositoryMapping, error) {
+	p := env.Getenv(env.VariableRepositoryConfig)
+	if p == "" {
+		return nil, nil
+	}
+	m, err := LoadRepositoryMapping(p)
+	if err != nil {
+		return nil, fmt.Errorf("loading $%s: %w", env.VariableRepositoryConfig, err)
+	}
+	return m, nil
+}
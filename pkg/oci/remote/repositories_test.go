@@ -0,0 +1,112 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+const testDigest = "sha256:d34db33fd34db33fd34db33fd34db33fd34db33fd34db33fd34db33fd34db33f"
+
+func TestRepositoryMapping(t *testing.T) {
+	m, err := ParseRepositoryMapping([]byte(`{"repositories": [
+		{"source": "mirror.example.com/team-a/*", "target": "registry.example.com/team-a-signatures"},
+		{"source": "mirror.example.com/*/*", "target": "registry.example.com/signatures"}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseRepositoryMapping() = %v", err)
+	}
+
+	tests := []struct {
+		image string
+		want  string
+	}{{
+		image: "mirror.example.com/team-a/app",
+		want:  "registry.example.com/team-a-signatures",
+	}, {
+		image: "mirror.example.com/team-b/app",
+		want:  "registry.example.com/signatures",
+	}, {
+		image: "other.example.com/team-a/app",
+		want:  "other.example.com/team-a/app",
+	}, {
+		// Targets are not remapped, so signature tags resolve to themselves.
+		image: "registry.example.com/signatures",
+		want:  "registry.example.com/signatures",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.image, func(t *testing.T) {
+			ref, err := name.ParseReference(tc.image + "@" + testDigest)
+			if err != nil {
+				t.Fatalf("ParseReference() = %v", err)
+			}
+			tag, err := SignatureTag(ref, WithRepositoryMapping(m))
+			if err != nil {
+				t.Fatalf("SignatureTag() = %v", err)
+			}
+			if got := tag.Context().Name(); got != tc.want {
+				t.Errorf("SignatureTag() repository = %s, wanted %s", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("target repository override wins", func(t *testing.T) {
+		ref := name.MustParseReference("mirror.example.com/team-a/app@" + testDigest)
+		override := name.MustParseReference("override.example.com/sigs").Context()
+		tag, err := SignatureTag(ref, WithTargetRepository(override), WithRepositoryMapping(m))
+		if err != nil {
+			t.Fatalf("SignatureTag() = %v", err)
+		}
+		if got := tag.Context().Name(); got != override.Name() {
+			t.Errorf("SignatureTag() repository = %s, wanted %s", got, override.Name())
+		}
+	})
+}
+
+func TestParseRepositoryMappingErrors(t *testing.T) {
+	for _, b := range []string{
+		`{"repositories": [{"source": "[", "target": "registry.example.com/signatures"}]}`,
+		`{"repositories": [{"source": "*", "target": "bad$repo"}]}`,
+		`not json`,
+	} {
+		if _, err := ParseRepositoryMapping([]byte(b)); err == nil {
+			t.Errorf("ParseRepositoryMapping(%s) succeeded, wanted an error", b)
+		}
+	}
+}
+
+func TestGetEnvRepositoryMapping(t *testing.T) {
+	t.Setenv("COSIGN_REPOSITORY_CONFIG", "")
+	if m, err := GetEnvRepositoryMapping(); err != nil || m != nil {
+		t.Errorf("GetEnvRepositoryMapping() = %v, %v, wanted nil", m, err)
+	}
+
+	p := filepath.Join(t.TempDir(), "repositories.json")
+	if err := os.WriteFile(p, []byte(`{"repositories": [{"source": "*/*", "target": "registry.example.com/signatures"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COSIGN_REPOSITORY_CONFIG", p)
+	m, err := GetEnvRepositoryMapping()
+	if err != nil {
+		t.Fatalf("GetEnvRepositoryMapping() = %v", err)
+	}
+	if got, ok := m.Lookup(name.MustParseReference("ghcr.io/app").Context()); !ok || got.Name() != "registry.example.com/signatures" {
+		t.Errorf("Lookup() = %v, %v, wanted registry.example.com/signatures", got, ok)
+	}
+}
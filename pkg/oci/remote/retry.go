@@ -0,0 +1,133 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// DefaultRetryStatusCodes are the HTTP response codes retried by a
+// RetryPolicy that does not list any.
+var DefaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures how registry operations are retried when a
+// registry is flaky or rate limits requests.
+type RetryPolicy struct {
+	// MaxAttempts is how many times an operation is attempted in total.
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt. It doubles after
+	// each further failed attempt.
+	Backoff time.Duration
+	// StatusCodes are the HTTP response codes that are retried, defaulting
+	// to DefaultRetryStatusCodes.
+	StatusCodes []int
+}
+
+func (p *RetryPolicy) statusCodes() []int {
+	if len(p.StatusCodes) == 0 {
+		return DefaultRetryStatusCodes
+	}
+	return p.StatusCodes
+}
+
+// RemoteOptions returns the GGCR options applying p to the requests GGCR
+// makes on its own, such as blob uploads and lazy layer fetches.
+func (p *RetryPolicy) RemoteOptions() []remote.Option {
+	return []remote.Option{
+		remote.WithRetryBackoff(remote.Backoff{
+			Duration: p.Backoff,
+			Factor:   2.0,
+			Jitter:   0.1,
+			Steps:    p.MaxAttempts,
+		}),
+		remote.WithRetryStatusCodes(p.statusCodes()...),
+	}
+}
+
+// retryable reports whether err is a response code p retries or a network
+// error.
+func (p *RetryPolicy) retryable(err error) bool {
+	if terr := (&transport.Error{}); errors.As(err, &terr) {
+		return slices.Contains(p.statusCodes(), terr.StatusCode)
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr)
+}
+
+// WithRetryPolicy is a functional option for retrying registry operations
+// that fail with a retryable response code or a network error, waiting
+// longer after each attempt.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) {
+		o.RetryPolicy = &p
+	}
+}
+
+// withRetries calls f until it succeeds, fails with an error that
+// o.RetryPolicy does not retry, or the policy runs out of attempts.
+func withRetries[T any](o *options, f func() (T, error)) (T, error) {
+	v, err := f()
+	p := o.RetryPolicy
+	if p == nil {
+		return v, err
+	}
+	wait := p.Backoff
+	for attempt := 1; err != nil && attempt < p.MaxAttempts && p.retryable(err); attempt++ {
+		time.Sleep(wait)
+		wait *= 2
+		v, err = f()
+	}
+	return v, err
+}
+
+func (o *options) get(ref name.Reference) (*remote.Descriptor, error) {
+	return viaMirrors(o, ref, func(ref name.Reference, ropt []remote.Option) (*remote.Descriptor, error) {
+		return withRetries(o, func() (*remote.Descriptor, error) {
+			return remoteGet(ref, ropt...)
+		})
+	})
+}
+
+func (o *options) image(ref name.Reference) (v1.Image, error) {
+	return viaMirrors(o, ref, func(ref name.Reference, ropt []remote.Option) (v1.Image, error) {
+		return withRetries(o, func() (v1.Image, error) {
+			return remoteImage(ref, ropt...)
+		})
+	})
+}
+
+func (o *options) index(ref name.Reference) (v1.ImageIndex, error) {
+	return viaMirrors(o, ref, func(ref name.Reference, ropt []remote.Option) (v1.ImageIndex, error) {
+		return withRetries(o, func() (v1.ImageIndex, error) {
+			return remoteIndex(ref, ropt...)
+		})
+	})
+}
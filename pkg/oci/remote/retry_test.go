@@ -0,0 +1,93 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+func TestWithRetryPolicy(t *testing.T) {
+	rg := remoteGet
+	defer func() {
+		remoteGet = rg
+	}()
+
+	tag := name.MustParseReference("gcr.io/distroless/static:nonroot")
+	h := v1.Hash{
+		Algorithm: "sha256",
+		Hex:       "be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4",
+	}
+	policy := WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	})
+
+	tests := []struct {
+		name      string
+		failures  []int
+		opts      []Option
+		wantCalls int
+		wantErr   bool
+	}{{
+		name:      "rate limited then succeeds",
+		failures:  []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		opts:      []Option{policy},
+		wantCalls: 3,
+	}, {
+		name:      "gives up after max attempts",
+		failures:  []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		opts:      []Option{policy},
+		wantCalls: 3,
+		wantErr:   true,
+	}, {
+		name:      "not found is not retried",
+		failures:  []int{http.StatusNotFound},
+		opts:      []Option{policy},
+		wantCalls: 1,
+		wantErr:   true,
+	}, {
+		name:      "no retries without a policy",
+		failures:  []int{http.StatusTooManyRequests},
+		wantCalls: 1,
+		wantErr:   true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			remoteGet = func(_ name.Reference, _ ...remote.Option) (*remote.Descriptor, error) {
+				calls++
+				if calls <= len(tc.failures) {
+					return nil, &transport.Error{StatusCode: tc.failures[calls-1]}
+				}
+				return &remote.Descriptor{Descriptor: v1.Descriptor{Digest: h}}, nil
+			}
+
+			_, err := ResolveDigest(tag, tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ResolveDigest() = %v, wanted error: %v", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("remote.Get called %d times, wanted %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
@@ -18,23 +18,24 @@ package remote
 import (
 	"errors"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/empty"
 	"github.com/franchb/cosign/v2/pkg/oci/internal/signature"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
-const maxLayers = 1000
-
 // Signatures fetches the signatures image represented by the named reference.
 // If the tag is not found, this returns an empty oci.Signatures.
 func Signatures(ref name.Reference, opts ...Option) (oci.Signatures, error) {
 	o := makeOptions(ref.Context(), opts...)
-	img, err := remoteImage(ref, o.ROpt...)
+	img, err := o.cachedSignaturesImage(ref)
 	var te *transport.Error
 	if errors.As(err, &te) {
 		if te.StatusCode != http.StatusNotFound {
@@ -45,12 +46,25 @@ func Signatures(ref name.Reference, opts ...Option) (oci.Signatures, error) {
 		return nil, err
 	}
 	return &sigs{
-		Image: img,
+		Image:          img,
+		identityHashes: o.IdentityHashes,
+		predicateTypes: o.PredicateTypes,
+		annotations:    o.Annotations,
+		maxLayers:      o.MaxLayers,
+		fetchWorkers:   o.FetchWorkers,
 	}, nil
 }
 
 type sigs struct {
 	v1.Image
+
+	identityHashes []string
+	predicateTypes []string
+	annotations    map[string]string
+	maxLayers      int64
+	fetchWorkers   int
+	// skipped is the number of layers the last call to Get or Iterate skipped.
+	skipped int
 }
 
 // The wrapped Image implements ConfigLayer, but the wrapping hides that from typechecks in pkg/v1/remote.
@@ -59,25 +73,100 @@ func (s *sigs) ConfigLayer() (v1.Layer, error) {
 	return partial.ConfigLayer(s.Image)
 }
 
-var _ oci.Signatures = (*sigs)(nil)
+var _ oci.IterableSignatures = (*sigs)(nil)
 
 // Get implements oci.Signatures
 func (s *sigs) Get() ([]oci.Signature, error) {
+	descs, err := s.descriptors()
+	if err != nil {
+		return nil, err
+	}
+	return signature.Fetch(descs, s.Image.LayerByDigest, s.fetchWorkers)
+}
+
+// Annotations implements oci.Signatures
+func (s *sigs) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(s.Image)
+}
+
+// Subject implements oci.Signatures
+func (s *sigs) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(s.Image)
+}
+
+// Iterate implements oci.IterableSignatures
+func (s *sigs) Iterate() (oci.SignatureIterator, error) {
+	descs, err := s.descriptors()
+	if err != nil {
+		return nil, err
+	}
+	return signature.Iterate(descs, s.Image.LayerByDigest), nil
+}
+
+// descriptors returns the descriptors of the layers that pass the configured
+// filters, recording how many were skipped by their index annotations.
+func (s *sigs) descriptors() ([]v1.Descriptor, error) {
 	m, err := s.Manifest()
 	if err != nil {
 		return nil, err
 	}
 	numLayers := int64(len(m.Layers))
-	if numLayers > maxLayers {
-		return nil, oci.NewMaxLayersExceeded(numLayers, maxLayers)
+	if numLayers > s.maxLayers {
+		return nil, oci.NewMaxLayersExceeded(numLayers, s.maxLayers)
 	}
-	signatures := make([]oci.Signature, 0, len(m.Layers))
+	descs := make([]v1.Descriptor, 0, len(m.Layers))
+	s.skipped = 0
 	for _, desc := range m.Layers {
-		layer, err := s.Image.LayerByDigest(desc.Digest)
-		if err != nil {
-			return nil, err
+		if !s.hasAnnotations(desc) {
+			continue
+		}
+		if !s.matches(desc) {
+			s.skipped++
+			continue
+		}
+		descs = append(descs, desc)
+	}
+	return descs, nil
+}
+
+// SkippedByIndex returns the number of layers that the last call to Get or
+// Iterate on s
+// skipped because their index annotations did not match the filters set with
+// WithIdentityHashFilter or WithPredicateTypeFilter.
+func SkippedByIndex(s oci.Signatures) int {
+	if sg, ok := s.(*sigs); ok {
+		return sg.skipped
+	}
+	return 0
+}
+
+// hasAnnotations reports whether desc holds every annotation set with
+// WithAnnotationFilter.
+func (s *sigs) hasAnnotations(desc v1.Descriptor) bool {
+	for k, v := range s.annotations {
+		if got, ok := desc.Annotations[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether the layer described by desc may satisfy the
+// configured filters, judging only by its index annotations. Layers lacking
+// an annotation are kept so that signatures written by older clients are
+// still considered.
+func (s *sigs) matches(desc v1.Descriptor) bool {
+	if v, ok := desc.Annotations[static.IdentityHashAnnotationKey]; ok && len(s.identityHashes) > 0 {
+		if !slices.ContainsFunc(strings.Split(v, ","), func(h string) bool {
+			return slices.Contains(s.identityHashes, h)
+		}) {
+			return false
+		}
+	}
+	if v, ok := desc.Annotations[static.PredicateTypeAnnotationKey]; ok && len(s.predicateTypes) > 0 {
+		if !slices.Contains(s.predicateTypes, v) {
+			return false
 		}
-		signatures = append(signatures, signature.New(layer, desc))
 	}
-	return signatures, nil
+	return true
 }
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/fake"
@@ -96,5 +97,179 @@ func TestSignaturesErrors(t *testing.T) {
 		if err == nil || want.Error() != err.Error() {
 			t.Fatalf("Get() = %v", err)
 		}
+
+		sigs, err = Signatures(name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.sig"), WithMaxLayers(10000))
+		if err != nil {
+			t.Fatalf("Signatures() = %v", err)
+		}
+		if _, err := sigs.Get(); err != nil {
+			t.Fatalf("Get() with WithMaxLayers(10000) = %v", err)
+		}
+
+		t.Setenv("COSIGN_MAX_SIGNATURE_LAYERS", "500")
+		sigs, err = Signatures(name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.sig"))
+		if err != nil {
+			t.Fatalf("Signatures() = %v", err)
+		}
+		want = errors.New("number of layers (10000) exceeded the limit (500)")
+		_, err = sigs.Get()
+		if err == nil || want.Error() != err.Error() {
+			t.Fatalf("Get() = %v", err)
+		}
+	})
+}
+
+func TestSignaturesFilter(t *testing.T) {
+	ri := remote.Image
+	t.Cleanup(func() {
+		remoteImage = ri
 	})
+
+	alice := static.IdentityHash("https://accounts.example.com", "alice@example.com")
+	bob := static.IdentityHash("https://accounts.example.com", "bob@example.com")
+	layer, err := static.NewSignature(nil, "")
+	if err != nil {
+		t.Fatalf("static.NewSignature() = %v", err)
+	}
+	remoteImage = func(_ name.Reference, _ ...remote.Option) (v1.Image, error) {
+		img := &fake.FakeImage{
+			ManifestStub: func() (*v1.Manifest, error) {
+				return &v1.Manifest{
+					Layers: []v1.Descriptor{{
+						Annotations: map[string]string{static.IdentityHashAnnotationKey: alice},
+					}, {
+						Annotations: map[string]string{static.IdentityHashAnnotationKey: bob + "," + alice},
+					}, {
+						Annotations: map[string]string{static.IdentityHashAnnotationKey: bob},
+					}, {
+						Annotations: map[string]string{static.PredicateTypeAnnotationKey: "https://slsa.dev/provenance/v1"},
+					}, {
+						// Written by an older client, so not indexed.
+					}},
+				}, nil
+			},
+		}
+		img.LayerByDigestReturns(layer, nil)
+		return img, nil
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{{
+		name: "no filter",
+		want: 5,
+	}, {
+		name: "identity filter",
+		opts: []Option{WithIdentityHashFilter(alice)},
+		want: 4,
+	}, {
+		name: "predicate type filter",
+		opts: []Option{WithPredicateTypeFilter("https://cosign.sigstore.dev/attestation/v1")},
+		want: 4,
+	}, {
+		name: "both filters",
+		opts: []Option{WithIdentityHashFilter(bob), WithPredicateTypeFilter("https://slsa.dev/provenance/v1")},
+		want: 4,
+	}, {
+		name: "filters disabled",
+		opts: []Option{WithIdentityHashFilter(bob), WithoutIndexFilters()},
+		want: 5,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sigs, err := Signatures(name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.sig"), tc.opts...)
+			if err != nil {
+				t.Fatalf("Signatures() = %v", err)
+			}
+			sl, err := sigs.Get()
+			if err != nil {
+				t.Fatalf("Get() = %v", err)
+			}
+			if len(sl) != tc.want {
+				t.Errorf("len(Get()) = %d, wanted %d", len(sl), tc.want)
+			}
+			if got, want := SkippedByIndex(sigs), 5-tc.want; got != want {
+				t.Errorf("SkippedByIndex() = %d, wanted %d", got, want)
+			}
+		})
+	}
+}
+
+func TestSignaturesAnnotationFilter(t *testing.T) {
+	ri := remote.Image
+	t.Cleanup(func() {
+		remoteImage = ri
+	})
+
+	layer, err := static.NewSignature(nil, "")
+	if err != nil {
+		t.Fatalf("static.NewSignature() = %v", err)
+	}
+	remoteImage = func(_ name.Reference, _ ...remote.Option) (v1.Image, error) {
+		img := &fake.FakeImage{
+			ManifestStub: func() (*v1.Manifest, error) {
+				return &v1.Manifest{
+					Layers: []v1.Descriptor{{
+						Annotations: map[string]string{static.PredicateTypeAnnotationKey: "https://spdx.dev/Document", "team": "a"},
+					}, {
+						Annotations: map[string]string{static.PredicateTypeAnnotationKey: "https://spdx.dev/Document", "team": "b"},
+					}, {
+						Annotations: map[string]string{static.PredicateTypeAnnotationKey: "https://slsa.dev/provenance/v1"},
+					}, {
+						// Written by an older client, so not annotated.
+					}},
+				}, nil
+			},
+		}
+		img.LayerByDigestReturns(layer, nil)
+		return img, nil
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{{
+		name: "no filter",
+		want: 4,
+	}, {
+		name: "one annotation",
+		opts: []Option{WithAnnotationFilter(map[string]string{static.PredicateTypeAnnotationKey: "https://spdx.dev/Document"})},
+		want: 2,
+	}, {
+		name: "every annotation must match",
+		opts: []Option{WithAnnotationFilter(map[string]string{static.PredicateTypeAnnotationKey: "https://spdx.dev/Document", "team": "b"})},
+		want: 1,
+	}, {
+		name: "filters are merged",
+		opts: []Option{
+			WithAnnotationFilter(map[string]string{static.PredicateTypeAnnotationKey: "https://spdx.dev/Document"}),
+			WithAnnotationFilter(map[string]string{"team": "a"}),
+		},
+		want: 1,
+	}, {
+		name: "kept without index filters",
+		opts: []Option{WithAnnotationFilter(map[string]string{"team": "c"}), WithoutIndexFilters()},
+		want: 0,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sigs, err := Signatures(name.MustParseReference("gcr.io/distroless/static:sha256-deadbeef.att"), tc.opts...)
+			if err != nil {
+				t.Fatalf("Signatures() = %v", err)
+			}
+			sl, err := sigs.Get()
+			if err != nil {
+				t.Fatalf("Get() = %v", err)
+			}
+			if len(sl) != tc.want {
+				t.Errorf("len(Get()) = %d, wanted %d", len(sl), tc.want)
+			}
+			if got := SkippedByIndex(sigs); got != 0 {
+				t.Errorf("SkippedByIndex() = %d, wanted 0", got)
+			}
+		})
+	}
 }
@@ -58,3 +58,15 @@ func (i *unknown) Attestations() (oci.Signatures, error) {
 func (i *unknown) Attachment(name string) (oci.File, error) {
 	return attachment(i, name, i.opt)
 }
+
+// Annotations implements oci.SignedEntity. The manifest of an unknown entity
+// is never read, so it has no annotations.
+func (*unknown) Annotations() (map[string]string, error) {
+	return nil, nil
+}
+
+// Subject implements oci.SignedEntity. The manifest of an unknown entity is
+// never read, so it has no subject.
+func (*unknown) Subject() (*v1.Descriptor, error) {
+	return nil, nil
+}
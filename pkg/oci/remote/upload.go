@@ -0,0 +1,355 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// defaultUploadRetryPolicy is how chunk uploads are retried when no
+// RetryPolicy is given.
+var defaultUploadRetryPolicy = RetryPolicy{MaxAttempts: 5, Backoff: time.Second}
+
+// ChunkedUploads configures uploading layers in chunks, so that an upload
+// interrupted by a retryable error resumes from the last chunk the registry
+// received instead of starting over.
+type ChunkedUploads struct {
+	// ChunkSize is the largest number of bytes sent in a single request.
+	ChunkSize int64
+	// Keychain resolves the credentials for the registry, defaulting to
+	// authn.DefaultKeychain.
+	Keychain authn.Keychain
+	// Transport sends the upload requests, defaulting to
+	// remote.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// WithChunkedUploads is a functional option for uploading the layers of
+// signatures, attestations and SBOMs in chunks, see ChunkedUploads. Failed
+// chunks are retried according to the RetryPolicy, if any, and otherwise up
+// to five times.
+func WithChunkedUploads(ctx context.Context, c ChunkedUploads) Option {
+	return func(o *options) {
+		o.UploadContext = ctx
+		o.ChunkedUploads = &c
+	}
+}
+
+// UploadLayers uploads the layers of img to repo in chunks if
+// WithChunkedUploads is given, so that writing img afterwards finds them
+// present. Without WithChunkedUploads it does nothing, leaving the layers to
+// be uploaded with img.
+func UploadLayers(repo name.Repository, img v1.Image, opts ...Option) error {
+	return makeOptions(repo, opts...).uploadLayers(repo, img)
+}
+
+func (o *options) uploadLayers(repo name.Repository, img v1.Image) error {
+	if o.ChunkedUploads == nil {
+		return nil
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	for _, l := range layers {
+		if err := o.uploadLayer(repo, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeImage writes img to ref, uploading its layers in chunks first if
+// o.ChunkedUploads is set.
+func (o *options) writeImage(ref name.Reference, img v1.Image) error {
+	if err := o.uploadLayers(ref.Context(), img); err != nil {
+		return err
+	}
+	return remoteWrite(ref, img, o.ROpt...)
+}
+
+// writeLayer writes l to repo, in chunks if o.ChunkedUploads is set.
+func (o *options) writeLayer(repo name.Repository, l v1.Layer) error {
+	if o.ChunkedUploads != nil {
+		return o.uploadLayer(repo, l)
+	}
+	return remote.WriteLayer(repo, l, o.ROpt...)
+}
+
+// uploadLayer uploads l to repo in chunks of o.ChunkedUploads.ChunkSize,
+// unless the registry already has it.
+func (o *options) uploadLayer(repo name.Repository, l v1.Layer) error {
+	digest, err := l.Digest()
+	if err != nil {
+		return err
+	}
+	size, err := l.Size()
+	if err != nil {
+		return err
+	}
+	u, err := o.newUploader(repo)
+	if err != nil {
+		return err
+	}
+	if ok, err := u.exists(digest); err != nil || ok {
+		return err
+	}
+
+	policy := defaultUploadRetryPolicy
+	if o.RetryPolicy != nil {
+		policy = *o.RetryPolicy
+	}
+	chunks := &chunkReader{layer: l, size: o.ChunkedUploads.ChunkSize}
+	defer chunks.close()
+
+	location, err := u.start()
+	if err != nil {
+		return err
+	}
+	var offset int64
+	failures, wait := 0, policy.Backoff
+	for offset < size {
+		chunk, err := chunks.at(offset)
+		if err != nil {
+			return err
+		}
+		next, err := u.patch(location, offset, chunk)
+		if err == nil {
+			location, offset = next, offset+int64(len(chunk))
+			failures, wait = 0, policy.Backoff
+			continue
+		}
+		failures++
+		if !policy.retryable(err) || failures >= policy.MaxAttempts {
+			return fmt.Errorf("uploading %s to %s at offset %d: %w", digest, repo, offset, err)
+		}
+		time.Sleep(wait)
+		wait *= 2
+		// Find out how much of the chunk the registry received. If it
+		// cannot tell, start over.
+		if location, offset, err = u.status(location); err != nil {
+			if location, err = u.start(); err != nil {
+				return err
+			}
+			offset = 0
+		}
+	}
+	return u.commit(location, digest)
+}
+
+// uploader makes the requests of the chunked upload protocol of the OCI
+// distribution spec.
+type uploader struct {
+	ctx    context.Context
+	client *http.Client
+	repo   name.Repository
+}
+
+func (o *options) newUploader(repo name.Repository) (*uploader, error) {
+	ctx := o.UploadContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	kc := o.ChunkedUploads.Keychain
+	if kc == nil {
+		kc = authn.DefaultKeychain
+	}
+	auth, err := authn.Resolve(ctx, kc, repo)
+	if err != nil {
+		return nil, err
+	}
+	rt := o.ChunkedUploads.Transport
+	if rt == nil {
+		rt = remote.DefaultTransport
+	}
+	t, err := transport.NewWithContext(ctx, repo.Registry, auth, rt, []string{repo.Scope(transport.PushScope)})
+	if err != nil {
+		return nil, err
+	}
+	return &uploader{ctx: ctx, client: &http.Client{Transport: t}, repo: repo}, nil
+}
+
+func (u *uploader) url(path string) *url.URL {
+	return &url.URL{
+		Scheme: u.repo.Scheme(),
+		Host:   u.repo.RegistryStr(),
+		Path:   fmt.Sprintf("/v2/%s/blobs/%s", u.repo.RepositoryStr(), path),
+	}
+}
+
+func (u *uploader) do(method string, target *url.URL, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(u.ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return u.client.Do(req)
+}
+
+// exists reports whether the registry already has the blob digest.
+func (u *uploader) exists(digest v1.Hash) (bool, error) {
+	resp, err := u.do(http.MethodHead, u.url(digest.String()), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// start opens an upload session and returns its location.
+func (u *uploader) start() (*url.URL, error) {
+	target := u.url("uploads/")
+	resp, err := u.do(http.MethodPost, target, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := transport.CheckError(resp, http.StatusAccepted); err != nil {
+		return nil, err
+	}
+	return location(target, resp)
+}
+
+// patch sends chunk, which starts at offset, and returns the location to
+// continue the upload at.
+func (u *uploader) patch(at *url.URL, offset int64, chunk []byte) (*url.URL, error) {
+	resp, err := u.do(http.MethodPatch, at, chunk, http.Header{
+		"Content-Type":  {"application/octet-stream"},
+		"Content-Range": {fmt.Sprintf("%d-%d", offset, offset+int64(len(chunk))-1)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := transport.CheckError(resp, http.StatusAccepted, http.StatusNoContent); err != nil {
+		return nil, err
+	}
+	return location(at, resp)
+}
+
+// status returns the location to continue the upload at and how many bytes
+// the registry has received.
+func (u *uploader) status(at *url.URL) (*url.URL, int64, error) {
+	resp, err := u.do(http.MethodGet, at, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if err := transport.CheckError(resp, http.StatusNoContent); err != nil {
+		return nil, 0, err
+	}
+	next, err := location(at, resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Range is the inclusive range of bytes received, such as "0-1023".
+	r := resp.Header.Get("Range")
+	_, end, ok := strings.Cut(strings.TrimPrefix(r, "bytes="), "-")
+	n, err := strconv.ParseInt(end, 10, 64)
+	if !ok || err != nil {
+		return nil, 0, fmt.Errorf("parsing upload range %q", r)
+	}
+	return next, n + 1, nil
+}
+
+// commit completes the upload as the blob digest.
+func (u *uploader) commit(at *url.URL, digest v1.Hash) error {
+	target := *at
+	q := target.Query()
+	q.Set("digest", digest.String())
+	target.RawQuery = q.Encode()
+	resp, err := u.do(http.MethodPut, &target, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return transport.CheckError(resp, http.StatusCreated)
+}
+
+// location resolves the Location of resp against the URL it was sent to.
+func location(base *url.URL, resp *http.Response) (*url.URL, error) {
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return nil, errors.New("registry did not return an upload location")
+	}
+	return base.Parse(loc)
+}
+
+// chunkReader reads the compressed contents of a layer in chunks, keeping
+// the last chunk so that it can be sent again.
+type chunkReader struct {
+	layer v1.Layer
+	size  int64
+
+	rc    io.ReadCloser
+	pos   int64
+	start int64
+	buf   []byte
+}
+
+// at returns the chunk starting at offset, which is at most size bytes and
+// ends with the current chunk.
+func (c *chunkReader) at(offset int64) ([]byte, error) {
+	if offset >= c.start && offset < c.start+int64(len(c.buf)) {
+		return c.buf[offset-c.start:], nil
+	}
+	if c.rc == nil || offset < c.pos {
+		c.close()
+		rc, err := c.layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		c.rc, c.pos = rc, 0
+	}
+	if _, err := io.CopyN(io.Discard, c.rc, offset-c.pos); err != nil {
+		return nil, err
+	}
+	if c.buf == nil {
+		c.buf = make([]byte, c.size)
+	}
+	n, err := io.ReadFull(c.rc, c.buf[:cap(c.buf)])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	c.buf, c.start, c.pos = c.buf[:n], offset, offset+int64(n)
+	if n == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return c.buf, nil
+}
+
+func (c *chunkReader) close() {
+	if c.rc != nil {
+		c.rc.Close()
+		c.rc = nil
+	}
+}
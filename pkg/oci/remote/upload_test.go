@@ -0,0 +1,173 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// flakyUploads wraps a registry, answering upload status requests, which
+// the registry does not support, and failing the response to the PATCH
+// numbered failPatch after the registry has received the chunk.
+type flakyUploads struct {
+	registry  http.Handler
+	failPatch int
+
+	mu       sync.Mutex
+	patches  int
+	received map[string]string
+}
+
+func (f *flakyUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	isUpload := strings.Contains(r.URL.Path, "/blobs/uploads/") && !strings.HasSuffix(r.URL.Path, "/uploads/")
+	switch {
+	case r.Method == http.MethodGet && isUpload:
+		w.Header().Set("Location", r.URL.Path)
+		w.Header().Set("Range", f.received[r.URL.Path])
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPatch:
+		f.patches++
+		rec := httptest.NewRecorder()
+		f.registry.ServeHTTP(rec, r)
+		if rec.Code < http.StatusMultipleChoices {
+			f.received[rec.Header().Get("Location")] = rec.Header().Get("Range")
+		}
+		if f.patches == f.failPatch {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		_, _ = io.Copy(w, rec.Body)
+	default:
+		f.registry.ServeHTTP(w, r)
+	}
+}
+
+func TestUploadLayersChunked(t *testing.T) {
+	f := &flakyUploads{
+		registry:  registry.New(registry.Logger(log.New(io.Discard, "", 0))),
+		failPatch: 3,
+		received:  map[string]string{},
+	}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	repo, err := name.NewRepository(strings.TrimPrefix(s.URL, "http://") + "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(10*1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := layers[0].Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []Option{
+		WithChunkedUploads(context.Background(), ChunkedUploads{ChunkSize: 1024}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}),
+	}
+	if err := UploadLayers(repo, img, opts...); err != nil {
+		t.Fatalf("UploadLayers() = %v", err)
+	}
+
+	// The chunk whose response was lost had reached the registry, so the
+	// upload resumed after it rather than sending it again.
+	if want := int((size + 1023) / 1024); f.patches != want {
+		t.Errorf("uploaded in %d chunks, wanted %d", f.patches, want)
+	}
+	digest, err := layers[0].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := remote.Layer(repo.Digest(digest.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := uploaded.Compressed()
+	if err != nil {
+		t.Fatalf("layer was not uploaded: %v", err)
+	}
+	// Reading the layer verifies its digest.
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		t.Errorf("reading the uploaded layer: %v", err)
+	}
+	rc.Close()
+
+	// Uploading the layer again finds it present.
+	f.patches = 0
+	if err := UploadLayers(repo, img, opts...); err != nil {
+		t.Fatalf("UploadLayers() = %v", err)
+	}
+	if f.patches != 0 {
+		t.Errorf("uploaded a present layer in %d chunks", f.patches)
+	}
+}
+
+func TestUploadLayersGivesUp(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", r.URL.Path+"id")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPatch:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	repo, err := name.NewRepository(strings.TrimPrefix(s.URL, "http://") + "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = UploadLayers(repo, img,
+		WithChunkedUploads(context.Background(), ChunkedUploads{ChunkSize: 512}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprint(http.StatusServiceUnavailable)) {
+		t.Errorf("UploadLayers() = %v, wanted the upload to fail", err)
+	}
+}
@@ -18,7 +18,9 @@ package remote
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 
 	ociexperimental "github.com/franchb/cosign/v2/internal/pkg/oci/remote"
@@ -27,6 +29,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 )
@@ -56,7 +59,7 @@ func WriteSignedImageIndexImages(ref name.Reference, sii oci.SignedImageIndex, o
 		return fmt.Errorf("signed image: %w", err)
 	}
 	if si != nil {
-		if err := remoteWrite(ref, si, o.ROpt...); err != nil {
+		if err := o.writeImage(ref, si); err != nil {
 			return fmt.Errorf("remote write: %w", err)
 		}
 	}
@@ -71,7 +74,7 @@ func WriteSignedImageIndexImages(ref name.Reference, sii oci.SignedImageIndex, o
 		if err != nil {
 			return fmt.Errorf("sigs tag: %w", err)
 		}
-		if err := remoteWrite(sigsTag, sigs, o.ROpt...); err != nil {
+		if err := o.writeImage(sigsTag, sigs); err != nil {
 			return err
 		}
 	}
@@ -86,7 +89,7 @@ func WriteSignedImageIndexImages(ref name.Reference, sii oci.SignedImageIndex, o
 		if err != nil {
 			return fmt.Errorf("sigs tag: %w", err)
 		}
-		return remoteWrite(attsTag, atts, o.ROpt...)
+		return o.writeImage(attsTag, atts)
 	}
 	return nil
 }
@@ -110,7 +113,7 @@ func WriteSignatures(repo name.Repository, se oci.SignedEntity, opts ...Option)
 	tag := o.TargetRepository.Tag(normalize(h, o.TagPrefix, o.SignatureSuffix))
 
 	// Write the Signatures image to the tag, with the provided remote.Options
-	return remoteWrite(tag, sigs, o.ROpt...)
+	return o.writeImage(tag, sigs)
 }
 
 // WriteAttestations publishes the attestations attached to the given entity
@@ -132,12 +135,74 @@ func WriteAttestations(repo name.Repository, se oci.SignedEntity, opts ...Option
 	tag := o.TargetRepository.Tag(normalize(h, o.TagPrefix, o.AttestationSuffix))
 
 	// Write the Signatures image to the tag, with the provided remote.Options
-	return remoteWrite(tag, atts, o.ROpt...)
+	return o.writeImage(tag, atts)
 }
 
 // WriteSignaturesExperimentalOCI publishes the signatures attached to the given entity
 // into the provided repository (using OCI 1.1 methods).
 func WriteSignaturesExperimentalOCI(d name.Digest, se oci.SignedEntity, opts ...Option) error {
+	sigs, err := se.Signatures()
+	if err != nil {
+		return err
+	}
+	return writeReferrer(d, sigs, "sig", "signature", opts...)
+}
+
+// WriteAttestationsExperimentalOCI publishes the attestations attached to the
+// given entity into the provided repository (using OCI 1.1 methods).
+func WriteAttestationsExperimentalOCI(d name.Digest, se oci.SignedEntity, opts ...Option) error {
+	atts, err := se.Attestations()
+	if err != nil {
+		return err
+	}
+	return writeReferrer(d, atts, "att", "attestation", opts...)
+}
+
+// WriteSignaturesReferrers publishes the signatures attached to the given
+// entity as an OCI 1.1 referrer of d. If the registry rejects manifests with a
+// subject, the signatures are published to the signature tag as
+// WriteSignatures does.
+func WriteSignaturesReferrers(d name.Digest, se oci.SignedEntity, opts ...Option) error {
+	err := WriteSignaturesExperimentalOCI(d, se, opts...)
+	if !referrersUnsupported(err) {
+		return err
+	}
+	// TODO: use ui.Warnf
+	fmt.Fprintf(os.Stderr, "WARNING: registry does not accept referrers (%v), pushing signatures to the signature tag instead.\n", err)
+	return WriteSignatures(d.Repository, se, opts...)
+}
+
+// WriteAttestationsReferrers publishes the attestations attached to the given
+// entity as an OCI 1.1 referrer of d. If the registry rejects manifests with a
+// subject, the attestations are published to the attestation tag as
+// WriteAttestations does.
+func WriteAttestationsReferrers(d name.Digest, se oci.SignedEntity, opts ...Option) error {
+	err := WriteAttestationsExperimentalOCI(d, se, opts...)
+	if !referrersUnsupported(err) {
+		return err
+	}
+	// TODO: use ui.Warnf
+	fmt.Fprintf(os.Stderr, "WARNING: registry does not accept referrers (%v), pushing attestations to the attestation tag instead.\n", err)
+	return WriteAttestations(d.Repository, se, opts...)
+}
+
+// referrersUnsupported reports whether err is how registries predating OCI
+// 1.1 reject a manifest with a subject or an artifact config media type.
+func referrersUnsupported(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	switch terr.StatusCode {
+	case http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusUnsupportedMediaType:
+		return true
+	}
+	return false
+}
+
+// writeReferrer publishes sigs as a manifest whose subject is d, with the
+// artifactType of the attachment attName. kind names the content in messages.
+func writeReferrer(d name.Digest, sigs oci.Signatures, attName, kind string, opts ...Option) error {
 	o := makeOptions(d.Repository, opts...)
 	signTarget := d.String()
 	ref, err := name.ParseReference(signTarget, o.NameOpts...)
@@ -148,10 +213,6 @@ func WriteSignaturesExperimentalOCI(d name.Digest, se oci.SignedEntity, opts ...
 	if err != nil {
 		return err
 	}
-	sigs, err := se.Signatures()
-	if err != nil {
-		return err
-	}
 
 	// Write the signature blobs
 	s, err := sigs.Get()
@@ -159,7 +220,7 @@ func WriteSignaturesExperimentalOCI(d name.Digest, se oci.SignedEntity, opts ...
 		return err
 	}
 	for _, v := range s {
-		if err := remote.WriteLayer(d.Repository, v, o.ROpt...); err != nil {
+		if err := o.writeLayer(d.Repository, v); err != nil {
 			return err
 		}
 	}
@@ -188,7 +249,7 @@ func WriteSignaturesExperimentalOCI(d name.Digest, se oci.SignedEntity, opts ...
 		return err
 	}
 
-	artifactType := ociexperimental.ArtifactType("sig")
+	artifactType := ociexperimental.ArtifactType(attName)
 	m.Config.MediaType = types.MediaType(artifactType)
 	m.Subject = desc
 	b, err = json.Marshal(&m)
@@ -203,9 +264,13 @@ func WriteSignaturesExperimentalOCI(d name.Digest, se oci.SignedEntity, opts ...
 	if err != nil {
 		return err
 	}
+	layerMediaType := types.MediaType(ctypes.SimpleSigningMediaType)
+	if len(m.Layers) > 0 {
+		layerMediaType = m.Layers[0].MediaType
+	}
 	// TODO: use ui.Infof
-	fmt.Fprintf(os.Stderr, "Uploading signature for [%s] to [%s] with config.mediaType [%s] layers[0].mediaType [%s].\n",
-		d.String(), targetRef.String(), artifactType, ctypes.SimpleSigningMediaType)
+	fmt.Fprintf(os.Stderr, "Uploading %s for [%s] to [%s] with config.mediaType [%s] layers[0].mediaType [%s].\n",
+		kind, d.String(), targetRef.String(), artifactType, layerMediaType)
 	return remote.Put(targetRef, &taggableManifest{raw: b, mediaType: m.MediaType}, o.ROpt...)
 }
 
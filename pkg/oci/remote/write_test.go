@@ -16,13 +16,21 @@
 package remote
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	ociexperimental "github.com/franchb/cosign/v2/internal/pkg/oci/remote"
 	"github.com/franchb/cosign/v2/pkg/oci/mutate"
 	"github.com/franchb/cosign/v2/pkg/oci/signed"
 	"github.com/franchb/cosign/v2/pkg/oci/static"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -111,3 +119,79 @@ func TestWriteAttestations(t *testing.T) {
 		t.Fatalf("WriteAttestations() = %v", err)
 	}
 }
+
+func TestWriteAttestationsReferrers(t *testing.T) {
+	for _, rejectSubject := range []bool{false, true} {
+		t.Run(fmt.Sprintf("rejectSubject=%v", rejectSubject), func(t *testing.T) {
+			reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+			// Registries predating OCI 1.1 reject manifests with a subject.
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if rejectSubject && r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/") {
+					body, err := io.ReadAll(r.Body)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					if bytes.Contains(body, []byte(`"subject"`)) {
+						http.Error(w, `{"errors":[{"code":"MANIFEST_INVALID"}]}`, http.StatusBadRequest)
+						return
+					}
+					r.Body = io.NopCloser(bytes.NewReader(body))
+				}
+				reg.ServeHTTP(w, r)
+			}))
+			defer s.Close()
+
+			img, err := random.Image(300, 1)
+			if err != nil {
+				t.Fatalf("random.Image() = %v", err)
+			}
+			ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/repo:latest")
+			if err != nil {
+				t.Fatalf("ParseReference() = %v", err)
+			}
+			if err := remote.Write(ref, img); err != nil {
+				t.Fatalf("remote.Write() = %v", err)
+			}
+			h, err := img.Digest()
+			if err != nil {
+				t.Fatalf("Digest() = %v", err)
+			}
+			d := ref.Context().Digest(h.String())
+
+			att, err := static.NewAttestation([]byte(`{"payloadType":"application/vnd.in-toto+json"}`))
+			if err != nil {
+				t.Fatalf("static.NewAttestation() = %v", err)
+			}
+			se, err := mutate.AttachAttestationToEntity(SignedUnknown(d), att)
+			if err != nil {
+				t.Fatalf("AttachAttestationToEntity() = %v", err)
+			}
+			if err := WriteAttestationsReferrers(d, se); err != nil {
+				t.Fatalf("WriteAttestationsReferrers() = %v", err)
+			}
+
+			idx, err := Referrers(d, ociexperimental.ArtifactType("att"))
+			if err != nil {
+				t.Fatalf("Referrers() = %v", err)
+			}
+			wantReferrers := 1
+			if rejectSubject {
+				wantReferrers = 0
+			}
+			if got := len(idx.Manifests); got != wantReferrers {
+				t.Errorf("got %d referrers, wanted %d", got, wantReferrers)
+			}
+			attTag, err := AttestationTag(d)
+			if err != nil {
+				t.Fatalf("AttestationTag() = %v", err)
+			}
+			_, err = remote.Head(attTag)
+			if rejectSubject && err != nil {
+				t.Errorf("attestation tag was not written on fallback: %v", err)
+			} else if !rejectSubject && err == nil {
+				t.Error("attestation tag was written although the registry accepted the referrer")
+			}
+		})
+	}
+}
@@ -26,8 +26,10 @@ import (
 	payloadsize "github.com/franchb/cosign/v2/internal/pkg/cosign/payload/size"
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	ctypes "github.com/franchb/cosign/v2/pkg/types"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
@@ -73,6 +75,14 @@ func (s *sigLayer) Payload() ([]byte, error) {
 		return nil, err
 	}
 	defer r.Close()
+	if strings.HasSuffix(string(s.desc.MediaType), ctypes.ZstdMediaTypeSuffix) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer d.Close()
+		return payloadsize.ReadAll(d)
+	}
 	payload, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
@@ -29,6 +29,15 @@ type Signatures interface {
 
 	// Get retrieves the list of signatures stored.
 	Get() ([]Signature, error)
+
+	// Annotations returns the annotations of the manifest the signatures are
+	// stored in, such as its creation time or the tool that wrote it, as
+	// opposed to the annotations of each Signature.
+	Annotations() (map[string]string, error)
+
+	// Subject returns the descriptor of the manifest the signatures refer to
+	// as an OCI 1.1 referrer, or nil if they are stored under a tag.
+	Subject() (*v1.Descriptor, error)
 }
 
 // Signature holds a single image signature.
@@ -51,3 +51,13 @@ func (*image) Attestations() (oci.Signatures, error) {
 func (*image) Attachment(name string) (oci.File, error) { //nolint: revive
 	return nil, errors.New("unimplemented")
 }
+
+// Annotations implements oci.SignedImage
+func (i *image) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(i.Image)
+}
+
+// Subject implements oci.SignedImage
+func (i *image) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(i.Image)
+}
@@ -72,3 +72,13 @@ func (*index) Attestations() (oci.Signatures, error) {
 func (*index) Attachment(name string) (oci.File, error) { //nolint: revive
 	return nil, errors.New("unimplemented")
 }
+
+// Annotations implements oci.SignedImageIndex
+func (ii *index) Annotations() (map[string]string, error) {
+	return oci.ManifestAnnotations(ii.v1Index)
+}
+
+// Subject implements oci.SignedImageIndex
+func (ii *index) Subject() (*v1.Descriptor, error) {
+	return oci.ManifestSubject(ii.v1Index)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// uncompressed returns the uncompressed contents of b, the content of a
+// layer of media type mt. Image layers are decompressed if they are gzip
+// (including estargz) or zstd compressed, detected the way GGCR does by
+// their leading bytes since media types are not always accurate. Other
+// content, such as signatures and attached files, is returned as is.
+func uncompressed(b []byte, mt types.MediaType) (io.ReadCloser, error) {
+	if mt.IsLayer() {
+		switch {
+		case bytes.HasPrefix(b, gzipMagic):
+			return gzip.NewReader(bytes.NewReader(b))
+		case bytes.HasPrefix(b, zstdMagic):
+			d, err := zstd.NewReader(bytes.NewReader(b), zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		}
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// compressZstd returns b zstd compressed. The encoding is deterministic, so
+// that signing the same payload twice yields the same layer.
+func compressZstd(b []byte) ([]byte, error) {
+	e, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer e.Close()
+	return e.EncodeAll(b, nil), nil
+}
@@ -49,6 +49,9 @@ func NewFile(payload []byte, opts ...Option) (oci.File, error) {
 
 	// Add annotations from options
 	img = mutate.Annotations(img, o.Annotations).(v1.Image)
+	if o.Subject != nil {
+		img = mutate.Subject(img, *o.Subject).(v1.Image)
+	}
 
 	if o.RecordCreationTimestamp {
 		t, err := now.Now()
@@ -91,7 +94,9 @@ func (f *file) Payload() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	rc, err := f.layer.Uncompressed()
+	// Like attached files read from a registry, return the raw bytes
+	// even if the layer media type says they are compressed.
+	rc, err := f.layer.Compressed()
 	if err != nil {
 		return nil, err
 	}
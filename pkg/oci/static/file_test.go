@@ -16,6 +16,8 @@
 package static
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"io"
 	"strings"
@@ -24,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestNewFile(t *testing.T) {
@@ -175,6 +178,101 @@ func TestNewFile(t *testing.T) {
 	})
 }
 
+func TestNewFileWithSubject(t *testing.T) {
+	subject := v1.Descriptor{
+		MediaType: types.OCIManifestSchema1,
+		Size:      42,
+		Digest: v1.Hash{
+			Algorithm: "sha256",
+			Hex:       "be5d77c62dbe7fedfb0a4e5ec2f91078080800ab1f18358e5f31fcc8faa023c4",
+		},
+	}
+	f, err := NewFile([]byte("license text"), WithLayerMediaType("text/plain"),
+		WithConfigMediaType("application/vnd.example.license"), WithSubject(subject))
+	if err != nil {
+		t.Fatalf("NewFile() = %v", err)
+	}
+	m, err := f.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest() = %v", err)
+	}
+	if m.Subject == nil || m.Subject.Digest != subject.Digest {
+		t.Errorf("Manifest().Subject = %v, wanted %v", m.Subject, subject)
+	}
+	if got, want := m.Config.MediaType, types.MediaType("application/vnd.example.license"); got != want {
+		t.Errorf("Manifest().Config.MediaType = %s, wanted %s", got, want)
+	}
+	if mt, err := f.FileMediaType(); err != nil {
+		t.Fatalf("FileMediaType() = %v", err)
+	} else if mt != "text/plain" {
+		t.Errorf("FileMediaType() = %s, wanted text/plain", mt)
+	}
+}
+
+func TestNewFileCompressedLayer(t *testing.T) {
+	content := []byte("this is the layer content!")
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	if _, err := gw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zst := zw.EncodeAll(content, nil)
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		mediaType  types.MediaType
+		wantDiffID []byte
+	}{{
+		name:       "gzip layer",
+		payload:    gz.Bytes(),
+		mediaType:  types.OCILayer,
+		wantDiffID: content,
+	}, {
+		name:       "zstd layer",
+		payload:    zst,
+		mediaType:  types.OCILayerZStd,
+		wantDiffID: content,
+	}, {
+		name:       "gzip file is not a layer",
+		payload:    gz.Bytes(),
+		mediaType:  "application/gzip",
+		wantDiffID: gz.Bytes(),
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFile(tc.payload, WithLayerMediaType(tc.mediaType))
+			if err != nil {
+				t.Fatalf("NewFile() = %v", err)
+			}
+			layers, err := f.Layers()
+			if err != nil {
+				t.Fatalf("Layers() = %v", err)
+			}
+			l := layers[0]
+
+			wantDigest, _, _ := v1.SHA256(bytes.NewReader(tc.payload))
+			if got, err := l.Digest(); err != nil || got != wantDigest {
+				t.Errorf("Digest() = %v, %v, wanted %v", got, err, wantDigest)
+			}
+			wantDiffID, _, _ := v1.SHA256(bytes.NewReader(tc.wantDiffID))
+			if got, err := l.DiffID(); err != nil || got != wantDiffID {
+				t.Errorf("DiffID() = %v, %v, wanted %v", got, err, wantDiffID)
+			}
+			if got, err := f.Payload(); err != nil || !bytes.Equal(got, tc.payload) {
+				t.Errorf("Payload() = %v, wanted the raw layer bytes", err)
+			}
+		})
+	}
+}
+
 type mockLayer struct {
 	size int64
 }
@@ -183,11 +281,11 @@ func (m *mockLayer) Size() (int64, error) {
 	return m.size, nil
 }
 
-func (m *mockLayer) Uncompressed() (io.ReadCloser, error) {
+func (m *mockLayer) Compressed() (io.ReadCloser, error) {
 	return io.NopCloser(strings.NewReader("data")), nil
 }
 
-func (m *mockLayer) Digest() (v1.Hash, error)            { panic("not implemented") }
-func (m *mockLayer) DiffID() (v1.Hash, error)            { panic("not implemented") }
-func (m *mockLayer) Compressed() (io.ReadCloser, error)  { panic("not implemented") }
-func (m *mockLayer) MediaType() (types.MediaType, error) { panic("not implemented") }
+func (m *mockLayer) Digest() (v1.Hash, error)             { panic("not implemented") }
+func (m *mockLayer) DiffID() (v1.Hash, error)             { panic("not implemented") }
+func (m *mockLayer) Uncompressed() (io.ReadCloser, error) { panic("not implemented") }
+func (m *mockLayer) MediaType() (types.MediaType, error)  { panic("not implemented") }
@@ -0,0 +1,63 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/franchb/cosign/v2/internal/pkg/certext"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+// Annotations written onto signature and attestation layer descriptors, and
+// collected onto their manifests, so that consumers can filter signatures by
+// signer or type without downloading them. They are only an index: they are
+// not signed and verification never relies on them.
+const (
+	// IdentityHashAnnotationKey holds a comma separated list of IdentityHash
+	// values, one for each subject alternative name of the signing certificate.
+	IdentityHashAnnotationKey = "dev.sigstore.cosign/identity-sha256"
+	// PredicateTypeAnnotationKey holds the predicate type of an attestation.
+	PredicateTypeAnnotationKey = "predicateType"
+)
+
+// IdentityHash returns the hex encoded SHA256 of a certificate identity, which
+// is the OIDC issuer and subject alternative name of the certificate.
+func IdentityHash(issuer, subject string) string {
+	h := sha256.Sum256([]byte(issuer + "\n" + subject))
+	return hex.EncodeToString(h[:])
+}
+
+// IdentityHashes returns the IdentityHashAnnotationKey value for a PEM encoded
+// certificate, or the empty string if it cannot be parsed or carries no OIDC
+// issuer.
+func IdentityHashes(certPEM []byte) string {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(certPEM)
+	if err != nil || len(certs) == 0 {
+		return ""
+	}
+	issuer := certext.OIDCIssuer(certs[0])
+	if issuer == "" {
+		return ""
+	}
+	sans := cryptoutils.GetSubjectAlternateNames(certs[0])
+	hashes := make([]string, 0, len(sans))
+	for _, san := range sans {
+		hashes = append(hashes, IdentityHash(issuer, san))
+	}
+	return strings.Join(hashes, ",")
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"testing"
+
+	"github.com/franchb/cosign/v2/test"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+)
+
+func TestIdentityHashAnnotation(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	leafCert, _, _ := test.GenerateLeafCert("subject@example.com", "https://issuer.example.com", rootCert, rootKey)
+	leafPEM, _ := cryptoutils.MarshalCertificateToPEM(leafCert)
+	rootPEM, _ := cryptoutils.MarshalCertificateToPEM(rootCert)
+
+	sig, err := NewSignature([]byte("payload"), "sig", WithCertChain(leafPEM, rootPEM))
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+	ann, err := sig.Annotations()
+	if err != nil {
+		t.Fatalf("Annotations() = %v", err)
+	}
+	want := IdentityHash("https://issuer.example.com", "subject@example.com")
+	if got := ann[IdentityHashAnnotationKey]; got != want {
+		t.Errorf("Annotations()[%q] = %q, wanted %q", IdentityHashAnnotationKey, got, want)
+	}
+
+	sig, err = NewSignature([]byte("payload"), "sig", WithCertChain([]byte("not a cert"), nil))
+	if err != nil {
+		t.Fatalf("NewSignature() = %v", err)
+	}
+	ann, err = sig.Annotations()
+	if err != nil {
+		t.Fatalf("Annotations() = %v", err)
+	}
+	if _, ok := ann[IdentityHashAnnotationKey]; ok {
+		t.Errorf("Annotations() has %q for an unparseable certificate", IdentityHashAnnotationKey)
+	}
+}
@@ -17,9 +17,11 @@ package static
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	ctypes "github.com/franchb/cosign/v2/pkg/types"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
@@ -35,6 +37,9 @@ type options struct {
 	Chain                   []byte
 	Annotations             map[string]string
 	RecordCreationTimestamp bool
+	Subject                 *v1.Descriptor
+	Zstd                    bool
+	DSSEEnvelope            bool
 }
 
 func makeOptions(opts ...Option) (*options, error) {
@@ -48,9 +53,20 @@ func makeOptions(opts ...Option) (*options, error) {
 		opt(o)
 	}
 
+	if o.DSSEEnvelope {
+		o.LayerMediaType = ctypes.DssePayloadType
+	}
+
+	if o.Zstd && !strings.HasSuffix(string(o.LayerMediaType), ctypes.ZstdMediaTypeSuffix) {
+		o.LayerMediaType += ctypes.ZstdMediaTypeSuffix
+	}
+
 	if o.Cert != nil {
 		o.Annotations[CertificateAnnotationKey] = string(o.Cert)
 		o.Annotations[ChainAnnotationKey] = string(o.Chain)
+		if hashes := IdentityHashes(o.Cert); hashes != "" {
+			o.Annotations[IdentityHashAnnotationKey] = hashes
+		}
 	}
 
 	if o.Bundle != nil {
@@ -120,3 +136,31 @@ func WithRecordCreationTimestamp(rct bool) Option {
 		o.RecordCreationTimestamp = rct
 	}
 }
+
+// WithSubject sets the subject of a file, making it an OCI 1.1 referrer of
+// the manifest subject describes.
+func WithSubject(subject v1.Descriptor) Option {
+	return func(o *options) {
+		o.Subject = &subject
+	}
+}
+
+// WithZstdCompression stores signatures and attestations zstd compressed,
+// suffixing their layer media type with +zstd. Payload still returns the
+// uncompressed payload. It is meant for large attestations such as SBOMs.
+func WithZstdCompression() Option {
+	return func(o *options) {
+		o.Zstd = true
+	}
+}
+
+// WithDSSEEnvelope marks the payload as a complete DSSE envelope made
+// elsewhere, such as by witness, and stores it with the DSSE media type. The
+// envelope may hold any number of signatures and is kept byte-for-byte, so
+// that fields cosign does not know about survive. Constructing the signature
+// fails if the payload is not an envelope with at least one signature.
+func WithDSSEEnvelope() Option {
+	return func(o *options) {
+		o.DSSEEnvelope = true
+	}
+}
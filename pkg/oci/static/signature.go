@@ -19,13 +19,19 @@ import (
 	"bytes"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
 	"github.com/franchb/cosign/v2/pkg/oci"
+	ctypes "github.com/franchb/cosign/v2/pkg/types"
 	"github.com/franchb/sigstore/pkg/cryptoutils"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
 )
 
 const (
@@ -42,11 +48,22 @@ func NewSignature(payload []byte, b64sig string, opts ...Option) (oci.Signature,
 	if err != nil {
 		return nil, err
 	}
-	return &staticLayer{
+	if o.DSSEEnvelope {
+		if err := checkEnvelope(payload); err != nil {
+			return nil, err
+		}
+	}
+	l := &staticLayer{
 		b:      payload,
 		b64sig: b64sig,
 		opts:   o,
-	}, nil
+	}
+	if o.Zstd {
+		if l.compressed, err = compressZstd(payload); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
 }
 
 // NewAttestation constructs a new oci.Signature from the provided options.
@@ -57,6 +74,27 @@ func NewAttestation(payload []byte, opts ...Option) (oci.Signature, error) {
 	return NewSignature(payload, "", opts...)
 }
 
+// checkEnvelope returns an error if b is not a DSSE envelope holding a
+// payload and at least one signature.
+func checkEnvelope(b []byte) error {
+	var env ssldsse.Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return fmt.Errorf("parsing DSSE envelope: %w", err)
+	}
+	if env.PayloadType == "" || env.Payload == "" {
+		return errors.New("DSSE envelope has no payload")
+	}
+	if len(env.Signatures) == 0 {
+		return errors.New("DSSE envelope has no signatures")
+	}
+	for i, sig := range env.Signatures {
+		if sig.Sig == "" {
+			return fmt.Errorf("DSSE envelope signature %d is empty", i)
+		}
+	}
+	return nil
+}
+
 // Copy constructs a new oci.Signature from the provided one.
 func Copy(sig oci.Signature) (oci.Signature, error) {
 	payload, err := sig.Payload()
@@ -74,6 +112,9 @@ func Copy(sig oci.Signature) (oci.Signature, error) {
 		return nil, err
 	}
 	opts = append(opts, WithLayerMediaType(mt))
+	if strings.HasSuffix(string(mt), ctypes.ZstdMediaTypeSuffix) {
+		opts = append(opts, WithZstdCompression())
+	}
 
 	ann, err := sig.Annotations()
 	if err != nil {
@@ -119,6 +160,17 @@ type staticLayer struct {
 	b      []byte
 	b64sig string
 	opts   *options
+	// compressed is the zstd compressed payload stored in the registry, if
+	// the layer is compressed.
+	compressed []byte
+}
+
+// content returns the bytes of the layer as stored in the registry.
+func (l *staticLayer) content() []byte {
+	if l.compressed != nil {
+		return l.compressed
+	}
+	return l.b
 }
 
 var _ v1.Layer = (*staticLayer)(nil)
@@ -186,29 +238,37 @@ func (l *staticLayer) RFC3161Timestamp() (*bundle.RFC3161Timestamp, error) {
 
 // Digest implements v1.Layer
 func (l *staticLayer) Digest() (v1.Hash, error) {
-	h, _, err := v1.SHA256(bytes.NewReader(l.b))
+	h, _, err := v1.SHA256(bytes.NewReader(l.content()))
 	return h, err
 }
 
 // DiffID implements v1.Layer
 func (l *staticLayer) DiffID() (v1.Hash, error) {
-	h, _, err := v1.SHA256(bytes.NewReader(l.b))
+	rc, err := l.Uncompressed()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	defer rc.Close()
+	h, _, err := v1.SHA256(rc)
 	return h, err
 }
 
 // Compressed implements v1.Layer
 func (l *staticLayer) Compressed() (io.ReadCloser, error) {
-	return io.NopCloser(bytes.NewReader(l.b)), nil
+	return io.NopCloser(bytes.NewReader(l.content())), nil
 }
 
 // Uncompressed implements v1.Layer
 func (l *staticLayer) Uncompressed() (io.ReadCloser, error) {
-	return io.NopCloser(bytes.NewReader(l.b)), nil
+	if l.compressed != nil {
+		return io.NopCloser(bytes.NewReader(l.b)), nil
+	}
+	return uncompressed(l.b, l.opts.LayerMediaType)
 }
 
 // Size implements v1.Layer
 func (l *staticLayer) Size() (int64, error) {
-	return int64(len(l.b)), nil
+	return int64(len(l.content())), nil
 }
 
 // MediaType implements v1.Layer
@@ -668,3 +668,151 @@ func mustDecode(s string) []byte {
 	}
 	return b
 }
+
+func TestNewAttestationZstd(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"spdxVersion":"SPDX-2.3"}`, 100))
+	l, err := NewAttestation(payload, WithLayerMediaType("application/vnd.dsse.envelope.v1+json"), WithZstdCompression())
+	if err != nil {
+		t.Fatalf("NewAttestation() = %v", err)
+	}
+
+	mt, err := l.MediaType()
+	if err != nil {
+		t.Fatalf("MediaType() = %v", err)
+	}
+	if want := types.MediaType("application/vnd.dsse.envelope.v1+json+zstd"); mt != want {
+		t.Errorf("MediaType() = %s, wanted %s", mt, want)
+	}
+
+	gotPayload, err := l.Payload()
+	if err != nil {
+		t.Fatalf("Payload() = %v", err)
+	}
+	if !cmp.Equal(gotPayload, payload) {
+		t.Error("Payload() is not the uncompressed payload")
+	}
+
+	size, err := l.Size()
+	if err != nil {
+		t.Fatalf("Size() = %v", err)
+	}
+	if size >= int64(len(payload)) {
+		t.Errorf("Size() = %d, wanted less than the %d bytes of the payload", size, len(payload))
+	}
+
+	rc, err := l.Compressed()
+	if err != nil {
+		t.Fatalf("Compressed() = %v", err)
+	}
+	defer rc.Close()
+	compressed, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll() = %v", err)
+	}
+	if !strings.HasPrefix(string(compressed), string(zstdMagic)) {
+		t.Error("Compressed() is not zstd compressed")
+	}
+
+	rc, err = l.Uncompressed()
+	if err != nil {
+		t.Fatalf("Uncompressed() = %v", err)
+	}
+	defer rc.Close()
+	uncompressed, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll() = %v", err)
+	}
+	if !cmp.Equal(uncompressed, payload) {
+		t.Error("Uncompressed() is not the payload")
+	}
+
+	// The same payload always yields the same layer, and copies stay
+	// compressed.
+	cp, err := Copy(l)
+	if err != nil {
+		t.Fatalf("Copy() = %v", err)
+	}
+	for _, sig := range []v1.Layer{l, cp} {
+		d, err := sig.Digest()
+		if err != nil {
+			t.Fatalf("Digest() = %v", err)
+		}
+		want, _, err := v1.SHA256(strings.NewReader(string(compressed)))
+		if err != nil {
+			t.Fatalf("SHA256() = %v", err)
+		}
+		if d != want {
+			t.Errorf("Digest() = %s, wanted %s", d, want)
+		}
+	}
+	cpMT, err := cp.MediaType()
+	if err != nil {
+		t.Fatalf("MediaType() = %v", err)
+	}
+	if cpMT != mt {
+		t.Errorf("Copy() media type = %s, wanted %s", cpMT, mt)
+	}
+}
+
+func TestNewAttestationDSSEEnvelope(t *testing.T) {
+	// An envelope as written by witness, with two signatures, fields cosign
+	// does not model and its own whitespace.
+	envelope := []byte(`{"payload": "e30=", "payloadType": "application/vnd.in-toto+json",
+  "signatures": [{"keyid": "a", "sig": "c2lnMQ==", "certificate": "LS0t"},
+                 {"keyid": "b", "sig": "c2lnMg==", "timestamps": [{"type": "tsp", "data": "ZGF0YQ=="}]}]}`)
+
+	l, err := NewAttestation(envelope, WithDSSEEnvelope())
+	if err != nil {
+		t.Fatalf("NewAttestation() = %v", err)
+	}
+	mt, err := l.MediaType()
+	if err != nil {
+		t.Fatalf("MediaType() = %v", err)
+	}
+	if mt != "application/vnd.dsse.envelope.v1+json" {
+		t.Errorf("MediaType() = %s, wanted the DSSE media type", mt)
+	}
+	gotPayload, err := l.Payload()
+	if err != nil {
+		t.Fatalf("Payload() = %v", err)
+	}
+	if !cmp.Equal(gotPayload, envelope) {
+		t.Errorf("Payload() = %s, wanted the envelope unchanged", gotPayload)
+	}
+	d, err := l.Digest()
+	if err != nil {
+		t.Fatalf("Digest() = %v", err)
+	}
+	want, _, err := v1.SHA256(strings.NewReader(string(envelope)))
+	if err != nil {
+		t.Fatalf("SHA256() = %v", err)
+	}
+	if d != want {
+		t.Errorf("Digest() = %s, wanted %s", d, want)
+	}
+
+	// Copies keep the envelope as it was.
+	cp, err := Copy(l)
+	if err != nil {
+		t.Fatalf("Copy() = %v", err)
+	}
+	if cpPayload, err := cp.Payload(); err != nil || !cmp.Equal(cpPayload, envelope) {
+		t.Errorf("Copy() payload = %s, %v, wanted the envelope unchanged", cpPayload, err)
+	}
+}
+
+func TestNewAttestationDSSEEnvelopeInvalid(t *testing.T) {
+	for name, envelope := range map[string]string{
+		"not json":         `not json`,
+		"no payload":       `{"payloadType": "application/vnd.in-toto+json", "signatures": [{"sig": "c2ln"}]}`,
+		"no signatures":    `{"payload": "e30=", "payloadType": "application/vnd.in-toto+json", "signatures": []}`,
+		"empty signature":  `{"payload": "e30=", "payloadType": "application/vnd.in-toto+json", "signatures": [{"sig": "c2ln"}, {"keyid": "b"}]}`,
+		"trailing content": `{"payload": "e30=", "payloadType": "application/vnd.in-toto+json", "signatures": [{"sig": "c2ln"}]} {}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewAttestation([]byte(envelope), WithDSSEEnvelope()); err == nil {
+				t.Error("NewAttestation() succeeded, wanted an error")
+			}
+		})
+	}
+}
@@ -17,25 +17,150 @@ package walk
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/franchb/cosign/v2/pkg/oci"
 	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/sync/errgroup"
 )
 
 // Fn is the signature of the callback supplied to SignedEntity.
 // The oci.SignedEntity is either an oci.SignedImageIndex or an oci.SignedImage.
 // This callback is called on oci.SignedImageIndex *before* its children.
+// Returning mutate.ErrSkipChildren from it skips the children of an index.
 type Fn func(context.Context, oci.SignedEntity) error
 
+// Option is a functional option for SignedEntity.
+type Option func(*options)
+
+type options struct {
+	concurrency int
+	maxDepth    int
+}
+
+// WithConcurrency is a functional option for visiting up to n entities at
+// once, fetching the children of an index in parallel. The callback must
+// then be safe for concurrent use. Indexes are still visited before their
+// children, and the first error cancels the rest of the walk.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = max(n, 1)
+	}
+}
+
+// WithMaxDepth is a functional option for not descending into indexes that
+// are depth levels below the parent, which is at depth 0. Their children are
+// not visited.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = depth
+	}
+}
+
 // SignedEntity calls `fn` on the signed entity and each of its constituent entities
 // (`SignedImageIndex` or `SignedImage`) transitively.
 // Any errors returned by an `fn` are returned by `Walk`.
-func SignedEntity(ctx context.Context, parent oci.SignedEntity, fn Fn) error {
-	_, err := mutate.Map(ctx, parent, func(ctx context.Context, se oci.SignedEntity) (oci.SignedEntity, error) {
-		if err := fn(ctx, se); err != nil {
-			return nil, err
+// By default entities are visited one at a time at any depth, see
+// WithConcurrency and WithMaxDepth.
+func SignedEntity(ctx context.Context, parent oci.SignedEntity, fn Fn, opts ...Option) error {
+	o := &options{
+		concurrency: 1,
+		maxDepth:    -1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	w := &walker{
+		fn:       fn,
+		maxDepth: o.maxDepth,
+		slots:    make(chan struct{}, o.concurrency),
+	}
+	return w.walk(ctx, func() (oci.SignedEntity, error) { return parent, nil }, 0)
+}
+
+type walker struct {
+	fn       Fn
+	maxDepth int
+	// slots bounds the number of entities loaded and visited at once.
+	slots chan struct{}
+}
+
+// walk visits the entity returned by load, which is at depth, and then its
+// children.
+func (w *walker) walk(ctx context.Context, load func() (oci.SignedEntity, error), depth int) error {
+	var children []func() (oci.SignedEntity, error)
+	if err := w.withSlot(ctx, func() error {
+		se, err := load()
+		if err != nil {
+			return err
 		}
-		return se, nil
-	})
-	return err
+		children, err = w.visit(ctx, se, depth)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if cap(w.slots) == 1 {
+		for _, child := range children {
+			if err := w.walk(ctx, child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	for _, child := range children {
+		g.Go(func() error {
+			return w.walk(ctx, child, depth+1)
+		})
+	}
+	return g.Wait()
+}
+
+// visit calls the callback on se, returning loaders for the children to
+// visit next.
+func (w *walker) visit(ctx context.Context, se oci.SignedEntity, depth int) ([]func() (oci.SignedEntity, error), error) {
+	if err := w.fn(ctx, se); errors.Is(err, mutate.ErrSkipChildren) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	sii, ok := se.(oci.SignedImageIndex)
+	if !ok || (w.maxDepth >= 0 && depth >= w.maxDepth) {
+		return nil, nil
+	}
+	im, err := sii.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	children := make([]func() (oci.SignedEntity, error), 0, len(im.Manifests))
+	for _, desc := range im.Manifests {
+		switch desc.MediaType {
+		case types.OCIImageIndex, types.DockerManifestList:
+			children = append(children, func() (oci.SignedEntity, error) {
+				return sii.SignedImageIndex(desc.Digest)
+			})
+		case types.OCIManifestSchema1, types.DockerManifestSchema2:
+			children = append(children, func() (oci.SignedEntity, error) {
+				return sii.SignedImage(desc.Digest)
+			})
+		default:
+			return nil, fmt.Errorf("unknown mime type: %v", desc.MediaType)
+		}
+	}
+	return children, nil
+}
+
+// withSlot runs f once fewer than the configured number of entities are
+// being visited.
+func (w *walker) withSlot(ctx context.Context, f func() error) error {
+	select {
+	case w.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-w.slots }()
+	return f()
 }
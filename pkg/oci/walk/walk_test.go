@@ -18,9 +18,13 @@ package walk
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/franchb/cosign/v2/pkg/oci"
+	ocimutate "github.com/franchb/cosign/v2/pkg/oci/mutate"
 	"github.com/franchb/cosign/v2/pkg/oci/signed"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
@@ -85,3 +89,94 @@ func TestMapImageIndex(t *testing.T) {
 		}
 	})
 }
+
+func TestWalkOptions(t *testing.T) {
+	ii, err := random.Index(300 /* bytes */, 3 /* layers */, 4 /* images */)
+	if err != nil {
+		t.Fatalf("random.Index() = %v", err)
+	}
+	ii2, err := random.Index(300 /* bytes */, 3 /* layers */, 4 /* images */)
+	if err != nil {
+		t.Fatalf("random.Index() = %v", err)
+	}
+	sii := signed.ImageIndex(mutate.AppendManifests(ii, mutate.IndexAddendum{
+		Add: ii2,
+	}))
+
+	t.Run("max depth", func(t *testing.T) {
+		tests := []struct {
+			depth int
+			want  int
+		}{{0, 1}, {1, 6}, {2, 10}}
+		for _, tc := range tests {
+			calls := 0
+			err := SignedEntity(context.Background(), sii, func(_ context.Context, _ oci.SignedEntity) error {
+				calls++
+				return nil
+			}, WithMaxDepth(tc.depth))
+			if err != nil {
+				t.Fatalf("SignedEntity() = %v", err)
+			}
+			if calls != tc.want {
+				t.Errorf("WithMaxDepth(%d) visited %d entities, wanted %d", tc.depth, calls, tc.want)
+			}
+		}
+	})
+
+	t.Run("bounded concurrency", func(t *testing.T) {
+		var mu sync.Mutex
+		seen := map[oci.SignedEntity]bool{}
+		var running, peak atomic.Int32
+		err := SignedEntity(context.Background(), sii, func(_ context.Context, se oci.SignedEntity) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			seen[se] = true
+			return nil
+		}, WithConcurrency(3))
+		if err != nil {
+			t.Fatalf("SignedEntity() = %v", err)
+		}
+		if len(seen) != 10 {
+			t.Errorf("visited %d entities, wanted 10", len(seen))
+		}
+		if p := peak.Load(); p > 3 || p < 2 {
+			t.Errorf("visited up to %d entities at once, wanted 2 to 3", p)
+		}
+	})
+
+	t.Run("skip children", func(t *testing.T) {
+		calls := 0
+		err := SignedEntity(context.Background(), sii, func(_ context.Context, _ oci.SignedEntity) error {
+			calls++
+			return ocimutate.ErrSkipChildren
+		}, WithConcurrency(4))
+		if err != nil {
+			t.Fatalf("SignedEntity() = %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("visited %d entities, wanted 1", calls)
+		}
+	})
+
+	t.Run("error cancels the walk", func(t *testing.T) {
+		want := errors.New("this is the error I expect")
+		got := SignedEntity(context.Background(), sii, func(_ context.Context, se oci.SignedEntity) error {
+			if _, ok := se.(oci.SignedImage); ok {
+				return want
+			}
+			return nil
+		}, WithConcurrency(4))
+		if !errors.Is(got, want) {
+			t.Fatalf("SignedEntity() = %v, wanted %v", got, want)
+		}
+	})
+}
@@ -31,27 +31,23 @@ import (
 // policyBody - String representing either cue or rego language
 // jsonBytes - Bytes to evaluate against the policyBody in the given language
 func EvaluatePolicyAgainstJSON(ctx context.Context, name, policyType string, policyBody string, jsonBytes []byte) (warnings error, errors error) {
-	switch policyType {
-	case "cue":
-		cueValidationErr := evaluateCue(ctx, jsonBytes, policyBody)
-		if cueValidationErr != nil {
-			return nil, &EvaluationFailure{
-				fmt.Errorf("failed evaluating cue policy for %s: %w", name, cueValidationErr),
-			}
-		}
-	case "rego":
-		regoValidationWarn, regoValidationErr := evaluateRego(ctx, jsonBytes, policyBody)
-		if regoValidationErr != nil {
-			return regoValidationWarn, &EvaluationFailure{
-				fmt.Errorf("failed evaluating rego policy for type %s: %w", name, regoValidationErr),
-			}
-		}
+	evaluator, err := NewEvaluator(policyType, policyBody)
+	if err != nil {
+		return nil, err
+	}
+	warnings, errors = evaluator.Evaluate(ctx, [][]byte{jsonBytes})
+	if errors == nil {
 		// It is possible to return warning messages when the policy is compliant
-		return regoValidationWarn, regoValidationErr
-	default:
-		return nil, fmt.Errorf("sorry Type %s is not supported yet", policyType)
+		return warnings, nil
+	}
+	if policyType == "cue" {
+		return nil, &EvaluationFailure{
+			fmt.Errorf("failed evaluating cue policy for %s: %w", name, errors),
+		}
+	}
+	return warnings, &EvaluationFailure{
+		fmt.Errorf("failed evaluating %s policy for type %s: %w", policyType, name, errors),
 	}
-	return nil, nil
 }
 
 // evaluateCue evaluates a cue policy `evaluator` against `attestation`
@@ -0,0 +1,97 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/cue"
+	"github.com/franchb/cosign/v2/pkg/cosign/rego"
+)
+
+// PolicyEvaluator evaluates a policy against JSON payloads, such as the
+// output of AttestationToPayloadJSON. Consumers may implement it to plug in
+// policy engines that cosign does not provide.
+type PolicyEvaluator interface { //nolint: revive
+	// Evaluate checks every payload against the policy. warnings holds
+	// findings that do not fail the policy; errors is non-nil if any payload
+	// fails it.
+	Evaluate(ctx context.Context, payloads [][]byte) (warnings error, errors error)
+}
+
+// NewEvaluator returns the PolicyEvaluator for policyBody written in the
+// language policyType, which is "cue" or "rego".
+func NewEvaluator(policyType, policyBody string) (PolicyEvaluator, error) {
+	switch policyType {
+	case "cue":
+		return NewCUEEvaluator(policyBody), nil
+	case "rego":
+		return NewRegoEvaluator(policyBody), nil
+	default:
+		return nil, fmt.Errorf("sorry Type %s is not supported yet", policyType)
+	}
+}
+
+// evaluatorFunc adapts a function checking a single payload to a
+// PolicyEvaluator that checks each payload in turn.
+type evaluatorFunc func(ctx context.Context, payload []byte) (warnings error, errors error)
+
+// Evaluate implements PolicyEvaluator
+func (f evaluatorFunc) Evaluate(ctx context.Context, payloads [][]byte) (error, error) {
+	var warns, errs []error
+	for _, payload := range payloads {
+		warn, err := f(ctx, payload)
+		if warn != nil {
+			warns = append(warns, warn)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(warns...), errors.Join(errs...)
+}
+
+// NewCUEEvaluator returns a PolicyEvaluator for the CUE policy policyBody.
+func NewCUEEvaluator(policyBody string) PolicyEvaluator {
+	return evaluatorFunc(func(ctx context.Context, payload []byte) (error, error) {
+		return nil, evaluateCue(ctx, payload, policyBody)
+	})
+}
+
+// NewRegoEvaluator returns a PolicyEvaluator for the Rego module policyBody,
+// which must define data.sigstore.isCompliant.
+func NewRegoEvaluator(policyBody string) PolicyEvaluator {
+	return evaluatorFunc(func(ctx context.Context, payload []byte) (error, error) {
+		return evaluateRego(ctx, payload, policyBody)
+	})
+}
+
+// NewCUEFileEvaluator returns a PolicyEvaluator for the CUE policies loaded
+// from paths.
+func NewCUEFileEvaluator(paths []string) PolicyEvaluator {
+	return evaluatorFunc(func(_ context.Context, payload []byte) (error, error) {
+		return nil, cue.ValidateJSON(payload, paths)
+	})
+}
+
+// NewRegoFileEvaluator returns a PolicyEvaluator for the Rego policies loaded
+// from paths, which must define data.signature.allow.
+func NewRegoFileEvaluator(paths []string) PolicyEvaluator {
+	return evaluatorFunc(func(_ context.Context, payload []byte) (error, error) {
+		return nil, errors.Join(rego.ValidateJSON(payload, paths)...)
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEvaluators(t *testing.T) {
+	pass := []byte(`{"level": "high"}`)
+	fail := []byte(`{"level": "low"}`)
+
+	tests := []struct {
+		name        string
+		policyType  string
+		policyBody  string
+		payloads    [][]byte
+		wantErrSub  string
+		wantWarnSub string
+	}{{
+		name:       "cue passes",
+		policyType: "cue",
+		policyBody: `level: "high"`,
+		payloads:   [][]byte{pass, pass},
+	}, {
+		name:       "cue fails on any payload",
+		policyType: "cue",
+		policyBody: `level: "high"`,
+		payloads:   [][]byte{pass, fail},
+		wantErrSub: `conflicting values`,
+	}, {
+		name:       "rego passes",
+		policyType: "rego",
+		policyBody: `package sigstore
+			default isCompliant = false
+			isCompliant {
+				input.level == "high"
+			}`,
+		payloads: [][]byte{pass},
+	}, {
+		name:       "rego fails",
+		policyType: "rego",
+		policyBody: `package sigstore
+			default isCompliant = false
+			isCompliant {
+				input.level == "high"
+			}`,
+		payloads:   [][]byte{fail},
+		wantErrSub: "policy is not compliant",
+	}, {
+		name:       "rego warns",
+		policyType: "rego",
+		policyBody: `package sigstore
+			isCompliant[response] {
+				response := {
+					"result" : true,
+					"error" : "",
+					"warning" : "level is only checked loosely"
+				}
+			}`,
+		payloads:    [][]byte{pass},
+		wantWarnSub: "level is only checked loosely",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluator, err := NewEvaluator(tc.policyType, tc.policyBody)
+			if err != nil {
+				t.Fatalf("NewEvaluator() = %v", err)
+			}
+			warn, err := evaluator.Evaluate(context.Background(), tc.payloads)
+			switch {
+			case tc.wantErrSub == "" && err != nil:
+				t.Errorf("Evaluate() = %v, wanted no error", err)
+			case tc.wantErrSub != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErrSub)):
+				t.Errorf("Evaluate() = %v, wanted error containing %q", err, tc.wantErrSub)
+			}
+			switch {
+			case tc.wantWarnSub == "" && warn != nil:
+				t.Errorf("Evaluate() warnings = %v, wanted none", warn)
+			case tc.wantWarnSub != "" && (warn == nil || !strings.Contains(warn.Error(), tc.wantWarnSub)):
+				t.Errorf("Evaluate() warnings = %v, wanted %q", warn, tc.wantWarnSub)
+			}
+		})
+	}
+}
+
+func TestNewEvaluatorUnsupported(t *testing.T) {
+	if _, err := NewEvaluator("cel", "true"); err == nil {
+		t.Error("NewEvaluator() succeeded for an unsupported policy type")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PluginContext describes the verification a policy plugin is consulted for.
+type PluginContext struct {
+	// Image is the image whose attestations are verified.
+	Image string `json:"image"`
+	// PredicateType is the predicate type the attestations were selected by.
+	PredicateType string `json:"predicateType,omitempty"`
+}
+
+// PluginRequest is what a policy plugin receives on stdin for each
+// attestation.
+type PluginRequest struct {
+	// Attestation is the attestation, as returned by AttestationToPayloadJSON.
+	Attestation json.RawMessage `json:"attestation"`
+	// Context describes the verification.
+	Context PluginContext `json:"context"`
+}
+
+// PluginVerdict is what a policy plugin must write to stdout.
+type PluginVerdict struct {
+	// Allow is whether the attestation complies with the policy.
+	Allow bool `json:"allow"`
+	// Violations explain why the attestation does not comply.
+	Violations []string `json:"violations,omitempty"`
+	// Warnings are findings that do not fail the policy.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewPluginEvaluator returns a PolicyEvaluator that runs the executable at
+// path once for each payload. The plugin reads a PluginRequest as JSON from
+// stdin and answers with a PluginVerdict as JSON on stdout. A plugin that
+// exits with a non-zero status fails the policy.
+func NewPluginEvaluator(path string, pctx PluginContext) PolicyEvaluator {
+	return evaluatorFunc(func(ctx context.Context, payload []byte) (error, error) {
+		return runPlugin(ctx, path, PluginRequest{Attestation: payload, Context: pctx})
+	})
+}
+
+func runPlugin(ctx context.Context, path string, req PluginRequest) (error, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path) //nolint:gosec // the plugin is chosen by the user
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running policy plugin %s: %w: %s", path, err, msg)
+		}
+		return nil, fmt.Errorf("running policy plugin %s: %w", path, err)
+	}
+
+	var verdict PluginVerdict
+	if err := json.Unmarshal(stdout.Bytes(), &verdict); err != nil {
+		return nil, fmt.Errorf("parsing verdict of policy plugin %s: %w", path, err)
+	}
+	var warnings error
+	if len(verdict.Warnings) > 0 {
+		warnings = errors.New(strings.Join(verdict.Warnings, ", "))
+	}
+	if verdict.Allow {
+		return warnings, nil
+	}
+	if len(verdict.Violations) == 0 {
+		return warnings, fmt.Errorf("policy plugin %s denied the attestation", path)
+	}
+	errs := make([]error, 0, len(verdict.Violations))
+	for _, v := range verdict.Violations {
+		errs = append(errs, fmt.Errorf("policy plugin %s: %s", path, v))
+	}
+	return warnings, errors.Join(errs...)
+}
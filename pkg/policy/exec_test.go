@@ -0,0 +1,94 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginEvaluator(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	pctx := PluginContext{Image: "example.com/image@sha256:abc", PredicateType: "https://slsa.dev/provenance/v0.2"}
+
+	tests := []struct {
+		name        string
+		script      string
+		wantErrSub  string
+		wantWarnSub string
+	}{{
+		name: "allows based on request",
+		script: `if grep -q '"level":"high"'; then echo '{"allow": true}'; else echo '{"allow": false}'; fi
+`,
+	}, {
+		name: "sees the context",
+		script: `grep -q '"image":"example.com/image@sha256:abc"' && echo '{"allow": true, "warnings": ["context seen"]}'
+`,
+		wantWarnSub: "context seen",
+	}, {
+		name: "denies with violations",
+		script: `echo '{"allow": false, "violations": ["builder is not trusted"]}'
+`,
+		wantErrSub: "builder is not trusted",
+	}, {
+		name: "denies without violations",
+		script: `echo '{"allow": false}'
+`,
+		wantErrSub: "denied the attestation",
+	}, {
+		name: "fails",
+		script: `echo 'validator crashed' >&2; exit 3
+`,
+		wantErrSub: "validator crashed",
+	}, {
+		name: "invalid verdict",
+		script: `echo 'yes'
+`,
+		wantErrSub: "parsing verdict",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluator := NewPluginEvaluator(writePlugin(t, tc.script), pctx)
+			warn, err := evaluator.Evaluate(context.Background(), [][]byte{[]byte(`{"level":"high"}`)})
+			switch {
+			case tc.wantErrSub == "" && err != nil:
+				t.Errorf("Evaluate() = %v, wanted no error", err)
+			case tc.wantErrSub != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErrSub)):
+				t.Errorf("Evaluate() = %v, wanted error containing %q", err, tc.wantErrSub)
+			}
+			switch {
+			case tc.wantWarnSub == "" && warn != nil:
+				t.Errorf("Evaluate() warnings = %v, wanted none", warn)
+			case tc.wantWarnSub != "" && (warn == nil || !strings.Contains(warn.Error(), tc.wantWarnSub)):
+				t.Errorf("Evaluate() warnings = %v, wanted %q", warn, tc.wantWarnSub)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// AttestationTime returns the time at which a verified attestation was made.
+// In order of preference this is the integrated time of the transparency log
+// entry or the time of the RFC3161 timestamp, as accepted by verification.
+// Timestamps that verification did not check, such as a bundle when the
+// transparency log was ignored, are never used: they are annotations that
+// anyone with push access to the registry can rewrite.
+//
+// If allowStatementTime is set and no verified time is available, the
+// timestamp recorded in the in-toto statement's predicate is used instead
+// (the cosign custom predicate's "timestamp", or the SLSA provenance build
+// finish time). That time is chosen by the signer.
+//
+// Anything fed here must have been validated with either
+// `VerifyLocalImageAttestations` or `VerifyImageAttestations`
+func AttestationTime(att oci.Signature, allowStatementTime bool) (time.Time, error) {
+	if ts, ok := cosign.GetVerifiedTimestamps(att); ok {
+		if ts.IntegratedTime != nil {
+			return *ts.IntegratedTime, nil
+		}
+		if ts.RFC3161Time != nil {
+			return *ts.RFC3161Time, nil
+		}
+	}
+	if !allowStatementTime {
+		return time.Time{}, errors.New("attestation has no verified transparency log entry or RFC3161 timestamp")
+	}
+	return statementTime(att)
+}
+
+// statementTime extracts a timestamp from the predicate of the in-toto
+// statement wrapped in the attestation's DSSE envelope.
+func statementTime(att PayloadProvider) (time.Time, error) {
+	decoded, err := decodeStatement(att)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var statement struct {
+		Predicate struct {
+			// Cosign custom predicate
+			Timestamp string `json:"timestamp"`
+			// SLSA provenance v0.2
+			Metadata struct {
+				BuildFinishedOn string `json:"buildFinishedOn"`
+			} `json:"metadata"`
+			// SLSA provenance v1
+			RunDetails struct {
+				Metadata struct {
+					FinishedOn string `json:"finishedOn"`
+				} `json:"metadata"`
+			} `json:"runDetails"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(decoded, &statement); err != nil {
+		return time.Time{}, fmt.Errorf("unmarshal in-toto statement: %w", err)
+	}
+	for _, ts := range []string{
+		statement.Predicate.Timestamp,
+		statement.Predicate.Metadata.BuildFinishedOn,
+		statement.Predicate.RunDetails.Metadata.FinishedOn,
+	} {
+		if ts == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing statement timestamp %q: %w", ts, err)
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.New("attestation has no transparency log entry, RFC3161 timestamp or statement timestamp")
+}
+
+// decodeStatement returns the in-toto statement wrapped in the attestation's
+// DSSE envelope.
+func decodeStatement(att PayloadProvider) ([]byte, error) {
+	p, err := att.Payload()
+	if err != nil {
+		return nil, fmt.Errorf("getting payload: %w", err)
+	}
+	var env struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.Unmarshal(p, &env); err != nil {
+		return nil, fmt.Errorf("unmarshaling payload data: %w", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+	return decoded, nil
+}
+
+// CheckAttestationFreshness fails when the newest of the given attestations was
+// made more than maxAge before now, judged by AttestationTime. Attestations
+// whose time cannot be determined are not considered fresh.
+func CheckAttestationFreshness(atts []oci.Signature, maxAge time.Duration, now time.Time, allowStatementTime bool) error {
+	if len(atts) == 0 {
+		return errors.New("no attestations to check freshness of")
+	}
+	var newest time.Time
+	var errs []error
+	for _, att := range atts {
+		t, err := AttestationTime(att, allowStatementTime)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if t.After(newest) {
+			newest = t
+		}
+	}
+	if newest.IsZero() {
+		return &EvaluationFailure{
+			fmt.Errorf("unable to determine attestation time: %w", errors.Join(errs...)),
+		}
+	}
+	if age := now.Sub(newest); age > maxAge {
+		return &EvaluationFailure{
+			fmt.Errorf("newest matching attestation was made at %s, %s ago, which exceeds the maximum age of %s",
+				newest.UTC().Format(time.RFC3339), age.Round(time.Second), maxAge),
+		}
+	}
+	return nil
+}
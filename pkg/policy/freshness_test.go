@@ -0,0 +1,237 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/internal/pkg/cosign/tsa"
+	tsaMock "github.com/franchb/cosign/v2/internal/pkg/cosign/tsa/mock"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/bundle"
+	"github.com/franchb/cosign/v2/pkg/oci"
+	"github.com/franchb/cosign/v2/pkg/oci/empty"
+	"github.com/franchb/cosign/v2/pkg/oci/mutate"
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/pkg/types"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/dsse"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+func mustAttestation(t *testing.T, statement string, opts ...static.Option) oci.Signature {
+	t.Helper()
+	env := fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":%q}`,
+		base64.StdEncoding.EncodeToString([]byte(statement)))
+	att, err := static.NewAttestation([]byte(env), opts...)
+	if err != nil {
+		t.Fatalf("static.NewAttestation() = %v", err)
+	}
+	return att
+}
+
+// mustVerifiedAttestation signs statement with sv, attaches the options built
+// from the signed envelope and returns the result of verifying it with co.
+func mustVerifiedAttestation(t *testing.T, sv signature.SignerVerifier, statement string, co *cosign.CheckOpts, opts func([]byte) []static.Option) ([]oci.Signature, error) {
+	t.Helper()
+	env, err := dsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(bytes.NewReader([]byte(statement)))
+	if err != nil {
+		t.Fatalf("SignMessage() = %v", err)
+	}
+	att, err := static.NewAttestation(env, append([]static.Option{static.WithLayerMediaType(types.DssePayloadType)}, opts(env)...)...)
+	if err != nil {
+		t.Fatalf("static.NewAttestation() = %v", err)
+	}
+	atts, err := mutate.AppendSignatures(empty.Signatures(), false, att)
+	if err != nil {
+		t.Fatalf("AppendSignatures() = %v", err)
+	}
+	co.SigVerifier = sv
+	checked, _, err := cosign.VerifyImageAttestation(context.Background(), atts, v1.Hash{}, co)
+	return checked, err
+}
+
+func TestAttestationTime(t *testing.T) {
+	integrated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	withBundle := static.WithBundle(&bundle.RekorBundle{
+		Payload: bundle.RekorPayload{IntegratedTime: integrated.Unix()},
+	})
+
+	tests := []struct {
+		name               string
+		att                oci.Signature
+		allowStatementTime bool
+		want               time.Time
+		wantError          bool
+	}{{
+		name:      "unverified bundle is not trusted",
+		att:       mustAttestation(t, `{"predicate":{"timestamp":"2020-01-01T00:00:00Z"}}`, withBundle),
+		wantError: true,
+	}, {
+		name:               "unverified bundle ignored in favour of statement",
+		att:                mustAttestation(t, `{"predicate":{"timestamp":"2020-01-01T00:00:00Z"}}`, withBundle),
+		allowStatementTime: true,
+		want:               time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:      "statement timestamp requires opt in",
+		att:       mustAttestation(t, `{"predicate":{"timestamp":"2020-01-01T00:00:00Z"}}`),
+		wantError: true,
+	}, {
+		name:               "cosign custom predicate timestamp",
+		att:                mustAttestation(t, `{"predicate":{"timestamp":"2020-01-01T00:00:00Z"}}`),
+		allowStatementTime: true,
+		want:               time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:               "slsa v0.2 build finished",
+		att:                mustAttestation(t, `{"predicate":{"metadata":{"buildFinishedOn":"2021-01-01T00:00:00Z"}}}`),
+		allowStatementTime: true,
+		want:               time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:               "slsa v1 run finished",
+		att:                mustAttestation(t, `{"predicate":{"runDetails":{"metadata":{"finishedOn":"2022-01-01T00:00:00Z"}}}}`),
+		allowStatementTime: true,
+		want:               time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:               "no time available",
+		att:                mustAttestation(t, `{"predicate":{}}`),
+		allowStatementTime: true,
+		wantError:          true,
+	}, {
+		name:               "malformed statement timestamp",
+		att:                mustAttestation(t, `{"predicate":{"timestamp":"yesterday"}}`),
+		allowStatementTime: true,
+		wantError:          true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := AttestationTime(tc.att, tc.allowStatementTime)
+			if tc.wantError {
+				if err == nil {
+					t.Errorf("AttestationTime() = %v, wanted error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AttestationTime() = %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("AttestationTime() = %v, wanted %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAttestationTimeVerified(t *testing.T) {
+	sv, _, err := signature.NewDefaultECDSASignerVerifier()
+	if err != nil {
+		t.Fatalf("NewDefaultECDSASignerVerifier() = %v", err)
+	}
+	statement := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://cosign.sigstore.dev/attestation/v1","predicate":{"timestamp":"2020-01-01T00:00:00Z"}}`
+
+	t.Run("bundle not trusted when the tlog is ignored", func(t *testing.T) {
+		forged := static.WithBundle(&bundle.RekorBundle{
+			Payload: bundle.RekorPayload{IntegratedTime: time.Now().Unix()},
+		})
+		checked, err := mustVerifiedAttestation(t, sv, statement, &cosign.CheckOpts{IgnoreTlog: true},
+			func([]byte) []static.Option { return []static.Option{forged} })
+		if err != nil {
+			t.Fatalf("VerifyImageAttestation() = %v", err)
+		}
+		if err := CheckAttestationFreshness(checked, time.Hour, time.Now(), false); err == nil {
+			t.Error("CheckAttestationFreshness() with an unverified bundle succeeded, wanted error")
+		}
+		got, err := AttestationTime(checked[0], true)
+		if err != nil {
+			t.Fatalf("AttestationTime() = %v", err)
+		}
+		if want := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+			t.Errorf("AttestationTime() = %v, wanted statement time %v", got, want)
+		}
+	})
+
+	tsaClient, err := tsaMock.NewTSAClient(tsaMock.TSAClientOptions{Time: time.Now()})
+	if err != nil {
+		t.Fatalf("NewTSAClient() = %v", err)
+	}
+	withTimestamp := func(env []byte) []static.Option {
+		resp, err := tsa.GetTimestampedSignature(env, tsaClient)
+		if err != nil {
+			t.Fatalf("GetTimestampedSignature() = %v", err)
+		}
+		return []static.Option{static.WithRFC3161Timestamp(bundle.TimestampToRFC3161Timestamp(resp))}
+	}
+
+	t.Run("timestamp not trusted without a TSA chain", func(t *testing.T) {
+		if _, err := mustVerifiedAttestation(t, sv, statement, &cosign.CheckOpts{IgnoreTlog: true}, withTimestamp); err == nil {
+			t.Error("VerifyImageAttestation() with an unverifiable timestamp succeeded, wanted error")
+		}
+	})
+
+	t.Run("verified timestamp", func(t *testing.T) {
+		chainPEM, err := cryptoutils.MarshalCertificatesToPEM(tsaClient.CertChain)
+		if err != nil {
+			t.Fatalf("MarshalCertificatesToPEM() = %v", err)
+		}
+		leaves, intermediates, roots, err := tsa.SplitPEMCertificateChain(chainPEM)
+		if err != nil {
+			t.Fatalf("SplitPEMCertificateChain() = %v", err)
+		}
+		co := &cosign.CheckOpts{
+			IgnoreTlog:                  true,
+			TSACertificate:              leaves[0],
+			TSAIntermediateCertificates: intermediates,
+			TSARootCertificates:         roots,
+		}
+		checked, err := mustVerifiedAttestation(t, sv, statement, co, withTimestamp)
+		if err != nil {
+			t.Fatalf("VerifyImageAttestation() = %v", err)
+		}
+		if err := CheckAttestationFreshness(checked, time.Hour, time.Now(), false); err != nil {
+			t.Errorf("CheckAttestationFreshness() = %v", err)
+		}
+		if err := CheckAttestationFreshness(checked, time.Hour, time.Now().Add(48*time.Hour), false); err == nil {
+			t.Error("CheckAttestationFreshness() of a stale attestation succeeded, wanted error")
+		}
+	})
+}
+
+func TestCheckAttestationFreshness(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := mustAttestation(t, `{"predicate":{"timestamp":"2024-01-01T00:00:00Z"}}`)
+	recent := mustAttestation(t, `{"predicate":{"timestamp":"2024-05-31T00:00:00Z"}}`)
+	undated := mustAttestation(t, `{"predicate":{}}`)
+
+	if err := CheckAttestationFreshness([]oci.Signature{old, recent}, 48*time.Hour, now, true); err != nil {
+		t.Errorf("CheckAttestationFreshness() with a recent attestation = %v", err)
+	}
+	if err := CheckAttestationFreshness([]oci.Signature{old, recent}, 48*time.Hour, now, false); err == nil {
+		t.Error("CheckAttestationFreshness() trusted statement time without opting in, wanted error")
+	}
+	if err := CheckAttestationFreshness([]oci.Signature{old, undated}, 48*time.Hour, now, true); err == nil {
+		t.Error("CheckAttestationFreshness() with only stale attestations succeeded, wanted error")
+	}
+	if err := CheckAttestationFreshness([]oci.Signature{undated}, 48*time.Hour, now, true); err == nil {
+		t.Error("CheckAttestationFreshness() with undated attestations succeeded, wanted error")
+	}
+	if err := CheckAttestationFreshness(nil, 48*time.Hour, now, true); err == nil {
+		t.Error("CheckAttestationFreshness() with no attestations succeeded, wanted error")
+	}
+}
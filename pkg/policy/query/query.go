@@ -0,0 +1,532 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package query evaluates jq-like queries over JSON documents, such as the
+// predicates of attestations.
+//
+// A query is a path, such as .packages[].name, optionally compared with a
+// JSON value, as in .packages[].name == "openssl", or tested with one of the
+// functions startswith, endswith, contains and test, as in
+// .packages[].versionInfo | startswith("3.0."). Paths select object fields
+// with .name or ["name"], array elements with [n] and every element of an
+// array or object with []. Queries are combined with and, or and
+// parentheses, and a path followed by | and a query applies that query to
+// each value the path selects, as in
+// .packages[] | .name == "openssl" and (.versionInfo | startswith("3.0.")).
+//
+// Since a path may select many values, a comparison matches if any of them
+// satisfies it, and a bare path matches if any of them is neither null nor
+// false.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Query is a parsed query.
+type Query struct {
+	src  string
+	root node
+}
+
+// Parse parses the query s.
+func Parse(s string) (*Query, error) {
+	p := &parser{src: s}
+	if err := p.lex(); err != nil {
+		return nil, fmt.Errorf("parsing query %q: %w", s, err)
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("parsing query %q: %w", s, err)
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("parsing query %q: unexpected %q at offset %d", s, t.text, t.pos)
+	}
+	return &Query{src: s, root: root}, nil
+}
+
+// String returns the query as it was parsed.
+func (q *Query) String() string {
+	return q.src
+}
+
+// Match reports whether the JSON value v, as decoded by encoding/json into
+// an interface{}, satisfies the query.
+func (q *Query) Match(v interface{}) bool {
+	return q.root.match(v)
+}
+
+// MatchJSON reports whether the JSON document b satisfies the query.
+func (q *Query) MatchJSON(b []byte) (bool, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return false, err
+	}
+	return q.Match(v), nil
+}
+
+type node interface {
+	match(v interface{}) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(v interface{}) bool { return n.left.match(v) || n.right.match(v) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(v interface{}) bool { return n.left.match(v) && n.right.match(v) }
+
+// segment selects the values within a value, see path.
+type segment func(v interface{}) []interface{}
+
+// path selects values within a document, one segment after another.
+type path []segment
+
+func (p path) eval(v interface{}) []interface{} {
+	values := []interface{}{v}
+	for _, seg := range p {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, seg(v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func field(name string) segment {
+	return func(v interface{}) []interface{} {
+		if m, ok := v.(map[string]interface{}); ok {
+			return []interface{}{m[name]}
+		}
+		return nil
+	}
+}
+
+func element(i int) segment {
+	return func(v interface{}) []interface{} {
+		a, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		j := i
+		if j < 0 {
+			j += len(a)
+		}
+		if j < 0 || j >= len(a) {
+			return []interface{}{nil}
+		}
+		return []interface{}{a[j]}
+	}
+}
+
+func elements(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			values = append(values, e)
+		}
+		return values
+	}
+	return nil
+}
+
+// testNode matches if any value selected by path satisfies test.
+type testNode struct {
+	path path
+	test func(v interface{}) bool
+}
+
+func (n testNode) match(v interface{}) bool {
+	for _, v := range n.path.eval(v) {
+		if n.test(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func truthy(v interface{}) bool {
+	return v != nil && v != false
+}
+
+// compare returns a test comparing values with want using op.
+func compare(op string, want interface{}) func(v interface{}) bool {
+	switch op {
+	case "==":
+		return func(v interface{}) bool { return reflect.DeepEqual(v, want) }
+	case "!=":
+		return func(v interface{}) bool { return !reflect.DeepEqual(v, want) }
+	}
+	return func(v interface{}) bool {
+		var c int
+		switch v := v.(type) {
+		case float64:
+			w, ok := want.(float64)
+			if !ok {
+				return false
+			}
+			switch {
+			case v < w:
+				c = -1
+			case v > w:
+				c = 1
+			}
+		case string:
+			w, ok := want.(string)
+			if !ok {
+				return false
+			}
+			c = strings.Compare(v, w)
+		default:
+			return false
+		}
+		switch op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default: // ">="
+			return c >= 0
+		}
+	}
+}
+
+// function returns a test applying the string function name with arg.
+func function(name, arg string) (func(v interface{}) bool, error) {
+	var f func(s string) bool
+	switch name {
+	case "startswith":
+		f = func(s string) bool { return strings.HasPrefix(s, arg) }
+	case "endswith":
+		f = func(s string) bool { return strings.HasSuffix(s, arg) }
+	case "contains":
+		f = func(s string) bool { return strings.Contains(s, arg) }
+	case "test":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		f = re.MatchString
+	default:
+		return nil, fmt.Errorf("unknown function %s, expected startswith, endswith, contains or test", name)
+	}
+	return func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && f(s)
+	}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokIdent
+	tokString
+	tokNumber
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src    string
+	tokens []token
+}
+
+// punctuation lists the punctuation tokens, longest first so that "<=" is
+// not mistaken for "<".
+var punctuation = []string{"==", "!=", "<=", ">=", "<", ">", ".", "[", "]", "(", ")", "|"}
+
+func (p *parser) lex() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return fmt.Errorf("unterminated string at offset %d", i)
+			}
+			p.tokens = append(p.tokens, token{kind: tokString, text: s[i : j+1], pos: i})
+			i = j + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789.eE+-", s[j]) >= 0 {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: s[i:j], pos: i})
+			i = j
+		case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || (s[j]|0x20 >= 'a' && s[j]|0x20 <= 'z') || (s[j] >= '0' && s[j] <= '9')) {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: s[i:j], pos: i})
+			i = j
+		default:
+			found := false
+			for _, punct := range punctuation {
+				if strings.HasPrefix(s[i:], punct) {
+					p.tokens = append(p.tokens, token{kind: tokPunct, text: punct, pos: i})
+					i += len(punct)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) peek() token {
+	if len(p.tokens) == 0 {
+		return token{kind: tokEOF, pos: len(p.src)}
+	}
+	return p.tokens[0]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+// isCall reports whether the next tokens are a function name and "(".
+func (p *parser) isCall() bool {
+	return len(p.tokens) > 1 && p.tokens[0].kind == tokIdent && p.tokens[1].kind == tokPunct && p.tokens[1].text == "("
+}
+
+// accept consumes the next token if it is the punctuation or keyword text.
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return fmt.Errorf("expected %q at the end", text)
+		}
+		return fmt.Errorf("expected %q at offset %d, found %q", text, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.accept("(") {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	switch {
+	case t.kind == tokPunct && strings.ContainsAny(t.text, "=!<>"):
+		p.next()
+		want, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return testNode{path: path, test: compare(t.text, want)}, nil
+	case p.accept("|"):
+		if !p.isCall() {
+			// The rest of the query applies to each selected value.
+			sub, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return testNode{path: path, test: sub.match}, nil
+		}
+		name := p.next()
+		p.next() // (
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a string", name.text)
+		}
+		test, err := function(name.text, s)
+		if err != nil {
+			return nil, err
+		}
+		return testNode{path: path, test: test}, p.expect(")")
+	}
+	return testNode{path: path, test: truthy}, nil
+}
+
+// parsePath parses a path, which starts with ".".
+func (p *parser) parsePath() (path, error) {
+	if err := p.expect("."); err != nil {
+		return nil, err
+	}
+	var segs path
+	// The first segment may follow the leading "." directly.
+	if t := p.peek(); t.kind == tokIdent || t.kind == tokString {
+		p.next()
+		name, err := fieldName(t)
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, field(name))
+	}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			name, err := fieldName(t)
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, field(name))
+		case p.accept("["):
+			if p.accept("]") {
+				segs = append(segs, elements)
+				continue
+			}
+			t := p.next()
+			switch t.kind {
+			case tokNumber:
+				i, err := strconv.Atoi(t.text)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q at offset %d", t.text, t.pos)
+				}
+				segs = append(segs, element(i))
+			case tokString:
+				name, err := fieldName(t)
+				if err != nil {
+					return nil, err
+				}
+				segs = append(segs, field(name))
+			default:
+				return nil, fmt.Errorf("expected an index or a string at offset %d", t.pos)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return segs, nil
+		}
+	}
+}
+
+func fieldName(t token) (string, error) {
+	switch t.kind {
+	case tokIdent:
+		return t.text, nil
+	case tokString:
+		var s string
+		if err := json.Unmarshal([]byte(t.text), &s); err != nil {
+			return "", fmt.Errorf("invalid string %s at offset %d: %w", t.text, t.pos, err)
+		}
+		return s, nil
+	}
+	if t.kind == tokEOF {
+		return "", fmt.Errorf("expected a field name at the end")
+	}
+	return "", fmt.Errorf("expected a field name at offset %d, found %q", t.pos, t.text)
+}
+
+// parseValue parses a JSON string, number, true, false or null.
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString, tokNumber:
+		var v interface{}
+		if err := json.Unmarshal([]byte(t.text), &v); err != nil {
+			return nil, fmt.Errorf("invalid value %s at offset %d: %w", t.text, t.pos, err)
+		}
+		return v, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("expected a value at the end")
+	}
+	return nil, fmt.Errorf("expected a value at offset %d, found %q", t.pos, t.text)
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"testing"
+)
+
+const spdx = `{
+	"spdxVersion": "SPDX-2.3",
+	"name": "app",
+	"packages": [
+		{"name": "openssl", "versionInfo": "3.0.13", "files": 12},
+		{"name": "zlib", "versionInfo": "1.3.1", "files": 3}
+	],
+	"creationInfo": {"creators": ["Tool: syft"], "licenseListVersion": "3.22"},
+	"odd key": true
+}`
+
+func TestMatchJSON(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: `.packages[].name == "openssl"`, want: true},
+		{query: `.packages[].name == "curl"`},
+		{query: `.packages[].versionInfo | startswith("3.0.")`, want: true},
+		{query: `.packages[].versionInfo | startswith("3.1.")`},
+		{query: `.packages[].versionInfo | endswith(".1")`, want: true},
+		{query: `.packages[].name | contains("ssl")`, want: true},
+		{query: `.packages[].versionInfo | test("^3\\.0\\.1[0-9]$")`, want: true},
+		{query: `.packages[0].name == "openssl"`, want: true},
+		{query: `.packages[-1].name == "openssl"`},
+		{query: `.packages[5].name`},
+		{query: `.packages[].files > 10`, want: true},
+		{query: `.packages[].files >= 13`},
+		{query: `.packages[].files < 3`},
+		{query: `.packages[].files <= 3`, want: true},
+		{query: `.spdxVersion != "SPDX-2.3"`},
+		{query: `.name > "aaa"`, want: true},
+		{query: `.name > 1`},
+		{query: `.creationInfo.creators[] == "Tool: syft"`, want: true},
+		{query: `.creationInfo["licenseListVersion"] == "3.22"`, want: true},
+		{query: `.["odd key"]`, want: true},
+		{query: `."odd key" == true`, want: true},
+		{query: `.missing`},
+		{query: `.missing == null`, want: true},
+		{query: `.creationInfo[]`, want: true},
+		{query: `.packages[].name == "curl" or .packages[].name == "zlib"`, want: true},
+		{query: `.packages[].name == "openssl" and .packages[].name == "curl"`},
+		{query: `(.name == "x" or .name == "app") and .packages[].files == 3`, want: true},
+		{query: `.`, want: true},
+		{query: `.packages[] | .name == "openssl" and (.versionInfo | startswith("3.0."))`, want: true},
+		{query: `.packages[] | .name == "zlib" and (.versionInfo | startswith("3.0."))`},
+		{query: `.packages[] | .name == "zlib" and .files == 3`, want: true},
+		{query: `.creationInfo | .creators[] | contains("syft")`, want: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := Parse(tc.query)
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+			got, err := q.MatchJSON([]byte(spdx))
+			if err != nil {
+				t.Fatalf("MatchJSON() = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("MatchJSON() = %v, wanted %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`packages`,
+		`.packages[`,
+		`.packages[x]`,
+		`.name ==`,
+		`.name == "unterminated`,
+		`.name | lower("x")`,
+		`.name | test("[")`,
+		`.name | startswith(1)`,
+		`(.name == "app"`,
+		`.name == "app" .version`,
+		`.name # comment`,
+		`.packages[] |`,
+		`.packages[] | startswith`,
+	} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("Parse(%q) succeeded, wanted an error", query)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+// SigningInput is the JSON document that signing policies are evaluated
+// against before an image is signed, so that producers can refuse to sign
+// images that do not meet their own preconditions.
+type SigningInput struct {
+	// Image is the reference by digest of the image to be signed.
+	Image string `json:"image"`
+	// Repository is the repository of Image, such as "example.com/app".
+	Repository string `json:"repository"`
+	// Digest is the digest of Image.
+	Digest string `json:"digest"`
+	// Attestations are the verified attestations of Image.
+	Attestations []SigningAttestation `json:"attestations"`
+}
+
+// SigningAttestation is an attestation in a SigningInput.
+type SigningAttestation struct {
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+	// Time is when the attestation was made, see AttestationTime, and is
+	// omitted if it cannot be determined.
+	Time *time.Time `json:"time,omitempty"`
+}
+
+// NewSigningInput returns the SigningInput for signing digest, which has the
+// attestations atts.
+//
+// Anything fed here must have been validated with either
+// `VerifyLocalImageAttestations` or `VerifyImageAttestations`
+func NewSigningInput(digest name.Digest, atts []oci.Signature) (*SigningInput, error) {
+	in := &SigningInput{
+		Image:        digest.String(),
+		Repository:   digest.Context().Name(),
+		Digest:       digest.DigestStr(),
+		Attestations: make([]SigningAttestation, 0, len(atts)),
+	}
+	for _, att := range atts {
+		decoded, err := decodeStatement(att)
+		if err != nil {
+			return nil, err
+		}
+		var a SigningAttestation
+		if err := json.Unmarshal(decoded, &a); err != nil {
+			return nil, fmt.Errorf("unmarshal in-toto statement: %w", err)
+		}
+		if t, err := AttestationTime(att, true); err == nil {
+			t = t.UTC()
+			a.Time = &t
+		}
+		in.Attestations = append(in.Attestations, a)
+	}
+	return in, nil
+}
+
+// CheckSigningPolicy evaluates in against each of evaluators. It returns the
+// warnings of all of them, and an EvaluationFailure listing every violation
+// if any rejects in.
+func CheckSigningPolicy(ctx context.Context, in *SigningInput, evaluators []PolicyEvaluator) (error, error) {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling signing policy input: %w", err)
+	}
+	var warns, errs []error
+	for _, evaluator := range evaluators {
+		warn, err := evaluator.Evaluate(ctx, [][]byte{payload})
+		if warn != nil {
+			warns = append(warns, warn)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(warns...), &EvaluationFailure{
+			fmt.Errorf("%s does not meet the signing policy: %w", in.Image, errors.Join(errs...)),
+		}
+	}
+	return errors.Join(warns...), nil
+}
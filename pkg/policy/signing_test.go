@@ -0,0 +1,104 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/franchb/cosign/v2/pkg/oci"
+)
+
+const signingTestImage = "example.com/app@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+func TestNewSigningInput(t *testing.T) {
+	digest := name.MustParseReference(signingTestImage).(name.Digest)
+	atts := []oci.Signature{
+		mustAttestation(t, `{"predicateType":"https://cosign.sigstore.dev/attestation/vuln/v1","predicate":{"scanner":{"result":{}}, "timestamp":"2024-01-02T03:04:05Z"}}`),
+		mustAttestation(t, `{"predicateType":"https://example.com/untimed","predicate":{}}`),
+	}
+	in, err := NewSigningInput(digest, atts)
+	if err != nil {
+		t.Fatalf("NewSigningInput() = %v", err)
+	}
+	if in.Image != digest.String() || in.Repository != "example.com/app" || in.Digest != digest.DigestStr() {
+		t.Errorf("NewSigningInput() = %+v, wanted the image, repository and digest of %s", in, digest)
+	}
+	if len(in.Attestations) != 2 {
+		t.Fatalf("NewSigningInput() attestations = %+v, wanted 2", in.Attestations)
+	}
+	if got := in.Attestations[0]; got.PredicateType != "https://cosign.sigstore.dev/attestation/vuln/v1" || got.Time == nil || !got.Time.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("first attestation = %+v, wanted the vuln predicate made at 2024-01-02T03:04:05Z", got)
+	}
+	if got := in.Attestations[1]; got.Time != nil {
+		t.Errorf("second attestation time = %v, wanted none", got.Time)
+	}
+
+	// An image without attestations still has a list of them.
+	in, err = NewSigningInput(digest, nil)
+	if err != nil {
+		t.Fatalf("NewSigningInput() = %v", err)
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	if !strings.Contains(string(b), `"attestations":[]`) {
+		t.Errorf("Marshal() = %s, wanted an empty list of attestations", b)
+	}
+}
+
+func TestCheckSigningPolicy(t *testing.T) {
+	digest := name.MustParseReference(signingTestImage).(name.Digest)
+	in, err := NewSigningInput(digest, nil)
+	if err != nil {
+		t.Fatalf("NewSigningInput() = %v", err)
+	}
+
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed.cue")
+	if err := os.WriteFile(allowed, []byte(`repository: "example.com/app"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other.cue")
+	if err := os.WriteFile(other, []byte(`repository: "example.com/other"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	warn := evaluatorFunc(func(context.Context, []byte) (error, error) {
+		return errors.New("checked loosely"), nil
+	})
+
+	warnings, err := CheckSigningPolicy(context.Background(), in, []PolicyEvaluator{NewCUEFileEvaluator([]string{allowed}), warn})
+	if err != nil {
+		t.Errorf("CheckSigningPolicy() = %v", err)
+	}
+	if warnings == nil || !strings.Contains(warnings.Error(), "checked loosely") {
+		t.Errorf("CheckSigningPolicy() warnings = %v, wanted checked loosely", warnings)
+	}
+
+	_, err = CheckSigningPolicy(context.Background(), in, []PolicyEvaluator{NewCUEFileEvaluator([]string{other})})
+	var failure *EvaluationFailure
+	if !errors.As(err, &failure) || !strings.Contains(err.Error(), digest.String()) {
+		t.Errorf("CheckSigningPolicy() = %v, wanted an EvaluationFailure naming %s", err, digest)
+	}
+}
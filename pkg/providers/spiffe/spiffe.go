@@ -17,8 +17,11 @@ package spiffe
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
 
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
 
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
@@ -50,29 +53,57 @@ func getSocketPath() string {
 	return defaultSocketPath
 }
 
+// getSocketAddr returns the address of the Workload API. The socket may be
+// given as a path or, as the SPIFFE Workload Endpoint specification has it,
+// as a unix:// or tcp:// URI.
+func getSocketAddr() string {
+	socket := getSocketPath()
+	if strings.HasPrefix(socket, "unix:") || strings.HasPrefix(socket, "tcp:") {
+		return socket
+	}
+	return "unix://" + socket
+}
+
 // Enabled implements providers.Interface
 func (ga *spiffe) Enabled(_ context.Context) bool {
+	addr := getSocketAddr()
+	path, ok := strings.CutPrefix(addr, "unix://")
+	if !ok {
+		// A TCP endpoint can only be probed by connecting to it.
+		return strings.HasPrefix(addr, "tcp:")
+	}
 	// If we can stat the file without error then this is enabled.
-	_, err := os.Stat(getSocketPath())
+	_, err := os.Stat(path)
 	return err == nil
 }
 
 // Provide implements providers.Interface
 func (ga *spiffe) Provide(ctx context.Context, audience string) (string, error) {
+	params := jwtsvid.Params{
+		Audience: audience,
+	}
+	// A workload entitled to several SPIFFE IDs picks the one to sign as,
+	// otherwise the Workload API returns the first of them.
+	if id := env.Getenv(env.VariableSPIFFEID); id != "" {
+		subject, err := spiffeid.FromString(id)
+		if err != nil {
+			return "", fmt.Errorf("parsing %s: %w", env.VariableSPIFFEID, err)
+		}
+		params.Subject = subject
+	}
+
 	// Creates a new Workload API client, connecting to provided socket path
 	// Environment variable `SPIFFE_ENDPOINT_SOCKET` is used if given and
 	// defaultSocketPath if not.
-	client, err := workloadapi.New(ctx, workloadapi.WithAddr("unix://"+getSocketPath()))
+	client, err := workloadapi.New(ctx, workloadapi.WithAddr(getSocketAddr()))
 	if err != nil {
 		return "", err
 	}
 	defer client.Close()
 
-	svid, err := client.FetchJWTSVID(ctx, jwtsvid.Params{
-		Audience: audience,
-	})
+	svid, err := client.FetchJWTSVID(ctx, params)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("fetching JWT-SVID: %w", err)
 	}
 
 	return svid.Marshal(), nil
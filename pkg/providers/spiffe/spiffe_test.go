@@ -15,9 +15,20 @@
 package spiffe
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net"
 	"os"
-
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"google.golang.org/grpc"
 )
 
 const nonDefault = "/run/sockets/spire"
@@ -31,3 +42,99 @@ func TestGetSocketPath(t *testing.T) {
 		t.Errorf("Expected %s got %s", nonDefault, got)
 	}
 }
+
+func TestGetSocketAddr(t *testing.T) {
+	for socket, want := range map[string]string{
+		"/run/sockets/spire":        "unix:///run/sockets/spire",
+		"unix:///run/sockets/spire": "unix:///run/sockets/spire",
+		"tcp://127.0.0.1:8081":      "tcp://127.0.0.1:8081",
+	} {
+		t.Setenv("SPIFFE_ENDPOINT_SOCKET", socket)
+		if got := getSocketAddr(); got != want {
+			t.Errorf("getSocketAddr() with %s = %s, wanted %s", socket, got, want)
+		}
+	}
+}
+
+// fakeWorkloadAPI hands out a JWT-SVID for each of its SPIFFE IDs.
+type fakeWorkloadAPI struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+	ids []string
+}
+
+func (f *fakeWorkloadAPI) FetchJWTSVID(_ context.Context, req *workload.JWTSVIDRequest) (*workload.JWTSVIDResponse, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, err
+	}
+	resp := &workload.JWTSVIDResponse{}
+	for _, id := range f.ids {
+		if req.SpiffeId != "" && req.SpiffeId != id {
+			continue
+		}
+		token, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Subject:  id,
+			Audience: jwt.Audience(req.Audience),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}).Serialize()
+		if err != nil {
+			return nil, err
+		}
+		resp.Svids = append(resp.Svids, &workload.JWTSVID{SpiffeId: id, Svid: token})
+	}
+	return resp, nil
+}
+
+func TestProvide(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, &fakeWorkloadAPI{
+		ids: []string{"spiffe://example.org/first", "spiffe://example.org/builder"},
+	})
+	go server.Serve(lis) //nolint: errcheck
+	t.Cleanup(server.Stop)
+
+	t.Setenv("SPIFFE_ENDPOINT_SOCKET", "unix://"+socket)
+	p := &spiffe{}
+	if !p.Enabled(context.Background()) {
+		t.Fatal("Enabled() = false with a Workload API socket")
+	}
+
+	for id, want := range map[string]string{
+		"":                             "spiffe://example.org/first",
+		"spiffe://example.org/builder": "spiffe://example.org/builder",
+	} {
+		t.Setenv("COSIGN_SPIFFE_ID", id)
+		token, err := p.Provide(context.Background(), "sigstore")
+		if err != nil {
+			t.Fatalf("Provide() = %v", err)
+		}
+		parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{jose.ES256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var claims jwt.Claims
+		if err := parsed.UnsafeClaimsWithoutVerification(&claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims.Subject != want {
+			t.Errorf("Provide() returned a JWT-SVID for %s, wanted %s", claims.Subject, want)
+		}
+		if !claims.Audience.Contains("sigstore") {
+			t.Errorf("Provide() returned a JWT-SVID for audience %v, wanted sigstore", claims.Audience)
+		}
+	}
+
+	t.Setenv("COSIGN_SPIFFE_ID", "not a spiffe id")
+	if _, err := p.Provide(context.Background(), "sigstore"); err == nil {
+		t.Error("Provide() succeeded with an invalid COSIGN_SPIFFE_ID")
+	}
+}
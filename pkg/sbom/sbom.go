@@ -0,0 +1,70 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom generates the SBOMs of images, to attest them without a
+// separate SBOM stage.
+package sbom
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// GeneratorSyft generates SBOMs with the syft library.
+const GeneratorSyft = "syft"
+
+// Format is the format of a generated SBOM.
+type Format string
+
+const (
+	FormatSPDXJSON      Format = "spdx-json"
+	FormatCycloneDXJSON Format = "cyclonedx-json"
+)
+
+// Options configure how generators pull the image.
+type Options struct {
+	// Keychain resolves the credentials of the registry.
+	Keychain authn.Keychain
+	// AllowInsecure skips the verification of the TLS certificate of the
+	// registry.
+	AllowInsecure bool
+	// AllowHTTP allows pulling from the registry over plain HTTP.
+	AllowHTTP bool
+}
+
+// Generator generates the SBOM of the image at ref in format.
+type Generator func(ctx context.Context, ref name.Digest, format Format, opts Options) ([]byte, error)
+
+var generators = map[string]Generator{
+	GeneratorSyft: generateSyft,
+}
+
+// GeneratorFor returns the generator called name.
+func GeneratorFor(name string) (Generator, error) {
+	g, ok := generators[name]
+	if !ok {
+		names := make([]string, 0, len(generators))
+		for n := range generators {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown SBOM generator %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return g, nil
+}
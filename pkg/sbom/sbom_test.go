@@ -0,0 +1,33 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratorFor(t *testing.T) {
+	if g, err := GeneratorFor(GeneratorSyft); err != nil || g == nil {
+		t.Errorf("GeneratorFor(%q) = %v, %v, want the syft generator", GeneratorSyft, g, err)
+	}
+	_, err := GeneratorFor("trivy")
+	if err == nil {
+		t.Fatal("GeneratorFor() of an unknown generator succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), GeneratorSyft) {
+		t.Errorf("GeneratorFor() error %q does not list the generators", err)
+	}
+}
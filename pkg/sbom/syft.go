@@ -0,0 +1,68 @@
+//go:build syft
+// +build syft
+
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anchore/stereoscope/pkg/image"
+	"github.com/anchore/syft/syft"
+	"github.com/anchore/syft/syft/format"
+	"github.com/anchore/syft/syft/format/cyclonedxjson"
+	"github.com/anchore/syft/syft/format/spdxjson"
+	syftsbom "github.com/anchore/syft/syft/sbom"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// generateSyft catalogs the packages of the image at ref with syft, pulling
+// the image from its registry rather than from a local daemon.
+func generateSyft(ctx context.Context, ref name.Digest, f Format, opts Options) ([]byte, error) {
+	var encoder syftsbom.FormatEncoder
+	var err error
+	switch f {
+	case FormatSPDXJSON:
+		encoder, err = spdxjson.NewFormatEncoderWithConfig(spdxjson.DefaultEncoderConfig())
+	case FormatCycloneDXJSON:
+		encoder, err = cyclonedxjson.NewFormatEncoderWithConfig(cyclonedxjson.DefaultEncoderConfig())
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format %s", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := syft.DefaultGetSourceConfig().
+		WithSources("registry").
+		WithRegistryOptions(&image.RegistryOptions{
+			InsecureSkipTLSVerify: opts.AllowInsecure,
+			InsecureUseHTTP:       opts.AllowHTTP,
+			Keychain:              opts.Keychain,
+		})
+	src, err := syft.GetSource(ctx, ref.String(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getting image %s: %w", ref, err)
+	}
+	defer src.Close()
+
+	s, err := syft.CreateSBOM(ctx, src, syft.DefaultCreateSBOMConfig())
+	if err != nil {
+		return nil, fmt.Errorf("cataloging image %s: %w", ref, err)
+	}
+	return format.Encode(*s, encoder)
+}
@@ -0,0 +1,31 @@
+//go:build !syft
+// +build !syft
+
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// generateSyft is unavailable without the syft build tag, as the syft
+// library and its scanners add much to the size of cosign.
+func generateSyft(context.Context, name.Digest, Format, Options) ([]byte, error) {
+	return nil, errors.New("cosign was built without syft, build it with -tags syft to generate SBOMs with it")
+}
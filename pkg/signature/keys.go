@@ -17,12 +17,15 @@ package signature
 import (
 	"context"
 	"crypto"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"strings"
 
+	"filippo.io/age"
 	"github.com/franchb/cosign/v2/pkg/blob"
 	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
 	"github.com/franchb/cosign/v2/pkg/cosign/git"
 	"github.com/franchb/cosign/v2/pkg/cosign/git/gitlab"
 	"github.com/franchb/cosign/v2/pkg/cosign/kubernetes"
@@ -43,7 +46,7 @@ func LoadPublicKey(ctx context.Context, keyRef string) (verifier signature.Verif
 func VerifierForKeyRef(ctx context.Context, keyRef string, hashAlgorithm crypto.Hash) (verifier signature.Verifier, err error) {
 	// The key could be plaintext, in a file, at a URL, or in KMS.
 	var perr *kms.ProviderNotFoundError
-	kmsKey, err := kms.Get(ctx, keyRef, hashAlgorithm)
+	kmsKey, err := kmsVerifier(ctx, keyRef, hashAlgorithm)
 	switch {
 	case err == nil:
 		// KMS specified
@@ -63,20 +66,51 @@ func VerifierForKeyRef(ctx context.Context, keyRef string, hashAlgorithm crypto.
 		return nil, err
 	}
 
+	// Key ring of several public keys, for key rotation.
+	if cosign.IsKeyRing(raw) {
+		ring, err := cosign.LoadKeyRing(raw, hashAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("loading key ring: %w", err)
+		}
+		return ring, nil
+	}
+
 	// PEM encoded file.
-	pubKey, err := cryptoutils.UnmarshalPEMToPublicKey(raw)
+	verifier, err = cosign.LoadPublicKeyPEM(raw, hashAlgorithm)
 	if err != nil {
 		return nil, fmt.Errorf("pem to public key: %w", err)
 	}
+	return verifier, nil
+}
 
-	return signature.LoadVerifier(pubKey, hashAlgorithm)
+// privateKeyLoader loads cosign private keys, either as they sign by
+// default or deterministically.
+type privateKeyLoader struct {
+	load    func(key, pass []byte) (signature.SignerVerifier, error)
+	loadAge func(key []byte, identities []age.Identity) (signature.SignerVerifier, error)
 }
 
-func loadKey(keyPath string, pf cosign.PassFunc) (signature.SignerVerifier, error) {
+var (
+	defaultLoader       = privateKeyLoader{load: cosign.LoadPrivateKey, loadAge: cosign.LoadAgePrivateKey}
+	deterministicLoader = privateKeyLoader{load: cosign.LoadDeterministicPrivateKey, loadAge: cosign.LoadDeterministicAgePrivateKey}
+)
+
+func loadKey(keyPath string, pf cosign.PassFunc, loader privateKeyLoader) (signature.SignerVerifier, error) {
 	kb, err := blob.LoadFileOrURL(keyPath)
 	if err != nil {
 		return nil, err
 	}
+	if cosign.IsAgeEncrypted(kb) {
+		identityFile := env.Getenv(env.VariableAgeIdentityFile)
+		if identityFile == "" {
+			return nil, fmt.Errorf("%s is encrypted with age, set %s to an age identity file to decrypt it", keyPath, env.VariableAgeIdentityFile)
+		}
+		identities, err := cosign.LoadAgeIdentities(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		return loader.loadAge(kb, identities)
+	}
 	pass := []byte{}
 	if pf != nil {
 		pass, err = pf(false)
@@ -84,16 +118,12 @@ func loadKey(keyPath string, pf cosign.PassFunc) (signature.SignerVerifier, erro
 			return nil, err
 		}
 	}
-	return cosign.LoadPrivateKey(kb, pass)
+	return loader.load(kb, pass)
 }
 
 // LoadPublicKeyRaw loads a verifier from a PEM-encoded public key
 func LoadPublicKeyRaw(raw []byte, hashAlgorithm crypto.Hash) (signature.Verifier, error) {
-	pub, err := cryptoutils.UnmarshalPEMToPublicKey(raw)
-	if err != nil {
-		return nil, err
-	}
-	return signature.LoadVerifier(pub, hashAlgorithm)
+	return cosign.LoadPublicKeyPEM(raw, hashAlgorithm)
 }
 
 func SignerFromKeyRef(ctx context.Context, keyRef string, pf cosign.PassFunc) (signature.Signer, error) {
@@ -101,6 +131,27 @@ func SignerFromKeyRef(ctx context.Context, keyRef string, pf cosign.PassFunc) (s
 }
 
 func SignerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.PassFunc) (signature.SignerVerifier, error) {
+	return signerVerifierFromKeyRef(ctx, keyRef, pf, defaultLoader)
+}
+
+// DeterministicSignerVerifierFromKeyRef is like SignerVerifierFromKeyRef, but
+// the returned SignerVerifier signs the same message with the same
+// signature, see cosign.LoadDeterministicPrivateKey. Only cosign private
+// keys can sign deterministically: keys of KMSs and PKCS #11 tokens are
+// rejected.
+func DeterministicSignerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.PassFunc) (signature.SignerVerifier, error) {
+	if strings.HasPrefix(keyRef, pkcs11key.ReferenceScheme) {
+		return nil, errors.New("PKCS #11 keys cannot sign deterministically")
+	}
+	for _, p := range kms.SupportedProviders() {
+		if strings.HasPrefix(keyRef, p) {
+			return nil, errors.New("KMS keys cannot sign deterministically")
+		}
+	}
+	return signerVerifierFromKeyRef(ctx, keyRef, pf, deterministicLoader)
+}
+
+func signerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.PassFunc, loader privateKeyLoader) (signature.SignerVerifier, error) {
 	switch {
 	case strings.HasPrefix(keyRef, pkcs11key.ReferenceScheme):
 		pkcs11UriConfig := pkcs11key.NewPkcs11UriConfig()
@@ -129,7 +180,7 @@ func SignerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.Pass
 		}
 
 		if len(s.Data) > 0 {
-			return cosign.LoadPrivateKey(s.Data["cosign.key"], s.Data["cosign.password"])
+			return loader.load(s.Data["cosign.key"], s.Data["cosign.password"])
 		}
 	case strings.HasPrefix(keyRef, gitlab.ReferenceScheme):
 		split := strings.Split(keyRef, "://")
@@ -150,7 +201,7 @@ func SignerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.Pass
 			return nil, err
 		}
 
-		return cosign.LoadPrivateKey([]byte(pk), []byte(pass))
+		return loader.load([]byte(pk), []byte(pass))
 	}
 
 	if strings.Contains(keyRef, "://") {
@@ -165,7 +216,7 @@ func SignerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.Pass
 		// ProviderNotFoundError is okay; loadKey handles other URL schemes
 	}
 
-	return loadKey(keyRef, pf)
+	return loadKey(keyRef, pf, loader)
 }
 
 func PublicKeyFromKeyRef(ctx context.Context, keyRef string) (signature.Verifier, error) {
@@ -231,5 +282,16 @@ func PublicKeyPem(key signature.PublicKeyProvider, pkOpts ...signature.PublicKey
 	if err != nil {
 		return nil, err
 	}
+	// Keep RSA-PSS keys marked as such, so that they are verified with PSS.
+	pss := false
+	switch k := key.(type) {
+	case *signature.RSAPSSSignerVerifier, *signature.RSAPSSVerifier:
+		pss = true
+	case *pkcs11key.Key:
+		pss = k.RSAPSS()
+	}
+	if rsaPub, ok := pub.(*rsa.PublicKey); ok && pss {
+		return cosign.MarshalRSAPSSPublicKeyToPEM(rsaPub)
+	}
 	return cryptoutils.MarshalPublicKeyToPEM(pub)
 }
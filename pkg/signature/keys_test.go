@@ -15,16 +15,20 @@
 package signature
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"filippo.io/age"
 	"github.com/franchb/cosign/v2/pkg/blob"
 	"github.com/franchb/cosign/v2/pkg/cosign"
 	sigsignature "github.com/franchb/sigstore/pkg/signature"
 	"github.com/franchb/sigstore/pkg/signature/kms"
+	"github.com/franchb/sigstore/pkg/signature/kms/fake"
 )
 
 func generateKeyFile(t *testing.T, tmpDir string, pf cosign.PassFunc) (privFile, pubFile string) {
@@ -100,6 +104,43 @@ func TestSignerFromPrivateKeyFileRef(t *testing.T) {
 	}
 }
 
+func TestSignerFromAgeKeyFileRef(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := cosign.GenerateKeyPairAge(cosign.KeyPairOptions{}, []age.Recipient{id.Recipient()})
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	keyFile := filepath.Join(tmpDir, "cosign.key")
+	if err := os.WriteFile(keyFile, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(tmpDir, "key.txt")
+	if err := os.WriteFile(identityFile, []byte(id.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The password function must not be consulted for age encrypted keys.
+	noPass := func(bool) ([]byte, error) {
+		return nil, errors.New("unexpected password prompt")
+	}
+
+	t.Setenv("COSIGN_AGE_IDENTITY_FILE", "")
+	if _, err := SignerFromKeyRef(ctx, keyFile, noPass); err == nil {
+		t.Fatal("SignerFromKeyRef should have returned error without an age identity")
+	}
+
+	t.Setenv("COSIGN_AGE_IDENTITY_FILE", identityFile)
+	if _, err := SignerFromKeyRef(ctx, keyFile, noPass); err != nil {
+		t.Fatalf("SignerFromKeyRef returned error: %v", err)
+	}
+}
+
 func TestPublicKeyFromFileRef(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
@@ -111,6 +152,124 @@ func TestPublicKeyFromFileRef(t *testing.T) {
 	}
 }
 
+func TestRSAPSSKeyFileRef(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	keys, err := cosign.GenerateKeyPairWithOptions(cosign.KeyPairOptions{KeyType: cosign.KeyTypeRSA3072, RSAScheme: cosign.RSASchemePSS}, pass("whatever"))
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	keyFile := filepath.Join(tmpDir, "cosign.key")
+	pubFile := filepath.Join(tmpDir, "cosign.pub")
+	if err := os.WriteFile(keyFile, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubFile, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sv, err := SignerVerifierFromKeyRef(ctx, keyFile, pass("whatever"))
+	if err != nil {
+		t.Fatalf("SignerVerifierFromKeyRef returned error: %v", err)
+	}
+	// The public key of the loaded signer is still marked as an RSA-PSS key.
+	pem, err := PublicKeyPem(sv)
+	if err != nil {
+		t.Fatalf("PublicKeyPem returned error: %v", err)
+	}
+	if !bytes.Equal(pem, keys.PublicBytes) {
+		t.Errorf("PublicKeyPem() = %s, wanted %s", pem, keys.PublicBytes)
+	}
+
+	sig, err := sv.SignMessage(bytes.NewReader([]byte("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := PublicKeyFromKeyRef(ctx, pubFile)
+	if err != nil {
+		t.Fatalf("PublicKeyFromKeyRef returned error: %v", err)
+	}
+	if _, ok := verifier.(*sigsignature.RSAPSSVerifier); !ok {
+		t.Errorf("PublicKeyFromKeyRef() = %T, wanted *signature.RSAPSSVerifier", verifier)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("payload"))); err != nil {
+		t.Errorf("VerifySignature returned error: %v", err)
+	}
+}
+
+func TestDeterministicSignerVerifierFromKeyRef(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+	// ed25519 signs deterministically whatever Go cosign is built with.
+	keys, err := cosign.GenerateKeyPairWithOptions(cosign.KeyPairOptions{KeyType: cosign.KeyTypeED25519}, pass("whatever"))
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	keyFile, pubFile := filepath.Join(td, "cosign.key"), filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(keyFile, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubFile, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sv, err := DeterministicSignerVerifierFromKeyRef(ctx, keyFile, pass("whatever"))
+	if err != nil {
+		t.Fatalf("DeterministicSignerVerifierFromKeyRef returned error: %v", err)
+	}
+	sig, err := sv.SignMessage(bytes.NewReader([]byte("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := sv.SignMessage(bytes.NewReader([]byte("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, again) {
+		t.Error("signing the same payload twice gave different signatures")
+	}
+	verifier, err := PublicKeyFromKeyRef(ctx, pubFile)
+	if err != nil {
+		t.Fatalf("PublicKeyFromKeyRef returned error: %v", err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("payload"))); err != nil {
+		t.Errorf("VerifySignature returned error: %v", err)
+	}
+
+	for _, keyRef := range []string{fake.ReferenceScheme + "key", "pkcs11:token=cosign;object=key"} {
+		if _, err := DeterministicSignerVerifierFromKeyRef(ctx, keyRef, pass("whatever")); err == nil {
+			t.Errorf("DeterministicSignerVerifierFromKeyRef(%s) succeeded, want error", keyRef)
+		}
+	}
+}
+
+func TestPublicKeyFromKeyRingFileRef(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	var ring []byte
+	for i := 0; i < 2; i++ {
+		keys, err := cosign.GenerateKeyPair(pass("whatever"))
+		if err != nil {
+			t.Fatalf("failed to generate keypair: %v", err)
+		}
+		ring = append(ring, keys.PublicBytes...)
+	}
+	ringFile := filepath.Join(tmpDir, "keyring.pub")
+	if err := os.WriteFile(ringFile, ring, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := PublicKeyFromKeyRef(ctx, ringFile)
+	if err != nil {
+		t.Fatalf("PublicKeyFromKeyRef returned error: %v", err)
+	}
+	if _, ok := verifier.(*cosign.KeyRing); !ok {
+		t.Errorf("PublicKeyFromKeyRef() = %T, wanted *cosign.KeyRing", verifier)
+	}
+}
+
 func TestPublicKeyFromEnvVar(t *testing.T) {
 	keys, err := cosign.GenerateKeyPair(pass("whatever"))
 	if err != nil {
@@ -0,0 +1,325 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alibabakms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // HMAC-SHA1 is the signature method of the Alibaba Cloud RPC API
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EnvAccessKeyID and EnvAccessKeySecret are the AccessKey pair of a
+	// RAM user, or with EnvSecurityToken, temporary STS credentials.
+	EnvAccessKeyID     = "ALIBABA_CLOUD_ACCESS_KEY_ID"
+	EnvAccessKeySecret = "ALIBABA_CLOUD_ACCESS_KEY_SECRET" //nolint:gosec
+	EnvSecurityToken   = "ALIBABA_CLOUD_SECURITY_TOKEN"    //nolint:gosec
+	// EnvRoleArn is a RAM role to assume with the AccessKey pair.
+	EnvRoleArn = "ALIBABA_CLOUD_ROLE_ARN"
+	// EnvRoleSessionName names the session of EnvRoleArn, "cosign" by default.
+	EnvRoleSessionName = "ALIBABA_CLOUD_ROLE_SESSION_NAME"
+	// EnvECSMetadata is the RAM role attached to the ECS instance cosign
+	// runs on, to use when no AccessKey pair is set.
+	EnvECSMetadata = "ALIBABA_CLOUD_ECS_METADATA"
+
+	defaultRoleSessionName = "cosign"
+	stsVersion             = "2015-04-01"
+	ecsMetadataURL         = "http://100.100.100.200/latest/meta-data/ram/security-credentials/"
+)
+
+// credentials authenticate Alibaba Cloud API requests.
+type credentials struct {
+	AccessKeyID     string
+	AccessKeySecret string
+	SecurityToken   string
+	// Expiration is when temporary credentials expire, or zero.
+	Expiration time.Time
+}
+
+func (c *credentials) expiresSoon() bool {
+	return !c.Expiration.IsZero() && time.Until(c.Expiration) < 5*time.Minute
+}
+
+// credentialsProvider returns the credentials to sign requests with.
+type credentialsProvider interface {
+	credentials(ctx context.Context) (*credentials, error)
+}
+
+// newCredentialsProvider returns the credentialsProvider selected by the
+// environment: the AccessKey pair of EnvAccessKeyID and EnvAccessKeySecret,
+// used to assume EnvRoleArn if it is set, or the RAM role of the ECS
+// instance named by EnvECSMetadata.
+func newCredentialsProvider(region string) (credentialsProvider, error) {
+	id, secret := os.Getenv(EnvAccessKeyID), os.Getenv(EnvAccessKeySecret)
+	switch {
+	case id != "" && secret != "":
+		static := &staticCredentials{&credentials{
+			AccessKeyID:     id,
+			AccessKeySecret: secret,
+			SecurityToken:   os.Getenv(EnvSecurityToken),
+		}}
+		roleArn := os.Getenv(EnvRoleArn)
+		if roleArn == "" {
+			return static, nil
+		}
+		sessionName := os.Getenv(EnvRoleSessionName)
+		if sessionName == "" {
+			sessionName = defaultRoleSessionName
+		}
+		return &assumeRoleCredentials{
+			client:      http.DefaultClient,
+			base:        static,
+			stsURL:      "https://sts." + region + ".aliyuncs.com",
+			roleArn:     roleArn,
+			sessionName: sessionName,
+		}, nil
+	case os.Getenv(EnvECSMetadata) != "":
+		return &ecsRAMRoleCredentials{
+			client:      &http.Client{Timeout: 10 * time.Second},
+			metadataURL: ecsMetadataURL,
+			roleName:    os.Getenv(EnvECSMetadata),
+		}, nil
+	default:
+		return nil, fmt.Errorf("set %s and %s, optionally with %s, or %s to authenticate to Alibaba Cloud KMS",
+			EnvAccessKeyID, EnvAccessKeySecret, EnvRoleArn, EnvECSMetadata)
+	}
+}
+
+type staticCredentials struct {
+	creds *credentials
+}
+
+func (s *staticCredentials) credentials(context.Context) (*credentials, error) {
+	return s.creds, nil
+}
+
+// cachedCredentials holds temporary credentials until they expire soon.
+type cachedCredentials struct {
+	mu    sync.Mutex
+	creds *credentials
+}
+
+func (c *cachedCredentials) get(ctx context.Context, refresh func(context.Context) (*credentials, error)) (*credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds != nil && !c.creds.expiresSoon() {
+		return c.creds, nil
+	}
+	creds, err := refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.creds = creds
+	return creds, nil
+}
+
+// assumeRoleCredentials are the STS credentials of a RAM role assumed with
+// the base credentials.
+type assumeRoleCredentials struct {
+	client      *http.Client
+	base        credentialsProvider
+	stsURL      string
+	roleArn     string
+	sessionName string
+
+	cache cachedCredentials
+}
+
+func (a *assumeRoleCredentials) credentials(ctx context.Context) (*credentials, error) {
+	return a.cache.get(ctx, a.assumeRole)
+}
+
+func (a *assumeRoleCredentials) assumeRole(ctx context.Context) (*credentials, error) {
+	base, err := a.base.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("RoleArn", a.roleArn)
+	params.Set("RoleSessionName", a.sessionName)
+	params.Set("DurationSeconds", "3600")
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string `json:"AccessKeyId"`
+			AccessKeySecret string `json:"AccessKeySecret"`
+			SecurityToken   string `json:"SecurityToken"`
+			Expiration      string `json:"Expiration"`
+		} `json:"Credentials"`
+	}
+	if err := callRPC(ctx, a.client, base, a.stsURL, stsVersion, "AssumeRole", params, &resp); err != nil {
+		return nil, fmt.Errorf("assuming RAM role %s: %w", a.roleArn, err)
+	}
+	expiration, err := time.Parse(time.RFC3339, resp.Credentials.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("assuming RAM role %s: %w", a.roleArn, err)
+	}
+	return &credentials{
+		AccessKeyID:     resp.Credentials.AccessKeyID,
+		AccessKeySecret: resp.Credentials.AccessKeySecret,
+		SecurityToken:   resp.Credentials.SecurityToken,
+		Expiration:      expiration,
+	}, nil
+}
+
+// ecsRAMRoleCredentials are the STS credentials the ECS metadata service
+// issues for the RAM role attached to the instance.
+type ecsRAMRoleCredentials struct {
+	client      *http.Client
+	metadataURL string
+	roleName    string
+
+	cache cachedCredentials
+}
+
+func (e *ecsRAMRoleCredentials) credentials(ctx context.Context) (*credentials, error) {
+	return e.cache.get(ctx, e.fetch)
+}
+
+func (e *ecsRAMRoleCredentials) fetch(ctx context.Context) (*credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.metadataURL+url.PathEscape(e.roleName), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading ECS RAM role credentials: %w", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading ECS RAM role credentials for %s: %s", e.roleName, resp.Status)
+	}
+	var out struct {
+		Code            string `json:"Code"`
+		AccessKeyID     string `json:"AccessKeyId"`
+		AccessKeySecret string `json:"AccessKeySecret"`
+		SecurityToken   string `json:"SecurityToken"`
+		Expiration      string `json:"Expiration"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("reading ECS RAM role credentials for %s: %w", e.roleName, err)
+	}
+	if out.Code != "Success" {
+		return nil, fmt.Errorf("reading ECS RAM role credentials for %s: %s", e.roleName, out.Code)
+	}
+	expiration, err := time.Parse(time.RFC3339, out.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("reading ECS RAM role credentials for %s: %w", e.roleName, err)
+	}
+	return &credentials{
+		AccessKeyID:     out.AccessKeyID,
+		AccessKeySecret: out.AccessKeySecret,
+		SecurityToken:   out.SecurityToken,
+		Expiration:      expiration,
+	}, nil
+}
+
+// callRPC calls action of the RPC style API at endpoint, signed with creds,
+// decoding the JSON response into out.
+func callRPC(ctx context.Context, client *http.Client, creds *credentials, endpoint, version, action string, params url.Values, out any) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	form := url.Values{}
+	for k, v := range params {
+		form[k] = v
+	}
+	form.Set("Action", action)
+	form.Set("Version", version)
+	form.Set("Format", "JSON")
+	form.Set("AccessKeyId", creds.AccessKeyID)
+	form.Set("SignatureMethod", "HMAC-SHA1")
+	form.Set("SignatureVersion", "1.0")
+	form.Set("SignatureNonce", hex.EncodeToString(nonce))
+	form.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	if creds.SecurityToken != "" {
+		form.Set("SecurityToken", creds.SecurityToken)
+	}
+	form.Set("Signature", rpcSignature(http.MethodPost, form, creds.AccessKeySecret))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code      string `json:"Code"`
+			Message   string `json:"Message"`
+			RequestID string `json:"RequestId"`
+		}
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Code != "" {
+			return fmt.Errorf("%s: %s: %s (request id %s)", action, apiErr.Code, apiErr.Message, apiErr.RequestID)
+		}
+		return fmt.Errorf("%s: %s", action, resp.Status)
+	}
+	return json.Unmarshal(b, out)
+}
+
+// rpcSignature returns the signature of the parameters of an RPC style API
+// request, using signature version 1.0.
+func rpcSignature(method string, params url.Values, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "Signature" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, percentEncode(k)+"="+percentEncode(params.Get(k)))
+	}
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// percentEncode encodes s as RFC 3986 requires, which differs from
+// url.QueryEscape for spaces, '*' and '~'.
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
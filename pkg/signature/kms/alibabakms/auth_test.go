@@ -0,0 +1,160 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alibabakms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkRPCRequest checks that r is a POSTed RPC request for action, signed
+// with creds, returning its parameters.
+func checkRPCRequest(t *testing.T, r *http.Request, creds *credentials, action string) url.Values {
+	t.Helper()
+	require.Equal(t, http.MethodPost, r.Method)
+	require.NoError(t, r.ParseForm())
+	require.Equal(t, action, r.PostForm.Get("Action"))
+	require.Equal(t, creds.AccessKeyID, r.PostForm.Get("AccessKeyId"))
+	require.Equal(t, creds.SecurityToken, r.PostForm.Get("SecurityToken"))
+	require.Equal(t, rpcSignature(http.MethodPost, r.PostForm, creds.AccessKeySecret), r.PostForm.Get("Signature"), "request signature")
+	return r.PostForm
+}
+
+func TestRPCSignature(t *testing.T) {
+	// The example from the Alibaba Cloud documentation of signature
+	// version 1.0.
+	params := url.Values{
+		"AccessKeyId":      {"testid"},
+		"Action":           {"DescribeRegions"},
+		"Format":           {"XML"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf"},
+		"SignatureVersion": {"1.0"},
+		"Timestamp":        {"2016-02-23T12:46:24Z"},
+		"Version":          {"2014-05-26"},
+	}
+	require.Equal(t, "OLeaidS1JvxuMvnyHOwuJ+uX5qY=", rpcSignature(http.MethodGet, params, "testsecret"))
+
+	require.Equal(t, "a%20b%2A~%2F", percentEncode("a b*~/"))
+}
+
+func TestNewCredentialsProvider(t *testing.T) {
+	for _, env := range []string{EnvAccessKeyID, EnvAccessKeySecret, EnvSecurityToken, EnvRoleArn, EnvRoleSessionName, EnvECSMetadata} {
+		t.Setenv(env, "")
+	}
+	_, err := newCredentialsProvider("cn-hangzhou")
+	require.ErrorContains(t, err, EnvAccessKeyID)
+
+	t.Setenv(EnvECSMetadata, "cosign-signer")
+	p, err := newCredentialsProvider("cn-hangzhou")
+	require.NoError(t, err)
+	require.IsType(t, &ecsRAMRoleCredentials{}, p)
+
+	// An AccessKey pair takes precedence over the ECS RAM role.
+	t.Setenv(EnvAccessKeyID, "id")
+	t.Setenv(EnvAccessKeySecret, "secret")
+	t.Setenv(EnvSecurityToken, "token")
+	p, err = newCredentialsProvider("cn-hangzhou")
+	require.NoError(t, err)
+	creds, err := p.credentials(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &credentials{AccessKeyID: "id", AccessKeySecret: "secret", SecurityToken: "token"}, creds)
+
+	t.Setenv(EnvRoleArn, "acs:ram::123:role/cosign")
+	p, err = newCredentialsProvider("cn-hangzhou")
+	require.NoError(t, err)
+	ar, ok := p.(*assumeRoleCredentials)
+	require.True(t, ok)
+	require.Equal(t, "https://sts.cn-hangzhou.aliyuncs.com", ar.stsURL)
+	require.Equal(t, defaultRoleSessionName, ar.sessionName)
+}
+
+func TestAssumeRoleCredentials(t *testing.T) {
+	base := &credentials{AccessKeyID: "id", AccessKeySecret: "secret"}
+	var calls atomic.Int32
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		params := checkRPCRequest(t, r, base, "AssumeRole")
+		require.Equal(t, stsVersion, params.Get("Version"))
+		require.Equal(t, "acs:ram::123:role/cosign", params.Get("RoleArn"))
+		require.Equal(t, "ci", params.Get("RoleSessionName"))
+		fmt.Fprintf(w, `{"RequestId": "1", "Credentials": {"AccessKeyId": "STS.id", "AccessKeySecret": "sts-secret", "SecurityToken": "sts-token", "Expiration": %q}}`,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer sts.Close()
+
+	p := &assumeRoleCredentials{
+		client:      sts.Client(),
+		base:        &staticCredentials{base},
+		stsURL:      sts.URL,
+		roleArn:     "acs:ram::123:role/cosign",
+		sessionName: "ci",
+	}
+	for i := 0; i < 2; i++ {
+		creds, err := p.credentials(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "STS.id", creds.AccessKeyID)
+		require.Equal(t, "sts-secret", creds.AccessKeySecret)
+		require.Equal(t, "sts-token", creds.SecurityToken)
+	}
+	require.Equal(t, int32(1), calls.Load(), "credentials should be reused until they expire")
+
+	p.roleArn = "acs:ram::123:role/other"
+	p.cache.creds = nil
+	sts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"RequestId": "2", "Code": "NoPermission", "Message": "You are not authorized to do this action."}`)
+	})
+	_, err := p.credentials(context.Background())
+	require.ErrorContains(t, err, "NoPermission: You are not authorized")
+}
+
+func TestECSRAMRoleCredentials(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest/meta-data/ram/security-credentials/cosign-signer" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"Code": "Success", "AccessKeyId": "STS.ecs", "AccessKeySecret": "ecs-secret", "SecurityToken": "ecs-token", "Expiration": %q}`,
+			time.Now().Add(6*time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer metadata.Close()
+
+	p := &ecsRAMRoleCredentials{
+		client:      metadata.Client(),
+		metadataURL: metadata.URL + "/latest/meta-data/ram/security-credentials/",
+		roleName:    "cosign-signer",
+	}
+	creds, err := p.credentials(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "STS.ecs", creds.AccessKeyID)
+	require.Equal(t, "ecs-token", creds.SecurityToken)
+
+	p = &ecsRAMRoleCredentials{
+		client:      metadata.Client(),
+		metadataURL: metadata.URL + "/latest/meta-data/ram/security-credentials/",
+		roleName:    "missing",
+	}
+	_, err = p.credentials(context.Background())
+	require.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,284 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alibabakms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+)
+
+func init() {
+	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID)
+	})
+}
+
+// ReferenceScheme is the scheme of references to keys in Alibaba Cloud KMS.
+const ReferenceScheme = "alibabakms://"
+
+// Algorithms that keys can be created with.
+const (
+	AlgorithmECDSAP256SHA256    = "ecdsa-p256-sha256"
+	AlgorithmRSA2048PKCS1SHA256 = "rsa-2048-pkcs1v15-sha256"
+	AlgorithmRSA3072PKCS1SHA256 = "rsa-3072-pkcs1v15-sha256"
+)
+
+// algorithmMap maps algorithms to the KeySpec keys are created with.
+var algorithmMap = map[string]string{
+	AlgorithmECDSAP256SHA256:    "EC_P256",
+	AlgorithmRSA2048PKCS1SHA256: "RSA_2048",
+	AlgorithmRSA3072PKCS1SHA256: "RSA_3072",
+}
+
+const (
+	kmsVersion = "2016-01-20"
+	cacheTTL   = 5 * time.Minute
+)
+
+var (
+	errKMSReference = errors.New("kms specification should be in the format alibabakms://REGION/KEY_ID or alibabakms://REGION/alias/ALIAS")
+
+	referenceRE = regexp.MustCompile(`^alibabakms://([a-z0-9-]+)/((?:alias/)?[\w.-]+)$`)
+)
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	if !referenceRE.MatchString(ref) {
+		return errKMSReference
+	}
+	return nil
+}
+
+// ParseReference parses an alibabakms-scheme URI into its constituent parts.
+// keyID is either the ID of a key or an alias of it starting with "alias/".
+func ParseReference(referenceStr string) (region, keyID string, err error) {
+	v := referenceRE.FindStringSubmatch(referenceStr)
+	if v == nil {
+		return "", "", fmt.Errorf("invalid alibabakms format %q", referenceStr)
+	}
+	return v[1], v[2], nil
+}
+
+type alibabaKMSClient struct {
+	client      *http.Client
+	credentials credentialsProvider
+	endpoint    string
+	keyID       string
+
+	mu        sync.Mutex
+	key       *alibabaSigningKey
+	keyExpiry time.Time
+}
+
+// alibabaSigningKey is the primary version of a key and how to sign and
+// verify with it.
+type alibabaSigningKey struct {
+	KeyID     string
+	VersionID string
+	Algorithm string
+	Verifier  signature.Verifier
+	HashFunc  crypto.Hash
+}
+
+func newAlibabaKMSClient(referenceStr string) (*alibabaKMSClient, error) {
+	if err := ValidReference(referenceStr); err != nil {
+		return nil, err
+	}
+	region, keyID, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := newCredentialsProvider(region)
+	if err != nil {
+		return nil, err
+	}
+	return &alibabaKMSClient{
+		client:      http.DefaultClient,
+		credentials: creds,
+		endpoint:    "https://kms." + region + ".aliyuncs.com",
+		keyID:       keyID,
+	}, nil
+}
+
+func (a *alibabaKMSClient) call(ctx context.Context, action string, params url.Values, out any) error {
+	creds, err := a.credentials.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	return callRPC(ctx, a.client, creds, a.endpoint, kmsVersion, action, params, out)
+}
+
+type keyMetadata struct {
+	KeyID             string `json:"KeyId"`
+	KeySpec           string `json:"KeySpec"`
+	KeyUsage          string `json:"KeyUsage"`
+	KeyState          string `json:"KeyState"`
+	PrimaryKeyVersion string `json:"PrimaryKeyVersion"`
+}
+
+func (a *alibabaKMSClient) describeKey(ctx context.Context) (*keyMetadata, error) {
+	var resp struct {
+		KeyMetadata keyMetadata `json:"KeyMetadata"`
+	}
+	if err := a.call(ctx, "DescribeKey", url.Values{"KeyId": {a.keyID}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.KeyMetadata, nil
+}
+
+func (a *alibabaKMSClient) fetchPublicKey(ctx context.Context, keyID, versionID string) (crypto.PublicKey, error) {
+	var resp struct {
+		PublicKey string `json:"PublicKey"`
+	}
+	if err := a.call(ctx, "GetPublicKey", url.Values{"KeyId": {keyID}, "KeyVersionId": {versionID}}, &resp); err != nil {
+		return nil, err
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey([]byte(resp.PublicKey))
+}
+
+func (a *alibabaKMSClient) getAlibabaSigningKey(ctx context.Context) (*alibabaSigningKey, error) {
+	md, err := a.describeKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if md.KeyUsage != "SIGN/VERIFY" {
+		return nil, fmt.Errorf("key %s has usage %s, not SIGN/VERIFY", md.KeyID, md.KeyUsage)
+	}
+	pubKey, err := a.fetchPublicKey(ctx, md.KeyID, md.PrimaryKeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	sk := alibabaSigningKey{KeyID: md.KeyID, VersionID: md.PrimaryKeyVersion, HashFunc: crypto.SHA256}
+	switch {
+	case strings.HasPrefix(md.KeySpec, "RSA_"):
+		pub, ok := pubKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", md.KeySpec, pubKey)
+		}
+		sk.Algorithm = "RSA_PKCS1_SHA_256"
+		sk.Verifier, err = signature.LoadRSAPKCS1v15Verifier(pub, crypto.SHA256)
+	case md.KeySpec == "EC_P256":
+		pub, ok := pubKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", md.KeySpec, pubKey)
+		}
+		sk.Algorithm = "ECDSA_SHA_256"
+		sk.Verifier, err = signature.LoadECDSAVerifier(pub, crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported key spec %s specified by KMS", md.KeySpec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initializing internal verifier: %w", err)
+	}
+	return &sk, nil
+}
+
+// getSK returns the signing key, fetching it again once it has been cached
+// for cacheTTL so that key rotations are picked up.
+func (a *alibabaKMSClient) getSK(ctx context.Context) (*alibabaSigningKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.key != nil && time.Now().Before(a.keyExpiry) {
+		return a.key, nil
+	}
+	sk, err := a.getAlibabaSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.key, a.keyExpiry = sk, time.Now().Add(cacheTTL)
+	return sk, nil
+}
+
+func (a *alibabaKMSClient) getHashFunc(ctx context.Context) (crypto.Hash, error) {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return sk.HashFunc, nil
+}
+
+func (a *alibabaKMSClient) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hf != sk.HashFunc {
+		return nil, fmt.Errorf("hash function %v does not match the %v of the key", hf, sk.HashFunc)
+	}
+	params := url.Values{
+		"KeyId":        {sk.KeyID},
+		"KeyVersionId": {sk.VersionID},
+		"Algorithm":    {sk.Algorithm},
+		"Digest":       {base64.StdEncoding.EncodeToString(digest)},
+	}
+	var resp struct {
+		Value string `json:"Value"`
+	}
+	if err := a.call(ctx, "AsymmetricSign", params, &resp); err != nil {
+		return nil, fmt.Errorf("calling Alibaba Cloud KMS AsymmetricSign: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Value)
+}
+
+func (a *alibabaKMSClient) verify(ctx context.Context, sig, message io.Reader, opts ...signature.VerifyOption) error {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return err
+	}
+	return sk.Verifier.VerifySignature(sig, message, opts...)
+}
+
+// createKey creates a key and gives it the alias the client refers to.
+func (a *alibabaKMSClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if !strings.HasPrefix(a.keyID, "alias/") {
+		return nil, errors.New("create alibabakms key specification should be in the format alibabakms://REGION/alias/ALIAS")
+	}
+	keySpec, ok := algorithmMap[algorithm]
+	if !ok {
+		return nil, errors.New("unknown algorithm requested")
+	}
+	var created struct {
+		KeyMetadata keyMetadata `json:"KeyMetadata"`
+	}
+	params := url.Values{
+		"KeySpec":     {keySpec},
+		"KeyUsage":    {"SIGN/VERIFY"},
+		"Description": {"Created by cosign"},
+	}
+	if err := a.call(ctx, "CreateKey", params, &created); err != nil {
+		return nil, fmt.Errorf("alibabakms key create error: %w", err)
+	}
+	md := created.KeyMetadata
+	if err := a.call(ctx, "CreateAlias", url.Values{"KeyId": {md.KeyID}, "AliasName": {a.keyID}}, &struct{}{}); err != nil {
+		return nil, fmt.Errorf("alibabakms key create error: %w", err)
+	}
+	return a.fetchPublicKey(ctx, md.KeyID, md.PrimaryKeyVersion)
+}
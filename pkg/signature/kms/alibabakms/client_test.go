@@ -0,0 +1,243 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alibabakms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantRegion string
+		wantKeyID  string
+		wantErr    bool
+	}{{
+		in:         "alibabakms://cn-hangzhou/key-hzz62f1cb66fa42qo4ysc",
+		wantRegion: "cn-hangzhou",
+		wantKeyID:  "key-hzz62f1cb66fa42qo4ysc",
+	}, {
+		in:         "alibabakms://ap-southeast-1/0b30658a-ed1a-4922-b8f7-a673ca9cf6e2",
+		wantRegion: "ap-southeast-1",
+		wantKeyID:  "0b30658a-ed1a-4922-b8f7-a673ca9cf6e2",
+	}, {
+		in:         "alibabakms://cn-shanghai/alias/cosign",
+		wantRegion: "cn-shanghai",
+		wantKeyID:  "alias/cosign",
+	}, {
+		in:      "alibabakms://cn-hangzhou/",
+		wantErr: true,
+	}, {
+		in:      "alibabakms://cn-hangzhou/alias/cosign/extra",
+		wantErr: true,
+	}, {
+		in:      "ocikms://cn-hangzhou/key-hzz62f1cb66fa42qo4ysc",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			region, keyID, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			require.Equal(t, tt.wantRegion, region)
+			require.Equal(t, tt.wantKeyID, keyID)
+		})
+	}
+}
+
+// fakeKMS serves the parts of the KMS API the client uses, for a single key
+// with the alias alias/cosign, checking that every request is signed with
+// creds.
+type fakeKMS struct {
+	t       *testing.T
+	creds   *credentials
+	keySpec string
+	usage   string
+	private crypto.Signer
+}
+
+const (
+	fakeKeyID     = "key-hzz62f1cb66fa42qo4ysc"
+	fakeVersionID = "a3bb5ade-7c2f-4f1c-8fb4-30d8d0b9d2b5"
+)
+
+func (f *fakeKMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := r.FormValue("Action")
+	params := checkRPCRequest(f.t, r, f.creds, action)
+	require.Equal(f.t, kmsVersion, params.Get("Version"))
+
+	md := keyMetadata{KeyID: fakeKeyID, KeySpec: f.keySpec, KeyUsage: f.usage, KeyState: "Enabled", PrimaryKeyVersion: fakeVersionID}
+	if keyID := params.Get("KeyId"); action != "CreateKey" && keyID != fakeKeyID && keyID != "alias/cosign" {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"RequestId": "1", "Code": "Forbidden.KeyNotFound", "Message": "The specified Key %s is not found."}`, keyID)
+		return
+	}
+	switch action {
+	case "DescribeKey":
+		json.NewEncoder(w).Encode(map[string]any{"KeyMetadata": md})
+	case "GetPublicKey":
+		require.Equal(f.t, fakeVersionID, params.Get("KeyVersionId"))
+		pub, err := cryptoutils.MarshalPublicKeyToPEM(f.private.Public())
+		require.NoError(f.t, err)
+		json.NewEncoder(w).Encode(map[string]string{"PublicKey": string(pub)})
+	case "AsymmetricSign":
+		require.Equal(f.t, fakeKeyID, params.Get("KeyId"))
+		require.Equal(f.t, fakeVersionID, params.Get("KeyVersionId"))
+		digest, err := base64.StdEncoding.DecodeString(params.Get("Digest"))
+		require.NoError(f.t, err)
+		sig, err := f.private.Sign(rand.Reader, digest, crypto.SHA256)
+		require.NoError(f.t, err)
+		json.NewEncoder(w).Encode(map[string]string{"Value": base64.StdEncoding.EncodeToString(sig)})
+	case "CreateKey":
+		require.Equal(f.t, f.keySpec, params.Get("KeySpec"))
+		require.Equal(f.t, "SIGN/VERIFY", params.Get("KeyUsage"))
+		json.NewEncoder(w).Encode(map[string]any{"KeyMetadata": md})
+	case "CreateAlias":
+		require.Equal(f.t, "alias/cosign", params.Get("AliasName"))
+		fmt.Fprint(w, `{"RequestId": "1"}`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"RequestId": "1", "Code": "InvalidAction.NotFound", "Message": "Specified api is not found."}`)
+	}
+}
+
+func newFakeKMSSignerVerifier(t *testing.T, keyID, keySpec, usage string, private crypto.Signer) *SignerVerifier {
+	t.Helper()
+	creds := &credentials{AccessKeyID: "STS.id", AccessKeySecret: "secret", SecurityToken: "token"}
+	kms := httptest.NewServer(&fakeKMS{t: t, creds: creds, keySpec: keySpec, usage: usage, private: private})
+	t.Cleanup(kms.Close)
+	return &SignerVerifier{client: &alibabaKMSClient{
+		client:      kms.Client(),
+		credentials: &staticCredentials{creds},
+		endpoint:    kms.URL,
+		keyID:       keyID,
+	}}
+}
+
+func TestSignerVerifier(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		keyID     string
+		keySpec   string
+		algorithm string
+		private   crypto.Signer
+	}{{
+		name:      "ecdsa by id",
+		keyID:     fakeKeyID,
+		keySpec:   "EC_P256",
+		algorithm: AlgorithmECDSAP256SHA256,
+		private:   ecKey,
+	}, {
+		name:      "rsa by alias",
+		keyID:     "alias/cosign",
+		keySpec:   "RSA_2048",
+		algorithm: AlgorithmRSA2048PKCS1SHA256,
+		private:   rsaKey,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newFakeKMSSignerVerifier(t, tt.keyID, tt.keySpec, "SIGN/VERIFY", tt.private)
+			ctx := context.Background()
+			message := []byte("hello, alibaba cloud")
+
+			sig, err := sv.SignMessage(bytes.NewReader(message), options.WithContext(ctx))
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+			require.Error(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("something else"))))
+
+			pub, err := sv.PublicKey()
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(tt.private.Public(), pub))
+
+			// The key is verifiable without KMS.
+			verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+
+			signer, opts, err := sv.CryptoSigner(ctx, func(err error) { t.Error(err) })
+			require.NoError(t, err)
+			require.Equal(t, crypto.SHA256, opts.HashFunc())
+			digest := sha256.Sum256(message)
+			sig, err = signer.Sign(rand.Reader, digest[:], opts)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+		})
+	}
+}
+
+func TestCreateKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sv := newFakeKMSSignerVerifier(t, "alias/cosign", "EC_P256", "SIGN/VERIFY", ecKey)
+	pub, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.NoError(t, err)
+	require.NoError(t, cryptoutils.EqualKeys(ecKey.Public(), pub))
+
+	_, err = sv.CreateKey(context.Background(), "ed25519")
+	require.ErrorContains(t, err, "unknown algorithm")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "EC_P256", "SIGN/VERIFY", ecKey)
+	_, err = sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.ErrorContains(t, err, "alias/ALIAS")
+}
+
+func TestSignerVerifierErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sv := newFakeKMSSignerVerifier(t, "alias/missing", "EC_P256", "SIGN/VERIFY", ecKey)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "Forbidden.KeyNotFound: The specified Key alias/missing is not found.")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "Aliyun_AES_256", "ENCRYPT/DECRYPT", ecKey)
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")))
+	require.ErrorContains(t, err, "not SIGN/VERIFY")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "EC_SM2", "SIGN/VERIFY", ecKey)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "unsupported key spec EC_SM2")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "EC_P256", "SIGN/VERIFY", ecKey)
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")), options.WithCryptoSignerOpts(crypto.SHA384))
+	require.Error(t, err)
+}
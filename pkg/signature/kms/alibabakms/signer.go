@@ -0,0 +1,172 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alibabakms
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+)
+
+var alibabaSupportedHashFuncs = []crypto.Hash{
+	crypto.SHA256,
+}
+
+// SignerVerifier is a signature.SignerVerifier that uses the Alibaba Cloud
+// key management service
+type SignerVerifier struct {
+	client *alibabaKMSClient
+}
+
+// LoadSignerVerifier generates signatures using the specified key in Alibaba
+// Cloud KMS, with the primary version of the key and SHA-256.
+//
+// It also can verify signatures locally using the public key.
+func LoadSignerVerifier(_ context.Context, referenceStr string) (*SignerVerifier, error) {
+	client, err := newAlibabaKMSClient(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	return &SignerVerifier{client: client}, nil
+}
+
+// SignMessage signs the provided message using Alibaba Cloud KMS. If the
+// message is provided, this method will compute the digest according to the
+// hash function of the key.
+func (a *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	var digest []byte
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	defaultHf, err := a.client.getHashFunc(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+	var signerOpts crypto.SignerOpts = defaultHf
+	for _, opt := range opts {
+		opt.ApplyDigest(&digest)
+		opt.ApplyCryptoSignerOpts(&signerOpts)
+	}
+
+	hf := signerOpts.HashFunc()
+	if len(digest) == 0 {
+		digest, hf, err = signature.ComputeDigestForSigning(message, hf, alibabaSupportedHashFuncs, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.client.sign(ctx, digest, hf)
+}
+
+// PublicKey returns the public key that can be used to verify signatures created by
+// this signer. If the caller wishes to specify the context to use to obtain
+// the public key, pass option.WithContext(desiredCtx).
+//
+// All other options are ignored if specified.
+func (a *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+
+	sk, err := a.client.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Verifier.PublicKey(opts...)
+}
+
+// VerifySignature verifies the signature for the given message. Unless provided
+// in an option, the digest of the message will be computed using the hash
+// function of the key.
+func (a *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	return a.client.verify(ctx, sig, message, opts...)
+}
+
+// CreateKey creates a new key with the specified algorithm, and gives it the
+// alias of the reference.
+func (a *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	return a.client.createKey(ctx, algorithm)
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	alibabaOptions := []signature.SignOption{
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	}
+
+	return c.sv.SignMessage(nil, alibabaOptions...)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (a *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	defaultHf, err := a.client.getHashFunc(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       a,
+		hashFunc: defaultHf,
+		errFunc:  errFunc,
+	}
+
+	return csw, defaultHf, nil
+}
+
+// SupportedAlgorithms returns the list of algorithms supported by Alibaba Cloud KMS
+func (*SignerVerifier) SupportedAlgorithms() (result []string) {
+	for k := range algorithmMap {
+		result = append(result, k)
+	}
+	return
+}
+
+// DefaultAlgorithm returns the default algorithm for Alibaba Cloud KMS
+func (*SignerVerifier) DefaultAlgorithm() string {
+	return AlgorithmECDSAP256SHA256
+}
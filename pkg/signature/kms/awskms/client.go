@@ -0,0 +1,409 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+)
+
+func init() {
+	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID, hashFunc)
+	})
+}
+
+// ReferenceScheme is the scheme of references to keys in AWS KMS.
+const ReferenceScheme = "awskms://"
+
+const cacheTTL = 5 * time.Minute
+
+var (
+	errKMSReference = errors.New("kms specification should be in the format awskms://[ENDPOINT]/[ID/ALIAS/ARN] (endpoint optional)")
+
+	// Key IDs, aliases and ARNs are documented at
+	// https://docs.aws.amazon.com/kms/latest/developerguide/concepts.html#key-id
+	uuidRE      = `m?r?k?-?[A-Fa-f0-9]{8}-?[A-Fa-f0-9]{4}-?[A-Fa-f0-9]{4}-?[A-Fa-f0-9]{4}-?[A-Fa-f0-9]{12}`
+	arnRE       = `arn:(?:aws|aws-us-gov|aws-cn):kms:([a-z0-9-]+):\d{12}:`
+	hostRE      = `([^/]*)/`
+	keyIDRE     = regexp.MustCompile(`^awskms://` + hostRE + `(` + uuidRE + `)$`)
+	keyARNRE    = regexp.MustCompile(`^awskms://` + hostRE + `(` + arnRE + `key/` + uuidRE + `)$`)
+	aliasNameRE = regexp.MustCompile(`^awskms://` + hostRE + `((alias/.*))$`)
+	aliasARNRE  = regexp.MustCompile(`^awskms://` + hostRE + `(` + arnRE + `(alias/.*))$`)
+)
+
+// Reference identifies a key in AWS KMS.
+type Reference struct {
+	// Endpoint is the host of the KMS endpoint, or empty to use the
+	// endpoint of the Region.
+	Endpoint string
+	// KeyID is the ID, ARN, alias name or alias ARN of the key.
+	KeyID string
+	// Alias is the alias name of the key if it is referred to by alias.
+	Alias string
+	// Region is the Region of the key if it is referred to by ARN.
+	Region string
+}
+
+// ParseReference parses an awskms-scheme URI into its constituent parts.
+func ParseReference(resourceID string) (*Reference, error) {
+	switch {
+	case keyIDRE.MatchString(resourceID):
+		v := keyIDRE.FindStringSubmatch(resourceID)
+		return &Reference{Endpoint: v[1], KeyID: v[2]}, nil
+	case keyARNRE.MatchString(resourceID):
+		v := keyARNRE.FindStringSubmatch(resourceID)
+		return &Reference{Endpoint: v[1], KeyID: v[2], Region: v[3]}, nil
+	case aliasARNRE.MatchString(resourceID):
+		v := aliasARNRE.FindStringSubmatch(resourceID)
+		return &Reference{Endpoint: v[1], KeyID: v[2], Region: v[3], Alias: v[4]}, nil
+	case aliasNameRE.MatchString(resourceID):
+		v := aliasNameRE.FindStringSubmatch(resourceID)
+		return &Reference{Endpoint: v[1], KeyID: v[2], Alias: v[3]}, nil
+	}
+	return nil, errKMSReference
+}
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	_, err := ParseReference(ref)
+	return err
+}
+
+// kmsAPI is the part of kms.Client the client uses.
+type kmsAPI interface {
+	CreateAlias(ctx context.Context, params *kms.CreateAliasInput, optFns ...func(*kms.Options)) (*kms.CreateAliasOutput, error)
+	CreateKey(ctx context.Context, params *kms.CreateKeyInput, optFns ...func(*kms.Options)) (*kms.CreateKeyOutput, error)
+	DescribeKey(ctx context.Context, params *kms.DescribeKeyInput, optFns ...func(*kms.Options)) (*kms.DescribeKeyOutput, error)
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+type awsKMSClient struct {
+	client kmsAPI
+	// region is the Region of the caller, whose replicas of multi-Region
+	// keys are preferred.
+	region   string
+	ref      *Reference
+	hashFunc crypto.Hash
+
+	mu        sync.Mutex
+	key       *awsSigningKey
+	keyExpiry time.Time
+}
+
+// awsSigningKey is the key a reference resolved to and how to sign and
+// verify with it.
+type awsSigningKey struct {
+	ARN       string
+	Region    string
+	KeySpec   types.KeySpec
+	Algorithm types.SigningAlgorithmSpec
+	HashFunc  crypto.Hash
+	Verifier  signature.Verifier
+}
+
+func newAWSKMSClient(ctx context.Context, ref *Reference, hashFunc crypto.Hash) (*awsKMSClient, error) {
+	var opts []func(*config.LoadOptions) error
+	if os.Getenv("AWS_TLS_INSECURE_SKIP_VERIFY") == "1" {
+		opts = append(opts, config.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // nolint: gosec
+			},
+		}))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	client := kms.NewFromConfig(cfg, func(o *kms.Options) {
+		if ref.Endpoint != "" {
+			o.BaseEndpoint = aws.String("https://" + ref.Endpoint)
+		}
+	})
+	return &awsKMSClient{client: client, region: cfg.Region, ref: ref, hashFunc: hashFunc}, nil
+}
+
+// inRegion directs a request to region, unless it is empty.
+func inRegion(region string) func(*kms.Options) {
+	return func(o *kms.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	}
+}
+
+// arnRegion returns the Region of a KMS key ARN.
+func arnRegion(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[3]
+}
+
+func (a *awsKMSClient) describeKey(ctx context.Context, keyID, region string) (*types.KeyMetadata, error) {
+	out, err := a.client.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)}, inRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("getting key metadata: %w", err)
+	}
+	if out.KeyMetadata == nil || out.KeyMetadata.Arn == nil {
+		return nil, fmt.Errorf("no metadata for key %s", keyID)
+	}
+	return out.KeyMetadata, nil
+}
+
+// resolveKey returns the metadata of the key the reference refers to. Keys
+// referred to by ARN are looked up in their Region, and multi-Region keys
+// resolve to their primary or replica key in the caller's Region, if any.
+func (a *awsKMSClient) resolveKey(ctx context.Context) (*types.KeyMetadata, error) {
+	region := a.ref.Region
+	if region == "" {
+		region = a.region
+	}
+	md, err := a.describeKey(ctx, a.ref.KeyID, region)
+	if err != nil {
+		return nil, err
+	}
+	if md.MultiRegion == nil || !*md.MultiRegion || md.MultiRegionConfiguration == nil || a.region == "" || arnRegion(*md.Arn) == a.region {
+		return md, nil
+	}
+	mrc := md.MultiRegionConfiguration
+	related := mrc.ReplicaKeys
+	if mrc.PrimaryKey != nil {
+		related = append([]types.MultiRegionKey{*mrc.PrimaryKey}, related...)
+	}
+	for _, k := range related {
+		if k.Arn == nil || k.Region == nil || *k.Region != a.region {
+			continue
+		}
+		local, err := a.describeKey(ctx, *k.Arn, a.region)
+		if err != nil {
+			return nil, fmt.Errorf("resolving multi-Region key %s in %s: %w", *md.Arn, a.region, err)
+		}
+		if local.KeyState == types.KeyStateEnabled {
+			return local, nil
+		}
+	}
+	return md, nil
+}
+
+// validateKey returns an error unless the key can sign with a key spec the
+// provider supports.
+func validateKey(md *types.KeyMetadata) error {
+	arn := aws.ToString(md.Arn)
+	if md.KeyUsage != types.KeyUsageTypeSignVerify {
+		return fmt.Errorf("key %s has usage %s, not %s", arn, md.KeyUsage, types.KeyUsageTypeSignVerify)
+	}
+	if md.KeyState != types.KeyStateEnabled {
+		return fmt.Errorf("key %s is %s", arn, md.KeyState)
+	}
+	if _, ok := keySpecHashFuncs[md.KeySpec]; !ok {
+		return fmt.Errorf("key %s has unsupported key spec %s", arn, md.KeySpec)
+	}
+	if len(md.SigningAlgorithms) == 0 {
+		return fmt.Errorf("key %s has no signing algorithms", arn)
+	}
+	return nil
+}
+
+// keySpecHashFuncs maps the supported key specs to the hash functions their
+// signing algorithms use, preferred first.
+var keySpecHashFuncs = map[types.KeySpec][]crypto.Hash{
+	types.KeySpecRsa2048:     {crypto.SHA256, crypto.SHA384, crypto.SHA512},
+	types.KeySpecRsa3072:     {crypto.SHA256, crypto.SHA384, crypto.SHA512},
+	types.KeySpecRsa4096:     {crypto.SHA256, crypto.SHA384, crypto.SHA512},
+	types.KeySpecEccNistP256: {crypto.SHA256},
+	types.KeySpecEccNistP384: {crypto.SHA384},
+	types.KeySpecEccNistP521: {crypto.SHA512},
+}
+
+// algorithmHashFunc returns the hash function of a signing algorithm.
+func algorithmHashFunc(alg types.SigningAlgorithmSpec) crypto.Hash {
+	switch {
+	case strings.HasSuffix(string(alg), "_256"):
+		return crypto.SHA256
+	case strings.HasSuffix(string(alg), "_384"):
+		return crypto.SHA384
+	case strings.HasSuffix(string(alg), "_512"):
+		return crypto.SHA512
+	default:
+		return 0
+	}
+}
+
+// pickAlgorithm returns the first signing algorithm of the key that uses
+// hashFunc, or its first signing algorithm if none does.
+func pickAlgorithm(algs []types.SigningAlgorithmSpec, hashFunc crypto.Hash) types.SigningAlgorithmSpec {
+	for _, alg := range algs {
+		if algorithmHashFunc(alg) == hashFunc {
+			return alg
+		}
+	}
+	return algs[0]
+}
+
+func (a *awsKMSClient) getAWSSigningKey(ctx context.Context) (*awsSigningKey, error) {
+	md, err := a.resolveKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateKey(md); err != nil {
+		return nil, err
+	}
+	sk := &awsSigningKey{
+		ARN:       *md.Arn,
+		Region:    arnRegion(*md.Arn),
+		KeySpec:   md.KeySpec,
+		Algorithm: pickAlgorithm(md.SigningAlgorithms, a.hashFunc),
+	}
+	sk.HashFunc = algorithmHashFunc(sk.Algorithm)
+
+	out, err := a.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(sk.ARN)}, inRegion(sk.Region))
+	if err != nil {
+		return nil, fmt.Errorf("getting public key: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	switch sk.Algorithm {
+	case types.SigningAlgorithmSpecRsassaPssSha256, types.SigningAlgorithmSpecRsassaPssSha384, types.SigningAlgorithmSpecRsassaPssSha512:
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", sk.KeySpec, pub)
+		}
+		sk.Verifier, err = signature.LoadRSAPSSVerifier(rsaPub, sk.HashFunc, nil)
+	case types.SigningAlgorithmSpecRsassaPkcs1V15Sha256, types.SigningAlgorithmSpecRsassaPkcs1V15Sha384, types.SigningAlgorithmSpecRsassaPkcs1V15Sha512:
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", sk.KeySpec, pub)
+		}
+		sk.Verifier, err = signature.LoadRSAPKCS1v15Verifier(rsaPub, sk.HashFunc)
+	case types.SigningAlgorithmSpecEcdsaSha256, types.SigningAlgorithmSpecEcdsaSha384, types.SigningAlgorithmSpecEcdsaSha512:
+		ecPub, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", sk.KeySpec, pub)
+		}
+		sk.Verifier, err = signature.LoadECDSAVerifier(ecPub, sk.HashFunc)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %s", sk.Algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initializing internal verifier: %w", err)
+	}
+	return sk, nil
+}
+
+// getSK returns the signing key, resolving the reference again once it has
+// been cached for cacheTTL so that aliases pointed at other keys are picked
+// up.
+func (a *awsKMSClient) getSK(ctx context.Context) (*awsSigningKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.key != nil && time.Now().Before(a.keyExpiry) {
+		return a.key, nil
+	}
+	sk, err := a.getAWSSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.key, a.keyExpiry = sk, time.Now().Add(cacheTTL)
+	return sk, nil
+}
+
+func (a *awsKMSClient) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hf != sk.HashFunc {
+		return nil, fmt.Errorf("hash function %v does not match the %v of the key", hf, sk.HashFunc)
+	}
+	out, err := a.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(sk.ARN),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: sk.Algorithm,
+	}, inRegion(sk.Region))
+	if err != nil {
+		return nil, fmt.Errorf("signing with kms: %w", err)
+	}
+	return out.Signature, nil
+}
+
+func (a *awsKMSClient) verify(ctx context.Context, sig, message io.Reader, opts ...signature.VerifyOption) error {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return err
+	}
+	return sk.Verifier.VerifySignature(sig, message, opts...)
+}
+
+// createKey creates a key and gives it the alias the client refers to, or
+// returns the public key of the key the alias already refers to.
+func (a *awsKMSClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if a.ref.Alias == "" {
+		return nil, errors.New("must use alias key format")
+	}
+	sk, err := a.getSK(ctx)
+	if err == nil {
+		return sk.Verifier.PublicKey()
+	}
+	var errNotFound *types.NotFoundException
+	if !errors.As(err, &errNotFound) {
+		return nil, fmt.Errorf("looking up key: %w", err)
+	}
+
+	key, err := a.client.CreateKey(ctx, &kms.CreateKeyInput{
+		KeySpec:     types.KeySpec(algorithm),
+		KeyUsage:    types.KeyUsageTypeSignVerify,
+		Description: aws.String("Created by Sigstore"),
+	}, inRegion(a.ref.Region))
+	if err != nil {
+		return nil, fmt.Errorf("creating key: %w", err)
+	}
+	if _, err := a.client.CreateAlias(ctx, &kms.CreateAliasInput{
+		AliasName:   aws.String(a.ref.Alias),
+		TargetKeyId: key.KeyMetadata.KeyId,
+	}, inRegion(a.ref.Region)); err != nil {
+		return nil, fmt.Errorf("creating alias %q: %w", a.ref.Alias, err)
+	}
+	sk, err = a.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Verifier.PublicKey()
+}
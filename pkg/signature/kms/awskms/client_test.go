@@ -0,0 +1,403 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awskms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *Reference
+		wantErr bool
+	}{{
+		in:   "awskms:///1234abcd-12ab-34cd-56ef-1234567890ab",
+		want: &Reference{KeyID: "1234abcd-12ab-34cd-56ef-1234567890ab"},
+	}, {
+		in:   "awskms://localhost:4566/1234abcd-12ab-34cd-56ef-1234567890ab",
+		want: &Reference{Endpoint: "localhost:4566", KeyID: "1234abcd-12ab-34cd-56ef-1234567890ab"},
+	}, {
+		in:   "awskms:///arn:aws:kms:us-east-2:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab",
+		want: &Reference{KeyID: "arn:aws:kms:us-east-2:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab", Region: "us-east-2"},
+	}, {
+		in:   "awskms:///alias/ExampleAlias",
+		want: &Reference{KeyID: "alias/ExampleAlias", Alias: "alias/ExampleAlias"},
+	}, {
+		in:   "awskms:///arn:aws-us-gov:kms:us-gov-west-1:111122223333:alias/ExampleAlias",
+		want: &Reference{KeyID: "arn:aws-us-gov:kms:us-gov-west-1:111122223333:alias/ExampleAlias", Alias: "alias/ExampleAlias", Region: "us-gov-west-1"},
+	}, {
+		in:      "awskms:///ExampleAlias",
+		wantErr: true,
+	}, {
+		in:      "gcpkms:///alias/ExampleAlias",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// fakeKey is a key of fakeKMS.
+type fakeKey struct {
+	md      types.KeyMetadata
+	private crypto.Signer
+}
+
+// fakeKMS implements kmsAPI for keys in several Regions, checking that
+// every request is sent to the Region of the key it names.
+type fakeKMS struct {
+	t       *testing.T
+	keys    map[string]*fakeKey
+	aliases map[string]string
+	created *kms.CreateKeyInput
+}
+
+const account = "111122223333"
+
+func keyARN(region, id string) string {
+	return "arn:aws:kms:" + region + ":" + account + ":key/" + id
+}
+
+func (f *fakeKMS) addKey(region, id string, spec types.KeySpec, private crypto.Signer, algs ...types.SigningAlgorithmSpec) *fakeKey {
+	k := &fakeKey{md: types.KeyMetadata{
+		Arn:               aws.String(keyARN(region, id)),
+		KeyId:             aws.String(id),
+		KeySpec:           spec,
+		KeyUsage:          types.KeyUsageTypeSignVerify,
+		KeyState:          types.KeyStateEnabled,
+		SigningAlgorithms: algs,
+	}, private: private}
+	f.keys[*k.md.Arn] = k
+	return k
+}
+
+func region(optFns []func(*kms.Options)) string {
+	o := kms.Options{Region: "eu-west-1"}
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	return o.Region
+}
+
+func (f *fakeKMS) find(keyID string, optFns []func(*kms.Options)) (*fakeKey, error) {
+	r := region(optFns)
+	arn := keyID
+	switch {
+	case strings.HasPrefix(keyID, "alias/"):
+		arn = f.aliases[r+"/"+keyID]
+	case strings.HasPrefix(keyID, "arn:"):
+		require.Equal(f.t, arnRegion(keyID), r, "request for %s sent to %s", keyID, r)
+		if strings.Contains(keyID, ":alias/") {
+			arn = f.aliases[r+"/"+keyID[strings.Index(keyID, "alias/"):]]
+		}
+	default:
+		arn = keyARN(r, keyID)
+	}
+	k, ok := f.keys[arn]
+	if !ok {
+		return nil, &types.NotFoundException{Message: aws.String(keyID + " is not found.")}
+	}
+	return k, nil
+}
+
+func (f *fakeKMS) CreateAlias(_ context.Context, params *kms.CreateAliasInput, optFns ...func(*kms.Options)) (*kms.CreateAliasOutput, error) {
+	k, err := f.find(*params.TargetKeyId, optFns)
+	require.NoError(f.t, err)
+	f.aliases[region(optFns)+"/"+*params.AliasName] = *k.md.Arn
+	return &kms.CreateAliasOutput{}, nil
+}
+
+func (f *fakeKMS) CreateKey(_ context.Context, params *kms.CreateKeyInput, optFns ...func(*kms.Options)) (*kms.CreateKeyOutput, error) {
+	f.created = params
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(f.t, err)
+	k := f.addKey(region(optFns), "00000000-0000-0000-0000-000000000001", params.KeySpec, priv, types.SigningAlgorithmSpecEcdsaSha256)
+	return &kms.CreateKeyOutput{KeyMetadata: &k.md}, nil
+}
+
+func (f *fakeKMS) DescribeKey(_ context.Context, params *kms.DescribeKeyInput, optFns ...func(*kms.Options)) (*kms.DescribeKeyOutput, error) {
+	k, err := f.find(*params.KeyId, optFns)
+	if err != nil {
+		return nil, err
+	}
+	md := k.md
+	return &kms.DescribeKeyOutput{KeyMetadata: &md}, nil
+}
+
+func (f *fakeKMS) GetPublicKey(_ context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	k, err := f.find(*params.KeyId, optFns)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(k.private.Public())
+	require.NoError(f.t, err)
+	return &kms.GetPublicKeyOutput{KeyId: k.md.Arn, PublicKey: der}, nil
+}
+
+func (f *fakeKMS) Sign(_ context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	require.True(f.t, strings.HasPrefix(*params.KeyId, "arn:"), "signing with unresolved key %s", *params.KeyId)
+	k, err := f.find(*params.KeyId, optFns)
+	if err != nil {
+		return nil, err
+	}
+	require.Equal(f.t, types.MessageTypeDigest, params.MessageType)
+	hf := algorithmHashFunc(params.SigningAlgorithm)
+	var opts crypto.SignerOpts = hf
+	if strings.HasPrefix(string(params.SigningAlgorithm), "RSASSA_PSS") {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hf}
+	}
+	sig, err := k.private.Sign(rand.Reader, params.Message, opts)
+	require.NoError(f.t, err)
+	return &kms.SignOutput{KeyId: k.md.Arn, Signature: sig, SigningAlgorithm: params.SigningAlgorithm}, nil
+}
+
+func newFakeKMS(t *testing.T) *fakeKMS {
+	return &fakeKMS{t: t, keys: map[string]*fakeKey{}, aliases: map[string]string{}}
+}
+
+func loadFakeSignerVerifier(t *testing.T, f *fakeKMS, ref string, hashFunc crypto.Hash) (*SignerVerifier, error) {
+	t.Helper()
+	r, err := ParseReference(ref)
+	require.NoError(t, err)
+	return loadSignerVerifier(context.Background(), &awsKMSClient{client: f, region: "eu-west-1", ref: r, hashFunc: hashFunc})
+}
+
+func TestSignerVerifier(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		spec     types.KeySpec
+		private  crypto.Signer
+		algs     []types.SigningAlgorithmSpec
+		hashFunc crypto.Hash
+		wantAlg  types.SigningAlgorithmSpec
+		wantHash crypto.Hash
+	}{{
+		name:     "ecdsa p384 with the sha256 of cosign",
+		spec:     types.KeySpecEccNistP384,
+		private:  ecKey,
+		algs:     []types.SigningAlgorithmSpec{types.SigningAlgorithmSpecEcdsaSha384},
+		hashFunc: crypto.SHA256,
+		wantAlg:  types.SigningAlgorithmSpecEcdsaSha384,
+		wantHash: crypto.SHA384,
+	}, {
+		name:    "rsa pss",
+		spec:    types.KeySpecRsa2048,
+		private: rsaKey,
+		algs: []types.SigningAlgorithmSpec{
+			types.SigningAlgorithmSpecRsassaPssSha512,
+			types.SigningAlgorithmSpecRsassaPssSha256,
+			types.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+		},
+		hashFunc: crypto.SHA256,
+		wantAlg:  types.SigningAlgorithmSpecRsassaPssSha256,
+		wantHash: crypto.SHA256,
+	}, {
+		name:     "rsa pkcs1v15",
+		spec:     types.KeySpecRsa2048,
+		private:  rsaKey,
+		algs:     []types.SigningAlgorithmSpec{types.SigningAlgorithmSpecRsassaPkcs1V15Sha384},
+		hashFunc: crypto.SHA384,
+		wantAlg:  types.SigningAlgorithmSpecRsassaPkcs1V15Sha384,
+		wantHash: crypto.SHA384,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeKMS(t)
+			k := f.addKey("eu-west-1", "1234abcd-12ab-34cd-56ef-1234567890ab", tt.spec, tt.private, tt.algs...)
+			f.aliases["eu-west-1/alias/cosign"] = *k.md.Arn
+			sv, err := loadFakeSignerVerifier(t, f, "awskms:///alias/cosign", tt.hashFunc)
+			require.NoError(t, err)
+			require.Equal(t, *k.md.Arn, sv.ResolvedKeyID())
+			require.Equal(t, tt.wantAlg, sv.client.key.Algorithm)
+
+			ctx := context.Background()
+			message := []byte("hello, aws")
+			sig, err := sv.SignMessage(bytes.NewReader(message), options.WithContext(ctx))
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+			require.Error(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("something else"))))
+
+			pub, err := sv.PublicKey()
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(tt.private.Public(), pub))
+
+			signer, opts, err := sv.CryptoSigner(ctx, func(err error) { t.Error(err) })
+			require.NoError(t, err)
+			require.Equal(t, tt.wantHash, opts.HashFunc())
+			h := tt.wantHash.New()
+			h.Write(message)
+			sig, err = signer.Sign(rand.Reader, h.Sum(nil), opts)
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+		})
+	}
+}
+
+func TestMultiRegionKeyResolution(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	const mrk = "mrk-1234abcd12ab34cd56ef1234567890ab"
+	f := newFakeKMS(t)
+	primary := f.addKey("us-east-1", mrk, types.KeySpecEccNistP256, priv, types.SigningAlgorithmSpecEcdsaSha256)
+	replica := f.addKey("eu-west-1", mrk, types.KeySpecEccNistP256, priv, types.SigningAlgorithmSpecEcdsaSha256)
+	other := f.addKey("ap-south-1", mrk, types.KeySpecEccNistP256, priv, types.SigningAlgorithmSpecEcdsaSha256)
+	mrc := &types.MultiRegionConfiguration{
+		MultiRegionKeyType: types.MultiRegionKeyTypePrimary,
+		PrimaryKey:         &types.MultiRegionKey{Arn: primary.md.Arn, Region: aws.String("us-east-1")},
+		ReplicaKeys: []types.MultiRegionKey{
+			{Arn: replica.md.Arn, Region: aws.String("eu-west-1")},
+			{Arn: other.md.Arn, Region: aws.String("ap-south-1")},
+		},
+	}
+	for _, k := range []*fakeKey{primary, replica, other} {
+		k.md.MultiRegion = aws.Bool(true)
+		k.md.MultiRegionConfiguration = mrc
+	}
+	f.aliases["us-east-1/alias/cosign"] = *primary.md.Arn
+
+	// The primary key in us-east-1 resolves to the replica in the caller's
+	// Region, as does an alias of it in us-east-1.
+	for _, ref := range []string{
+		"awskms:///" + *primary.md.Arn,
+		"awskms:///arn:aws:kms:us-east-1:" + account + ":alias/cosign",
+	} {
+		sv, err := loadFakeSignerVerifier(t, f, ref, crypto.SHA256)
+		require.NoError(t, err)
+		require.Equal(t, *replica.md.Arn, sv.ResolvedKeyID())
+		_, err = sv.SignMessage(bytes.NewReader([]byte("hello")))
+		require.NoError(t, err)
+	}
+
+	// Without a usable replica in the caller's Region, the key in the
+	// Region of the ARN is used.
+	replica.md.KeyState = types.KeyStateDisabled
+	sv, err := loadFakeSignerVerifier(t, f, "awskms:///"+*other.md.Arn, crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, *other.md.Arn, sv.ResolvedKeyID())
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")))
+	require.NoError(t, err)
+
+	// Keys that are not multi-Region keys are used in their Region.
+	single := f.addKey("us-west-2", "1234abcd-12ab-34cd-56ef-1234567890ab", types.KeySpecEccNistP256, priv, types.SigningAlgorithmSpecEcdsaSha256)
+	sv, err = loadFakeSignerVerifier(t, f, "awskms:///"+*single.md.Arn, crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, *single.md.Arn, sv.ResolvedKeyID())
+}
+
+func TestKeyValidation(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		mutate  func(*types.KeyMetadata)
+		wantErr string
+	}{{
+		name:    "encryption key",
+		mutate:  func(md *types.KeyMetadata) { md.KeyUsage = types.KeyUsageTypeEncryptDecrypt },
+		wantErr: "has usage ENCRYPT_DECRYPT, not SIGN_VERIFY",
+	}, {
+		name:    "disabled key",
+		mutate:  func(md *types.KeyMetadata) { md.KeyState = types.KeyStateDisabled },
+		wantErr: "is Disabled",
+	}, {
+		name:    "secp256k1 key",
+		mutate:  func(md *types.KeyMetadata) { md.KeySpec = types.KeySpecEccSecgP256k1 },
+		wantErr: "unsupported key spec ECC_SECG_P256K1",
+	}, {
+		name:    "sm2 key",
+		mutate:  func(md *types.KeyMetadata) { md.KeySpec = types.KeySpecSm2 },
+		wantErr: "unsupported key spec SM2",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeKMS(t)
+			k := f.addKey("eu-west-1", "1234abcd-12ab-34cd-56ef-1234567890ab", types.KeySpecEccNistP256, priv, types.SigningAlgorithmSpecEcdsaSha256)
+			tt.mutate(&k.md)
+			_, err := loadFakeSignerVerifier(t, f, "awskms:///1234abcd-12ab-34cd-56ef-1234567890ab", crypto.SHA256)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// Missing keys fail up front unless they are aliases CreateKey can create.
+	f := newFakeKMS(t)
+	_, err = loadFakeSignerVerifier(t, f, "awskms:///1234abcd-12ab-34cd-56ef-1234567890ab", crypto.SHA256)
+	var errNotFound *types.NotFoundException
+	require.ErrorAs(t, err, &errNotFound)
+	sv, err := loadFakeSignerVerifier(t, f, "awskms:///alias/cosign", crypto.SHA256)
+	require.NoError(t, err)
+	require.Empty(t, sv.ResolvedKeyID())
+}
+
+func TestCreateKey(t *testing.T) {
+	f := newFakeKMS(t)
+	sv, err := loadFakeSignerVerifier(t, f, "awskms:///alias/cosign", crypto.SHA256)
+	require.NoError(t, err)
+	pub, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.NoError(t, err)
+	require.Equal(t, types.KeySpecEccNistP256, f.created.KeySpec)
+	require.NotEmpty(t, sv.ResolvedKeyID())
+
+	// The verifier is usable without KMS.
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	require.NoError(t, err)
+	sig, err := sv.SignMessage(bytes.NewReader([]byte("hello")))
+	require.NoError(t, err)
+	require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("hello"))))
+
+	// An existing alias is not created again.
+	f.created = nil
+	again, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.NoError(t, err)
+	require.NoError(t, cryptoutils.EqualKeys(pub, again))
+	require.Nil(t, f.created)
+
+	sv, err = loadFakeSignerVerifier(t, f, "awskms:///"+keyARN("eu-west-1", "00000000-0000-0000-0000-000000000001"), crypto.SHA256)
+	require.NoError(t, err)
+	_, err = sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.ErrorContains(t, err, "must use alias key format")
+}
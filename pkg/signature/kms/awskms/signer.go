@@ -0,0 +1,209 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+)
+
+var awsSupportedAlgorithms = []types.KeySpec{
+	types.KeySpecRsa2048,
+	types.KeySpecRsa3072,
+	types.KeySpecRsa4096,
+	types.KeySpecEccNistP256,
+	types.KeySpecEccNistP384,
+	types.KeySpecEccNistP521,
+}
+
+var awsSupportedHashFuncs = []crypto.Hash{
+	crypto.SHA256,
+	crypto.SHA384,
+	crypto.SHA512,
+}
+
+// SignerVerifier is a signature.SignerVerifier that uses a key in AWS KMS
+type SignerVerifier struct {
+	client *awsKMSClient
+}
+
+// LoadSignerVerifier generates signatures using the key referred to by
+// referenceStr, preferring a signing algorithm that uses hashFunc. The key is
+// resolved and checked to be an enabled signing key of a supported key spec
+// before it is returned, except for aliases that do not exist yet, which
+// CreateKey can create.
+//
+// It also can verify signatures locally using the public key.
+func LoadSignerVerifier(ctx context.Context, referenceStr string, hashFunc crypto.Hash) (*SignerVerifier, error) {
+	ref, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newAWSKMSClient(ctx, ref, hashFunc)
+	if err != nil {
+		return nil, err
+	}
+	return loadSignerVerifier(ctx, client)
+}
+
+func loadSignerVerifier(ctx context.Context, client *awsKMSClient) (*SignerVerifier, error) {
+	var errNotFound *types.NotFoundException
+	if _, err := client.getSK(ctx); err != nil && (client.ref.Alias == "" || !errors.As(err, &errNotFound)) {
+		return nil, err
+	}
+	return &SignerVerifier{client: client}, nil
+}
+
+// ResolvedKeyID returns the ARN of the key the reference resolved to, or the
+// empty string if it has not been resolved.
+func (a *SignerVerifier) ResolvedKeyID() string {
+	a.client.mu.Lock()
+	defer a.client.mu.Unlock()
+	if a.client.key == nil {
+		return ""
+	}
+	return a.client.key.ARN
+}
+
+// SignMessage signs the provided message using AWS KMS. If the message is
+// provided, this method will compute the digest according to the hash
+// function of the signing algorithm of the key.
+func (a *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	var digest []byte
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	sk, err := a.client.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var signerOpts crypto.SignerOpts = sk.HashFunc
+	for _, opt := range opts {
+		opt.ApplyDigest(&digest)
+		opt.ApplyCryptoSignerOpts(&signerOpts)
+	}
+
+	hf := signerOpts.HashFunc()
+	if len(digest) == 0 {
+		digest, hf, err = signature.ComputeDigestForSigning(message, hf, awsSupportedHashFuncs, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.client.sign(ctx, digest, hf)
+}
+
+// PublicKey returns the public key that can be used to verify signatures created by
+// this signer. If the caller wishes to specify the context to use to obtain
+// the public key, pass option.WithContext(desiredCtx).
+//
+// All other options are ignored if specified.
+func (a *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	sk, err := a.client.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Verifier.PublicKey()
+}
+
+// VerifySignature verifies the signature for the given message. Unless provided
+// in an option, the digest of the message will be computed using the hash
+// function of the signing algorithm of the key.
+func (a *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	return a.client.verify(ctx, sig, message, opts...)
+}
+
+// CreateKey creates a key with the specified key spec and gives it the alias
+// of the reference, unless the alias already refers to a key.
+func (a *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	return a.client.createKey(ctx, algorithm)
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	return c.sv.SignMessage(nil,
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (a *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	sk, err := a.client.getSK(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       a,
+		hashFunc: sk.HashFunc,
+		errFunc:  errFunc,
+	}
+
+	return csw, sk.HashFunc, nil
+}
+
+// SupportedAlgorithms returns the list of key specs AWS KMS keys can be
+// created with
+func (*SignerVerifier) SupportedAlgorithms() []string {
+	s := make([]string, len(awsSupportedAlgorithms))
+	for i := range awsSupportedAlgorithms {
+		s[i] = string(awsSupportedAlgorithms[i])
+	}
+	return s
+}
+
+// DefaultAlgorithm returns the default key spec of AWS KMS keys
+func (*SignerVerifier) DefaultAlgorithm() string {
+	return string(types.KeySpecEccNistP256)
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azurekms
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+const (
+	// EnvAuthMethod selects how to authenticate to Azure: "environment",
+	// "managedidentity", "workloadidentity" or "cli". By default the
+	// credentials of the environment, workload identity, managed identity
+	// and the Azure CLI are tried in turn.
+	EnvAuthMethod = "AZURE_AUTH_METHOD"
+	// EnvEnvironment selects the Azure cloud, AZUREPUBLICCLOUD by default.
+	EnvEnvironment = "AZURE_ENVIRONMENT"
+	// EnvClientID is the client ID of a user-assigned managed identity.
+	EnvClientID = "AZURE_CLIENT_ID"
+)
+
+// azureCloud is an Azure cloud and the DNS suffixes of its vaults.
+type azureCloud struct {
+	Configuration    cloud.Configuration
+	VaultSuffix      string
+	ManagedHSMSuffix string
+}
+
+var (
+	azurePublicCloud = azureCloud{
+		Configuration:    cloud.AzurePublic,
+		VaultSuffix:      "vault.azure.net",
+		ManagedHSMSuffix: "managedhsm.azure.net",
+	}
+	azureGovernmentCloud = azureCloud{
+		Configuration:    cloud.AzureGovernment,
+		VaultSuffix:      "vault.usgovcloudapi.net",
+		ManagedHSMSuffix: "managedhsm.usgovcloudapi.net",
+	}
+	azureChinaCloud = azureCloud{
+		Configuration:    cloud.AzureChina,
+		VaultSuffix:      "vault.azure.cn",
+		ManagedHSMSuffix: "managedhsm.azure.cn",
+	}
+)
+
+// getCloud returns the Azure cloud selected by EnvEnvironment.
+func getCloud() (azureCloud, error) {
+	switch name := strings.ToUpper(os.Getenv(EnvEnvironment)); name {
+	case "", "AZURECLOUD", "AZUREPUBLICCLOUD":
+		return azurePublicCloud, nil
+	case "AZUREUSGOVERNMENT", "AZUREUSGOVERNMENTCLOUD":
+		return azureGovernmentCloud, nil
+	case "AZURECHINACLOUD":
+		return azureChinaCloud, nil
+	default:
+		return azureCloud{}, fmt.Errorf("unknown %s %s", EnvEnvironment, name)
+	}
+}
+
+// newCredential returns the credential selected by EnvAuthMethod.
+func newCredential(clientOpts azcore.ClientOptions) (azcore.TokenCredential, error) {
+	var managedIdentityID azidentity.ManagedIDKind
+	if id := os.Getenv(EnvClientID); id != "" {
+		managedIdentityID = azidentity.ClientID(id)
+	}
+	switch method := strings.ToLower(os.Getenv(EnvAuthMethod)); method {
+	case "":
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: clientOpts})
+	case "environment":
+		// The service principal of the environment, or the managed
+		// identity when the environment has none.
+		cred, err := azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{ClientOptions: clientOpts})
+		if err == nil {
+			return cred, nil
+		}
+		return azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOpts, ID: managedIdentityID})
+	case "managedidentity":
+		return azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOpts, ID: managedIdentityID})
+	case "workloadidentity":
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{ClientOptions: clientOpts})
+	case "cli":
+		return azidentity.NewAzureCLICredential(nil)
+	default:
+		return nil, fmt.Errorf("unknown %s %s", EnvAuthMethod, method)
+	}
+}
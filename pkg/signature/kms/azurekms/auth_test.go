@@ -0,0 +1,68 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azurekms
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCloud(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    azureCloud
+		wantErr bool
+	}{
+		{env: "", want: azurePublicCloud},
+		{env: "AzurePublicCloud", want: azurePublicCloud},
+		{env: "AZUREUSGOVERNMENT", want: azureGovernmentCloud},
+		{env: "AZURECHINACLOUD", want: azureChinaCloud},
+		{env: "AZUREGERMANCLOUD", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv(EnvEnvironment, tt.env)
+			got, err := getCloud()
+			if tt.wantErr {
+				require.ErrorContains(t, err, "unknown AZURE_ENVIRONMENT")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewCredential(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "00000000-0000-0000-0000-000000000000")
+	t.Setenv(EnvClientID, "00000000-0000-0000-0000-000000000001")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "/var/run/secrets/azure/tokens/azure-identity-token")
+
+	for _, method := range []string{"", "environment", "managedidentity", "workloadidentity", "cli", "CLI"} {
+		t.Run(method, func(t *testing.T) {
+			t.Setenv(EnvAuthMethod, method)
+			cred, err := newCredential(azcore.ClientOptions{})
+			require.NoError(t, err)
+			require.NotNil(t, cred)
+		})
+	}
+
+	t.Setenv(EnvAuthMethod, "kerberos")
+	_, err := newCredential(azcore.ClientOptions{})
+	require.ErrorContains(t, err, "unknown AZURE_AUTH_METHOD kerberos")
+}
@@ -0,0 +1,437 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azurekms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func init() {
+	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID, opts...)
+	})
+}
+
+// ReferenceScheme is the scheme of references to keys in Azure Key Vault and
+// Managed HSM.
+const ReferenceScheme = "azurekms://"
+
+// Algorithms that keys can be created with.
+const (
+	AlgorithmES256 = "ES256"
+	AlgorithmES384 = "ES384"
+	AlgorithmES512 = "ES512"
+	AlgorithmRS256 = "RS256"
+	AlgorithmRS384 = "RS384"
+	AlgorithmRS512 = "RS512"
+)
+
+// keySpec is the type and size of the keys of an algorithm.
+type keySpec struct {
+	Kty     azkeys.KeyType
+	Curve   azkeys.CurveName
+	KeySize int32
+}
+
+// algorithmMap maps algorithms to the keys they are created with.
+var algorithmMap = map[string]keySpec{
+	AlgorithmES256: {Kty: azkeys.KeyTypeEC, Curve: azkeys.CurveNameP256},
+	AlgorithmES384: {Kty: azkeys.KeyTypeEC, Curve: azkeys.CurveNameP384},
+	AlgorithmES512: {Kty: azkeys.KeyTypeEC, Curve: azkeys.CurveNameP521},
+	AlgorithmRS256: {Kty: azkeys.KeyTypeRSA, KeySize: 2048},
+	AlgorithmRS384: {Kty: azkeys.KeyTypeRSA, KeySize: 3072},
+	AlgorithmRS512: {Kty: azkeys.KeyTypeRSA, KeySize: 4096},
+}
+
+const cacheTTL = 5 * time.Minute
+
+var (
+	errReference = errors.New("kms specification should be in the format azurekms://[VAULT_NAME][VAULT_URI]/[KEY][/VERSION]")
+
+	referenceRE = regexp.MustCompile(`^azurekms://([A-Za-z0-9][A-Za-z0-9.-]*)/([A-Za-z0-9-]{1,127})(?:/([A-Za-z0-9]+))?$`)
+)
+
+// Reference identifies a key and, optionally, one of its versions.
+type Reference struct {
+	// Vault is the name of a key vault, or the host name of a key vault
+	// or a Managed HSM.
+	Vault string
+	// Key is the name of the key.
+	Key string
+	// Version pins the key version to sign and verify with, or is empty to
+	// use the current version.
+	Version string
+}
+
+// ParseReference parses references of the form
+// azurekms://VAULT/KEY[/VERSION], where VAULT is the name of a key vault or
+// the host name of a key vault or Managed HSM, like
+// NAME.managedhsm.azure.net.
+func ParseReference(ref string) (*Reference, error) {
+	v := referenceRE.FindStringSubmatch(ref)
+	if v == nil {
+		return nil, errReference
+	}
+	return &Reference{Vault: v[1], Key: v[2], Version: v[3]}, nil
+}
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	_, err := ParseReference(ref)
+	return err
+}
+
+// vaultURL returns the URL of the vault of r in cloud c, and whether it is a
+// Managed HSM. Vaults named without a domain are key vaults of c.
+func (r *Reference) vaultURL(c azureCloud) (string, bool) {
+	host := r.Vault
+	if !strings.Contains(host, ".") {
+		host += "." + c.VaultSuffix
+	}
+	managedHSM := strings.Contains(host, ".managedhsm.")
+	return "https://" + host + "/", managedHSM
+}
+
+// kvClient is the part of azkeys.Client the client uses.
+type kvClient interface {
+	CreateKey(ctx context.Context, name string, parameters azkeys.CreateKeyParameters, options *azkeys.CreateKeyOptions) (azkeys.CreateKeyResponse, error)
+	GetKey(ctx context.Context, name, version string, options *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error)
+	Sign(ctx context.Context, name, version string, parameters azkeys.SignParameters, options *azkeys.SignOptions) (azkeys.SignResponse, error)
+	NewListKeyPropertiesVersionsPager(name string, options *azkeys.ListKeyPropertiesVersionsOptions) *runtime.Pager[azkeys.ListKeyPropertiesVersionsResponse]
+}
+
+type azureKMSClient struct {
+	client     kvClient
+	managedHSM bool
+	keyName    string
+	keyVersion string
+
+	mu        sync.Mutex
+	key       *azureSigningKey
+	keyExpiry time.Time
+}
+
+// azureSigningKey is a version of a key and how to sign and verify with it.
+type azureSigningKey struct {
+	Version   string
+	Algorithm azkeys.SignatureAlgorithm
+	Verifier  signature.Verifier
+	HashFunc  crypto.Hash
+}
+
+func newAzureKMSClient(ref *Reference, keyVersion string) (*azureKMSClient, error) {
+	c, err := getCloud()
+	if err != nil {
+		return nil, err
+	}
+	vaultURL, managedHSM := ref.vaultURL(c)
+	clientOpts := azcore.ClientOptions{Cloud: c.Configuration}
+	cred, err := newCredential(clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("new azure credential: %w", err)
+	}
+	client, err := azkeys.NewClient(vaultURL, cred, &azkeys.ClientOptions{ClientOptions: clientOpts})
+	if err != nil {
+		return nil, fmt.Errorf("new azure kms client: %w", err)
+	}
+	return &azureKMSClient{
+		client:     client,
+		managedHSM: managedHSM,
+		keyName:    ref.Key,
+		keyVersion: keyVersion,
+	}, nil
+}
+
+func (a *azureKMSClient) getAzureSigningKey(ctx context.Context) (*azureSigningKey, error) {
+	resp, err := a.client.GetKey(ctx, a.keyName, a.keyVersion, nil)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	if resp.Key == nil || resp.Key.KID == nil {
+		return nil, fmt.Errorf("key %s has no key material", a.keyName)
+	}
+	pub, err := publicKeyFromJWK(resp.Key)
+	if err != nil {
+		return nil, err
+	}
+	sk := azureSigningKey{Version: resp.Key.KID.Version()}
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			sk.HashFunc, sk.Algorithm = crypto.SHA256, azkeys.SignatureAlgorithmES256
+		case elliptic.P384():
+			sk.HashFunc, sk.Algorithm = crypto.SHA384, azkeys.SignatureAlgorithmES384
+		default:
+			sk.HashFunc, sk.Algorithm = crypto.SHA512, azkeys.SignatureAlgorithmES512
+		}
+		sk.Verifier, err = signature.LoadECDSAVerifier(pub, sk.HashFunc)
+	case *rsa.PublicKey:
+		switch pub.Size() {
+		case 256:
+			sk.HashFunc, sk.Algorithm = crypto.SHA256, azkeys.SignatureAlgorithmRS256
+		case 384:
+			sk.HashFunc, sk.Algorithm = crypto.SHA384, azkeys.SignatureAlgorithmRS384
+		case 512:
+			sk.HashFunc, sk.Algorithm = crypto.SHA512, azkeys.SignatureAlgorithmRS512
+		default:
+			return nil, fmt.Errorf("unsupported RSA key size: %d", pub.Size())
+		}
+		sk.Verifier, err = signature.LoadRSAPKCS1v15Verifier(pub, sk.HashFunc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initializing internal verifier: %w", err)
+	}
+	return &sk, nil
+}
+
+// publicKeyFromJWK returns the public key of a JSON web key of Key Vault,
+// whose key types are suffixed with -HSM for keys protected by an HSM.
+func publicKeyFromJWK(jwk *azkeys.JSONWebKey) (crypto.PublicKey, error) {
+	if jwk.Kty == nil {
+		return nil, errors.New("key has no key type")
+	}
+	switch *jwk.Kty {
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		if jwk.Crv == nil {
+			return nil, errors.New("EC key has no curve")
+		}
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch *jwk.Crv {
+		case azkeys.CurveNameP256:
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case azkeys.CurveNameP384:
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case azkeys.CurveNameP521:
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", *jwk.Crv)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(jwk.X) > size || len(jwk.Y) > size {
+			return nil, errors.New("invalid EC key coordinates")
+		}
+		// Checks that the point is on the curve.
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		copy(point[1+size-len(jwk.X):], jwk.X)
+		copy(point[1+2*size-len(jwk.Y):], jwk.Y)
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid EC key: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(jwk.X), Y: new(big.Int).SetBytes(jwk.Y)}, nil
+	case azkeys.KeyTypeRSA, azkeys.KeyTypeRSAHSM:
+		e := new(big.Int).SetBytes(jwk.E)
+		if len(jwk.N) == 0 || !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(jwk.N), E: int(e.Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", *jwk.Kty)
+	}
+}
+
+// getSK returns the signing key, fetching it again once it has been cached
+// for cacheTTL so that the rotations of unpinned keys are picked up.
+func (a *azureKMSClient) getSK(ctx context.Context) (*azureSigningKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.key != nil && time.Now().Before(a.keyExpiry) {
+		return a.key, nil
+	}
+	sk, err := a.getAzureSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.key, a.keyExpiry = sk, time.Now().Add(cacheTTL)
+	return sk, nil
+}
+
+func (a *azureKMSClient) getHashFunc(ctx context.Context) (crypto.Hash, error) {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return sk.HashFunc, nil
+}
+
+// sign signs digest with the version of the key whose public key verifies
+// the signature, so that a rotation never produces unverifiable signatures.
+func (a *azureKMSClient) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hf != sk.HashFunc {
+		return nil, fmt.Errorf("hash function %v does not match the %v of the key", hf, sk.HashFunc)
+	}
+	resp, err := a.client.Sign(ctx, a.keyName, sk.Version, azkeys.SignParameters{
+		Algorithm: to.Ptr(sk.Algorithm),
+		Value:     digest,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("signing the payload: %w", err)
+	}
+	if _, ok := sk.Verifier.(*signature.ECDSAVerifier); !ok {
+		return resp.Result, nil
+	}
+	// Key Vault returns ECDSA signatures as the concatenation r||s.
+	l := len(resp.Result)
+	if l == 0 || l%2 != 0 {
+		return nil, errors.New("invalid ECDSA signature returned by Key Vault")
+	}
+	r, s := new(big.Int).SetBytes(resp.Result[:l/2]), new(big.Int).SetBytes(resp.Result[l/2:])
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.Bytes()
+}
+
+func (a *azureKMSClient) verify(ctx context.Context, sig, message io.Reader, opts ...signature.VerifyOption) error {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return err
+	}
+	return sk.Verifier.VerifySignature(sig, message, opts...)
+}
+
+// KeyVersion is a version of a key.
+type KeyVersion struct {
+	// Version is the version to pin in key references.
+	Version string
+	Enabled bool
+	// Created, NotBefore and Expires are zero when unset.
+	Created   time.Time
+	NotBefore time.Time
+	Expires   time.Time
+}
+
+// listVersions returns the versions of the key, oldest first.
+func (a *azureKMSClient) listVersions(ctx context.Context) ([]KeyVersion, error) {
+	var versions []KeyVersion
+	pager := a.client.NewListKeyPropertiesVersionsPager(a.keyName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing versions of key %s: %w", a.keyName, err)
+		}
+		for _, props := range page.Value {
+			if props == nil || props.KID == nil {
+				continue
+			}
+			v := KeyVersion{Version: props.KID.Version()}
+			if attrs := props.Attributes; attrs != nil {
+				v.Enabled = attrs.Enabled != nil && *attrs.Enabled
+				if attrs.Created != nil {
+					v.Created = *attrs.Created
+				}
+				if attrs.NotBefore != nil {
+					v.NotBefore = *attrs.NotBefore
+				}
+				if attrs.Expires != nil {
+					v.Expires = *attrs.Expires
+				}
+			}
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Created.Before(versions[j].Created)
+	})
+	return versions, nil
+}
+
+// createKey creates the key, or returns the public key of its current
+// version if it already exists. Keys in a Managed HSM are HSM-protected.
+func (a *azureKMSClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if a.keyVersion != "" {
+		return nil, errors.New("cannot create a key from a reference pinning a key version")
+	}
+	spec, ok := algorithmMap[algorithm]
+	if !ok {
+		return nil, errors.New("unknown algorithm requested")
+	}
+	pub, err := a.public(ctx)
+	var respErr *azcore.ResponseError
+	switch {
+	case err == nil:
+		return pub, nil
+	case !errors.As(err, &respErr) || respErr.StatusCode != http.StatusNotFound:
+		return nil, fmt.Errorf("unexpected error returned by get key operation: %w", err)
+	}
+
+	params := azkeys.CreateKeyParameters{
+		Kty:           to.Ptr(spec.Kty),
+		KeyAttributes: &azkeys.KeyAttributes{Enabled: to.Ptr(true)},
+		KeyOps: []*azkeys.KeyOperation{
+			to.Ptr(azkeys.KeyOperationSign),
+			to.Ptr(azkeys.KeyOperationVerify),
+		},
+		Tags: map[string]*string{"use": to.Ptr("sigstore")},
+	}
+	if spec.Curve != "" {
+		params.Curve = to.Ptr(spec.Curve)
+	}
+	if spec.KeySize != 0 {
+		params.KeySize = to.Ptr(spec.KeySize)
+	}
+	if a.managedHSM {
+		switch spec.Kty {
+		case azkeys.KeyTypeEC:
+			params.Kty = to.Ptr(azkeys.KeyTypeECHSM)
+		case azkeys.KeyTypeRSA:
+			params.Kty = to.Ptr(azkeys.KeyTypeRSAHSM)
+		}
+	}
+	if _, err := a.client.CreateKey(ctx, a.keyName, params, nil); err != nil {
+		return nil, fmt.Errorf("azurekms key create error: %w", err)
+	}
+	return a.public(ctx)
+}
+
+func (a *azureKMSClient) public(ctx context.Context) (crypto.PublicKey, error) {
+	sk, err := a.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Verifier.PublicKey()
+}
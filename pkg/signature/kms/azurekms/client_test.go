@@ -0,0 +1,447 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azurekms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *Reference
+		wantErr bool
+	}{{
+		in:   "azurekms://cosign-vault/cosign",
+		want: &Reference{Vault: "cosign-vault", Key: "cosign"},
+	}, {
+		in:   "azurekms://cosign-vault.vault.azure.net/cosign/0123456789abcdef0123456789abcdef",
+		want: &Reference{Vault: "cosign-vault.vault.azure.net", Key: "cosign", Version: "0123456789abcdef0123456789abcdef"},
+	}, {
+		in:   "azurekms://cosign-hsm.managedhsm.azure.net/cosign",
+		want: &Reference{Vault: "cosign-hsm.managedhsm.azure.net", Key: "cosign"},
+	}, {
+		in:      "azurekms://cosign-vault/",
+		wantErr: true,
+	}, {
+		in:      "azurekms://cosign-vault/cosign/versions/0123",
+		wantErr: true,
+	}, {
+		in:      "azurekms://cosign-vault/cosign_key",
+		wantErr: true,
+	}, {
+		in:      "hashivault://cosign-vault/cosign",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVaultURL(t *testing.T) {
+	tests := []struct {
+		vault          string
+		cloud          azureCloud
+		wantURL        string
+		wantManagedHSM bool
+	}{{
+		vault:   "cosign-vault",
+		cloud:   azurePublicCloud,
+		wantURL: "https://cosign-vault.vault.azure.net/",
+	}, {
+		vault:   "cosign-vault",
+		cloud:   azureChinaCloud,
+		wantURL: "https://cosign-vault.vault.azure.cn/",
+	}, {
+		vault:   "cosign-vault.vault.usgovcloudapi.net",
+		cloud:   azurePublicCloud,
+		wantURL: "https://cosign-vault.vault.usgovcloudapi.net/",
+	}, {
+		vault:          "cosign-hsm.managedhsm.azure.net",
+		cloud:          azurePublicCloud,
+		wantURL:        "https://cosign-hsm.managedhsm.azure.net/",
+		wantManagedHSM: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.vault, func(t *testing.T) {
+			ref := &Reference{Vault: tt.vault, Key: "cosign"}
+			gotURL, gotManagedHSM := ref.vaultURL(tt.cloud)
+			require.Equal(t, tt.wantURL, gotURL)
+			require.Equal(t, tt.wantManagedHSM, gotManagedHSM)
+		})
+	}
+}
+
+// fakeVersion is a version of the key of fakeKeyVault.
+type fakeVersion struct {
+	version string
+	private crypto.Signer
+	created time.Time
+	enabled bool
+}
+
+// fakeKeyVault implements kvClient for a single key named cosign, whose last
+// version is its current one.
+type fakeKeyVault struct {
+	t        *testing.T
+	hsm      bool
+	versions []fakeVersion
+	created  *azkeys.CreateKeyParameters
+}
+
+func (f *fakeKeyVault) find(name, version string) (*fakeVersion, error) {
+	if name == "cosign" && len(f.versions) > 0 {
+		if version == "" {
+			return &f.versions[len(f.versions)-1], nil
+		}
+		for i := range f.versions {
+			if f.versions[i].version == version {
+				return &f.versions[i], nil
+			}
+		}
+	}
+	return nil, &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "KeyNotFound"}
+}
+
+func (f *fakeKeyVault) jwk(v *fakeVersion) *azkeys.JSONWebKey {
+	kid := azkeys.ID("https://cosign-vault.vault.azure.net/keys/cosign/" + v.version)
+	switch pub := v.private.Public().(type) {
+	case *ecdsa.PublicKey:
+		kty, crv := azkeys.KeyTypeEC, azkeys.CurveName(pub.Curve.Params().Name)
+		if f.hsm {
+			kty = azkeys.KeyTypeECHSM
+		}
+		return &azkeys.JSONWebKey{KID: &kid, Kty: &kty, Crv: &crv, X: pub.X.Bytes(), Y: pub.Y.Bytes()}
+	case *rsa.PublicKey:
+		kty := azkeys.KeyTypeRSA
+		if f.hsm {
+			kty = azkeys.KeyTypeRSAHSM
+		}
+		return &azkeys.JSONWebKey{KID: &kid, Kty: &kty, N: pub.N.Bytes(), E: big.NewInt(int64(pub.E)).Bytes()}
+	}
+	f.t.Fatalf("unexpected key type %T", v.private)
+	return nil
+}
+
+func (f *fakeKeyVault) CreateKey(_ context.Context, name string, parameters azkeys.CreateKeyParameters, _ *azkeys.CreateKeyOptions) (azkeys.CreateKeyResponse, error) {
+	require.Equal(f.t, "cosign", name)
+	f.created = &parameters
+	var private crypto.Signer
+	var err error
+	switch *parameters.Kty {
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, int(*parameters.KeySize))
+	}
+	require.NoError(f.t, err)
+	f.versions = append(f.versions, fakeVersion{version: "0000000000000000000000000000000a", private: private, created: time.Now(), enabled: true})
+	return azkeys.CreateKeyResponse{KeyBundle: azkeys.KeyBundle{Key: f.jwk(&f.versions[0])}}, nil
+}
+
+func (f *fakeKeyVault) GetKey(_ context.Context, name, version string, _ *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error) {
+	v, err := f.find(name, version)
+	if err != nil {
+		return azkeys.GetKeyResponse{}, err
+	}
+	return azkeys.GetKeyResponse{KeyBundle: azkeys.KeyBundle{Key: f.jwk(v)}}, nil
+}
+
+func (f *fakeKeyVault) Sign(_ context.Context, name, version string, parameters azkeys.SignParameters, _ *azkeys.SignOptions) (azkeys.SignResponse, error) {
+	require.NotEmpty(f.t, version, "signing with an unresolved key version")
+	v, err := f.find(name, version)
+	if err != nil {
+		return azkeys.SignResponse{}, err
+	}
+	if !v.enabled {
+		return azkeys.SignResponse{}, &azcore.ResponseError{StatusCode: http.StatusForbidden, ErrorCode: "Forbidden"}
+	}
+	var sig []byte
+	switch priv := v.private.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, priv, parameters.Value)
+		require.NoError(f.t, err)
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	case *rsa.PrivateKey:
+		hf := map[azkeys.SignatureAlgorithm]crypto.Hash{
+			azkeys.SignatureAlgorithmRS256: crypto.SHA256,
+			azkeys.SignatureAlgorithmRS384: crypto.SHA384,
+			azkeys.SignatureAlgorithmRS512: crypto.SHA512,
+		}[*parameters.Algorithm]
+		sig, err = rsa.SignPKCS1v15(rand.Reader, priv, hf, parameters.Value)
+		require.NoError(f.t, err)
+	}
+	return azkeys.SignResponse{KeyOperationResult: azkeys.KeyOperationResult{KID: f.jwk(v).KID, Result: sig}}, nil
+}
+
+// NewListKeyPropertiesVersionsPager returns the versions newest first, one
+// per page.
+func (f *fakeKeyVault) NewListKeyPropertiesVersionsPager(name string, _ *azkeys.ListKeyPropertiesVersionsOptions) *runtime.Pager[azkeys.ListKeyPropertiesVersionsResponse] {
+	require.Equal(f.t, "cosign", name)
+	next := len(f.versions) - 1
+	return runtime.NewPager(runtime.PagingHandler[azkeys.ListKeyPropertiesVersionsResponse]{
+		More: func(azkeys.ListKeyPropertiesVersionsResponse) bool { return next >= 0 },
+		Fetcher: func(context.Context, *azkeys.ListKeyPropertiesVersionsResponse) (azkeys.ListKeyPropertiesVersionsResponse, error) {
+			v := f.versions[next]
+			next--
+			return azkeys.ListKeyPropertiesVersionsResponse{KeyPropertiesListResult: azkeys.KeyPropertiesListResult{
+				Value: []*azkeys.KeyProperties{{
+					KID:        f.jwk(&v).KID,
+					Attributes: &azkeys.KeyAttributes{Enabled: to.Ptr(v.enabled), Created: to.Ptr(v.created)},
+				}},
+			}}, nil
+		},
+	})
+}
+
+func newFakeSignerVerifier(f *fakeKeyVault, keyVersion string) *SignerVerifier {
+	return &SignerVerifier{client: &azureKMSClient{
+		client:     f,
+		managedHSM: f.hsm,
+		keyName:    "cosign",
+		keyVersion: keyVersion,
+	}}
+}
+
+func TestSignerVerifier(t *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		hsm      bool
+		private  crypto.Signer
+		hashFunc crypto.Hash
+	}{{
+		name:     "ecdsa p256",
+		private:  p256Key,
+		hashFunc: crypto.SHA256,
+	}, {
+		name:     "ecdsa p384 in managed hsm",
+		hsm:      true,
+		private:  p384Key,
+		hashFunc: crypto.SHA384,
+	}, {
+		name:     "rsa in managed hsm",
+		hsm:      true,
+		private:  rsaKey,
+		hashFunc: crypto.SHA256,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeKeyVault{t: t, hsm: tt.hsm, versions: []fakeVersion{{version: "0123456789abcdef0123456789abcdef", private: tt.private, enabled: true}}}
+			sv := newFakeSignerVerifier(f, "")
+			ctx := context.Background()
+			message := []byte("hello, azure")
+
+			sig, err := sv.SignMessage(bytes.NewReader(message), options.WithContext(ctx))
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+			require.Error(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("something else"))))
+
+			pub, err := sv.PublicKey()
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(tt.private.Public(), pub))
+
+			// The key is verifiable without Key Vault.
+			verifier, err := signature.LoadVerifier(pub, tt.hashFunc)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+
+			signer, opts, err := sv.CryptoSigner(ctx, func(err error) { t.Error(err) })
+			require.NoError(t, err)
+			require.Equal(t, tt.hashFunc, opts.HashFunc())
+			h := tt.hashFunc.New()
+			h.Write(message)
+			sig, err = signer.Sign(rand.Reader, h.Sum(nil), opts)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+		})
+	}
+}
+
+func TestPinnedKeyVersion(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now()
+	f := &fakeKeyVault{t: t, versions: []fakeVersion{
+		{version: "0000000000000000000000000000000a", private: oldKey, created: now.Add(-time.Hour), enabled: false},
+		{version: "0000000000000000000000000000000b", private: newKey, created: now, enabled: true},
+	}}
+	message := []byte("signed before the rotation")
+	digest := sha256.Sum256(message)
+	oldSig, err := oldKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+
+	// The current version no longer verifies signatures of the old one.
+	current := newFakeSignerVerifier(f, "")
+	require.Error(t, current.VerifySignature(bytes.NewReader(oldSig), bytes.NewReader(message)))
+
+	pinned := newFakeSignerVerifier(f, "0000000000000000000000000000000a")
+	require.NoError(t, pinned.VerifySignature(bytes.NewReader(oldSig), bytes.NewReader(message)))
+	pub, err := pinned.PublicKey()
+	require.NoError(t, err)
+	require.NoError(t, cryptoutils.EqualKeys(oldKey.Public(), pub))
+
+	// Disabled versions verify, but do not sign.
+	_, err = pinned.SignMessage(bytes.NewReader(message))
+	var respErr *azcore.ResponseError
+	require.True(t, errors.As(err, &respErr))
+	require.Equal(t, http.StatusForbidden, respErr.StatusCode)
+
+	_, err = pinned.CreateKey(context.Background(), AlgorithmES256)
+	require.ErrorContains(t, err, "pinning a key version")
+
+	_, err = newFakeSignerVerifier(f, "0000000000000000000000000000000c").PublicKey()
+	require.ErrorContains(t, err, "KeyNotFound")
+}
+
+func TestKeyVersions(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	f := &fakeKeyVault{t: t}
+	var want []KeyVersion
+	for i := 0; i < 3; i++ {
+		v := fakeVersion{version: fmt.Sprintf("%032x", i), private: key, created: now.Add(time.Duration(i) * time.Hour), enabled: i > 0}
+		f.versions = append(f.versions, v)
+		want = append(want, KeyVersion{Version: v.version, Enabled: v.enabled, Created: v.created})
+	}
+
+	got, err := newFakeSignerVerifier(f, "").KeyVersions(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestLoadSignerVerifierKeyVersion(t *testing.T) {
+	t.Setenv(EnvAuthMethod, "cli")
+
+	sv, err := LoadSignerVerifier(context.Background(), "azurekms://cosign-vault/cosign", options.WithKeyVersion("0123"))
+	require.NoError(t, err)
+	require.Equal(t, "0123", sv.client.keyVersion)
+
+	sv, err = LoadSignerVerifier(context.Background(), "azurekms://cosign-vault/cosign/0123", options.WithKeyVersion("0123"))
+	require.NoError(t, err)
+	require.Equal(t, "0123", sv.client.keyVersion)
+
+	_, err = LoadSignerVerifier(context.Background(), "azurekms://cosign-vault/cosign/0123", options.WithKeyVersion("4567"))
+	require.ErrorContains(t, err, "conflicts")
+}
+
+func TestCreateKey(t *testing.T) {
+	for _, hsm := range []bool{false, true} {
+		t.Run(fmt.Sprintf("managed hsm %v", hsm), func(t *testing.T) {
+			f := &fakeKeyVault{t: t, hsm: hsm}
+			sv := newFakeSignerVerifier(f, "")
+			pub, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(f.versions[0].private.Public(), pub))
+			require.Equal(t, azkeys.CurveNameP256, *f.created.Curve)
+			wantKty := azkeys.KeyTypeEC
+			if hsm {
+				wantKty = azkeys.KeyTypeECHSM
+			}
+			require.Equal(t, wantKty, *f.created.Kty)
+
+			// Creating an existing key returns its public key.
+			f.created = nil
+			sv = newFakeSignerVerifier(f, "")
+			pub, err = sv.CreateKey(context.Background(), AlgorithmRS256)
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(f.versions[0].private.Public(), pub))
+			require.Nil(t, f.created)
+
+			_, err = sv.CreateKey(context.Background(), "ed25519")
+			require.ErrorContains(t, err, "unknown algorithm")
+		})
+	}
+}
+
+func TestPublicKeyFromJWK(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		jwk     *azkeys.JSONWebKey
+		wantErr string
+	}{{
+		name:    "no key type",
+		jwk:     &azkeys.JSONWebKey{},
+		wantErr: "no key type",
+	}, {
+		name:    "symmetric key",
+		jwk:     &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeOctHSM)},
+		wantErr: "unsupported key type oct-HSM",
+	}, {
+		name:    "secp256k1",
+		jwk:     &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeEC), Crv: to.Ptr(azkeys.CurveNameP256K), X: key.X.Bytes(), Y: key.Y.Bytes()},
+		wantErr: "unsupported curve P-256K",
+	}, {
+		name:    "point not on curve",
+		jwk:     &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeEC), Crv: to.Ptr(azkeys.CurveNameP256), X: key.X.Bytes(), Y: key.X.Bytes()},
+		wantErr: "invalid EC key",
+	}, {
+		name:    "rsa without modulus",
+		jwk:     &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeRSA), E: []byte{1, 0, 1}},
+		wantErr: "invalid RSA key",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := publicKeyFromJWK(tt.jwk)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azurekms
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+)
+
+var azureSupportedHashFuncs = []crypto.Hash{
+	crypto.SHA256,
+	crypto.SHA384,
+	crypto.SHA512,
+}
+
+// SignerVerifier is a signature.SignerVerifier that uses a key in Azure Key
+// Vault or Managed HSM
+type SignerVerifier struct {
+	client *azureKMSClient
+}
+
+// LoadSignerVerifier generates signatures using the key referred to by
+// referenceStr, with the key version it pins or that opts set with
+// options.WithKeyVersion, or else the current version of the key.
+//
+// It also can verify signatures locally using the public key.
+func LoadSignerVerifier(_ context.Context, referenceStr string, opts ...signature.RPCOption) (*SignerVerifier, error) {
+	ref, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	var keyVersion string
+	for _, opt := range opts {
+		opt.ApplyKeyVersion(&keyVersion)
+	}
+	switch {
+	case ref.Version != "" && keyVersion != "" && keyVersion != ref.Version:
+		return nil, fmt.Errorf("key version %s conflicts with version %s of %s", keyVersion, ref.Version, referenceStr)
+	case ref.Version != "":
+		keyVersion = ref.Version
+	}
+	client, err := newAzureKMSClient(ref, keyVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &SignerVerifier{client: client}, nil
+}
+
+// SignMessage signs the provided message using Azure Key Vault. If the
+// message is provided, this method will compute the digest according to the
+// hash function of the key.
+func (a *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	var digest []byte
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	defaultHf, err := a.client.getHashFunc(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+	var signerOpts crypto.SignerOpts = defaultHf
+	for _, opt := range opts {
+		opt.ApplyDigest(&digest)
+		opt.ApplyCryptoSignerOpts(&signerOpts)
+	}
+
+	hf := signerOpts.HashFunc()
+	if len(digest) == 0 {
+		digest, hf, err = signature.ComputeDigestForSigning(message, hf, azureSupportedHashFuncs, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.client.sign(ctx, digest, hf)
+}
+
+// PublicKey returns the public key that can be used to verify signatures created by
+// this signer. If the caller wishes to specify the context to use to obtain
+// the public key, pass option.WithContext(desiredCtx).
+//
+// All other options are ignored if specified.
+func (a *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	return a.client.public(ctx)
+}
+
+// VerifySignature verifies the signature for the given message. Unless provided
+// in an option, the digest of the message will be computed using the hash
+// function of the key.
+func (a *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	return a.client.verify(ctx, sig, message, opts...)
+}
+
+// KeyVersions returns the versions of the key, oldest first, so that the
+// version that produced a signature can be pinned to verify it.
+func (a *SignerVerifier) KeyVersions(ctx context.Context) ([]KeyVersion, error) {
+	return a.client.listVersions(ctx)
+}
+
+// CreateKey creates the key with the specified algorithm unless it already
+// exists. It fails if the reference pins a key version.
+func (a *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	return a.client.createKey(ctx, algorithm)
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	return c.sv.SignMessage(nil,
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (a *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	defaultHf, err := a.client.getHashFunc(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       a,
+		hashFunc: defaultHf,
+		errFunc:  errFunc,
+	}
+
+	return csw, defaultHf, nil
+}
+
+// SupportedAlgorithms returns the list of algorithms supported by Azure Key Vault
+func (*SignerVerifier) SupportedAlgorithms() []string {
+	result := make([]string, 0, len(algorithmMap))
+	for k := range algorithmMap {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// DefaultAlgorithm returns the default algorithm for Azure Key Vault
+func (*SignerVerifier) DefaultAlgorithm() string {
+	return AlgorithmES256
+}
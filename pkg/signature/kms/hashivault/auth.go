@@ -0,0 +1,147 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+const (
+	// EnvAuthMethod selects how to log in to Vault: "token" (the default)
+	// uses VAULT_TOKEN or ~/.vault-token, "kubernetes", "approle" and "jwt"
+	// log in with the auth method of that name.
+	EnvAuthMethod = "VAULT_AUTH_METHOD"
+	// EnvAuthPath is the mount path of the auth method, which is the name
+	// of the method by default.
+	EnvAuthPath = "VAULT_AUTH_PATH"
+	// EnvRole is the role to log in as with the kubernetes and jwt methods.
+	EnvRole = "VAULT_ROLE"
+	// EnvKubernetesTokenPath is the service account token to log in with
+	// the kubernetes method.
+	EnvKubernetesTokenPath = "VAULT_KUBERNETES_TOKEN_PATH"
+	// EnvRoleID and EnvSecretID are the credentials of the approle method.
+	EnvRoleID   = "VAULT_ROLE_ID"
+	EnvSecretID = "VAULT_SECRET_ID" //nolint:gosec
+	// EnvJWT is the token to log in with the jwt method.
+	EnvJWT = "VAULT_JWT"
+
+	authMethodToken      = "token"
+	authMethodKubernetes = "kubernetes"
+	authMethodAppRole    = "approle"
+	authMethodJWT        = "jwt"
+
+	defaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint:gosec
+)
+
+// login returns the token to call Vault with, logging in to namespace with
+// the auth method selected by EnvAuthMethod unless it is "token".
+func login(ctx context.Context, client *vault.Client, namespace string) (string, error) {
+	method := os.Getenv(EnvAuthMethod)
+	if method == "" || method == authMethodToken {
+		return tokenFromEnv()
+	}
+
+	data := map[string]any{}
+	switch method {
+	case authMethodKubernetes:
+		path := os.Getenv(EnvKubernetesTokenPath)
+		if path == "" {
+			path = defaultKubernetesTokenPath
+		}
+		jwt, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return "", fmt.Errorf("reading kubernetes service account token: %w", err)
+		}
+		data["role"] = os.Getenv(EnvRole)
+		data["jwt"] = strings.TrimSpace(string(jwt))
+	case authMethodJWT:
+		data["role"] = os.Getenv(EnvRole)
+		data["jwt"] = os.Getenv(EnvJWT)
+		if data["jwt"] == "" {
+			return "", fmt.Errorf("%s must be set to log in with the jwt auth method", EnvJWT)
+		}
+	case authMethodAppRole:
+		data["role_id"] = os.Getenv(EnvRoleID)
+		data["secret_id"] = os.Getenv(EnvSecretID)
+		if data["role_id"] == "" {
+			return "", fmt.Errorf("%s must be set to log in with the approle auth method", EnvRoleID)
+		}
+	default:
+		return "", fmt.Errorf("unsupported %s %q, must be one of %s, %s, %s or %s",
+			EnvAuthMethod, method, authMethodToken, authMethodKubernetes, authMethodAppRole, authMethodJWT)
+	}
+	if role, ok := data["role"]; ok && role == "" {
+		return "", fmt.Errorf("%s must be set to log in with the %s auth method", EnvRole, method)
+	}
+
+	mount := os.Getenv(EnvAuthPath)
+	if mount == "" {
+		mount = method
+	}
+	return loginWith(ctx, client, namespace, method, mount, data)
+}
+
+// loginWith logs in to namespace with the auth method mounted at mount.
+func loginWith(ctx context.Context, client *vault.Client, namespace, method, mount string, data map[string]any) (string, error) {
+	secret, err := client.Logical().WriteWithContext(ctx, namespacedPath(namespace, "auth/"+strings.Trim(mount, "/")+"/login"), data)
+	if err != nil {
+		return "", fmt.Errorf("vault %s login: %w", method, err)
+	}
+	if secret == nil {
+		return "", fmt.Errorf("vault %s login: empty response", method)
+	}
+	token, err := secret.TokenID()
+	if err != nil {
+		return "", fmt.Errorf("vault %s login: %w", method, err)
+	}
+	if token == "" {
+		return "", fmt.Errorf("vault %s login: no token issued", method)
+	}
+	return token, nil
+}
+
+// tokenFromEnv returns VAULT_TOKEN, or the token of ~/.vault-token.
+func tokenFromEnv() (string, error) {
+	if token := os.Getenv(vault.EnvVaultToken); token != "" {
+		return token, nil
+	}
+	log.Printf("VAULT_TOKEN is not set, trying to read token from file at path ~/.vault-token")
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	token, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", fmt.Errorf("read .vault-token file: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// namespacedPath returns path within namespace. Vault Enterprise accepts
+// namespaces as a prefix of request paths, relative to the namespace of the
+// client.
+func namespacedPath(namespace, path string) string {
+	if namespace == "" {
+		return path
+	}
+	return namespace + "/" + path
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogin(t *testing.T) {
+	dir := t.TempDir()
+	saToken := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(saToken, []byte("sa-jwt\n"), 0600))
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantLogin map[string]any
+		wantErr   bool
+	}{{
+		name: "kubernetes",
+		env:  map[string]string{EnvAuthMethod: "kubernetes", EnvRole: "signer", EnvKubernetesTokenPath: saToken},
+		wantLogin: map[string]any{
+			"path": "auth/kubernetes/login",
+			"data": map[string]any{"role": "signer", "jwt": "sa-jwt"},
+		},
+	}, {
+		name: "jwt with custom mount",
+		env:  map[string]string{EnvAuthMethod: "jwt", EnvAuthPath: "gitlab/", EnvRole: "ci", EnvJWT: "ci-jwt"},
+		wantLogin: map[string]any{
+			"path": "auth/gitlab/login",
+			"data": map[string]any{"role": "ci", "jwt": "ci-jwt"},
+		},
+	}, {
+		name: "approle",
+		env:  map[string]string{EnvAuthMethod: "approle", EnvRoleID: "id", EnvSecretID: "secret"},
+		wantLogin: map[string]any{
+			"path": "auth/approle/login",
+			"data": map[string]any{"role_id": "id", "secret_id": "secret"},
+		},
+	}, {
+		name:    "kubernetes without role",
+		env:     map[string]string{EnvAuthMethod: "kubernetes", EnvKubernetesTokenPath: saToken},
+		wantErr: true,
+	}, {
+		name:    "kubernetes without token",
+		env:     map[string]string{EnvAuthMethod: "kubernetes", EnvRole: "signer", EnvKubernetesTokenPath: filepath.Join(dir, "missing")},
+		wantErr: true,
+	}, {
+		name:    "jwt without token",
+		env:     map[string]string{EnvAuthMethod: "jwt", EnvRole: "ci"},
+		wantErr: true,
+	}, {
+		name:    "approle without role id",
+		env:     map[string]string{EnvAuthMethod: "approle"},
+		wantErr: true,
+	}, {
+		name:    "unsupported",
+		env:     map[string]string{EnvAuthMethod: "ldap"},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{EnvAuthMethod, EnvAuthPath, EnvRole, EnvKubernetesTokenPath, EnvRoleID, EnvSecretID, EnvJWT} {
+				t.Setenv(k, tt.env[k])
+			}
+			t.Setenv("VAULT_NAMESPACE", "")
+			f := newFakeVault(t, "team-a")
+			s := httptest.NewServer(f)
+			defer s.Close()
+			client, err := newVaultClient(s.URL)
+			require.NoError(t, err)
+
+			token, err := login(context.Background(), client, "team-a")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("login() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				require.Empty(t, f.logins)
+				return
+			}
+			require.Equal(t, f.token, token)
+			require.Equal(t, []map[string]any{tt.wantLogin}, f.logins)
+		})
+	}
+}
+
+func TestLoginToken(t *testing.T) {
+	t.Setenv(EnvAuthMethod, "token")
+	t.Setenv("VAULT_TOKEN", "s.env")
+	token, err := login(context.Background(), nil, "")
+	require.NoError(t, err)
+	require.Equal(t, "s.env", token)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VAULT_TOKEN", "")
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".vault-token"), []byte("s.file\n"), 0600))
+	token, err = login(context.Background(), nil, "")
+	require.NoError(t, err)
+	require.Equal(t, "s.file", token)
+}
+
+func TestNewVaultClientRequiresAddress(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	_, err := newVaultClient("")
+	require.ErrorIs(t, err, errNoAddress)
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+	sighashivault "github.com/franchb/sigstore/pkg/signature/kms/hashivault"
+	vault "github.com/hashicorp/vault/api"
+)
+
+func init() {
+	// Importing sighashivault registers its provider first, which this one
+	// replaces.
+	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID, hashFunc, opts...)
+	})
+}
+
+// ReferenceScheme is the scheme of references to keys in the transit secrets
+// engine of HashiCorp Vault.
+const ReferenceScheme = sighashivault.ReferenceScheme
+
+var (
+	errReference = errors.New("kms specification should be in the format hashivault://[NAMESPACE/]KEY[/versions/VERSION]")
+	errNoAddress = errors.New("VAULT_ADDR is not set")
+)
+
+// Reference identifies a transit key and, optionally, one of its versions.
+type Reference struct {
+	// Namespace is the Vault Enterprise namespace of the transit secrets
+	// engine, relative to VAULT_NAMESPACE.
+	Namespace string
+	// Key is the name of the transit key.
+	Key string
+	// Version pins the key version to sign and verify with, or is empty to
+	// use the latest version.
+	Version string
+}
+
+// ParseReference parses references of the form
+// hashivault://[NAMESPACE/]KEY[/versions/VERSION], where NAMESPACE may
+// itself be a path of nested namespaces.
+func ParseReference(ref string) (*Reference, error) {
+	path, ok := strings.CutPrefix(ref, ReferenceScheme)
+	if !ok {
+		return nil, errReference
+	}
+	parts := strings.Split(path, "/")
+	r := &Reference{}
+	if n := len(parts); n >= 3 && parts[n-2] == "versions" {
+		v, err := strconv.ParseUint(parts[n-1], 10, 64)
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("invalid key version %q: %w", parts[n-1], errReference)
+		}
+		r.Version = strconv.FormatUint(v, 10)
+		parts = parts[:n-2]
+	}
+	r.Key = parts[len(parts)-1]
+	if err := sighashivault.ValidReference(ReferenceScheme + r.Key); err != nil {
+		return nil, errReference
+	}
+	for _, ns := range parts[:len(parts)-1] {
+		if ns == "" {
+			return nil, errReference
+		}
+	}
+	r.Namespace = strings.Join(parts[:len(parts)-1], "/")
+	return r, nil
+}
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	_, err := ParseReference(ref)
+	return err
+}
+
+// newVaultClient returns a client for address, or VAULT_ADDR if it is empty.
+// Like every Vault client, it sends VAULT_NAMESPACE with its requests.
+func newVaultClient(address string) (*vault.Client, error) {
+	if address == "" {
+		address = os.Getenv(vault.EnvVaultAddress)
+	}
+	if address == "" {
+		return nil, errNoAddress
+	}
+	client, err := vault.NewClient(&vault.Config{Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("new vault client: %w", err)
+	}
+	return client, nil
+}
+
+// NewClient returns a client of VAULT_ADDR with the token of a login to
+// namespace, with the auth method that VAULT_AUTH_METHOD selects, for use
+// with secrets engines other than transit.
+func NewClient(ctx context.Context, namespace string) (*vault.Client, error) {
+	client, err := newVaultClient("")
+	if err != nil {
+		return nil, err
+	}
+	token, err := login(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+	return client, nil
+}
+
+// fetchPublicKey returns the public key of version of the transit key read
+// from keyPath.
+func fetchPublicKey(ctx context.Context, client *vault.Client, keyPath, version string) (crypto.PublicKey, error) {
+	keyResult, err := client.Logical().ReadWithContext(ctx, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	if keyResult == nil {
+		return nil, fmt.Errorf("could not read data from transit key path: %s", keyPath)
+	}
+	keys, ok := keyResult.Data["keys"].(map[string]any)
+	if !ok {
+		return nil, errors.New("failed to read transit key keys: corrupted response")
+	}
+	keyData, ok := keys[version]
+	if !ok {
+		return nil, fmt.Errorf("transit key %s has no version %s", keyPath, version)
+	}
+	keyMap, ok := keyData.(map[string]any)
+	if !ok {
+		return nil, errors.New("could not parse transit key keys data")
+	}
+	publicKey, ok := keyMap["public_key"].(string)
+	if !ok {
+		return nil, errors.New("failed to read transit key public key: corrupted response")
+	}
+	// vault returns ed25519 public keys as the base64 encoding of their
+	// raw bytes, and other keys as PEM.
+	if keyMap["name"] == "ed25519" {
+		raw, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to base64 decode ed25519 public key: %w", err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("decoded ed25519 public key length is %d, should be %d", len(raw), ed25519.PublicKeySize)
+		}
+		return ed25519.PublicKey(raw), nil
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey([]byte(publicKey))
+}
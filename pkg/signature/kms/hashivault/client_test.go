@@ -0,0 +1,226 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Reference
+		wantErr bool
+	}{{
+		in:   "hashivault://cosign",
+		want: Reference{Key: "cosign"},
+	}, {
+		in:   "hashivault://team-a/cosign",
+		want: Reference{Namespace: "team-a", Key: "cosign"},
+	}, {
+		in:   "hashivault://org/team-a/cosign/versions/3",
+		want: Reference{Namespace: "org/team-a", Key: "cosign", Version: "3"},
+	}, {
+		in:   "hashivault://cosign/versions/1",
+		want: Reference{Key: "cosign", Version: "1"},
+	}, {
+		in:      "hashivault://cosign/versions/0",
+		wantErr: true,
+	}, {
+		in:      "hashivault://cosign/versions/latest",
+		wantErr: true,
+	}, {
+		in:      "hashivault://team-a//cosign",
+		wantErr: true,
+	}, {
+		in:      "hashivault://team-a/",
+		wantErr: true,
+	}, {
+		in:      "awskms://cosign",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			if err == nil {
+				require.Equal(t, tt.want, *got)
+			}
+		})
+	}
+}
+
+// fakeVault serves the parts of the Vault API the provider uses, for
+// transit keys with two versions mounted at transit in a namespace.
+type fakeVault struct {
+	t         *testing.T
+	namespace string
+	token     string
+	versions  map[string]*ecdsa.PrivateKey
+
+	logins []map[string]any
+	signed []string
+}
+
+func newFakeVault(t *testing.T, namespace string) *fakeVault {
+	f := &fakeVault{t: t, namespace: namespace, token: "s.issued", versions: map[string]*ecdsa.PrivateKey{}}
+	for _, v := range []string{"1", "2"} {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		f.versions[v] = priv
+	}
+	return f
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	// Namespaces may be given by header, path prefix, or both.
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	ns := r.Header.Get("X-Vault-Namespace")
+	if ns != "" {
+		ns += "/"
+	}
+	path = strings.TrimPrefix(ns+path, f.namespace+"/")
+
+	if strings.HasPrefix(path, "auth/") {
+		f.logins = append(f.logins, map[string]any{"path": path, "data": body})
+		json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": f.token}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != f.token {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && path == "transit/keys/cosign":
+		keys := map[string]any{}
+		for v, priv := range f.versions {
+			pub, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+			require.NoError(f.t, err)
+			keys[v] = map[string]any{"name": "P-256", "public_key": string(pub)}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"keys": keys, "latest_version": 2}})
+	case r.Method == http.MethodPut && path == "transit/sign/cosign/sha2-256":
+		version, _ := body["key_version"].(string)
+		f.signed = append(f.signed, version)
+		if version == "0" || version == "" {
+			version = "2"
+		}
+		digest, err := base64.StdEncoding.DecodeString(body["input"].(string))
+		require.NoError(f.t, err)
+		sig, err := ecdsa.SignASN1(rand.Reader, f.versions[version], digest)
+		require.NoError(f.t, err)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"signature": "vault:v" + version + ":" + base64.StdEncoding.EncodeToString(sig),
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSignerVerifierPinnedVersion(t *testing.T) {
+	f := newFakeVault(t, "org/team-a")
+	s := httptest.NewServer(f)
+	defer s.Close()
+	t.Setenv("VAULT_ADDR", s.URL)
+	t.Setenv("VAULT_NAMESPACE", "org")
+	t.Setenv(EnvAuthMethod, authMethodAppRole)
+	t.Setenv(EnvRoleID, "role")
+	t.Setenv(EnvSecretID, "secret")
+
+	sv, err := LoadSignerVerifier(context.Background(), "hashivault://team-a/cosign/versions/1", crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{
+		"path": "auth/approle/login",
+		"data": map[string]any{"role_id": "role", "secret_id": "secret"},
+	}}, f.logins)
+
+	pub, err := sv.PublicKey()
+	require.NoError(t, err)
+	require.True(t, f.versions["1"].PublicKey.Equal(pub))
+
+	msg := []byte("payload")
+	sig, err := sv.SignMessage(bytes.NewReader(msg))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, f.signed)
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	require.NoError(t, err)
+	require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)))
+
+	// The crypto.Signer also reports the pinned version's key.
+	signer, _, err := sv.CryptoSigner(context.Background(), func(err error) { t.Error(err) })
+	require.NoError(t, err)
+	require.True(t, f.versions["1"].PublicKey.Equal(signer.Public()))
+	digest := sha256.Sum256(msg)
+	_, err = signer.Sign(nil, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "1"}, f.signed)
+
+	_, err = sv.CreateKey(context.Background(), "ecdsa-p256")
+	require.Error(t, err)
+}
+
+func TestSignerVerifierLatestVersion(t *testing.T) {
+	f := newFakeVault(t, "team-a")
+	s := httptest.NewServer(f)
+	defer s.Close()
+	t.Setenv("VAULT_ADDR", s.URL)
+	t.Setenv("VAULT_NAMESPACE", "")
+	t.Setenv(EnvAuthMethod, "")
+
+	sv, err := LoadSignerVerifier(context.Background(), "hashivault://team-a/cosign", crypto.SHA256,
+		options.WithRPCAuthOpts(options.RPCAuth{Token: f.token}))
+	require.NoError(t, err)
+	require.Empty(t, f.logins)
+
+	pub, err := sv.PublicKey()
+	require.NoError(t, err)
+	require.True(t, f.versions["2"].PublicKey.Equal(pub))
+	_, err = sv.SignMessage(bytes.NewReader([]byte("payload")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, f.signed)
+}
+
+func TestSignerVerifierConflictingVersion(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	_, err := LoadSignerVerifier(context.Background(), "hashivault://cosign/versions/1", crypto.SHA256,
+		options.WithKeyVersion("2"), options.WithRPCAuthOpts(options.RPCAuth{Token: "t"}))
+	require.ErrorContains(t, err, "conflicts")
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/franchb/sigstore/pkg/signature"
+	sighashivault "github.com/franchb/sigstore/pkg/signature/kms/hashivault"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// SignerVerifier is the SignerVerifier of sighashivault for a transit key in
+// a namespace, logged in to with the auth method selected by EnvAuthMethod,
+// and pinned to a key version if its reference names one.
+type SignerVerifier struct {
+	*sighashivault.SignerVerifier
+
+	client  *vault.Client
+	keyPath string
+	version string
+
+	publicKeyOnce sync.Once
+	publicKey     crypto.PublicKey
+	publicKeyErr  error
+}
+
+// LoadSignerVerifier generates signatures using the transit key referred to
+// by referenceStr and hash algorithm. hashFunc should be set to
+// crypto.Hash(0) if the key is an ED25519 signing key.
+func LoadSignerVerifier(ctx context.Context, referenceStr string, hashFunc crypto.Hash, opts ...signature.RPCOption) (*SignerVerifier, error) {
+	ref, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	rpcAuth := options.RPCAuth{}
+	var keyVersion string
+	for _, opt := range opts {
+		opt.ApplyRPCAuthOpts(&rpcAuth)
+		opt.ApplyKeyVersion(&keyVersion)
+	}
+	if ref.Version != "" && keyVersion != "" && keyVersion != ref.Version {
+		return nil, fmt.Errorf("key version %s conflicts with version %s of %s", keyVersion, ref.Version, referenceStr)
+	}
+
+	client, err := newVaultClient(rpcAuth.Address)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rpcAuth.Token != "":
+	case rpcAuth.OIDC.Token != "":
+		mount := rpcAuth.OIDC.Path
+		if mount == "" {
+			mount = authMethodJWT
+		}
+		rpcAuth.Token, err = loginWith(ctx, client, ref.Namespace, authMethodJWT, mount, map[string]any{
+			"role": rpcAuth.OIDC.Role,
+			"jwt":  rpcAuth.OIDC.Token,
+		})
+	default:
+		rpcAuth.Token, err = login(ctx, client, ref.Namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(rpcAuth.Token)
+	rpcAuth.Address = client.Address()
+
+	transitPath := rpcAuth.Path
+	if transitPath == "" {
+		transitPath = os.Getenv("TRANSIT_SECRET_ENGINE_PATH")
+	}
+	if transitPath == "" {
+		transitPath = "transit"
+	}
+	rpcAuth.Path = namespacedPath(ref.Namespace, transitPath)
+
+	switch {
+	case ref.Version != "":
+		keyVersion = ref.Version
+	case keyVersion == "0":
+		// Vault signs with the latest version for version 0.
+		keyVersion = ""
+	}
+	// Logging in again with rpcAuth.OIDC would ignore the namespace, so
+	// only the token is passed on.
+	sv, err := sighashivault.LoadSignerVerifier(ReferenceScheme+ref.Key, hashFunc,
+		options.WithContext(ctx),
+		options.WithRPCAuthOpts(options.RPCAuth{Address: rpcAuth.Address, Path: rpcAuth.Path, Token: rpcAuth.Token}),
+		options.WithKeyVersion(keyVersion),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &SignerVerifier{
+		SignerVerifier: sv,
+		client:         client,
+		keyPath:        rpcAuth.Path + "/keys/" + ref.Key,
+		version:        keyVersion,
+	}, nil
+}
+
+// PublicKey returns the public key of the pinned key version, or of the
+// latest version if none is pinned.
+func (h *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	if h.version == "" {
+		return h.SignerVerifier.PublicKey(opts...)
+	}
+	h.publicKeyOnce.Do(func() {
+		ctx := context.Background()
+		for _, opt := range opts {
+			opt.ApplyContext(&ctx)
+		}
+		h.publicKey, h.publicKeyErr = fetchPublicKey(ctx, h.client, h.keyPath, h.version)
+	})
+	return h.publicKey, h.publicKeyErr
+}
+
+// CreateKey attempts to create a new key in Vault with the specified
+// algorithm. It fails if the reference pins a key version.
+func (h *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if h.version != "" {
+		return nil, errors.New("cannot create a key from a reference pinning a key version")
+	}
+	return h.SignerVerifier.CreateKey(ctx, algorithm)
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	return c.sv.SignMessage(nil,
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (h *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	_, opts, err := h.SignerVerifier.CryptoSigner(ctx, errFunc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cryptoSignerWrapper{
+		ctx:      ctx,
+		hashFunc: opts.HashFunc(),
+		sv:       h,
+		errFunc:  errFunc,
+	}, opts, nil
+}
@@ -0,0 +1,439 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocikms
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // OCI identifies federation certificates by their SHA-1 fingerprint
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EnvOCIAuth selects how requests are authenticated: "api_key" to use
+	// the API signing key of the config file, or "instance_principal" to
+	// use the identity of the compute instance cosign runs on.
+	EnvOCIAuth = "OCI_CLI_AUTH"
+	// EnvOCIConfigFile overrides the path of the config file, which is
+	// ~/.oci/config by default.
+	EnvOCIConfigFile = "OCI_CLI_CONFIG_FILE"
+	// EnvOCIProfile selects the profile of the config file, which is
+	// DEFAULT by default.
+	EnvOCIProfile = "OCI_CLI_PROFILE"
+
+	authAPIKey            = "api_key"
+	authInstancePrincipal = "instance_principal"
+	defaultProfile        = "DEFAULT"
+
+	metadataURL = "http://169.254.169.254/opc/v2"
+)
+
+// requestSigner adds the OCI request signature to API requests.
+type requestSigner interface {
+	sign(ctx context.Context, req *http.Request, body []byte) error
+}
+
+// newRequestSigner returns the requestSigner selected by EnvOCIAuth. If it
+// is not set, the config file is used when it exists and the instance
+// principal otherwise. authHost is the host of the identity service of the
+// realm and region the vault is in.
+func newRequestSigner(authHost string) (requestSigner, error) {
+	switch auth := os.Getenv(EnvOCIAuth); auth {
+	case authAPIKey:
+		return loadAPIKeySigner()
+	case authInstancePrincipal:
+		return newInstancePrincipalSigner(http.DefaultClient, metadataURL, "https://"+authHost), nil
+	case "":
+		signer, err := loadAPIKeySigner()
+		if errors.Is(err, os.ErrNotExist) {
+			return newInstancePrincipalSigner(http.DefaultClient, metadataURL, "https://"+authHost), nil
+		}
+		return signer, err
+	default:
+		return nil, fmt.Errorf("unsupported %s %q, must be %q or %q", EnvOCIAuth, auth, authAPIKey, authInstancePrincipal)
+	}
+}
+
+// keySigner signs requests with an RSA key identified by keyID.
+type keySigner struct {
+	keyID string
+	key   *rsa.PrivateKey
+}
+
+func (s *keySigner) sign(_ context.Context, req *http.Request, body []byte) error {
+	return signRequest(req, body, s.keyID, s.key)
+}
+
+// loadAPIKeySigner returns a requestSigner for the API signing key of the
+// profile selected by EnvOCIProfile in the config file.
+func loadAPIKeySigner() (requestSigner, error) {
+	path := os.Getenv(EnvOCIConfigFile)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".oci", "config")
+	}
+	profile := os.Getenv(EnvOCIProfile)
+	if profile == "" {
+		profile = defaultProfile
+	}
+	return loadConfigFile(path, profile)
+}
+
+func loadConfigFile(path, profile string) (*keySigner, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading oci config file: %w", err)
+	}
+	cfg, err := parseConfigFile(b, profile)
+	if err != nil {
+		return nil, fmt.Errorf("parsing oci config file %s: %w", path, err)
+	}
+	for _, k := range []string{"tenancy", "user", "fingerprint", "key_file"} {
+		if cfg[k] == "" {
+			return nil, fmt.Errorf("oci config file %s: profile %s is missing %s", path, profile, k)
+		}
+	}
+	keyFile := cfg["key_file"]
+	if rest, ok := strings.CutPrefix(keyFile, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		keyFile = filepath.Join(home, rest)
+	}
+	kb, err := os.ReadFile(filepath.Clean(keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading oci api key: %w", err)
+	}
+	key, err := parseRSAPrivateKey(kb, []byte(cfg["pass_phrase"]))
+	if err != nil {
+		return nil, fmt.Errorf("parsing oci api key %s: %w", keyFile, err)
+	}
+	return &keySigner{
+		keyID: cfg["tenancy"] + "/" + cfg["user"] + "/" + cfg["fingerprint"],
+		key:   key,
+	}, nil
+}
+
+// parseConfigFile returns the settings of profile in an OCI config file.
+// Settings of the DEFAULT profile apply to every other profile unless they
+// are overridden.
+func parseConfigFile(b []byte, profile string) (map[string]string, error) {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if sections[name] == nil {
+				sections[name] = map[string]string{}
+			}
+			section = sections[name]
+		default:
+			k, v, ok := strings.Cut(line, "=")
+			if !ok || section == nil {
+				return nil, fmt.Errorf("line %d: expected a [profile] or key=value", n)
+			}
+			section[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	cfg, ok := sections[profile]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found", profile)
+	}
+	merged := map[string]string{}
+	for k, v := range sections[defaultProfile] {
+		merged[k] = v
+	}
+	for k, v := range cfg {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+func parseRSAPrivateKey(b, passphrase []byte) (*rsa.PrivateKey, error) {
+	p, _ := pem.Decode(b)
+	if p == nil {
+		return nil, errors.New("invalid pem block")
+	}
+	der := p.Bytes
+	if x509.IsEncryptedPEMBlock(p) { //nolint:staticcheck // the OCI CLI writes legacy encrypted PEM keys
+		if len(passphrase) == 0 {
+			return nil, errors.New("key is encrypted and no pass_phrase is set")
+		}
+		var err error
+		der, err = x509.DecryptPEMBlock(p, passphrase) //nolint:staticcheck
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch p.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T, OCI API keys are RSA keys", k)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported pem type: %s", p.Type)
+	}
+}
+
+// signRequest adds the Date and Authorization headers of the OCI request
+// signature scheme to req, and for requests with a body, the headers
+// covering it.
+func signRequest(req *http.Request, body []byte, keyID string, key *rsa.PrivateKey) error {
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	headers := []string{"date", "(request-target)", "host"}
+	if req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch {
+		sum := sha256.Sum256(body)
+		req.Header.Set("X-Content-Sha256", base64.StdEncoding.EncodeToString(sum[:]))
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		headers = append(headers, "content-length", "content-type", "x-content-sha256")
+	}
+
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		switch h {
+		case "(request-target)":
+			lines = append(lines, h+": "+strings.ToLower(req.Method)+" "+req.URL.RequestURI())
+		case "host":
+			lines = append(lines, h+": "+req.URL.Host)
+		default:
+			lines = append(lines, h+": "+req.Header.Get(h))
+		}
+	}
+	digest := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf(`Signature version="1",headers=%q,keyId=%q,algorithm="rsa-sha256",signature=%q`,
+		strings.Join(headers, " "), keyID, base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// instancePrincipalSigner signs requests as the compute instance, with a
+// session token the identity service issues for the instance certificate
+// from the metadata service.
+type instancePrincipalSigner struct {
+	client      *http.Client
+	metadataURL string
+	authURL     string
+
+	mu      sync.Mutex
+	session *keySigner
+	expiry  time.Time
+}
+
+func newInstancePrincipalSigner(client *http.Client, metadataURL, authURL string) *instancePrincipalSigner {
+	return &instancePrincipalSigner{
+		client:      client,
+		metadataURL: metadataURL,
+		authURL:     authURL,
+	}
+}
+
+func (s *instancePrincipalSigner) sign(ctx context.Context, req *http.Request, body []byte) error {
+	session, err := s.sessionSigner(ctx)
+	if err != nil {
+		return err
+	}
+	return session.sign(ctx, req, body)
+}
+
+// sessionSigner returns the signer for the current session token, federating
+// a new one if it is about to expire.
+func (s *instancePrincipalSigner) sessionSigner(ctx context.Context) (*keySigner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.session != nil && time.Until(s.expiry) > time.Minute {
+		return s.session, nil
+	}
+
+	leaf, err := s.metadata(ctx, "/identity/cert.pem")
+	if err != nil {
+		return nil, err
+	}
+	leafKey, err := s.metadata(ctx, "/identity/key.pem")
+	if err != nil {
+		return nil, err
+	}
+	intermediate, err := s.metadata(ctx, "/identity/intermediate.pem")
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseCertificate(leaf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing instance certificate: %w", err)
+	}
+	tenancy, err := tenancyFromCertificate(cert)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseRSAPrivateKey(leafKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing instance key: %w", err)
+	}
+	intermediateCert, err := parseCertificate(intermediate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing instance intermediate certificate: %w", err)
+	}
+
+	sessionKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	sessionPub, err := x509.MarshalPKIXPublicKey(&sessionKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]any{
+		"certificate":              base64.StdEncoding.EncodeToString(cert.Raw),
+		"publicKey":                base64.StdEncoding.EncodeToString(sessionPub),
+		"intermediateCertificates": []string{base64.StdEncoding.EncodeToString(intermediateCert.Raw)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.authURL+"/v1/x509", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := sha1.Sum(cert.Raw) //nolint:gosec
+	if err := signRequest(req, body, tenancy+"/fed-x509/"+colonHex(fingerprint[:]), key); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := doJSON(s.client, req, &resp); err != nil {
+		return nil, fmt.Errorf("federating instance principal: %w", err)
+	}
+	expiry, err := tokenExpiry(resp.Token)
+	if err != nil {
+		return nil, fmt.Errorf("federating instance principal: %w", err)
+	}
+	s.session = &keySigner{keyID: "ST$" + resp.Token, key: sessionKey}
+	s.expiry = expiry
+	return s.session, nil
+}
+
+func (s *instancePrincipalSigner) metadata(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.metadataURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer Oracle")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading instance metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading instance metadata %s: %s", path, resp.Status)
+	}
+	return b, nil
+}
+
+func parseCertificate(b []byte) (*x509.Certificate, error) {
+	p, _ := pem.Decode(b)
+	if p == nil {
+		return nil, errors.New("invalid pem block")
+	}
+	return x509.ParseCertificate(p.Bytes)
+}
+
+// tenancyFromCertificate returns the tenancy OCID an instance certificate
+// was issued in.
+func tenancyFromCertificate(cert *x509.Certificate) (string, error) {
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if tenancy, ok := strings.CutPrefix(ou, "opc-tenant:"); ok {
+			return tenancy, nil
+		}
+	}
+	return "", errors.New("instance certificate does not name a tenancy")
+}
+
+// tokenExpiry returns the expiry of a session token, which is a JWT.
+func tokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("malformed session token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed session token: %w", err)
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("malformed session token: %w", err)
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
+
+func colonHex(b []byte) string {
+	s := make([]string, len(b))
+	for i, c := range b {
+		s[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(s, ":")
+}
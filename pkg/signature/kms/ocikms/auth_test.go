@@ -0,0 +1,220 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocikms
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var authorizationRE = regexp.MustCompile(`^Signature version="1",headers="([^"]+)",keyId="([^"]+)",algorithm="rsa-sha256",signature="([^"]+)"$`)
+
+// verifySignedRequest checks the OCI request signature of req against pub,
+// returning the key ID it was made with.
+func verifySignedRequest(t *testing.T, req *http.Request, body []byte, pub *rsa.PublicKey) string {
+	t.Helper()
+	m := authorizationRE.FindStringSubmatch(req.Header.Get("Authorization"))
+	if m == nil {
+		t.Fatalf("malformed Authorization header %q", req.Header.Get("Authorization"))
+	}
+	headers := strings.Split(m[1], " ")
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		switch h {
+		case "(request-target)":
+			lines = append(lines, h+": "+strings.ToLower(req.Method)+" "+req.URL.RequestURI())
+		case "host":
+			lines = append(lines, h+": "+req.Host)
+		default:
+			lines = append(lines, h+": "+req.Header.Get(h))
+		}
+	}
+	if req.Method == http.MethodPost {
+		require.Contains(t, headers, "x-content-sha256")
+		sum := sha256.Sum256(body)
+		require.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), req.Header.Get("X-Content-Sha256"))
+	}
+	sig, err := base64.StdEncoding.DecodeString(m[3])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	require.NoError(t, rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig), "request signature")
+	return m[2]
+}
+
+func TestParseConfigFile(t *testing.T) {
+	config := []byte(`
+# comments are ignored
+[DEFAULT]
+tenancy=ocid1.tenancy.oc1..default
+region = us-ashburn-1
+
+[CI]
+user=ocid1.user.oc1..ci
+region=eu-frankfurt-1
+`)
+	cfg, err := parseConfigFile(config, "CI")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"tenancy": "ocid1.tenancy.oc1..default",
+		"user":    "ocid1.user.oc1..ci",
+		"region":  "eu-frankfurt-1",
+	}, cfg)
+
+	_, err = parseConfigFile(config, "MISSING")
+	require.ErrorContains(t, err, "profile MISSING not found")
+	_, err = parseConfigFile([]byte("user=before-any-profile"), defaultProfile)
+	require.ErrorContains(t, err, "line 1")
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	td := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFile := filepath.Join(td, "oci_api_key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	configFile := filepath.Join(td, "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(`[DEFAULT]
+user=ocid1.user.oc1..user
+fingerprint=20:3b:97:13:55:1c:5b:0d:d3:37:d8:50:4e:c5:3a:34
+key_file=%s
+tenancy=ocid1.tenancy.oc1..tenancy
+`, keyFile)), 0600))
+
+	t.Setenv(EnvOCIAuth, "")
+	t.Setenv(EnvOCIConfigFile, configFile)
+	t.Setenv(EnvOCIProfile, "")
+	signer, err := newRequestSigner("auth.us-ashburn-1.oraclecloud.com")
+	require.NoError(t, err)
+	ks, ok := signer.(*keySigner)
+	require.True(t, ok, "newRequestSigner() = %T, wanted the config file key", signer)
+	require.Equal(t, "ocid1.tenancy.oc1..tenancy/ocid1.user.oc1..user/20:3b:97:13:55:1c:5b:0d:d3:37:d8:50:4e:c5:3a:34", ks.keyID)
+	require.True(t, key.Equal(ks.key))
+
+	// Without a config file the instance principal is used, unless the
+	// config file is asked for explicitly.
+	t.Setenv(EnvOCIConfigFile, filepath.Join(td, "missing"))
+	signer, err = newRequestSigner("auth.us-ashburn-1.oraclecloud.com")
+	require.NoError(t, err)
+	require.IsType(t, &instancePrincipalSigner{}, signer)
+	t.Setenv(EnvOCIAuth, authAPIKey)
+	_, err = newRequestSigner("auth.us-ashburn-1.oraclecloud.com")
+	require.Error(t, err)
+	t.Setenv(EnvOCIAuth, "resource_principal")
+	_, err = newRequestSigner("auth.us-ashburn-1.oraclecloud.com")
+	require.ErrorContains(t, err, "unsupported")
+}
+
+func TestSignRequest(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	body := []byte(`{"message":"aGVsbG8="}`)
+	req, err := http.NewRequest(http.MethodPost, "https://example-crypto.kms.us-ashburn-1.oraclecloud.com/20180608/sign?x=1", strings.NewReader(string(body)))
+	require.NoError(t, err)
+	require.NoError(t, signRequest(req, body, "tenancy/user/fingerprint", key))
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	require.NotEmpty(t, req.Header.Get("Date"))
+	require.Equal(t, "tenancy/user/fingerprint", verifySignedRequest(t, req, body, &key.PublicKey))
+}
+
+func TestInstancePrincipalSigner(t *testing.T) {
+	leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:         "ocid1.instance.oc1.iad.instance",
+			OrganizationalUnit: []string{"opc-instance:ocid1.instance.oc1.iad.instance", "opc-tenant:ocid1.tenancy.oc1..tenancy"},
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &leafKey.PublicKey, leafKey)
+	require.NoError(t, err)
+	leafPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(leafKey)})
+
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer Oracle" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/opc/v2/identity/cert.pem", "/opc/v2/identity/intermediate.pem":
+			w.Write(leafPEM)
+		case "/opc/v2/identity/key.pem":
+			w.Write(keyPEM)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer metadata.Close()
+
+	claims, err := json.Marshal(map[string]int64{"exp": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	token := "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".c2ln"
+	var federations atomic.Int32
+	var sessionKey *rsa.PublicKey
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		federations.Add(1)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		keyID := verifySignedRequest(t, r, body, &leafKey.PublicKey)
+		require.True(t, strings.HasPrefix(keyID, "ocid1.tenancy.oc1..tenancy/fed-x509/"), keyID)
+		var req struct {
+			Certificate string `json:"certificate"`
+			PublicKey   string `json:"publicKey"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Equal(t, base64.StdEncoding.EncodeToString(leafDER), req.Certificate)
+		der, err := base64.StdEncoding.DecodeString(req.PublicKey)
+		require.NoError(t, err)
+		pub, err := x509.ParsePKIXPublicKey(der)
+		require.NoError(t, err)
+		sessionKey = pub.(*rsa.PublicKey)
+		fmt.Fprintf(w, `{"token": %q}`, token)
+	}))
+	defer auth.Close()
+
+	signer := newInstancePrincipalSigner(http.DefaultClient, metadata.URL+"/opc/v2", auth.URL)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, "https://example-management.kms.us-ashburn-1.oraclecloud.com/20180608/keys/ocid1.key", nil)
+		require.NoError(t, err)
+		require.NoError(t, signer.sign(context.Background(), req, nil))
+		require.Equal(t, "ST$"+token, verifySignedRequest(t, req, nil, sessionKey))
+	}
+	require.Equal(t, int32(1), federations.Load(), "session token should be reused until it expires")
+}
@@ -0,0 +1,364 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocikms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+)
+
+func init() {
+	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID)
+	})
+}
+
+// ReferenceScheme is the scheme of references to keys in OCI Vault.
+const ReferenceScheme = "ocikms://"
+
+// Algorithms that keys can be created with.
+const (
+	AlgorithmECDSAP256SHA256    = "ecdsa-p256-sha256"
+	AlgorithmECDSAP384SHA384    = "ecdsa-p384-sha384"
+	AlgorithmECDSAP521SHA512    = "ecdsa-p521-sha512"
+	AlgorithmRSA2048PKCS1SHA256 = "rsa-2048-pkcs1v15-sha256"
+	AlgorithmRSA3072PKCS1SHA256 = "rsa-3072-pkcs1v15-sha256"
+	AlgorithmRSA4096PKCS1SHA256 = "rsa-4096-pkcs1v15-sha256"
+)
+
+// keyShape is the algorithm and size of a vault key.
+type keyShape struct {
+	Algorithm string `json:"algorithm"`
+	// Length is the size of the key in bytes.
+	Length  int    `json:"length"`
+	CurveID string `json:"curveId,omitempty"`
+}
+
+var algorithmMap = map[string]keyShape{
+	AlgorithmECDSAP256SHA256:    {Algorithm: "ECDSA", Length: 32, CurveID: "NIST_P256"},
+	AlgorithmECDSAP384SHA384:    {Algorithm: "ECDSA", Length: 48, CurveID: "NIST_P384"},
+	AlgorithmECDSAP521SHA512:    {Algorithm: "ECDSA", Length: 66, CurveID: "NIST_P521"},
+	AlgorithmRSA2048PKCS1SHA256: {Algorithm: "RSA", Length: 256},
+	AlgorithmRSA3072PKCS1SHA256: {Algorithm: "RSA", Length: 384},
+	AlgorithmRSA4096PKCS1SHA256: {Algorithm: "RSA", Length: 512},
+}
+
+const (
+	apiVersion = "/20180608"
+	cacheTTL   = 5 * time.Minute
+)
+
+var (
+	errKMSReference = errors.New("kms specification should be in the format ocikms://VAULT_CRYPTO_ENDPOINT/KEY_OCID or ocikms://VAULT_CRYPTO_ENDPOINT/compartment/COMPARTMENT_OCID/keyname/KEY_NAME")
+
+	createRE = regexp.MustCompile(`^ocikms://([^/]+-crypto\.kms\.[^/]+)/compartment/([^/]+)/keyname/([^/]+)$`)
+	keyIDRE  = regexp.MustCompile(`^ocikms://([^/]+-crypto\.kms\.[^/]+)/(ocid1\.key\.[^/]+)$`)
+
+	allREs = []*regexp.Regexp{createRE, keyIDRE}
+)
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	for _, re := range allREs {
+		if re.MatchString(ref) {
+			return nil
+		}
+	}
+	return errKMSReference
+}
+
+// ParseReference parses an ocikms-scheme URI into its constituent parts.
+// endpoint is the crypto endpoint of the vault, and either keyID or both
+// compartmentID and keyName are set.
+func ParseReference(referenceStr string) (endpoint, keyID, compartmentID, keyName string, err error) {
+	if v := createRE.FindStringSubmatch(referenceStr); v != nil {
+		endpoint, compartmentID, keyName = v[1], v[2], v[3]
+		return
+	}
+	if v := keyIDRE.FindStringSubmatch(referenceStr); v != nil {
+		endpoint, keyID = v[1], v[2]
+		return
+	}
+	err = fmt.Errorf("invalid ocikms format %q", referenceStr)
+	return
+}
+
+type ociKMSClient struct {
+	client        *http.Client
+	signer        requestSigner
+	cryptoURL     string
+	managementURL string
+	keyID         string
+	compartmentID string
+	keyName       string
+
+	mu        sync.Mutex
+	key       *ociSigningKey
+	keyExpiry time.Time
+}
+
+// ociSigningKey is the current version of a vault key and how to sign and
+// verify with it.
+type ociSigningKey struct {
+	VersionID        string
+	SigningAlgorithm string
+	Verifier         signature.Verifier
+	HashFunc         crypto.Hash
+}
+
+func newOCIKMSClient(referenceStr string) (*ociKMSClient, error) {
+	if err := ValidReference(referenceStr); err != nil {
+		return nil, err
+	}
+	endpoint, keyID, compartmentID, keyName, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	// Crypto endpoints are VAULT-crypto.kms.REGION.DOMAIN, next to the
+	// management endpoint of the vault and the identity service of the
+	// region.
+	vault, regionHost, _ := strings.Cut(endpoint, "-crypto.kms.")
+	signer, err := newRequestSigner("auth." + regionHost)
+	if err != nil {
+		return nil, err
+	}
+	return &ociKMSClient{
+		client:        http.DefaultClient,
+		signer:        signer,
+		cryptoURL:     "https://" + endpoint,
+		managementURL: "https://" + vault + "-management.kms." + regionHost,
+		keyID:         keyID,
+		compartmentID: compartmentID,
+		keyName:       keyName,
+	}, nil
+}
+
+// do sends an API request signed by o.signer, decoding the JSON response
+// into out.
+func (o *ociKMSClient) do(ctx context.Context, method, u string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := o.signer.sign(ctx, req, body); err != nil {
+		return err
+	}
+	return doJSON(o.client, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Code != "" {
+			return fmt.Errorf("%s %s: %s: %s: %s", req.Method, req.URL.Path, resp.Status, apiErr.Code, apiErr.Message)
+		}
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return json.Unmarshal(b, out)
+}
+
+type ociKey struct {
+	ID                string   `json:"id"`
+	CurrentKeyVersion string   `json:"currentKeyVersion"`
+	KeyShape          keyShape `json:"keyShape"`
+	LifecycleState    string   `json:"lifecycleState"`
+}
+
+func (o *ociKMSClient) getKey(ctx context.Context, keyID string) (*ociKey, error) {
+	var key ociKey
+	if err := o.do(ctx, http.MethodGet, o.managementURL+apiVersion+"/keys/"+url.PathEscape(keyID), nil, &key); err != nil {
+		return nil, fmt.Errorf("getting oci vault key: %w", err)
+	}
+	return &key, nil
+}
+
+func (o *ociKMSClient) fetchPublicKey(ctx context.Context, key *ociKey) (crypto.PublicKey, error) {
+	var version struct {
+		PublicKey string `json:"publicKey"`
+	}
+	u := o.managementURL + apiVersion + "/keys/" + url.PathEscape(key.ID) + "/keyVersions/" + url.PathEscape(key.CurrentKeyVersion)
+	if err := o.do(ctx, http.MethodGet, u, nil, &version); err != nil {
+		return nil, fmt.Errorf("getting oci vault key version: %w", err)
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey([]byte(version.PublicKey))
+}
+
+func (o *ociKMSClient) getOCISigningKey(ctx context.Context) (*ociSigningKey, error) {
+	key, err := o.getKey(ctx, o.keyID)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := o.fetchPublicKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	sk := ociSigningKey{VersionID: key.CurrentKeyVersion}
+	switch pub := pubKey.(type) {
+	case *rsa.PublicKey:
+		sk.SigningAlgorithm, sk.HashFunc = "SHA_256_RSA_PKCS1_V1_5", crypto.SHA256
+		sk.Verifier, err = signature.LoadRSAPKCS1v15Verifier(pub, crypto.SHA256)
+	case *ecdsa.PublicKey:
+		switch key.KeyShape.CurveID {
+		case "NIST_P256":
+			sk.SigningAlgorithm, sk.HashFunc = "ECDSA_SHA_256", crypto.SHA256
+		case "NIST_P384":
+			sk.SigningAlgorithm, sk.HashFunc = "ECDSA_SHA_384", crypto.SHA384
+		case "NIST_P521":
+			sk.SigningAlgorithm, sk.HashFunc = "ECDSA_SHA_512", crypto.SHA512
+		default:
+			return nil, fmt.Errorf("unsupported curve %q specified by KMS", key.KeyShape.CurveID)
+		}
+		sk.Verifier, err = signature.LoadECDSAVerifier(pub, sk.HashFunc)
+	default:
+		return nil, errors.New("unknown algorithm specified by KMS")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initializing internal verifier: %w", err)
+	}
+	return &sk, nil
+}
+
+// getSK returns the signing key, fetching it again once it has been cached
+// for cacheTTL so that key rotations are picked up.
+func (o *ociKMSClient) getSK(ctx context.Context) (*ociSigningKey, error) {
+	if o.keyID == "" {
+		return nil, errors.New("ocikms key specification should be in the format ocikms://VAULT_CRYPTO_ENDPOINT/KEY_OCID to sign and verify")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.key != nil && time.Now().Before(o.keyExpiry) {
+		return o.key, nil
+	}
+	sk, err := o.getOCISigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.key, o.keyExpiry = sk, time.Now().Add(cacheTTL)
+	return sk, nil
+}
+
+func (o *ociKMSClient) getHashFunc(ctx context.Context) (crypto.Hash, error) {
+	sk, err := o.getSK(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return sk.HashFunc, nil
+}
+
+func (o *ociKMSClient) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	sk, err := o.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hf != sk.HashFunc {
+		return nil, fmt.Errorf("hash function %v does not match the %v of the key", hf, sk.HashFunc)
+	}
+	req := map[string]string{
+		"keyId":            o.keyID,
+		"keyVersionId":     sk.VersionID,
+		"message":          base64.StdEncoding.EncodeToString(digest),
+		"messageType":      "DIGEST",
+		"signingAlgorithm": sk.SigningAlgorithm,
+	}
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+	if err := o.do(ctx, http.MethodPost, o.cryptoURL+apiVersion+"/sign", req, &resp); err != nil {
+		return nil, fmt.Errorf("calling OCI Vault sign: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Signature)
+}
+
+func (o *ociKMSClient) verify(ctx context.Context, sig, message io.Reader, opts ...signature.VerifyOption) error {
+	sk, err := o.getSK(ctx)
+	if err != nil {
+		return err
+	}
+	return sk.Verifier.VerifySignature(sig, message, opts...)
+}
+
+func (o *ociKMSClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if o.compartmentID == "" || o.keyName == "" {
+		return nil, errors.New("create ocikms key specification should be in the format ocikms://VAULT_CRYPTO_ENDPOINT/compartment/COMPARTMENT_OCID/keyname/KEY_NAME")
+	}
+	shape, ok := algorithmMap[algorithm]
+	if !ok {
+		return nil, errors.New("unknown algorithm requested")
+	}
+	req := map[string]any{
+		"compartmentId":  o.compartmentID,
+		"displayName":    o.keyName,
+		"keyShape":       shape,
+		"protectionMode": "HSM",
+	}
+	var key ociKey
+	if err := o.do(ctx, http.MethodPost, o.managementURL+apiVersion+"/keys", req, &key); err != nil {
+		return nil, fmt.Errorf("ocikms key create error: %w", err)
+	}
+	for key.LifecycleState != "ENABLED" {
+		if key.LifecycleState != "CREATING" {
+			return nil, fmt.Errorf("ocikms key create error: key %s is %s", key.ID, key.LifecycleState)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+		k, err := o.getKey(ctx, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		key = *k
+	}
+	return o.fetchPublicKey(ctx, &key)
+}
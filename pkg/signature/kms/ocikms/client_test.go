@@ -0,0 +1,264 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocikms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in                string
+		wantEndpoint      string
+		wantKeyID         string
+		wantCompartmentID string
+		wantKeyName       string
+		wantErr           bool
+	}{{
+		in:           "ocikms://abc123-crypto.kms.us-ashburn-1.oraclecloud.com/ocid1.key.oc1.iad.abc123.xyz",
+		wantEndpoint: "abc123-crypto.kms.us-ashburn-1.oraclecloud.com",
+		wantKeyID:    "ocid1.key.oc1.iad.abc123.xyz",
+	}, {
+		in:                "ocikms://abc123-crypto.kms.us-ashburn-1.oraclecloud.com/compartment/ocid1.compartment.oc1..xyz/keyname/cosign",
+		wantEndpoint:      "abc123-crypto.kms.us-ashburn-1.oraclecloud.com",
+		wantCompartmentID: "ocid1.compartment.oc1..xyz",
+		wantKeyName:       "cosign",
+	}, {
+		// The management endpoint can't be used to sign.
+		in:      "ocikms://abc123-management.kms.us-ashburn-1.oraclecloud.com/ocid1.key.oc1.iad.abc123.xyz",
+		wantErr: true,
+	}, {
+		in:      "ocikms://abc123-crypto.kms.us-ashburn-1.oraclecloud.com/ocid1.vault.oc1.iad.abc123.xyz",
+		wantErr: true,
+	}, {
+		in:      "awskms://abc123-crypto.kms.us-ashburn-1.oraclecloud.com/ocid1.key.oc1.iad.abc123.xyz",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			endpoint, keyID, compartmentID, keyName, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			require.Equal(t, tt.wantEndpoint, endpoint)
+			require.Equal(t, tt.wantKeyID, keyID)
+			require.Equal(t, tt.wantCompartmentID, compartmentID)
+			require.Equal(t, tt.wantKeyName, keyName)
+		})
+	}
+}
+
+func TestNewOCIKMSClientEndpoints(t *testing.T) {
+	t.Setenv(EnvOCIAuth, authInstancePrincipal)
+	c, err := newOCIKMSClient("ocikms://abc123-crypto.kms.eu-frankfurt-1.oraclecloud.com/ocid1.key.oc1.eu-frankfurt-1.abc123.xyz")
+	require.NoError(t, err)
+	require.Equal(t, "https://abc123-crypto.kms.eu-frankfurt-1.oraclecloud.com", c.cryptoURL)
+	require.Equal(t, "https://abc123-management.kms.eu-frankfurt-1.oraclecloud.com", c.managementURL)
+	ip, ok := c.signer.(*instancePrincipalSigner)
+	require.True(t, ok)
+	require.Equal(t, "https://auth.eu-frankfurt-1.oraclecloud.com", ip.authURL)
+}
+
+// fakeVault serves the parts of the OCI Vault API the client uses, for a
+// single key, checking that every request is signed with apiKey.
+type fakeVault struct {
+	t       *testing.T
+	apiKey  *rsa.PublicKey
+	keyID   string
+	shape   keyShape
+	private crypto.Signer
+	signAlg string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(f.t, err)
+	verifySignedRequest(f.t, r, body, f.apiKey)
+
+	key := ociKey{ID: f.keyID, CurrentKeyVersion: "ocid1.keyversion.1", KeyShape: f.shape, LifecycleState: "ENABLED"}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == apiVersion+"/keys/"+f.keyID:
+		json.NewEncoder(w).Encode(key)
+	case r.Method == http.MethodGet && r.URL.Path == apiVersion+"/keys/"+f.keyID+"/keyVersions/ocid1.keyversion.1":
+		pub, err := cryptoutils.MarshalPublicKeyToPEM(f.private.Public())
+		require.NoError(f.t, err)
+		json.NewEncoder(w).Encode(map[string]string{"publicKey": string(pub)})
+	case r.Method == http.MethodPost && r.URL.Path == apiVersion+"/keys":
+		var req struct {
+			CompartmentID string   `json:"compartmentId"`
+			DisplayName   string   `json:"displayName"`
+			KeyShape      keyShape `json:"keyShape"`
+		}
+		require.NoError(f.t, json.Unmarshal(body, &req))
+		require.Equal(f.t, "ocid1.compartment.oc1..xyz", req.CompartmentID)
+		require.Equal(f.t, "cosign", req.DisplayName)
+		require.Equal(f.t, f.shape, req.KeyShape)
+		json.NewEncoder(w).Encode(key)
+	case r.Method == http.MethodPost && r.URL.Path == apiVersion+"/sign":
+		var req map[string]string
+		require.NoError(f.t, json.Unmarshal(body, &req))
+		require.Equal(f.t, f.keyID, req["keyId"])
+		require.Equal(f.t, "ocid1.keyversion.1", req["keyVersionId"])
+		require.Equal(f.t, "DIGEST", req["messageType"])
+		if req["signingAlgorithm"] != f.signAlg {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"code": "InvalidParameter", "message": "unexpected signing algorithm %s"}`, req["signingAlgorithm"])
+			return
+		}
+		digest, err := base64.StdEncoding.DecodeString(req["message"])
+		require.NoError(f.t, err)
+		sig, err := f.private.Sign(rand.Reader, digest, crypto.SHA256)
+		require.NoError(f.t, err)
+		json.NewEncoder(w).Encode(map[string]string{"signature": base64.StdEncoding.EncodeToString(sig)})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code": "NotAuthorizedOrNotFound", "message": "not found"}`)
+	}
+}
+
+func newFakeVaultSignerVerifier(t *testing.T, private crypto.Signer, shape keyShape, signAlg string) *SignerVerifier {
+	t.Helper()
+	apiKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	vault := httptest.NewServer(&fakeVault{
+		t:       t,
+		apiKey:  &apiKey.PublicKey,
+		keyID:   "ocid1.key.oc1.iad.abc123.xyz",
+		shape:   shape,
+		private: private,
+		signAlg: signAlg,
+	})
+	t.Cleanup(vault.Close)
+	return &SignerVerifier{client: &ociKMSClient{
+		client:        vault.Client(),
+		signer:        &keySigner{keyID: "tenancy/user/fingerprint", key: apiKey},
+		cryptoURL:     vault.URL,
+		managementURL: vault.URL,
+		keyID:         "ocid1.key.oc1.iad.abc123.xyz",
+		compartmentID: "ocid1.compartment.oc1..xyz",
+		keyName:       "cosign",
+	}}
+}
+
+func TestSignerVerifier(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		private crypto.Signer
+		shape   keyShape
+		signAlg string
+	}{{
+		name:    "ecdsa",
+		private: ecKey,
+		shape:   algorithmMap[AlgorithmECDSAP256SHA256],
+		signAlg: "ECDSA_SHA_256",
+	}, {
+		name:    "rsa",
+		private: rsaKey,
+		shape:   algorithmMap[AlgorithmRSA2048PKCS1SHA256],
+		signAlg: "SHA_256_RSA_PKCS1_V1_5",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newFakeVaultSignerVerifier(t, tt.private, tt.shape, tt.signAlg)
+			ctx := context.Background()
+			message := []byte("hello, vault")
+
+			sig, err := sv.SignMessage(bytes.NewReader(message), options.WithContext(ctx))
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+			require.Error(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("something else"))))
+
+			pub, err := sv.PublicKey()
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(tt.private.Public(), pub))
+
+			// The key is verifiable without the vault.
+			verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+
+			signer, opts, err := sv.CryptoSigner(ctx, func(err error) { t.Error(err) })
+			require.NoError(t, err)
+			require.Equal(t, crypto.SHA256, opts.HashFunc())
+			digest := sha256.Sum256(message)
+			sig, err = signer.Sign(rand.Reader, digest[:], opts)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+
+			created, err := sv.CreateKey(ctx, algorithmName(t, tt.shape))
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(tt.private.Public(), created))
+		})
+	}
+}
+
+func TestSignerVerifierErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sv := newFakeVaultSignerVerifier(t, ecKey, algorithmMap[AlgorithmECDSAP256SHA256], "ECDSA_SHA_384")
+
+	// API errors are surfaced with their code and message.
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")))
+	require.ErrorContains(t, err, "InvalidParameter: unexpected signing algorithm ECDSA_SHA_256")
+
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")), options.WithCryptoSignerOpts(crypto.SHA512))
+	require.ErrorContains(t, err, "does not match")
+
+	_, err = sv.CreateKey(context.Background(), "ed25519")
+	require.ErrorContains(t, err, "unknown algorithm")
+
+	sv.client.keyID = "ocid1.key.oc1.iad.missing"
+	sv.client.key = nil
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "NotAuthorizedOrNotFound")
+}
+
+func algorithmName(t *testing.T, shape keyShape) string {
+	t.Helper()
+	for name, s := range algorithmMap {
+		if s == shape {
+			return name
+		}
+	}
+	t.Fatalf("no algorithm for %v", shape)
+	return ""
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+)
+
+func init() {
+	sigkms.AddProvider(ReferenceScheme, func(_ context.Context, keyResourceID string, _ crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(keyResourceID)
+	})
+}
+
+const (
+	// ReferenceScheme is the scheme of references to keys held by signer
+	// plugins.
+	ReferenceScheme = "plugin://"
+	// ExecutablePrefix prefixes the name of a plugin to give the name of
+	// its executable.
+	ExecutablePrefix = "cosign-signer-"
+)
+
+var (
+	errReference   = errors.New("kms specification should be in the format plugin://NAME[/KEY]")
+	referenceRegex = regexp.MustCompile(`^plugin://(?P<name>[a-z0-9][a-z0-9_-]*)(?:/(?P<key>.*))?$`)
+)
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	if !referenceRegex.MatchString(ref) {
+		return errReference
+	}
+	return nil
+}
+
+// ParseReference parses a plugin://NAME[/KEY] reference into the name of the
+// plugin and the reference of the key it is passed.
+func ParseReference(ref string) (name, keyRef string, err error) {
+	v := referenceRegex.FindStringSubmatch(ref)
+	if v == nil {
+		return "", "", errReference
+	}
+	return v[referenceRegex.SubexpIndex("name")], v[referenceRegex.SubexpIndex("key")], nil
+}
+
+// pluginKey is a public key returned by a plugin, with the verifier for its
+// signatures.
+type pluginKey struct {
+	HashFunc crypto.Hash
+	Verifier signature.Verifier
+}
+
+type pluginClient struct {
+	name       string
+	executable string
+	keyRef     string
+
+	mu  sync.Mutex
+	key *pluginKey
+}
+
+func newPluginClient(referenceStr string) (*pluginClient, error) {
+	name, keyRef, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	executable, err := exec.LookPath(ExecutablePrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("finding signer plugin %s: %w", name, err)
+	}
+	return &pluginClient{name: name, executable: executable, keyRef: keyRef}, nil
+}
+
+// call runs the plugin with req and returns its successful response.
+func (p *pluginClient) call(ctx context.Context, req *Request) (*Response, error) {
+	req.ProtocolVersion = ProtocolVersion
+	req.KeyRef = p.keyRef
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.executable) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("running signer plugin %s: %w", p.name, runErr)
+		}
+		return nil, fmt.Errorf("decoding response of signer plugin %s: %w", p.name, err)
+	}
+	switch {
+	case resp.Error != "":
+		return nil, fmt.Errorf("signer plugin %s: %s: %s", p.name, req.Method, resp.Error)
+	case runErr != nil:
+		return nil, fmt.Errorf("running signer plugin %s: %w", p.name, runErr)
+	case resp.ProtocolVersion != ProtocolVersion:
+		return nil, fmt.Errorf("signer plugin %s speaks protocol version %q, want %q", p.name, resp.ProtocolVersion, ProtocolVersion)
+	}
+	return &resp, nil
+}
+
+// parseKey parses the public key of a publicKey or createKey response.
+func parseKey(resp *Response) (*pluginKey, error) {
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(resp.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	hf, err := ParseHashName(resp.HashFunc)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pub.(ed25519.PublicKey); ok && resp.HashFunc == "" {
+		hf = crypto.Hash(0)
+	}
+	verifier, err := signature.LoadVerifier(pub, hf)
+	if err != nil {
+		return nil, err
+	}
+	return &pluginKey{HashFunc: hf, Verifier: verifier}, nil
+}
+
+// getKey returns the public key of the plugin, which is fetched once.
+func (p *pluginClient) getKey(ctx context.Context) (*pluginKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.key != nil {
+		return p.key, nil
+	}
+	resp, err := p.call(ctx, &Request{Method: MethodPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseKey(resp)
+	if err != nil {
+		return nil, fmt.Errorf("signer plugin %s: %w", p.name, err)
+	}
+	p.key = key
+	return key, nil
+}
+
+func (p *pluginClient) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	hashName, err := HashName(hf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.call(ctx, &Request{Method: MethodSign, HashFunc: hashName, Digest: digest})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Signature) == 0 {
+		return nil, fmt.Errorf("signer plugin %s returned no signature", p.name)
+	}
+	return resp.Signature, nil
+}
+
+func (p *pluginClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	resp, err := p.call(ctx, &Request{Method: MethodCreateKey, Algorithm: algorithm})
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseKey(resp)
+	if err != nil {
+		return nil, fmt.Errorf("signer plugin %s: %w", p.name, err)
+	}
+	p.mu.Lock()
+	p.key = key
+	p.mu.Unlock()
+	return key.Verifier.PublicKey()
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/stretchr/testify/require"
+)
+
+const envFakeKey = "FAKE_SIGNER_PLUGIN_KEY"
+
+// TestMain runs the test binary as the cosign-signer-test plugin when it is
+// invoked by that name, signing with the private key in envFakeKey.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == ExecutablePrefix+"test" {
+		os.Exit(fakePlugin())
+	}
+	os.Exit(m.Run())
+}
+
+func fakePlugin() int {
+	priv, err := cryptoutils.UnmarshalPEMToPrivateKey([]byte(os.Getenv(envFakeKey)), cryptoutils.SkipPassword)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	signer := priv.(crypto.Signer)
+	err = Serve(os.Stdin, os.Stdout, func(req *Request) (*Response, error) {
+		switch req.KeyRef {
+		case "fail":
+			return nil, errors.New("key is disabled")
+		case "crash":
+			os.Exit(3)
+		}
+		switch req.Method {
+		case MethodPublicKey:
+		case MethodCreateKey:
+			if req.Algorithm != AlgorithmECDSAP256SHA256 {
+				return nil, fmt.Errorf("unsupported algorithm %s", req.Algorithm)
+			}
+		case MethodSign:
+			hf, err := ParseHashName(req.HashFunc)
+			if err != nil {
+				return nil, err
+			}
+			sig, err := signer.Sign(rand.Reader, req.Digest, hf)
+			return &Response{Signature: sig}, err
+		default:
+			return nil, fmt.Errorf("unknown method %s", req.Method)
+		}
+		pub, err := cryptoutils.MarshalPublicKeyToPEM(signer.Public())
+		return &Response{PublicKey: string(pub)}, err
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// installFakePlugin puts the test binary in PATH as the plugin named test,
+// signing with priv.
+func installFakePlugin(t *testing.T, priv crypto.Signer) {
+	dir := t.TempDir()
+	require.NoError(t, os.Symlink(os.Args[0], filepath.Join(dir, ExecutablePrefix+"test")))
+	t.Setenv("PATH", dir)
+	pemKey, err := cryptoutils.MarshalPrivateKeyToPEM(priv)
+	require.NoError(t, err)
+	t.Setenv(envFakeKey, string(pemKey))
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantName   string
+		wantKeyRef string
+		wantErr    bool
+	}{{
+		in:       "plugin://acme-hsm",
+		wantName: "acme-hsm",
+	}, {
+		in:         "plugin://acme-hsm/slot/3?label=release",
+		wantName:   "acme-hsm",
+		wantKeyRef: "slot/3?label=release",
+	}, {
+		in:      "plugin://../bin/sh",
+		wantErr: true,
+	}, {
+		in:      "plugin://Acme/key",
+		wantErr: true,
+	}, {
+		in:      "awskms://acme-hsm/key",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			name, keyRef, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			require.Equal(t, tt.wantName, name)
+			require.Equal(t, tt.wantKeyRef, keyRef)
+		})
+	}
+}
+
+func TestSignerVerifier(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, priv := range []crypto.Signer{ecdsaKey, ed25519Key} {
+		t.Run(fmt.Sprintf("%T", priv), func(t *testing.T) {
+			installFakePlugin(t, priv)
+			sv, err := LoadSignerVerifier("plugin://test/key")
+			require.NoError(t, err)
+
+			pub, err := sv.PublicKey()
+			require.NoError(t, err)
+			require.Equal(t, priv.Public(), pub)
+
+			msg := []byte("payload")
+			sig, err := sv.SignMessage(bytes.NewReader(msg))
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)))
+			require.Error(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("other"))))
+
+			verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)))
+		})
+	}
+}
+
+func TestCryptoSigner(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	installFakePlugin(t, priv)
+	sv, err := LoadSignerVerifier("plugin://test/key")
+	require.NoError(t, err)
+
+	signer, opts, err := sv.CryptoSigner(context.Background(), func(err error) { t.Error(err) })
+	require.NoError(t, err)
+	require.Equal(t, crypto.SHA256, opts.HashFunc())
+	require.True(t, priv.PublicKey.Equal(signer.Public()))
+	digest := sha256.Sum256([]byte("payload"))
+	sig, err := signer.Sign(rand.Reader, digest[:], opts)
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(&priv.PublicKey, digest[:], sig))
+}
+
+func TestCreateKey(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	installFakePlugin(t, priv)
+	sv, err := LoadSignerVerifier("plugin://test/new-key")
+	require.NoError(t, err)
+
+	pub, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.NoError(t, err)
+	require.True(t, priv.PublicKey.Equal(pub))
+	_, err = sv.CreateKey(context.Background(), AlgorithmED25519)
+	require.ErrorContains(t, err, "unsupported algorithm ed25519")
+}
+
+func TestPluginFailures(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	installFakePlugin(t, priv)
+
+	sv, err := LoadSignerVerifier("plugin://test/fail")
+	require.NoError(t, err)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "signer plugin test: publicKey: key is disabled")
+
+	sv, err = LoadSignerVerifier("plugin://test/crash")
+	require.NoError(t, err)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "exit status 3")
+
+	_, err = LoadSignerVerifier("plugin://missing/key")
+	require.ErrorContains(t, err, "finding signer plugin missing")
+}
+
+func TestServeProtocolVersion(t *testing.T) {
+	var out bytes.Buffer
+	err := Serve(bytes.NewReader([]byte(`{"protocolVersion":"0","method":"publicKey"}`)), &out, func(*Request) (*Response, error) {
+		t.Fatal("handler called for an unsupported protocol version")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"protocolVersion":"1","error":"unsupported protocol version \"0\", want \"1\""}`, out.String())
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin implements plugin:// keys, which sign with an external
+// executable so that HSMs and signing services cosign has no provider for
+// can be used without changing cosign.
+//
+// A reference plugin://NAME/KEY runs the executable cosign-signer-NAME found
+// in PATH once for every operation. cosign writes a JSON Request to its
+// standard input and reads a JSON Response from its standard output; the
+// plugin's standard error is passed through to the user. KEY is passed to
+// the plugin as Request.KeyRef without being interpreted by cosign.
+//
+// Version 1 of the protocol has the following methods:
+//
+//   - publicKey returns the PEM encoded public key of the key in
+//     Response.PublicKey, and the name of the hash function to sign with in
+//     Response.HashFunc. It defaults to none for ed25519 keys, which sign
+//     messages rather than digests, and to sha256 for other keys.
+//   - sign returns the signature of Request.Digest in Response.Signature.
+//     Request.HashFunc names the hash function of the digest, or is none if
+//     Request.Digest is the message itself.
+//   - createKey creates the key with Request.Algorithm, one of
+//     SupportedAlgorithms, and returns it like publicKey.
+//
+// Binary fields are base64 encoded. A plugin reports failures by setting
+// Response.Error, or by exiting with a non-zero status. Signatures are
+// verified by cosign with the public key, without running the plugin.
+//
+// Plugins written in Go may implement the protocol with Serve.
+package plugin
@@ -0,0 +1,118 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the version of the plugin protocol spoken by cosign.
+const ProtocolVersion = "1"
+
+// Methods of the plugin protocol.
+const (
+	MethodPublicKey = "publicKey"
+	MethodSign      = "sign"
+	MethodCreateKey = "createKey"
+)
+
+// Request is written by cosign to the standard input of a plugin.
+type Request struct {
+	// ProtocolVersion is the version of the protocol the request is in.
+	ProtocolVersion string `json:"protocolVersion"`
+	// Method is the operation to perform.
+	Method string `json:"method"`
+	// KeyRef is the part of the key reference following plugin://NAME/.
+	KeyRef string `json:"keyRef"`
+	// HashFunc names the hash function of Digest, for sign.
+	HashFunc string `json:"hashFunc,omitempty"`
+	// Digest is the digest to sign, for sign.
+	Digest []byte `json:"digest,omitempty"`
+	// Algorithm is the algorithm of the key to create, for createKey.
+	Algorithm string `json:"algorithm,omitempty"`
+}
+
+// Response is written by a plugin to its standard output.
+type Response struct {
+	// ProtocolVersion is the version of the protocol the response is in.
+	ProtocolVersion string `json:"protocolVersion"`
+	// Error describes why the request failed, or is empty if it succeeded.
+	Error string `json:"error,omitempty"`
+	// PublicKey is the PEM encoded public key, for publicKey and createKey.
+	PublicKey string `json:"publicKey,omitempty"`
+	// HashFunc names the hash function to sign with, for publicKey and
+	// createKey.
+	HashFunc string `json:"hashFunc,omitempty"`
+	// Signature is the signature, for sign.
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// Handler answers a Request to a plugin.
+type Handler func(req *Request) (*Response, error)
+
+// Serve reads a Request from in, answers it with h and writes the Response
+// to out. An error returned by h is sent to cosign in Response.Error, while
+// Serve only returns errors reading or writing the protocol.
+func Serve(in io.Reader, out io.Writer, h Handler) error {
+	var req Request
+	if err := json.NewDecoder(in).Decode(&req); err != nil {
+		return fmt.Errorf("decoding request: %w", err)
+	}
+	resp := &Response{}
+	if req.ProtocolVersion != ProtocolVersion {
+		resp.Error = fmt.Sprintf("unsupported protocol version %q, want %q", req.ProtocolVersion, ProtocolVersion)
+	} else if r, err := h(&req); err != nil {
+		resp.Error = err.Error()
+	} else if r != nil {
+		resp = r
+	}
+	resp.ProtocolVersion = ProtocolVersion
+	return json.NewEncoder(out).Encode(resp)
+}
+
+// hashNames are the names of hash functions in the protocol.
+var hashNames = map[crypto.Hash]string{
+	crypto.Hash(0): "none",
+	crypto.SHA224:  "sha224",
+	crypto.SHA256:  "sha256",
+	crypto.SHA384:  "sha384",
+	crypto.SHA512:  "sha512",
+}
+
+// HashName returns the name of h in the protocol.
+func HashName(h crypto.Hash) (string, error) {
+	name, ok := hashNames[h]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash function %v", h)
+	}
+	return name, nil
+}
+
+// ParseHashName returns the hash function named name in the protocol, which
+// is SHA-256 if name is empty.
+func ParseHashName(name string) (crypto.Hash, error) {
+	if name == "" {
+		return crypto.SHA256, nil
+	}
+	for h, n := range hashNames {
+		if n == name {
+			return h, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported hash function %q", name)
+}
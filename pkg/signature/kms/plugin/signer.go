@@ -0,0 +1,176 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+)
+
+// Algorithms a plugin may be asked to create keys with.
+const (
+	AlgorithmECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgorithmECDSAP384SHA384 = "ecdsa-p384-sha384"
+	AlgorithmRSA2048SHA256   = "rsa-2048-sha256"
+	AlgorithmRSA4096SHA256   = "rsa-4096-sha256"
+	AlgorithmED25519         = "ed25519"
+	defaultAlgorithm         = AlgorithmECDSAP256SHA256
+)
+
+var supportedAlgorithms = []string{
+	AlgorithmECDSAP256SHA256,
+	AlgorithmECDSAP384SHA384,
+	AlgorithmRSA2048SHA256,
+	AlgorithmRSA4096SHA256,
+	AlgorithmED25519,
+}
+
+// SignerVerifier is a signature.SignerVerifier that signs with a signer
+// plugin
+type SignerVerifier struct {
+	client *pluginClient
+}
+
+// LoadSignerVerifier generates signatures using the plugin and key referred
+// to by referenceStr, with the hash function the plugin reports for the key.
+//
+// It verifies signatures locally using the public key.
+func LoadSignerVerifier(referenceStr string) (*SignerVerifier, error) {
+	client, err := newPluginClient(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	return &SignerVerifier{client: client}, nil
+}
+
+// SignMessage signs the provided message using the plugin. If the message is
+// provided, this method will compute the digest according to the hash
+// function of the key.
+func (p *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	key, err := p.client.getKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+	digest, hf, err := signature.ComputeDigestForSigning(message, key.HashFunc, []crypto.Hash{key.HashFunc}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.sign(ctx, digest, hf)
+}
+
+// PublicKey returns the public key that can be used to verify signatures created by
+// this signer. If the caller wishes to specify the context to use to obtain
+// the public key, pass option.WithContext(desiredCtx).
+//
+// All other options are ignored if specified.
+func (p *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	key, err := p.client.getKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return key.Verifier.PublicKey(opts...)
+}
+
+// VerifySignature verifies the signature for the given message with the
+// public key of the plugin. Unless provided in an option, the digest of the
+// message will be computed using the hash function of the key.
+func (p *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+	key, err := p.client.getKey(ctx)
+	if err != nil {
+		return err
+	}
+	return key.Verifier.VerifySignature(sig, message, opts...)
+}
+
+// CreateKey asks the plugin to create the key with the specified algorithm.
+func (p *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	return p.client.createKey(ctx, algorithm)
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	pluginOptions := []signature.SignOption{
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	}
+
+	return c.sv.SignMessage(nil, pluginOptions...)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (p *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	key, err := p.client.getKey(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching default hash function: %w", err)
+	}
+
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       p,
+		hashFunc: key.HashFunc,
+		errFunc:  errFunc,
+	}
+
+	return csw, key.HashFunc, nil
+}
+
+// SupportedAlgorithms returns the algorithms plugins may be asked to create
+// keys with
+func (*SignerVerifier) SupportedAlgorithms() []string {
+	return supportedAlgorithms
+}
+
+// DefaultAlgorithm returns the algorithm plugins are asked to create keys
+// with by default
+func (*SignerVerifier) DefaultAlgorithm() string {
+	return defaultAlgorithm
+}
@@ -0,0 +1,250 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tencentkms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// EnvSecretID and EnvSecretKey are the API key of a CAM user, or with
+	// EnvSessionToken, temporary credentials.
+	EnvSecretID     = "TENCENTCLOUD_SECRET_ID"
+	EnvSecretKey    = "TENCENTCLOUD_SECRET_KEY"    //nolint:gosec
+	EnvSessionToken = "TENCENTCLOUD_SESSION_TOKEN" //nolint:gosec
+	// EnvCVMRoleName is the CAM role attached to the CVM instance cosign
+	// runs on, to use when no API key is set.
+	EnvCVMRoleName = "TENCENTCLOUD_CVM_ROLE_NAME"
+
+	cvmMetadataURL = "http://metadata.tencentyun.com/latest/meta-data/cam/security-credentials/"
+	contentType    = "application/json; charset=utf-8"
+)
+
+// credentials authenticate Tencent Cloud API requests.
+type credentials struct {
+	SecretID     string
+	SecretKey    string
+	SessionToken string
+	// Expiration is when temporary credentials expire, or zero.
+	Expiration time.Time
+}
+
+func (c *credentials) expiresSoon() bool {
+	return !c.Expiration.IsZero() && time.Until(c.Expiration) < 5*time.Minute
+}
+
+// credentialsProvider returns the credentials to sign requests with.
+type credentialsProvider interface {
+	credentials(ctx context.Context) (*credentials, error)
+}
+
+// newCredentialsProvider returns the credentialsProvider selected by the
+// environment: the API key of EnvSecretID and EnvSecretKey, or the CAM role
+// of the CVM instance named by EnvCVMRoleName.
+func newCredentialsProvider() (credentialsProvider, error) {
+	id, key := os.Getenv(EnvSecretID), os.Getenv(EnvSecretKey)
+	switch {
+	case id != "" && key != "":
+		return &staticCredentials{&credentials{
+			SecretID:     id,
+			SecretKey:    key,
+			SessionToken: os.Getenv(EnvSessionToken),
+		}}, nil
+	case os.Getenv(EnvCVMRoleName) != "":
+		return &cvmRoleCredentials{
+			client:      &http.Client{Timeout: 10 * time.Second},
+			metadataURL: cvmMetadataURL,
+			roleName:    os.Getenv(EnvCVMRoleName),
+		}, nil
+	default:
+		return nil, fmt.Errorf("set %s and %s, or %s to authenticate to Tencent Cloud KMS",
+			EnvSecretID, EnvSecretKey, EnvCVMRoleName)
+	}
+}
+
+type staticCredentials struct {
+	creds *credentials
+}
+
+func (s *staticCredentials) credentials(context.Context) (*credentials, error) {
+	return s.creds, nil
+}
+
+// cvmRoleCredentials are the temporary credentials the CVM metadata service
+// issues for the CAM role attached to the instance. They are held until they
+// expire soon.
+type cvmRoleCredentials struct {
+	client      *http.Client
+	metadataURL string
+	roleName    string
+
+	mu    sync.Mutex
+	creds *credentials
+}
+
+func (c *cvmRoleCredentials) credentials(ctx context.Context) (*credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds != nil && !c.creds.expiresSoon() {
+		return c.creds, nil
+	}
+	creds, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.creds = creds
+	return creds, nil
+}
+
+func (c *cvmRoleCredentials) fetch(ctx context.Context) (*credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.metadataURL+url.PathEscape(c.roleName), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading CVM role credentials: %w", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading CVM role credentials for %s: %s", c.roleName, resp.Status)
+	}
+	var out struct {
+		Code         string `json:"Code"`
+		TmpSecretID  string `json:"TmpSecretId"`
+		TmpSecretKey string `json:"TmpSecretKey"`
+		Token        string `json:"Token"`
+		ExpiredTime  int64  `json:"ExpiredTime"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("reading CVM role credentials for %s: %w", c.roleName, err)
+	}
+	if out.Code != "Success" {
+		return nil, fmt.Errorf("reading CVM role credentials for %s: %s", c.roleName, out.Code)
+	}
+	return &credentials{
+		SecretID:     out.TmpSecretID,
+		SecretKey:    out.TmpSecretKey,
+		SessionToken: out.Token,
+		Expiration:   time.Unix(out.ExpiredTime, 0),
+	}, nil
+}
+
+// callAPI calls action of service at endpoint with the JSON encoding of
+// params, signed with creds, decoding the response into out.
+func callAPI(ctx context.Context, client *http.Client, creds *credentials, endpoint, service, version, region, action string, params, out any) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Version", version)
+	req.Header.Set("X-TC-Region", region)
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(timestamp, 10))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-TC-Token", creds.SessionToken)
+	}
+	req.Header.Set("Authorization", tc3Authorization(creds, service, req.URL.Host, payload, timestamp))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", action, resp.Status)
+	}
+	// Errors are reported in the response with a 200 status.
+	var envelope struct {
+		Response json.RawMessage `json:"Response"`
+	}
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	var apiErr struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestID string `json:"RequestId"`
+	}
+	if err := json.Unmarshal(envelope.Response, &apiErr); err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	if apiErr.Error != nil {
+		return fmt.Errorf("%s: %s: %s (request id %s)", action, apiErr.Error.Code, apiErr.Error.Message, apiErr.RequestID)
+	}
+	return json.Unmarshal(envelope.Response, out)
+}
+
+// tc3Authorization returns the Authorization header of a request to service
+// at host with payload, signed with creds at timestamp using signature
+// method TC3-HMAC-SHA256 over the content-type and host headers.
+func tc3Authorization(creds *credentials, service, host string, payload []byte, timestamp int64) string {
+	date := time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	scope := date + "/" + service + "/tc3_request"
+	return fmt.Sprintf("TC3-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s",
+		creds.SecretID, scope, tc3Signature(creds.SecretKey, service, host, payload, timestamp))
+}
+
+// tc3Signature returns the TC3-HMAC-SHA256 signature of a POST request.
+func tc3Signature(secretKey, service, host string, payload []byte, timestamp int64) string {
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := "POST\n/\n\n" +
+		"content-type:" + contentType + "\nhost:" + host + "\n\n" +
+		"content-type;host\n" + hex.EncodeToString(payloadHash[:])
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	date := time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	stringToSign := "TC3-HMAC-SHA256\n" + strconv.FormatInt(timestamp, 10) + "\n" +
+		date + "/" + service + "/tc3_request\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("TC3"+secretKey), date)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "tc3_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
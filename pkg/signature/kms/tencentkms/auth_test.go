@@ -0,0 +1,117 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tencentkms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkAPIRequest checks that r is a POSTed API request for action, signed
+// with creds, returning its parameters.
+func checkAPIRequest(t *testing.T, r *http.Request, creds *credentials, action string) map[string]any {
+	t.Helper()
+	require.Equal(t, http.MethodPost, r.Method)
+	require.Equal(t, action, r.Header.Get("X-TC-Action"))
+	require.Equal(t, creds.SessionToken, r.Header.Get("X-TC-Token"))
+	payload, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	timestamp, err := strconv.ParseInt(r.Header.Get("X-TC-Timestamp"), 10, 64)
+	require.NoError(t, err)
+	require.Equal(t, tc3Authorization(creds, kmsService, r.Host, payload, timestamp), r.Header.Get("Authorization"), "request signature")
+	var params map[string]any
+	require.NoError(t, json.Unmarshal(payload, &params))
+	return params
+}
+
+func TestTC3Signature(t *testing.T) {
+	// The example from the Tencent Cloud documentation of signature
+	// method v3.
+	payload := []byte(`{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`)
+	require.Equal(t, "72e494ea809ad7a8c8f7a4507b9bddcbaa8e581f516e8da2f66e2c5a96525168",
+		tc3Signature("Gu5t9xGARNpq86cd98joQYCN3EXAMPLE", "cvm", "cvm.tencentcloudapi.com", payload, 1551113065))
+
+	creds := &credentials{SecretID: "AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE", SecretKey: "Gu5t9xGARNpq86cd98joQYCN3EXAMPLE"}
+	require.Equal(t, "TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE/2019-02-25/cvm/tc3_request, SignedHeaders=content-type;host, Signature=72e494ea809ad7a8c8f7a4507b9bddcbaa8e581f516e8da2f66e2c5a96525168",
+		tc3Authorization(creds, "cvm", "cvm.tencentcloudapi.com", payload, 1551113065))
+}
+
+func TestNewCredentialsProvider(t *testing.T) {
+	for _, env := range []string{EnvSecretID, EnvSecretKey, EnvSessionToken, EnvCVMRoleName} {
+		t.Setenv(env, "")
+	}
+	_, err := newCredentialsProvider()
+	require.ErrorContains(t, err, EnvSecretID)
+
+	t.Setenv(EnvCVMRoleName, "cosign-signer")
+	p, err := newCredentialsProvider()
+	require.NoError(t, err)
+	require.IsType(t, &cvmRoleCredentials{}, p)
+
+	// An API key takes precedence over the CVM role.
+	t.Setenv(EnvSecretID, "id")
+	t.Setenv(EnvSecretKey, "key")
+	t.Setenv(EnvSessionToken, "token")
+	p, err = newCredentialsProvider()
+	require.NoError(t, err)
+	creds, err := p.credentials(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &credentials{SecretID: "id", SecretKey: "key", SessionToken: "token"}, creds)
+}
+
+func TestCVMRoleCredentials(t *testing.T) {
+	var calls int
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest/meta-data/cam/security-credentials/cosign-signer" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		calls++
+		fmt.Fprintf(w, `{"Code": "Success", "TmpSecretId": "AKIDcvm", "TmpSecretKey": "cvm-key", "Token": "cvm-token", "ExpiredTime": %d}`,
+			time.Now().Add(time.Hour).Unix())
+	}))
+	defer metadata.Close()
+
+	p := &cvmRoleCredentials{
+		client:      metadata.Client(),
+		metadataURL: metadata.URL + "/latest/meta-data/cam/security-credentials/",
+		roleName:    "cosign-signer",
+	}
+	for i := 0; i < 2; i++ {
+		creds, err := p.credentials(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "AKIDcvm", creds.SecretID)
+		require.Equal(t, "cvm-key", creds.SecretKey)
+		require.Equal(t, "cvm-token", creds.SessionToken)
+	}
+	require.Equal(t, 1, calls, "credentials should be reused until they expire")
+
+	p = &cvmRoleCredentials{
+		client:      metadata.Client(),
+		metadataURL: metadata.URL + "/latest/meta-data/cam/security-credentials/",
+		roleName:    "missing",
+	}
+	_, err := p.credentials(context.Background())
+	require.ErrorContains(t, err, "404")
+}
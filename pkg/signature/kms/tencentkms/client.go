@@ -0,0 +1,295 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tencentkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	sigkms "github.com/franchb/sigstore/pkg/signature/kms"
+)
+
+func init() {
+	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID)
+	})
+}
+
+// ReferenceScheme is the scheme of references to keys in Tencent Cloud KMS.
+const ReferenceScheme = "tencentkms://"
+
+// Algorithms that keys can be created with.
+const (
+	AlgorithmECDSAP256SHA256    = "ecdsa-p256-sha256"
+	AlgorithmRSA2048PKCS1SHA256 = "rsa-2048-pkcs1v15-sha256"
+)
+
+// algorithmMap maps algorithms to the KeyUsage keys are created with.
+var algorithmMap = map[string]string{
+	AlgorithmECDSAP256SHA256:    "ASYMMETRIC_SIGN_VERIFY_ECC",
+	AlgorithmRSA2048PKCS1SHA256: "ASYMMETRIC_SIGN_VERIFY_RSA_2048",
+}
+
+const (
+	kmsService  = "kms"
+	kmsVersion  = "2019-01-18"
+	kmsEndpoint = "https://kms.tencentcloudapi.com"
+	cacheTTL    = 5 * time.Minute
+)
+
+var (
+	errKMSReference = errors.New("kms specification should be in the format tencentkms://REGION/KEY_ID or tencentkms://REGION/alias/ALIAS")
+
+	referenceRE = regexp.MustCompile(`^tencentkms://([a-z0-9-]+)/((?:alias/)?[\w-]+)$`)
+)
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	if !referenceRE.MatchString(ref) {
+		return errKMSReference
+	}
+	return nil
+}
+
+// ParseReference parses a tencentkms-scheme URI into its constituent parts.
+// keyID is either the ID of a key or an alias of it starting with "alias/".
+func ParseReference(referenceStr string) (region, keyID string, err error) {
+	v := referenceRE.FindStringSubmatch(referenceStr)
+	if v == nil {
+		return "", "", fmt.Errorf("invalid tencentkms format %q", referenceStr)
+	}
+	return v[1], v[2], nil
+}
+
+type tencentKMSClient struct {
+	client      *http.Client
+	credentials credentialsProvider
+	endpoint    string
+	region      string
+	keyID       string
+
+	mu        sync.Mutex
+	key       *tencentSigningKey
+	keyExpiry time.Time
+}
+
+// tencentSigningKey is a key and how to sign and verify with it.
+type tencentSigningKey struct {
+	KeyID     string
+	Algorithm string
+	Verifier  signature.Verifier
+	HashFunc  crypto.Hash
+}
+
+func newTencentKMSClient(referenceStr string) (*tencentKMSClient, error) {
+	if err := ValidReference(referenceStr); err != nil {
+		return nil, err
+	}
+	region, keyID, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := newCredentialsProvider()
+	if err != nil {
+		return nil, err
+	}
+	return &tencentKMSClient{
+		client:      http.DefaultClient,
+		credentials: creds,
+		endpoint:    kmsEndpoint,
+		region:      region,
+		keyID:       keyID,
+	}, nil
+}
+
+func (t *tencentKMSClient) call(ctx context.Context, action string, params, out any) error {
+	creds, err := t.credentials.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	return callAPI(ctx, t.client, creds, t.endpoint, kmsService, kmsVersion, t.region, action, params, out)
+}
+
+type keyMetadata struct {
+	KeyID    string `json:"KeyId"`
+	Alias    string `json:"Alias"`
+	KeyUsage string `json:"KeyUsage"`
+	KeyState string `json:"KeyState"`
+}
+
+// describeKey returns the metadata of the key, looking it up by its alias
+// when the client refers to one since DescribeKey only accepts key IDs.
+func (t *tencentKMSClient) describeKey(ctx context.Context) (*keyMetadata, error) {
+	alias, ok := strings.CutPrefix(t.keyID, "alias/")
+	if !ok {
+		var resp struct {
+			KeyMetadata keyMetadata `json:"KeyMetadata"`
+		}
+		if err := t.call(ctx, "DescribeKey", map[string]any{"KeyId": t.keyID}, &resp); err != nil {
+			return nil, err
+		}
+		return &resp.KeyMetadata, nil
+	}
+	var resp struct {
+		KeyMetadatas []keyMetadata `json:"KeyMetadatas"`
+	}
+	// SearchKeyAlias matches aliases partially, so look for the exact one.
+	if err := t.call(ctx, "ListKeyDetail", map[string]any{"SearchKeyAlias": alias, "Limit": 200}, &resp); err != nil {
+		return nil, err
+	}
+	for _, md := range resp.KeyMetadatas {
+		if md.Alias == alias {
+			return &md, nil
+		}
+	}
+	return nil, fmt.Errorf("no key with alias %s", alias)
+}
+
+func (t *tencentKMSClient) fetchPublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	var resp struct {
+		PublicKeyPem string `json:"PublicKeyPem"`
+	}
+	if err := t.call(ctx, "GetPublicKey", map[string]any{"KeyId": keyID}, &resp); err != nil {
+		return nil, err
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey([]byte(resp.PublicKeyPem))
+}
+
+func (t *tencentKMSClient) getTencentSigningKey(ctx context.Context) (*tencentSigningKey, error) {
+	md, err := t.describeKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(md.KeyUsage, "ASYMMETRIC_SIGN_VERIFY_") {
+		return nil, fmt.Errorf("key %s has usage %s, not for signing", md.KeyID, md.KeyUsage)
+	}
+	pubKey, err := t.fetchPublicKey(ctx, md.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	sk := tencentSigningKey{KeyID: md.KeyID, HashFunc: crypto.SHA256}
+	switch md.KeyUsage {
+	case "ASYMMETRIC_SIGN_VERIFY_RSA_2048":
+		pub, ok := pubKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", md.KeyUsage, pubKey)
+		}
+		sk.Algorithm = "RSA_PKCS1_SHA_256"
+		sk.Verifier, err = signature.LoadRSAPKCS1v15Verifier(pub, crypto.SHA256)
+	case "ASYMMETRIC_SIGN_VERIFY_ECC":
+		pub, ok := pubKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of %s key is %T", md.KeyUsage, pubKey)
+		}
+		sk.Algorithm = "ECC_P256"
+		sk.Verifier, err = signature.LoadECDSAVerifier(pub, crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported key usage %s specified by KMS", md.KeyUsage)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initializing internal verifier: %w", err)
+	}
+	return &sk, nil
+}
+
+// getSK returns the signing key, fetching it again once it has been cached
+// for cacheTTL so that changes to an alias are picked up.
+func (t *tencentKMSClient) getSK(ctx context.Context) (*tencentSigningKey, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.key != nil && time.Now().Before(t.keyExpiry) {
+		return t.key, nil
+	}
+	sk, err := t.getTencentSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.key, t.keyExpiry = sk, time.Now().Add(cacheTTL)
+	return sk, nil
+}
+
+func (t *tencentKMSClient) getHashFunc(ctx context.Context) (crypto.Hash, error) {
+	sk, err := t.getSK(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return sk.HashFunc, nil
+}
+
+func (t *tencentKMSClient) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	sk, err := t.getSK(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hf != sk.HashFunc {
+		return nil, fmt.Errorf("hash function %v does not match the %v of the key", hf, sk.HashFunc)
+	}
+	params := map[string]any{
+		"KeyId":       sk.KeyID,
+		"Algorithm":   sk.Algorithm,
+		"Message":     base64.StdEncoding.EncodeToString(digest),
+		"MessageType": "DIGEST",
+	}
+	var resp struct {
+		Signature string `json:"Signature"`
+	}
+	if err := t.call(ctx, "SignByAsymmetricKey", params, &resp); err != nil {
+		return nil, fmt.Errorf("calling Tencent Cloud KMS SignByAsymmetricKey: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Signature)
+}
+
+func (t *tencentKMSClient) verify(ctx context.Context, sig, message io.Reader, opts ...signature.VerifyOption) error {
+	sk, err := t.getSK(ctx)
+	if err != nil {
+		return err
+	}
+	return sk.Verifier.VerifySignature(sig, message, opts...)
+}
+
+// createKey creates a key with the alias the client refers to.
+func (t *tencentKMSClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	alias, ok := strings.CutPrefix(t.keyID, "alias/")
+	if !ok {
+		return nil, errors.New("create tencentkms key specification should be in the format tencentkms://REGION/alias/ALIAS")
+	}
+	keyUsage, ok := algorithmMap[algorithm]
+	if !ok {
+		return nil, errors.New("unknown algorithm requested")
+	}
+	var created keyMetadata
+	params := map[string]any{
+		"Alias":       alias,
+		"KeyUsage":    keyUsage,
+		"Description": "Created by cosign",
+	}
+	if err := t.call(ctx, "CreateKey", params, &created); err != nil {
+		return nil, fmt.Errorf("tencentkms key create error: %w", err)
+	}
+	return t.fetchPublicKey(ctx, created.KeyID)
+}
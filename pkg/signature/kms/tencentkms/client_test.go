@@ -0,0 +1,249 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tencentkms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franchb/sigstore/pkg/cryptoutils"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantRegion string
+		wantKeyID  string
+		wantErr    bool
+	}{{
+		in:         "tencentkms://ap-guangzhou/4f2c8a7e-1b3d-11ef-9d5c-5254000b1f3c",
+		wantRegion: "ap-guangzhou",
+		wantKeyID:  "4f2c8a7e-1b3d-11ef-9d5c-5254000b1f3c",
+	}, {
+		in:         "tencentkms://ap-singapore/alias/cosign_signer",
+		wantRegion: "ap-singapore",
+		wantKeyID:  "alias/cosign_signer",
+	}, {
+		in:      "tencentkms://ap-guangzhou/",
+		wantErr: true,
+	}, {
+		in:      "tencentkms://ap-guangzhou/alias/cosign/extra",
+		wantErr: true,
+	}, {
+		in:      "alibabakms://ap-guangzhou/4f2c8a7e-1b3d-11ef-9d5c-5254000b1f3c",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			region, keyID, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (ValidReference(tt.in) != nil) != tt.wantErr {
+				t.Errorf("ValidReference() disagrees with ParseReference()")
+			}
+			require.Equal(t, tt.wantRegion, region)
+			require.Equal(t, tt.wantKeyID, keyID)
+		})
+	}
+}
+
+// fakeKMS serves the parts of the KMS API the client uses, for a single key
+// with the alias cosign, checking that every request is signed with creds.
+type fakeKMS struct {
+	t       *testing.T
+	creds   *credentials
+	usage   string
+	private crypto.Signer
+}
+
+const fakeKeyID = "4f2c8a7e-1b3d-11ef-9d5c-5254000b1f3c"
+
+func (f *fakeKMS) respond(w http.ResponseWriter, resp map[string]any) {
+	resp["RequestId"] = "1"
+	require.NoError(f.t, json.NewEncoder(w).Encode(map[string]any{"Response": resp}))
+}
+
+func (f *fakeKMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := r.Header.Get("X-TC-Action")
+	params := checkAPIRequest(f.t, r, f.creds, action)
+	require.Equal(f.t, kmsVersion, r.Header.Get("X-TC-Version"))
+	require.Equal(f.t, "ap-guangzhou", r.Header.Get("X-TC-Region"))
+
+	md := keyMetadata{KeyID: fakeKeyID, Alias: "cosign", KeyUsage: f.usage, KeyState: "Enabled"}
+	if keyID, ok := params["KeyId"]; ok && keyID != fakeKeyID {
+		f.respond(w, map[string]any{"Error": map[string]string{
+			"Code":    "ResourceUnavailable.CmkNotFound",
+			"Message": fmt.Sprintf("KeyId %s not found", keyID),
+		}})
+		return
+	}
+	switch action {
+	case "DescribeKey":
+		f.respond(w, map[string]any{"KeyMetadata": md})
+	case "ListKeyDetail":
+		// Aliases are matched partially.
+		other := keyMetadata{KeyID: "other", Alias: "cosign-other", KeyUsage: f.usage}
+		var found []keyMetadata
+		if params["SearchKeyAlias"] == "cosign" {
+			found = []keyMetadata{other, md}
+		}
+		f.respond(w, map[string]any{"KeyMetadatas": found, "TotalCount": len(found)})
+	case "GetPublicKey":
+		pub, err := cryptoutils.MarshalPublicKeyToPEM(f.private.Public())
+		require.NoError(f.t, err)
+		f.respond(w, map[string]any{"KeyId": fakeKeyID, "PublicKeyPem": string(pub)})
+	case "SignByAsymmetricKey":
+		require.Equal(f.t, "DIGEST", params["MessageType"])
+		digest, err := base64.StdEncoding.DecodeString(params["Message"].(string))
+		require.NoError(f.t, err)
+		sig, err := f.private.Sign(rand.Reader, digest, crypto.SHA256)
+		require.NoError(f.t, err)
+		f.respond(w, map[string]any{"Signature": base64.StdEncoding.EncodeToString(sig)})
+	case "CreateKey":
+		require.Equal(f.t, "cosign", params["Alias"])
+		require.Equal(f.t, f.usage, params["KeyUsage"])
+		f.respond(w, map[string]any{"KeyId": fakeKeyID, "Alias": "cosign", "KeyUsage": f.usage})
+	default:
+		f.respond(w, map[string]any{"Error": map[string]string{
+			"Code":    "InvalidAction",
+			"Message": "The action does not exist.",
+		}})
+	}
+}
+
+func newFakeKMSSignerVerifier(t *testing.T, keyID, usage string, private crypto.Signer) *SignerVerifier {
+	t.Helper()
+	creds := &credentials{SecretID: "AKIDtmp", SecretKey: "key", SessionToken: "token"}
+	kms := httptest.NewServer(&fakeKMS{t: t, creds: creds, usage: usage, private: private})
+	t.Cleanup(kms.Close)
+	return &SignerVerifier{client: &tencentKMSClient{
+		client:      kms.Client(),
+		credentials: &staticCredentials{creds},
+		endpoint:    kms.URL,
+		region:      "ap-guangzhou",
+		keyID:       keyID,
+	}}
+}
+
+func TestSignerVerifier(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		keyID   string
+		usage   string
+		private crypto.Signer
+	}{{
+		name:    "ecdsa by id",
+		keyID:   fakeKeyID,
+		usage:   "ASYMMETRIC_SIGN_VERIFY_ECC",
+		private: ecKey,
+	}, {
+		name:    "rsa by alias",
+		keyID:   "alias/cosign",
+		usage:   "ASYMMETRIC_SIGN_VERIFY_RSA_2048",
+		private: rsaKey,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newFakeKMSSignerVerifier(t, tt.keyID, tt.usage, tt.private)
+			ctx := context.Background()
+			message := []byte("hello, tencent cloud")
+
+			sig, err := sv.SignMessage(bytes.NewReader(message), options.WithContext(ctx))
+			require.NoError(t, err)
+			require.NoError(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+			require.Error(t, sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("something else"))))
+
+			pub, err := sv.PublicKey()
+			require.NoError(t, err)
+			require.NoError(t, cryptoutils.EqualKeys(tt.private.Public(), pub))
+
+			// The key is verifiable without KMS.
+			verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+
+			signer, opts, err := sv.CryptoSigner(ctx, func(err error) { t.Error(err) })
+			require.NoError(t, err)
+			require.Equal(t, crypto.SHA256, opts.HashFunc())
+			digest := sha256.Sum256(message)
+			sig, err = signer.Sign(rand.Reader, digest[:], opts)
+			require.NoError(t, err)
+			require.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+		})
+	}
+}
+
+func TestCreateKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sv := newFakeKMSSignerVerifier(t, "alias/cosign", "ASYMMETRIC_SIGN_VERIFY_ECC", ecKey)
+	pub, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.NoError(t, err)
+	require.NoError(t, cryptoutils.EqualKeys(ecKey.Public(), pub))
+
+	_, err = sv.CreateKey(context.Background(), "ed25519")
+	require.ErrorContains(t, err, "unknown algorithm")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "ASYMMETRIC_SIGN_VERIFY_ECC", ecKey)
+	_, err = sv.CreateKey(context.Background(), sv.DefaultAlgorithm())
+	require.ErrorContains(t, err, "alias/ALIAS")
+}
+
+func TestSignerVerifierErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sv := newFakeKMSSignerVerifier(t, "alias/missing", "ASYMMETRIC_SIGN_VERIFY_ECC", ecKey)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "no key with alias missing")
+
+	sv = newFakeKMSSignerVerifier(t, "b1e2b0a4-1b3d-11ef-9d5c-5254000b1f3c", "ASYMMETRIC_SIGN_VERIFY_ECC", ecKey)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "ResourceUnavailable.CmkNotFound: KeyId b1e2b0a4-1b3d-11ef-9d5c-5254000b1f3c not found")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "ENCRYPT_DECRYPT", ecKey)
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")))
+	require.ErrorContains(t, err, "not for signing")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "ASYMMETRIC_SIGN_VERIFY_SM2", ecKey)
+	_, err = sv.PublicKey()
+	require.ErrorContains(t, err, "unsupported key usage ASYMMETRIC_SIGN_VERIFY_SM2")
+
+	sv = newFakeKMSSignerVerifier(t, fakeKeyID, "ASYMMETRIC_SIGN_VERIFY_ECC", ecKey)
+	_, err = sv.SignMessage(bytes.NewReader([]byte("hello")), options.WithCryptoSignerOpts(crypto.SHA384))
+	require.Error(t, err)
+}
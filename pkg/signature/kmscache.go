@@ -0,0 +1,246 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/franchb/cosign/v2/internal/ui"
+	"github.com/franchb/cosign/v2/pkg/cosign"
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+	"github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/kms"
+)
+
+// DefaultKMSCacheTTL is how long the verifiers of KMS keys are cached unless
+// COSIGN_KMS_CACHE_TTL says otherwise.
+const DefaultKMSCacheTTL = 10 * time.Minute
+
+// kmsCache holds the verifiers of the KMS keys loaded by this process, so
+// that verifying many signatures does not fetch the same public key again.
+var kmsCache = struct {
+	sync.Mutex
+	entries map[string]kmsCacheEntry
+}{entries: map[string]kmsCacheEntry{}}
+
+type kmsCacheEntry struct {
+	verifier signature.Verifier
+	fetched  time.Time
+}
+
+// kmsCacheFile is a public key cached in COSIGN_KMS_CACHE_DIR.
+type kmsCacheFile struct {
+	KeyRef        string    `json:"keyRef"`
+	ResolvedKeyID string    `json:"resolvedKeyID,omitempty"`
+	PublicKey     string    `json:"publicKey"`
+	Fetched       time.Time `json:"fetched"`
+}
+
+// ResolvedKey is implemented by verifiers of KMS keys whose references, like
+// aliases, are resolved to a specific key.
+type ResolvedKey interface {
+	// ResolvedKeyID returns the identifier, like an ARN, of the key the
+	// reference resolved to, or "" if it is not known.
+	ResolvedKeyID() string
+}
+
+// ResolvedKeyID returns the identifier of the key v verifies with, if v is a
+// ResolvedKey, or "".
+func ResolvedKeyID(v signature.Verifier) string {
+	if rk, ok := v.(ResolvedKey); ok {
+		return rk.ResolvedKeyID()
+	}
+	return ""
+}
+
+// kmsCacheTTL returns the value of COSIGN_KMS_CACHE_TTL, or
+// DefaultKMSCacheTTL if it is unset.
+func kmsCacheTTL() (time.Duration, error) {
+	v, ok := env.LookupEnv(env.VariableKMSCacheTTL)
+	if !ok || v == "" {
+		return DefaultKMSCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", env.VariableKMSCacheTTL, err)
+	}
+	return ttl, nil
+}
+
+// kmsVerifier returns the verifier of the KMS key keyRef, cached in process
+// and, if COSIGN_KMS_CACHE_DIR is set, on disk for COSIGN_KMS_CACHE_TTL.
+// Errors, including kms.ProviderNotFoundError, are never cached.
+func kmsVerifier(ctx context.Context, keyRef string, hashAlgorithm crypto.Hash) (signature.Verifier, error) {
+	load := func() (signature.Verifier, error) {
+		return kms.Get(ctx, keyRef, hashAlgorithm)
+	}
+	ttl, err := kmsCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return load()
+	}
+
+	key := kmsCacheKey(keyRef, hashAlgorithm)
+	now := time.Now()
+	// Holding the lock while loading keeps concurrent verifications from
+	// fetching the same key at once.
+	kmsCache.Lock()
+	defer kmsCache.Unlock()
+	if e, ok := kmsCache.entries[key]; ok && now.Sub(e.fetched) <= ttl {
+		return e.verifier, nil
+	}
+
+	dir := env.Getenv(env.VariableKMSCacheDir)
+	if dir != "" {
+		if cf, v, ok := readKMSCacheFile(dir, key, keyRef, hashAlgorithm, now, ttl); ok {
+			cached := &cachedKMSVerifier{Verifier: v, resolvedKeyID: cf.ResolvedKeyID, load: load}
+			kmsCache.entries[key] = kmsCacheEntry{verifier: cached, fetched: cf.Fetched}
+			return cached, nil
+		}
+	}
+
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	kmsCache.entries[key] = kmsCacheEntry{verifier: v, fetched: now}
+	if dir != "" {
+		if err := writeKMSCacheFile(dir, key, keyRef, v, now); err != nil {
+			ui.Warnf(ctx, "caching the public key of %s: %v", keyRef, err)
+		}
+	}
+	return v, nil
+}
+
+// kmsCacheKey identifies keyRef used with hashAlgorithm, and names the file
+// it is cached in.
+func kmsCacheKey(keyRef string, hashAlgorithm crypto.Hash) string {
+	sum := sha256.Sum256([]byte(keyRef + "\x00" + hashAlgorithm.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// readKMSCacheFile returns the public key of keyRef cached in dir and a
+// verifier for it, unless it was fetched more than ttl ago.
+func readKMSCacheFile(dir, key, keyRef string, hashAlgorithm crypto.Hash, now time.Time, ttl time.Duration) (*kmsCacheFile, signature.Verifier, bool) {
+	file := filepath.Join(dir, key+".json")
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, false
+	}
+	var cf kmsCacheFile
+	if err := json.Unmarshal(b, &cf); err != nil || cf.KeyRef != keyRef {
+		return nil, nil, false
+	}
+	if now.Sub(cf.Fetched) > ttl {
+		_ = os.Remove(file)
+		return nil, nil, false
+	}
+	v, err := cosign.LoadPublicKeyPEM([]byte(cf.PublicKey), hashAlgorithm)
+	if err != nil {
+		return nil, nil, false
+	}
+	return &cf, v, true
+}
+
+// writeKMSCacheFile caches the public key of v in dir, replacing the file
+// so that concurrent readers never see a partial write.
+func writeKMSCacheFile(dir, key, keyRef string, v signature.Verifier, fetched time.Time) error {
+	pem, err := PublicKeyPem(v)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(kmsCacheFile{KeyRef: keyRef, ResolvedKeyID: ResolvedKeyID(v), PublicKey: string(pem), Fetched: fetched})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, key+".json"))
+}
+
+// cachedKMSVerifier verifies signatures with a public key cached on disk.
+// Signatures it does not verify are verified again with the KMS key, which
+// is only loaded then, in case the key was rotated since it was cached or
+// uses a signature scheme its public key does not record, like RSA-PSS.
+type cachedKMSVerifier struct {
+	signature.Verifier
+	resolvedKeyID string
+	load          func() (signature.Verifier, error)
+
+	once        sync.Once
+	kmsVerifier signature.Verifier
+	kmsErr      error
+}
+
+// VerifySignature implements signature.Verifier
+func (c *cachedKMSVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+	var msgBytes []byte
+	if message != nil {
+		if msgBytes, err = io.ReadAll(message); err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+	}
+	if err := c.Verifier.VerifySignature(bytes.NewReader(sigBytes), bytes.NewReader(msgBytes), opts...); err == nil {
+		return nil
+	}
+	c.once.Do(func() {
+		c.kmsVerifier, c.kmsErr = c.load()
+	})
+	if c.kmsErr != nil {
+		return fmt.Errorf("loading kms key: %w", c.kmsErr)
+	}
+	return c.kmsVerifier.VerifySignature(bytes.NewReader(sigBytes), bytes.NewReader(msgBytes), opts...)
+}
+
+// ResolvedKeyID implements ResolvedKey, returning the key resolved when the
+// public key was cached unless the KMS key has been loaded since.
+func (c *cachedKMSVerifier) ResolvedKeyID() string {
+	if c.kmsVerifier != nil {
+		if id := ResolvedKeyID(c.kmsVerifier); id != "" {
+			return id
+		}
+	}
+	return c.resolvedKeyID
+}
@@ -0,0 +1,248 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/franchb/cosign/v2/pkg/cosign/env"
+	sigsignature "github.com/franchb/sigstore/pkg/signature"
+	"github.com/franchb/sigstore/pkg/signature/kms"
+	"github.com/franchb/sigstore/pkg/signature/kms/fake"
+)
+
+// countingKMS is a KMS provider counting the keys it loads, whose key can be
+// rotated.
+type countingKMS struct {
+	mu    sync.Mutex
+	loads int
+	priv  *ecdsa.PrivateKey
+}
+
+func (c *countingKMS) rotate(t *testing.T) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.priv = priv
+}
+
+func (c *countingKMS) loaded() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loads
+}
+
+var testKMS = &countingKMS{}
+
+func init() {
+	kms.AddProvider("countingkms://", func(ctx context.Context, _ string, hf crypto.Hash, _ ...sigsignature.RPCOption) (kms.SignerVerifier, error) {
+		testKMS.mu.Lock()
+		defer testKMS.mu.Unlock()
+		testKMS.loads++
+		return fake.LoadSignerVerifier(context.WithValue(ctx, fake.KmsCtxKey{}, crypto.PrivateKey(testKMS.priv)), hf)
+	})
+}
+
+func setupKMSCache(t *testing.T) {
+	t.Helper()
+	testKMS.rotate(t)
+	t.Cleanup(func() {
+		kmsCache.Lock()
+		defer kmsCache.Unlock()
+		kmsCache.entries = map[string]kmsCacheEntry{}
+	})
+}
+
+func signWithTestKMS(t *testing.T, msg []byte) []byte {
+	t.Helper()
+	testKMS.mu.Lock()
+	defer testKMS.mu.Unlock()
+	sv, err := sigsignature.LoadECDSASignerVerifier(testKMS.priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sv.SignMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestKMSVerifierInProcessCache(t *testing.T) {
+	setupKMSCache(t)
+	ctx := context.Background()
+	loads := testKMS.loaded()
+
+	v1, err := PublicKeyFromKeyRef(ctx, "countingkms://key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := PublicKeyFromKeyRef(ctx, "countingkms://key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1 != v2 {
+		t.Error("the cached verifier was not reused")
+	}
+	if got := testKMS.loaded() - loads; got != 1 {
+		t.Errorf("loaded the key %d times, want 1", got)
+	}
+
+	// Keys used with other hash functions are cached apart.
+	if _, err := PublicKeyFromKeyRefWithHashAlgo(ctx, "countingkms://key", crypto.SHA384); err != nil {
+		t.Fatal(err)
+	}
+	if got := testKMS.loaded() - loads; got != 2 {
+		t.Errorf("loaded the key %d times, want 2", got)
+	}
+
+	t.Setenv(env.VariableKMSCacheTTL.String(), "0")
+	if _, err := PublicKeyFromKeyRef(ctx, "countingkms://key"); err != nil {
+		t.Fatal(err)
+	}
+	if got := testKMS.loaded() - loads; got != 3 {
+		t.Errorf("loaded the key %d times with caching disabled, want 3", got)
+	}
+
+	t.Setenv(env.VariableKMSCacheTTL.String(), "ten minutes")
+	if _, err := PublicKeyFromKeyRef(ctx, "countingkms://key"); err == nil {
+		t.Error("PublicKeyFromKeyRef accepted an invalid cache TTL")
+	}
+}
+
+func TestKMSVerifierDiskCache(t *testing.T) {
+	setupKMSCache(t)
+	dir := filepath.Join(t.TempDir(), "kms")
+	t.Setenv(env.VariableKMSCacheDir.String(), dir)
+	ctx := context.Background()
+	msg := []byte("payload")
+
+	if _, err := PublicKeyFromKeyRef(ctx, "countingkms://key"); err != nil {
+		t.Fatal(err)
+	}
+	loads := testKMS.loaded()
+
+	// A new process reads the public key from the cache directory.
+	kmsCache.entries = map[string]kmsCacheEntry{}
+	v, err := PublicKeyFromKeyRef(ctx, "countingkms://key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifySignature(bytes.NewReader(signWithTestKMS(t, msg)), bytes.NewReader(msg)); err != nil {
+		t.Fatalf("VerifySignature() = %v", err)
+	}
+	if got := testKMS.loaded() - loads; got != 0 {
+		t.Errorf("loaded the key %d times, want 0", got)
+	}
+
+	// Signatures of a rotated key are verified with the KMS key.
+	testKMS.rotate(t)
+	if err := v.VerifySignature(bytes.NewReader(signWithTestKMS(t, msg)), bytes.NewReader(msg)); err != nil {
+		t.Fatalf("VerifySignature() after rotation = %v", err)
+	}
+	if err := v.VerifySignature(bytes.NewReader([]byte("bad")), bytes.NewReader(msg)); err == nil {
+		t.Error("VerifySignature() accepted an invalid signature")
+	}
+	if got := testKMS.loaded() - loads; got != 1 {
+		t.Errorf("loaded the key %d times, want 1", got)
+	}
+}
+
+func TestKMSVerifierDiskCacheExpiry(t *testing.T) {
+	setupKMSCache(t)
+	dir := t.TempDir()
+	t.Setenv(env.VariableKMSCacheDir.String(), dir)
+	t.Setenv(env.VariableKMSCacheTTL.String(), "1h")
+
+	if _, err := PublicKeyFromKeyRef(context.Background(), "countingkms://key"); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, kmsCacheKey("countingkms://key", crypto.SHA256)+".json")
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cf kmsCacheFile
+	if err := json.Unmarshal(b, &cf); err != nil {
+		t.Fatal(err)
+	}
+	if cf.KeyRef != "countingkms://key" {
+		t.Errorf("cached key ref = %q", cf.KeyRef)
+	}
+	cf.Fetched = cf.Fetched.Add(-2 * time.Hour)
+	if b, err = json.Marshal(cf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	kmsCache.entries = map[string]kmsCacheEntry{}
+	loads := testKMS.loaded()
+	if _, err := PublicKeyFromKeyRef(context.Background(), "countingkms://key"); err != nil {
+		t.Fatal(err)
+	}
+	if got := testKMS.loaded() - loads; got != 1 {
+		t.Errorf("loaded the key %d times after the cache expired, want 1", got)
+	}
+}
+
+// resolvedVerifier is a verifier of a key resolved from an alias.
+type resolvedVerifier struct {
+	sigsignature.Verifier
+}
+
+func (resolvedVerifier) ResolvedKeyID() string { return "key-1234" }
+
+func TestKMSVerifierDiskCacheResolvedKeyID(t *testing.T) {
+	setupKMSCache(t)
+	dir := t.TempDir()
+	t.Setenv(env.VariableKMSCacheDir.String(), dir)
+
+	v, err := sigsignature.LoadECDSAVerifier(&testKMS.priv.PublicKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ResolvedKeyID(v); got != "" {
+		t.Errorf("ResolvedKeyID() = %q, want none", got)
+	}
+	key := kmsCacheKey("countingkms://alias", crypto.SHA256)
+	if err := writeKMSCacheFile(dir, key, "countingkms://alias", resolvedVerifier{v}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := PublicKeyFromKeyRef(context.Background(), "countingkms://alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ResolvedKeyID(cached); got != "key-1234" {
+		t.Errorf("ResolvedKeyID() = %q, want key-1234", got)
+	}
+}
@@ -30,4 +30,14 @@ const (
 	SPDXJSONMediaType      = "text/spdx+json"
 	WasmLayerMediaType     = "application/vnd.wasm.content.layer.v1+wasm"
 	WasmConfigMediaType    = "application/vnd.wasm.config.v1+json"
+
+	// WasmModuleMediaType and WasmArtifactConfigMediaType are the media
+	// types of the CNCF TAG Runtime WebAssembly OCI artifact, which wasm
+	// runtimes and registries pull modules as.
+	WasmModuleMediaType         = "application/wasm"
+	WasmArtifactConfigMediaType = "application/vnd.wasm.config.v0+json"
 )
+
+// ZstdMediaTypeSuffix is appended to the media type of signature and
+// attestation layers that are stored zstd compressed.
+const ZstdMediaTypeSuffix = "+zstd"
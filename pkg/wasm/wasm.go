@@ -0,0 +1,170 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wasm stores WebAssembly modules as OCI artifacts in the layout of
+// the CNCF TAG Runtime, which wasm runtimes and registries pull.
+package wasm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/franchb/cosign/v2/pkg/types"
+)
+
+var (
+	// magic starts every WebAssembly binary.
+	magic = []byte("\x00asm")
+	// coreVersion is the version and layer field of core modules, while
+	// components have a different layer.
+	coreVersion = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// ErrNotWasm is returned for files that are not WebAssembly binaries and
+// artifacts that do not hold one.
+var ErrNotWasm = errors.New("not a WebAssembly module")
+
+// config is the config of a WebAssembly OCI artifact.
+type config struct {
+	Created      time.Time `json:"created"`
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	LayerDigests []string  `json:"layerDigests"`
+}
+
+// NewArtifact returns the OCI artifact holding the WebAssembly module, a
+// core module or a component, created at created.
+func NewArtifact(module []byte, created time.Time) (v1.Image, error) {
+	if !bytes.HasPrefix(module, magic) || len(module) < len(magic)+len(coreVersion) {
+		return nil, ErrNotWasm
+	}
+	layer := static.NewLayer(module, types.WasmModuleMediaType)
+	digest, err := layer.Digest()
+	if err != nil {
+		return nil, err
+	}
+	cfg := config{
+		Created:      created.UTC(),
+		Architecture: "wasm",
+		OS:           "wasip1",
+		LayerDigests: []string{digest.String()},
+	}
+	if !bytes.Equal(module[len(magic):len(magic)+len(coreVersion)], coreVersion) {
+		// Components target the component model of WASI 0.2.
+		cfg.OS = "wasip2"
+	}
+	rawConfig, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	configDigest, configSize, err := v1.SHA256(bytes.NewReader(rawConfig))
+	if err != nil {
+		return nil, err
+	}
+	m := v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     ggcrtypes.OCIManifestSchema1,
+		Config: v1.Descriptor{
+			MediaType: types.WasmArtifactConfigMediaType,
+			Digest:    configDigest,
+			Size:      configSize,
+		},
+		Layers: []v1.Descriptor{{
+			MediaType: types.WasmModuleMediaType,
+			Digest:    digest,
+			Size:      int64(len(module)),
+		}},
+	}
+	rawManifest, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return partial.CompressedToImage(&artifact{
+		rawConfig:   rawConfig,
+		rawManifest: rawManifest,
+		module:      layer,
+	})
+}
+
+// ModuleDigest returns the digest of the WebAssembly module in img, which
+// is an artifact written by NewArtifact or by `cosign upload wasm`.
+func ModuleDigest(img v1.Image) (v1.Hash, error) {
+	m, err := img.Manifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	switch m.Config.MediaType {
+	case types.WasmArtifactConfigMediaType, types.WasmConfigMediaType:
+	default:
+		return v1.Hash{}, fmt.Errorf("%w: the artifact config has media type %q", ErrNotWasm, m.Config.MediaType)
+	}
+	for _, l := range m.Layers {
+		switch l.MediaType {
+		case types.WasmModuleMediaType, types.WasmLayerMediaType:
+			return l.Digest, nil
+		}
+	}
+	return v1.Hash{}, fmt.Errorf("%w: the artifact has no module layer", ErrNotWasm)
+}
+
+// FileDigest returns the digest of the WebAssembly module read from r.
+func FileDigest(r io.Reader) (v1.Hash, error) {
+	var head bytes.Buffer
+	if _, err := io.CopyN(&head, r, int64(len(magic))); err != nil || !bytes.Equal(head.Bytes(), magic) {
+		return v1.Hash{}, ErrNotWasm
+	}
+	h, _, err := v1.SHA256(io.MultiReader(&head, r))
+	return h, err
+}
+
+// artifact is a WebAssembly OCI artifact.
+type artifact struct {
+	rawConfig   []byte
+	rawManifest []byte
+	module      v1.Layer
+}
+
+var _ partial.CompressedImageCore = (*artifact)(nil)
+
+// RawConfigFile implements partial.CompressedImageCore
+func (a *artifact) RawConfigFile() ([]byte, error) {
+	return a.rawConfig, nil
+}
+
+// MediaType implements partial.CompressedImageCore
+func (a *artifact) MediaType() (ggcrtypes.MediaType, error) {
+	return ggcrtypes.OCIManifestSchema1, nil
+}
+
+// RawManifest implements partial.CompressedImageCore
+func (a *artifact) RawManifest() ([]byte, error) {
+	return a.rawManifest, nil
+}
+
+// LayerByDigest implements partial.CompressedImageCore
+func (a *artifact) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	if d, err := a.module.Digest(); err == nil && d == h {
+		return a.module, nil
+	}
+	return nil, fmt.Errorf("blob %v not found", h)
+}
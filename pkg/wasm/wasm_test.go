@@ -0,0 +1,136 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+
+	"github.com/franchb/cosign/v2/pkg/oci/static"
+	"github.com/franchb/cosign/v2/pkg/types"
+)
+
+var (
+	// coreModule is an empty core module.
+	coreModule = []byte("\x00asm\x01\x00\x00\x00")
+	// component is an empty component.
+	component = []byte("\x00asm\x0d\x00\x01\x00")
+)
+
+func TestNewArtifact(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		module []byte
+		os     string
+	}{
+		{name: "core module", module: coreModule, os: "wasip1"},
+		{name: "component", module: component, os: "wasip2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := NewArtifact(tc.module, created)
+			require.NoError(t, err)
+
+			m, err := img.Manifest()
+			require.NoError(t, err)
+			require.Equal(t, types.WasmArtifactConfigMediaType, string(m.Config.MediaType))
+			require.Len(t, m.Layers, 1)
+			require.Equal(t, types.WasmModuleMediaType, string(m.Layers[0].MediaType))
+			want, _, err := v1.SHA256(bytes.NewReader(tc.module))
+			require.NoError(t, err)
+			require.Equal(t, want, m.Layers[0].Digest)
+
+			raw, err := img.RawConfigFile()
+			require.NoError(t, err)
+			var cfg config
+			require.NoError(t, json.Unmarshal(raw, &cfg))
+			require.Equal(t, config{
+				Created:      created,
+				Architecture: "wasm",
+				OS:           tc.os,
+				LayerDigests: []string{want.String()},
+			}, cfg)
+
+			got, err := ModuleDigest(img)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+			got, err = FileDigest(bytes.NewReader(tc.module))
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+
+	_, err := NewArtifact([]byte("#!/bin/sh\n"), created)
+	require.ErrorIs(t, err, ErrNotWasm)
+	_, err = FileDigest(strings.NewReader("#!/bin/sh\n"))
+	require.ErrorIs(t, err, ErrNotWasm)
+}
+
+func TestModuleDigest(t *testing.T) {
+	// Artifacts written by `cosign upload wasm` hold modules too.
+	legacy, err := static.NewFile(coreModule, static.WithLayerMediaType(types.WasmLayerMediaType), static.WithConfigMediaType(types.WasmConfigMediaType))
+	require.NoError(t, err)
+	got, err := ModuleDigest(legacy)
+	require.NoError(t, err)
+	want, _, err := v1.SHA256(bytes.NewReader(coreModule))
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	_, err = ModuleDigest(img)
+	require.ErrorIs(t, err, ErrNotWasm)
+}
+
+func TestArtifactPush(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	ref, err := name.ParseReference(strings.TrimPrefix(s.URL, "http://") + "/module:v1")
+	require.NoError(t, err)
+
+	img, err := NewArtifact(component, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	pulled, err := remote.Image(ref)
+	require.NoError(t, err)
+	want, err := img.Digest()
+	require.NoError(t, err)
+	got, err := pulled.Digest()
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	layers, err := pulled.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+	rc, err := layers[0].Compressed()
+	require.NoError(t, err)
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, component, b)
+}
@@ -25,8 +25,9 @@ import (
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/generate"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/options"
 	"github.com/franchb/cosign/v2/cmd/cosign/cli/sign"
+	"github.com/franchb/cosign/v2/pkg/cosign"
 	"github.com/franchb/cosign/v2/pkg/cosign/env"
-	_ "github.com/franchb/sigstore/pkg/signature/kms/hashivault"
+	_ "github.com/franchb/cosign/v2/pkg/signature/kms/hashivault"
 )
 
 const (
@@ -53,7 +54,7 @@ func TestSecretsKMS(t *testing.T) {
 
 	prefix := path.Join(td, "test-kms")
 
-	must(generate.GenerateKeyPairCmd(ctx, kms, prefix, nil), t)
+	must(generate.GenerateKeyPairCmd(ctx, kms, prefix, cosign.KeyPairOptions{}, nil, nil), t)
 
 	pubKey := prefix + ".pub"
 	privKey := kms